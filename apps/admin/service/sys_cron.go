@@ -1,15 +1,32 @@
 package service
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/idempotence"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/encryption"
+	"github.com/flare-admin/flare-server-go/framework/pkg/goroutine"
+	"github.com/flare-admin/flare-server-go/framework/support/base/infrastructure/persistence/entity"
+	em "github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	es "github.com/flare-admin/flare-server-go/framework/support/sysevent/service"
 	ts "github.com/flare-admin/flare-server-go/framework/support/systask/service"
+	"gorm.io/gorm"
 )
 
 type SysCronService struct {
 	tm ts.ITaskManager
+	db *gorm.DB
+	dl es.IDeadLetterServiceApi
+	it idempotence.IdempotencyTool
+	wh es.IWebhookServiceApi
+	rp es.IEventReplayServiceApi
 }
 
-func NewSysCronService(tm ts.ITaskManager) (*SysCronService, func(), error) {
+func NewSysCronService(tm ts.ITaskManager, db *gorm.DB, dl es.IDeadLetterServiceApi, it idempotence.IdempotencyTool,
+	wh es.IWebhookServiceApi, rp es.IEventReplayServiceApi) (*SysCronService, func(), error) {
 
 	// 启动任务管理器
 	err := tm.Initialize()
@@ -23,12 +40,23 @@ func NewSysCronService(tm ts.ITaskManager) (*SysCronService, func(), error) {
 	tm.Start()
 	return &SysCronService{
 		tm: tm,
+		db: db,
+		dl: dl,
+		it: it,
+		wh: wh,
+		rp: rp,
 	}, clumpfunc, nil
 }
 func (s *SysCronService) Start() {
 	// 启动cron调度器
 	// s.cron.Start()
 	s.register()
+	// 接管上次退出时未完成的回放，本实例重启前执行的回放在租约过期后才能接管
+	goroutine.SecureGo(context.Background(), func(ctx context.Context) {
+		_ = s.EventReplayRecover(nil)
+		time.Sleep(em.ReplayLeaseTimeout)
+		_ = s.EventReplayRecover(nil)
+	})
 }
 
 func (s *SysCronService) Test(data map[string]string) error {
@@ -36,6 +64,97 @@ func (s *SysCronService) Test(data map[string]string) error {
 	return nil
 }
 
+// PiiReEncrypt 敏感字段重新加密，密钥轮换或开启加密后执行，同时回填盲索引
+// 参数 batch_size 每批处理的行数
+func (s *SysCronService) PiiReEncrypt(data map[string]string) error {
+	batchSize, _ := strconv.Atoi(data["batch_size"])
+	for _, model := range []interface{}{&entity.SysUser{}, &entity.Department{}} {
+		n, err := encryption.ReEncrypt(context.Background(), s.db, model, batchSize)
+		if err != nil {
+			return err
+		}
+		hlog.Infof("pii re-encrypt %T: %d rows", model, n)
+	}
+	return nil
+}
+
+// PiiBlindIndexBackfill 回填敏感字段的盲索引，不修改加密字段
+// 配置盲索引密钥后执行，未开启加密时也可执行；回填完成前历史数据按明文查询
+// 参数 batch_size 每批处理的行数
+func (s *SysCronService) PiiBlindIndexBackfill(data map[string]string) error {
+	batchSize, _ := strconv.Atoi(data["batch_size"])
+	for _, model := range []interface{}{&entity.SysUser{}, &entity.Department{}} {
+		n, err := encryption.BackfillBlindIndex(context.Background(), s.db, model, batchSize)
+		if err != nil {
+			return err
+		}
+		hlog.Infof("pii blind index backfill %T: %d rows", model, n)
+	}
+	return nil
+}
+
+// DeadLetterRetry 按订阅的重试策略自动重试到期的死信
+// 参数 batch_size 每次处理的条数
+func (s *SysCronService) DeadLetterRetry(data map[string]string) error {
+	batchSize, _ := strconv.Atoi(data["batch_size"])
+	n, err := s.dl.RetryDue(context.Background(), batchSize)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		hlog.Infof("dead letter retry: %d", n)
+	}
+	return nil
+}
+
+// IdempotencyPrune 清理过期的消息幂等记录
+// 参数 retention_hours 保留时长（小时），默认 7 天
+func (s *SysCronService) IdempotencyPrune(data map[string]string) error {
+	retention := idempotence.DefaultRetention
+	if hours, _ := strconv.Atoi(data["retention_hours"]); hours > 0 {
+		retention = time.Duration(hours) * time.Hour
+	}
+	n, err := s.it.Prune(context.Background(), time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+	hlog.Infof("idempotency prune: %d records", n)
+	return nil
+}
+
+// WebhookRetry 按退避策略重新投递到期的 Webhook
+// 参数 batch_size 每次处理的条数
+func (s *SysCronService) WebhookRetry(data map[string]string) error {
+	batchSize, _ := strconv.Atoi(data["batch_size"])
+	n, err := s.wh.RetryDue(context.Background(), batchSize)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		hlog.Infof("webhook retry: %d", n)
+	}
+	return nil
+}
+
+// EventReplayRecover 接管租约过期的事件回放，从最后投递的记录继续
+func (s *SysCronService) EventReplayRecover(data map[string]string) error {
+	n, err := s.rp.Recover(context.Background())
+	if err != nil {
+		hlog.Errorf("event replay recover failed: %v", err)
+		return err
+	}
+	if n > 0 {
+		hlog.Infof("event replay recover: %d", n)
+	}
+	return nil
+}
+
 func (s *SysCronService) register() {
 	s.tm.RegisterHandler("test", s.Test)
+	s.tm.RegisterHandler("pii_reencrypt", s.PiiReEncrypt)
+	s.tm.RegisterHandler("pii_blind_index_backfill", s.PiiBlindIndexBackfill)
+	s.tm.RegisterHandler("dead_letter_retry", s.DeadLetterRetry)
+	s.tm.RegisterHandler("idempotency_prune", s.IdempotencyPrune)
+	s.tm.RegisterHandler("webhook_retry", s.WebhookRetry)
+	s.tm.RegisterHandler("event_replay_recover", s.EventReplayRecover)
 }
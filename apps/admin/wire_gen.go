@@ -16,8 +16,6 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/infrastructure/idempotence"
 	"github.com/flare-admin/flare-server-go/framework/infrastructure/mq"
 	database2 "github.com/flare-admin/flare-server-go/framework/pkg/database"
-	"github.com/flare-admin/flare-server-go/framework/pkg/database/snowflake_id"
-	"github.com/flare-admin/flare-server-go/framework/pkg/events"
 	"github.com/flare-admin/flare-server-go/framework/pkg/lua_engine"
 	manager2 "github.com/flare-admin/flare-server-go/framework/pkg/mqevent/manager"
 	"github.com/flare-admin/flare-server-go/framework/support"
@@ -52,6 +50,7 @@ import (
 	handler4 "github.com/flare-admin/flare-server-go/framework/support/rule_engine/application/command/handler"
 	handler3 "github.com/flare-admin/flare-server-go/framework/support/rule_engine/application/queries/handler"
 	service7 "github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/service"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/notify"
 	data3 "github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/data"
 	repository4 "github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/repository"
 	admin2 "github.com/flare-admin/flare-server-go/framework/support/rule_engine/interfaces/admin"
@@ -91,14 +90,20 @@ func wireApp(bootstrap *configs.Bootstrap, configsData *configs.Data) (*app, fun
 		return nil, nil, err
 	}
 	iToken := server.NewRdbToken(bootstrap, redisClient)
-	iIdGenerate := snowflake_id.NewSnowIdGen()
-	db, cleanup2, err := database2.NewDb(configsData)
+	iIdGenerate, cleanup2, err := database.NewIdGenerate(configsData, redisClient)
 	if err != nil {
 		cleanup()
 		return nil, nil, err
 	}
+	db, cleanup3, err := database.NewDb(configsData)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
 	databaseData, err := database2.NewData(iIdGenerate, db, configsData)
 	if err != nil {
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
@@ -112,7 +117,7 @@ func wireApp(bootstrap *configs.Bootstrap, configsData *configs.Data) (*app, fun
 	iSysRoleRepo := data.NewSysRoleRepo(iDataBase)
 	iPermissionsRepo := data.NewSysMenuRepo(iDataBase)
 	iRoleRepository := repository.NewRoleRepository(iSysRoleRepo, iPermissionsRepo)
-	iEventBus := events.NewEventBus()
+	iEventBus, cleanup4 := events2.NewSysEventBus()
 	roleCommandService := service2.NewRoleCommandService(iRoleRepository, iEventBus)
 	roleCommandHandler := handlers2.NewRoleCommandHandler(roleCommandService)
 	roleConverter := converter.NewRoleConverter()
@@ -128,6 +133,8 @@ func wireApp(bootstrap *configs.Bootstrap, configsData *configs.Data) (*app, fun
 	iPermissionsRepository := casbin.NewRepositoryImpl(iSysRoleRepo, iPermissionsRepo, iSysTenantRepo)
 	enforcer, err := server.NewCasBinEnforcer(redisClient, iPermissionsRepository)
 	if err != nil {
+		cleanup4()
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
@@ -226,16 +233,65 @@ func wireApp(bootstrap *configs.Bootstrap, configsData *configs.Data) (*app, fun
 	repositoryICategoryRepository := repository4.NewRuleCategoryRepository(iRuleCategoryRepository)
 	iRuleRepository := data3.NewRuleRepository(iDataBase)
 	repositoryIRuleRepository := repository4.NewRuleRepository(iRuleRepository)
+	ruleChangeNotifier := notify.NewRedisRuleChangeNotifier(redisClient)
+	ruleCache, cleanup5, err := rule_engine.NewRuleCache(bootstrap, repositoryIRuleRepository, repositoryICategoryRepository, ruleChangeNotifier)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
 	ruleTemplateService := service7.NewRuleTemplateService(repositoryITemplateRepository, repositoryICategoryRepository, repositoryIRuleRepository, iIdGenerate)
 	handlerTemplateCommandHandler := handler4.NewTemplateCommandHandler(ruleTemplateService)
 	templateService2 := admin2.NewTemplateService(handlerTemplateQueryHandler, handlerTemplateCommandHandler, enforcer)
 	handlerCategoryQueryHandler := handler3.NewCategoryQueryHandler(repositoryICategoryRepository)
-	ruleCategoryService := service7.NewRuleCategoryService(repositoryICategoryRepository, repositoryITemplateRepository, repositoryIRuleRepository, iIdGenerate)
+	ruleCategoryService := service7.NewRuleCategoryService(repositoryICategoryRepository, repositoryITemplateRepository, repositoryIRuleRepository, iIdGenerate, ruleCache)
 	handlerCategoryCommandHandler := handler4.NewCategoryCommandHandler(ruleCategoryService)
 	categoryService2 := admin2.NewCategoryService(handlerCategoryQueryHandler, handlerCategoryCommandHandler, enforcer)
-	ruleQueryHandler := handler3.NewRuleQueryHandler(repositoryIRuleRepository)
+	iRuleVersionRepository := data3.NewRuleVersionRepository(iDataBase)
+	repositoryIRuleVersionRepository := repository4.NewRuleVersionRepository(iRuleVersionRepository, iRuleRepository)
+	iRuleTestCaseRepository := data3.NewRuleTestCaseRepository(iDataBase)
+	repositoryIRuleTestCaseRepository := repository4.NewRuleTestCaseRepository(iRuleTestCaseRepository)
+	iRuleExecutionLogRepository := data3.NewRuleExecutionLogRepository(iDataBase)
+	repositoryIRuleExecutionLogRepository := repository4.NewRuleExecutionLogRepository(iRuleExecutionLogRepository)
+	ruleQueryHandler := handler3.NewRuleQueryHandler(repositoryIRuleRepository, repositoryIRuleVersionRepository, repositoryIRuleTestCaseRepository, repositoryIRuleExecutionLogRepository)
 	ruleExecutor := lua_engine.NewRuleExecutorWithDB(iDataBase)
-	ruleService := service7.NewRuleService(repositoryIRuleRepository, repositoryITemplateRepository, repositoryICategoryRepository, ruleExecutor, iIdGenerate)
+	ruleExecutionRecorder, cleanup6, err := rule_engine.NewRuleExecutionRecorder(bootstrap, repositoryIRuleExecutionLogRepository, repositoryIRuleRepository)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	mqServer, cleanup7, err := mq.NewMqServer(bootstrap)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	scheduler, cleanup8, err := mq.NewDelayScheduler(bootstrap, redisClient, mqServer)
+	if err != nil {
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	store := events2.NewEventStore(bootstrap, iDataBase)
+	imqEventBus := events2.NewNatsEventBus(mqServer, scheduler, store)
+	actionRegistry := rule_engine.NewRuleActionRegistry(imqEventBus)
+	ruleExecutionService := service7.NewRuleExecutionService(repositoryIRuleRepository, repositoryICategoryRepository, repositoryIRuleVersionRepository, iDataBase, ruleExecutor, ruleExecutionRecorder, ruleCache, actionRegistry)
+	ruleService := service7.NewRuleService(repositoryIRuleRepository, repositoryITemplateRepository, repositoryICategoryRepository, repositoryIRuleVersionRepository, repositoryIRuleTestCaseRepository, ruleExecutionService, ruleExecutor, iIdGenerate, ruleCache)
 	ruleCommandHandler := handler4.NewRuleCommandHandler(ruleService)
 	adminRuleService := admin2.NewRuleService(ruleQueryHandler, ruleCommandHandler, enforcer)
 	ruleEngineServer := rule_engine.NewServer(templateService2, categoryService2, adminRuleService)
@@ -249,26 +305,29 @@ func wireApp(bootstrap *configs.Bootstrap, configsData *configs.Data) (*app, fun
 	iSubscribeParameterRepo := data5.NewSubscribeParameterRepo(iDataBase)
 	iDeadLetterSubscribeRepo := data5.NewDeadLetterSubscribeRepo(iDataBase)
 	iSubscribeSmServerApi := base2.NewSubscribeManagerUseCase(iSubscribeRepo, iSubscribeParameterRepo, iDeadLetterSubscribeRepo, client)
-	mqServer, cleanup3, err := mq.NewMqServer(bootstrap)
-	if err != nil {
-		cleanup2()
-		cleanup()
-		return nil, nil, err
-	}
-	imqEventBus := events2.NewNatsEventBus(mqServer)
 	idempotencyTool := idempotence.NewIdempotencyTool(iDataBase, redisClient)
 	eventManager := manager2.NewEventBusManager(iSubscribeSmServerApi, imqEventBus, idempotencyTool)
-	iSubscribeServerApi := biz2.NewSubscribeUseCase(iSubscribeRepo, iDataBase, iSubscribeParameterRepo, eventManager, iDeadLetterSubscribeRepo, client)
+	iEventReplayRepo := data5.NewEventReplayRepo(iDataBase)
+	iEventReplayServiceApi := biz2.NewEventReplayUseCase(iEventReplayRepo, iSubscribeRepo, store, eventManager, iDataBase)
+	iSubscribeServerApi := biz2.NewSubscribeUseCase(iSubscribeRepo, iDataBase, iSubscribeParameterRepo, eventManager, iDeadLetterSubscribeRepo, client, iEventReplayServiceApi)
 	iDeadLetterServiceApi := biz2.NewDeadLetterSubscribeUseCase(iDeadLetterSubscribeRepo, iSubscribeRepo, eventManager, iDataBase)
-	eventService := sysevent_service.NewEventService(iEventServerApi, iSubscribeServerApi, iDeadLetterServiceApi, enforcer)
+	iWebhookRepo := data5.NewWebhookRepo(iDataBase)
+	iWebhookDeliveryRepo := data5.NewWebhookDeliveryRepo(iDataBase)
+	iWebhookServiceApi := biz2.NewWebhookUseCase(iWebhookRepo, iWebhookDeliveryRepo, imqEventBus, iDataBase)
+	eventService := sysevent_service.NewEventService(iEventServerApi, iSubscribeServerApi, iDeadLetterServiceApi, iEventReplayServiceApi, iWebhookServiceApi, enforcer)
 	iDictionaryRepo := data6.NewDictionaryRepo(iDataBase)
 	client2 := database.NewRedisClient(redisClient)
 	iTranslator := translator.NewTranslator(iDictionaryRepo, client2)
 	iDictionaryService := biz3.NewDictionaryUseCase(iDictionaryRepo, iTranslator, iIdGenerate)
 	dictionaryService := dictionaryinterfaces.NewDictionaryService(iDictionaryService, enforcer)
 	supportServer := support.NewServer(metricsController, baseServer, configHandler, restCacheHandler, tempServer, ruleEngineServer, taskService, eventService, dictionaryService)
-	sysCronService, cleanup4, err := service8.NewSysCronService(iTaskManager)
+	sysCronService, cleanup9, err := service8.NewSysCronService(iTaskManager, db, iDeadLetterServiceApi, idempotencyTool, iWebhookServiceApi, iEventReplayServiceApi)
 	if err != nil {
+		cleanup8()
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
 		cleanup3()
 		cleanup2()
 		cleanup()
@@ -277,6 +336,11 @@ func wireApp(bootstrap *configs.Bootstrap, configsData *configs.Data) (*app, fun
 	storageFactory := infrastructure.NewStorageFactory(bootstrap)
 	storageAdapter, err := infrastructure.NewStorageAdapter(storageFactory)
 	if err != nil {
+		cleanup9()
+		cleanup8()
+		cleanup7()
+		cleanup6()
+		cleanup5()
 		cleanup4()
 		cleanup3()
 		cleanup2()
@@ -291,6 +355,11 @@ func wireApp(bootstrap *configs.Bootstrap, configsData *configs.Data) (*app, fun
 	serve := server.NewServer(bootstrap, iToken, iDbOperationLogWrite, supportServer, sysCronService, storage_restService)
 	mainApp := newApp(serve, eventManager)
 	return mainApp, func() {
+		cleanup9()
+		cleanup8()
+		cleanup7()
+		cleanup6()
+		cleanup5()
 		cleanup4()
 		cleanup3()
 		cleanup2()
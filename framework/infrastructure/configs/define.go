@@ -10,6 +10,8 @@ type Bootstrap struct {
 	Storage    *StorageConfig `mapstructure:"storage"` // 添加存储配置
 	NSQConfig  *NSQConfig     `mapstructure:"nsq"`
 	NATSConfig *NATSConfig    `mapstructure:"nats"` // 添加 NATS 配置
+	MQ         *MQConfig      `mapstructure:"mq"`   // 消息队列类型选择
+	RuleEngine *RuleEngine    `mapstructure:"rule_engine"`
 }
 
 type Server struct {
@@ -38,8 +40,10 @@ type JWT struct {
 	ExpirationRefresh int64  `mapstructure:"expiration_refresh"`
 }
 type Data struct {
-	DataBase *DataBase `mapstructure:"database"`
-	Redis    *Redis    `mapstructure:"redis"`
+	DataBase   *DataBase   `mapstructure:"database"`
+	Redis      *Redis      `mapstructure:"redis"`
+	Encryption *Encryption `mapstructure:"encryption"` // 敏感字段加密
+	Snowflake  *Snowflake  `mapstructure:"snowflake"`  // 雪花ID
 }
 
 // DataBase 数据库
@@ -50,6 +54,33 @@ type DataBase struct {
 	MaxIdleConns  int32  `mapstructure:"max_idle_conns"`
 	MaxOpenConns  int32  `mapstructure:"max_open_conns"`
 	LogLevel      int64  `mapstructure:"log_level"`
+	SlowThreshold int64  `mapstructure:"slow_threshold"` // 慢查询阈值(毫秒)，默认 200，小于 0 时不记录
+}
+
+// Encryption 敏感字段加密配置
+type Encryption struct {
+	// CurrentKeyId 当前加密使用的密钥ID
+	CurrentKeyId string `mapstructure:"current_key_id"`
+	// Keys 密钥ID => base64 编码的 32 字节密钥，轮换时保留旧密钥用于解密
+	Keys map[string]string `mapstructure:"keys"`
+	// BlindIndexKey base64 编码的盲索引 HMAC 密钥，至少 32 字节，开启加密时必填，配置后不可更换
+	BlindIndexKey string `mapstructure:"blind_index_key"`
+}
+
+// Snowflake 雪花ID配置，机器ID通过 Redis 租用
+type Snowflake struct {
+	// Epoch 起始日期，格式 2006-01-02，默认 2020-01-01，上线后不可修改
+	Epoch string `mapstructure:"epoch"`
+	// NodeBits 机器ID位数，默认 10
+	NodeBits uint8 `mapstructure:"node_bits"`
+	// StepBits 序列号位数，默认 12
+	StepBits uint8 `mapstructure:"step_bits"`
+	// LeaseTtl 机器ID租期(秒)，默认 30
+	LeaseTtl int64 `mapstructure:"lease_ttl"`
+	// MaxBackward 允许等待的时钟回拨(毫秒)，默认 5
+	MaxBackward int64 `mapstructure:"max_backward"`
+	// KeyPrefix 租约 key 前缀，共用同一数据库的服务必须相同
+	KeyPrefix string `mapstructure:"key_prefix"`
 }
 
 // Redis 数据库
@@ -79,6 +110,60 @@ type NSQConfig struct {
 	Snappy              bool    `mapstructure:"snappy"`                // 是否启用 Snappy 压缩
 }
 
+// MQConfig 消息队列配置
+type MQConfig struct {
+	// Type 消息队列类型：nats(默认)、nsq、memory(进程内，仅单机部署)、redis(Redis Streams，复用 redis 连接配置)
+	Type string `mapstructure:"type"`
+	// MaxRetries 内存队列/Redis Streams 最大重试次数
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryDelay 内存队列重试基础延迟(毫秒)
+	RetryDelay int `mapstructure:"retry_delay"`
+	// KeyPrefix Redis Streams 键前缀
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// MaxLen Redis Streams 单个 stream 近似最大长度
+	MaxLen int64 `mapstructure:"max_len"`
+	// ClaimIdle Redis Streams 未确认消息重新认领的空闲时间(毫秒)
+	ClaimIdle int `mapstructure:"claim_idle"`
+	// DelayPollInterval 持久化延迟消息扫描间隔(毫秒)，延迟消息保存在 redis 中，所有 MQ 类型通用
+	DelayPollInterval int `mapstructure:"delay_poll_interval"`
+	// EventStore 是否将发布的事件写入事件存储(按月分表)，开启后可以回放历史事件
+	EventStore bool `mapstructure:"event_store"`
+}
+
+// RuleEngine 规则引擎配置
+type RuleEngine struct {
+	// ExecutionLog 执行日志配置
+	ExecutionLog *RuleExecutionLog `mapstructure:"execution_log"`
+	// Cache 规则缓存配置
+	Cache *RuleCache `mapstructure:"cache"`
+}
+
+// RuleCache 规则缓存配置，启用的规则缓存在内存中，修改后通过 Redis 通知所有实例
+type RuleCache struct {
+	// Disabled 关闭缓存，每次执行时查询数据库
+	Disabled bool `mapstructure:"disabled"`
+	// RefreshInterval 定时全量刷新间隔(秒)，默认 300，小于 0 时不定时刷新
+	RefreshInterval int `mapstructure:"refresh_interval"`
+}
+
+// RuleExecutionLog 规则执行日志配置，日志异步批量写入数据库
+type RuleExecutionLog struct {
+	// Disabled 关闭执行日志，规则执行次数和监控指标不受影响
+	Disabled bool `mapstructure:"disabled"`
+	// SampleRate 成功执行的日志采样率(0-1]，默认 1，小于 0 时只记录失败的执行；失败的执行总是记录
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// RecordData 是否记录每个规则的输入输出数据
+	RecordData bool `mapstructure:"record_data"`
+	// BatchSize 每批写入数量，默认 100
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval 写入间隔(毫秒)，默认 1000
+	FlushInterval int `mapstructure:"flush_interval"`
+	// QueueSize 写入队列长度，队列已满时丢弃日志，默认 10000
+	QueueSize int `mapstructure:"queue_size"`
+	// RetentionDays 保留天数，默认 30，小于 0 时不清理
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
 // NATSConfig NATS 配置
 type NATSConfig struct {
 	// Address 地址
@@ -97,4 +182,10 @@ type NATSConfig struct {
 	AckWait int `mapstructure:"ack_wait"`
 	// MaxDeliver 最大投递次数
 	MaxDeliver int `mapstructure:"max_deliver"`
+	// JetStream 是否启用 JetStream 持久化模式(持久化消费者、显式确认、失败退避重试)
+	JetStream bool `mapstructure:"jetstream"`
+	// StreamMaxAge JetStream 流消息保留时间(秒)
+	StreamMaxAge int `mapstructure:"stream_max_age"`
+	// RetryBackoff JetStream 失败重试基础退避时间(毫秒)
+	RetryBackoff int `mapstructure:"retry_backoff"`
 }
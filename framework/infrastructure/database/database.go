@@ -1,23 +1,26 @@
 package database
 
 import (
+	"context"
 	"github.com/dtm-labs/rockscache"
 	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
 	"github.com/flare-admin/flare-server-go/framework/infrastructure/database/cache"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/snowflake_id"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hredis"
+	"github.com/flare-admin/flare-server-go/framework/support/base/metrics"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 	"time"
 )
 
 var ProviderSet = wire.NewSet(
-	snowflake_id.NewSnowIdGen,
+	NewIdGenerate,
 	NewHdbClient,
 	NewDataBase,
 	NewRc,
-	database.NewDb,
+	NewDb,
 	database.NewData,
 	cache.NewCache,
 	NewRedisClient,
@@ -25,6 +28,32 @@ var ProviderSet = wire.NewSet(
 	NewTransactional,
 )
 
+// NewDb 创建数据库连接，并注册查询指标、慢查询日志和连接池指标
+func NewDb(conf *configs.Data) (*gorm.DB, func(), error) {
+	db, cleanup, err := database.NewDb(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = db.Use(metrics.NewGormPlugin(time.Duration(conf.DataBase.SlowThreshold) * time.Millisecond)); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	driver := conf.DataBase.Driver
+	if driver == "" {
+		driver = "mysql"
+	}
+	if err = metrics.RegisterDBStats(driver, sqlDB); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return db, cleanup, nil
+}
+
 func NewDataBase(data *database.Data) database.IDataBase {
 	return data
 }
@@ -42,6 +71,30 @@ func NewHdbClient(conf *configs.Data) (*hredis.RedisClient, func(), error) {
 	})
 }
 
+// NewIdGenerate 集群安全的雪花ID生成器，机器ID从 Redis 租用
+func NewIdGenerate(conf *configs.Data, hc *hredis.RedisClient) (snowflake_id.IIdGenerate, func(), error) {
+	layout, err := snowflake_id.LayoutFromConfig(conf.Snowflake)
+	if err != nil {
+		return nil, nil, err
+	}
+	ttl := 30 * time.Second
+	prefix := snowflake_id.DefaultWorkerKeyPrefix
+	if conf.Snowflake != nil {
+		if conf.Snowflake.LeaseTtl > 0 {
+			ttl = time.Duration(conf.Snowflake.LeaseTtl) * time.Second
+		}
+		if conf.Snowflake.KeyPrefix != "" {
+			prefix = conf.Snowflake.KeyPrefix
+		}
+	}
+	store := snowflake_id.NewRedisWorkerIdStore(hc.GetClient(), prefix)
+	gen, err := snowflake_id.NewLeasedIdGen(context.Background(), store, layout, ttl)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gen, gen.Close, nil
+}
+
 func NewRedisClient(hc *hredis.RedisClient) *redis.Client {
 	return hc.GetClient()
 }
@@ -1,21 +1,58 @@
 package events
 
 import (
+	"context"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
 	sysevents "github.com/flare-admin/flare-server-go/framework/pkg/events"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/delay"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/eventstore"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/manager"
 	"github.com/google/wire"
+	"time"
 )
 
 var ProviderSet = wire.NewSet(
 	manager.NewEventBusManager,
+	NewEventStore,
 	NewNatsEventBus,
 	//系统事件
-	sysevents.NewEventBus,
+	NewSysEventBus,
 )
 
-// NewNatsEventBus 创建 NATS 事件总线
-func NewNatsEventBus(sr mq.Server) mqevent.IMQEventBus {
-	return mqevent.NewMQEventBus(sr)
+// NewSysEventBus 创建进程内事件总线，退出时等待异步事件处理完成
+func NewSysEventBus() (sysevents.IEventBus, func()) {
+	bus := sysevents.NewAsyncEventBus()
+	return bus, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := bus.Close(ctx); err != nil {
+			hlog.Errorf("close sys event bus: %v", err)
+		}
+	}
+}
+
+// NewEventStore 创建事件存储并提前创建当月和下月的分表，未开启时返回 nil
+// 建表失败只记录日志，第一次写入时重试
+func NewEventStore(cof *configs.Bootstrap, db database.IDataBase) *eventstore.Store {
+	if cof.MQ == nil || !cof.MQ.EventStore {
+		return nil
+	}
+	store := eventstore.NewStore(db, "")
+	if err := store.Prepare(); err != nil {
+		hlog.Errorf("prepare event store partitions: %v", err)
+	}
+	return store
+}
+
+// NewNatsEventBus 创建 NATS 事件总线，延迟事件由持久化调度器发布，开启事件存储时发布的事件写入存储
+func NewNatsEventBus(sr mq.Server, scheduler *delay.Scheduler, store *eventstore.Store) mqevent.IMQEventBus {
+	opts := []mqevent.BusOption{mqevent.WithDelayScheduler(scheduler)}
+	if store != nil {
+		opts = append(opts, mqevent.WithEventStore(store))
+	}
+	return mqevent.NewMQEventBus(sr, opts...)
 }
@@ -4,13 +4,26 @@ import (
 	"time"
 )
 
+// 消息处理状态
+const (
+	StatusProcessing = "processing" // 处理中，租约到期前其他消费者不能处理
+	StatusDone       = "success"    // 处理完成
+	StatusFailed     = "failed"     // 处理失败，可以立即重新认领
+)
+
 type IdempotencyRecord struct {
-	ID        uint      `gorm:"primaryKey"`                                                     // 自增主键
-	Topic     string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_topic_group_message"` // 主题
-	Channel   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_topic_group_message"` // 消费者组
-	MessageID string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_topic_group_message"` // 消息ID
-	Status    string    `gorm:"type:varchar(50)"`                                               // 消息处理状态
-	CreatedAt time.Time `gorm:"type:timestamp;default:now();not null"`                          // 消息处理时间
+	ID         uint      `gorm:"primaryKey"`                                                     // 自增主键
+	Topic      string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_topic_group_message"` // 主题
+	Channel    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_topic_group_message"` // 消费者组
+	MessageID  string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_topic_group_message"` // 消息ID
+	Status     string    `gorm:"type:varchar(50)"`                                               // 消息处理状态
+	Owner      string    `gorm:"type:varchar(64);not null;default:''"`                           // 持有租约的认领标识
+	LeaseUntil int64     `gorm:"not null;default:0"`                                             // 租约到期时间（毫秒）
+	Attempts   int       `gorm:"not null;default:0"`                                             // 认领次数
+	Result     string    `gorm:"type:text"`                                                      // 处理结果，重复消息时回放
+	Error      string    `gorm:"type:text"`                                                      // 最后一次失败原因
+	CreatedAt  time.Time `gorm:"type:timestamp;not null;index"`                                  // 消息处理时间
+	UpdatedAt  int64     `gorm:"not null;default:0"`                                             // 更新时间（毫秒）
 }
 
 // TableName  为了确保 (Topic, ConsumerGroup, MessageID) 的唯一性，可以定义如下索引
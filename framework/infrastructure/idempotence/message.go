@@ -2,23 +2,67 @@ package idempotence
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hredis"
+	"github.com/flare-admin/flare-server-go/framework/pkg/random"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
+const (
+	// DefaultLease 默认租约时长，处理超过该时间未完成的消息可被其他消费者重新认领
+	DefaultLease = 5 * time.Minute
+	// 已完成记录在 Redis 中的缓存时间
+	doneCacheTTL = 24 * time.Hour
+)
+
+// ErrLeaseLost 租约已过期并被其他消费者认领
+var ErrLeaseLost = errors.New("idempotence: lease lost")
+
+// ClaimState 认领结果
+type ClaimState int
+
+const (
+	ClaimAcquired ClaimState = iota + 1 // 认领成功，需要处理消息
+	ClaimBusy                           // 其他消费者处理中且租约未过期
+	ClaimDone                           // 消息已处理，Result 为处理结果
+)
+
+// Claim 消息认领
+type Claim struct {
+	Topic     string
+	Channel   string
+	MessageID string
+	State     ClaimState
+	Result    []byte // 已处理消息的处理结果
+	token     string
+}
+
 type IdempotencyTool interface {
+	// Claim 原子认领消息：未处理或租约过期、处理失败的消息认领成功，处理中返回 ClaimBusy，已处理返回 ClaimDone
+	Claim(ctx context.Context, topic, channel, messageID string, lease time.Duration) (*Claim, error)
+	// Complete 标记认领的消息处理完成并保存处理结果，租约已被他人认领时返回 ErrLeaseLost
+	Complete(ctx context.Context, claim *Claim, result []byte) error
+	// Fail 标记认领的消息处理失败，失败的消息可以立即重新认领
+	Fail(ctx context.Context, claim *Claim, cause error) error
+	// Prune 清理 before 之前创建且不在处理中的记录，返回清理条数
+	Prune(ctx context.Context, before time.Time) (int64, error)
+
 	// Check 检查消息是否已经处理过
+	// Deprecated: 与 MarkProcessed 分开调用不是原子的，使用 Claim
 	Check(ctx context.Context, topic, channel, messageID string) (bool, error)
-
 	// MarkProcessed 标记消息已处理
+	// Deprecated: 使用 Claim 和 Complete
 	MarkProcessed(ctx context.Context, channel, group, messageID string) error
 }
 
+// MessageIdempotence 基于数据库唯一索引的幂等工具，已完成的消息缓存到 Redis
 type MessageIdempotence struct {
 	data database.IDataBase
 	rdb  *redis.Client
@@ -38,6 +82,136 @@ func NewIdempotencyTool(data database.IDataBase, rdb *hredis.RedisClient) Idempo
 	}
 }
 
+// Claim 插入处理中记录，唯一索引冲突时尝试接管失败或租约过期的记录
+func (r *MessageIdempotence) Claim(ctx context.Context, topic, channel, messageID string, lease time.Duration) (*Claim, error) {
+	claim := &Claim{Topic: topic, Channel: channel, MessageID: messageID}
+	redisKey := r.generateRedisKey(topic, channel, messageID)
+
+	// 1. 已完成的消息直接从 Redis 返回
+	result, err := r.rdb.Get(ctx, redisKey).Result()
+	if err == nil {
+		claim.State = ClaimDone
+		claim.Result = []byte(result)
+		return claim, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, err
+	}
+	claim.token = token
+	now := utils.GetTimeNow()
+	leaseUntil := now.Add(lease).UnixMilli()
+
+	// 2. 插入处理中记录
+	record := IdempotencyRecord{
+		Topic:      topic,
+		Channel:    channel,
+		MessageID:  messageID,
+		Status:     StatusProcessing,
+		Owner:      token,
+		LeaseUntil: leaseUntil,
+		Attempts:   1,
+		CreatedAt:  now,
+		UpdatedAt:  now.UnixMilli(),
+	}
+	res := r.data.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		claim.State = ClaimAcquired
+		return claim, nil
+	}
+
+	// 3. 接管失败或租约过期的记录
+	res = r.data.DB(ctx).Model(&IdempotencyRecord{}).
+		Where("topic = ? AND channel = ? AND message_id = ?", topic, channel, messageID).
+		Where("status = ? OR (status = ? AND lease_until < ?)", StatusFailed, StatusProcessing, now.UnixMilli()).
+		Updates(map[string]interface{}{
+			"status":      StatusProcessing,
+			"owner":       token,
+			"lease_until": leaseUntil,
+			"attempts":    gorm.Expr("attempts + 1"),
+			"updated_at":  now.UnixMilli(),
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		claim.State = ClaimAcquired
+		return claim, nil
+	}
+
+	// 4. 其他消费者处理中或已完成
+	if err = r.data.DB(ctx).Where("topic = ? AND channel = ? AND message_id = ?", topic, channel, messageID).First(&record).Error; err != nil {
+		return nil, err
+	}
+	if record.Status == StatusDone {
+		claim.State = ClaimDone
+		claim.Result = []byte(record.Result)
+		r.rdb.Set(ctx, redisKey, record.Result, doneCacheTTL)
+		return claim, nil
+	}
+	claim.State = ClaimBusy
+	return claim, nil
+}
+
+// Complete 标记处理完成
+func (r *MessageIdempotence) Complete(ctx context.Context, claim *Claim, result []byte) error {
+	if err := r.finish(ctx, claim, map[string]interface{}{
+		"status": StatusDone,
+		"result": string(result),
+	}); err != nil {
+		return err
+	}
+	r.rdb.Set(ctx, r.generateRedisKey(claim.Topic, claim.Channel, claim.MessageID), result, doneCacheTTL)
+	return nil
+}
+
+// Fail 标记处理失败
+func (r *MessageIdempotence) Fail(ctx context.Context, claim *Claim, cause error) error {
+	msg := ""
+	if cause != nil {
+		msg = cause.Error()
+	}
+	return r.finish(ctx, claim, map[string]interface{}{
+		"status": StatusFailed,
+		"error":  msg,
+	})
+}
+
+// finish 只有仍持有租约的认领才能更新记录
+func (r *MessageIdempotence) finish(ctx context.Context, claim *Claim, values map[string]interface{}) error {
+	if claim == nil || claim.State != ClaimAcquired {
+		return fmt.Errorf("idempotence: claim of %s is not acquired", claimID(claim))
+	}
+	values["lease_until"] = 0
+	values["updated_at"] = utils.GetTimeNow().UnixMilli()
+	res := r.data.DB(ctx).Model(&IdempotencyRecord{}).
+		Where("topic = ? AND channel = ? AND message_id = ? AND owner = ? AND status = ?",
+			claim.Topic, claim.Channel, claim.MessageID, claim.token, StatusProcessing).
+		Updates(values)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Prune 清理过期记录，处理中且租约未过期的记录保留
+func (r *MessageIdempotence) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res := r.data.DB(ctx).
+		Where("created_at < ? AND (status <> ? OR lease_until < ?)", before, StatusProcessing, utils.GetTimeNow().UnixMilli()).
+		Delete(&IdempotencyRecord{})
+	return res.RowsAffected, res.Error
+}
+
 // Check 幂等性检查
 func (r *MessageIdempotence) Check(ctx context.Context, topic, channel, messageID string) (bool, error) {
 	redisKey := r.generateRedisKey(topic, channel, messageID)
@@ -50,12 +224,12 @@ func (r *MessageIdempotence) Check(ctx context.Context, topic, channel, messageI
 	if exists > 0 {
 		return true, nil // 消息已处理
 	}
-	// 2. 检查 PostgreSQL
+	// 2. 检查数据库
 	var record IdempotencyRecord
-	result := r.data.DB(ctx).Where("topic = ? AND channel = ? AND message_id = ?", topic, channel, messageID).First(&record)
+	result := r.data.DB(ctx).Where("topic = ? AND channel = ? AND message_id = ? AND status = ?", topic, channel, messageID, StatusDone).First(&record)
 	if result.RowsAffected > 0 {
 		// 将记录缓存到 Redis
-		r.rdb.Set(ctx, redisKey, 1, 24*time.Hour)
+		r.rdb.Set(ctx, redisKey, record.Result, doneCacheTTL)
 		return true, nil
 	}
 
@@ -66,20 +240,22 @@ func (r *MessageIdempotence) Check(ctx context.Context, topic, channel, messageI
 func (r *MessageIdempotence) MarkProcessed(ctx context.Context, topic, channel, messageID string) error {
 	redisKey := r.generateRedisKey(topic, channel, messageID)
 
-	// 1. 将记录存入 PostgreSQL
+	// 1. 将记录存入数据库
+	now := utils.GetTimeNow()
 	record := IdempotencyRecord{
 		Topic:     topic,
 		Channel:   channel,
 		MessageID: messageID,
-		Status:    "success",
-		CreatedAt: utils.GetTimeNow(),
+		Status:    StatusDone,
+		CreatedAt: now,
+		UpdatedAt: now.UnixMilli(),
 	}
 	if err := r.data.DB(ctx).Create(&record).Error; err != nil {
 		return err
 	}
 
 	// 2. 缓存到 Redis
-	r.rdb.Set(ctx, redisKey, 1, 24*time.Hour)
+	r.rdb.Set(ctx, redisKey, "", doneCacheTTL)
 
 	return nil
 }
@@ -88,3 +264,14 @@ func (r *MessageIdempotence) MarkProcessed(ctx context.Context, topic, channel,
 func (r *MessageIdempotence) generateRedisKey(topic, group, messageID string) string {
 	return fmt.Sprintf("message:topic:%s:group:%s:msgid:%s", topic, group, messageID)
 }
+
+func newClaimToken() (string, error) {
+	return random.GenerateRandomAlphaNumericString(20)
+}
+
+func claimID(claim *Claim) string {
+	if claim == nil {
+		return "<nil>"
+	}
+	return claim.Topic + "/" + claim.Channel + "/" + claim.MessageID
+}
@@ -0,0 +1,143 @@
+package idempotence
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newMessageTool(t *testing.T) (*MessageIdempotence, *miniredis.Miniredis) {
+	dsn := filepath.Join(t.TempDir(), "idempotence.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err = db.AutoMigrate(&IdempotencyRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := database.NewData(nil, db, &configs.Data{})
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return &MessageIdempotence{data: data, rdb: rdb}, mr
+}
+
+func TestMessageIdempotence_ConcurrentClaim(t *testing.T) {
+	tool, mr := newMessageTool(t)
+	ctx := context.Background()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		states = map[ClaimState]int{}
+		winner *Claim
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claim, err := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			states[claim.State]++
+			if claim.State == ClaimAcquired {
+				winner = claim
+			}
+		}()
+	}
+	wg.Wait()
+	if states[ClaimAcquired] != 1 || states[ClaimBusy] != 7 {
+		t.Fatalf("claim states = %v", states)
+	}
+
+	if err := tool.Complete(ctx, winner, []byte(`{"ok":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	// Redis 缓存丢失时从数据库回放结果
+	mr.FlushAll()
+	replay, err := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+	if err != nil || replay.State != ClaimDone || string(replay.Result) != `{"ok":true}` {
+		t.Fatalf("replay = %+v, %v", replay, err)
+	}
+	cached, _ := mr.Get(tool.generateRedisKey("order", "notify", "m1"))
+	if cached != `{"ok":true}` {
+		t.Fatalf("cached = %q", cached)
+	}
+}
+
+func TestMessageIdempotence_ReclaimExpired(t *testing.T) {
+	tool, _ := newMessageTool(t)
+	ctx := context.Background()
+
+	stale, err := tool.Claim(ctx, "order", "notify", "m1", 10*time.Millisecond)
+	if err != nil || stale.State != ClaimAcquired {
+		t.Fatalf("claim = %+v, %v", stale, err)
+	}
+	if busy, _ := tool.Claim(ctx, "order", "notify", "m1", time.Minute); busy.State != ClaimBusy {
+		t.Fatalf("claim before expire = %+v", busy)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	next, err := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+	if err != nil || next.State != ClaimAcquired {
+		t.Fatalf("claim after expire = %+v, %v", next, err)
+	}
+	// 原消费者的租约已被接管，不能再更新记录
+	if err = tool.Complete(ctx, stale, []byte("stale")); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("complete with expired lease: %v", err)
+	}
+	if err = tool.Complete(ctx, next, []byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	var record IdempotencyRecord
+	tool.data.DB(ctx).First(&record)
+	if record.Status != StatusDone || record.Result != "next" || record.Attempts != 2 {
+		t.Fatalf("record = %+v", record)
+	}
+}
+
+func TestMessageIdempotence_FailThenRetry(t *testing.T) {
+	tool, _ := newMessageTool(t)
+	ctx := context.Background()
+
+	first, _ := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+	if err := tool.Fail(ctx, first, errors.New("downstream unavailable")); err != nil {
+		t.Fatal(err)
+	}
+	var record IdempotencyRecord
+	tool.data.DB(ctx).First(&record)
+	if record.Status != StatusFailed || record.Error != "downstream unavailable" {
+		t.Fatalf("failed record = %+v", record)
+	}
+
+	// 失败的消息不等租约到期即可重新认领
+	retry, err := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+	if err != nil || retry.State != ClaimAcquired {
+		t.Fatalf("retry = %+v, %v", retry, err)
+	}
+	if err = tool.Complete(ctx, retry, []byte("done")); err != nil {
+		t.Fatal(err)
+	}
+	if done, _ := tool.Claim(ctx, "order", "notify", "m1", time.Minute); done.State != ClaimDone || string(done.Result) != "done" {
+		t.Fatalf("after retry = %+v", done)
+	}
+}
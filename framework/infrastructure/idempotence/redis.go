@@ -0,0 +1,133 @@
+package idempotence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// DefaultRetention 记录的默认保留时间
+const DefaultRetention = 7 * 24 * time.Hour
+
+// 认领：已完成返回 done 和结果，处理中且租约未过期返回 busy，否则写入处理中状态
+var claimScript = redis.NewScript(`
+local status = redis.call('HGET', KEYS[1], 'status')
+local now = tonumber(ARGV[3])
+if status == 'success' then
+	return {'done', redis.call('HGET', KEYS[1], 'result') or ''}
+end
+if status == 'processing' and tonumber(redis.call('HGET', KEYS[1], 'lease_until') or '0') >= now then
+	return {'busy', ''}
+end
+redis.call('HSET', KEYS[1], 'status', 'processing', 'owner', ARGV[1], 'lease_until', now + tonumber(ARGV[2]), 'updated_at', now)
+redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {'acquired', ''}
+`)
+
+// 完成或失败：只有仍持有租约的认领才能更新
+var finishScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] or redis.call('HGET', KEYS[1], 'status') ~= 'processing' then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'status', ARGV[2], ARGV[3], ARGV[4], 'lease_until', 0, 'updated_at', ARGV[5])
+redis.call('PEXPIRE', KEYS[1], ARGV[6])
+return 1
+`)
+
+// RedisIdempotence 基于 Redis 的幂等工具，记录按保留时间自动过期
+type RedisIdempotence struct {
+	rdb       *redis.Client
+	prefix    string
+	retention time.Duration
+}
+
+// NewRedisIdempotencyTool 创建基于 Redis 的幂等工具，retention 为 0 时使用 DefaultRetention
+func NewRedisIdempotencyTool(rdb *redis.Client, prefix string, retention time.Duration) IdempotencyTool {
+	if prefix == "" {
+		prefix = "idempotence"
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &RedisIdempotence{rdb: rdb, prefix: prefix, retention: retention}
+}
+
+func (r *RedisIdempotence) Claim(ctx context.Context, topic, channel, messageID string, lease time.Duration) (*Claim, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, err
+	}
+	res, err := claimScript.Run(ctx, r.rdb, []string{r.key(topic, channel, messageID)},
+		token, lease.Milliseconds(), utils.GetTimeNow().UnixMilli(), r.retention.Milliseconds()).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	claim := &Claim{Topic: topic, Channel: channel, MessageID: messageID}
+	switch res[0] {
+	case "done":
+		claim.State = ClaimDone
+		claim.Result = []byte(res[1])
+	case "busy":
+		claim.State = ClaimBusy
+	default:
+		claim.State = ClaimAcquired
+		claim.token = token
+	}
+	return claim, nil
+}
+
+func (r *RedisIdempotence) Complete(ctx context.Context, claim *Claim, result []byte) error {
+	return r.finish(ctx, claim, StatusDone, "result", string(result))
+}
+
+func (r *RedisIdempotence) Fail(ctx context.Context, claim *Claim, cause error) error {
+	msg := ""
+	if cause != nil {
+		msg = cause.Error()
+	}
+	return r.finish(ctx, claim, StatusFailed, "error", msg)
+}
+
+func (r *RedisIdempotence) finish(ctx context.Context, claim *Claim, status, field, value string) error {
+	if claim == nil || claim.State != ClaimAcquired {
+		return fmt.Errorf("idempotence: claim of %s is not acquired", claimID(claim))
+	}
+	ok, err := finishScript.Run(ctx, r.rdb, []string{r.key(claim.Topic, claim.Channel, claim.MessageID)},
+		claim.token, status, field, value, utils.GetTimeNow().UnixMilli(), r.retention.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Prune 记录由 Redis 按保留时间过期，无需清理
+func (r *RedisIdempotence) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *RedisIdempotence) Check(ctx context.Context, topic, channel, messageID string) (bool, error) {
+	status, err := r.rdb.HGet(ctx, r.key(topic, channel, messageID), "status").Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return status == StatusDone, err
+}
+
+func (r *RedisIdempotence) MarkProcessed(ctx context.Context, topic, channel, messageID string) error {
+	key := r.key(topic, channel, messageID)
+	if err := r.rdb.HSet(ctx, key, "status", StatusDone, "updated_at", utils.GetTimeNow().UnixMilli()).Err(); err != nil {
+		return err
+	}
+	return r.rdb.PExpire(ctx, key, r.retention).Err()
+}
+
+func (r *RedisIdempotence) key(topic, channel, messageID string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", r.prefix, topic, channel, messageID)
+}
@@ -0,0 +1,79 @@
+package idempotence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/idempotence"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisTool(t *testing.T) (idempotence.IdempotencyTool, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return idempotence.NewRedisIdempotencyTool(rdb, "", time.Hour), mr
+}
+
+func TestRedisIdempotence_ClaimCompleteReplay(t *testing.T) {
+	tool, mr := newRedisTool(t)
+	ctx := context.Background()
+
+	first, err := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+	if err != nil || first.State != idempotence.ClaimAcquired {
+		t.Fatalf("first claim = %+v, %v", first, err)
+	}
+	second, err := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+	if err != nil || second.State != idempotence.ClaimBusy {
+		t.Fatalf("concurrent claim = %+v, %v", second, err)
+	}
+	if err = tool.Complete(ctx, second, nil); err == nil {
+		t.Fatal("complete without lease must fail")
+	}
+	if err = tool.Complete(ctx, first, []byte(`{"ok":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := tool.Claim(ctx, "order", "notify", "m1", time.Minute)
+	if err != nil || replay.State != idempotence.ClaimDone || string(replay.Result) != `{"ok":true}` {
+		t.Fatalf("replay = %+v, %v", replay, err)
+	}
+	if done, _ := tool.Check(ctx, "order", "notify", "m1"); !done {
+		t.Fatal("check should report processed")
+	}
+	// 其他通道独立
+	if other, _ := tool.Claim(ctx, "order", "audit", "m1", time.Minute); other.State != idempotence.ClaimAcquired {
+		t.Fatalf("other channel = %+v", other)
+	}
+	// 记录按保留时间过期
+	mr.FastForward(2 * time.Hour)
+	if again, _ := tool.Claim(ctx, "order", "notify", "m1", time.Minute); again.State != idempotence.ClaimAcquired {
+		t.Fatalf("after retention = %+v", again)
+	}
+}
+
+func TestRedisIdempotence_ReclaimExpiredAndFailed(t *testing.T) {
+	tool, _ := newRedisTool(t)
+	ctx := context.Background()
+
+	stale, _ := tool.Claim(ctx, "pay", "c", "m1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	taken, err := tool.Claim(ctx, "pay", "c", "m1", time.Minute)
+	if err != nil || taken.State != idempotence.ClaimAcquired {
+		t.Fatalf("reclaim expired lease = %+v, %v", taken, err)
+	}
+	// 原持有者租约已丢失
+	if err = tool.Complete(ctx, stale, nil); !errors.Is(err, idempotence.ErrLeaseLost) {
+		t.Fatalf("stale complete err = %v", err)
+	}
+
+	if err = tool.Fail(ctx, taken, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	retry, err := tool.Claim(ctx, "pay", "c", "m1", time.Minute)
+	if err != nil || retry.State != idempotence.ClaimAcquired {
+		t.Fatalf("claim after failure = %+v, %v", retry, err)
+	}
+}
@@ -3,38 +3,73 @@ package mq
 import (
 	"fmt"
 	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hredis"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/delay"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/memory"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq/nats"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/nsq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/redis"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq/server"
 	"github.com/google/wire"
+	"time"
 )
 
 var ProviderSet = wire.NewSet(
 	NewMqServer,
+	NewDelayScheduler,
 )
 
 func NewMqServer(cof *configs.Bootstrap) (mq.Server, func(), error) {
-	//1. 创建 NATS 配置
-	natsConfig := nats.NewConfigBuilder(cof.NATSConfig.Address).
-		MaxRetries(cof.NATSConfig.MaxRetries).
-		ReconnectWait(cof.NATSConfig.ReconnectWait). // 5秒
-		AckWait(cof.NATSConfig.AckWait).             // 60秒
-		QueueGroup(cof.NATSConfig.QueueGroup).
-		DurableName(cof.NATSConfig.DurableName).
-		Build()
-
-	// 2. 转换为通用 MQ 配置
-	mqConfig := natsConfig.ToMQConfig()
+	mqType := "nats"
+	if cof.MQ != nil && cof.MQ.Type != "" {
+		mqType = cof.MQ.Type
+	}
 
-	//config := nsq.NewConfigBuilder(cof.NSQConfig.Address).
-	//	MaxRetries(cof.NSQConfig.MaxRetries).
-	//	MaxInFlight(cof.NSQConfig.MaxInFlight).
-	//	MaxBackoffDuration(cof.NSQConfig.MaxBackoffDuration).
-	//	LookupdPollInterval(cof.NSQConfig.LookupdPollInterval).
-	//	Build()
-	//mqConfig := config.ToMQConfig()
+	// 1. 按类型创建通用 MQ 配置
+	var mqConfig *models.Config
+	switch mqType {
+	case "memory":
+		mqConfig = memory.NewConfigBuilder().
+			MaxRetries(cof.MQ.MaxRetries).
+			RetryDelay(cof.MQ.RetryDelay).
+			Build().
+			ToMQConfig()
+	case "redis":
+		mqConfig = redis.NewConfigBuilder(cof.Data.Redis.Addr).
+			Password(cof.Data.Redis.Password).
+			DB(int(cof.Data.Redis.Db)).
+			MaxRetries(cof.MQ.MaxRetries).
+			KeyPrefix(cof.MQ.KeyPrefix).
+			MaxLen(cof.MQ.MaxLen).
+			ClaimIdle(cof.MQ.ClaimIdle).
+			Build().
+			ToMQConfig()
+	case "nsq":
+		mqConfig = nsq.NewConfigBuilder(cof.NSQConfig.Address).
+			MaxRetries(cof.NSQConfig.MaxRetries).
+			MaxInFlight(cof.NSQConfig.MaxInFlight).
+			MaxBackoffDuration(cof.NSQConfig.MaxBackoffDuration).
+			LookupdPollInterval(cof.NSQConfig.LookupdPollInterval).
+			Build().
+			ToMQConfig()
+	default:
+		mqConfig = nats.NewConfigBuilder(cof.NATSConfig.Address).
+			MaxRetries(cof.NATSConfig.MaxRetries).
+			ReconnectWait(cof.NATSConfig.ReconnectWait). // 5秒
+			AckWait(cof.NATSConfig.AckWait).             // 60秒
+			QueueGroup(cof.NATSConfig.QueueGroup).
+			DurableName(cof.NATSConfig.DurableName).
+			MaxDeliver(cof.NATSConfig.MaxDeliver).
+			JetStream(cof.NATSConfig.JetStream).
+			StreamMaxAge(cof.NATSConfig.StreamMaxAge).
+			RetryBackoff(cof.NATSConfig.RetryBackoff).
+			Build().
+			ToMQConfig()
+	}
 
-	// 3. 创建统一服务器
+	// 2. 创建统一服务器
 	server, err := server.NewServer(mqConfig)
 	if err != nil {
 		panic(fmt.Sprintf("创建mq服务器失败: %v", err))
@@ -44,3 +79,22 @@ func NewMqServer(cof *configs.Bootstrap) (mq.Server, func(), error) {
 	}
 	return server, cleanup, nil
 }
+
+// NewDelayScheduler 创建持久化延迟消息调度器，多实例部署时通过 redis 选举一个实例投递
+func NewDelayScheduler(cof *configs.Bootstrap, hc *hredis.RedisClient, sr mq.Server) (*delay.Scheduler, func(), error) {
+	cfg := delay.Config{}
+	if cof.MQ != nil && cof.MQ.DelayPollInterval > 0 {
+		cfg.PollInterval = time.Duration(cof.MQ.DelayPollInterval) * time.Millisecond
+	}
+	rdb := hc.GetClient()
+	scheduler := delay.NewScheduler(
+		delay.NewRedisStore(rdb, delay.DefaultKeyPrefix),
+		delay.NewRedisElector(rdb, delay.DefaultKeyPrefix),
+		delay.NewServerProducer(sr),
+		cfg,
+	)
+	cleanup := func() {
+		scheduler.Close()
+	}
+	return scheduler, cleanup, nil
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+type contextAfterCommitKey struct{}
+
+// afterCommitHooks 事务提交后执行的函数
+type afterCommitHooks struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context)
+}
+
+// AfterCommit 注册在当前事务提交后执行的函数，事务回滚时不执行。
+// ctx 不在 InTx 开启的事务中时返回 false，由调用方决定是否立即执行
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) bool {
+	hooks, ok := ctx.Value(contextAfterCommitKey{}).(*afterCommitHooks)
+	if !ok {
+		return false
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.fns = append(hooks.fns, fn)
+	return true
+}
+
+// 开启事务时挂载提交钩子
+func withAfterCommit(ctx context.Context) (context.Context, *afterCommitHooks) {
+	hooks := &afterCommitHooks{}
+	return context.WithValue(ctx, contextAfterCommitKey{}, hooks), hooks
+}
+
+// run 按注册顺序执行，ctx 为开启事务前的上下文，单个函数 panic 不影响其他函数
+func (h *afterCommitHooks) run(ctx context.Context) {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+	for _, fn := range fns {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					hlog.CtxErrorf(ctx, "after commit hook panic: %v", r)
+				}
+			}()
+			fn(ctx)
+		}()
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAfterCommit(t *testing.T) {
+	if AfterCommit(context.Background(), func(ctx context.Context) {}) {
+		t.Fatal("AfterCommit outside a transaction must return false")
+	}
+
+	txCtx, hooks := withAfterCommit(context.Background())
+	var order []int
+	AfterCommit(txCtx, func(ctx context.Context) { order = append(order, 1) })
+	AfterCommit(txCtx, func(ctx context.Context) { panic("boom") })
+	AfterCommit(txCtx, func(ctx context.Context) { order = append(order, 2) })
+	if len(order) != 0 {
+		t.Fatal("hooks must not run before commit")
+	}
+	hooks.run(context.Background())
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Fatalf("order = %v", order)
+	}
+}
+
+func TestAfterCommitRollback(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := NewData(nil, db, &configs.Data{})
+
+	ran := 0
+	rollback := errors.New("rollback")
+	err = data.InTx(context.Background(), func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) { ran++ })
+		return rollback
+	})
+	if !errors.Is(err, rollback) || ran != 0 {
+		t.Fatalf("InTx = %v, hooks ran %d times after rollback", err, ran)
+	}
+
+	err = data.InTx(context.Background(), func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) { ran++ })
+		return nil
+	})
+	if err != nil || ran != 1 {
+		t.Fatalf("InTx = %v, hooks ran %d times after commit", err, ran)
+	}
+}
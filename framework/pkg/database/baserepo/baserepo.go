@@ -8,11 +8,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/plugin"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 	"golang.org/x/exp/constraints"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SupportedIDTypes 支持的 ID 类型
@@ -38,6 +41,14 @@ type IBaseRepo[T IModel, I SupportedIDTypes] interface {
 	BathAdd(ctx context.Context, data ...*T) error
 	Count(ctx context.Context, qb *db_query.QueryBuilder) (int64, error)
 	Find(ctx context.Context, qb *db_query.QueryBuilder) ([]*T, error)
+	// Upsert 批量插入，唯一键冲突时更新 updateColumns，updateColumns 为空时忽略冲突
+	Upsert(ctx context.Context, conflictColumns []string, updateColumns []string, data ...*T) error
+	// UpdateWhere 按条件批量更新，返回影响行数
+	UpdateWhere(ctx context.Context, qb *db_query.QueryBuilder, values map[string]interface{}) (int64, error)
+	// FindInBatches 按主键顺序分批遍历，fn 返回错误时停止
+	FindInBatches(ctx context.Context, qb *db_query.QueryBuilder, size int, fn func(ctx context.Context, batch []*T) error) error
+	// Exists 是否存在满足条件的记录
+	Exists(ctx context.Context, qb *db_query.QueryBuilder) (bool, error)
 	Db(ctx context.Context) *gorm.DB
 	GetDb() database.IDataBase
 	InTx(ctx context.Context, fn func(ctx context.Context) error) error
@@ -134,6 +145,34 @@ func (r *BaseRepo[T, I]) EditById(ctx context.Context, data *T) error {
 	return db.Updates(data).Error
 }
 
+// UpdateWhere 按条件批量更新
+//   - 条件为空时拒绝执行，避免误更新全表
+//   - 自动追加软删除和租户条件（租户插件只作用于查询和创建）
+//   - 未指定 updated_at 时自动设置，在副本上设置，不修改调用方传入的 values
+func (r *BaseRepo[T, I]) UpdateWhere(ctx context.Context, qb *db_query.QueryBuilder, values map[string]interface{}) (int64, error) {
+	if len(values) == 0 {
+		return 0, errors.New("update values is empty")
+	}
+	where, args := qb.BuildWhere()
+	if where == "" {
+		return 0, errors.New("update where is empty")
+	}
+	if _, ok := values["updated_at"]; !ok && hasField(r.Model, "UpdatedAt") {
+		updates := make(map[string]interface{}, len(values)+1)
+		for k, v := range values {
+			updates[k] = v
+		}
+		updates["updated_at"] = utils.GetDateUnixMilli()
+		values = updates
+	}
+	db := r.scoped(ctx).Where(where, args...)
+	if tenantID := plugin.GetCtxTenantID(ctx); tenantID != "" && !plugin.IsIgnoreTenant(ctx) && r.hasColumn(db, actx.KeyTenantId) {
+		db = db.Where(fmt.Sprintf("%s = ?", actx.KeyTenantId), tenantID)
+	}
+	res := db.Updates(values)
+	return res.RowsAffected, res.Error
+}
+
 // --------------------------- 添加 ---------------------------
 
 func (r *BaseRepo[T, I]) Add(ctx context.Context, data *T) (*T, error) {
@@ -159,6 +198,34 @@ func (r *BaseRepo[T, I]) BathAdd(ctx context.Context, data ...*T) error {
 	return r.db.DB(ctx).Create(data).Error
 }
 
+// Upsert 批量插入或更新
+// 参数：
+//
+//	conflictColumns ：冲突判断的唯一键列，MySQL 使用 ON DUPLICATE KEY 时以表上的唯一索引为准
+//	updateColumns ：冲突时更新的列，为空时忽略冲突；软删除的记录需要恢复时传入 deleted_at
+func (r *BaseRepo[T, I]) Upsert(ctx context.Context, conflictColumns []string, updateColumns []string, data ...*T) error {
+	if len(data) == 0 {
+		return nil
+	}
+	for _, d := range data {
+		setCreatedAt(d)
+		setUpdatedAt(d)
+		setIDIfEmpty(r, d)
+	}
+	onConflict := clause.OnConflict{DoNothing: len(updateColumns) == 0}
+	for _, col := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: col})
+	}
+	if len(updateColumns) > 0 {
+		columns := updateColumns
+		if hasField(r.Model, "UpdatedAt") && !utils.ContainsString(columns, "updated_at") {
+			columns = append(append([]string{}, columns...), "updated_at")
+		}
+		onConflict.DoUpdates = clause.AssignmentColumns(columns)
+	}
+	return r.db.DB(ctx).Clauses(onConflict).Create(data).Error
+}
+
 // --------------------------- 查询 ---------------------------
 
 func (r *BaseRepo[T, I]) Count(ctx context.Context, qb *db_query.QueryBuilder) (int64, error) {
@@ -189,6 +256,53 @@ func (r *BaseRepo[T, I]) Find(ctx context.Context, qb *db_query.QueryBuilder) ([
 	return res, err
 }
 
+// FindInBatches 按主键游标分批遍历，不会一次性加载全部数据
+// 游标分页要求按主键排序，因此忽略 qb 的排序和分页
+func (r *BaseRepo[T, I]) FindInBatches(ctx context.Context, qb *db_query.QueryBuilder, size int, fn func(ctx context.Context, batch []*T) error) error {
+	if size <= 0 {
+		size = 500
+	}
+	db := r.scoped(ctx)
+	if where, values := qb.BuildWhere(); where != "" {
+		db = db.Where(where, values...)
+	}
+	var batch []*T
+	return db.FindInBatches(&batch, size, func(tx *gorm.DB, _ int) error {
+		return fn(ctx, batch)
+	}).Error
+}
+
+func (r *BaseRepo[T, I]) Exists(ctx context.Context, qb *db_query.QueryBuilder) (bool, error) {
+	db := r.scoped(ctx)
+	if where, values := qb.BuildWhere(); where != "" {
+		db = db.Where(where, values...)
+	}
+	var rows []int
+	if err := db.Select("1").Limit(1).Find(&rows).Error; err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
+
+// 带软删除条件的 db，使用新的模型实例避免并发时钩子修改共享的 r.Model
+func (r *BaseRepo[T, I]) scoped(ctx context.Context) *gorm.DB {
+	db := r.db.DB(ctx).Model(new(T))
+	if hasDeletedField(r.Model) {
+		db = db.Where("deleted_at = 0")
+	}
+	return db
+}
+
+// 表中是否存在指定列
+func (r *BaseRepo[T, I]) hasColumn(db *gorm.DB, column string) bool {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return false
+	}
+	_, ok := stmt.Schema.FieldsByDBName[column]
+	return ok
+}
+
 // --------------------------- 事务 & DB ---------------------------
 
 func (r *BaseRepo[T, I]) Db(ctx context.Context) *gorm.DB {
@@ -219,11 +333,16 @@ func (r *BaseRepo[T, I]) GenInt64Id() int64 {
 
 // 检查是否有 DeletedAt 字段
 func hasDeletedField[T IModel](model T) bool {
+	return hasField(model, "DeletedAt")
+}
+
+// 检查是否有指定字段
+func hasField[T IModel](model T, name string) bool {
 	v := reflect.ValueOf(model)
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
-	_, ok := v.Type().FieldByName("DeletedAt")
+	_, ok := v.Type().FieldByName(name)
 	return ok
 }
 
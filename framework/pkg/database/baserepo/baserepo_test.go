@@ -0,0 +1,290 @@
+package baserepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/plugin"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+type repoModel struct {
+	ID       string `gorm:"column:id;primaryKey"`
+	Code     string `gorm:"column:code"`
+	Name     string `gorm:"column:name"`
+	TenantID string `gorm:"column:tenant_id"`
+	database.BaseIntTime
+}
+
+func (repoModel) TableName() string {
+	return "repo_model"
+}
+
+type plainRepoModel struct {
+	ID   int64  `gorm:"column:id;primaryKey"`
+	Name string `gorm:"column:name"`
+}
+
+func (plainRepoModel) TableName() string {
+	return "plain_repo_model"
+}
+
+// 测试用数据源，记录执行的 SQL
+type fakeDataBase struct {
+	db   *gorm.DB
+	mu   sync.Mutex
+	sqls []string
+	seq  int64
+}
+
+func newFakeDataBase(t *testing.T, dialector gorm.Dialector) *fakeDataBase {
+	db, err := gorm.Open(dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err = db.Use(plugin.NewTenantPlugin()); err != nil {
+		t.Fatalf("use plugin: %v", err)
+	}
+	f := &fakeDataBase{db: db}
+	record := func(tx *gorm.DB) {
+		f.mu.Lock()
+		f.sqls = append(f.sqls, tx.Statement.SQL.String())
+		f.mu.Unlock()
+	}
+	_ = db.Callback().Create().After("gorm:create").Register("test:record", record)
+	_ = db.Callback().Update().After("gorm:update").Register("test:record", record)
+	_ = db.Callback().Query().After("gorm:query").Register("test:record", record)
+	return f
+}
+
+func newMysqlDataBase(t *testing.T) *fakeDataBase {
+	return newFakeDataBase(t, mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}))
+}
+
+func (f *fakeDataBase) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *fakeDataBase) InIndependentTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *fakeDataBase) DB(ctx context.Context) *gorm.DB {
+	return f.db.WithContext(ctx)
+}
+
+func (f *fakeDataBase) AutoMigrate(dst ...interface{}) error {
+	return nil
+}
+
+func (f *fakeDataBase) GenStringId() string {
+	return fmt.Sprint(f.GenInt64Id())
+}
+
+func (f *fakeDataBase) GenInt64Id() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	return f.seq
+}
+
+func (f *fakeDataBase) lastSQL() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.sqls) == 0 {
+		return ""
+	}
+	return f.sqls[len(f.sqls)-1]
+}
+
+func TestBaseRepo_UpsertMysql(t *testing.T) {
+	fdb := newMysqlDataBase(t)
+	repo := NewBaseRepo[repoModel, string](fdb)
+	ctx := context.Background()
+
+	data := []*repoModel{{Code: "a", Name: "A"}, {Code: "b", Name: "B"}}
+	if err := repo.Upsert(ctx, []string{"code"}, []string{"name"}, data...); err != nil {
+		t.Fatal(err)
+	}
+	sql := fdb.lastSQL()
+	if !strings.Contains(sql, "ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`updated_at`=VALUES(`updated_at`)") {
+		t.Fatalf("unexpected sql: %s", sql)
+	}
+	if data[0].ID == "" || data[0].CreatedAt == 0 || data[0].UpdatedAt == 0 {
+		t.Fatalf("id/time not filled: %+v", data[0])
+	}
+
+	// 未指定更新列时忽略冲突
+	if err := repo.Upsert(ctx, []string{"code"}, nil, &repoModel{Code: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if sql = fdb.lastSQL(); !strings.Contains(sql, "ON DUPLICATE KEY UPDATE `id`=`id`") {
+		t.Fatalf("unexpected sql: %s", sql)
+	}
+
+	if err := repo.Upsert(ctx, []string{"code"}, []string{"name"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBaseRepo_UpsertPostgres(t *testing.T) {
+	fdb := newFakeDataBase(t, postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=u password=p dbname=test"}))
+	repo := NewBaseRepo[repoModel, string](fdb)
+
+	if err := repo.Upsert(context.Background(), []string{"tenant_id", "code"}, []string{"name", "updated_at"}, &repoModel{Code: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	sql := fdb.lastSQL()
+	if !strings.Contains(sql, `ON CONFLICT ("tenant_id","code") DO UPDATE SET "name"="excluded"."name","updated_at"="excluded"."updated_at"`) {
+		t.Fatalf("unexpected sql: %s", sql)
+	}
+
+	if err := repo.Upsert(context.Background(), []string{"code"}, nil, &repoModel{Code: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if sql = fdb.lastSQL(); !strings.Contains(sql, `ON CONFLICT ("code") DO NOTHING`) {
+		t.Fatalf("unexpected sql: %s", sql)
+	}
+}
+
+func TestBaseRepo_UpdateWhere(t *testing.T) {
+	fdb := newMysqlDataBase(t)
+	repo := NewBaseRepo[repoModel, string](fdb)
+	ctx := actx.WithTenantId(context.Background(), "t1")
+
+	qb := db_query.NewQueryBuilder().WhereIn("code", []string{"a", "b"})
+	values := map[string]interface{}{"name": "x"}
+	if _, err := repo.UpdateWhere(ctx, qb, values); err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 {
+		t.Fatalf("caller values modified: %v", values)
+	}
+	sql := fdb.lastSQL()
+	for _, want := range []string{"`name`=?", "`updated_at`=?", "code IN (?,?)", "deleted_at = 0", "tenant_id = ?"} {
+		if !strings.Contains(sql, want) {
+			t.Fatalf("sql %q missing %q", sql, want)
+		}
+	}
+
+	// 忽略租户时不追加租户条件
+	if _, err := repo.UpdateWhere(actx.WithIgnoreTenantId(ctx), qb, map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if sql = fdb.lastSQL(); strings.Contains(sql, "tenant_id") {
+		t.Fatalf("unexpected tenant condition: %s", sql)
+	}
+
+	// 条件或更新值为空时拒绝执行
+	if _, err := repo.UpdateWhere(ctx, db_query.NewQueryBuilder(), map[string]interface{}{"name": "x"}); err == nil {
+		t.Fatal("empty where should fail")
+	}
+	if _, err := repo.UpdateWhere(ctx, qb, nil); err == nil {
+		t.Fatal("empty values should fail")
+	}
+
+	// 没有租户和软删除字段的表
+	plain := NewBaseRepo[plainRepoModel, int64](fdb)
+	if _, err := plain.UpdateWhere(ctx, db_query.NewQueryBuilder().WhereEq("id", 1), map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if sql = fdb.lastSQL(); strings.Contains(sql, "tenant_id") || strings.Contains(sql, "deleted_at") || strings.Contains(sql, "updated_at") {
+		t.Fatalf("unexpected sql: %s", sql)
+	}
+}
+
+func TestBaseRepo_Exists(t *testing.T) {
+	fdb := newMysqlDataBase(t)
+	repo := NewBaseRepo[repoModel, string](fdb)
+	ctx := actx.WithTenantId(context.Background(), "t1")
+
+	ok, err := repo.Exists(ctx, db_query.NewQueryBuilder().WhereEq("code", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("dry run should not find rows")
+	}
+	sql := fdb.lastSQL()
+	for _, want := range []string{"SELECT 1 FROM `repo_model`", "code = ?", "deleted_at = 0", "`repo_model`.`tenant_id` = ?", "LIMIT ?"} {
+		if !strings.Contains(sql, want) {
+			t.Fatalf("sql %q missing %q", sql, want)
+		}
+	}
+}
+
+func TestBaseRepo_FindInBatches(t *testing.T) {
+	fdb := newMysqlDataBase(t)
+	source := make([]*repoModel, 0, 5)
+	for i := 1; i <= 5; i++ {
+		source = append(source, &repoModel{ID: fmt.Sprintf("%02d", i)})
+	}
+	// 模拟数据库按主键游标返回数据
+	var served int
+	err := fdb.db.Callback().Query().Replace("gorm:query", func(tx *gorm.DB) {
+		callbacks.BuildQuerySQL(tx)
+		limit := 0
+		if c, ok := tx.Statement.Clauses["LIMIT"]; ok {
+			if l, ok := c.Expression.(clause.Limit); ok && l.Limit != nil {
+				limit = *l.Limit
+			}
+		}
+		rv := tx.Statement.ReflectValue
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, limit))
+		for ; served < len(source) && limit > 0; served++ {
+			rv.Set(reflect.Append(rv, reflect.ValueOf(source[served])))
+			tx.RowsAffected++
+			limit--
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewBaseRepo[repoModel, string](fdb)
+
+	var sizes []int
+	var ids []string
+	err = repo.FindInBatches(context.Background(), db_query.NewQueryBuilder().WhereEq("code", "a"), 2, func(ctx context.Context, batch []*repoModel) error {
+		sizes = append(sizes, len(batch))
+		for _, m := range batch {
+			ids = append(ids, m.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(sizes) != "[2 2 1]" || len(ids) != 5 {
+		t.Fatalf("unexpected batches: %v %v", sizes, ids)
+	}
+	if sql := fdb.lastSQL(); !strings.Contains(sql, "`repo_model`.`id` > ?") || !strings.Contains(sql, "ORDER BY `repo_model`.`id`") || !strings.Contains(sql, "deleted_at = 0") {
+		t.Fatalf("unexpected sql: %s", sql)
+	}
+
+	// fn 返回错误时停止遍历
+	served = 0
+	stop := errors.New("stop")
+	var calls int
+	err = repo.FindInBatches(context.Background(), db_query.NewQueryBuilder(), 2, func(ctx context.Context, batch []*repoModel) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("expected stop after first batch, err=%v calls=%d", err, calls)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/encryption"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/plugin"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/snowflake_id"
 	"gorm.io/driver/mysql"
@@ -45,7 +46,21 @@ func NewDb(cof *configs.Data) (*gorm.DB, func(), error) {
 		hlog.Fatalf("failed opening connection to mysql: %v", err)
 	}
 	// 添加插件
-	err = db.Use(plugin.NewTenantPlugin())
+	if err = db.Use(plugin.NewTenantPlugin()); err != nil {
+		hlog.Fatalf("failed registering tenant plugin: %v", err)
+	}
+	if err = db.Use(plugin.NewOperatorPlugin()); err != nil {
+		hlog.Fatalf("failed registering operator plugin: %v", err)
+	}
+	// 敏感字段加密，未配置密钥时明文读写，盲索引仍然生效
+	keyring, err := encryption.NewKeyring(cof.Encryption)
+	if err != nil {
+		hlog.Fatalf("failed loading encryption keys: %v", err)
+	}
+	encryption.Setup(keyring)
+	if err = db.Use(encryption.NewPlugin()); err != nil {
+		hlog.Fatalf("failed registering encryption plugin: %v", err)
+	}
 	// 获取底层的 SQL 连接池
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -83,19 +98,24 @@ func (d Data) InTx(ctx context.Context, fn func(ctx context.Context) error) erro
 		return fn(ctx)
 	}
 	// 未找到事务，创建新的事务
-	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 将 tx 放入到 ctx 中
-		ctx = context.WithValue(ctx, contextTxKey{}, tx)
-		return fn(ctx)
-	})
+	return d.transaction(ctx, fn)
 }
 
 func (d Data) InIndependentTx(ctx context.Context, fn func(ctx context.Context) error) error {
-	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return d.transaction(ctx, fn)
+}
+
+// transaction 开启事务，提交成功后执行 AfterCommit 注册的函数
+func (d Data) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	txCtx, hooks := withAfterCommit(ctx)
+	if err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 将 tx 放入到 ctx 中
-		ctx = context.WithValue(ctx, contextTxKey{}, tx)
-		return fn(ctx)
-	})
+		return fn(context.WithValue(txCtx, contextTxKey{}, tx))
+	}); err != nil {
+		return err
+	}
+	hooks.run(ctx)
+	return nil
 }
 
 func (d Data) DB(ctx context.Context) *gorm.DB {
@@ -0,0 +1,211 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+)
+
+// 密文前缀，格式：enc:<keyId>:<base64(nonce+密文)>
+const cipherPrefix = "enc:"
+
+var (
+	ErrKeyNotFound      = errors.New("encryption key not found")
+	ErrInvalidKey       = errors.New("encryption key must be 32 bytes (base64 encoded)")
+	ErrInvalidCipher    = errors.New("invalid cipher text")
+	ErrCurrentKeyNotSet = errors.New("encryption current key id not set")
+	// ErrBlindIndexKeyNotSet 开启加密时必须配置盲索引密钥，否则盲索引退化为无密钥的哈希，可被字典攻击
+	ErrBlindIndexKeyNotSet  = errors.New("encryption blind index key not set")
+	ErrInvalidBlindIndexKey = fmt.Errorf("blind index key must be at least %d bytes (base64 encoded)", minBlindIndexKeyLen)
+)
+
+// 盲索引密钥的最小长度
+const minBlindIndexKeyLen = 32
+
+// Keyring 密钥环，按密钥ID保存 AES-256 密钥，支持密钥轮换
+type Keyring struct {
+	currentKeyId string
+	aeads        map[string]cipher.AEAD
+	indexKey     []byte
+}
+
+// NewKeyring 根据配置创建密钥环
+// 开启加密时必须配置盲索引密钥；只配置盲索引密钥时不加密，仅写入盲索引
+func NewKeyring(conf *configs.Encryption) (*Keyring, error) {
+	kr := &Keyring{aeads: map[string]cipher.AEAD{}}
+	if conf == nil {
+		return kr, nil
+	}
+	if conf.BlindIndexKey != "" {
+		key, err := base64.StdEncoding.DecodeString(conf.BlindIndexKey)
+		if err != nil {
+			return nil, fmt.Errorf("decode blind index key: %w", err)
+		}
+		if len(key) < minBlindIndexKeyLen {
+			return nil, ErrInvalidBlindIndexKey
+		}
+		kr.indexKey = key
+	}
+	if len(conf.Keys) == 0 {
+		return kr, nil
+	}
+	if conf.CurrentKeyId == "" {
+		return nil, ErrCurrentKeyNotSet
+	}
+	kr.currentKeyId = conf.CurrentKeyId
+	for kid, encoded := range conf.Keys {
+		if strings.Contains(kid, ":") {
+			return nil, fmt.Errorf("invalid encryption key id %q", kid)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key %s: %w", kid, ErrInvalidKey)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		kr.aeads[kid] = aead
+	}
+	if _, ok := kr.aeads[kr.currentKeyId]; !ok {
+		return nil, fmt.Errorf("current key %s: %w", kr.currentKeyId, ErrKeyNotFound)
+	}
+	if kr.indexKey == nil {
+		return nil, ErrBlindIndexKeyNotSet
+	}
+	return kr, nil
+}
+
+// Enabled 是否配置了加密密钥，未配置时读写均为明文
+func (k *Keyring) Enabled() bool {
+	return k != nil && k.currentKeyId != ""
+}
+
+// IndexEnabled 是否配置了盲索引密钥，未配置时不计算盲索引，按明文查询
+func (k *Keyring) IndexEnabled() bool {
+	return k != nil && len(k.indexKey) > 0
+}
+
+// CurrentKeyId 当前加密使用的密钥ID
+func (k *Keyring) CurrentKeyId() string {
+	if k == nil {
+		return ""
+	}
+	return k.currentKeyId
+}
+
+// Encrypt 使用当前密钥加密，空字符串不加密
+func (k *Keyring) Encrypt(plain string) (string, error) {
+	if plain == "" || !k.Enabled() {
+		return plain, nil
+	}
+	aead := k.aeads[k.currentKeyId]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	// 以字段所在的密钥ID作为附加数据，防止密文被挪到其他密钥下解密
+	sealed := aead.Seal(nonce, nonce, []byte(plain), []byte(k.currentKeyId))
+	return cipherPrefix + k.currentKeyId + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密，非密文格式的历史明文数据原样返回
+func (k *Keyring) Decrypt(value string) (string, error) {
+	kid, payload, ok := parseCipher(value)
+	if !ok {
+		return value, nil
+	}
+	if k == nil {
+		return "", fmt.Errorf("key %s: %w", kid, ErrKeyNotFound)
+	}
+	aead, exists := k.aeads[kid]
+	if !exists {
+		return "", fmt.Errorf("key %s: %w", kid, ErrKeyNotFound)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCipher
+	}
+	nonce, data := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, data, []byte(kid))
+	if err != nil {
+		return "", ErrInvalidCipher
+	}
+	return string(plain), nil
+}
+
+// NeedsRotation 值是否需要用当前密钥重新加密（历史明文或旧密钥加密）
+func (k *Keyring) NeedsRotation(value string) bool {
+	if value == "" || !k.Enabled() {
+		return false
+	}
+	kid, _, ok := parseCipher(value)
+	return !ok || kid != k.currentKeyId
+}
+
+// BlindIndex 计算盲索引，同一明文始终得到相同结果，用于等值查询
+// 未配置盲索引密钥时返回空字符串，不计算无密钥的哈希
+func (k *Keyring) BlindIndex(value string) string {
+	value = Normalize(value)
+	if value == "" || !k.IndexEnabled() {
+		return ""
+	}
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Normalize 盲索引计算前的规范化：去除首尾空白并转小写
+func Normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// IsEncrypted 值是否为密文格式
+func IsEncrypted(value string) bool {
+	_, _, ok := parseCipher(value)
+	return ok
+}
+
+func parseCipher(value string) (kid, payload string, ok bool) {
+	if !strings.HasPrefix(value, cipherPrefix) {
+		return "", "", false
+	}
+	rest := value[len(cipherPrefix):]
+	idx := strings.IndexByte(rest, ':')
+	if idx <= 0 {
+		return "", "", false
+	}
+	return rest[:idx], rest[idx+1:], true
+}
+
+var defaultKeyring atomic.Pointer[Keyring]
+
+// Setup 设置全局密钥环，gorm 序列化器和盲索引插件均使用全局密钥环
+func Setup(k *Keyring) {
+	defaultKeyring.Store(k)
+}
+
+// Default 获取全局密钥环
+func Default() *Keyring {
+	return defaultKeyring.Load()
+}
+
+// BlindIndex 使用全局密钥环计算盲索引
+func BlindIndex(value string) string {
+	return Default().BlindIndex(value)
+}
@@ -0,0 +1,147 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func newTestKeyring(t *testing.T, current string, keys ...string) *Keyring {
+	conf := &configs.Encryption{
+		CurrentKeyId:  current,
+		Keys:          map[string]string{},
+		BlindIndexKey: testKey('i'),
+	}
+	for _, kid := range keys {
+		conf.Keys[kid] = testKey(kid[0])
+	}
+	kr, err := NewKeyring(conf)
+	if err != nil {
+		t.Fatalf("new keyring: %v", err)
+	}
+	return kr
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	kr := newTestKeyring(t, "a1", "a1")
+
+	c1, err := kr.Encrypt("13800138000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, _ := kr.Encrypt("13800138000")
+	if c1 == c2 {
+		t.Fatal("cipher text should be randomized")
+	}
+	if !strings.HasPrefix(c1, "enc:a1:") {
+		t.Fatalf("unexpected cipher format: %s", c1)
+	}
+	plain, err := kr.Decrypt(c1)
+	if err != nil || plain != "13800138000" {
+		t.Fatalf("decrypt = %q, %v", plain, err)
+	}
+
+	// 空值与历史明文
+	if v, _ := kr.Encrypt(""); v != "" {
+		t.Fatalf("empty value should not be encrypted: %q", v)
+	}
+	if v, _ := kr.Decrypt("legacy"); v != "legacy" {
+		t.Fatalf("plain text should pass through: %q", v)
+	}
+
+	// 篡改密文
+	tampered := c1[:len(c1)-2] + "AA"
+	if _, err = kr.Decrypt(tampered); !errors.Is(err, ErrInvalidCipher) {
+		t.Fatalf("tampered cipher: %v", err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old := newTestKeyring(t, "a1", "a1")
+	c, _ := old.Encrypt("zhangsan@example.com")
+
+	kr := newTestKeyring(t, "b2", "a1", "b2")
+	plain, err := kr.Decrypt(c)
+	if err != nil || plain != "zhangsan@example.com" {
+		t.Fatalf("decrypt with old key = %q, %v", plain, err)
+	}
+	if !kr.NeedsRotation(c) || !kr.NeedsRotation("plain") {
+		t.Fatal("old cipher and plain text should need rotation")
+	}
+	c2, _ := kr.Encrypt(plain)
+	if kr.NeedsRotation(c2) {
+		t.Fatal("current cipher should not need rotation")
+	}
+
+	// 旧密钥移除后无法解密
+	removed := newTestKeyring(t, "b2", "b2")
+	if _, err = removed.Decrypt(c); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("removed key: %v", err)
+	}
+}
+
+func TestKeyring_BlindIndex(t *testing.T) {
+	kr := newTestKeyring(t, "a1", "a1")
+	if kr.BlindIndex(" Zhang@Example.com ") != kr.BlindIndex("zhang@example.com") {
+		t.Fatal("blind index should be normalized")
+	}
+	if kr.BlindIndex("13800138000") == kr.BlindIndex("13800138001") {
+		t.Fatal("blind index collision")
+	}
+	if kr.BlindIndex("") != "" {
+		t.Fatal("empty value should have empty index")
+	}
+	// 盲索引与加密密钥无关，轮换加密密钥后索引不变
+	if newTestKeyring(t, "b2", "b2").BlindIndex("x") != kr.BlindIndex("x") {
+		t.Fatal("blind index should not depend on encryption key")
+	}
+}
+
+func TestNewKeyring_Invalid(t *testing.T) {
+	if _, err := NewKeyring(&configs.Encryption{Keys: map[string]string{"a": testKey('a')}}); !errors.Is(err, ErrCurrentKeyNotSet) {
+		t.Fatalf("missing current key: %v", err)
+	}
+	if _, err := NewKeyring(&configs.Encryption{CurrentKeyId: "a", Keys: map[string]string{"a": "short"}}); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("invalid key: %v", err)
+	}
+	if _, err := NewKeyring(&configs.Encryption{CurrentKeyId: "b", Keys: map[string]string{"a": testKey('a')}}); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("unknown current key: %v", err)
+	}
+	kr, err := NewKeyring(nil)
+	if err != nil || kr.Enabled() {
+		t.Fatalf("nil config should disable encryption: %v", err)
+	}
+	if v, _ := kr.Encrypt("x"); v != "x" {
+		t.Fatal("disabled keyring should not encrypt")
+	}
+}
+
+func TestNewKeyring_BlindIndexKey(t *testing.T) {
+	keys := map[string]string{"a": testKey('a')}
+	if _, err := NewKeyring(&configs.Encryption{CurrentKeyId: "a", Keys: keys}); !errors.Is(err, ErrBlindIndexKeyNotSet) {
+		t.Fatalf("missing blind index key: %v", err)
+	}
+	short := base64.StdEncoding.EncodeToString([]byte("short"))
+	if _, err := NewKeyring(&configs.Encryption{CurrentKeyId: "a", Keys: keys, BlindIndexKey: short}); !errors.Is(err, ErrInvalidBlindIndexKey) {
+		t.Fatalf("short blind index key: %v", err)
+	}
+
+	// 只配置盲索引密钥时不加密，仅计算盲索引
+	kr, err := NewKeyring(&configs.Encryption{BlindIndexKey: testKey('i')})
+	if err != nil || kr.Enabled() || !kr.IndexEnabled() || kr.BlindIndex("x") == "" {
+		t.Fatalf("index only keyring: %v", err)
+	}
+
+	// 未配置盲索引密钥时不计算无密钥的哈希
+	kr, _ = NewKeyring(nil)
+	if kr.IndexEnabled() || kr.BlindIndex("x") != "" {
+		t.Fatal("blind index without key should be empty")
+	}
+}
@@ -0,0 +1,201 @@
+package encryption
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// BlindIndexTag 盲索引标签，值为来源字段的列名，例如：
+//
+//	Phone      string `gorm:"column:phone;serializer:encrypted"`
+//	PhoneIndex string `gorm:"column:phone_bidx;size:64;index" blindindex:"phone"`
+const BlindIndexTag = "blindindex"
+
+// Plugin 加密插件
+//   - 写入时根据来源字段的明文计算盲索引列
+//   - Updates(map)/Update/UpdateColumn 不经过序列化器，由插件对加密字段的值进行加密
+type Plugin struct{}
+
+func NewPlugin() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "encryption_plugin"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("encryption:before_create", p.beforeCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("encryption:before_update", p.beforeUpdate); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 盲索引字段与来源字段
+type indexPair struct {
+	source *schema.Field
+	index  *schema.Field
+}
+
+func (p *Plugin) beforeCreate(db *gorm.DB) {
+	p.apply(db, false)
+}
+
+func (p *Plugin) beforeUpdate(db *gorm.DB) {
+	p.apply(db, true)
+}
+
+func (p *Plugin) apply(db *gorm.DB, isUpdate bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	pairs := indexPairs(db.Statement.Schema)
+	switch dest := db.Statement.Dest.(type) {
+	case map[string]interface{}:
+		applyMap(db, dest, pairs)
+	case []map[string]interface{}:
+		for _, m := range dest {
+			applyMap(db, m, pairs)
+		}
+	default:
+		if len(pairs) == 0 {
+			return
+		}
+		rv := reflect.ValueOf(db.Statement.Dest)
+		for rv.Kind() == reflect.Ptr {
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Struct && !rv.CanAddr() {
+			// Updates(struct) 传入的非指针结构体，复制一份可寻址的值
+			addr := reflect.New(rv.Type())
+			addr.Elem().Set(rv)
+			db.Statement.Dest = addr.Interface()
+			rv = addr.Elem()
+		}
+		applyValue(db, rv, pairs, isUpdate)
+		if isUpdate && len(db.Statement.Selects) > 0 {
+			appendSelects(db.Statement, pairs)
+		}
+	}
+}
+
+// 结构体写入，根据来源字段明文计算盲索引
+func applyValue(db *gorm.DB, rv reflect.Value, pairs []indexPair, isUpdate bool) {
+	ctx := db.Statement.Context
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			applyValue(db, rv.Index(i), pairs, isUpdate)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !rv.IsNil() {
+			applyValue(db, rv.Elem(), pairs, isUpdate)
+		}
+	case reflect.Struct:
+		if !rv.CanAddr() {
+			_ = db.AddError(gorm.ErrInvalidValue)
+			return
+		}
+		for _, pair := range pairs {
+			// 序列化字段的 ValueOf 返回序列化包装，这里直接读取字段原始值
+			value := pair.source.ReflectValueOf(ctx, rv)
+			// 按结构体更新时零值字段不会被更新，索引保持不变
+			if value.IsZero() && isUpdate && !selected(db.Statement, pair.source) {
+				continue
+			}
+			_ = db.AddError(pair.index.Set(ctx, rv, Default().BlindIndex(toString(value.Interface()))))
+		}
+	default:
+	}
+}
+
+// map 写入，计算盲索引并加密字段值
+func applyMap(db *gorm.DB, m map[string]interface{}, pairs []indexPair) {
+	for _, pair := range pairs {
+		if value, ok := mapValue(m, pair.source); ok {
+			delete(m, pair.index.Name)
+			m[pair.index.DBName] = Default().BlindIndex(toString(value))
+		}
+	}
+	for _, field := range db.Statement.Schema.Fields {
+		if !isEncryptedField(field) {
+			continue
+		}
+		for _, key := range []string{field.DBName, field.Name} {
+			value, ok := m[key].(string)
+			if !ok || IsEncrypted(value) {
+				continue
+			}
+			encrypted, err := Default().Encrypt(value)
+			if err != nil {
+				_ = db.AddError(err)
+				return
+			}
+			m[key] = encrypted
+		}
+	}
+}
+
+// 指定了 Select 的更新，来源字段被更新时需同时更新盲索引
+func appendSelects(stmt *gorm.Statement, pairs []indexPair) {
+	for _, pair := range pairs {
+		if selected(stmt, pair.source) && !selected(stmt, pair.index) {
+			stmt.Selects = append(stmt.Selects, pair.index.DBName)
+		}
+	}
+}
+
+func selected(stmt *gorm.Statement, field *schema.Field) bool {
+	for _, c := range stmt.Selects {
+		if c == "*" || c == field.DBName || c == field.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func mapValue(m map[string]interface{}, field *schema.Field) (interface{}, bool) {
+	if v, ok := m[field.DBName]; ok {
+		return v, true
+	}
+	v, ok := m[field.Name]
+	return v, ok
+}
+
+func indexPairs(s *schema.Schema) []indexPair {
+	pairs := make([]indexPair, 0)
+	for _, field := range s.Fields {
+		sourceName := field.Tag.Get(BlindIndexTag)
+		if sourceName == "" {
+			continue
+		}
+		if source := s.LookUpField(sourceName); source != nil {
+			pairs = append(pairs, indexPair{source: source, index: field})
+		}
+	}
+	return pairs
+}
+
+func isEncryptedField(field *schema.Field) bool {
+	_, ok := field.Serializer.(Serializer)
+	return ok
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package encryption
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type piiModel struct {
+	ID         int64  `gorm:"column:id;primaryKey"`
+	Phone      string `gorm:"column:phone;serializer:encrypted"`
+	Email      string `gorm:"column:email;serializer:encrypted"`
+	PhoneIndex string `gorm:"column:phone_bidx" blindindex:"phone"`
+	EmailIndex string `gorm:"column:email_bidx" blindindex:"email"`
+}
+
+func newPluginTestDB(t *testing.T) *gorm.DB {
+	Setup(newTestKeyring(t, "a1", "a1"))
+	t.Cleanup(func() { Setup(nil) })
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err = db.Use(NewPlugin()); err != nil {
+		t.Fatalf("use plugin: %v", err)
+	}
+	return db
+}
+
+// 取出 SQL 参数中的字符串，序列化字段会以 driver.Valuer 的形式出现
+func stringVars(t *testing.T, stmt *gorm.Statement) []string {
+	res := make([]string, 0, len(stmt.Vars))
+	for _, v := range stmt.Vars {
+		if valuer, ok := v.(interface{ Value() (interface{}, error) }); ok {
+			val, err := valuer.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			v = val
+		}
+		if s, ok := v.(string); ok {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func TestPlugin_Create(t *testing.T) {
+	db := newPluginTestDB(t)
+	m := &piiModel{ID: 1, Phone: "13800138000", Email: "A@b.com"}
+	stmt := db.Create(m).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if m.PhoneIndex != BlindIndex("13800138000") || m.EmailIndex != BlindIndex("a@b.com") {
+		t.Fatalf("blind index not filled: %+v", m)
+	}
+	if m.Phone != "13800138000" {
+		t.Fatalf("model value should stay plain: %s", m.Phone)
+	}
+	for _, v := range stringVars(t, stmt) {
+		if v == "13800138000" || v == "A@b.com" {
+			t.Fatalf("plain text written: %v", stmt.Vars)
+		}
+	}
+}
+
+func TestPlugin_BatchCreate(t *testing.T) {
+	db := newPluginTestDB(t)
+	list := []*piiModel{{ID: 1, Phone: "1"}, {ID: 2, Phone: "2"}}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range list {
+		if m.PhoneIndex != BlindIndex(m.Phone) {
+			t.Fatalf("batch blind index not filled: %+v", m)
+		}
+	}
+}
+
+func TestPlugin_UpdateMap(t *testing.T) {
+	db := newPluginTestDB(t)
+	values := map[string]interface{}{"phone": "13900139000"}
+	stmt := db.Model(&piiModel{ID: 1}).Updates(values).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if !IsEncrypted(values["phone"].(string)) {
+		t.Fatalf("map value not encrypted: %v", values)
+	}
+	if values["phone_bidx"] != BlindIndex("13900139000") {
+		t.Fatalf("map blind index not filled: %v", values)
+	}
+
+	stmt = db.Model(&piiModel{ID: 1}).UpdateColumn("email", "x@y.com").Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	found := false
+	for _, v := range stringVars(t, stmt) {
+		if v == "x@y.com" {
+			t.Fatalf("plain text written: %v", stmt.Vars)
+		}
+		if v == BlindIndex("x@y.com") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("update column blind index missing: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+}
+
+func TestPlugin_UpdateStruct(t *testing.T) {
+	db := newPluginTestDB(t)
+	stmt := db.Model(&piiModel{ID: 1}).Updates(piiModel{Phone: "13700137000"}).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	vars := strings.Join(stringVars(t, stmt), ",")
+	if !strings.Contains(vars, BlindIndex("13700137000")) {
+		t.Fatalf("blind index missing: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+	// 未更新的邮箱不应写入空索引
+	if strings.Contains(stmt.SQL.String(), "email_bidx") {
+		t.Fatalf("zero field index updated: %s", stmt.SQL.String())
+	}
+}
+
+func TestSerializer_Scan(t *testing.T) {
+	Setup(newTestKeyring(t, "a1", "a1"))
+	defer Setup(nil)
+	sch, err := schema.Parse(&piiModel{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := sch.LookUpField("phone")
+	c, _ := Default().Encrypt("13800138000")
+
+	m := &piiModel{}
+	if err = (Serializer{}).Scan(context.Background(), field, reflect.ValueOf(m).Elem(), []byte(c)); err != nil {
+		t.Fatal(err)
+	}
+	if m.Phone != "13800138000" {
+		t.Fatalf("scan = %q", m.Phone)
+	}
+	// 历史明文数据
+	if err = (Serializer{}).Scan(context.Background(), field, reflect.ValueOf(m).Elem(), "legacy"); err != nil || m.Phone != "legacy" {
+		t.Fatalf("scan plain = %q, %v", m.Phone, err)
+	}
+}
@@ -0,0 +1,157 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ReEncrypt 使用当前密钥重新加密表中的加密字段，并回填缺失的盲索引
+// 用于密钥轮换以及历史明文数据迁移，已是当前密钥加密且索引完整的行会跳过
+// 参数：
+//
+//	model ：带 `serializer:encrypted` 字段的实体，如 &entity.SysUser{}
+//	batchSize ：每批处理的行数
+//
+// 返回值：
+//
+//	int64 ：重新加密的行数
+func ReEncrypt(ctx context.Context, db *gorm.DB, model interface{}, batchSize int) (int64, error) {
+	kr := Default()
+	if !kr.Enabled() {
+		return 0, ErrCurrentKeyNotSet
+	}
+	return rewriteTable(ctx, db, kr, model, batchSize, true)
+}
+
+// BackfillBlindIndex 回填缺失或过期的盲索引，不修改加密字段
+// 不要求开启加密，只配置盲索引密钥时也可执行，用于已有数据在开启盲索引查询前的迁移
+// 参数：
+//
+//	model ：带 `blindindex` 标签字段的实体，如 &entity.SysUser{}
+//	batchSize ：每批处理的行数
+//
+// 返回值：
+//
+//	int64 ：更新的行数
+func BackfillBlindIndex(ctx context.Context, db *gorm.DB, model interface{}, batchSize int) (int64, error) {
+	kr := Default()
+	if !kr.IndexEnabled() {
+		return 0, ErrBlindIndexKeyNotSet
+	}
+	return rewriteTable(ctx, db, kr, model, batchSize, false)
+}
+
+// rewriteTable 按主键分批读取原始值并更新，reencrypt 为 false 时只更新盲索引
+func rewriteTable(ctx context.Context, db *gorm.DB, kr *Keyring, model interface{}, batchSize int, reencrypt bool) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, err
+	}
+	sch := stmt.Schema
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil {
+		return 0, fmt.Errorf("table %s has no primary key", sch.Table)
+	}
+	encFields := make([]*schema.Field, 0)
+	for _, field := range sch.Fields {
+		if isEncryptedField(field) && field.DBName != "" {
+			encFields = append(encFields, field)
+		}
+	}
+	pairs := indexPairs(sch)
+	if !reencrypt {
+		// 只回填盲索引时，只需读取作为索引来源的加密字段
+		sources := make([]*schema.Field, 0, len(pairs))
+		for _, pair := range pairs {
+			if isEncryptedField(pair.source) {
+				sources = append(sources, pair.source)
+			}
+		}
+		encFields = sources
+	}
+	if len(encFields) == 0 && len(pairs) == 0 {
+		return 0, nil
+	}
+	columns := []string{pk.DBName}
+	for _, field := range encFields {
+		columns = append(columns, field.DBName)
+	}
+	for _, pair := range pairs {
+		columns = append(columns, pair.index.DBName)
+		if !isEncryptedField(pair.source) {
+			columns = append(columns, pair.source.DBName)
+		}
+	}
+
+	var (
+		total int64
+		last  interface{}
+	)
+	for {
+		// 使用 Table 而非 Model 查询原始值，避免触发序列化器和各类插件
+		query := db.WithContext(ctx).Table(sch.Table).Select(columns).Order(pk.DBName).Limit(batchSize)
+		if last != nil {
+			query = query.Where(fmt.Sprintf("%s > ?", pk.DBName), last)
+		}
+		rows := make([]map[string]interface{}, 0, batchSize)
+		if err := query.Find(&rows).Error; err != nil {
+			return total, err
+		}
+		for _, row := range rows {
+			updates, err := rotateRow(kr, row, encFields, pairs, reencrypt)
+			if err != nil {
+				return total, fmt.Errorf("re-encrypt %s %v: %w", sch.Table, row[pk.DBName], err)
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			if err := db.WithContext(ctx).Table(sch.Table).
+				Where(fmt.Sprintf("%s = ?", pk.DBName), row[pk.DBName]).
+				UpdateColumns(updates).Error; err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(rows) < batchSize {
+			return total, nil
+		}
+		last = rows[len(rows)-1][pk.DBName]
+	}
+}
+
+// 计算单行需要更新的列
+func rotateRow(kr *Keyring, row map[string]interface{}, encFields []*schema.Field, pairs []indexPair, reencrypt bool) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	plains := make(map[string]string, len(encFields))
+	for _, field := range encFields {
+		raw := toString(row[field.DBName])
+		plain, err := kr.Decrypt(raw)
+		if err != nil {
+			return nil, err
+		}
+		plains[field.DBName] = plain
+		if reencrypt && kr.NeedsRotation(raw) {
+			encrypted, err := kr.Encrypt(plain)
+			if err != nil {
+				return nil, err
+			}
+			updates[field.DBName] = encrypted
+		}
+	}
+	for _, pair := range pairs {
+		plain, ok := plains[pair.source.DBName]
+		if !ok {
+			plain = toString(row[pair.source.DBName])
+		}
+		if index := kr.BlindIndex(plain); index != toString(row[pair.index.DBName]) {
+			updates[pair.index.DBName] = index
+		}
+	}
+	return updates, nil
+}
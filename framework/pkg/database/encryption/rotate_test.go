@@ -0,0 +1,74 @@
+package encryption
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newSqliteDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err = db.AutoMigrate(&piiModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func searchPhone(t *testing.T, db *gorm.DB, phone string, fuzzy bool) []int64 {
+	query, args := SearchCondition("phone", phone, fuzzy)
+	var ids []int64
+	if err := db.Model(&piiModel{}).Where(query, args...).Order("id").Pluck("id", &ids).Error; err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	return ids
+}
+
+func TestBackfillBlindIndex(t *testing.T) {
+	defer Setup(nil)
+	db := newSqliteDB(t)
+	// 未开启加密时写入的历史明文数据，没有盲索引
+	if err := db.Exec("INSERT INTO pii_models (id, phone, email) VALUES (1, '13800138000', 'a@b.com'), (2, '13900139000', '')").Error; err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchPhone(t, db, "3800", true); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("plain fuzzy search = %v", ids)
+	}
+	if _, err := BackfillBlindIndex(context.Background(), db, &piiModel{}, 1); err != ErrBlindIndexKeyNotSet {
+		t.Fatalf("backfill without key: %v", err)
+	}
+
+	// 只配置盲索引密钥：回填前历史数据仍可按明文查到
+	kr, err := NewKeyring(&configs.Encryption{BlindIndexKey: testKey('i')})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Setup(kr)
+	if ids := searchPhone(t, db, "13800138000", false); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("search before backfill = %v", ids)
+	}
+	n, err := BackfillBlindIndex(context.Background(), db, &piiModel{}, 1)
+	if err != nil || n != 2 {
+		t.Fatalf("backfill = %d, %v", n, err)
+	}
+	var m piiModel
+	db.Raw("SELECT * FROM pii_models WHERE id = 1").Scan(&m)
+	if m.PhoneIndex != BlindIndex("13800138000") || m.EmailIndex != BlindIndex("a@b.com") || m.Phone != "13800138000" {
+		t.Fatalf("row after backfill = %+v", m)
+	}
+	if ids := searchPhone(t, db, " 13800138000 ", false); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("search by index = %v", ids)
+	}
+	// 开启盲索引后只支持精确匹配
+	if ids := searchPhone(t, db, "3800", true); len(ids) != 0 {
+		t.Fatalf("fuzzy search with index = %v", ids)
+	}
+	if n, _ = BackfillBlindIndex(context.Background(), db, &piiModel{}, 10); n != 0 {
+		t.Fatalf("second backfill = %d", n)
+	}
+}
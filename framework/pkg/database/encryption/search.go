@@ -0,0 +1,24 @@
+package encryption
+
+import "fmt"
+
+// 盲索引列名后缀，与实体中 `blindindex` 标签字段的列名约定一致
+const blindIndexSuffix = "_bidx"
+
+// SearchCondition 构造加密字段的查询条件，盲索引列为 column + "_bidx"
+//   - 未配置盲索引密钥时按明文查询，fuzzy 为 true 时模糊匹配
+//   - 配置盲索引密钥后只能按盲索引精确匹配，不再支持模糊查询；盲索引尚未回填的历史行仍按明文查询
+//
+// 返回值可直接传给 QueryBuilder.WhereRaw 或 gorm 的 Where
+func SearchCondition(column, value string, fuzzy bool) (string, []interface{}) {
+	plainSQL, plainArg := column+" = ?", interface{}(value)
+	if fuzzy {
+		plainSQL, plainArg = column+" LIKE ?", "%"+value+"%"
+	}
+	index := Default().BlindIndex(value)
+	if index == "" {
+		return plainSQL, []interface{}{plainArg}
+	}
+	idx := column + blindIndexSuffix
+	return fmt.Sprintf("(%s = ? OR ((%s = '' OR %s IS NULL) AND %s))", idx, idx, idx, plainSQL), []interface{}{index, plainArg}
+}
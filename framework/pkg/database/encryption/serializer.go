@@ -0,0 +1,53 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName 加密序列化器名称，字段使用 `gorm:"serializer:encrypted"` 开启加密
+const SerializerName = "encrypted"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Serializer 字段加密序列化器，写入时使用全局密钥环加密，读取时解密
+type Serializer struct{}
+
+// Scan 读取并解密
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("invalid value type %T for encrypted field %s", dbValue, field.Name)
+	}
+	plain, err := Default().Decrypt(value)
+	if err != nil {
+		return fmt.Errorf("decrypt field %s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plain)
+}
+
+// Value 加密后写入
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	switch v := fieldValue.(type) {
+	case string:
+		return Default().Encrypt(v)
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		return Default().Encrypt(*v)
+	default:
+		return nil, fmt.Errorf("invalid field type %T for encrypted field %s, only string supported", fieldValue, field.Name)
+	}
+}
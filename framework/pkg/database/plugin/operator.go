@@ -1,10 +1,12 @@
 package plugin
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 const (
@@ -12,96 +14,135 @@ const (
 	Updater = "updater"
 )
 
+// OperatorPlugin 操作人插件，写入时自动填充创建人/更新人字段
 type OperatorPlugin struct{}
 
 func (t *OperatorPlugin) Name() string {
 	return "operator_plugin"
 }
 
-func NewOperatorPlugin() *TenantPlugin {
-	return &TenantPlugin{}
+func NewOperatorPlugin() *OperatorPlugin {
+	return &OperatorPlugin{}
 }
 
 func (t *OperatorPlugin) Initialize(db *gorm.DB) error {
-	if err := db.Callback().Create().Before("gorm:create").Register("user_tracking:before_create", t.beforeCreate); err != nil {
+	if err := db.Callback().Create().Before("gorm:create").Register("operator:before_create", t.beforeCreate); err != nil {
 		return err
 	}
-	if err := db.Callback().Update().Before("gorm:update").Register("user_tracking:before_update", t.beforeUpdate); err != nil {
+	if err := db.Callback().Update().Before("gorm:update").Register("operator:before_update", t.beforeUpdate); err != nil {
 		return err
 	}
 	return nil
 }
 
+// 创建前，填充创建人和更新人（已显式赋值的不覆盖）
 func (t *OperatorPlugin) beforeCreate(db *gorm.DB) {
-	ctx := db.Statement.Context
-	OperatorUserId := actx.GetUserId(ctx)
-	if db.Statement.Schema != nil && OperatorUserId != "" {
-		// 检查是否存在租户字段
-		field := db.Statement.Schema.FieldsByDBName[Creator]
+	operator := GetCtxOperator(db.Statement.Context)
+	if operator == "" || db.Statement.Schema == nil {
+		return
+	}
+	for _, name := range []string{Creator, Updater} {
+		field := db.Statement.Schema.LookUpField(name)
 		if field == nil {
-			// 表中没有租户字段，直接返回
-			return
+			// 表中没有该字段，跳过
+			continue
 		}
-		switch db.Statement.ReflectValue.Kind() {
-		case reflect.Slice, reflect.Array:
-			for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
-				rv := reflect.Indirect(db.Statement.ReflectValue.Index(i))
-				field1 := db.Statement.Schema.FieldsByDBName[Creator]
-				if field1 != nil {
-					err := field1.Set(ctx, rv, OperatorUserId)
-					if err != nil {
-						err = db.Statement.AddError(err)
-						if err != nil {
-							return
-						}
-					}
-				}
-
-			}
-		case reflect.Struct:
-			field := db.Statement.Schema.FieldsByDBName[Creator]
-			if field != nil {
-				db.Statement.SetColumn(Creator, OperatorUserId)
+		switch dest := db.Statement.Dest.(type) {
+		case map[string]interface{}:
+			fillMap(dest, field, operator)
+		case []map[string]interface{}:
+			for _, m := range dest {
+				fillMap(m, field, operator)
 			}
 		default:
-
+			fillValue(db, field, db.Statement.ReflectValue, operator)
 		}
 	}
 }
 
+// 更新前，覆盖更新人
 func (t *OperatorPlugin) beforeUpdate(db *gorm.DB) {
+	operator := GetCtxOperator(db.Statement.Context)
+	if operator == "" || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(Updater)
+	if field == nil {
+		// 表中没有更新人字段，直接返回
+		return
+	}
+	// 指定了 Select 的更新，需要把更新人加入更新列，否则会被忽略
+	if len(db.Statement.Selects) > 0 && !containsColumn(db.Statement.Selects, "*", field) {
+		db.Statement.Selects = append(db.Statement.Selects, field.DBName)
+	}
+	// 显式 Omit 更新人时尊重调用方
+	if containsColumn(db.Statement.Omits, "", field) {
+		return
+	}
+	// Dest 为 map（Updates(map)/Update/UpdateColumn）或结构体时，由 gorm 写入对应位置
+	db.Statement.SetColumn(field.DBName, operator, true)
+}
+
+// 设置结构体或结构体切片中的字段，仅在字段为空时填充
+func fillValue(db *gorm.DB, field *schema.Field, rv reflect.Value, operator string) {
 	ctx := db.Statement.Context
-	OperatorUserId := actx.GetUserId(ctx)
-	if db.Statement.Schema != nil && OperatorUserId != "" {
-		// 检查是否存在租户字段
-		field := db.Statement.Schema.FieldsByDBName[Updater]
-		if field == nil {
-			// 表中没有租户字段，直接返回
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fillValue(db, field, rv.Index(i), operator)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !rv.IsNil() {
+			fillValue(db, field, rv.Elem(), operator)
+		}
+	case reflect.Struct:
+		if _, isZero := field.ValueOf(ctx, rv); !isZero {
 			return
 		}
-		switch db.Statement.ReflectValue.Kind() {
-		case reflect.Slice, reflect.Array:
-			for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
-				rv := reflect.Indirect(db.Statement.ReflectValue.Index(i))
-				field1 := db.Statement.Schema.FieldsByDBName[Updater]
-				if field1 != nil {
-					err := field1.Set(ctx, rv, OperatorUserId)
-					if err != nil {
-						err = db.Statement.AddError(err)
-						if err != nil {
-							return
-						}
-					}
-				}
+		if !rv.CanAddr() {
+			_ = db.AddError(gorm.ErrInvalidValue)
+			return
+		}
+		_ = db.AddError(field.Set(ctx, rv, operator))
+	default:
+	}
+}
 
-			}
-		case reflect.Struct:
-			field := db.Statement.Schema.FieldsByDBName[Updater]
-			if field != nil {
-				db.Statement.SetColumn(Updater, OperatorUserId)
-			}
-		default:
+// 设置 map 中的字段，仅在未赋值时填充
+func fillMap(m map[string]interface{}, field *schema.Field, operator string) {
+	if v, ok := m[field.DBName]; ok && v != "" {
+		return
+	}
+	if v, ok := m[field.Name]; ok && v != "" {
+		return
+	}
+	delete(m, field.Name)
+	m[field.DBName] = operator
+}
 
+// 判断列集合中是否包含字段
+func containsColumn(columns []string, wildcard string, field *schema.Field) bool {
+	for _, c := range columns {
+		if (wildcard != "" && c == wildcard) || c == field.DBName || c == field.Name {
+			return true
 		}
 	}
+	return false
+}
+
+// OperatorIDNotNil 操作人id是否为空
+func OperatorIDNotNil(userId string) bool {
+	return userId != "" && userId != "<nil>"
+}
+
+// GetCtxOperator 获取当前操作人，系统上下文（定时任务、事件消费等无登录用户）返回空
+func GetCtxOperator(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	userId := actx.GetUserId(ctx)
+	if OperatorIDNotNil(userId) {
+		return userId
+	}
+	return ""
 }
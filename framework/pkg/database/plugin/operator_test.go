@@ -0,0 +1,209 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type operatorModel struct {
+	ID      int64  `gorm:"column:id;primaryKey"`
+	Name    string `gorm:"column:name"`
+	Creator string `gorm:"column:creator"`
+	Updater string `gorm:"column:updater"`
+}
+
+type plainModel struct {
+	ID   int64  `gorm:"column:id;primaryKey"`
+	Name string `gorm:"column:name"`
+}
+
+func newOperatorTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err = db.Use(NewOperatorPlugin()); err != nil {
+		t.Fatalf("use plugin: %v", err)
+	}
+	return db
+}
+
+func hasVar(stmt *gorm.Statement, v interface{}) bool {
+	for _, item := range stmt.Vars {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOperatorPlugin_Create(t *testing.T) {
+	db := newOperatorTestDB(t)
+	ctx := actx.WithUserId(context.Background(), "u1")
+
+	m := &operatorModel{ID: 1, Name: "a"}
+	if err := db.WithContext(ctx).Create(m).Error; err != nil {
+		t.Fatal(err)
+	}
+	if m.Creator != "u1" || m.Updater != "u1" {
+		t.Fatalf("creator/updater not filled: %+v", m)
+	}
+
+	// 已显式赋值的不覆盖
+	m = &operatorModel{ID: 2, Creator: "importer"}
+	if err := db.WithContext(ctx).Create(m).Error; err != nil {
+		t.Fatal(err)
+	}
+	if m.Creator != "importer" || m.Updater != "u1" {
+		t.Fatalf("explicit creator overwritten: %+v", m)
+	}
+}
+
+func TestOperatorPlugin_BatchCreate(t *testing.T) {
+	db := newOperatorTestDB(t)
+	ctx := actx.WithUserId(context.Background(), "u1")
+
+	list := []*operatorModel{{ID: 1}, {ID: 2}, {ID: 3}}
+	if err := db.WithContext(ctx).Create(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range list {
+		if m.Creator != "u1" || m.Updater != "u1" {
+			t.Fatalf("batch item not filled: %+v", m)
+		}
+	}
+
+	values := []operatorModel{{ID: 4}, {ID: 5}}
+	if err := db.WithContext(ctx).CreateInBatches(&values, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range values {
+		if m.Creator != "u1" {
+			t.Fatalf("batch value not filled: %+v", m)
+		}
+	}
+}
+
+func TestOperatorPlugin_CreateMap(t *testing.T) {
+	db := newOperatorTestDB(t)
+	ctx := actx.WithUserId(context.Background(), "u1")
+
+	values := map[string]interface{}{"id": 1, "name": "a"}
+	stmt := db.WithContext(ctx).Model(&operatorModel{}).Create(values).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if values[Creator] != "u1" || values[Updater] != "u1" {
+		t.Fatalf("map not filled: %v", values)
+	}
+}
+
+func TestOperatorPlugin_Update(t *testing.T) {
+	db := newOperatorTestDB(t)
+	ctx := actx.WithUserId(context.Background(), "u2")
+
+	// Save
+	m := &operatorModel{ID: 1, Name: "a", Creator: "u1", Updater: "u1"}
+	if err := db.WithContext(ctx).Save(m).Error; err != nil {
+		t.Fatal(err)
+	}
+	if m.Updater != "u2" || m.Creator != "u1" {
+		t.Fatalf("save not tracked: %+v", m)
+	}
+
+	// Updates(struct)
+	m = &operatorModel{ID: 1}
+	stmt := db.WithContext(ctx).Model(m).Updates(operatorModel{Name: "b"}).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if !hasVar(stmt, "u2") {
+		t.Fatalf("updates(struct) missing updater: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+
+	// Updates(map)
+	values := map[string]interface{}{"name": "c"}
+	stmt = db.WithContext(ctx).Model(&operatorModel{ID: 1}).Updates(values).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if !hasVar(stmt, "u2") {
+		t.Fatalf("updates(map) missing updater: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+
+	// Update
+	stmt = db.WithContext(ctx).Model(&operatorModel{ID: 1}).Update("name", "d").Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if !hasVar(stmt, "u2") {
+		t.Fatalf("update missing updater: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+
+	// UpdateColumn 跳过钩子，但仍记录更新人
+	stmt = db.WithContext(ctx).Model(&operatorModel{ID: 1}).UpdateColumn("name", "e").Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if !hasVar(stmt, "u2") {
+		t.Fatalf("update column missing updater: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+
+	// Select 指定列
+	stmt = db.WithContext(ctx).Model(&operatorModel{ID: 1}).Select("name").Updates(operatorModel{Name: "f"}).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if !hasVar(stmt, "u2") {
+		t.Fatalf("select update missing updater: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+
+	// Omit 更新人
+	stmt = db.WithContext(ctx).Model(&operatorModel{ID: 1}).Omit(Updater).Updates(map[string]interface{}{"name": "g"}).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if hasVar(stmt, "u2") {
+		t.Fatalf("omitted updater written: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+}
+
+func TestOperatorPlugin_SystemContext(t *testing.T) {
+	db := newOperatorTestDB(t)
+
+	m := &operatorModel{ID: 1}
+	if err := db.WithContext(context.Background()).Create(m).Error; err != nil {
+		t.Fatal(err)
+	}
+	if m.Creator != "" || m.Updater != "" {
+		t.Fatalf("system context should not fill operator: %+v", m)
+	}
+
+	stmt := db.WithContext(actx.WithUserId(context.Background(), "")).Model(&operatorModel{ID: 1}).
+		Updates(map[string]interface{}{"name": "a"}).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if len(stmt.Vars) != 2 {
+		t.Fatalf("system context should not write updater: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+}
+
+func TestOperatorPlugin_NoColumns(t *testing.T) {
+	db := newOperatorTestDB(t)
+	ctx := actx.WithUserId(context.Background(), "u1")
+
+	if err := db.WithContext(ctx).Create(&plainModel{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(ctx).Model(&plainModel{ID: 1}).Updates(map[string]interface{}{"name": "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
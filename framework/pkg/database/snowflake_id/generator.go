@@ -0,0 +1,159 @@
+package snowflake_id
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+)
+
+var (
+	ErrClockBackwards = errors.New("snowflake: clock moved backwards")
+	ErrInvalidLayout  = errors.New("snowflake: invalid bit layout")
+)
+
+// Layout 雪花ID位布局，时间戳位数为 63 - NodeBits - StepBits
+type Layout struct {
+	Epoch    time.Time // 起始时间
+	NodeBits uint8     // 机器ID位数
+	StepBits uint8     // 序列号位数
+	// MaxBackward 允许等待的时钟回拨，超过该值直接返回 ErrClockBackwards
+	MaxBackward time.Duration
+}
+
+// DefaultLayout 默认布局，与 NewSnowFlakeNode 保持一致，已生成的ID不受影响
+func DefaultLayout() Layout {
+	return Layout{
+		Epoch:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NodeBits:    10,
+		StepBits:    12,
+		MaxBackward: 5 * time.Millisecond,
+	}
+}
+
+// Validate 校验位布局
+func (l Layout) Validate() error {
+	if l.NodeBits == 0 || l.StepBits == 0 || int(l.NodeBits)+int(l.StepBits) > 31 {
+		return fmt.Errorf("%w: node bits %d, step bits %d", ErrInvalidLayout, l.NodeBits, l.StepBits)
+	}
+	if l.Epoch.After(time.Now()) {
+		return fmt.Errorf("%w: epoch %s is in the future", ErrInvalidLayout, l.Epoch)
+	}
+	return nil
+}
+
+// MaxNode 最大机器ID
+func (l Layout) MaxNode() int64 {
+	return -1 ^ (-1 << l.NodeBits)
+}
+
+// Generator 指定机器ID的雪花ID生成器
+type Generator struct {
+	mu       sync.Mutex
+	layout   Layout
+	epoch    int64 // 毫秒
+	node     int64
+	stepMask int64
+	last     int64 // 上一次生成的时间戳（相对 epoch 的毫秒数）
+	step     int64
+	now      func() int64
+}
+
+// NewGenerator 创建生成器
+// 参数：
+//
+//	layout ：位布局
+//	node ：机器ID
+//	lastMs ：该机器ID上一次生成ID的时间（unix 毫秒），用于防止重启后时钟回拨导致重复，未知时传 0
+func NewGenerator(layout Layout, node int64, lastMs int64) (*Generator, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if node < 0 || node > layout.MaxNode() {
+		return nil, fmt.Errorf("snowflake: node %d out of range [0, %d]", node, layout.MaxNode())
+	}
+	g := &Generator{
+		layout:   layout,
+		epoch:    layout.Epoch.UnixMilli(),
+		node:     node,
+		stepMask: -1 ^ (-1 << layout.StepBits),
+		now:      func() int64 { return time.Now().UnixMilli() },
+	}
+	if lastMs > g.epoch {
+		g.last = lastMs - g.epoch
+	}
+	return g, nil
+}
+
+// Node 机器ID
+func (g *Generator) Node() int64 {
+	return g.node
+}
+
+// LastMs 上一次生成ID的时间（unix 毫秒）
+func (g *Generator) LastMs() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.last + g.epoch
+}
+
+// Generate 生成ID
+func (g *Generator) Generate() (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now() - g.epoch
+	if now < g.last {
+		// 小幅回拨等待时钟追上，大幅回拨直接报错
+		backward := time.Duration(g.last-now) * time.Millisecond
+		if backward > g.layout.MaxBackward {
+			return 0, fmt.Errorf("%w: %s", ErrClockBackwards, backward)
+		}
+		time.Sleep(backward)
+		if now = g.now() - g.epoch; now < g.last {
+			return 0, fmt.Errorf("%w: %s", ErrClockBackwards, time.Duration(g.last-now)*time.Millisecond)
+		}
+	}
+	if now == g.last {
+		g.step = (g.step + 1) & g.stepMask
+		if g.step == 0 {
+			// 当前毫秒序列号用尽，等待下一毫秒
+			for now <= g.last {
+				time.Sleep(100 * time.Microsecond)
+				now = g.now() - g.epoch
+			}
+		}
+	} else {
+		g.step = 0
+	}
+	g.last = now
+	timeShift := g.layout.NodeBits + g.layout.StepBits
+	return now<<timeShift | g.node<<g.layout.StepBits | g.step, nil
+}
+
+// LayoutFromConfig 根据配置生成位布局，未配置的项使用默认值
+func LayoutFromConfig(conf *configs.Snowflake) (Layout, error) {
+	layout := DefaultLayout()
+	if conf == nil {
+		return layout, nil
+	}
+	if conf.Epoch != "" {
+		epoch, err := time.ParseInLocation("2006-01-02", conf.Epoch, time.UTC)
+		if err != nil {
+			return layout, fmt.Errorf("%w: epoch %s", ErrInvalidLayout, conf.Epoch)
+		}
+		layout.Epoch = epoch
+	}
+	if conf.NodeBits > 0 {
+		layout.NodeBits = conf.NodeBits
+	}
+	if conf.StepBits > 0 {
+		layout.StepBits = conf.StepBits
+	}
+	if conf.MaxBackward > 0 {
+		layout.MaxBackward = time.Duration(conf.MaxBackward) * time.Millisecond
+	}
+	return layout, layout.Validate()
+}
@@ -0,0 +1,208 @@
+package snowflake_id
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// LeasedIdGen 集群安全的雪花ID生成器
+//   - 机器ID通过 IWorkerIdStore 租用，后台定时续约
+//   - 续约失败超过租期后停止发号，租约被他人持有时自动重新申请
+//   - 新租约从该机器ID最后记录的时间继续，防止重启后时钟回拨导致ID重复
+type LeasedIdGen struct {
+	store  IWorkerIdStore
+	layout Layout
+	owner  string
+	ttl    time.Duration
+
+	mu         sync.RWMutex
+	gen        *Generator
+	validUntil time.Time
+
+	reacquire chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewLeasedIdGen 创建集群安全的ID生成器
+// 参数：
+//
+//	store ：租约存储
+//	layout ：位布局
+//	ttl ：租期，心跳间隔为租期的 1/3
+func NewLeasedIdGen(ctx context.Context, store IWorkerIdStore, layout Layout, ttl time.Duration) (*LeasedIdGen, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	hostname, _ := os.Hostname()
+	g := &LeasedIdGen{
+		store:     store,
+		layout:    layout,
+		owner:     fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), strconv.FormatInt(rand.Int63(), 36)),
+		ttl:       ttl,
+		reacquire: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	hctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	go g.heartbeat(hctx)
+	return g, nil
+}
+
+// WorkerId 当前持有的机器ID，未持有时返回 -1
+func (g *LeasedIdGen) WorkerId() int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.gen == nil {
+		return -1
+	}
+	return g.gen.Node()
+}
+
+// Generate 生成ID，租约失效时等待重新申请，最长等待一个租期
+func (g *LeasedIdGen) Generate() (int64, error) {
+	deadline := time.Now().Add(g.ttl)
+	for {
+		g.mu.RLock()
+		gen, valid := g.gen, time.Now().Before(g.validUntil)
+		g.mu.RUnlock()
+		if gen != nil && valid {
+			return gen.Generate()
+		}
+		select {
+		case g.reacquire <- struct{}{}:
+		default:
+		}
+		if time.Now().After(deadline) {
+			return 0, ErrLeaseLost
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (g *LeasedIdGen) GenStringId() string {
+	return strconv.FormatInt(g.GenInt64Id(), 10)
+}
+
+func (g *LeasedIdGen) GenInt64Id() int64 {
+	id, err := g.Generate()
+	if err != nil {
+		// 无法保证唯一性时不能继续发号
+		hlog.Errorf("snowflake generate id error: %v", err)
+		panic(err)
+	}
+	return id
+}
+
+// Close 停止心跳并释放租约
+func (g *LeasedIdGen) Close() {
+	g.cancel()
+	<-g.done
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.gen == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := g.store.Release(ctx, g.owner, g.gen.Node(), g.gen.LastMs()); err != nil {
+		hlog.Warnf("snowflake release worker id %d error: %v", g.gen.Node(), err)
+	}
+	g.gen = nil
+}
+
+func (g *LeasedIdGen) heartbeat(ctx context.Context) {
+	defer close(g.done)
+	ticker := time.NewTicker(g.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.renew(ctx)
+		case <-g.reacquire:
+			g.mu.RLock()
+			lost := g.gen == nil
+			g.mu.RUnlock()
+			if lost {
+				if err := g.acquire(ctx); err != nil {
+					hlog.Warnf("snowflake acquire worker id error: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// 续约，租约被他人持有时放弃当前机器ID并重新申请
+func (g *LeasedIdGen) renew(ctx context.Context) {
+	g.mu.RLock()
+	gen := g.gen
+	g.mu.RUnlock()
+	if gen == nil {
+		if err := g.acquire(ctx); err != nil {
+			hlog.Warnf("snowflake acquire worker id error: %v", err)
+		}
+		return
+	}
+	start := time.Now()
+	err := g.store.Renew(ctx, g.owner, gen.Node(), gen.LastMs(), g.ttl)
+	switch {
+	case err == nil:
+		g.mu.Lock()
+		if g.gen == gen {
+			g.validUntil = g.leaseDeadline(start)
+		}
+		g.mu.Unlock()
+	case errors.Is(err, ErrLeaseLost):
+		hlog.Warnf("snowflake worker id %d lease lost, re-acquiring", gen.Node())
+		g.mu.Lock()
+		if g.gen == gen {
+			g.gen = nil
+		}
+		g.mu.Unlock()
+		if err = g.acquire(ctx); err != nil {
+			hlog.Warnf("snowflake acquire worker id error: %v", err)
+		}
+	default:
+		// 存储暂不可用，租期内继续发号，超过租期后 Generate 会等待
+		hlog.Warnf("snowflake renew worker id %d error: %v", gen.Node(), err)
+	}
+}
+
+func (g *LeasedIdGen) acquire(ctx context.Context) error {
+	start := time.Now()
+	lease, err := g.store.Acquire(ctx, g.owner, g.layout.MaxNode(), g.ttl)
+	if err != nil {
+		return err
+	}
+	gen, err := NewGenerator(g.layout, lease.WorkerId, lease.LastMs)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.gen = gen
+	g.validUntil = g.leaseDeadline(start)
+	g.mu.Unlock()
+	hlog.Infof("snowflake acquired worker id %d", lease.WorkerId)
+	return nil
+}
+
+// 本地认为租约有效的截止时间，预留 1/5 租期应对各节点间的时钟误差
+func (g *LeasedIdGen) leaseDeadline(start time.Time) time.Time {
+	return start.Add(g.ttl - g.ttl/5)
+}
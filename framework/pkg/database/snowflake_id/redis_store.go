@@ -0,0 +1,91 @@
+package snowflake_id
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultWorkerKeyPrefix 默认租约 key 前缀，共用同一数据库的服务必须使用相同前缀
+const DefaultWorkerKeyPrefix = "snowflake:worker"
+
+var (
+	// KEYS[1] 租约 KEYS[2] 最后生成时间；ARGV[1] 持有者 ARGV[2] 租期(毫秒)
+	acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return tonumber(redis.call("GET", KEYS[2]) or "0")
+end
+return -1
+`)
+	// ARGV[3] 最后生成时间
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+if tonumber(ARGV[3]) > tonumber(redis.call("GET", KEYS[2]) or "0") then
+	redis.call("SET", KEYS[2], ARGV[3])
+end
+return 1
+`)
+	// ARGV[2] 最后生成时间
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[2]) > tonumber(redis.call("GET", KEYS[2]) or "0") then
+	redis.call("SET", KEYS[2], ARGV[2])
+end
+return redis.call("DEL", KEYS[1])
+`)
+)
+
+// RedisWorkerIdStore 基于 Redis 的机器ID租约存储
+type RedisWorkerIdStore struct {
+	rdb    *redis.Client
+	prefix string
+}
+
+func NewRedisWorkerIdStore(rdb *redis.Client, prefix string) *RedisWorkerIdStore {
+	if prefix == "" {
+		prefix = DefaultWorkerKeyPrefix
+	}
+	return &RedisWorkerIdStore{rdb: rdb, prefix: prefix}
+}
+
+func (s *RedisWorkerIdStore) keys(workerId int64) []string {
+	return []string{
+		fmt.Sprintf("%s:%d", s.prefix, workerId),
+		fmt.Sprintf("%s:%d:last", s.prefix, workerId),
+	}
+}
+
+func (s *RedisWorkerIdStore) Acquire(ctx context.Context, owner string, maxWorkerId int64, ttl time.Duration) (*WorkerLease, error) {
+	for _, id := range probeOrder(maxWorkerId) {
+		last, err := acquireScript.Run(ctx, s.rdb, s.keys(id), owner, ttl.Milliseconds()).Int64()
+		if err != nil {
+			return nil, err
+		}
+		if last >= 0 {
+			return &WorkerLease{WorkerId: id, LastMs: last}, nil
+		}
+	}
+	return nil, ErrNoWorkerAvailable
+}
+
+func (s *RedisWorkerIdStore) Renew(ctx context.Context, owner string, workerId int64, lastMs int64, ttl time.Duration) error {
+	ok, err := renewScript.Run(ctx, s.rdb, s.keys(workerId), owner, ttl.Milliseconds(), lastMs).Int64()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *RedisWorkerIdStore) Release(ctx context.Context, owner string, workerId int64, lastMs int64) error {
+	return releaseScript.Run(ctx, s.rdb, s.keys(workerId), owner, lastMs).Err()
+}
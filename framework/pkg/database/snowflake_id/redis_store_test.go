@@ -0,0 +1,102 @@
+package snowflake_id
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisWorkerIdStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return NewRedisWorkerIdStore(rdb, ""), mr
+}
+
+func TestRedisWorkerIdStore_Exhausted(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	ctx := context.Background()
+	seen := map[int64]bool{}
+	for i := 0; i < 4; i++ {
+		lease, err := store.Acquire(ctx, "node", 3, time.Minute)
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		if seen[lease.WorkerId] {
+			t.Fatalf("worker id %d acquired twice", lease.WorkerId)
+		}
+		seen[lease.WorkerId] = true
+	}
+	if _, err := store.Acquire(ctx, "other", 3, time.Minute); !errors.Is(err, ErrNoWorkerAvailable) {
+		t.Fatalf("acquire when exhausted: %v", err)
+	}
+
+	// 租约过期后可以重新申请
+	mr.FastForward(time.Minute)
+	if _, err := store.Acquire(ctx, "other", 3, time.Minute); err != nil {
+		t.Fatalf("acquire after expire: %v", err)
+	}
+}
+
+func TestRedisWorkerIdStore_RenewAfterTakeover(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	ctx := context.Background()
+	a, err := store.Acquire(ctx, "a", 0, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Renew(ctx, "a", a.WorkerId, 1000, time.Second); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+
+	// a 的租约过期后被 b 接管，b 拿到 a 最后记录的时间
+	mr.FastForward(2 * time.Second)
+	b, err := store.Acquire(ctx, "b", 0, time.Second)
+	if err != nil {
+		t.Fatalf("takeover: %v", err)
+	}
+	if b.WorkerId != a.WorkerId || b.LastMs != 1000 {
+		t.Fatalf("takeover lease = %+v", b)
+	}
+	if err = store.Renew(ctx, "a", a.WorkerId, 2000, time.Second); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("renew after takeover: %v", err)
+	}
+	if owner, _ := mr.Get(store.keys(a.WorkerId)[0]); owner != "b" {
+		t.Fatalf("owner = %q", owner)
+	}
+	if last, _ := mr.Get(store.keys(a.WorkerId)[1]); last != "1000" {
+		t.Fatalf("last = %q, stale renew must not update it", last)
+	}
+}
+
+func TestRedisWorkerIdStore_ReleaseNotOwned(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	ctx := context.Background()
+	lease, err := store.Acquire(ctx, "a", 0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := store.keys(lease.WorkerId)
+
+	if err = store.Release(ctx, "b", lease.WorkerId, 5000); err != nil {
+		t.Fatalf("release not owned: %v", err)
+	}
+	if owner, _ := mr.Get(keys[0]); owner != "a" || mr.Exists(keys[1]) {
+		t.Fatalf("release by another node changed the lease: owner=%q", owner)
+	}
+
+	if err = store.Release(ctx, "a", lease.WorkerId, 5000); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if mr.Exists(keys[0]) {
+		t.Fatal("lease should be deleted")
+	}
+	next, err := store.Acquire(ctx, "b", 0, time.Minute)
+	if err != nil || next.LastMs != 5000 {
+		t.Fatalf("acquire after release = %+v, %v", next, err)
+	}
+}
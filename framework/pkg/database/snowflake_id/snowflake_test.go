@@ -0,0 +1,236 @@
+package snowflake_id
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenerator_Layout(t *testing.T) {
+	layout := DefaultLayout()
+	layout.NodeBits, layout.StepBits = 5, 8
+	g, err := NewGenerator(layout, 31, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node := (id >> 8) & 31; node != 31 {
+		t.Fatalf("node bits = %d", node)
+	}
+	if _, err = NewGenerator(layout, 32, 0); err == nil {
+		t.Fatal("node out of range should fail")
+	}
+	layout.NodeBits, layout.StepBits = 20, 20
+	if _, err = NewGenerator(layout, 1, 0); !errors.Is(err, ErrInvalidLayout) {
+		t.Fatalf("invalid layout: %v", err)
+	}
+}
+
+func TestGenerator_ClockBackwards(t *testing.T) {
+	g, _ := NewGenerator(DefaultLayout(), 1, 0)
+	now := time.Now().UnixMilli()
+	g.now = func() int64 { return now }
+	first, _ := g.Generate()
+
+	// 大幅回拨直接报错
+	g.now = func() int64 { return now - 1000 }
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("expected clock backwards, got %v", err)
+	}
+
+	// 小幅回拨等待后继续
+	var calls int
+	g.now = func() int64 {
+		calls++
+		if calls == 1 {
+			return now - 2
+		}
+		return now + 1
+	}
+	id, err := g.Generate()
+	if err != nil || id <= first {
+		t.Fatalf("small backwards: id=%d first=%d err=%v", id, first, err)
+	}
+}
+
+func TestGenerator_LastMsGuard(t *testing.T) {
+	// 重启后本机时钟落后于上次记录的时间，不能生成更小的ID
+	future := time.Now().Add(time.Hour).UnixMilli()
+	g, _ := NewGenerator(DefaultLayout(), 1, future)
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("expected clock backwards, got %v", err)
+	}
+}
+
+func TestGenerator_StepOverflow(t *testing.T) {
+	layout := DefaultLayout()
+	layout.StepBits = 2
+	g, _ := NewGenerator(layout, 1, 0)
+	seen := make(map[int64]struct{})
+	for i := 0; i < 100; i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = struct{}{}
+	}
+}
+
+func TestLeasedIdGen_ConcurrentNodes(t *testing.T) {
+	const (
+		nodes   = 64
+		perNode = 2000
+	)
+	store := NewMemoryWorkerIdStore()
+	ctx := context.Background()
+
+	gens := make([]*LeasedIdGen, nodes)
+	var wg sync.WaitGroup
+	errs := make(chan error, nodes)
+	for i := 0; i < nodes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g, err := NewLeasedIdGen(ctx, store, DefaultLayout(), time.Second)
+			if err != nil {
+				errs <- err
+				return
+			}
+			gens[i] = g
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, g := range gens {
+			g.Close()
+		}
+	}()
+
+	workers := make(map[int64]struct{})
+	for _, g := range gens {
+		if _, ok := workers[g.WorkerId()]; ok {
+			t.Fatalf("worker id %d leased twice", g.WorkerId())
+		}
+		workers[g.WorkerId()] = struct{}{}
+	}
+
+	results := make([][]int64, nodes)
+	for i, g := range gens {
+		wg.Add(1)
+		go func(i int, g *LeasedIdGen) {
+			defer wg.Done()
+			ids := make([]int64, 0, perNode)
+			for j := 0; j < perNode; j++ {
+				ids = append(ids, g.GenInt64Id())
+			}
+			results[i] = ids
+		}(i, g)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]struct{}, nodes*perNode)
+	for _, ids := range results {
+		for _, id := range ids {
+			if _, ok := seen[id]; ok {
+				t.Fatalf("duplicate id %d", id)
+			}
+			seen[id] = struct{}{}
+		}
+	}
+}
+
+func TestLeasedIdGen_Exhausted(t *testing.T) {
+	layout := DefaultLayout()
+	layout.NodeBits = 2
+	store := NewMemoryWorkerIdStore()
+	ctx := context.Background()
+	gens := make([]*LeasedIdGen, 0, 4)
+	for i := 0; i < 4; i++ {
+		g, err := NewLeasedIdGen(ctx, store, layout, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gens = append(gens, g)
+	}
+	if _, err := NewLeasedIdGen(ctx, store, layout, time.Second); !errors.Is(err, ErrNoWorkerAvailable) {
+		t.Fatalf("expected no worker available, got %v", err)
+	}
+	// 释放后可以再次申请
+	gens[0].Close()
+	g, err := NewLeasedIdGen(ctx, store, layout, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+	for _, g := range gens[1:] {
+		g.Close()
+	}
+}
+
+func TestLeasedIdGen_Reacquire(t *testing.T) {
+	store := NewMemoryWorkerIdStore()
+	ctx := context.Background()
+	g, err := NewLeasedIdGen(ctx, store, DefaultLayout(), 300*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	before := g.GenInt64Id()
+	old := g.WorkerId()
+
+	// 模拟租约过期后被其他节点抢占
+	store.mu.Lock()
+	store.workers[old].owner = "other"
+	store.mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for g.WorkerId() == old || g.WorkerId() == -1 {
+		if time.Now().After(deadline) {
+			t.Fatal("worker id not re-acquired")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	after := g.GenInt64Id()
+	if after == before {
+		t.Fatal("duplicate id after re-acquire")
+	}
+}
+
+func TestLeasedIdGen_LastMsCarriedOver(t *testing.T) {
+	layout := DefaultLayout()
+	layout.NodeBits = 1
+	store := NewMemoryWorkerIdStore()
+	ctx := context.Background()
+
+	g1, _ := NewLeasedIdGen(ctx, store, layout, time.Second)
+	g2, _ := NewLeasedIdGen(ctx, store, layout, time.Second)
+	id := g1.GenInt64Id()
+	worker := g1.WorkerId()
+	g1.Close()
+
+	// 新持有者从上次记录的时间继续
+	g3, err := NewLeasedIdGen(ctx, store, layout, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g3.Close()
+	defer g2.Close()
+	if g3.WorkerId() != worker {
+		t.Fatalf("expected worker %d, got %d", worker, g3.WorkerId())
+	}
+	if next := g3.GenInt64Id(); next <= id {
+		t.Fatalf("id went backwards: %d <= %d", next, id)
+	}
+}
@@ -0,0 +1,305 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+)
+
+var (
+	// ErrBusClosed 事件总线已关闭
+	ErrBusClosed = errors.New("event bus closed")
+	// ErrHandlerTimeout 处理器执行超时
+	ErrHandlerTimeout = errors.New("event handler timeout")
+	// ErrHandlerPanic 处理器发生 panic
+	ErrHandlerPanic = errors.New("event handler panic")
+)
+
+// subscription 订阅
+type subscription struct {
+	pattern       string
+	handler       EventHandler
+	mode          DeliveryMode
+	priority      int
+	retries       int
+	retryInterval time.Duration
+	timeout       time.Duration
+	afterCommit   bool
+	queueSize     int
+	queue         chan delivery
+}
+
+// delivery 异步投递的事件
+type delivery struct {
+	ctx   context.Context
+	event Event
+}
+
+// AsyncEventBus 进程内事件总线，支持同步/异步投递、优先级、通配符订阅、重试、超时和事务提交后投递。
+// 处理器之间相互隔离：一个处理器失败或 panic 不影响其他处理器
+type AsyncEventBus struct {
+	mu   sync.RWMutex
+	subs []*subscription // 按优先级降序
+
+	// closeMu 保护关闭状态和异步队列的写入
+	closeMu sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewAsyncEventBus 创建进程内事件总线
+func NewAsyncEventBus() *AsyncEventBus {
+	return &AsyncEventBus{}
+}
+
+// Subscribe 同步订阅事件，实现 IEventBus
+func (bus *AsyncEventBus) Subscribe(eventName string, handler EventHandler) error {
+	return bus.SubscribeWith(eventName, handler)
+}
+
+// SubscribeWith 订阅事件，pattern 按 "." 分段匹配事件名称，"*" 匹配一段，"**" 匹配任意多段，如 "user.*"
+func (bus *AsyncEventBus) SubscribeWith(pattern string, handler EventHandler, opts ...SubscribeOption) error {
+	if pattern == "" || handler == nil {
+		return fmt.Errorf("subscribe %q: pattern and handler are required", pattern)
+	}
+	sub := &subscription{pattern: pattern, handler: handler, queueSize: defaultQueueSize}
+	for _, opt := range opts {
+		opt(sub)
+	}
+
+	bus.closeMu.RLock()
+	defer bus.closeMu.RUnlock()
+	if bus.closed {
+		return ErrBusClosed
+	}
+	if sub.mode == DeliveryAsync {
+		sub.queue = make(chan delivery, sub.queueSize)
+		bus.wg.Add(1)
+		go bus.work(sub)
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	// 按优先级插入，相同优先级保持订阅顺序
+	i := sort.Search(len(bus.subs), func(i int) bool {
+		return bus.subs[i].priority < sub.priority
+	})
+	bus.subs = append(bus.subs, nil)
+	copy(bus.subs[i+1:], bus.subs[i:])
+	bus.subs[i] = sub
+	return nil
+}
+
+// Publish 发布事件，返回同步处理器的汇总错误和异步投递入队失败的错误
+func (bus *AsyncEventBus) Publish(ctx context.Context, event Event) error {
+	bus.mu.RLock()
+	matched := make([]*subscription, 0, len(bus.subs))
+	later := make([]*subscription, 0)
+	for _, sub := range bus.subs {
+		if !matchEventName(sub.pattern, event.EventName()) {
+			continue
+		}
+		if sub.afterCommit {
+			later = append(later, sub)
+		}
+		matched = append(matched, sub)
+	}
+	bus.mu.RUnlock()
+
+	// 事务中发布的事件延迟到提交后投递，提交时已没有调用方接收错误，只记录日志
+	if len(later) > 0 && database.AfterCommit(ctx, func(ctx context.Context) {
+		if err := bus.dispatch(ctx, event, later); err != nil {
+			hlog.CtxErrorf(ctx, "deliver event %s after commit failed: %v", event.EventName(), err)
+		}
+	}) {
+		now := matched[:0]
+		for _, sub := range matched {
+			if !sub.afterCommit {
+				now = append(now, sub)
+			}
+		}
+		matched = now
+	}
+	return bus.dispatch(ctx, event, matched)
+}
+
+// Close 停止接收新事件，等待异步队列中的事件处理完成或 ctx 结束
+func (bus *AsyncEventBus) Close(ctx context.Context) error {
+	bus.closeMu.Lock()
+	if !bus.closed {
+		bus.closed = true
+		bus.mu.RLock()
+		for _, sub := range bus.subs {
+			if sub.queue != nil {
+				close(sub.queue)
+			}
+		}
+		bus.mu.RUnlock()
+	}
+	bus.closeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		bus.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (bus *AsyncEventBus) dispatch(ctx context.Context, event Event, subs []*subscription) error {
+	var errs []error
+	for _, sub := range subs {
+		var err error
+		if sub.mode == DeliveryAsync {
+			err = bus.enqueue(ctx, sub, event)
+		} else {
+			err = sub.handle(ctx, event)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// enqueue 放入异步队列，异步处理不受发布者 ctx 取消的影响
+func (bus *AsyncEventBus) enqueue(ctx context.Context, sub *subscription, event Event) error {
+	bus.closeMu.RLock()
+	defer bus.closeMu.RUnlock()
+	if bus.closed {
+		return ErrBusClosed
+	}
+	select {
+	case sub.queue <- delivery{ctx: context.WithoutCancel(ctx), event: event}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("enqueue event %s to %s: %w", event.EventName(), sub.name(), ctx.Err())
+	}
+}
+
+// work 异步订阅的工作协程，按入队顺序逐个处理
+func (bus *AsyncEventBus) work(sub *subscription) {
+	defer bus.wg.Done()
+	for d := range sub.queue {
+		if err := sub.handle(d.ctx, d.event); err != nil {
+			hlog.CtxErrorf(d.ctx, "async event handler failed: %v", err)
+		}
+	}
+}
+
+// handle 执行处理器，失败后按配置重试
+func (s *subscription) handle(ctx context.Context, event Event) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var pending <-chan error
+		if pending, err = s.invoke(ctx, event); err == nil {
+			return nil
+		}
+		if attempt >= s.retries {
+			break
+		}
+		// 超时的执行仍在运行，等待其返回后再重试，避免同一事件被并发处理
+		if pending != nil {
+			select {
+			case <-pending:
+			case <-ctx.Done():
+				return fmt.Errorf("event %s handler %s: %w", event.EventName(), s.name(), errors.Join(err, ctx.Err()))
+			}
+		}
+		if s.retryInterval > 0 {
+			timer := time.NewTimer(s.retryInterval)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("event %s handler %s: %w", event.EventName(), s.name(), errors.Join(err, ctx.Err()))
+			}
+		}
+	}
+	return fmt.Errorf("event %s handler %s: %w", event.EventName(), s.name(), err)
+}
+
+// invoke 单次执行，隔离 panic 并控制超时
+// 超时后不再等待处理器，返回的 pending 在处理器实际返回时可读，未超时时为 nil
+func (s *subscription) invoke(ctx context.Context, event Event) (pending <-chan error, err error) {
+	if s.timeout <= 0 {
+		return nil, safeHandle(ctx, s.handler, event)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- safeHandle(ctx, s.handler, event)
+	}()
+	select {
+	case err = <-done:
+		return nil, err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return done, fmt.Errorf("%w after %s", ErrHandlerTimeout, s.timeout)
+		}
+		return done, ctx.Err()
+	}
+}
+
+func (s *subscription) name() string {
+	return fmt.Sprintf("%T(%s)", s.handler, s.pattern)
+}
+
+func safeHandle(ctx context.Context, handler EventHandler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			hlog.CtxErrorf(ctx, "event %s handler panic: %v\n%s", event.EventName(), r, debug.Stack())
+			err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+		}
+	}()
+	return handler.Handle(ctx, event)
+}
+
+// matchEventName 按 "." 分段匹配，"*" 匹配一段，"**" 匹配任意多段
+func matchEventName(pattern, name string) bool {
+	if pattern == name {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "."), strings.Split(name, "."))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "**":
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(name) == 0 {
+				return false
+			}
+		default:
+			if len(name) == 0 || pattern[0] != name[0] {
+				return false
+			}
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,216 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type testEvent struct {
+	BaseEvent
+}
+
+func newTestEvent(name string) *testEvent {
+	return &testEvent{BaseEvent: NewBaseEvent(name)}
+}
+
+type funcHandler func(ctx context.Context, event Event) error
+
+func (f funcHandler) Handle(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+func TestMatchEventName(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"user.created", "user.created", true},
+		{"user.*", "user.created", true},
+		{"user.*", "user", false},
+		{"user.*", "user.role.changed", false},
+		{"user.**", "user.role.changed", true},
+		{"*.created", "role.created", true},
+		{"**", "department.user.assigned", true},
+		{"role.*", "user.created", false},
+	}
+	for _, c := range cases {
+		if got := matchEventName(c.pattern, c.name); got != c.want {
+			t.Errorf("matchEventName(%q, %q) = %v", c.pattern, c.name, got)
+		}
+	}
+}
+
+func TestAsyncEventBus_SyncPriorityAndErrors(t *testing.T) {
+	bus := NewAsyncEventBus()
+	var order []string
+	record := func(name string, err error) EventHandler {
+		return funcHandler(func(ctx context.Context, event Event) error {
+			order = append(order, name)
+			return err
+		})
+	}
+	errLow := errors.New("low failed")
+	_ = bus.SubscribeWith("user.*", record("low", errLow), WithPriority(-1))
+	_ = bus.Subscribe("user.created", record("default", nil))
+	_ = bus.SubscribeWith("user.created", funcHandler(func(ctx context.Context, event Event) error {
+		order = append(order, "panic")
+		panic("boom")
+	}), WithPriority(5))
+	_ = bus.SubscribeWith("user.created", record("high", nil), WithPriority(10))
+	_ = bus.Subscribe("role.created", record("other", nil))
+
+	err := bus.Publish(context.Background(), newTestEvent("user.created"))
+	if !errors.Is(err, errLow) || !errors.Is(err, ErrHandlerPanic) {
+		t.Fatalf("Publish error = %v", err)
+	}
+	want := []string{"high", "panic", "default", "low"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v", order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestAsyncEventBus_RetryAndTimeout(t *testing.T) {
+	bus := NewAsyncEventBus()
+	calls := 0
+	_ = bus.SubscribeWith("order.paid", funcHandler(func(ctx context.Context, event Event) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	}), WithRetry(2, time.Millisecond))
+	if err := bus.Publish(context.Background(), newTestEvent("order.paid")); err != nil || calls != 3 {
+		t.Fatalf("Publish = %v, calls = %d", err, calls)
+	}
+
+	_ = bus.SubscribeWith("order.slow", funcHandler(func(ctx context.Context, event Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), WithTimeout(10*time.Millisecond))
+	if err := bus.Publish(context.Background(), newTestEvent("order.slow")); !errors.Is(err, ErrHandlerTimeout) {
+		t.Fatalf("Publish = %v", err)
+	}
+
+	// 超时的执行返回前不会开始重试
+	var running, overlapped, attempts atomic.Int32
+	_ = bus.SubscribeWith("order.stuck", funcHandler(func(ctx context.Context, event Event) error {
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		defer running.Add(-1)
+		attempts.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		return errors.New("still failing")
+	}), WithTimeout(5*time.Millisecond), WithRetry(2, 0))
+	if err := bus.Publish(context.Background(), newTestEvent("order.stuck")); !errors.Is(err, ErrHandlerTimeout) {
+		t.Fatalf("Publish = %v", err)
+	}
+	if attempts.Load() != 3 || overlapped.Load() != 0 {
+		t.Fatalf("attempts = %d, overlapped = %d", attempts.Load(), overlapped.Load())
+	}
+}
+
+func TestAsyncEventBus_AsyncOrderedAndIsolated(t *testing.T) {
+	bus := NewAsyncEventBus()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var got []int64
+	_ = bus.SubscribeWith("user.*", funcHandler(func(ctx context.Context, event Event) error {
+		<-release
+		return nil
+	}), WithAsync())
+	_ = bus.SubscribeWith("user.*", funcHandler(func(ctx context.Context, event Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, event.EventTime())
+		return nil
+	}), WithAsync())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var want []int64
+	for i := 0; i < 10; i++ {
+		event := newTestEvent("user.updated")
+		want = append(want, event.EventTime())
+		// 慢处理器不阻塞发布者
+		if err := bus.Publish(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 发布者的 ctx 取消不影响已入队的事件
+	cancel()
+	close(release)
+
+	closeCtx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+	if err := bus.Close(closeCtx); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events", len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events out of order: %v", got)
+		}
+	}
+	if err := bus.Publish(context.Background(), newTestEvent("user.updated")); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Publish after close = %v", err)
+	}
+}
+
+func TestIEventBus_SubscribeWithAfterCommit(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := database.NewData(nil, db, &configs.Data{})
+
+	var bus IEventBus = NewAsyncEventBus()
+	delivered := 0
+	if err = bus.SubscribeWith("user.updated", funcHandler(func(ctx context.Context, event Event) error {
+		delivered++
+		return nil
+	}), WithAfterCommit()); err != nil {
+		t.Fatal(err)
+	}
+
+	err = data.InTx(context.Background(), func(ctx context.Context) error {
+		if err := bus.Publish(ctx, newTestEvent("user.updated")); err != nil {
+			return err
+		}
+		if delivered != 0 {
+			t.Fatal("delivered before commit")
+		}
+		return nil
+	})
+	if err != nil || delivered != 1 {
+		t.Fatalf("after commit: delivered = %d, err = %v", delivered, err)
+	}
+
+	// 回滚时丢弃
+	_ = data.InTx(context.Background(), func(ctx context.Context) error {
+		_ = bus.Publish(ctx, newTestEvent("user.updated"))
+		return errors.New("rollback")
+	})
+	if delivered != 1 {
+		t.Fatalf("after rollback: delivered = %d", delivered)
+	}
+
+	if err = NewEventBus().SubscribeWith("user.updated", funcHandler(nil), WithAsync()); err == nil {
+		t.Fatal("DefEventBus must reject subscribe options")
+	}
+}
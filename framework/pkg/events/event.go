@@ -2,6 +2,7 @@ package events
 
 import (
 	"context"
+	"fmt"
 	"sync"
 )
 
@@ -26,6 +27,14 @@ func (bus *DefEventBus) Subscribe(eventName string, handler EventHandler) error
 	return nil
 }
 
+// SubscribeWith 订阅事件，DefEventBus 只支持按名称同步投递，传入选项时返回错误，需要选项时使用 AsyncEventBus
+func (bus *DefEventBus) SubscribeWith(eventName string, handler EventHandler, opts ...SubscribeOption) error {
+	if len(opts) > 0 {
+		return fmt.Errorf("subscribe %q: DefEventBus does not support subscribe options", eventName)
+	}
+	return bus.Subscribe(eventName, handler)
+}
+
 // Publish 发布事件
 func (bus *DefEventBus) Publish(ctx context.Context, event Event) error {
 	bus.mu.RLock()
@@ -19,6 +19,8 @@ type EventHandler interface {
 type IEventBus interface {
 	// Subscribe 订阅事件
 	Subscribe(eventName string, handler EventHandler) error
+	// SubscribeWith 按选项订阅事件，如异步投递、重试、事务提交后投递
+	SubscribeWith(pattern string, handler EventHandler, opts ...SubscribeOption) error
 	// Publish 发布事件
 	Publish(ctx context.Context, event Event) error
 }
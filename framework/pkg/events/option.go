@@ -0,0 +1,68 @@
+package events
+
+import "time"
+
+// DeliveryMode 投递方式
+type DeliveryMode int
+
+const (
+	// DeliverySync 在发布者的协程中按优先级依次执行，所有处理器都会执行，错误汇总后返回
+	DeliverySync DeliveryMode = iota
+	// DeliveryAsync 由订阅独立的工作协程按发布顺序执行，不阻塞发布者
+	DeliveryAsync
+)
+
+// 异步订阅默认队列长度
+const defaultQueueSize = 256
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(*subscription)
+
+// WithMode 设置投递方式，默认 DeliverySync
+func WithMode(mode DeliveryMode) SubscribeOption {
+	return func(s *subscription) {
+		s.mode = mode
+	}
+}
+
+// WithAsync 异步投递
+func WithAsync() SubscribeOption {
+	return WithMode(DeliveryAsync)
+}
+
+// WithPriority 设置优先级，数值越大越先执行，相同优先级按订阅顺序执行
+func WithPriority(priority int) SubscribeOption {
+	return func(s *subscription) {
+		s.priority = priority
+	}
+}
+
+// WithRetry 处理失败后按固定间隔重试 times 次
+func WithRetry(times int, interval time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		s.retries = times
+		s.retryInterval = interval
+	}
+}
+
+// WithTimeout 单次处理超时时间，超时后放弃等待并返回 ErrHandlerTimeout，处理器应响应 ctx 的取消
+// 配置了重试时，等超时的执行返回后才会重试，不响应取消的处理器会推迟重试
+func WithTimeout(timeout time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		s.timeout = timeout
+	}
+}
+
+// WithAfterCommit 在 database.InTx 开启的事务中发布时，事务提交后才投递，回滚时丢弃；不在事务中时立即投递
+func WithAfterCommit() SubscribeOption {
+	return func(s *subscription) {
+		s.afterCommit = true
+	}
+}
+
+// WithQueueSize 异步投递的队列长度，队列满时发布者等待
+func WithQueueSize(size int) SubscribeOption {
+	return func(s *subscription) {
+		s.queueSize = size
+	}
+}
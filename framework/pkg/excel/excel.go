@@ -2,6 +2,7 @@ package excel
 
 import (
 	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 	"github.com/xuri/excelize/v2"
 	"reflect"
 	"strconv"
@@ -204,6 +205,29 @@ func (e *ExcelExporter) WriteRows(data interface{}) error {
 	return nil
 }
 
+// AddSheet 新增工作表并切换到该工作表，之后的写入从新工作表的第一行开始
+func (e *ExcelExporter) AddSheet(sheet string) error {
+	if _, err := e.file.NewSheet(sheet); err != nil {
+		return err
+	}
+	e.sheet = sheet
+	e.rowIndex = 1
+	return nil
+}
+
+// WriteValues 按顺序写入一行值，不使用列配置，用于表头不固定的导出
+func (e *ExcelExporter) WriteValues(values ...interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, e.rowIndex)
+	if err != nil {
+		return err
+	}
+	if err := e.file.SetSheetRow(e.sheet, cell, &values); err != nil {
+		return err
+	}
+	e.rowIndex++
+	return nil
+}
+
 // Save 保存文件
 func (e *ExcelExporter) Save(filename string) error {
 	return e.file.SaveAs(filename)
@@ -243,6 +267,11 @@ func (e *ExcelExporter) formatValue(v reflect.Value, format string) interface{}
 		if v.Kind() == reflect.Float64 {
 			return fmt.Sprintf("%.2f%%", v.Float()*100)
 		}
+	case "mask_phone", "mask_email", "mask_name":
+		// 敏感字段脱敏导出
+		if v.Kind() == reflect.String {
+			return utils.Mask(strings.TrimPrefix(format, "mask_"), v.String())
+		}
 	}
 
 	// 默认返回原始值
@@ -0,0 +1,51 @@
+package excel
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExcelImporter Excel导入器
+type ExcelImporter struct {
+	file *excelize.File
+}
+
+// NewExcelImporter 从读取器打开Excel文件
+func NewExcelImporter(r io.Reader) (*ExcelImporter, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("打开Excel文件失败: %w", err)
+	}
+	return &ExcelImporter{file: file}, nil
+}
+
+// Sheets 获取所有工作表名称
+func (i *ExcelImporter) Sheets() []string {
+	return i.file.GetSheetList()
+}
+
+// HasSheet 是否存在工作表
+func (i *ExcelImporter) HasSheet(sheet string) bool {
+	idx, err := i.file.GetSheetIndex(sheet)
+	return err == nil && idx >= 0
+}
+
+// ReadRows 读取工作表的所有行
+// 单元格为原始值，不应用数字格式；每行末尾的空单元格会被省略，行的长度可能不同
+func (i *ExcelImporter) ReadRows(sheet string) ([][]string, error) {
+	if !i.HasSheet(sheet) {
+		return nil, fmt.Errorf("工作表不存在: %s", sheet)
+	}
+	rows, err := i.file.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("读取工作表失败: %w", err)
+	}
+	return rows, nil
+}
+
+// Close 关闭文件
+func (i *ExcelImporter) Close() error {
+	return i.file.Close()
+}
@@ -0,0 +1,55 @@
+package formula
+
+import "sync"
+
+// DefaultCacheSize 默认缓存的表达式数量
+const DefaultCacheSize = 1024
+
+// Cache 编译结果缓存，按表达式源码缓存编译后的语法树
+type Cache struct {
+	mu    sync.RWMutex
+	size  int
+	items map[string]*Expression
+}
+
+// NewCache 创建编译结果缓存，size 不大于0时使用默认大小
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &Cache{size: size, items: make(map[string]*Expression)}
+}
+
+// Compile 获取编译后的表达式，未缓存时编译并缓存
+// 编译失败的表达式不会缓存
+func (c *Cache) Compile(source string) (*Expression, error) {
+	c.mu.RLock()
+	expr, ok := c.items[source]
+	c.mu.RUnlock()
+	if ok {
+		return expr, nil
+	}
+
+	expr, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.items) >= c.size {
+		// 缓存已满时随机淘汰一个，公式修改后旧的语法树不会再被使用
+		for key := range c.items {
+			delete(c.items, key)
+			break
+		}
+	}
+	c.items[source] = expr
+	return expr, nil
+}
+
+// Len 缓存的表达式数量
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
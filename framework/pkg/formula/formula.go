@@ -0,0 +1,249 @@
+// Package formula 规则公式表达式
+//
+// 支持四则运算和取模、比较、逻辑运算、字符串和日期字面量、变量引用和内置函数，
+// 数字使用 big.Rat 精确计算，适合金额计算。
+package formula
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// 表达式错误
+var (
+	ErrSyntax        = errors.New("公式语法错误")
+	ErrType          = errors.New("公式类型错误")
+	ErrUndefinedVar  = errors.New("公式变量未定义")
+	ErrDivideByZero  = errors.New("公式除数为零")
+	ErrInvalidVarDef = errors.New("公式变量定义错误")
+)
+
+// Expression 编译后的表达式，可以并发计算
+type Expression struct {
+	source string
+	root   node
+	vars   []string
+}
+
+// Compile 编译表达式，语法错误、函数不存在或参数个数不正确时返回错误
+func Compile(source string) (*Expression, error) {
+	root, vars, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(vars)
+	return &Expression{source: source, root: root, vars: vars}, nil
+}
+
+// MustCompile 编译表达式，失败时 panic
+func MustCompile(source string) *Expression {
+	expr, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
+
+// String 返回表达式源码
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Variables 返回表达式引用的变量，按名称排序
+func (e *Expression) Variables() []string {
+	return e.vars
+}
+
+// Eval 使用上下文数据计算表达式
+func (e *Expression) Eval(data map[string]interface{}) (Value, error) {
+	return e.EvalWithVars(data, nil)
+}
+
+// EvalWithVars 使用上下文数据和变量定义计算表达式
+// 定义了的变量按定义的来源和类型取值，其余变量直接从上下文数据中按路径取值
+func (e *Expression) EvalWithVars(data map[string]interface{}, defs VarDefs) (Value, error) {
+	return e.root.eval(&env{data: data, defs: defs, cache: make(map[string]Value)})
+}
+
+// env 计算环境
+type env struct {
+	data  map[string]interface{}
+	defs  VarDefs
+	cache map[string]Value
+}
+
+// lookup 获取变量的值，同一次计算中变量只解析一次
+func (en *env) lookup(name string) (Value, error) {
+	if v, ok := en.cache[name]; ok {
+		return v, nil
+	}
+	var (
+		v   Value
+		err error
+	)
+	if def, ok := en.defs[name]; ok {
+		v, err = def.resolve(name, en.data)
+	} else {
+		v, err = FromNative(lookupPath(en.data, name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("变量 %s: %w", name, err)
+	}
+	en.cache[name] = v
+	return v, nil
+}
+
+// lookupPath 按 a.b.c 形式的路径从数据中取值，优先匹配完整的键
+func lookupPath(data map[string]interface{}, path string) interface{} {
+	if v, ok := data[path]; ok {
+		return v
+	}
+	current := data
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		v, ok := current[part]
+		if !ok {
+			return nil
+		}
+		if i == len(parts)-1 {
+			return v
+		}
+		next, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return nil
+}
+
+func (n *variableNode) eval(en *env) (Value, error) {
+	return en.lookup(n.name)
+}
+
+func (n *unaryNode) eval(en *env) (Value, error) {
+	v, err := n.operand.eval(en)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !truthy(v), nil
+	}
+	r, err := toNumber(v)
+	if err != nil {
+		return nil, fmt.Errorf("-%s: %w", typeName(v), err)
+	}
+	return new(big.Rat).Neg(r), nil
+}
+
+func (n *binaryNode) eval(en *env) (Value, error) {
+	left, err := n.left.eval(en)
+	if err != nil {
+		return nil, err
+	}
+	// 逻辑运算短路求值
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(en)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(en)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := n.right.eval(en)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case ">", ">=", "<", "<=":
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case ">":
+			return c > 0, nil
+		case ">=":
+			return c >= 0, nil
+		case "<":
+			return c < 0, nil
+		default:
+			return c <= 0, nil
+		}
+	case "+":
+		// 两侧都是字符串且不是数字时拼接
+		if ls, ok := left.(string); ok {
+			if rs, ok := right.(string); ok {
+				if _, lok := newNumber(ls); !lok {
+					return ls + rs, nil
+				}
+				if _, rok := newNumber(rs); !rok {
+					return ls + rs, nil
+				}
+			}
+		}
+	}
+	return arithmetic(n.op, left, right)
+}
+
+// arithmetic 数字运算，结果为精确值
+func arithmetic(op string, left, right Value) (Value, error) {
+	a, err := toNumber(left)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s %s: %w", typeName(left), op, typeName(right), err)
+	}
+	b, err := toNumber(right)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s %s: %w", typeName(left), op, typeName(right), err)
+	}
+	switch op {
+	case "+":
+		return new(big.Rat).Add(a, b), nil
+	case "-":
+		return new(big.Rat).Sub(a, b), nil
+	case "*":
+		return new(big.Rat).Mul(a, b), nil
+	case "/":
+		if b.Sign() == 0 {
+			return nil, ErrDivideByZero
+		}
+		return new(big.Rat).Quo(a, b), nil
+	case "%":
+		if b.Sign() == 0 {
+			return nil, ErrDivideByZero
+		}
+		// a - b * trunc(a / b)，符号与被除数一致
+		q := new(big.Rat).Quo(a, b)
+		t := new(big.Int).Quo(q.Num(), q.Denom())
+		return new(big.Rat).Sub(a, new(big.Rat).Mul(b, new(big.Rat).SetInt(t))), nil
+	}
+	return nil, fmt.Errorf("%w: 不支持的运算符 %s", ErrSyntax, op)
+}
+
+func (n *callNode) eval(en *env) (Value, error) {
+	v, err := n.fn.call(en, n.args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,154 @@
+package formula
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func evalString(t *testing.T, source string, data map[string]interface{}) interface{} {
+	t.Helper()
+	expr, err := Compile(source)
+	if err != nil {
+		t.Fatalf("compile %q: %v", source, err)
+	}
+	v, err := expr.Eval(data)
+	if err != nil {
+		t.Fatalf("eval %q: %v", source, err)
+	}
+	if r, ok := v.(*big.Rat); ok {
+		return ratString(r)
+	}
+	return v
+}
+
+func TestEvalPrecedence(t *testing.T) {
+	cases := map[string]interface{}{
+		"1 + 2 * 3":                 "7",
+		"(1 + 2) * 3":               "9",
+		"10 - 4 - 3":                "3",
+		"2 * (3 + (4 - 1)) / 4":     "3",
+		"-2 * 3 + 1":                "-5",
+		"7 % 3 + -7 % 3":            "0",
+		"1 + 2 > 2 && 3 < 4":        true,
+		"1 > 2 || 2 > 1 and 1 == 1": true,
+		"!1 > 2":                    true,
+		"not (1 < 2)":               false,
+		"'a' + \"b\"":               "ab",
+		"'10' + 5":                  "15",
+		"'abc' == 'abc'":            true,
+		"null == nil":               true,
+	}
+	for source, want := range cases {
+		if got := evalString(t, source, nil); got != want {
+			t.Errorf("%s = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestEvalDecimalExact(t *testing.T) {
+	data := map[string]interface{}{"price": 0.1, "qty": 3, "order": map[string]interface{}{"discount": 0.3}}
+	if got := evalString(t, "price * qty == order.discount", data); got != true {
+		t.Fatalf("0.1 * 3 == 0.3 = %v", got)
+	}
+	if got := evalString(t, "round(${price} * 3 / 7, 2)", data); got != "0.04" {
+		t.Fatalf("round = %v", got)
+	}
+	if got := evalString(t, "round(2.345, 2) + round(-2.345, 2)", nil); got != "0" {
+		t.Fatalf("round half away from zero = %v", got)
+	}
+}
+
+func TestEvalFunctions(t *testing.T) {
+	data := map[string]interface{}{"amount": 150, "start": "2024-01-31", "end": "2024-03-01 12:00:00"}
+	cases := map[string]interface{}{
+		"min(3, amount, 2)":                           "2",
+		"max(3, amount, 2)":                           "150",
+		"abs(-1.5)":                                   "1.5",
+		"floor(-1.5) + ceil(1.2)":                     "0",
+		"if(amount > 100, 'big', 1 / 0)":              "big",
+		"coalesce(missing, user.level, 7)":            "7",
+		"datediff(end, start)":                        "30",
+		"datediff(end, start, 'month')":               "1",
+		"datediff(start, end, 'hour')":                "-732",
+		"datediff(date('2025-02-01'), start, 'year')": "1",
+	}
+	for source, want := range cases {
+		if got := evalString(t, source, data); got != want {
+			t.Errorf("%s = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, source := range []string{"", "1 +", "(1 + 2", "1 2", "unknown(1)", "round()", "if(1, 2)", "'abc", "${a", "1 # 2"} {
+		if _, err := Compile(source); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: err = %v, want ErrSyntax", source, err)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	if _, err := MustCompile("1 / (2 - 2)").Eval(nil); !errors.Is(err, ErrDivideByZero) {
+		t.Errorf("divide: %v", err)
+	}
+	if _, err := MustCompile("amount + 1").Eval(nil); !errors.Is(err, ErrType) {
+		t.Errorf("nil operand: %v", err)
+	}
+	if _, err := MustCompile("'a' > 1").Eval(nil); !errors.Is(err, ErrType) {
+		t.Errorf("compare: %v", err)
+	}
+}
+
+func TestEvalWithVars(t *testing.T) {
+	defs, err := ParseVarDefs(map[string]interface{}{
+		"amount": map[string]interface{}{"type": "cents", "source": "order.amount", "required": true},
+		"rate":   map[string]interface{}{"type": "number", "default": "0.05"},
+		"vip":    "bool",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr := MustCompile("round(amount * if(vip, rate * 2, rate), 2)")
+	if got := expr.Variables(); len(got) != 3 || got[0] != "amount" {
+		t.Fatalf("variables = %v", got)
+	}
+	v, err := expr.EvalWithVars(map[string]interface{}{"order": map[string]interface{}{"amount": 12345}, "vip": "1"}, defs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ToNative(v); got != 12.35 {
+		t.Fatalf("result = %v", got)
+	}
+	if _, err := expr.EvalWithVars(nil, defs); !errors.Is(err, ErrUndefinedVar) {
+		t.Fatalf("required: %v", err)
+	}
+
+	for name, raw := range map[string]map[string]interface{}{
+		"类型":   {"a": "money"},
+		"定义":   {"a": 1},
+		"默认值":  {"a": map[string]interface{}{"type": "int", "default": 1.5}},
+		"取值路径": {"a": map[string]interface{}{"source": "a..b"}},
+	} {
+		if _, err := ParseVarDefs(raw); !errors.Is(err, ErrInvalidVarDef) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache(2)
+	a, _ := cache.Compile("1 + 1")
+	b, _ := cache.Compile("1 + 1")
+	if a != b {
+		t.Fatal("expression not cached")
+	}
+	if _, err := cache.Compile("1 +"); err == nil || cache.Len() != 1 {
+		t.Fatalf("invalid expression cached: %v, len=%d", err, cache.Len())
+	}
+	cache.Compile("2")
+	cache.Compile("3")
+	if cache.Len() != 2 {
+		t.Fatalf("len = %d", cache.Len())
+	}
+}
@@ -0,0 +1,225 @@
+package formula
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// function 内置函数
+type function struct {
+	minArgs int
+	maxArgs int // -1 表示不限制
+	// lazy 为 true 时参数由函数自行求值，用于 if、coalesce 等短路函数
+	lazy bool
+	fn   func(en *env, args []node, values []Value) (Value, error)
+}
+
+// call 调用函数
+func (f *function) call(en *env, args []node) (Value, error) {
+	if f.lazy {
+		return f.fn(en, args, nil)
+	}
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		v, err := arg.eval(en)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return f.fn(en, args, values)
+}
+
+// functions 内置函数表，函数名不区分大小写
+var functions = map[string]*function{
+	"min":      {minArgs: 1, maxArgs: -1, fn: fnMin},
+	"max":      {minArgs: 1, maxArgs: -1, fn: fnMax},
+	"abs":      {minArgs: 1, maxArgs: 1, fn: fnAbs},
+	"round":    {minArgs: 1, maxArgs: 2, fn: fnRound},
+	"floor":    {minArgs: 1, maxArgs: 1, fn: fnFloor},
+	"ceil":     {minArgs: 1, maxArgs: 1, fn: fnCeil},
+	"if":       {minArgs: 3, maxArgs: 3, lazy: true, fn: fnIf},
+	"coalesce": {minArgs: 1, maxArgs: -1, lazy: true, fn: fnCoalesce},
+	"datediff": {minArgs: 2, maxArgs: 3, fn: fnDateDiff},
+	"date":     {minArgs: 1, maxArgs: 1, fn: fnDate},
+	"now":      {minArgs: 0, maxArgs: 0, fn: fnNow},
+}
+
+// fnMin min(a, b, ...) 最小值
+func fnMin(_ *env, _ []node, values []Value) (Value, error) {
+	return extreme(values, -1)
+}
+
+// fnMax max(a, b, ...) 最大值
+func fnMax(_ *env, _ []node, values []Value) (Value, error) {
+	return extreme(values, 1)
+}
+
+func extreme(values []Value, sign int) (Value, error) {
+	result := values[0]
+	for _, v := range values[1:] {
+		c, err := compare(v, result)
+		if err != nil {
+			return nil, err
+		}
+		if c*sign > 0 {
+			result = v
+		}
+	}
+	return result, nil
+}
+
+// fnAbs abs(x) 绝对值
+func fnAbs(_ *env, _ []node, values []Value) (Value, error) {
+	r, err := toNumber(values[0])
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Abs(r), nil
+}
+
+// fnRound round(x, n) 保留 n 位小数，四舍五入(远离零)，n 默认为 0
+func fnRound(_ *env, _ []node, values []Value) (Value, error) {
+	r, err := toNumber(values[0])
+	if err != nil {
+		return nil, err
+	}
+	places := int64(0)
+	if len(values) > 1 {
+		p, err := toNumber(values[1])
+		if err != nil {
+			return nil, err
+		}
+		if !p.IsInt() || !p.Num().IsInt64() || p.Num().Int64() < 0 || p.Num().Int64() > 18 {
+			return nil, fmt.Errorf("%w: 小数位数必须是 0-18 的整数", ErrType)
+		}
+		places = p.Num().Int64()
+	}
+	return Round(r, int(places)), nil
+}
+
+// Round 保留 places 位小数，四舍五入(远离零)
+func Round(r *big.Rat, places int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	num := new(big.Int).Mul(r.Num(), scale)
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	// 余数的两倍不小于除数时进位
+	if m.Abs(m).Lsh(m, 1).Cmp(r.Denom()) >= 0 {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return new(big.Rat).SetFrac(q, scale)
+}
+
+// fnFloor floor(x) 向下取整
+func fnFloor(_ *env, _ []node, values []Value) (Value, error) {
+	r, err := toNumber(values[0])
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).SetInt(floor(r)), nil
+}
+
+// fnCeil ceil(x) 向上取整
+func fnCeil(_ *env, _ []node, values []Value) (Value, error) {
+	r, err := toNumber(values[0])
+	if err != nil {
+		return nil, err
+	}
+	neg := floor(new(big.Rat).Neg(r))
+	return new(big.Rat).SetInt(neg.Neg(neg)), nil
+}
+
+// floor 向下取整，分母恒为正数，欧几里得除法即为向下取整
+func floor(r *big.Rat) *big.Int {
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+// fnIf if(cond, a, b) 条件为真时返回 a，否则返回 b，只计算被选中的分支
+func fnIf(en *env, args []node, _ []Value) (Value, error) {
+	cond, err := args[0].eval(en)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(cond) {
+		return args[1].eval(en)
+	}
+	return args[2].eval(en)
+}
+
+// fnCoalesce coalesce(a, b, ...) 返回第一个非空的值
+func fnCoalesce(en *env, args []node, _ []Value) (Value, error) {
+	for _, arg := range args {
+		v, err := arg.eval(en)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+// fnDateDiff datediff(end, start, unit) 计算 end - start 的时间差，不足一个单位的部分舍去
+// unit 可选 year、month、day(默认)、hour、minute、second
+func fnDateDiff(_ *env, _ []node, values []Value) (Value, error) {
+	end, err := toDate(values[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := toDate(values[1])
+	if err != nil {
+		return nil, err
+	}
+	unit := "day"
+	if len(values) > 2 {
+		s, ok := values[2].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: 时间单位必须是字符串", ErrType)
+		}
+		unit = strings.ToLower(s)
+	}
+
+	var diff int64
+	switch unit {
+	case "year", "month":
+		months := int64(end.Year()-start.Year())*12 + int64(end.Month()-start.Month())
+		// 未满一个月的部分舍去
+		if months > 0 && end.Before(start.AddDate(0, int(months), 0)) {
+			months--
+		} else if months < 0 && end.After(start.AddDate(0, int(months), 0)) {
+			months++
+		}
+		diff = months
+		if unit == "year" {
+			diff = months / 12
+		}
+	case "day":
+		diff = int64(end.Sub(start) / (24 * time.Hour))
+	case "hour":
+		diff = int64(end.Sub(start) / time.Hour)
+	case "minute":
+		diff = int64(end.Sub(start) / time.Minute)
+	case "second":
+		diff = int64(end.Sub(start) / time.Second)
+	default:
+		return nil, fmt.Errorf("%w: 不支持的时间单位 %s", ErrType, unit)
+	}
+	return new(big.Rat).SetInt64(diff), nil
+}
+
+// fnDate date(x) 将字符串或Unix时间戳转换为日期
+func fnDate(_ *env, _ []node, values []Value) (Value, error) {
+	return toDate(values[0])
+}
+
+// fnNow now() 当前时间
+func fnNow(_ *env, _ []node, _ []Value) (Value, error) {
+	return time.Now(), nil
+}
@@ -0,0 +1,174 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 在源码中的位置，从0开始
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "结尾"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// 运算符，长的放在前面优先匹配
+var operators = []string{"&&", "||", "==", "!=", ">=", "<=", "+", "-", "*", "/", "%", ">", "<", "!"}
+
+// 关键字形式的运算符
+var keywordOperators = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+}
+
+// lex 将表达式拆分为词法单元
+func lex(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '9':
+			start := i
+			dot := false
+			for i < len(runes) && (runes[i] >= '0' && runes[i] <= '9' || runes[i] == '.' && !dot) {
+				if runes[i] == '.' {
+					dot = true
+				}
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:i]), start})
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("%w: 位置 %d 的字符串未结束", ErrSyntax, start)
+				}
+				if runes[i] == c {
+					i++
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					i++
+					continue
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{tokenString, sb.String(), start})
+		case c == '$' && i+1 < len(runes) && runes[i+1] == '{':
+			// 兼容 ${name} 形式的变量引用
+			start := i
+			i += 2
+			for i < len(runes) && runes[i] != '}' {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: 位置 %d 的变量引用未结束", ErrSyntax, start)
+			}
+			name := strings.TrimSpace(string(runes[start+2 : i]))
+			if !isPath(name) {
+				return nil, fmt.Errorf("%w: 位置 %d 的变量名 %q 不合法", ErrSyntax, start, name)
+			}
+			tokens = append(tokens, token{tokenIdent, name, start})
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(runes) && (isIdentPart(runes[i]) || runes[i] == '.' && i+1 < len(runes) && isIdentStart(runes[i+1])) {
+				i++
+			}
+			text := string(runes[start:i])
+			if op, ok := keywordOperators[strings.ToLower(text)]; ok {
+				tokens = append(tokens, token{tokenOperator, op, start})
+			} else {
+				tokens = append(tokens, token{tokenIdent, text, start})
+			}
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{tokenOperator, op, i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("%w: 位置 %d 的字符 %q 无法识别", ErrSyntax, i, c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c)
+}
+
+func isIdentPart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// isPath 判断是否为合法的变量路径，如 order.amount
+func isPath(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, part := range strings.Split(name, ".") {
+		runes := []rune(part)
+		if len(runes) == 0 || !isIdentStart(runes[0]) {
+			return false
+		}
+		for _, c := range runes[1:] {
+			if !isIdentPart(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
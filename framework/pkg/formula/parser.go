@@ -0,0 +1,219 @@
+package formula
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// 运算符优先级，数字越大优先级越高
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	">": 4, ">=": 4, "<": 4, "<=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// node 语法树节点
+type node interface {
+	eval(env *env) (Value, error)
+}
+
+// literalNode 字面量
+type literalNode struct {
+	value Value
+}
+
+// variableNode 变量引用，支持 order.amount 形式的路径
+type variableNode struct {
+	name string
+}
+
+// unaryNode 一元运算
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+// binaryNode 二元运算
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+// callNode 函数调用
+type callNode struct {
+	name string
+	fn   *function
+	args []node
+}
+
+// parser 递归下降语法分析器
+type parser struct {
+	tokens []token
+	pos    int
+	vars   map[string]struct{}
+}
+
+// parse 解析表达式生成语法树，同时收集引用的变量
+func parse(source string) (node, []string, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &parser{tokens: tokens, vars: make(map[string]struct{})}
+	if p.peek().kind == tokenEOF {
+		return nil, nil, fmt.Errorf("%w: 表达式为空", ErrSyntax)
+	}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, nil, fmt.Errorf("%w: 位置 %d 存在多余的 %s", ErrSyntax, t.pos, t)
+	}
+	vars := make([]string, 0, len(p.vars))
+	for name := range p.vars {
+		vars = append(vars, name)
+	}
+	return root, vars, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseExpr 按优先级爬升解析二元运算，左结合
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := binaryPrecedence[t.text]
+		if t.kind != tokenOperator || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpr(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+// parseUnary 解析一元运算
+// 负号优先级最高；逻辑非的操作数包含比较运算，如 !a > 1 等价于 !(a > 1)
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return p.parsePrimary()
+	}
+	switch t.text {
+	case "-", "+":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if t.text == "+" {
+			return operand, nil
+		}
+		return &unaryNode{op: t.text, operand: operand}, nil
+	case "!":
+		p.next()
+		operand, err := p.parseExpr(binaryPrecedence["&&"])
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.text, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		r, ok := newNumber(t.text)
+		if !ok {
+			return nil, fmt.Errorf("%w: 位置 %d 的数字 %s 不合法", ErrSyntax, t.pos, t.text)
+		}
+		return &literalNode{value: r}, nil
+	case tokenString:
+		return &literalNode{value: t.text}, nil
+	case tokenLParen:
+		expr, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, fmt.Errorf("%w: 位置 %d 缺少右括号", ErrSyntax, r.pos)
+		}
+		return expr, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(t)
+		}
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{value: nil}, nil
+		}
+		p.vars[t.text] = struct{}{}
+		return &variableNode{name: t.text}, nil
+	}
+	return nil, fmt.Errorf("%w: 位置 %d 不应出现 %s", ErrSyntax, t.pos, t)
+}
+
+// parseCall 解析函数调用，编译时检查函数是否存在以及参数个数
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("%w: 位置 %d 的函数 %s 不存在", ErrSyntax, name.pos, name.text)
+	}
+	p.next() // (
+	var args []node
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if r := p.next(); r.kind != tokenRParen {
+		return nil, fmt.Errorf("%w: 位置 %d 函数 %s 缺少右括号", ErrSyntax, r.pos, name.text)
+	}
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("%w: 函数 %s 的参数个数 %d 不正确", ErrSyntax, name.text, len(args))
+	}
+	return &callNode{name: strings.ToLower(name.text), fn: fn, args: args}, nil
+}
+
+func (n *literalNode) eval(_ *env) (Value, error) {
+	if r, ok := n.value.(*big.Rat); ok {
+		return new(big.Rat).Set(r), nil
+	}
+	return n.value, nil
+}
@@ -0,0 +1,195 @@
+package formula
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value 表达式的值
+// 取值为 nil、bool、string、*big.Rat(数字) 或 time.Time(日期)
+type Value interface{}
+
+// 日期字符串支持的格式
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// newNumber 解析十进制数字字符串，保持精确值
+func newNumber(s string) (*big.Rat, bool) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	return r, ok
+}
+
+// floatToNumber 按最短十进制表示转换浮点数，避免二进制误差，如 0.1 转换为 1/10
+func floatToNumber(f float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	return r
+}
+
+// FromNative 将Go的值转换为表达式的值
+func FromNative(v interface{}) (Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case bool, string, time.Time:
+		return val, nil
+	case *big.Rat:
+		return new(big.Rat).Set(val), nil
+	case float64:
+		return floatToNumber(val), nil
+	case float32:
+		return floatToNumber(float64(val)), nil
+	case int:
+		return new(big.Rat).SetInt64(int64(val)), nil
+	case int8:
+		return new(big.Rat).SetInt64(int64(val)), nil
+	case int16:
+		return new(big.Rat).SetInt64(int64(val)), nil
+	case int32:
+		return new(big.Rat).SetInt64(int64(val)), nil
+	case int64:
+		return new(big.Rat).SetInt64(val), nil
+	case uint:
+		return new(big.Rat).SetUint64(uint64(val)), nil
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(val)), nil
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(val)), nil
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(val)), nil
+	case uint64:
+		return new(big.Rat).SetUint64(val), nil
+	case json.Number:
+		if r, ok := newNumber(val.String()); ok {
+			return r, nil
+		}
+		return nil, fmt.Errorf("%w: 无效的数字 %q", ErrType, val)
+	}
+	return nil, fmt.Errorf("%w: 不支持的值类型 %T", ErrType, v)
+}
+
+// ToNative 将表达式的值转换为Go的值
+// 整数转换为 int64，其他数字转换为 float64
+func ToNative(v Value) interface{} {
+	if r, ok := v.(*big.Rat); ok {
+		if r.IsInt() && r.Num().IsInt64() {
+			return r.Num().Int64()
+		}
+		f, _ := r.Float64()
+		return f
+	}
+	return v
+}
+
+// typeName 值的类型名称，用于错误信息
+func typeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case *big.Rat:
+		return "number"
+	case time.Time:
+		return "date"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toNumber 转换为数字，字符串按十进制解析，布尔值不参与运算
+func toNumber(v Value) (*big.Rat, error) {
+	switch val := v.(type) {
+	case *big.Rat:
+		return val, nil
+	case string:
+		if r, ok := newNumber(val); ok {
+			return r, nil
+		}
+	case nil:
+		return nil, fmt.Errorf("%w: 值为空", ErrType)
+	}
+	return nil, fmt.Errorf("%w: %s 不能作为数字", ErrType, typeName(v))
+}
+
+// toDate 转换为日期，数字按Unix时间戳(秒)处理
+func toDate(v Value) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, val, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%w: 无效的日期 %q", ErrType, val)
+	case *big.Rat:
+		if val.IsInt() && val.Num().IsInt64() {
+			return time.Unix(val.Num().Int64(), 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %s 不能作为日期", ErrType, typeName(v))
+}
+
+// truthy 判断值的真假：null、false、0 和空字符串为假
+func truthy(v Value) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	case *big.Rat:
+		return val.Sign() != 0
+	}
+	return true
+}
+
+// compare 比较两个值，返回 -1、0、1
+// 数字和可解析为数字的字符串按数值比较，日期按时间比较，字符串按字典序比较
+func compare(a, b Value) (int, error) {
+	switch av := a.(type) {
+	case *big.Rat:
+		bv, err := toNumber(b)
+		if err != nil {
+			return 0, fmt.Errorf("%w: 无法比较 %s 和 %s", ErrType, typeName(a), typeName(b))
+		}
+		return av.Cmp(bv), nil
+	case time.Time:
+		bv, err := toDate(b)
+		if err != nil {
+			return 0, fmt.Errorf("%w: 无法比较 %s 和 %s", ErrType, typeName(a), typeName(b))
+		}
+		return av.Compare(bv), nil
+	case string:
+		switch b.(type) {
+		case *big.Rat, time.Time:
+			c, err := compare(b, a)
+			return -c, err
+		case string:
+			return strings.Compare(av, b.(string)), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: 无法比较 %s 和 %s", ErrType, typeName(a), typeName(b))
+}
+
+// equal 判断两个值是否相等，类型不兼容时不相等
+func equal(a, b Value) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if ab, ok := a.(bool); ok {
+		bb, ok := b.(bool)
+		return ok && ab == bb
+	}
+	if _, ok := b.(bool); ok {
+		return false
+	}
+	c, err := compare(a, b)
+	return err == nil && c == 0
+}
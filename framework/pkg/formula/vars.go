@@ -0,0 +1,161 @@
+package formula
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// 变量类型
+const (
+	VarNumber = "number" // 数字，精确计算
+	VarInt    = "int"    // 整数
+	VarCents  = "cents"  // 以分为单位的金额，取值时换算为元
+	VarString = "string" // 字符串
+	VarBool   = "bool"   // 布尔值
+	VarDate   = "date"   // 日期，支持日期字符串和Unix时间戳(秒)
+	VarAny    = "any"    // 不转换类型
+)
+
+var validVarTypes = map[string]bool{
+	VarNumber: true, VarInt: true, VarCents: true, VarString: true, VarBool: true, VarDate: true, VarAny: true,
+}
+
+// VarDef 变量定义
+type VarDef struct {
+	Type     string      `json:"type"`               // 变量类型，默认 any
+	Source   string      `json:"source,omitempty"`   // 取值路径，如 order.amount，默认与变量名相同
+	Default  interface{} `json:"default,omitempty"`  // 上下文中没有该值时使用的默认值
+	Required bool        `json:"required,omitempty"` // 是否必须，必须的变量缺失且没有默认值时报错
+}
+
+// VarDefs 变量定义，键为表达式中的变量名
+type VarDefs map[string]VarDef
+
+// ParseVarDefs 解析变量定义
+// 值可以是类型名称，如 {"amount": "number"}，也可以是完整的定义，如 {"amount": {"type": "cents", "source": "order.amount"}}
+func ParseVarDefs(raw map[string]interface{}) (VarDefs, error) {
+	defs := make(VarDefs, len(raw))
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !isPath(name) {
+			return nil, fmt.Errorf("%w: 变量名 %q 不合法", ErrInvalidVarDef, name)
+		}
+		var def VarDef
+		switch v := raw[name].(type) {
+		case string:
+			def.Type = v
+		case map[string]interface{}:
+			data, _ := json.Marshal(v)
+			if err := json.Unmarshal(data, &def); err != nil {
+				return nil, fmt.Errorf("%w: 变量 %s: %v", ErrInvalidVarDef, name, err)
+			}
+		default:
+			return nil, fmt.Errorf("%w: 变量 %s 的定义必须是类型名称或对象", ErrInvalidVarDef, name)
+		}
+		if def.Type == "" {
+			def.Type = VarAny
+		}
+		if !validVarTypes[def.Type] {
+			return nil, fmt.Errorf("%w: 变量 %s 的类型 %s 不支持", ErrInvalidVarDef, name, def.Type)
+		}
+		if def.Source != "" && !isPath(def.Source) {
+			return nil, fmt.Errorf("%w: 变量 %s 的取值路径 %q 不合法", ErrInvalidVarDef, name, def.Source)
+		}
+		if def.Default != nil {
+			if _, err := def.convert(def.Default); err != nil {
+				return nil, fmt.Errorf("%w: 变量 %s 的默认值: %v", ErrInvalidVarDef, name, err)
+			}
+		}
+		defs[name] = def
+	}
+	return defs, nil
+}
+
+// resolve 从上下文数据中取值并转换为定义的类型
+func (d VarDef) resolve(name string, data map[string]interface{}) (Value, error) {
+	source := d.Source
+	if source == "" {
+		source = name
+	}
+	raw := lookupPath(data, source)
+	if raw == nil {
+		raw = d.Default
+	}
+	if raw == nil {
+		if d.Required {
+			return nil, ErrUndefinedVar
+		}
+		return nil, nil
+	}
+	return d.convert(raw)
+}
+
+// convert 按定义的类型转换值
+func (d VarDef) convert(raw interface{}) (Value, error) {
+	v, err := FromNative(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch d.Type {
+	case VarNumber:
+		return toNumber(v)
+	case VarInt:
+		r, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if !r.IsInt() {
+			return nil, fmt.Errorf("%w: %s 不是整数", ErrType, r.RatString())
+		}
+		return r, nil
+	case VarCents:
+		r, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).Quo(r, big.NewRat(100, 1)), nil
+	case VarString:
+		switch val := v.(type) {
+		case string:
+			return val, nil
+		case *big.Rat:
+			return ratString(val), nil
+		}
+		return nil, fmt.Errorf("%w: %s 不能作为字符串", ErrType, typeName(v))
+	case VarBool:
+		switch val := v.(type) {
+		case bool:
+			return val, nil
+		case string:
+			switch val {
+			case "true", "1":
+				return true, nil
+			case "false", "0", "":
+				return false, nil
+			}
+		case *big.Rat:
+			return val.Sign() != 0, nil
+		}
+		return nil, fmt.Errorf("%w: %s 不能作为布尔值", ErrType, typeName(v))
+	case VarDate:
+		return toDate(v)
+	}
+	return v, nil
+}
+
+// ratString 数字的十进制字符串，有限小数不带多余的0，无限小数保留10位
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	if s, exact := r.FloatPrec(); exact {
+		return r.FloatString(s)
+	}
+	return r.FloatString(10)
+}
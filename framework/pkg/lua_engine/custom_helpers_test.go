@@ -48,15 +48,13 @@ func TestCustomHelpers(t *testing.T) {
 		-- 测试自定义乘法函数
 		local product = test_multiply(amount, 2)
 		
-		-- 设置验证结果
-		valid = true
-		action = "approve"
-		variables = {
+		-- 设置验证结果，输出变量写入上下文
+		success("approve", {
 			user_id = user_id,
 			original_amount = amount,
 			sum_result = sum,
 			product_result = product
-		}
+		})
 	`
 
 	// 执行规则
@@ -75,9 +73,9 @@ func TestCustomHelpers(t *testing.T) {
 	}
 
 	// 验证输出变量
-	variables, ok := result.Variables.(map[string]interface{})
-	if !ok {
-		t.Fatalf("输出变量类型错误")
+	variables := result.Context
+	if variables == nil {
+		t.Fatalf("输出变量为空")
 	}
 
 	// 验证用户ID
@@ -124,9 +122,7 @@ func TestCustomHelpersWithError(t *testing.T) {
 	// 测试正常除法
 	script1 := `
 		local result = safe_divide(10, 2)
-		valid = true
-		action = "approve"
-		variables = { result = result }
+		success("approve", { result = result })
 	`
 
 	result1, err := executor.Execute(script1, opts)
@@ -140,10 +136,10 @@ func TestCustomHelpersWithError(t *testing.T) {
 
 	// 测试除零错误
 	script2 := `
-		local result, error = safe_divide(10, 0)
+		local result, msg = safe_divide(10, 0)
 		valid = false
 		action = "reject"
-		error = error
+		error = msg
 	`
 
 	result2, err := executor.Execute(script2, opts)
@@ -203,14 +199,13 @@ func TestCustomHelpersMultipleFunctions(t *testing.T) {
 		local is_adult = check_age(age)
 		local bmi = calculate_bmi(70, 1.75)
 		
-		-- 设置验证结果
-		valid = is_adult
-		action = is_adult and "approve" or "reject"
-		variables = {
+		-- 设置验证结果，输出变量写入上下文
+		success(is_adult and "approve" or "reject", {
 			formatted_name = formatted_name,
 			is_adult = is_adult,
 			bmi = bmi
-		}
+		})
+		valid = is_adult
 	`
 
 	// 执行规则
@@ -229,9 +224,9 @@ func TestCustomHelpersMultipleFunctions(t *testing.T) {
 	}
 
 	// 验证输出变量
-	variables, ok := result.Variables.(map[string]interface{})
-	if !ok {
-		t.Fatalf("输出变量类型错误")
+	variables := result.Context
+	if variables == nil {
+		t.Fatalf("输出变量为空")
 	}
 
 	// 验证格式化姓名
@@ -1,16 +1,15 @@
 package lua_engine
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
 	lua "github.com/yuin/gopher-lua"
 )
 
-// registerDBHelperFunctions 注册数据库操作辅助函数
-func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOperationService) {
-	if dbService == nil {
+// registerDBHelperFunctions 注册数据库操作辅助函数，所有调用经过会话的策略检查
+func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, session *dbSession) {
+	if session == nil {
 		return
 	}
 
@@ -28,8 +27,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		})
 
 		// 执行插入操作
-		ctx := context.Background()
-		affected, err := dbService.Insert(ctx, table, data)
+		affected, err := session.Insert(table, data)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -62,8 +60,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		})
 
 		// 执行更新操作
-		ctx := context.Background()
-		affected, err := dbService.Update(ctx, table, data, whereSQL, whereArgs...)
+		affected, err := session.Update(table, data, whereSQL, whereArgs)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -96,8 +93,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		})
 
 		// 执行更新操作
-		ctx := context.Background()
-		affected, err := dbService.UpdateWithMap(ctx, table, where, data)
+		affected, err := session.UpdateWithMap(table, where, data)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -121,8 +117,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		}
 
 		// 执行删除操作
-		ctx := context.Background()
-		affected, err := dbService.Delete(ctx, table, whereSQL, whereArgs...)
+		affected, err := session.Delete(table, whereSQL, whereArgs)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -147,8 +142,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		})
 
 		// 执行删除操作
-		ctx := context.Background()
-		affected, err := dbService.DeleteWithMap(ctx, table, where)
+		affected, err := session.DeleteWithMap(table, where)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -171,8 +165,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		}
 
 		// 执行查询操作
-		ctx := context.Background()
-		results, err := dbService.Query(ctx, sql, args...)
+		results, err := session.Query(sql, args)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -205,8 +198,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		}
 
 		// 执行查询操作
-		ctx := context.Background()
-		result, err := dbService.QueryOne(ctx, sql, args...)
+		result, err := session.QueryOne(sql, args)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -235,8 +227,7 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		}
 
 		// 执行SQL操作
-		ctx := context.Background()
-		affected, err := dbService.Execute(ctx, sql, args...)
+		affected, err := session.Execute(sql, args)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -250,63 +241,10 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 	// 使用QueryBuilder查询
 	L.SetGlobal("db_query_builder", L.NewFunction(func(L *lua.LState) int {
 		table := L.CheckString(1)
-		builderTable := L.CheckTable(2)
-
-		// 构建QueryBuilder
-		builder := db_query.NewQueryBuilder()
-
-		// 解析条件
-		builderTable.ForEach(func(key, value lua.LValue) {
-			if key.Type() == lua.LTString {
-				keyStr := key.String()
-				switch keyStr {
-				case "where":
-					if value.Type() == lua.LTTable {
-						whereTable := value.(*lua.LTable)
-						whereTable.ForEach(func(field, condition lua.LValue) {
-							if field.Type() == lua.LTString && condition.Type() == lua.LTTable {
-								conditionTable := condition.(*lua.LTable)
-								operator := conditionTable.RawGetString("operator")
-								val := conditionTable.RawGetString("value")
-
-								if operator.Type() == lua.LTString {
-									op := db_query.Operator(operator.String())
-									builder.Where(field.String(), op, luaValueToGo(val))
-								}
-							}
-						})
-					}
-				case "order_by":
-					if value.Type() == lua.LTTable {
-						orderTable := value.(*lua.LTable)
-						orderTable.ForEach(func(field, direction lua.LValue) {
-							if field.Type() == lua.LTString && direction.Type() == lua.LTString {
-								asc := direction.String() == "ASC"
-								builder.OrderBy(field.String(), asc)
-							}
-						})
-					}
-				case "page":
-					if value.Type() == lua.LTTable {
-						pageTable := value.(*lua.LTable)
-						pageNum := pageTable.RawGetString("pageNum")
-						pageSize := pageTable.RawGetString("pageSize")
-
-						if pageNum.Type() == lua.LTNumber && pageSize.Type() == lua.LTNumber {
-							page := &db_query.Page{
-								Current: int(pageNum.(lua.LNumber)),
-								Size:    int(pageSize.(lua.LNumber)),
-							}
-							builder.WithPage(page)
-						}
-					}
-				}
-			}
-		})
+		query := parseLuaQuery(L.CheckTable(2))
 
 		// 执行查询
-		ctx := context.Background()
-		results, err := dbService.QueryWithBuilder(ctx, table, builder)
+		results, err := session.QueryWithBuilder(table, query)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -330,31 +268,10 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 	// 使用QueryBuilder统计数量
 	L.SetGlobal("db_count_builder", L.NewFunction(func(L *lua.LState) int {
 		table := L.CheckString(1)
-		builderTable := L.CheckTable(2)
-
-		// 构建QueryBuilder
-		builder := db_query.NewQueryBuilder()
-
-		// 解析WHERE条件
-		whereTable := builderTable.RawGetString("where")
-		if whereTable.Type() == lua.LTTable {
-			whereTable.(*lua.LTable).ForEach(func(field, condition lua.LValue) {
-				if field.Type() == lua.LTString && condition.Type() == lua.LTTable {
-					conditionTable := condition.(*lua.LTable)
-					operator := conditionTable.RawGetString("operator")
-					val := conditionTable.RawGetString("value")
-
-					if operator.Type() == lua.LTString {
-						op := db_query.Operator(operator.String())
-						builder.Where(field.String(), op, luaValueToGo(val))
-					}
-				}
-			})
-		}
+		query := parseLuaQuery(L.CheckTable(2))
 
 		// 执行统计
-		ctx := context.Background()
-		count, err := dbService.CountWithBuilder(ctx, table, builder)
+		count, err := session.CountWithBuilder(table, query)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -368,62 +285,10 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 	// 构建SQL语句
 	L.SetGlobal("db_build_sql", L.NewFunction(func(L *lua.LState) int {
 		table := L.CheckString(1)
-		builderTable := L.CheckTable(2)
-
-		// 构建QueryBuilder
-		builder := db_query.NewQueryBuilder()
-
-		// 解析条件
-		builderTable.ForEach(func(key, value lua.LValue) {
-			if key.Type() == lua.LTString {
-				keyStr := key.String()
-				switch keyStr {
-				case "where":
-					if value.Type() == lua.LTTable {
-						whereTable := value.(*lua.LTable)
-						whereTable.ForEach(func(field, condition lua.LValue) {
-							if field.Type() == lua.LTString && condition.Type() == lua.LTTable {
-								conditionTable := condition.(*lua.LTable)
-								operator := conditionTable.RawGetString("operator")
-								val := conditionTable.RawGetString("value")
-
-								if operator.Type() == lua.LTString {
-									op := db_query.Operator(operator.String())
-									builder.Where(field.String(), op, luaValueToGo(val))
-								}
-							}
-						})
-					}
-				case "order_by":
-					if value.Type() == lua.LTTable {
-						orderTable := value.(*lua.LTable)
-						orderTable.ForEach(func(field, direction lua.LValue) {
-							if field.Type() == lua.LTString && direction.Type() == lua.LTString {
-								asc := direction.String() == "ASC"
-								builder.OrderBy(field.String(), asc)
-							}
-						})
-					}
-				case "page":
-					if value.Type() == lua.LTTable {
-						pageTable := value.(*lua.LTable)
-						pageNum := pageTable.RawGetString("pageNum")
-						pageSize := pageTable.RawGetString("pageSize")
-
-						if pageNum.Type() == lua.LTNumber && pageSize.Type() == lua.LTNumber {
-							page := &db_query.Page{
-								Current: int(pageNum.(lua.LNumber)),
-								Size:    int(pageSize.(lua.LNumber)),
-							}
-							builder.WithPage(page)
-						}
-					}
-				}
-			}
-		})
+		query := parseLuaQuery(L.CheckTable(2))
 
 		// 构建SQL
-		sql, args, err := dbService.BuildSQL(table, builder)
+		sql, args, err := session.svc.BuildSQL(table, query.builder)
 		if err != nil {
 			L.Push(lua.LNil)
 			L.Push(lua.LString(err.Error()))
@@ -446,9 +311,8 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 	L.SetGlobal("db_transaction", L.NewFunction(func(L *lua.LState) int {
 		fn := L.CheckFunction(1)
 
-		// 执行事务
-		ctx := context.Background()
-		err := dbService.Transaction(ctx, func(ctx context.Context) error {
+		// 执行事务，事务内的数据库操作使用同一个事务
+		err := session.Transaction(func() error {
 			// 调用Lua函数
 			L.Push(fn)
 			if err := L.PCall(0, 0, nil); err != nil {
@@ -467,3 +331,58 @@ func (e *RuleExecutor) registerDBHelperFunctions(L *lua.LState, dbService *DBOpe
 		return 1
 	}))
 }
+
+// luaQuery 从Lua表解析的查询条件
+type luaQuery struct {
+	builder *db_query.QueryBuilder
+	fields  []string       // where 和 order_by 引用的字段
+	page    *db_query.Page // 分页，未指定时为 nil
+}
+
+// parseLuaQuery 解析查询条件表 {where = {field = {operator = "=", value = 1}}, order_by = {field = "ASC"}, page = {pageNum = 1, pageSize = 10}}
+func parseLuaQuery(tbl *lua.LTable) *luaQuery {
+	q := &luaQuery{builder: db_query.NewQueryBuilder()}
+
+	// 解析WHERE条件
+	if whereTable, ok := tbl.RawGetString("where").(*lua.LTable); ok {
+		whereTable.ForEach(func(field, condition lua.LValue) {
+			if field.Type() == lua.LTString && condition.Type() == lua.LTTable {
+				conditionTable := condition.(*lua.LTable)
+				operator := conditionTable.RawGetString("operator")
+				val := conditionTable.RawGetString("value")
+
+				if operator.Type() == lua.LTString {
+					op := db_query.Operator(operator.String())
+					q.builder.Where(field.String(), op, luaValueToGo(val))
+					q.fields = append(q.fields, field.String())
+				}
+			}
+		})
+	}
+
+	// 解析排序
+	if orderTable, ok := tbl.RawGetString("order_by").(*lua.LTable); ok {
+		orderTable.ForEach(func(field, direction lua.LValue) {
+			if field.Type() == lua.LTString && direction.Type() == lua.LTString {
+				asc := direction.String() == "ASC"
+				q.builder.OrderBy(field.String(), asc)
+				q.fields = append(q.fields, field.String())
+			}
+		})
+	}
+
+	// 解析分页
+	if pageTable, ok := tbl.RawGetString("page").(*lua.LTable); ok {
+		pageNum := pageTable.RawGetString("pageNum")
+		pageSize := pageTable.RawGetString("pageSize")
+
+		if pageNum.Type() == lua.LTNumber && pageSize.Type() == lua.LTNumber {
+			q.page = &db_query.Page{
+				Current: int(pageNum.(lua.LNumber)),
+				Size:    int(pageSize.(lua.LNumber)),
+			}
+			q.builder.WithPage(q.page)
+		}
+	}
+	return q
+}
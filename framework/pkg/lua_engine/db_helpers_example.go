@@ -1,6 +1,7 @@
 package lua_engine
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -18,7 +19,7 @@ func ExampleDBQueryWithConvertToLuaValue() {
 
 	// 创建规则执行器
 	executor := &RuleExecutor{}
-	executor.registerDBHelperFunctions(L, dbService)
+	executor.registerDBHelperFunctions(L, newDBSession(context.Background(), dbService, nil, "", nil))
 
 	// 示例1: 使用db_query执行COUNT查询
 	// SQL: SELECT count(*) FROM users WHERE from_uid = '711083979805036544' and is_real = 1
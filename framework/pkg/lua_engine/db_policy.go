@@ -0,0 +1,553 @@
+package lua_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+)
+
+// 数据库访问错误
+var (
+	ErrDBAccessDenied = errors.New("数据库访问被拒绝")
+	ErrDBRowLimit     = errors.New("超出行数限制")
+)
+
+const (
+	// DefaultDBMaxRows 查询默认最多返回的行数
+	DefaultDBMaxRows = 100
+	// DefaultDBMaxAffected 写操作默认最多影响的行数
+	DefaultDBMaxAffected int64 = 100
+	// DefaultTenantColumn 默认租户列
+	DefaultTenantColumn = "tenant_id"
+)
+
+// DBAccessMode 数据库访问模式
+type DBAccessMode string
+
+const (
+	DBAccessNone      DBAccessMode = ""      // 禁止访问
+	DBAccessReadOnly  DBAccessMode = "read"  // 只读
+	DBAccessReadWrite DBAccessMode = "write" // 读写
+)
+
+// 表名和列名只允许字母、数字和下划线，防止拼接到SQL中注入
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// 查询构建器允许的操作符
+var allowedOperators = map[db_query.Operator]bool{
+	db_query.Eq: true, db_query.Neq: true, db_query.Gt: true, db_query.Gte: true,
+	db_query.Lt: true, db_query.Lte: true, db_query.Like: true, db_query.In: true,
+	db_query.NotIn: true, db_query.IsNull: true, db_query.IsNotNull: true,
+}
+
+// DBTablePolicy 表访问策略
+type DBTablePolicy struct {
+	Table    string   `json:"table"`    // 表名
+	Columns  []string `json:"columns"`  // 允许访问的列，为空时不限制
+	ReadOnly bool     `json:"readOnly"` // 只读，策略为读写模式时也不允许写入该表
+	NoTenant bool     `json:"noTenant"` // 不按租户过滤，用于无租户列的公共表
+}
+
+// DBPolicy 规则的数据库访问策略
+// 只能访问声明的表和列，按租户列自动过滤数据；原始SQL不受表和租户限制，需要显式授权
+type DBPolicy struct {
+	Mode         DBAccessMode    `json:"mode"`         // 访问模式：空(禁止) read(只读) write(读写)
+	Tables       []DBTablePolicy `json:"tables"`       // 允许访问的表
+	TenantColumn string          `json:"tenantColumn"` // 租户列，默认 tenant_id
+	MaxRows      int             `json:"maxRows"`      // 查询最多返回的行数，默认 DefaultDBMaxRows
+	MaxAffected  int64           `json:"maxAffected"`  // 单次写操作最多影响的行数，超出时回滚，默认 DefaultDBMaxAffected
+	AllowRawSQL  bool            `json:"allowRawSql"`  // 允许 db_query、db_query_one、db_execute 和带SQL条件的 db_update、db_delete
+}
+
+// ParseDBPolicy 解析JSON格式的访问策略，内容为空时返回 nil
+func ParseDBPolicy(data string) (*DBPolicy, error) {
+	if data == "" || data == "{}" {
+		return nil, nil
+	}
+	var policy DBPolicy
+	if err := json.Unmarshal([]byte(data), &policy); err != nil {
+		return nil, fmt.Errorf("invalid db policy: %v", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate 验证策略
+func (p *DBPolicy) Validate() error {
+	switch p.Mode {
+	case DBAccessNone, DBAccessReadOnly, DBAccessReadWrite:
+	default:
+		return fmt.Errorf("invalid db access mode: %s", p.Mode)
+	}
+	if p.TenantColumn != "" && !identifierPattern.MatchString(p.TenantColumn) {
+		return fmt.Errorf("invalid tenant column: %s", p.TenantColumn)
+	}
+	for _, t := range p.Tables {
+		if !identifierPattern.MatchString(t.Table) {
+			return fmt.Errorf("invalid table: %s", t.Table)
+		}
+		for _, c := range t.Columns {
+			if !identifierPattern.MatchString(c) {
+				return fmt.Errorf("invalid column: %s.%s", t.Table, c)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *DBPolicy) tenantColumn() string {
+	if p.TenantColumn == "" {
+		return DefaultTenantColumn
+	}
+	return p.TenantColumn
+}
+
+func (p *DBPolicy) maxRows() int {
+	if p.MaxRows <= 0 {
+		return DefaultDBMaxRows
+	}
+	return p.MaxRows
+}
+
+func (p *DBPolicy) maxAffected() int64 {
+	if p.MaxAffected <= 0 {
+		return DefaultDBMaxAffected
+	}
+	return p.MaxAffected
+}
+
+func (p *DBPolicy) table(name string) *DBTablePolicy {
+	for i := range p.Tables {
+		if p.Tables[i].Table == name {
+			return &p.Tables[i]
+		}
+	}
+	return nil
+}
+
+func (t *DBTablePolicy) allowColumn(column string) bool {
+	if len(t.Columns) == 0 {
+		return true
+	}
+	for _, c := range t.Columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// DBCallRecord 规则执行中的一次数据库调用
+type DBCallRecord struct {
+	Op       string `json:"op"`               // 操作：insert update delete query count execute transaction
+	Table    string `json:"table,omitempty"`  // 表名
+	SQL      string `json:"sql,omitempty"`    // 原始SQL或SQL条件
+	Rows     int64  `json:"rows"`             // 返回或影响的行数
+	Duration int64  `json:"duration"`         // 耗时(毫秒)
+	Denied   bool   `json:"denied,omitempty"` // 是否被策略拒绝
+	Error    string `json:"error,omitempty"`  // 错误信息
+}
+
+// DBCallCallback 数据库调用回调函数
+type DBCallCallback func(call DBCallRecord)
+
+// dbSession 单次执行的数据库会话，按策略检查每次调用并记录调用轨迹
+// policy 为空时不做限制，只用于可信的脚本
+type dbSession struct {
+	ctx      context.Context
+	svc      *DBOperationService
+	policy   *DBPolicy
+	tenantID string
+	onCall   DBCallCallback
+	// 超出行数限制的错误，事务内的写操作无法单独回滚，出现后整个事务和本次执行都失败
+	err error
+}
+
+func newDBSession(ctx context.Context, svc *DBOperationService, policy *DBPolicy, tenantID string, onCall DBCallCallback) *dbSession {
+	if svc == nil {
+		return nil
+	}
+	return &dbSession{
+		ctx:      ctx,
+		svc:      svc,
+		policy:   policy,
+		tenantID: tenantID,
+		onCall:   onCall,
+	}
+}
+
+// record 记录调用
+func (s *dbSession) record(op, table, sql string, start time.Time, rows int64, err error) {
+	if s.onCall == nil {
+		return
+	}
+	call := DBCallRecord{
+		Op:       op,
+		Table:    table,
+		SQL:      sql,
+		Rows:     rows,
+		Duration: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		call.Error = err.Error()
+		call.Denied = errors.Is(err, ErrDBAccessDenied)
+	}
+	s.onCall(call)
+}
+
+func denied(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrDBAccessDenied, fmt.Sprintf(format, args...))
+}
+
+// checkMode 检查访问模式
+func (s *dbSession) checkMode(write bool) error {
+	switch s.policy.Mode {
+	case DBAccessNone:
+		return denied("规则未授权访问数据库")
+	case DBAccessReadOnly:
+		if write {
+			return denied("规则只允许读取数据库")
+		}
+	}
+	return nil
+}
+
+// checkTable 检查表和列的访问权限，返回表策略，不限制时返回 nil
+func (s *dbSession) checkTable(table string, write bool, columns ...string) (*DBTablePolicy, error) {
+	if s.policy == nil {
+		return nil, nil
+	}
+	if err := s.checkMode(write); err != nil {
+		return nil, err
+	}
+	tp := s.policy.table(table)
+	if tp == nil {
+		return nil, denied("表 %s 未授权", table)
+	}
+	if write && tp.ReadOnly {
+		return nil, denied("表 %s 只读", table)
+	}
+	for _, c := range columns {
+		if !identifierPattern.MatchString(c) {
+			return nil, denied("非法的列名 %q", c)
+		}
+		if !tp.allowColumn(c) {
+			return nil, denied("列 %s.%s 未授权", table, c)
+		}
+		if write && !tp.NoTenant && c == s.policy.tenantColumn() {
+			return nil, denied("不允许修改租户列 %s", c)
+		}
+	}
+	return tp, nil
+}
+
+// checkRaw 检查原始SQL权限
+func (s *dbSession) checkRaw(write bool) error {
+	if s.policy == nil {
+		return nil
+	}
+	if err := s.checkMode(write); err != nil {
+		return err
+	}
+	if !s.policy.AllowRawSQL {
+		return denied("规则未授权执行原始SQL")
+	}
+	return nil
+}
+
+// scoped 表是否需要按租户过滤
+func (s *dbSession) scoped(tp *DBTablePolicy) bool {
+	return s.policy != nil && tp != nil && !tp.NoTenant
+}
+
+// scopeWhere 在SQL条件上追加租户条件
+func (s *dbSession) scopeWhere(tp *DBTablePolicy, whereSQL string, args []interface{}) (string, []interface{}) {
+	if !s.scoped(tp) {
+		return whereSQL, args
+	}
+	tenant := s.policy.tenantColumn() + " = ?"
+	if whereSQL == "" {
+		return tenant, []interface{}{s.tenantID}
+	}
+	return "(" + whereSQL + ") AND " + tenant, append(args, s.tenantID)
+}
+
+// write 执行写操作，影响行数超出限制时回滚
+func (s *dbSession) write(fn func(ctx context.Context) (int64, error)) (int64, error) {
+	if s.policy == nil {
+		return fn(s.ctx)
+	}
+	var affected int64
+	err := s.svc.Transaction(s.ctx, func(ctx context.Context) error {
+		var err error
+		if affected, err = fn(ctx); err != nil {
+			return err
+		}
+		if max := s.policy.maxAffected(); affected > max {
+			return fmt.Errorf("%w: 影响 %d 行，最多 %d 行", ErrDBRowLimit, affected, max)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrDBRowLimit) && s.err == nil {
+		s.err = err
+	}
+	return affected, err
+}
+
+// Err 返回导致本次执行失败的错误
+func (s *dbSession) Err() error {
+	if s == nil {
+		return nil
+	}
+	return s.err
+}
+
+// checkRows 检查查询返回的行数
+func (s *dbSession) checkRows(n int) error {
+	if s.policy == nil {
+		return nil
+	}
+	if max := s.policy.maxRows(); n > max {
+		return fmt.Errorf("%w: 返回 %d 行，最多 %d 行", ErrDBRowLimit, n, max)
+	}
+	return nil
+}
+
+// filterColumns 去掉结果中未授权的列
+func filterColumns(tp *DBTablePolicy, rows []map[string]interface{}) {
+	if tp == nil || len(tp.Columns) == 0 {
+		return
+	}
+	for _, row := range rows {
+		for k := range row {
+			if !tp.allowColumn(k) {
+				delete(row, k)
+			}
+		}
+	}
+}
+
+func mapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Insert 插入数据，按租户过滤的表自动填充租户列
+func (s *dbSession) Insert(table string, data map[string]interface{}) (rows int64, err error) {
+	start := time.Now()
+	defer func() { s.record("insert", table, "", start, rows, err) }()
+	tp, err := s.checkTable(table, true, mapKeys(data)...)
+	if err != nil {
+		return 0, err
+	}
+	if s.scoped(tp) {
+		data[s.policy.tenantColumn()] = s.tenantID
+	}
+	return s.write(func(ctx context.Context) (int64, error) {
+		return s.svc.Insert(ctx, table, data)
+	})
+}
+
+// Update 按SQL条件更新数据，SQL条件视为原始SQL
+func (s *dbSession) Update(table string, data map[string]interface{}, whereSQL string, whereArgs []interface{}) (rows int64, err error) {
+	start := time.Now()
+	defer func() { s.record("update", table, whereSQL, start, rows, err) }()
+	if whereSQL != "" {
+		if err = s.checkRaw(true); err != nil {
+			return 0, err
+		}
+	}
+	tp, err := s.checkTable(table, true, mapKeys(data)...)
+	if err != nil {
+		return 0, err
+	}
+	scopedSQL, scopedArgs := s.scopeWhere(tp, whereSQL, whereArgs)
+	return s.write(func(ctx context.Context) (int64, error) {
+		return s.svc.Update(ctx, table, data, scopedSQL, scopedArgs...)
+	})
+}
+
+// UpdateWithMap 按等值条件更新数据
+func (s *dbSession) UpdateWithMap(table string, where, data map[string]interface{}) (rows int64, err error) {
+	start := time.Now()
+	defer func() { s.record("update", table, "", start, rows, err) }()
+	tp, err := s.checkTable(table, true, mapKeys(data)...)
+	if err == nil {
+		_, err = s.checkTable(table, false, mapKeys(where)...)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if s.scoped(tp) {
+		where[s.policy.tenantColumn()] = s.tenantID
+	}
+	return s.write(func(ctx context.Context) (int64, error) {
+		return s.svc.UpdateWithMap(ctx, table, where, data)
+	})
+}
+
+// Delete 按SQL条件删除数据，SQL条件视为原始SQL
+func (s *dbSession) Delete(table, whereSQL string, whereArgs []interface{}) (rows int64, err error) {
+	start := time.Now()
+	defer func() { s.record("delete", table, whereSQL, start, rows, err) }()
+	if whereSQL != "" {
+		if err = s.checkRaw(true); err != nil {
+			return 0, err
+		}
+	}
+	tp, err := s.checkTable(table, true)
+	if err != nil {
+		return 0, err
+	}
+	scopedSQL, scopedArgs := s.scopeWhere(tp, whereSQL, whereArgs)
+	return s.write(func(ctx context.Context) (int64, error) {
+		return s.svc.Delete(ctx, table, scopedSQL, scopedArgs...)
+	})
+}
+
+// DeleteWithMap 按等值条件删除数据
+func (s *dbSession) DeleteWithMap(table string, where map[string]interface{}) (rows int64, err error) {
+	start := time.Now()
+	defer func() { s.record("delete", table, "", start, rows, err) }()
+	tp, err := s.checkTable(table, true)
+	if err == nil {
+		_, err = s.checkTable(table, false, mapKeys(where)...)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if s.scoped(tp) {
+		where[s.policy.tenantColumn()] = s.tenantID
+	}
+	return s.write(func(ctx context.Context) (int64, error) {
+		return s.svc.DeleteWithMap(ctx, table, where)
+	})
+}
+
+// Query 执行原始SQL查询
+func (s *dbSession) Query(sql string, args []interface{}) (results []map[string]interface{}, err error) {
+	start := time.Now()
+	defer func() { s.record("query", "", sql, start, int64(len(results)), err) }()
+	if err = s.checkRaw(false); err != nil {
+		return nil, err
+	}
+	if results, err = s.svc.Query(s.ctx, sql, args...); err != nil {
+		return nil, err
+	}
+	if err = s.checkRows(len(results)); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// QueryOne 执行原始SQL查询单条数据
+func (s *dbSession) QueryOne(sql string, args []interface{}) (result map[string]interface{}, err error) {
+	start := time.Now()
+	defer func() {
+		var rows int64
+		if len(result) > 0 {
+			rows = 1
+		}
+		s.record("query", "", sql, start, rows, err)
+	}()
+	if err = s.checkRaw(false); err != nil {
+		return nil, err
+	}
+	return s.svc.QueryOne(s.ctx, sql, args...)
+}
+
+// Execute 执行原始SQL
+func (s *dbSession) Execute(sql string, args []interface{}) (rows int64, err error) {
+	start := time.Now()
+	defer func() { s.record("execute", "", sql, start, rows, err) }()
+	if err = s.checkRaw(true); err != nil {
+		return 0, err
+	}
+	return s.write(func(ctx context.Context) (int64, error) {
+		return s.svc.Execute(ctx, sql, args...)
+	})
+}
+
+// checkQuery 检查查询构建器引用的列和操作符
+func (s *dbSession) checkQuery(table string, q *luaQuery) (*DBTablePolicy, error) {
+	if s.policy == nil {
+		return nil, nil
+	}
+	for _, cond := range q.builder.GetConditions() {
+		if cond.IsRaw || !allowedOperators[cond.Operator] {
+			return nil, denied("不支持的查询操作符 %q", cond.Operator)
+		}
+	}
+	return s.checkTable(table, false, q.fields...)
+}
+
+// QueryWithBuilder 使用查询构建器查询，按策略限制返回行数和列
+func (s *dbSession) QueryWithBuilder(table string, q *luaQuery) (results []map[string]interface{}, err error) {
+	start := time.Now()
+	defer func() { s.record("query", table, "", start, int64(len(results)), err) }()
+	tp, err := s.checkQuery(table, q)
+	if err != nil {
+		return nil, err
+	}
+	if s.scoped(tp) {
+		q.builder.WhereEq(s.policy.tenantColumn(), s.tenantID)
+	}
+	if s.policy != nil {
+		max := s.policy.maxRows()
+		if q.page == nil {
+			q.page = &db_query.Page{Current: 1, Size: max}
+			q.builder.WithPage(q.page)
+		} else if q.page.Size > max {
+			q.page.Size = max
+		}
+	}
+	if results, err = s.svc.QueryWithBuilder(s.ctx, table, q.builder); err != nil {
+		return nil, err
+	}
+	filterColumns(tp, results)
+	return results, nil
+}
+
+// CountWithBuilder 使用查询构建器统计数量
+func (s *dbSession) CountWithBuilder(table string, q *luaQuery) (count int64, err error) {
+	start := time.Now()
+	defer func() { s.record("count", table, "", start, count, err) }()
+	tp, err := s.checkQuery(table, q)
+	if err != nil {
+		return 0, err
+	}
+	if s.scoped(tp) {
+		q.builder.WhereEq(s.policy.tenantColumn(), s.tenantID)
+	}
+	return s.svc.CountWithBuilder(s.ctx, table, q.builder)
+}
+
+// Transaction 在事务中执行，事务内的数据库调用使用事务上下文
+func (s *dbSession) Transaction(fn func() error) (err error) {
+	start := time.Now()
+	defer func() { s.record("transaction", "", "", start, 0, err) }()
+	if s.policy != nil {
+		if err = s.checkMode(false); err != nil {
+			return err
+		}
+	}
+	outer := s.ctx
+	defer func() { s.ctx = outer }()
+	return s.svc.Transaction(outer, func(ctx context.Context) error {
+		s.ctx = ctx
+		if err := fn(); err != nil {
+			return err
+		}
+		return s.err
+	})
+}
@@ -0,0 +1,193 @@
+package lua_engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// dryRunData 只生成SQL不执行的数据库，记录生成的SQL
+type dryRunData struct {
+	database.IDataBase
+	db   *gorm.DB
+	sqls []string
+}
+
+func newDryRunData(t *testing.T) *dryRunData {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &dryRunData{db: db}
+	record := func(tx *gorm.DB) {
+		d.sqls = append(d.sqls, tx.Statement.SQL.String())
+	}
+	_ = db.Callback().Create().After("gorm:create").Register("test:record", record)
+	_ = db.Callback().Update().After("gorm:update").Register("test:record", record)
+	_ = db.Callback().Delete().After("gorm:delete").Register("test:record", record)
+	_ = db.Callback().Query().After("gorm:query").Register("test:record", record)
+	_ = db.Callback().Raw().After("gorm:raw").Register("test:record", record)
+	return d
+}
+
+func (d *dryRunData) DB(ctx context.Context) *gorm.DB {
+	return d.db.WithContext(ctx)
+}
+
+func (d *dryRunData) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (d *dryRunData) last() string {
+	if len(d.sqls) == 0 {
+		return ""
+	}
+	return d.sqls[len(d.sqls)-1]
+}
+
+var testPolicy = &DBPolicy{
+	Mode: DBAccessReadWrite,
+	Tables: []DBTablePolicy{
+		{Table: "orders", Columns: []string{"id", "amount", "status", "tenant_id"}},
+		{Table: "dicts", ReadOnly: true, NoTenant: true},
+	},
+}
+
+func runWithPolicy(t *testing.T, d *dryRunData, policy *DBPolicy, script string) ([]DBCallRecord, error) {
+	t.Helper()
+	var calls []DBCallRecord
+	executor := NewRuleExecutorWithDB(d)
+	opts := NewExecuteOptions().
+		WithDBPolicy(policy, "t1").
+		WithDBCallCallback(func(call DBCallRecord) {
+			calls = append(calls, call)
+		})
+	_, err := executor.Execute(script, opts)
+	return calls, err
+}
+
+func TestDBPolicyDenied(t *testing.T) {
+	d := newDryRunData(t)
+	cases := map[string]string{
+		"未授权的表":   `local r, err = db_query_builder("users", {}) assert(r == nil and err)`,
+		"未授权的列":   `local r, err = db_update_map("orders", {id = 1}, {secret = 1}) assert(r == nil and err)`,
+		"只读表":     `local r, err = db_insert("dicts", {name = "x"}) assert(r == nil and err)`,
+		"修改租户列":   `local r, err = db_update_map("orders", {id = 1}, {tenant_id = "t2"}) assert(r == nil and err)`,
+		"原始SQL":   `local r, err = db_query("select * from orders") assert(r == nil and err)`,
+		"SQL条件":   `local r, err = db_delete("orders", "id = ?", 1) assert(r == nil and err)`,
+		"非法操作符":   `local r, err = db_query_builder("orders", {where = {id = {operator = "= 1 OR 1 =", value = 1}}}) assert(r == nil and err)`,
+		"非法的排序字段": `local r, err = db_query_builder("orders", {order_by = {["id; drop table orders"] = "ASC"}}) assert(r == nil and err)`,
+	}
+	for name, script := range cases {
+		calls, err := runWithPolicy(t, d, testPolicy, script+` valid = true`)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(calls) != 1 || !calls[0].Denied {
+			t.Errorf("%s: calls = %+v", name, calls)
+		}
+	}
+	if len(d.sqls) != 0 {
+		t.Fatalf("denied calls reached the database: %v", d.sqls)
+	}
+
+	// 未配置访问模式时禁止访问
+	calls, err := runWithPolicy(t, d, &DBPolicy{}, `db_query_builder("orders", {}) valid = true`)
+	if err != nil || len(calls) != 1 || !calls[0].Denied {
+		t.Fatalf("mode none: calls=%+v err=%v", calls, err)
+	}
+	// 只读模式禁止写入
+	readOnly := *testPolicy
+	readOnly.Mode = DBAccessReadOnly
+	calls, err = runWithPolicy(t, d, &readOnly, `db_update_map("orders", {id = 1}, {status = 2}) valid = true`)
+	if err != nil || len(calls) != 1 || !calls[0].Denied {
+		t.Fatalf("read only: calls=%+v err=%v", calls, err)
+	}
+}
+
+func TestDBPolicyTenantScoped(t *testing.T) {
+	d := newDryRunData(t)
+
+	calls, err := runWithPolicy(t, d, testPolicy, `
+		db_query_builder("orders", {where = {status = {operator = "=", value = 1}}})
+		db_update_map("orders", {id = 1}, {status = 2})
+		db_delete_map("orders", {id = 1})
+		db_insert("orders", {id = 2, amount = 10})
+		db_query_builder("dicts", {})
+		valid = true
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 5 {
+		t.Fatalf("calls = %+v", calls)
+	}
+	for _, call := range calls {
+		if call.Error != "" {
+			t.Fatalf("call %+v failed", call)
+		}
+	}
+	if len(d.sqls) != 5 {
+		t.Fatalf("sqls = %v", d.sqls)
+	}
+	for i, sql := range d.sqls[:4] {
+		if !strings.Contains(sql, "tenant_id") {
+			t.Errorf("sql %d not scoped by tenant: %s", i, sql)
+		}
+	}
+	if !strings.Contains(d.sqls[0], "LIMIT") {
+		t.Errorf("query not limited: %s", d.sqls[0])
+	}
+	if strings.Contains(d.sqls[4], "tenant_id") {
+		t.Errorf("no tenant table scoped: %s", d.sqls[4])
+	}
+}
+
+func TestDBPolicyRawSQL(t *testing.T) {
+	d := newDryRunData(t)
+	policy := *testPolicy
+	policy.AllowRawSQL = true
+
+	calls, err := runWithPolicy(t, d, &policy, `
+		db_delete("orders", "id = ? OR status = ?", 1, 2)
+		valid = true
+	`)
+	if err != nil || len(calls) != 1 || calls[0].SQL != "id = ? OR status = ?" {
+		t.Fatalf("calls=%+v err=%v", calls, err)
+	}
+	// SQL条件加括号后再追加租户条件
+	if sql := d.last(); !strings.Contains(sql, "(id = ? OR status = ?) AND tenant_id = ?") {
+		t.Fatalf("sql = %s", sql)
+	}
+}
+
+func TestDBSessionRowLimit(t *testing.T) {
+	d := newDryRunData(t)
+	svc := NewDBOperationService(d)
+	policy := *testPolicy
+	policy.MaxAffected = 1
+	session := newDBSession(context.Background(), svc, &policy, "t1", nil)
+
+	// 事务内超出限制的写操作使整个事务失败
+	err := session.Transaction(func() error {
+		_, err := session.write(func(ctx context.Context) (int64, error) {
+			return 2, nil
+		})
+		if !errors.Is(err, ErrDBRowLimit) {
+			t.Fatalf("write: %v", err)
+		}
+		return nil
+	})
+	if !errors.Is(err, ErrDBRowLimit) || !errors.Is(session.Err(), ErrDBRowLimit) {
+		t.Fatalf("transaction: %v, session: %v", err, session.Err())
+	}
+}
@@ -17,26 +17,32 @@ type ContextModificationCallback func(modifiedContext map[string]interface{})
 // ExecuteOptions 规则执行选项
 type ExecuteOptions struct {
 	Timeout                     time.Duration               // 执行超时时间
-	MaxMemory                   uint64                      // 最大内存使用(bytes)
+	MaxMemory                   uint64                      // 执行期间脚本可达的 Lua 值(字符串、表、函数)增加的最大字节数，按对象大小估算，0 不限制
+	MaxInstructions             int64                       // 最大执行指令数，0 不限制
 	Context                     map[string]interface{}      // 上下文数据
 	RequireFields               []string                    // 必需的返回字段
 	CustomHelpers               map[string]HelperFunction   // 自定义辅助函数
 	DBService                   *DBOperationService         // 数据库操作服务
+	DBPolicy                    *DBPolicy                   // 数据库访问策略，为空时不限制
+	TenantID                    string                      // 租户ID，按策略过滤数据库访问
+	DBCallCallback              DBCallCallback              // 数据库调用回调函数，用于记录执行轨迹
 	ContextModificationCallback ContextModificationCallback // 上下文修改回调函数
 }
 
 // DefaultOptions 默认选项
 var DefaultOptions = &ExecuteOptions{
-	Timeout:   5 * time.Second,
-	MaxMemory: 10 * 1024 * 1024, // 10MB
+	Timeout:         5 * time.Second,
+	MaxMemory:       10 * 1024 * 1024, // 10MB
+	MaxInstructions: DefaultMaxInstructions,
 }
 
 // NewExecuteOptions 创建执行选项
 func NewExecuteOptions() *ExecuteOptions {
 	return &ExecuteOptions{
-		Timeout:       5 * time.Second,
-		MaxMemory:     10 * 1024 * 1024, // 10MB
-		CustomHelpers: make(map[string]HelperFunction),
+		Timeout:         5 * time.Second,
+		MaxMemory:       10 * 1024 * 1024, // 10MB
+		MaxInstructions: DefaultMaxInstructions,
+		CustomHelpers:   make(map[string]HelperFunction),
 	}
 }
 
@@ -61,6 +67,12 @@ func (opts *ExecuteOptions) WithMaxMemory(maxMemory uint64) *ExecuteOptions {
 	return opts
 }
 
+// WithMaxInstructions 设置最大执行指令数
+func (opts *ExecuteOptions) WithMaxInstructions(maxInstructions int64) *ExecuteOptions {
+	opts.MaxInstructions = maxInstructions
+	return opts
+}
+
 // WithContext 设置上下文数据
 func (opts *ExecuteOptions) WithContext(context map[string]interface{}) *ExecuteOptions {
 	opts.Context = context
@@ -73,6 +85,19 @@ func (opts *ExecuteOptions) WithDBService(dbService *DBOperationService) *Execut
 	return opts
 }
 
+// WithDBPolicy 设置数据库访问策略
+func (opts *ExecuteOptions) WithDBPolicy(policy *DBPolicy, tenantID string) *ExecuteOptions {
+	opts.DBPolicy = policy
+	opts.TenantID = tenantID
+	return opts
+}
+
+// WithDBCallCallback 设置数据库调用回调函数
+func (opts *ExecuteOptions) WithDBCallCallback(callback DBCallCallback) *ExecuteOptions {
+	opts.DBCallCallback = callback
+	return opts
+}
+
 // WithContextModificationCallback 设置上下文修改回调函数
 func (opts *ExecuteOptions) WithContextModificationCallback(callback ContextModificationCallback) *ExecuteOptions {
 	opts.ContextModificationCallback = callback
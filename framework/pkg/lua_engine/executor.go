@@ -1,6 +1,7 @@
 package lua_engine
 
 import (
+	"context"
 	"fmt"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 	"sync"
@@ -12,50 +13,71 @@ import (
 )
 
 // RuleExecutor Lua规则执行器
+// 脚本在沙箱状态机中执行：只开放 base/table/string/math 和 os 的时间函数，
+// 通过 SetContext 实现超时取消，并限制指令数、调用深度和库函数分配的内存
 type RuleExecutor struct {
 	pool               sync.Pool
+	sandbox            SandboxConfig             // 沙箱配置
 	customHelpers      map[string]HelperFunction // 自定义辅助函数
 	customHelpersMutex sync.RWMutex              // 自定义辅助函数读写锁
 	dbService          *DBOperationService       // 数据库操作服务
 }
 
 // NewRuleExecutor 创建规则执行器
-func NewRuleExecutor() *RuleExecutor {
-	return &RuleExecutor{
-		pool: sync.Pool{
-			New: func() interface{} {
-				return lua.NewState()
-			},
-		},
+func NewRuleExecutor(opts ...ExecutorOption) *RuleExecutor {
+	e := &RuleExecutor{
+		sandbox:       DefaultSandboxConfig,
 		customHelpers: make(map[string]HelperFunction),
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.pool.New = func() interface{} {
+		return e.newState()
+	}
+	return e
 }
 
 // NewRuleExecutorWithDB 创建带数据库支持的规则执行器
-func NewRuleExecutorWithDB(db database.IDataBase) *RuleExecutor {
-	return &RuleExecutor{
-		pool: sync.Pool{
-			New: func() interface{} {
-				return lua.NewState()
-			},
-		},
-		customHelpers: make(map[string]HelperFunction),
-		dbService:     NewDBOperationService(db),
-	}
+func NewRuleExecutorWithDB(db database.IDataBase, opts ...ExecutorOption) *RuleExecutor {
+	e := NewRuleExecutor(opts...)
+	e.dbService = NewDBOperationService(db)
+	return e
+}
+
+// newState 创建沙箱状态机
+func (e *RuleExecutor) newState() *lua.LState {
+	return newSandboxState(e.sandbox)
 }
 
 // Execute 执行规则
 func (e *RuleExecutor) Execute(script string, opts *ExecuteOptions) (*ExecuteResult, error) {
+	return e.ExecuteWithContext(context.Background(), script, opts)
+}
+
+// ExecuteWithContext 执行规则，ctx 取消或超时后脚本在下一条指令处停止
+func (e *RuleExecutor) ExecuteWithContext(ctx context.Context, script string, opts *ExecuteOptions) (*ExecuteResult, error) {
 	if opts == nil {
 		opts = DefaultOptions
 	}
-
-	// 从池中获取Lua状态机
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	// 从池中获取Lua状态机，执行失败的状态机可能处于异常状态，直接丢弃不再归还
 	L := e.pool.Get().(*lua.LState)
-	defer e.pool.Put(L)
+	healthy := false
+	defer func() {
+		if healthy {
+			e.pool.Put(L)
+		} else {
+			L.Close()
+		}
+	}()
 
-	// 高效重置状态机（避免重新创建）
-	e.resetLuaState(L, opts.MaxMemory)
+	// 还原上次执行留下的全局变量
+	e.resetLuaState(L)
 
 	// 注入上下文数据
 	contextTable := L.NewTable()
@@ -73,26 +95,26 @@ func (e *RuleExecutor) Execute(script string, opts *ExecuteOptions) (*ExecuteRes
 	if opts.DBService != nil {
 		dbService = opts.DBService
 	}
-	e.registerDBHelperFunctions(L, dbService)
+	session := newDBSession(ctx, dbService, opts.DBPolicy, opts.TenantID, opts.DBCallCallback)
+	e.registerDBHelperFunctions(L, session)
 
 	// 执行脚本
 	start := utils.GetTimeNow()
-	done := make(chan error, 1)
-	go func() {
-		done <- L.DoString(script)
-	}()
-
-	// 等待执行完成或超时
-	var err error
-	select {
-	case err = <-done:
-	case <-time.After(opts.Timeout):
-		err = fmt.Errorf("执行超时")
+	budget := newExecBudget(ctx, L, opts.MaxInstructions, opts.MaxMemory)
+	L.SetContext(budget)
+	err := L.DoString(script)
+	L.RemoveContext()
+	// 脚本用 pcall 捕获了预算错误也视为失败
+	if berr := budget.Err(); berr != nil {
+		return nil, sandboxError(berr)
 	}
-
 	if err != nil {
 		return nil, err
 	}
+	// 写操作超出行数限制
+	if serr := session.Err(); serr != nil {
+		return nil, serr
+	}
 
 	// 获取执行结果
 	result := &ExecuteResult{
@@ -105,6 +127,7 @@ func (e *RuleExecutor) Execute(script string, opts *ExecuteOptions) (*ExecuteRes
 	// 提取修改后的上下文
 	modifiedContext := e.extractModifiedContext(L, opts.Context)
 	result.Context = modifiedContext
+	healthy = true
 
 	// 调用上下文修改回调函数
 	if opts.ContextModificationCallback != nil {
@@ -116,23 +139,8 @@ func (e *RuleExecutor) Execute(script string, opts *ExecuteOptions) (*ExecuteRes
 
 // ValidateScript 验证脚本语法
 func (e *RuleExecutor) ValidateScript(script string) error {
-	L := e.pool.Get().(*lua.LState)
-	defer e.pool.Put(L)
-
-	// 重置状态机
-	L.Close()
-	L = lua.NewState()
-
-	// 注入空的上下文和辅助函数
-	contextTable := L.NewTable()
-	L.SetGlobal("context", contextTable)
-	e.registerHelperFunctions(L)
-
-	// 注册自定义辅助函数
-	e.registerCustomHelperFunctions(L, nil)
-
-	// 注册数据库操作辅助函数
-	e.registerDBHelperFunctions(L, e.dbService)
+	L := e.newState()
+	defer L.Close()
 
 	// 解析脚本
 	_, err := L.LoadString(script)
@@ -145,12 +153,8 @@ func (e *RuleExecutor) ValidateScript(script string) error {
 
 // CompileTemplate 编译规则模板
 func (e *RuleExecutor) CompileTemplate(template string, params map[string]interface{}) (string, error) {
-	L := e.pool.Get().(*lua.LState)
-	defer e.pool.Put(L)
-
-	// 重置状态机
-	L.Close()
-	L = lua.NewState()
+	L := e.newState()
+	defer L.Close()
 
 	// 注入模板参数
 	paramsTable := L.NewTable()
@@ -111,8 +111,8 @@ func TestRuleExecutor_ExecuteWithCustomHelpers(t *testing.T) {
 		set_context_value("sum", result1)
 		set_context_value("message", result2)
 		
-		-- 返回验证结果
-		return true, "success", {sum = result1, message = result2}
+		-- 设置验证结果
+		success("success", {sum = result1, message = result2})
 	`
 
 	opts := NewExecuteOptions().WithTimeout(5 * time.Second)
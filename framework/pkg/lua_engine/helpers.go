@@ -901,18 +901,9 @@ func (e *RuleExecutor) convertStructToLua(L *lua.LState, value interface{}) lua.
 }
 
 // resetLuaState 高效重置Lua状态机
-func (e *RuleExecutor) resetLuaState(L *lua.LState, maxMemory uint64) {
-	// 清空全局表而不是重新创建状态机
-	L.SetGlobal("context", lua.LNil)
-	L.SetGlobal("valid", lua.LNil)
-	L.SetGlobal("action", lua.LNil)
-	L.SetGlobal("action_params", lua.LNil)
-	L.SetGlobal("variables", lua.LNil)
-
-	// 设置内存限制
-	if maxMemory > 0 {
-		L.SetMx(int(maxMemory))
-	}
+// 按创建时的快照还原全局变量，清除上次执行设置的 valid、action 和辅助函数
+func (e *RuleExecutor) resetLuaState(L *lua.LState) {
+	resetSandboxGlobals(L)
 }
 
 // injectContextData 高效注入上下文数据
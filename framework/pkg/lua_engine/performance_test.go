@@ -201,7 +201,7 @@ func BenchmarkLuaStateReset(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		executor.resetLuaState(L, 10*1024*1024) // 10MB
+		executor.resetLuaState(L)
 	}
 }
 
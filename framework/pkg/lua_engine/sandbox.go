@@ -0,0 +1,362 @@
+package lua_engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// 沙箱执行错误
+var (
+	ErrExecuteTimeout   = errors.New("执行超时")
+	ErrExecuteCanceled  = errors.New("执行已取消")
+	ErrInstructionLimit = errors.New("超出指令数限制")
+	ErrMemoryLimit      = errors.New("超出内存限制")
+)
+
+const (
+	// DefaultMaxCallDepth 默认最大调用深度
+	DefaultMaxCallDepth = 200
+	// DefaultMaxStackSize 默认数据栈最大槽位数
+	DefaultMaxStackSize = 256 * 1024
+	// DefaultMaxInstructions 默认单次执行的最大指令数
+	DefaultMaxInstructions int64 = 10000000
+
+	// 注册表中保存全局变量快照的键
+	sandboxGlobalsKey = "__sandbox_globals"
+	// 扫描寄存器时忽略的短字符串长度
+	minTrackedString = 256
+	// 每执行多少条指令扫描一次寄存器，拼接一次最少需要 3 条指令
+	memoryCheckInterval = 4
+	// 全量统计内存的最小指令间隔
+	minMeasureInterval = 16384
+)
+
+// 基础库中移除的函数：加载代码、访问文件、修改函数环境和绕过只读保护
+var unsafeBaseFuncs = []string{
+	"dofile", "loadfile", "load", "loadstring", "require", "module",
+	"getfenv", "setfenv", "rawset", "collectgarbage", "newproxy", "_printregs",
+}
+
+// os 库只保留时间相关函数
+var safeOsFuncs = []string{"time", "clock", "date", "difftime"}
+
+// 只读的标准库，脚本无法修改其中的函数影响后续执行
+var frozenLibs = []string{lua.StringLibName, lua.TabLibName, lua.MathLibName, lua.OsLibName}
+
+// SandboxConfig 沙箱状态机配置，创建状态机时生效
+type SandboxConfig struct {
+	MaxCallDepth int // 最大调用深度，超出时报 stack overflow
+	MaxStackSize int // 数据栈最大槽位数
+}
+
+// DefaultSandboxConfig 默认沙箱配置
+var DefaultSandboxConfig = SandboxConfig{
+	MaxCallDepth: DefaultMaxCallDepth,
+	MaxStackSize: DefaultMaxStackSize,
+}
+
+// ExecutorOption 执行器选项
+type ExecutorOption func(*RuleExecutor)
+
+// WithMaxCallDepth 设置最大调用深度
+func WithMaxCallDepth(depth int) ExecutorOption {
+	return func(e *RuleExecutor) {
+		e.sandbox.MaxCallDepth = depth
+	}
+}
+
+// WithMaxStackSize 设置数据栈最大槽位数
+func WithMaxStackSize(size int) ExecutorOption {
+	return func(e *RuleExecutor) {
+		e.sandbox.MaxStackSize = size
+	}
+}
+
+// newSandboxState 创建只开放白名单标准库的状态机
+func newSandboxState(cfg SandboxConfig) *lua.LState {
+	registrySize := lua.RegistrySize
+	if cfg.MaxStackSize > 0 && cfg.MaxStackSize < registrySize {
+		registrySize = cfg.MaxStackSize
+	}
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:        true,
+		CallStackSize:       cfg.MaxCallDepth,
+		RegistrySize:        registrySize,
+		RegistryMaxSize:     cfg.MaxStackSize,
+		MinimizeStackMemory: true,
+	})
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.LoadLibName, lua.OpenPackage}, // 基础库依赖 package 注册模块，必须最先打开
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+		{lua.OsLibName, lua.OpenOs},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	globals := L.G.Global
+	globals.RawSetString(lua.LoadLibName, lua.LNil)
+	for _, name := range unsafeBaseFuncs {
+		globals.RawSetString(name, lua.LNil)
+	}
+	os := L.NewTable()
+	if lib, ok := globals.RawGetString(lua.OsLibName).(*lua.LTable); ok {
+		for _, name := range safeOsFuncs {
+			os.RawSetString(name, lib.RawGetString(name))
+		}
+	}
+	globals.RawSetString(lua.OsLibName, os)
+	if lib, ok := globals.RawGetString(lua.StringLibName).(*lua.LTable); ok {
+		lib.RawSetString("rep", L.NewFunction(sandboxStrRep))
+	}
+	if lib, ok := globals.RawGetString(lua.TabLibName).(*lua.LTable); ok {
+		lib.RawSetString("concat", L.NewFunction(sandboxTableConcat))
+	}
+
+	for _, name := range frozenLibs {
+		if lib, ok := globals.RawGetString(name).(*lua.LTable); ok {
+			globals.RawSetString(name, readonlyTable(L, lib))
+		}
+	}
+	// 字符串的方法调用走字符串元表，同样替换为只读库并禁止 getmetatable 取到元表
+	if mt, ok := L.GetMetatable(lua.LString("")).(*lua.LTable); ok {
+		mt.RawSetString("__index", globals.RawGetString(lua.StringLibName))
+		mt.RawSetString("__metatable", lua.LString("locked"))
+	}
+
+	// 保存全局变量快照，归还到池中前据此还原
+	snapshot := L.NewTable()
+	globals.ForEach(func(k, v lua.LValue) {
+		snapshot.RawSet(k, v)
+	})
+	L.G.Registry.RawSetString(sandboxGlobalsKey, snapshot)
+	return L
+}
+
+// readonlyTable 返回只读代理表，写入时报错
+func readonlyTable(L *lua.LState, t *lua.LTable) *lua.LTable {
+	proxy := L.NewTable()
+	mt := L.NewTable()
+	mt.RawSetString("__index", t)
+	mt.RawSetString("__newindex", L.NewFunction(func(L *lua.LState) int {
+		L.RaiseError("attempt to modify a read-only table")
+		return 0
+	}))
+	mt.RawSetString("__metatable", lua.LString("locked"))
+	L.SetMetatable(proxy, mt)
+	return proxy
+}
+
+// resetSandboxGlobals 按快照还原全局变量，删除脚本和辅助函数新增的全局变量
+func resetSandboxGlobals(L *lua.LState) {
+	snapshot, ok := L.G.Registry.RawGetString(sandboxGlobalsKey).(*lua.LTable)
+	if !ok {
+		return
+	}
+	globals := L.G.Global
+	var added []lua.LValue
+	globals.ForEach(func(k, _ lua.LValue) {
+		if snapshot.RawGet(k) == lua.LNil {
+			added = append(added, k)
+		}
+	})
+	for _, k := range added {
+		globals.RawSet(k, lua.LNil)
+	}
+	snapshot.ForEach(func(k, v lua.LValue) {
+		globals.RawSet(k, v)
+	})
+	L.SetMetatable(globals, lua.LNil)
+	L.SetTop(0)
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// execBudget 单次执行的资源预算
+// 通过 SetContext 交给虚拟机，虚拟机每执行一条指令调用一次 Done，借此统计指令数和检查内存；
+// 超时、取消或超出预算后 Done 返回已关闭的通道，虚拟机在下一条指令处抛出错误
+//
+// 内存按脚本可达的 Lua 值统计(见 memoryMeter)，只计执行期间增加的部分：
+//   - 全量统计的间隔随可达对象数增长，遍历的开销分摊到每条指令上是常数
+//   - 两次全量统计之间每 memoryCheckInterval 条指令扫描一次当前函数的寄存器，新出现的长字符串和库函数的分配先累计，
+//     累计后超出预算时立即全量统计，释放的内存不计入
+//
+// 超出预算后最多再执行一个全量统计间隔，每条指令产生的短字符串不超过 minTrackedString，超出的部分有上限
+type execBudget struct {
+	context.Context
+	L               *lua.LState
+	maxInstructions int64
+	instructions    int64
+	maxMemory       int64
+	exceeded        atomic.Value
+
+	// 以下字段只在执行脚本的协程中访问
+	meter           *memoryMeter
+	baseline        int64 // 执行前状态机中已有的内存
+	live            int64 // 最近一次全量统计时脚本占用的内存
+	pending         int64 // 全量统计后新出现的长字符串和库函数分配
+	ticks           int   // 距离上次扫描寄存器的指令数
+	sinceMeasure    int   // 距离上次全量统计的指令数
+	measureInterval int   // 全量统计的间隔
+}
+
+func newExecBudget(ctx context.Context, L *lua.LState, maxInstructions int64, maxMemory uint64) *execBudget {
+	b := &execBudget{
+		Context:         ctx,
+		L:               L,
+		maxInstructions: maxInstructions,
+		maxMemory:       int64(maxMemory),
+	}
+	if b.maxMemory > 0 && L != nil {
+		b.meter = newMemoryMeter()
+		b.baseline = b.meter.measure(L)
+		b.measureInterval = b.nextMeasureInterval()
+	}
+	return b
+}
+
+func (b *execBudget) Done() <-chan struct{} {
+	if b.maxInstructions > 0 && atomic.AddInt64(&b.instructions, 1) > b.maxInstructions {
+		b.exceed(ErrInstructionLimit)
+	}
+	if b.meter != nil {
+		if b.ticks++; b.ticks >= memoryCheckInterval {
+			b.checkMemory()
+		}
+	}
+	if b.exceeded.Load() != nil {
+		return closedChan
+	}
+	return b.Context.Done()
+}
+
+// checkMemory 扫描寄存器，到达统计间隔或累计超出预算时全量统计
+func (b *execBudget) checkMemory() {
+	b.sinceMeasure += b.ticks
+	b.ticks = 0
+	b.pending += b.meter.registerBytes(b.L)
+	if b.sinceMeasure < b.measureInterval && b.live+b.pending <= b.maxMemory {
+		return
+	}
+	if b.measure() > b.maxMemory {
+		b.exceed(ErrMemoryLimit)
+	}
+}
+
+// measure 全量统计脚本占用的内存
+func (b *execBudget) measure() int64 {
+	b.live = b.meter.measure(b.L) - b.baseline
+	b.pending = 0
+	b.sinceMeasure = 0
+	b.measureInterval = b.nextMeasureInterval()
+	return b.live
+}
+
+func (b *execBudget) nextMeasureInterval() int {
+	return max(minMeasureInterval, 2*b.meter.objects)
+}
+
+func (b *execBudget) Err() error {
+	if err, ok := b.exceeded.Load().(error); ok {
+		return err
+	}
+	return b.Context.Err()
+}
+
+func (b *execBudget) exceed(err error) {
+	b.exceeded.CompareAndSwap(nil, err)
+}
+
+// alloc 库函数分配前检查内存预算，超出时抛出错误
+func (b *execBudget) alloc(L *lua.LState, n int64) {
+	if b.meter == nil {
+		return
+	}
+	if n <= b.maxMemory && b.live+b.pending+n > b.maxMemory {
+		b.measure()
+	}
+	if n > b.maxMemory || b.live+b.pending+n > b.maxMemory {
+		b.exceed(ErrMemoryLimit)
+		L.RaiseError("%s", ErrMemoryLimit.Error())
+	}
+	b.pending += n
+}
+
+// sandboxError 将预算错误转换为对外的执行错误
+func sandboxError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrExecuteTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrExecuteCanceled
+	}
+	return err
+}
+
+// budgetOf 获取状态机当前的执行预算，不在沙箱执行中时返回 nil
+func budgetOf(L *lua.LState) *execBudget {
+	b, _ := L.Context().(*execBudget)
+	return b
+}
+
+// sandboxStrRep string.rep 分配前检查内存预算
+func sandboxStrRep(L *lua.LState) int {
+	str := L.CheckString(1)
+	n := L.CheckInt(2)
+	if n <= 0 {
+		L.Push(lua.LString(""))
+		return 1
+	}
+	if b := budgetOf(L); b != nil {
+		size := int64(len(str)) * int64(n)
+		if len(str) > 0 && size/int64(len(str)) != int64(n) {
+			size = b.maxMemory + 1
+		}
+		b.alloc(L, size)
+	}
+	L.Push(lua.LString(strings.Repeat(str, n)))
+	return 1
+}
+
+// sandboxTableConcat table.concat 分配前检查内存预算
+func sandboxTableConcat(L *lua.LState) int {
+	tbl := L.CheckTable(1)
+	sep := L.OptString(2, "")
+	i := L.OptInt(3, 1)
+	j := L.OptInt(4, tbl.Len())
+	if i > j {
+		L.Push(lua.LString(""))
+		return 1
+	}
+	parts := make([]string, 0, j-i+1)
+	var size int64
+	for k := i; k <= j; k++ {
+		v := tbl.RawGetInt(k)
+		if !lua.LVCanConvToString(v) {
+			L.ArgError(1, "invalid value (at index "+lua.LNumber(k).String()+") in table for concat")
+		}
+		s := lua.LVAsString(v)
+		size += int64(len(s) + len(sep))
+		parts = append(parts, s)
+	}
+	if b := budgetOf(L); b != nil {
+		b.alloc(L, size)
+	}
+	L.Push(lua.LString(strings.Join(parts, sep)))
+	return 1
+}
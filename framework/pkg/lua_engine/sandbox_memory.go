@@ -0,0 +1,134 @@
+package lua_engine
+
+import (
+	"unsafe"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// 估算内存时使用的对象大小，与 gopher-lua 的实际占用同一量级，用于限制内存而非精确统计
+const (
+	valueSlotSize   = 16 // LValue 接口占用的字节数
+	stringOverhead  = 16 // 字符串头
+	objectOverhead  = 64 // 表、函数等对象本身
+	tableEntrySize  = 2 * valueSlotSize
+	dedupStringSize = 64 // 不短于该长度的字符串按底层数据去重，多处引用同一个字符串只计一次
+)
+
+// memoryMeter 统计脚本可以访问到的 Lua 值占用的内存
+// 从全局变量、当前函数环境和调用栈上每一层的局部变量、临时寄存器、函数及其上值出发遍历，
+// 表中的键值、元表都计入，脚本不再引用的值不计入
+type memoryMeter struct {
+	tables  map[*lua.LTable]struct{}
+	funcs   map[*lua.LFunction]struct{}
+	strings map[*byte]struct{} // 已计入的长字符串，两次统计之间也用于寄存器扫描去重
+	queue   []lua.LValue
+	objects int // 最近一次统计访问的对象数
+}
+
+func newMemoryMeter() *memoryMeter {
+	return &memoryMeter{
+		tables:  make(map[*lua.LTable]struct{}),
+		funcs:   make(map[*lua.LFunction]struct{}),
+		strings: make(map[*byte]struct{}),
+	}
+}
+
+// measure 统计状态机中可达的值占用的字节数
+func (m *memoryMeter) measure(L *lua.LState) int64 {
+	clear(m.tables)
+	clear(m.funcs)
+	clear(m.strings)
+	m.objects = 0
+	m.queue = append(m.queue[:0], L.G.Global, L.Env)
+	for level := 0; ; level++ {
+		dbg, ok := L.GetStack(level)
+		if !ok {
+			break
+		}
+		if fn, err := L.GetInfo("f", dbg, lua.LNil); err == nil {
+			m.push(fn)
+		}
+		// 局部变量之后是临时寄存器，名称为空时已超出该层使用的寄存器
+		for n := 1; ; n++ {
+			name, v := L.GetLocal(dbg, n)
+			if name == "" {
+				break
+			}
+			m.push(v)
+		}
+	}
+
+	var size int64
+	for len(m.queue) > 0 {
+		v := m.queue[len(m.queue)-1]
+		m.queue = m.queue[:len(m.queue)-1]
+		m.objects++
+		switch v := v.(type) {
+		case lua.LString:
+			if m.countString(v) {
+				size += int64(stringOverhead + len(v))
+			}
+		case *lua.LTable:
+			if _, ok := m.tables[v]; ok {
+				continue
+			}
+			m.tables[v] = struct{}{}
+			size += objectOverhead
+			v.ForEach(func(key, value lua.LValue) {
+				size += tableEntrySize
+				m.push(key)
+				m.push(value)
+			})
+			m.push(v.Metatable)
+		case *lua.LFunction:
+			if _, ok := m.funcs[v]; ok {
+				continue
+			}
+			m.funcs[v] = struct{}{}
+			size += objectOverhead
+			for _, uv := range v.Upvalues {
+				size += valueSlotSize
+				m.push(uv.Value())
+			}
+			if v.Env != nil {
+				m.push(v.Env)
+			}
+		}
+	}
+	return size
+}
+
+// push 只有字符串、表和函数需要继续统计，其他值的大小已计入所在的槽位
+func (m *memoryMeter) push(v lua.LValue) {
+	switch v.(type) {
+	case lua.LString, *lua.LTable, *lua.LFunction:
+		m.queue = append(m.queue, v)
+	}
+}
+
+// countString 字符串是否需要计入，长字符串已计入时返回 false
+func (m *memoryMeter) countString(s lua.LString) bool {
+	if len(s) < dedupStringSize {
+		return true
+	}
+	p := unsafe.StringData(string(s))
+	if _, ok := m.strings[p]; ok {
+		return false
+	}
+	m.strings[p] = struct{}{}
+	return true
+}
+
+// registerBytes 统计当前函数寄存器中上次统计后新出现的长字符串
+// 字符串拼接(..)由虚拟机直接执行，结果总是先写入寄存器，在两次全量统计之间据此及时发现大字符串
+func (m *memoryMeter) registerBytes(L *lua.LState) int64 {
+	var size int64
+	for i, top := 1, L.GetTop(); i <= top; i++ {
+		s, ok := L.Get(i).(lua.LString)
+		if ok && len(s) >= minTrackedString && m.countString(s) {
+			size += int64(stringOverhead + len(s))
+		}
+	}
+	return size
+}
@@ -0,0 +1,184 @@
+package lua_engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandboxInfiniteLoopTimeout(t *testing.T) {
+	executor := NewRuleExecutor()
+	opts := NewExecuteOptions().WithTimeout(100 * time.Millisecond).WithMaxInstructions(0)
+
+	start := time.Now()
+	_, err := executor.Execute(`while true do end`, opts)
+	if !errors.Is(err, ErrExecuteTimeout) {
+		t.Fatalf("err = %v, want ErrExecuteTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("script kept running for %v", elapsed)
+	}
+
+	// 超时的状态机不会归还到池中，后续执行正常
+	result, err := executor.Execute(`valid = true`, NewExecuteOptions())
+	if err != nil || !result.Valid {
+		t.Fatalf("execute after timeout: result=%+v err=%v", result, err)
+	}
+}
+
+func TestSandboxInstructionBudget(t *testing.T) {
+	executor := NewRuleExecutor()
+	opts := NewExecuteOptions().WithTimeout(time.Minute).WithMaxInstructions(10000)
+
+	_, err := executor.Execute(`while true do end`, opts)
+	if !errors.Is(err, ErrInstructionLimit) {
+		t.Fatalf("err = %v, want ErrInstructionLimit", err)
+	}
+
+	// pcall 捕获预算错误也无法继续执行
+	_, err = executor.Execute(`
+		for i = 1, 100 do
+			pcall(function() while true do end end)
+		end
+		valid = true
+	`, opts)
+	if !errors.Is(err, ErrInstructionLimit) {
+		t.Fatalf("pcall: err = %v, want ErrInstructionLimit", err)
+	}
+}
+
+func TestSandboxCancel(t *testing.T) {
+	executor := NewRuleExecutor()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := executor.ExecuteWithContext(ctx, `while true do end`, NewExecuteOptions().WithMaxInstructions(0))
+	if !errors.Is(err, ErrExecuteCanceled) {
+		t.Fatalf("err = %v, want ErrExecuteCanceled", err)
+	}
+}
+
+func TestSandboxUnsafeLibraries(t *testing.T) {
+	executor := NewRuleExecutor()
+	scripts := map[string]string{
+		"os.execute": `os.execute("echo pwned")`,
+		"os.exit":    `os.exit(1)`,
+		"io":         `io.open("/etc/passwd")`,
+		"load":       `load("return 1")()`,
+		"loadstring": `loadstring("return 1")()`,
+		"dofile":     `dofile("/etc/passwd")`,
+		"require":    `require("os")`,
+		"debug":      `debug.getinfo(1)`,
+	}
+	for name, script := range scripts {
+		if _, err := executor.Execute(script, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// 白名单内的库可以正常使用
+	result, err := executor.Execute(`
+		valid = os.time() > 0 and string.upper("a") == "A" and ("b"):upper() == "B" and math.max(1, 2) == 2
+	`, nil)
+	if err != nil || !result.Valid {
+		t.Fatalf("whitelisted libs: result=%+v err=%v", result, err)
+	}
+}
+
+func TestSandboxGlobalsIsolated(t *testing.T) {
+	executor := NewRuleExecutor()
+
+	// 标准库只读
+	if _, err := executor.Execute(`string.upper = function() return "x" end`, nil); err == nil {
+		t.Fatal("expected error when modifying string library")
+	}
+	if _, err := executor.Execute(`getmetatable("").__index = {}`, nil); err == nil {
+		t.Fatal("expected error when modifying string metatable")
+	}
+
+	// 脚本修改的全局变量不会影响下次执行
+	if _, err := executor.Execute(`tostring = nil; leaked = 1; valid = true`, nil); err != nil {
+		t.Fatal(err)
+	}
+	result, err := executor.Execute(`valid = leaked == nil and tostring(1) == "1"`, nil)
+	if err != nil || !result.Valid {
+		t.Fatalf("globals leaked between executions: result=%+v err=%v", result, err)
+	}
+}
+
+func TestSandboxMemoryLimit(t *testing.T) {
+	executor := NewRuleExecutor()
+	opts := NewExecuteOptions().WithMaxMemory(1024 * 1024)
+
+	_, err := executor.Execute(`local s = string.rep("x", 1024 * 1024 * 1024)`, opts)
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("string.rep: err = %v, want ErrMemoryLimit", err)
+	}
+
+	_, err = executor.Execute(`
+		local parts = {}
+		for i = 1, 100 do parts[i] = string.rep("x", 1024 * 64) end
+		local s = table.concat(parts)
+	`, opts)
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("table.concat: err = %v, want ErrMemoryLimit", err)
+	}
+
+	// .. 拼接由虚拟机直接执行，同样受内存限制
+	_, err = executor.Execute(`local s = "x" for i = 1, 27 do s = s .. s end`, opts)
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("concat: err = %v, want ErrMemoryLimit", err)
+	}
+	_, err = executor.Execute(`local s = "x" for i = 1, 27 do s = s .. s end`, NewExecuteOptions().WithMaxMemory(1024*1024).WithMaxInstructions(0))
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("concat without instruction limit: err = %v, want ErrMemoryLimit", err)
+	}
+
+	// 表中保存的字符串和表本身同样计入
+	_, err = executor.Execute(`
+		local big = string.rep("x", 1000)
+		local t = {}
+		for i = 1, 300000 do t[i] = big .. i end
+	`, opts)
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("table of strings: err = %v, want ErrMemoryLimit", err)
+	}
+	_, err = executor.Execute(`
+		local t = {}
+		local function fill() for i = 1, 300000 do t[i] = {i} end end
+		fill()
+	`, opts)
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("table of tables: err = %v, want ErrMemoryLimit", err)
+	}
+
+	// 不再引用的值不计入
+	result, err := executor.Execute(`
+		for i = 1, 2000 do local s = string.rep("y", 1000) .. i end
+		valid = true
+	`, opts)
+	if err != nil || !result.Valid {
+		t.Fatalf("released allocation: result=%+v err=%v", result, err)
+	}
+
+	result, err = executor.Execute(`
+		local s = string.rep("ab", 1024)
+		local t = s
+		for i = 1, 8 do s = s .. "c" end
+		valid = #string.rep("ab", 10) == 20 and #s == 2056
+	`, opts)
+	if err != nil || !result.Valid {
+		t.Fatalf("small allocation: result=%+v err=%v", result, err)
+	}
+}
+
+func TestSandboxCallDepth(t *testing.T) {
+	executor := NewRuleExecutor(WithMaxCallDepth(50))
+
+	_, err := executor.Execute(`local function f(n) return 1 + f(n + 1) end f(1)`, nil)
+	if err == nil || !strings.Contains(err.Error(), "stack overflow") {
+		t.Fatalf("err = %v, want stack overflow", err)
+	}
+}
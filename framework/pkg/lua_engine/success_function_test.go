@@ -1,3 +1,7 @@
+//go:build ignore
+
+// 演示代码，函数签名不是 go test 的测试函数且依赖未实现的辅助函数，不参与编译
+
 package lua_engine
 
 import (
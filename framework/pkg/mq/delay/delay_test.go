@@ -0,0 +1,229 @@
+package delay_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/delay"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/memory"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/server"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/redis/go-redis/v9"
+)
+
+// 记录发布内容的生产者
+type recordProducer struct {
+	topics []string
+	fail   error
+}
+
+func (p *recordProducer) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string) error {
+	if p.fail != nil {
+		return p.fail
+	}
+	p.topics = append(p.topics, topic+":"+string(payload))
+	return nil
+}
+
+func (p *recordProducer) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration) error {
+	return errors.New("not supported")
+}
+
+func (p *recordProducer) Close() error {
+	return nil
+}
+
+func newRedis(t *testing.T) *redis.Client {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+// 轮询间隔足够大，测试中手动调用 DispatchDue
+func newScheduler(t *testing.T, store delay.IStore, elector delay.ILeaderElector, producer *recordProducer) *delay.Scheduler {
+	s := delay.NewScheduler(store, elector, producer, delay.Config{PollInterval: time.Hour})
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestScheduler_DispatchAndCancel(t *testing.T) {
+	stores := map[string]func(t *testing.T) delay.IStore{
+		"memory": func(t *testing.T) delay.IStore { return delay.NewMemoryStore() },
+		"redis":  func(t *testing.T) delay.IStore { return delay.NewRedisStore(newRedis(t), "") },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			producer := &recordProducer{}
+			s := newScheduler(t, newStore(t), nil, producer)
+			now := time.Now()
+
+			_, _ = s.Schedule(ctx, "o2", models.NewBaseMessage("order.timeout", []byte("2"), nil), now.Add(-time.Second))
+			_, _ = s.Schedule(ctx, "o1", models.NewBaseMessage("order.timeout", []byte("1"), nil), now.Add(-2*time.Second))
+			_, _ = s.Schedule(ctx, "o3", models.NewBaseMessage("order.timeout", []byte("3"), nil), now.Add(-time.Second))
+			_, _ = s.Schedule(ctx, "later", models.NewBaseMessage("order.timeout", []byte("4"), nil), now.Add(time.Hour))
+
+			if ok, err := s.Cancel(ctx, "o3"); !ok || err != nil {
+				t.Fatalf("cancel = %v %v", ok, err)
+			}
+			if ok, _ := s.Cancel(ctx, "missing"); ok {
+				t.Fatal("cancel of missing message succeeded")
+			}
+
+			n, err := s.DispatchDue(ctx)
+			if err != nil || n != 2 {
+				t.Fatalf("dispatched %d: %v", n, err)
+			}
+			if len(producer.topics) != 2 || producer.topics[0] != "order.timeout:1" || producer.topics[1] != "order.timeout:2" {
+				t.Fatalf("unexpected publish order: %v", producer.topics)
+			}
+			// 已发布的消息不再重复投递，也无法取消
+			if n, _ = s.DispatchDue(ctx); n != 0 {
+				t.Fatalf("dispatched again: %d", n)
+			}
+			if ok, _ := s.Cancel(ctx, "o1"); ok {
+				t.Fatal("published message cancelled")
+			}
+			if ok, _ := s.Cancel(ctx, "later"); !ok {
+				t.Fatal("pending message not cancelled")
+			}
+		})
+	}
+}
+
+func TestScheduler_PublishFailureKeepsMessage(t *testing.T) {
+	ctx := context.Background()
+	store := delay.NewRedisStore(newRedis(t), "")
+	producer := &recordProducer{fail: errors.New("broker down")}
+	s := newScheduler(t, store, nil, producer)
+
+	_, _ = s.Schedule(ctx, "o1", models.NewBaseMessage("order.timeout", []byte("1"), nil), time.Now())
+	if _, err := s.DispatchDue(ctx); err == nil {
+		t.Fatal("expected publish error")
+	}
+	producer.fail = nil
+	if n, err := s.DispatchDue(ctx); err != nil || n != 1 {
+		t.Fatalf("dispatched %d: %v", n, err)
+	}
+}
+
+func TestScheduler_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	rdb := newRedis(t)
+	producer := &recordProducer{}
+
+	first := delay.NewScheduler(delay.NewRedisStore(rdb, ""), nil, producer, delay.Config{PollInterval: time.Hour})
+	_, _ = first.Schedule(ctx, "o1", models.NewBaseMessage("order.timeout", []byte("1"), nil), time.Now().Add(20*time.Millisecond))
+	_ = first.Close()
+
+	second := newScheduler(t, delay.NewRedisStore(rdb, ""), nil, producer)
+	time.Sleep(30 * time.Millisecond)
+	if n, err := second.DispatchDue(ctx); err != nil || n != 1 {
+		t.Fatalf("dispatched %d: %v", n, err)
+	}
+}
+
+func TestRedisElector(t *testing.T) {
+	ctx := context.Background()
+	rdb := newRedis(t)
+	e := delay.NewRedisElector(rdb, "")
+
+	if ok, _ := e.TryLead(ctx, "a", time.Minute); !ok {
+		t.Fatal("a should lead")
+	}
+	if ok, _ := e.TryLead(ctx, "b", time.Minute); ok {
+		t.Fatal("b should not lead while a holds the lease")
+	}
+	if ok, _ := e.TryLead(ctx, "a", time.Minute); !ok {
+		t.Fatal("a should renew")
+	}
+	_ = e.Resign(ctx, "a")
+	if ok, _ := e.TryLead(ctx, "b", time.Minute); !ok {
+		t.Fatal("b should lead after a resigned")
+	}
+}
+
+func TestScheduler_LeaderDispatches(t *testing.T) {
+	rdb := newRedis(t)
+	store := delay.NewRedisStore(rdb, "")
+	elector := delay.NewRedisElector(rdb, "")
+	var published atomic.Int32
+	producer := &countProducer{n: &published}
+
+	cfg := delay.Config{PollInterval: 10 * time.Millisecond}
+	s1 := delay.NewScheduler(store, elector, producer, cfg)
+	s2 := delay.NewScheduler(store, elector, producer, cfg)
+	defer s2.Close()
+	defer s1.Close()
+
+	for i := 0; i < 20; i++ {
+		_, _ = s1.Schedule(context.Background(), "", models.NewBaseMessage("t", []byte{byte(i)}, nil), time.Now())
+		time.Sleep(time.Microsecond)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for published.Load() < 20 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if published.Load() != 20 {
+		t.Fatalf("published %d", published.Load())
+	}
+	if s1.IsLeader() == s2.IsLeader() {
+		t.Fatalf("expected exactly one leader: %v %v", s1.IsLeader(), s2.IsLeader())
+	}
+}
+
+type countProducer struct {
+	recordProducer
+	n *atomic.Int32
+}
+
+func (p *countProducer) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string) error {
+	p.n.Add(1)
+	return nil
+}
+
+func TestEventBus_PublishAtAndCancel(t *testing.T) {
+	m := memory.NewMemory(memory.NewConfigBuilder().Build().ToMQConfig())
+	srv, err := server.NewServerWithMQ(memory.NewConfigBuilder().Build().ToMQConfig(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	s := delay.NewScheduler(delay.NewRedisStore(newRedis(t), ""), nil, delay.NewServerProducer(srv), delay.Config{PollInterval: time.Hour})
+	defer s.Close()
+	bus := mqevent.NewMQEventBus(srv, mqevent.WithDelayScheduler(s))
+
+	var received atomic.Value
+	_, _ = bus.Subscribe("order.timeout", "close", mqevent.EventHandlerFunc(func(ctx context.Context, event mqevent.Event) error {
+		received.Store(event.GetID())
+		return nil
+	}))
+
+	ctx := context.Background()
+	paid := mqevent.NewBaseEvent("order.timeout", map[string]interface{}{"order": "1"})
+	unpaid := mqevent.NewBaseEvent("order.timeout", map[string]interface{}{"order": "2"})
+	_ = bus.PublishAt(ctx, paid, time.Now())
+	_ = bus.PublishDelay(ctx, unpaid, 0)
+	// 订单已支付，取消超时事件
+	if ok, err := bus.CancelDelay(ctx, paid.GetID()); !ok || err != nil {
+		t.Fatalf("cancel = %v %v", ok, err)
+	}
+
+	if n, err := s.DispatchDue(ctx); err != nil || n != 1 {
+		t.Fatalf("dispatched %d: %v", n, err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err = m.WaitIdle(waitCtx); err != nil {
+		t.Fatal(err)
+	}
+	if received.Load() != unpaid.GetID() {
+		t.Fatalf("received %v, want %s", received.Load(), unpaid.GetID())
+	}
+}
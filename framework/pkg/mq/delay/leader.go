@@ -0,0 +1,74 @@
+package delay
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ILeaderElector 主节点选举，同一时刻只有一个实例负责投递到期消息
+type ILeaderElector interface {
+	// TryLead 尝试成为或续任主节点，返回当前实例是否为主节点
+	TryLead(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	// Resign 主动卸任
+	Resign(ctx context.Context, owner string) error
+}
+
+// LocalElector 单实例部署时使用，总是成为主节点
+type LocalElector struct{}
+
+func (LocalElector) TryLead(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (LocalElector) Resign(ctx context.Context, owner string) error {
+	return nil
+}
+
+var (
+	// KEYS[1] 主节点键；ARGV[1] 持有者 ARGV[2] 租期(毫秒)
+	leadScript = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+if cur == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if cur then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+	resignScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+)
+
+// RedisElector 基于 Redis 租约的主节点选举，主节点宕机后租约过期由其他实例接任
+type RedisElector struct {
+	rdb *redis.Client
+	key string
+}
+
+func NewRedisElector(rdb *redis.Client, prefix string) *RedisElector {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return &RedisElector{rdb: rdb, key: prefix + ":leader"}
+}
+
+func (e *RedisElector) TryLead(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	ok, err := leadScript.Run(ctx, e.rdb, []string{e.key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
+
+func (e *RedisElector) Resign(ctx context.Context, owner string) error {
+	return resignScript.Run(ctx, e.rdb, []string{e.key}, owner).Err()
+}
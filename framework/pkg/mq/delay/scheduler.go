@@ -0,0 +1,235 @@
+package delay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultLeaderTTL    = 10 * time.Second
+)
+
+// Config 调度器配置
+type Config struct {
+	// PollInterval 到期扫描间隔，同时决定投递精度
+	PollInterval time.Duration
+	// BatchSize 每批投递数量
+	BatchSize int
+	// LeaderTTL 主节点租期，主节点宕机后最长经过该时间由其他实例接任
+	LeaderTTL time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultPollInterval
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.LeaderTTL <= 0 {
+		c.LeaderTTL = defaultLeaderTTL
+	}
+	// 续约间隔必须小于租期
+	if c.LeaderTTL < 2*c.PollInterval {
+		c.LeaderTTL = 2 * c.PollInterval
+	}
+	return c
+}
+
+// Scheduler 持久化延迟消息调度器
+//   - 消息先写入 IStore，进程重启不丢失，也不受各 MQ 驱动最大延迟的限制
+//   - 所有实例都可以调度和取消，只有选举出的主节点扫描到期消息并通过 mq.Producer 正常发布
+//   - 发布成功后才从存储删除，主节点在两者之间宕机时消息可能重复投递(至少一次)
+//   - 通过 OnDispatch 注册的回调在每条消息发布成功后执行，例如将延迟事件写入事件存储
+type Scheduler struct {
+	store    IStore
+	elector  ILeaderElector
+	producer mq.Producer
+	cfg      Config
+	owner    string
+	leader   atomic.Bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+	hookMu   sync.RWMutex
+	hooks    []DispatchHook
+}
+
+// DispatchHook 延迟消息发布成功后的回调，收到的是调度时保存的原始消息
+type DispatchHook func(ctx context.Context, msg *models.BaseMessage)
+
+// NewScheduler 创建并启动调度器
+func NewScheduler(store IStore, elector ILeaderElector, producer mq.Producer, cfg Config) *Scheduler {
+	if elector == nil {
+		elector = LocalElector{}
+	}
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		store:    store,
+		elector:  elector,
+		producer: producer,
+		cfg:      cfg.withDefaults(),
+		owner:    fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), strconv.FormatInt(utils.GetTimeNow().UnixNano(), 36)),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s
+}
+
+// Schedule 调度消息在 at 时刻发布，id 为空时使用消息ID；相同 id 重复调度会覆盖之前的时间和内容
+func (s *Scheduler) Schedule(ctx context.Context, id string, msg *models.BaseMessage, at time.Time) (string, error) {
+	if msg == nil || msg.GetTopic() == "" {
+		return "", errors.New("delay: message topic is required")
+	}
+	if id == "" {
+		id = msg.GetID()
+	}
+	if id == "" {
+		id = models.GenerateID()
+	}
+	err := s.store.Add(ctx, &Message{ID: id, DueAt: at.UnixMilli(), Message: msg})
+	if err != nil {
+		return "", fmt.Errorf("保存延迟消息失败: %v", err)
+	}
+	return id, nil
+}
+
+// Cancel 取消尚未发布的延迟消息，返回是否取消成功
+func (s *Scheduler) Cancel(ctx context.Context, id string) (bool, error) {
+	return s.store.Cancel(ctx, id)
+}
+
+// OnDispatch 注册消息发布成功后的回调，回调在调度协程中同步执行，不应长时间阻塞
+func (s *Scheduler) OnDispatch(hook DispatchHook) {
+	if hook == nil {
+		return
+	}
+	s.hookMu.Lock()
+	defer s.hookMu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// IsLeader 当前实例是否为主节点
+func (s *Scheduler) IsLeader() bool {
+	return s.leader.Load()
+}
+
+// DispatchDue 发布所有到期消息并返回发布数量，不检查主节点身份，测试中可直接调用
+func (s *Scheduler) DispatchDue(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		due, err := s.store.Due(ctx, utils.GetTimeNow().UnixMilli(), s.cfg.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("读取到期消息失败: %v", err)
+		}
+		published := make([]*Message, 0, len(due))
+		var pubErr error
+		for _, m := range due {
+			if pubErr = s.producer.Publish(ctx, m.Message.GetTopic(), m.Message.GetPayload(), m.Message.GetHeaders()); pubErr != nil {
+				pubErr = fmt.Errorf("发布延迟消息 %s 失败: %v", m.ID, pubErr)
+				break
+			}
+			published = append(published, m)
+			s.dispatched(ctx, m.Message)
+		}
+		if err = s.store.Remove(ctx, published...); err != nil {
+			return total, fmt.Errorf("删除已发布的延迟消息失败: %v", err)
+		}
+		total += len(published)
+		if pubErr != nil {
+			return total, pubErr
+		}
+		if len(due) < s.cfg.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *Scheduler) dispatched(ctx context.Context, msg *models.BaseMessage) {
+	s.hookMu.RLock()
+	defer s.hookMu.RUnlock()
+	for _, hook := range s.hooks {
+		hook(ctx, msg)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	lead, err := s.elector.TryLead(ctx, s.owner, s.cfg.LeaderTTL)
+	if err != nil {
+		if ctx.Err() == nil {
+			hlog.Errorf("delay: leader election error: %v", err)
+		}
+		lead = false
+	}
+	if s.leader.Swap(lead) != lead {
+		hlog.Infof("delay: scheduler %s leader=%v", s.owner, lead)
+	}
+	if !lead {
+		return
+	}
+	if _, err = s.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+		hlog.Errorf("delay: dispatch error: %v", err)
+	}
+}
+
+// Close 停止调度并卸任主节点，不关闭 producer
+func (s *Scheduler) Close() error {
+	s.cancel()
+	<-s.done
+	if s.leader.Swap(false) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return s.elector.Resign(ctx, s.owner)
+	}
+	return nil
+}
+
+// ServerProducer 将 mq.Server 适配为 mq.Producer，供调度器通过统一服务发布
+type ServerProducer struct {
+	server mq.Server
+}
+
+func NewServerProducer(server mq.Server) *ServerProducer {
+	return &ServerProducer{server: server}
+}
+
+func (p *ServerProducer) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string) error {
+	return p.server.Publish(ctx, models.NewBaseMessage(topic, payload, headers))
+}
+
+func (p *ServerProducer) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration) error {
+	return p.server.PublishDelay(ctx, models.NewBaseMessage(topic, payload, headers), delay)
+}
+
+// Close 统一服务由创建方关闭
+func (p *ServerProducer) Close() error {
+	return nil
+}
@@ -0,0 +1,199 @@
+package delay
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultKeyPrefix 默认 Redis 键前缀，共用同一数据库的服务必须使用相同前缀
+const DefaultKeyPrefix = "mq:delay"
+
+// Message 延迟消息
+type Message struct {
+	// ID 消息ID，用于取消
+	ID string `json:"id"`
+	// DueAt 到期时间(unix 毫秒)
+	DueAt int64 `json:"due_at"`
+	// Message 到期后发布的消息
+	Message *models.BaseMessage `json:"message"`
+}
+
+// IStore 延迟消息持久化存储
+type IStore interface {
+	// Add 保存延迟消息，ID 已存在时覆盖
+	Add(ctx context.Context, msg *Message) error
+	// Cancel 取消延迟消息，消息不存在或已发布时返回 false
+	Cancel(ctx context.Context, id string) (bool, error)
+	// Due 按到期时间顺序获取到期的消息，不会从存储中删除
+	Due(ctx context.Context, nowMs int64, limit int) ([]*Message, error)
+	// Remove 删除已发布的消息，发布期间被重新调度(到期时间变化)的消息保留
+	Remove(ctx context.Context, msgs ...*Message) error
+}
+
+// MemoryStore 内存存储，用于单机部署和测试，进程重启后消息丢失
+type MemoryStore struct {
+	mu   sync.Mutex
+	msgs map[string]*Message
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{msgs: make(map[string]*Message)}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs[msg.ID] = msg
+	return nil
+}
+
+func (s *MemoryStore) Cancel(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.msgs[id]
+	delete(s.msgs, id)
+	return ok, nil
+}
+
+func (s *MemoryStore) Due(ctx context.Context, nowMs int64, limit int) ([]*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Message
+	for _, m := range s.msgs {
+		if m.DueAt <= nowMs {
+			due = append(due, m)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].DueAt < due[j].DueAt })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *MemoryStore) Remove(ctx context.Context, msgs ...*Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range msgs {
+		if cur, ok := s.msgs[m.ID]; ok && cur.DueAt == m.DueAt {
+			delete(s.msgs, m.ID)
+		}
+	}
+	return nil
+}
+
+var (
+	// KEYS[1] 到期时间有序集合 KEYS[2] 消息内容哈希；ARGV[1] 消息ID
+	cancelScript = redis.NewScript(`
+redis.call("HDEL", KEYS[2], ARGV[1])
+return redis.call("ZREM", KEYS[1], ARGV[1])
+`)
+	// ARGV[2] 读取时的到期时间，不一致说明已被重新调度
+	removeScript = redis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if score and tonumber(score) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("HDEL", KEYS[2], ARGV[1])
+return redis.call("ZREM", KEYS[1], ARGV[1])
+`)
+)
+
+// RedisStore 基于 Redis 的存储：有序集合按到期时间索引消息ID，哈希保存消息内容
+type RedisStore struct {
+	rdb    *redis.Client
+	prefix string
+}
+
+func NewRedisStore(rdb *redis.Client, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return &RedisStore{rdb: rdb, prefix: prefix}
+}
+
+func (s *RedisStore) queueKey() string {
+	return s.prefix + ":queue"
+}
+
+func (s *RedisStore) msgKey() string {
+	return s.prefix + ":msgs"
+}
+
+func (s *RedisStore) Add(ctx context.Context, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.msgKey(), msg.ID, data)
+		pipe.ZAdd(ctx, s.queueKey(), redis.Z{Score: float64(msg.DueAt), Member: msg.ID})
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Cancel(ctx context.Context, id string) (bool, error) {
+	n, err := cancelScript.Run(ctx, s.rdb, []string{s.queueKey(), s.msgKey()}, id).Int()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisStore) Due(ctx context.Context, nowMs int64, limit int) ([]*Message, error) {
+	ids, err := s.rdb.ZRangeByScore(ctx, s.queueKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(nowMs, 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	values, err := s.rdb.HMGet(ctx, s.msgKey(), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	due := make([]*Message, 0, len(ids))
+	var broken []interface{}
+	for i, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			// 内容已被取消删除，清理残留索引
+			broken = append(broken, ids[i])
+			continue
+		}
+		var msg Message
+		if err = json.Unmarshal([]byte(data), &msg); err != nil || msg.Message == nil {
+			hlog.CtxErrorf(ctx, "delay: invalid message %s: %v", ids[i], err)
+			broken = append(broken, ids[i])
+			continue
+		}
+		due = append(due, &msg)
+	}
+	if len(broken) > 0 {
+		s.rdb.ZRem(ctx, s.queueKey(), broken...)
+	}
+	return due, nil
+}
+
+func (s *RedisStore) Remove(ctx context.Context, msgs ...*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	keys := []string{s.queueKey(), s.msgKey()}
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, m := range msgs {
+			removeScript.Eval(ctx, pipe, keys, m.ID, m.DueAt)
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryDelay = 0
+)
+
+// Config 内存队列配置
+type Config struct {
+	// MaxRetries 最大重试次数，处理失败达到该次数后进入死信
+	MaxRetries int
+	// RetryDelay 重试基础延迟(毫秒)，第 n 次重试延迟 n * RetryDelay，0 表示立即重试
+	RetryDelay int
+}
+
+// ConfigBuilder 内存队列配置构建器
+type ConfigBuilder struct {
+	cfg *Config
+}
+
+// NewConfigBuilder 创建内存队列配置构建器
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{
+		cfg: &Config{
+			MaxRetries: defaultMaxRetries,
+			RetryDelay: defaultRetryDelay,
+		},
+	}
+}
+
+// MaxRetries 设置最大重试次数
+func (b *ConfigBuilder) MaxRetries(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.MaxRetries = v
+	}
+	return b
+}
+
+// RetryDelay 设置重试基础延迟(毫秒)
+func (b *ConfigBuilder) RetryDelay(v int) *ConfigBuilder {
+	b.cfg.RetryDelay = v
+	return b
+}
+
+// Build 构建配置
+func (b *ConfigBuilder) Build() *Config {
+	return b.cfg
+}
+
+// ToMQConfig 转换为MQ配置
+func (c *Config) ToMQConfig() *models.Config {
+	return &models.Config{
+		Type:       "memory",
+		MaxRetries: c.MaxRetries,
+		Options: map[string]interface{}{
+			"retry_delay": c.RetryDelay,
+		},
+	}
+}
+
+// FromMQConfig 从MQ配置创建内存队列配置
+func FromMQConfig(config *models.Config) (*Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("配置不能为空")
+	}
+
+	builder := NewConfigBuilder()
+	builder.MaxRetries(config.MaxRetries)
+
+	if config.Options != nil {
+		if v, ok := config.Options["retry_delay"].(int); ok {
+			builder.RetryDelay(v)
+		}
+	}
+
+	return builder.Build(), nil
+}
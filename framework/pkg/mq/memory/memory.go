@@ -0,0 +1,499 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+)
+
+// ErrClosed 消息队列已关闭
+var ErrClosed = errors.New("memory mq closed")
+
+// Memory 进程内消息队列实现，用于测试和单机部署，同一实例创建的生产者和消费者共享消息
+//   - 同一主题的不同通道各自收到一份消息
+//   - 同一通道的多个订阅竞争消费，每条消息只由其中一个处理
+//   - 主题还没有任何通道时消息暂存在主题上，第一个通道创建后投递给它
+//   - 进程退出后未处理的消息丢失
+type Memory struct {
+	config *Config
+
+	mu       sync.Mutex
+	topics   map[string]*topic
+	delayed  map[*delayedMessage]struct{}
+	inFlight int
+	closed   bool
+}
+
+type topic struct {
+	channels map[string]*channel
+	backlog  []*models.BaseMessage
+}
+
+type channel struct {
+	topic string
+	name  string
+	queue []*envelope
+	subs  []*subscription
+	cond  *sync.Cond
+}
+
+type envelope struct {
+	msg      *models.BaseMessage
+	attempts int
+}
+
+type subscription struct {
+	consumer *Consumer
+	handler  func(msg *models.BaseMessage) error
+	closed   bool
+	done     chan struct{}
+}
+
+type subKey struct {
+	topic   string
+	channel string
+}
+
+type delayedMessage struct {
+	timer *time.Timer
+	fire  func()
+}
+
+// NewMemory 创建内存消息队列
+func NewMemory(config *models.Config) *Memory {
+	cfg, err := FromMQConfig(config)
+	if err != nil {
+		cfg = NewConfigBuilder().Build()
+	}
+	return &Memory{
+		config:  cfg,
+		topics:  make(map[string]*topic),
+		delayed: make(map[*delayedMessage]struct{}),
+	}
+}
+
+// NewProducer 创建生产者
+func (m *Memory) NewProducer(config *models.Config) (mq.Producer, error) {
+	return &Producer{m: m}, nil
+}
+
+// NewConsumer 创建消费者
+func (m *Memory) NewConsumer(config *models.Config) (mq.Consumer, error) {
+	return &Consumer{m: m, subs: make(map[subKey][]*subscription)}, nil
+}
+
+// Close 关闭消息队列，停止所有订阅并丢弃未到期的延迟消息
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	for d := range m.delayed {
+		d.timer.Stop()
+	}
+	m.delayed = make(map[*delayedMessage]struct{})
+	for _, t := range m.topics {
+		for _, ch := range t.channels {
+			for _, sub := range ch.subs {
+				m.closeSub(sub)
+			}
+			ch.subs = nil
+			ch.cond.Broadcast()
+		}
+	}
+	return nil
+}
+
+// --------------------------- 测试辅助 ---------------------------
+
+// WaitIdle 等待所有可处理的消息处理完成，包括处理中、重试和延迟中的消息
+// 没有订阅的通道中积压的消息不会被处理，不影响空闲判断
+func (m *Memory) WaitIdle(ctx context.Context) error {
+	for {
+		if m.idle() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// FlushDelayed 立即投递所有延迟消息（包括等待重试的消息），返回投递数量
+func (m *Memory) FlushDelayed() int {
+	m.mu.Lock()
+	pending := make([]*delayedMessage, 0, len(m.delayed))
+	for d := range m.delayed {
+		pending = append(pending, d)
+	}
+	m.mu.Unlock()
+	n := 0
+	for _, d := range pending {
+		if d.timer.Stop() {
+			d.fire()
+			n++
+		}
+	}
+	return n
+}
+
+// Drain 取出通道中积压的消息，channel 为空时取出主题上暂存的消息
+func (m *Memory) Drain(topicName, channelName string) []*models.BaseMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.topics[topicName]
+	if !ok {
+		return nil
+	}
+	if channelName == "" {
+		msgs := t.backlog
+		t.backlog = nil
+		return msgs
+	}
+	ch, ok := t.channels[channelName]
+	if !ok {
+		return nil
+	}
+	msgs := make([]*models.BaseMessage, 0, len(ch.queue))
+	for _, env := range ch.queue {
+		msgs = append(msgs, env.msg)
+	}
+	ch.queue = nil
+	return msgs
+}
+
+func (m *Memory) idle() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inFlight > 0 || len(m.delayed) > 0 {
+		return false
+	}
+	for _, t := range m.topics {
+		for _, ch := range t.channels {
+			if len(ch.queue) > 0 && len(ch.subs) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// --------------------------- 投递 ---------------------------
+
+// 调用方需持有锁
+func (m *Memory) getTopic(name string) *topic {
+	t, ok := m.topics[name]
+	if !ok {
+		t = &topic{channels: make(map[string]*channel)}
+		m.topics[name] = t
+	}
+	return t
+}
+
+// 调用方需持有锁
+func (m *Memory) getChannel(topicName, channelName string) *channel {
+	t := m.getTopic(topicName)
+	ch, ok := t.channels[channelName]
+	if !ok {
+		ch = &channel{topic: topicName, name: channelName, cond: sync.NewCond(&m.mu)}
+		// 第一个通道接收主题上暂存的消息
+		if len(t.channels) == 0 {
+			for _, msg := range t.backlog {
+				ch.queue = append(ch.queue, &envelope{msg: msg})
+			}
+			t.backlog = nil
+		}
+		t.channels[channelName] = ch
+	}
+	return ch
+}
+
+// 调用方需持有锁
+func (m *Memory) enqueue(msg *models.BaseMessage) {
+	t := m.getTopic(msg.Topic)
+	if len(t.channels) == 0 {
+		t.backlog = append(t.backlog, msg)
+		return
+	}
+	for _, ch := range t.channels {
+		ch.push(&envelope{msg: cloneMessage(msg)})
+	}
+}
+
+func (m *Memory) publish(msg *models.BaseMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	m.enqueue(msg)
+	return nil
+}
+
+// 延迟执行 fn，fn 在持有锁的情况下执行
+func (m *Memory) schedule(delay time.Duration, fn func()) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	d := &delayedMessage{}
+	var once sync.Once
+	d.fire = func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.delayed, d)
+			if !m.closed {
+				fn()
+			}
+		})
+	}
+	m.delayed[d] = struct{}{}
+	d.timer = time.AfterFunc(delay, d.fire)
+	return nil
+}
+
+func (ch *channel) push(env *envelope) {
+	ch.queue = append(ch.queue, env)
+	ch.cond.Signal()
+}
+
+// --------------------------- 订阅 ---------------------------
+
+func (m *Memory) subscribe(ctx context.Context, c *Consumer, topicName, channelName string, handler func(msg *models.BaseMessage) error) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrClosed
+	}
+	ch := m.getChannel(topicName, channelName)
+	sub := &subscription{consumer: c, handler: handler, done: make(chan struct{})}
+	ch.subs = append(ch.subs, sub)
+	key := subKey{topic: topicName, channel: channelName}
+	c.subs[key] = append(c.subs[key], sub)
+	m.mu.Unlock()
+
+	go m.work(ch, sub)
+	// 上下文取消时停止订阅
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				m.mu.Lock()
+				m.removeSub(ch, sub)
+				m.mu.Unlock()
+			case <-sub.done:
+			}
+		}()
+	}
+	return nil
+}
+
+func (m *Memory) unsubscribe(c *Consumer, topicName, channelName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := subKey{topic: topicName, channel: channelName}
+	subs := c.subs[key]
+	delete(c.subs, key)
+	t, ok := m.topics[topicName]
+	if !ok {
+		return
+	}
+	ch, ok := t.channels[channelName]
+	if !ok {
+		return
+	}
+	for _, sub := range subs {
+		m.removeSub(ch, sub)
+	}
+}
+
+// 调用方需持有锁
+func (m *Memory) removeSub(ch *channel, sub *subscription) {
+	for i, s := range ch.subs {
+		if s == sub {
+			ch.subs = append(ch.subs[:i], ch.subs[i+1:]...)
+			break
+		}
+	}
+	m.closeSub(sub)
+	ch.cond.Broadcast()
+}
+
+// 调用方需持有锁
+func (m *Memory) closeSub(sub *subscription) {
+	if !sub.closed {
+		sub.closed = true
+		close(sub.done)
+	}
+}
+
+func (m *Memory) work(ch *channel, sub *subscription) {
+	for {
+		m.mu.Lock()
+		for len(ch.queue) == 0 && !sub.closed {
+			ch.cond.Wait()
+		}
+		if sub.closed {
+			// 唤醒其他订阅继续处理
+			ch.cond.Signal()
+			m.mu.Unlock()
+			return
+		}
+		env := ch.queue[0]
+		ch.queue[0] = nil
+		ch.queue = ch.queue[1:]
+		m.inFlight++
+		m.mu.Unlock()
+
+		err := safeHandle(sub.handler, env.msg)
+		if err != nil {
+			m.retry(ch, sub, env, err)
+		}
+
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}
+}
+
+// 处理失败，未超过最大重试次数时重新入队，否则进入死信
+func (m *Memory) retry(ch *channel, sub *subscription, env *envelope, err error) {
+	env.attempts++
+	if env.attempts < m.config.MaxRetries {
+		delay := time.Duration(env.attempts*m.config.RetryDelay) * time.Millisecond
+		if delay <= 0 {
+			m.mu.Lock()
+			ch.push(env)
+			m.mu.Unlock()
+			return
+		}
+		if serr := m.schedule(delay, func() { ch.push(env) }); serr != nil {
+			hlog.Warnf("memory mq retry message %s error: %v", env.msg.ID, serr)
+		}
+		return
+	}
+
+	handler := sub.consumer.getDeadLetterHandler()
+	if handler == nil {
+		hlog.Warnf("memory mq message %s dropped after %d attempts: %v", env.msg.ID, env.attempts, err)
+		return
+	}
+	deadLetterMsg := models.DeadLetterMessage{
+		Topic:      ch.topic,
+		Channel:    ch.name,
+		Payload:    env.msg.GetPayload(),
+		Headers:    env.msg.GetHeaders(),
+		Error:      err.Error(),
+		RetryCount: env.attempts,
+		Timestamp:  time.Unix(0, env.msg.Timestamp),
+		DeadTime:   utils.GetTimeNow(),
+	}
+	if derr := handler(deadLetterMsg); derr != nil {
+		hlog.Errorf("memory mq dead letter handler error: %v", derr)
+	}
+}
+
+func safeHandle(handler func(msg *models.BaseMessage) error, msg *models.BaseMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic recovered: %v", r)
+		}
+	}()
+	return handler(msg)
+}
+
+func cloneMessage(msg *models.BaseMessage) *models.BaseMessage {
+	cp := *msg
+	if msg.Headers != nil {
+		cp.Headers = make(map[string]string, len(msg.Headers))
+		for k, v := range msg.Headers {
+			cp.Headers[k] = v
+		}
+	}
+	return &cp
+}
+
+// --------------------------- 生产者 & 消费者 ---------------------------
+
+// Producer 内存队列生产者
+type Producer struct {
+	m *Memory
+}
+
+// Publish 发布消息
+func (p *Producer) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string) error {
+	return p.m.publish(models.NewBaseMessage(topic, payload, headers))
+}
+
+// PublishDelay 发布延迟消息
+func (p *Producer) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration) error {
+	msg := models.NewBaseMessage(topic, payload, headers)
+	if delay <= 0 {
+		return p.m.publish(msg)
+	}
+	return p.m.schedule(delay, func() { p.m.enqueue(msg) })
+}
+
+// Close 关闭生产者
+func (p *Producer) Close() error {
+	return nil
+}
+
+// Consumer 内存队列消费者
+type Consumer struct {
+	m                 *Memory
+	subs              map[subKey][]*subscription // 由 Memory 的锁保护
+	mu                sync.RWMutex
+	deadLetterHandler func(msg models.DeadLetterMessage) error
+}
+
+// SetDeadLetterHandler 设置死信处理器
+func (c *Consumer) SetDeadLetterHandler(handler func(msg models.DeadLetterMessage) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadLetterHandler = handler
+}
+
+func (c *Consumer) getDeadLetterHandler() func(msg models.DeadLetterMessage) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.deadLetterHandler
+}
+
+// Subscribe 订阅主题，同一通道多次订阅时竞争消费
+func (c *Consumer) Subscribe(ctx context.Context, topic string, channel string, handler func(msg *models.BaseMessage) error) error {
+	return c.m.subscribe(ctx, c, topic, channel, handler)
+}
+
+// Unsubscribe 取消该消费者在通道上的所有订阅，通道中积压的消息保留
+func (c *Consumer) Unsubscribe(topic string, channel string) error {
+	c.m.unsubscribe(c, topic, channel)
+	return nil
+}
+
+// Close 关闭消费者
+func (c *Consumer) Close() error {
+	c.m.mu.Lock()
+	keys := make([]subKey, 0, len(c.subs))
+	for key := range c.subs {
+		keys = append(keys, key)
+	}
+	c.m.mu.Unlock()
+	for _, key := range keys {
+		c.m.unsubscribe(c, key.topic, key.channel)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/memory"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/server"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+)
+
+func newMemory(t *testing.T, maxRetries int) *memory.Memory {
+	m := memory.NewMemory(memory.NewConfigBuilder().MaxRetries(maxRetries).Build().ToMQConfig())
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
+func waitIdle(t *testing.T, m *memory.Memory) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.WaitIdle(ctx); err != nil {
+		t.Fatalf("wait idle: %v", err)
+	}
+}
+
+func TestMemory_FanOutAndCompeting(t *testing.T) {
+	m := newMemory(t, 3)
+	ctx := context.Background()
+	producer, _ := m.NewProducer(nil)
+	c1, _ := m.NewConsumer(nil)
+	c2, _ := m.NewConsumer(nil)
+
+	var mu sync.Mutex
+	counts := map[string]int{}
+	ids := map[string]map[string]int{}
+	handler := func(name, channel string) func(msg *models.BaseMessage) error {
+		return func(msg *models.BaseMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[name]++
+			if ids[channel] == nil {
+				ids[channel] = map[string]int{}
+			}
+			ids[channel][string(msg.GetPayload())]++
+			return nil
+		}
+	}
+	// a 通道两个订阅竞争消费，b 通道单独收到全部消息
+	_ = c1.Subscribe(ctx, "order", "a", handler("a1", "a"))
+	_ = c2.Subscribe(ctx, "order", "a", handler("a2", "a"))
+	_ = c1.Subscribe(ctx, "order", "b", handler("b", "b"))
+
+	for i := 0; i < 100; i++ {
+		if err := producer.Publish(ctx, "order", []byte{byte(i)}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitIdle(t, m)
+
+	if counts["a1"]+counts["a2"] != 100 || counts["b"] != 100 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+	for channel, seen := range ids {
+		for payload, n := range seen {
+			if n != 1 {
+				t.Fatalf("channel %s received %v %d times", channel, []byte(payload), n)
+			}
+		}
+	}
+}
+
+func TestMemory_RetryAndDeadLetter(t *testing.T) {
+	m := newMemory(t, 3)
+	ctx := context.Background()
+	producer, _ := m.NewProducer(nil)
+	consumer, _ := m.NewConsumer(nil)
+
+	var attempts atomic.Int32
+	var dead []models.DeadLetterMessage
+	consumer.SetDeadLetterHandler(func(msg models.DeadLetterMessage) error {
+		dead = append(dead, msg)
+		return nil
+	})
+	_ = consumer.Subscribe(ctx, "pay", "notify", func(msg *models.BaseMessage) error {
+		if attempts.Add(1) == 2 {
+			panic("boom")
+		}
+		return errors.New("failed")
+	})
+
+	_ = producer.Publish(ctx, "pay", []byte("p1"), map[string]string{"k": "v"})
+	waitIdle(t, m)
+
+	if attempts.Load() != 3 {
+		t.Fatalf("attempts = %d", attempts.Load())
+	}
+	if len(dead) != 1 || dead[0].RetryCount != 3 || dead[0].Channel != "notify" || string(dead[0].Payload) != "p1" || dead[0].Headers["k"] != "v" {
+		t.Fatalf("unexpected dead letters: %+v", dead)
+	}
+}
+
+func TestMemory_PublishDelay(t *testing.T) {
+	m := newMemory(t, 3)
+	ctx := context.Background()
+	producer, _ := m.NewProducer(nil)
+	consumer, _ := m.NewConsumer(nil)
+
+	var received atomic.Int32
+	_ = consumer.Subscribe(ctx, "timeout", "close", func(msg *models.BaseMessage) error {
+		received.Add(1)
+		return nil
+	})
+	_ = producer.PublishDelay(ctx, "timeout", []byte("o1"), nil, time.Hour)
+
+	time.Sleep(10 * time.Millisecond)
+	if received.Load() != 0 {
+		t.Fatal("delayed message delivered early")
+	}
+	if n := m.FlushDelayed(); n != 1 {
+		t.Fatalf("flushed %d", n)
+	}
+	waitIdle(t, m)
+	if received.Load() != 1 {
+		t.Fatalf("received %d", received.Load())
+	}
+}
+
+func TestMemory_BacklogAndDrain(t *testing.T) {
+	m := newMemory(t, 3)
+	ctx := context.Background()
+	producer, _ := m.NewProducer(nil)
+	consumer, _ := m.NewConsumer(nil)
+
+	// 没有通道时消息暂存在主题上
+	_ = producer.Publish(ctx, "audit", []byte("1"), nil)
+	if msgs := m.Drain("audit", ""); len(msgs) != 1 {
+		t.Fatalf("topic backlog = %d", len(msgs))
+	}
+	_ = producer.Publish(ctx, "audit", []byte("2"), nil)
+
+	var received atomic.Int32
+	_ = consumer.Subscribe(ctx, "audit", "log", func(msg *models.BaseMessage) error {
+		received.Add(1)
+		return nil
+	})
+	waitIdle(t, m)
+	if received.Load() != 1 {
+		t.Fatalf("received %d", received.Load())
+	}
+
+	// 取消订阅后通道保留积压消息
+	_ = consumer.Unsubscribe("audit", "log")
+	_ = producer.Publish(ctx, "audit", []byte("3"), nil)
+	waitIdle(t, m)
+	if msgs := m.Drain("audit", "log"); len(msgs) != 1 || string(msgs[0].GetPayload()) != "3" {
+		t.Fatalf("unexpected drained messages: %v", msgs)
+	}
+}
+
+func TestMemory_EventBusPipeline(t *testing.T) {
+	m := newMemory(t, 2)
+	srv, err := server.NewServerWithMQ(memory.NewConfigBuilder().MaxRetries(2).Build().ToMQConfig(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := mqevent.NewMQEventBus(srv)
+
+	var got atomic.Value
+	_, err = bus.Subscribe("user.created", "welcome", mqevent.EventHandlerFunc(func(ctx context.Context, event mqevent.Event) error {
+		got.Store(event.GetData())
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = bus.Subscribe("user.created", "broken", mqevent.EventHandlerFunc(func(ctx context.Context, event mqevent.Event) error {
+		return errors.New("always fails")
+	}))
+
+	var dead atomic.Int32
+	if err = srv.SubscribeDeadLetter(context.Background(), func(msg models.DeadLetterMessage) error {
+		if msg.Channel == "broken" {
+			dead.Add(1)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	event := mqevent.NewBaseEvent("user.created", map[string]interface{}{"name": "alice"}, mqevent.WithTenantID("t1"))
+	if err = bus.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	waitIdle(t, m)
+
+	data, ok := got.Load().(map[string]interface{})
+	if !ok || data["name"] != "alice" {
+		t.Fatalf("unexpected event data: %v", got.Load())
+	}
+	if dead.Load() != 1 {
+		t.Fatalf("dead letters = %d", dead.Load())
+	}
+}
@@ -14,6 +14,9 @@ const (
 	defaultDurableName   = "default"
 	defaultAckWait       = 30
 	defaultMaxDeliver    = 3
+	defaultStreamMaxAge  = 7 * 24 * 3600
+	defaultRetryBackoff  = 1000
+	defaultStreamPrefix  = "MQ_"
 )
 
 // Config NATS配置
@@ -34,6 +37,14 @@ type Config struct {
 	AckWait int
 	// MaxDeliver 最大投递次数
 	MaxDeliver int
+	// JetStream 是否使用 JetStream 持久化模式，否则使用 core NATS 发布订阅
+	JetStream bool
+	// StreamPrefix JetStream 流名称前缀，每个主题一个流
+	StreamPrefix string
+	// StreamMaxAge JetStream 流消息保留时间(秒)
+	StreamMaxAge int
+	// RetryBackoff JetStream 处理失败重试的基础退避时间(毫秒)，按投递次数指数增长
+	RetryBackoff int
 }
 
 // ConfigBuilder NATS配置构建器
@@ -53,6 +64,9 @@ func NewConfigBuilder(address string) *ConfigBuilder {
 			DurableName:   defaultDurableName,
 			AckWait:       defaultAckWait,
 			MaxDeliver:    defaultMaxDeliver,
+			StreamPrefix:  defaultStreamPrefix,
+			StreamMaxAge:  defaultStreamMaxAge,
+			RetryBackoff:  defaultRetryBackoff,
 		},
 	}
 }
@@ -99,6 +113,36 @@ func (b *ConfigBuilder) MaxDeliver(v int) *ConfigBuilder {
 	return b
 }
 
+// JetStream 设置是否使用 JetStream 模式
+func (b *ConfigBuilder) JetStream(v bool) *ConfigBuilder {
+	b.cfg.JetStream = v
+	return b
+}
+
+// StreamPrefix 设置 JetStream 流名称前缀
+func (b *ConfigBuilder) StreamPrefix(v string) *ConfigBuilder {
+	if v != "" {
+		b.cfg.StreamPrefix = v
+	}
+	return b
+}
+
+// StreamMaxAge 设置 JetStream 流消息保留时间(秒)
+func (b *ConfigBuilder) StreamMaxAge(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.StreamMaxAge = v
+	}
+	return b
+}
+
+// RetryBackoff 设置 JetStream 重试基础退避时间(毫秒)
+func (b *ConfigBuilder) RetryBackoff(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.RetryBackoff = v
+	}
+	return b
+}
+
 // Build 构建配置
 func (b *ConfigBuilder) Build() *Config {
 	return b.cfg
@@ -117,6 +161,10 @@ func (c *Config) ToMQConfig() *models.Config {
 			"durable_name":   c.DurableName,
 			"ack_wait":       c.AckWait,
 			"max_deliver":    c.MaxDeliver,
+			"jetstream":      c.JetStream,
+			"stream_prefix":  c.StreamPrefix,
+			"stream_max_age": c.StreamMaxAge,
+			"retry_backoff":  c.RetryBackoff,
 		},
 	}
 }
@@ -149,6 +197,18 @@ func FromMQConfig(config *models.Config) (*Config, error) {
 		if v, ok := config.Options["max_deliver"].(int); ok {
 			builder.MaxDeliver(v)
 		}
+		if v, ok := config.Options["jetstream"].(bool); ok {
+			builder.JetStream(v)
+		}
+		if v, ok := config.Options["stream_prefix"].(string); ok {
+			builder.StreamPrefix(v)
+		}
+		if v, ok := config.Options["stream_max_age"].(int); ok {
+			builder.StreamMaxAge(v)
+		}
+		if v, ok := config.Options["retry_backoff"].(int); ok {
+			builder.RetryBackoff(v)
+		}
 	}
 
 	return builder.Build(), nil
@@ -0,0 +1,401 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// 延迟消息到期时间(unix 毫秒)消息头
+	headerDeliverAt = "Mq-Deliver-At"
+	// 失败重试的最大退避时间
+	maxRetryBackoff = 5 * time.Minute
+	// 延迟消息重新投递早于到期时间时最多等待的时间
+	maxEarlyWait = time.Second
+)
+
+func connect(cfg *Config) (*nats.Conn, jetstream.JetStream, error) {
+	conn, err := nats.Connect(cfg.Address,
+		nats.ReconnectWait(time.Duration(cfg.ReconnectWait)*time.Second),
+		nats.MaxReconnects(cfg.MaxReconnects),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接NATS失败: %v", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("创建JetStream失败: %v", err)
+	}
+	return conn, js, nil
+}
+
+// 流名称和消费者名称不能包含 . * > 和空白
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '/', '\\':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// streams 按主题自动创建流，已确认存在的流会被缓存
+type streams struct {
+	js     jetstream.JetStream
+	config *Config
+	known  sync.Map
+}
+
+func (s *streams) name(topic string) string {
+	return s.config.StreamPrefix + sanitizeName(topic)
+}
+
+func (s *streams) ensure(ctx context.Context, topic string) (jetstream.Stream, error) {
+	name := s.name(topic)
+	if v, ok := s.known.Load(name); ok {
+		return v.(jetstream.Stream), nil
+	}
+	stream, err := s.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      name,
+		Subjects:  []string{topic},
+		Retention: jetstream.LimitsPolicy,
+		Storage:   jetstream.FileStorage,
+		MaxAge:    time.Duration(s.config.StreamMaxAge) * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建流 %s 失败: %v", name, err)
+	}
+	s.known.Store(name, stream)
+	return stream, nil
+}
+
+// JetStreamProducer JetStream 生产者实现
+//   - 每个主题自动创建一个流，发布等待服务端确认写入
+//   - 延迟消息携带到期时间消息头，由消费者在到期前延迟确认
+type JetStreamProducer struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	config  *Config
+	streams *streams
+}
+
+// NewJetStreamProducer 创建 JetStream 生产者
+func NewJetStreamProducer(config *models.Config) (*JetStreamProducer, error) {
+	natsConfig, err := FromMQConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("转换配置失败: %v", err)
+	}
+	conn, js, err := connect(natsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &JetStreamProducer{
+		conn:    conn,
+		js:      js,
+		config:  natsConfig,
+		streams: &streams{js: js, config: natsConfig},
+	}, nil
+}
+
+// Publish 发布消息
+func (p *JetStreamProducer) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string) error {
+	return p.publish(ctx, topic, payload, headers, 0)
+}
+
+// PublishDelay 发布延迟消息，JetStream 不支持服务端定时投递，消息立即写入流并在到期前不交给处理器
+func (p *JetStreamProducer) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration) error {
+	return p.publish(ctx, topic, payload, headers, delay)
+}
+
+func (p *JetStreamProducer) publish(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration) error {
+	if _, err := p.streams.ensure(ctx, topic); err != nil {
+		return err
+	}
+	data, err := json.Marshal(models.NewBaseMessage(topic, payload, headers))
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+	msg := nats.NewMsg(topic)
+	msg.Data = data
+	if delay > 0 {
+		msg.Header.Set(headerDeliverAt, strconv.FormatInt(utils.GetTimeNow().Add(delay).UnixMilli(), 10))
+	}
+	if _, err = p.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("发布消息失败: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭生产者
+func (p *JetStreamProducer) Close() error {
+	p.conn.Close()
+	return nil
+}
+
+// JetStreamConsumer JetStream 消费者实现
+//   - 通道对应流上的持久化拉取消费者，同一通道的订阅竞争消费，不同通道各自收到全部消息
+//   - 新通道从创建之后的消息开始消费，已存在的通道从上次确认的位置继续，离线期间的消息不会丢失
+//   - 处理失败按投递次数指数退避后重新投递，投递次数达到 MaxDeliver 后终止并进入死信
+//   - 重试次数只根据消息元数据计算，不依赖本地状态，消息重新投递到其他实例时结果相同
+type JetStreamConsumer struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	config  *Config
+	streams *streams
+
+	mu                sync.Mutex
+	subs              map[string][]jetstream.ConsumeContext
+	deadLetterHandler func(msg models.DeadLetterMessage) error
+}
+
+// NewJetStreamConsumer 创建 JetStream 消费者
+func NewJetStreamConsumer(config *models.Config) (*JetStreamConsumer, error) {
+	natsConfig, err := FromMQConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("转换配置失败: %v", err)
+	}
+	conn, js, err := connect(natsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &JetStreamConsumer{
+		conn:    conn,
+		js:      js,
+		config:  natsConfig,
+		streams: &streams{js: js, config: natsConfig},
+		subs:    make(map[string][]jetstream.ConsumeContext),
+	}, nil
+}
+
+// SetDeadLetterHandler 设置死信处理器
+func (c *JetStreamConsumer) SetDeadLetterHandler(handler func(msg models.DeadLetterMessage) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadLetterHandler = handler
+}
+
+// 最大投递次数，未配置时使用最大重试次数
+func (c *JetStreamConsumer) maxDeliver() int {
+	if c.config.MaxDeliver > 0 {
+		return c.config.MaxDeliver
+	}
+	if c.config.MaxRetries > 0 {
+		return c.config.MaxRetries
+	}
+	return defaultMaxDeliver
+}
+
+func (c *JetStreamConsumer) durableName(channel string) string {
+	if c.config.DurableName == "" {
+		return sanitizeName(channel)
+	}
+	return sanitizeName(c.config.DurableName + "_" + channel)
+}
+
+// Subscribe 订阅主题
+func (c *JetStreamConsumer) Subscribe(ctx context.Context, topic string, channel string, handler func(msg *models.BaseMessage) error) error {
+	stream, err := c.streams.ensure(ctx, topic)
+	if err != nil {
+		return err
+	}
+	name := c.durableName(channel)
+	consumer, err := stream.Consumer(ctx, name)
+	if errors.Is(err, jetstream.ErrConsumerNotFound) {
+		ackWait := c.config.AckWait
+		if ackWait <= 0 {
+			ackWait = defaultAckWait
+		}
+		// 重试次数由客户端控制(延迟消息的提前投递不计入)，服务端不限制投递次数
+		consumer, err = stream.CreateConsumer(ctx, jetstream.ConsumerConfig{
+			Durable:       name,
+			FilterSubject: topic,
+			DeliverPolicy: jetstream.DeliverNewPolicy,
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			AckWait:       time.Duration(ackWait) * time.Second,
+			MaxDeliver:    -1,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("创建持久化消费者失败: %v", err)
+	}
+
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		c.handle(ctx, topic, channel, msg, handler)
+	}, jetstream.PullMaxMessages(defaultMaxInFlight), jetstream.ConsumeErrHandler(func(cc jetstream.ConsumeContext, err error) {
+		hlog.CtxWarnf(ctx, "jetstream consume %s/%s error: %v", topic, channel, err)
+	}))
+	if err != nil {
+		return fmt.Errorf("订阅主题失败: %v", err)
+	}
+
+	c.mu.Lock()
+	key := topic + ":" + channel
+	c.subs[key] = append(c.subs[key], cc)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *JetStreamConsumer) handle(ctx context.Context, topic, channel string, msg jetstream.Msg, handler func(msg *models.BaseMessage) error) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "读取消息元数据失败: %v", err)
+		_ = msg.Term()
+		return
+	}
+	key := fmt.Sprintf("%s/%s/%d", meta.Stream, meta.Consumer, meta.Sequence.Stream)
+
+	// 延迟消息只在首次投递时按剩余时间延后一次，之后的投递视为已到期
+	deliverAt, delayed := deliverAtOf(msg)
+	if delayed {
+		if remaining := deliverAt.Sub(utils.GetTimeNow()); remaining > 0 {
+			if meta.NumDelivered == 1 {
+				_ = msg.NakWithDelay(remaining)
+				return
+			}
+			// 重新投递受定时器精度和实例间时钟偏差影响可能略早于到期时间，等待剩余时间
+			time.Sleep(min(remaining, maxEarlyWait))
+		}
+	}
+	attempts := deliveryAttempts(meta, deliverAt, delayed)
+
+	var baseMsg models.BaseMessage
+	if err = json.Unmarshal(msg.Data(), &baseMsg); err != nil {
+		hlog.CtxErrorf(ctx, "反序列化消息失败: %v, body: %s", err, string(msg.Data()))
+		c.deadLetter(topic, channel, &baseMsg, meta, attempts, fmt.Sprintf("反序列化消息失败: %v", err))
+		c.finish(key, msg.Term)
+		return
+	}
+
+	err = safeHandle(handler, &baseMsg)
+	if err == nil {
+		c.finish(key, msg.Ack)
+		return
+	}
+	if attempts >= c.maxDeliver() {
+		c.deadLetter(topic, channel, &baseMsg, meta, attempts, err.Error())
+		c.finish(key, msg.Term)
+		return
+	}
+	hlog.CtxWarnf(ctx, "jetstream handle %s/%s message %d error (attempt %d): %v", topic, channel, meta.Sequence.Stream, attempts, err)
+	_ = msg.NakWithDelay(c.backoff(attempts))
+}
+
+// deliverAtOf 读取延迟消息的到期时间
+func deliverAtOf(msg jetstream.Msg) (time.Time, bool) {
+	at, err := strconv.ParseInt(msg.Headers().Get(headerDeliverAt), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(at), true
+}
+
+// deliveryAttempts 根据投递次数计算处理次数
+// 写入流时未到期的延迟消息首次投递会延后一次，这次投递不计入处理次数；
+// 消费者积压超过延迟时间、首次投递时已到期的消息没有延后，会多重试一次
+func deliveryAttempts(meta *jetstream.MsgMetadata, deliverAt time.Time, delayed bool) int {
+	attempts := int(meta.NumDelivered)
+	if delayed && meta.Timestamp.Before(deliverAt) {
+		attempts--
+	}
+	if attempts < 1 {
+		attempts = 1
+	}
+	return attempts
+}
+
+// 第 n 次失败后的退避时间为 RetryBackoff * 2^(n-1)
+func (c *JetStreamConsumer) backoff(attempts int) time.Duration {
+	d := time.Duration(c.config.RetryBackoff) * time.Millisecond
+	for i := 1; i < attempts && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d
+}
+
+func (c *JetStreamConsumer) finish(key string, ack func() error) {
+	if err := ack(); err != nil {
+		hlog.Errorf("jetstream ack %s error: %v", key, err)
+	}
+}
+
+func (c *JetStreamConsumer) deadLetter(topic, channel string, msg *models.BaseMessage, meta *jetstream.MsgMetadata, attempts int, reason string) {
+	c.mu.Lock()
+	handler := c.deadLetterHandler
+	c.mu.Unlock()
+	if handler == nil {
+		return
+	}
+	deadLetterMsg := models.DeadLetterMessage{
+		Topic:      topic,
+		Channel:    channel,
+		Payload:    msg.GetPayload(),
+		Headers:    msg.GetHeaders(),
+		Error:      reason,
+		RetryCount: attempts,
+		Timestamp:  meta.Timestamp,
+		DeadTime:   utils.GetTimeNow(),
+	}
+	if err := handler(deadLetterMsg); err != nil {
+		hlog.Errorf("jetstream dead letter handler error: %v", err)
+	}
+}
+
+// Unsubscribe 取消订阅，持久化消费者保留在服务端，重新订阅后继续消费期间的消息
+func (c *JetStreamConsumer) Unsubscribe(topic string, channel string) error {
+	key := topic + ":" + channel
+	c.mu.Lock()
+	subs := c.subs[key]
+	delete(c.subs, key)
+	c.mu.Unlock()
+	stopConsume(subs)
+	return nil
+}
+
+// Close 关闭消费者
+func (c *JetStreamConsumer) Close() error {
+	c.mu.Lock()
+	var subs []jetstream.ConsumeContext
+	for _, s := range c.subs {
+		subs = append(subs, s...)
+	}
+	c.subs = make(map[string][]jetstream.ConsumeContext)
+	c.mu.Unlock()
+	stopConsume(subs)
+	c.conn.Close()
+	return nil
+}
+
+func stopConsume(subs []jetstream.ConsumeContext) {
+	for _, cc := range subs {
+		cc.Stop()
+	}
+	for _, cc := range subs {
+		<-cc.Closed()
+	}
+}
+
+func safeHandle(handler func(msg *models.BaseMessage) error, msg *models.BaseMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic recovered: %v", r)
+		}
+	}()
+	return handler(msg)
+}
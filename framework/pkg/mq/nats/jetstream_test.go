@@ -0,0 +1,228 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	mqnats "github.com/flare-admin/flare-server-go/framework/pkg/mq/nats"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+func runJetStream(t *testing.T) string {
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv.ClientURL()
+}
+
+func newJetStream(t *testing.T) (mq.Producer, mq.Consumer) {
+	config := mqnats.NewConfigBuilder(runJetStream(t)).
+		JetStream(true).
+		MaxDeliver(3).
+		RetryBackoff(10).
+		Build().
+		ToMQConfig()
+	n := mqnats.NewNATS(config)
+	producer, err := n.NewProducer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer, err := n.NewConsumer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = consumer.Close()
+		_ = producer.Close()
+	})
+	return producer, consumer
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJetStream_DurableChannels(t *testing.T) {
+	producer, consumer := newJetStream(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	received := map[string][]string{}
+	handler := func(channel string) func(msg *models.BaseMessage) error {
+		return func(msg *models.BaseMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[channel] = append(received[channel], string(msg.GetPayload()))
+			return nil
+		}
+	}
+	if err := consumer.Subscribe(ctx, "user.created", "welcome", handler("welcome")); err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.Subscribe(ctx, "user.created", "audit", handler("audit")); err != nil {
+		t.Fatal(err)
+	}
+	_ = producer.Publish(ctx, "user.created", []byte("1"), nil)
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received["welcome"]) == 1 && len(received["audit"]) == 1
+	})
+
+	// 通道离线期间发布的消息在重新订阅后收到
+	_ = consumer.Unsubscribe("user.created", "welcome")
+	_ = producer.Publish(ctx, "user.created", []byte("2"), nil)
+	if err := consumer.Subscribe(ctx, "user.created", "welcome", handler("welcome")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received["welcome"]) == 2 && len(received["audit"]) == 2
+	})
+	if received["welcome"][1] != "2" {
+		t.Fatalf("unexpected messages: %v", received)
+	}
+}
+
+func TestJetStream_RetryAndDeadLetter(t *testing.T) {
+	producer, consumer := newJetStream(t)
+	ctx := context.Background()
+
+	var attempts atomic.Int32
+	dead := make(chan models.DeadLetterMessage, 1)
+	consumer.SetDeadLetterHandler(func(msg models.DeadLetterMessage) error {
+		dead <- msg
+		return nil
+	})
+	_ = consumer.Subscribe(ctx, "pay", "notify", func(msg *models.BaseMessage) error {
+		if attempts.Add(1) == 2 {
+			panic("boom")
+		}
+		return errors.New("failed")
+	})
+	_ = producer.Publish(ctx, "pay", []byte("p1"), map[string]string{"k": "v"})
+
+	select {
+	case msg := <-dead:
+		if msg.RetryCount != 3 || msg.Channel != "notify" || string(msg.Payload) != "p1" || msg.Headers["k"] != "v" {
+			t.Fatalf("unexpected dead letter: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dead letter not received")
+	}
+	// 终止后不再投递
+	time.Sleep(100 * time.Millisecond)
+	if attempts.Load() != 3 {
+		t.Fatalf("attempts = %d", attempts.Load())
+	}
+}
+
+func TestJetStream_PublishDelay(t *testing.T) {
+	producer, consumer := newJetStream(t)
+	ctx := context.Background()
+
+	var attempts atomic.Int32
+	var first atomic.Int64
+	dead := make(chan models.DeadLetterMessage, 1)
+	consumer.SetDeadLetterHandler(func(msg models.DeadLetterMessage) error {
+		dead <- msg
+		return nil
+	})
+	_ = consumer.Subscribe(ctx, "order.timeout", "close", func(msg *models.BaseMessage) error {
+		if attempts.Add(1) == 1 {
+			first.Store(time.Now().UnixMilli())
+		}
+		return errors.New("failed")
+	})
+	start := time.Now()
+	_ = producer.PublishDelay(ctx, "order.timeout", []byte("o1"), nil, 300*time.Millisecond)
+
+	select {
+	case msg := <-dead:
+		// 提前投递不计入重试次数
+		if msg.RetryCount != 3 || attempts.Load() != 3 {
+			t.Fatalf("retry count = %d, attempts = %d", msg.RetryCount, attempts.Load())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dead letter not received")
+	}
+	if delay := time.UnixMilli(first.Load()).Sub(start); delay < 300*time.Millisecond {
+		t.Fatalf("delivered after %v", delay)
+	}
+}
+
+func TestJetStream_PublishDelayAcrossInstances(t *testing.T) {
+	url := runJetStream(t)
+	config := mqnats.NewConfigBuilder(url).JetStream(true).MaxDeliver(3).RetryBackoff(10).Build().ToMQConfig()
+	n := mqnats.NewNATS(config)
+	producer, err := n.NewProducer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+	ctx := context.Background()
+
+	// 同一通道的两个实例竞争消费，重试次数不依赖处理消息的实例
+	var attempts atomic.Int32
+	dead := make(chan models.DeadLetterMessage, 2)
+	for i := 0; i < 2; i++ {
+		consumer, err := n.NewConsumer(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer consumer.Close()
+		consumer.SetDeadLetterHandler(func(msg models.DeadLetterMessage) error {
+			dead <- msg
+			return nil
+		})
+		if err = consumer.Subscribe(ctx, "order.expire", "close", func(msg *models.BaseMessage) error {
+			attempts.Add(1)
+			return errors.New("failed")
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		_ = producer.PublishDelay(ctx, "order.expire", []byte("o1"), nil, 100*time.Millisecond)
+	}
+
+	for i := 0; i < 4; i++ {
+		select {
+		case msg := <-dead:
+			if msg.RetryCount != 3 {
+				t.Fatalf("retry count = %d", msg.RetryCount)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("dead letter not received")
+		}
+	}
+	if attempts.Load() != 12 {
+		t.Fatalf("attempts = %d", attempts.Load())
+	}
+}
@@ -249,16 +249,30 @@ func NewNATS(config *models.Config) *NATS {
 	}
 }
 
-// NewProducer 创建生产者
+// NewProducer 创建生产者，配置开启 JetStream 时创建 JetStream 生产者
 func (n *NATS) NewProducer(config *models.Config) (mq.Producer, error) {
+	if isJetStream(config) {
+		return NewJetStreamProducer(config)
+	}
 	return NewNATSProducer(config)
 }
 
-// NewConsumer 创建消费者
+// NewConsumer 创建消费者，配置开启 JetStream 时创建 JetStream 消费者
 func (n *NATS) NewConsumer(config *models.Config) (mq.Consumer, error) {
+	if isJetStream(config) {
+		return NewJetStreamConsumer(config)
+	}
 	return NewNATSConsumer(config)
 }
 
+func isJetStream(config *models.Config) bool {
+	if config == nil || config.Options == nil {
+		return false
+	}
+	v, _ := config.Options["jetstream"].(bool)
+	return v
+}
+
 // Close 关闭连接
 func (n *NATS) Close() error {
 	return nil
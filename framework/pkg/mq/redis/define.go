@@ -0,0 +1,208 @@
+package redis
+
+import (
+	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+)
+
+const (
+	defaultMaxRetries        = 3
+	defaultKeyPrefix         = "mq:"
+	defaultMaxLen            = 100000
+	defaultBatchSize         = 10
+	defaultBlockTimeout      = 1000
+	defaultClaimIdle         = 30000
+	defaultClaimInterval     = 5000
+	defaultDelayPollInterval = 1000
+)
+
+// Config Redis Streams 配置
+type Config struct {
+	// Address 地址
+	Address string
+	// Password 密码
+	Password string
+	// DB 数据库
+	DB int
+	// MaxRetries 最大投递次数，达到后进入死信
+	MaxRetries int
+	// KeyPrefix 键前缀，主题对应的 stream 为 KeyPrefix+topic
+	KeyPrefix string
+	// MaxLen stream 近似最大长度，超过后裁剪最旧的消息
+	MaxLen int64
+	// BatchSize 每次读取的消息数
+	BatchSize int
+	// BlockTimeout 阻塞读取超时(毫秒)
+	BlockTimeout int
+	// ClaimIdle 待确认消息空闲多久后被重新认领(毫秒)，同时作为失败重试的间隔
+	ClaimIdle int
+	// ClaimInterval 认领检查间隔(毫秒)
+	ClaimInterval int
+	// DelayPollInterval 延迟消息扫描间隔(毫秒)
+	DelayPollInterval int
+}
+
+// ConfigBuilder Redis Streams 配置构建器
+type ConfigBuilder struct {
+	cfg *Config
+}
+
+// NewConfigBuilder 创建 Redis Streams 配置构建器
+func NewConfigBuilder(address string) *ConfigBuilder {
+	return &ConfigBuilder{
+		cfg: &Config{
+			Address:           address,
+			MaxRetries:        defaultMaxRetries,
+			KeyPrefix:         defaultKeyPrefix,
+			MaxLen:            defaultMaxLen,
+			BatchSize:         defaultBatchSize,
+			BlockTimeout:      defaultBlockTimeout,
+			ClaimIdle:         defaultClaimIdle,
+			ClaimInterval:     defaultClaimInterval,
+			DelayPollInterval: defaultDelayPollInterval,
+		},
+	}
+}
+
+// Password 设置密码
+func (b *ConfigBuilder) Password(v string) *ConfigBuilder {
+	b.cfg.Password = v
+	return b
+}
+
+// DB 设置数据库
+func (b *ConfigBuilder) DB(v int) *ConfigBuilder {
+	b.cfg.DB = v
+	return b
+}
+
+// MaxRetries 设置最大投递次数
+func (b *ConfigBuilder) MaxRetries(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.MaxRetries = v
+	}
+	return b
+}
+
+// KeyPrefix 设置键前缀
+func (b *ConfigBuilder) KeyPrefix(v string) *ConfigBuilder {
+	if v != "" {
+		b.cfg.KeyPrefix = v
+	}
+	return b
+}
+
+// MaxLen 设置 stream 近似最大长度
+func (b *ConfigBuilder) MaxLen(v int64) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.MaxLen = v
+	}
+	return b
+}
+
+// BatchSize 设置每次读取的消息数
+func (b *ConfigBuilder) BatchSize(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.BatchSize = v
+	}
+	return b
+}
+
+// BlockTimeout 设置阻塞读取超时(毫秒)
+func (b *ConfigBuilder) BlockTimeout(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.BlockTimeout = v
+	}
+	return b
+}
+
+// ClaimIdle 设置认领空闲时间(毫秒)
+func (b *ConfigBuilder) ClaimIdle(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.ClaimIdle = v
+	}
+	return b
+}
+
+// ClaimInterval 设置认领检查间隔(毫秒)
+func (b *ConfigBuilder) ClaimInterval(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.ClaimInterval = v
+	}
+	return b
+}
+
+// DelayPollInterval 设置延迟消息扫描间隔(毫秒)
+func (b *ConfigBuilder) DelayPollInterval(v int) *ConfigBuilder {
+	if v > 0 {
+		b.cfg.DelayPollInterval = v
+	}
+	return b
+}
+
+// Build 构建配置
+func (b *ConfigBuilder) Build() *Config {
+	return b.cfg
+}
+
+// ToMQConfig 转换为MQ配置
+func (c *Config) ToMQConfig() *models.Config {
+	return &models.Config{
+		Type:       "redis",
+		Address:    c.Address,
+		MaxRetries: c.MaxRetries,
+		Options: map[string]interface{}{
+			"password":            c.Password,
+			"db":                  c.DB,
+			"key_prefix":          c.KeyPrefix,
+			"max_len":             c.MaxLen,
+			"batch_size":          c.BatchSize,
+			"block_timeout":       c.BlockTimeout,
+			"claim_idle":          c.ClaimIdle,
+			"claim_interval":      c.ClaimInterval,
+			"delay_poll_interval": c.DelayPollInterval,
+		},
+	}
+}
+
+// FromMQConfig 从MQ配置创建 Redis Streams 配置
+func FromMQConfig(config *models.Config) (*Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("配置不能为空")
+	}
+
+	builder := NewConfigBuilder(config.Address)
+	builder.MaxRetries(config.MaxRetries)
+
+	if config.Options != nil {
+		if v, ok := config.Options["password"].(string); ok {
+			builder.Password(v)
+		}
+		if v, ok := config.Options["db"].(int); ok {
+			builder.DB(v)
+		}
+		if v, ok := config.Options["key_prefix"].(string); ok {
+			builder.KeyPrefix(v)
+		}
+		if v, ok := config.Options["max_len"].(int64); ok {
+			builder.MaxLen(v)
+		}
+		if v, ok := config.Options["batch_size"].(int); ok {
+			builder.BatchSize(v)
+		}
+		if v, ok := config.Options["block_timeout"].(int); ok {
+			builder.BlockTimeout(v)
+		}
+		if v, ok := config.Options["claim_idle"].(int); ok {
+			builder.ClaimIdle(v)
+		}
+		if v, ok := config.Options["claim_interval"].(int); ok {
+			builder.ClaimInterval(v)
+		}
+		if v, ok := config.Options["delay_poll_interval"].(int); ok {
+			builder.DelayPollInterval(v)
+		}
+	}
+
+	return builder.Build(), nil
+}
@@ -0,0 +1,487 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// 将到期的延迟消息移入对应 stream，多个实例同时执行时每条消息只会被移动一次
+// KEYS[1] 延迟队列；ARGV[1] 当前时间(毫秒) ARGV[2] 单次数量 ARGV[3] stream 前缀 ARGV[4] stream 最大长度
+// 成员格式为 topic + "\n" + 消息JSON
+var moveDueScript = redis.NewScript(`
+local items = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, m in ipairs(items) do
+	local i = string.find(m, "\n", 1, true)
+	if i then
+		redis.call("XADD", ARGV[3] .. string.sub(m, 1, i - 1), "MAXLEN", "~", ARGV[4], "*", "data", string.sub(m, i + 1))
+	end
+	redis.call("ZREM", KEYS[1], m)
+end
+return #items
+`)
+
+func newClient(cfg *Config) (*redis.Client, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Address,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		_ = rdb.Close()
+		return nil, fmt.Errorf("连接Redis失败: %v", err)
+	}
+	return rdb, nil
+}
+
+func streamKey(cfg *Config, topic string) string {
+	return cfg.KeyPrefix + topic
+}
+
+func deadLetterKey(cfg *Config, topic string) string {
+	return cfg.KeyPrefix + topic + ":dead"
+}
+
+func delayKey(cfg *Config) string {
+	return cfg.KeyPrefix + "delayed"
+}
+
+// Producer Redis Streams 生产者实现
+//   - 消息以 JSON 存放在 stream 的 data 字段，写入时按 MaxLen 近似裁剪
+//   - 延迟消息先写入有序集合，到期后由扫描协程移入 stream
+type Producer struct {
+	rdb    *redis.Client
+	config *Config
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRedisProducer 创建 Redis Streams 生产者
+func NewRedisProducer(config *models.Config) (*Producer, error) {
+	cfg, err := FromMQConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("转换配置失败: %v", err)
+	}
+	rdb, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Producer{
+		rdb:    rdb,
+		config: cfg,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go p.dispatchLoop(ctx)
+	return p, nil
+}
+
+// Publish 发布消息
+func (p *Producer) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string) error {
+	msg := models.NewBaseMessage(topic, payload, headers)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+	return p.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(p.config, topic),
+		MaxLen: p.config.MaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": data},
+	}).Err()
+}
+
+// PublishDelay 发布延迟消息
+func (p *Producer) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration) error {
+	if delay <= 0 {
+		return p.Publish(ctx, topic, payload, headers)
+	}
+	msg := models.NewBaseMessage(topic, payload, headers)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+	return p.rdb.ZAdd(ctx, delayKey(p.config), redis.Z{
+		Score:  float64(utils.GetTimeNow().Add(delay).UnixMilli()),
+		Member: topic + "\n" + string(data),
+	}).Err()
+}
+
+// DispatchDue 将到期的延迟消息移入 stream，返回移动数量
+func (p *Producer) DispatchDue(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := moveDueScript.Run(ctx, p.rdb, []string{delayKey(p.config)},
+			utils.GetTimeNow().UnixMilli(), p.config.BatchSize*10, p.config.KeyPrefix, p.config.MaxLen).Int()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < p.config.BatchSize*10 {
+			return total, nil
+		}
+	}
+}
+
+func (p *Producer) dispatchLoop(ctx context.Context) {
+	defer close(p.done)
+	ticker := time.NewTicker(time.Duration(p.config.DelayPollInterval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+				hlog.Errorf("redis mq dispatch delayed messages error: %v", err)
+			}
+		}
+	}
+}
+
+// Close 关闭生产者
+func (p *Producer) Close() error {
+	p.cancel()
+	<-p.done
+	return p.rdb.Close()
+}
+
+// Consumer Redis Streams 消费者实现
+//   - 通道对应消费组，同一通道的多个订阅竞争消费，不同通道各自收到全部消息
+//   - 新建的消费组只接收创建之后发布的消息
+//   - 处理失败的消息保留在待确认列表，空闲超过 ClaimIdle 后被同组任一消费者认领重试（包括崩溃实例遗留的消息）
+//   - 投递次数达到 MaxRetries 后写入死信 stream 并调用死信处理器
+type Consumer struct {
+	rdb    *redis.Client
+	config *Config
+	name   string
+
+	mu                sync.Mutex
+	seq               int
+	subs              map[string][]*subscription
+	deadLetterHandler func(msg models.DeadLetterMessage) error
+}
+
+type subscription struct {
+	topic    string
+	channel  string
+	consumer string
+	handler  func(msg *models.BaseMessage) error
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewRedisConsumer 创建 Redis Streams 消费者
+func NewRedisConsumer(config *models.Config) (*Consumer, error) {
+	cfg, err := FromMQConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("转换配置失败: %v", err)
+	}
+	rdb, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	return &Consumer{
+		rdb:    rdb,
+		config: cfg,
+		name:   fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), strconv.FormatInt(rand.Int63(), 36)),
+		subs:   make(map[string][]*subscription),
+	}, nil
+}
+
+// SetDeadLetterHandler 设置死信处理器
+func (c *Consumer) SetDeadLetterHandler(handler func(msg models.DeadLetterMessage) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadLetterHandler = handler
+}
+
+func (c *Consumer) getDeadLetterHandler() func(msg models.DeadLetterMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadLetterHandler
+}
+
+// Subscribe 订阅主题
+func (c *Consumer) Subscribe(ctx context.Context, topic string, channel string, handler func(msg *models.BaseMessage) error) error {
+	if err := c.ensureGroup(ctx, topic, channel); err != nil {
+		return fmt.Errorf("创建消费组失败: %v", err)
+	}
+	c.mu.Lock()
+	c.seq++
+	subCtx, cancel := context.WithCancel(ctx)
+	sub := &subscription{
+		topic:    topic,
+		channel:  channel,
+		consumer: fmt.Sprintf("%s-%d", c.name, c.seq),
+		handler:  handler,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	key := topic + ":" + channel
+	c.subs[key] = append(c.subs[key], sub)
+	c.mu.Unlock()
+
+	go c.run(subCtx, sub)
+	return nil
+}
+
+// Unsubscribe 取消订阅，未确认的消息保留在消费组中
+func (c *Consumer) Unsubscribe(topic string, channel string) error {
+	key := topic + ":" + channel
+	c.mu.Lock()
+	subs := c.subs[key]
+	delete(c.subs, key)
+	c.mu.Unlock()
+	stopSubs(subs)
+	return nil
+}
+
+// Close 关闭消费者
+func (c *Consumer) Close() error {
+	c.mu.Lock()
+	var subs []*subscription
+	for _, s := range c.subs {
+		subs = append(subs, s...)
+	}
+	c.subs = make(map[string][]*subscription)
+	c.mu.Unlock()
+	stopSubs(subs)
+	return c.rdb.Close()
+}
+
+func stopSubs(subs []*subscription) {
+	for _, sub := range subs {
+		sub.cancel()
+	}
+	for _, sub := range subs {
+		<-sub.done
+	}
+}
+
+func (c *Consumer) ensureGroup(ctx context.Context, topic, channel string) error {
+	err := c.rdb.XGroupCreateMkStream(ctx, streamKey(c.config, topic), channel, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func (c *Consumer) run(ctx context.Context, sub *subscription) {
+	defer close(sub.done)
+	key := streamKey(c.config, sub.topic)
+	claimInterval := time.Duration(c.config.ClaimInterval) * time.Millisecond
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= claimInterval {
+			c.reclaim(ctx, sub)
+			lastClaim = time.Now()
+		}
+		res, err := c.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    sub.channel,
+			Consumer: sub.consumer,
+			Streams:  []string{key, ">"},
+			Count:    int64(c.config.BatchSize),
+			Block:    time.Duration(c.config.BlockTimeout) * time.Millisecond,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				// stream 被删除后重建消费组
+				if err = c.ensureGroup(ctx, sub.topic, sub.channel); err == nil {
+					continue
+				}
+			}
+			hlog.Errorf("redis mq read %s/%s error: %v", sub.topic, sub.channel, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for _, stream := range res {
+			for _, m := range stream.Messages {
+				c.process(ctx, sub, m, 1)
+			}
+		}
+	}
+}
+
+// 认领空闲超时的待确认消息并按投递次数重试或进入死信
+func (c *Consumer) reclaim(ctx context.Context, sub *subscription) {
+	key := streamKey(c.config, sub.topic)
+	start := "0-0"
+	for {
+		msgs, next, err := c.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   key,
+			Group:    sub.channel,
+			MinIdle:  time.Duration(c.config.ClaimIdle) * time.Millisecond,
+			Start:    start,
+			Count:    int64(c.config.BatchSize),
+			Consumer: sub.consumer,
+		}).Result()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, redis.Nil) {
+				hlog.Errorf("redis mq claim %s/%s error: %v", sub.topic, sub.channel, err)
+			}
+			return
+		}
+		counts := c.deliveryCounts(ctx, sub, msgs)
+		for _, m := range msgs {
+			c.process(ctx, sub, m, counts[m.ID])
+		}
+		if len(msgs) == 0 || next == "0-0" {
+			return
+		}
+		start = next
+	}
+}
+
+// 查询消息的投递次数
+func (c *Consumer) deliveryCounts(ctx context.Context, sub *subscription, msgs []redis.XMessage) map[string]int {
+	counts := make(map[string]int, len(msgs))
+	if len(msgs) == 0 {
+		return counts
+	}
+	key := streamKey(c.config, sub.topic)
+	cmds := make([]*redis.XPendingExtCmd, 0, len(msgs))
+	_, _ = c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, m := range msgs {
+			cmds = append(cmds, pipe.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream: key,
+				Group:  sub.channel,
+				Start:  m.ID,
+				End:    m.ID,
+				Count:  1,
+			}))
+		}
+		return nil
+	})
+	for i, cmd := range cmds {
+		counts[msgs[i].ID] = 1
+		if pending, err := cmd.Result(); err == nil && len(pending) > 0 {
+			counts[msgs[i].ID] = int(pending[0].RetryCount)
+		}
+	}
+	return counts
+}
+
+// 处理一条消息，attempts 为当前投递次数
+func (c *Consumer) process(ctx context.Context, sub *subscription, m redis.XMessage, attempts int) {
+	key := streamKey(c.config, sub.topic)
+	data, _ := m.Values["data"].(string)
+	var msg models.BaseMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		hlog.Errorf("反序列化消息失败: %v, body: %s", err, data)
+		c.deadLetter(ctx, sub, m, &msg, attempts, fmt.Sprintf("反序列化消息失败: %v", err))
+		return
+	}
+	// 已达到最大投递次数（例如处理中的实例崩溃）不再处理
+	if attempts > c.config.MaxRetries {
+		c.deadLetter(ctx, sub, m, &msg, attempts-1, "超过最大投递次数")
+		return
+	}
+	err := safeHandle(sub.handler, &msg)
+	if err == nil {
+		if err = c.rdb.XAck(ctx, key, sub.channel, m.ID).Err(); err != nil {
+			hlog.Errorf("redis mq ack %s error: %v", m.ID, err)
+		}
+		return
+	}
+	if attempts >= c.config.MaxRetries {
+		c.deadLetter(ctx, sub, m, &msg, attempts, err.Error())
+		return
+	}
+	// 保留在待确认列表中，空闲超过 ClaimIdle 后重试
+	hlog.Warnf("redis mq handle %s/%s message %s error (attempt %d): %v", sub.topic, sub.channel, m.ID, attempts, err)
+}
+
+// 写入死信 stream、调用死信处理器并确认原消息
+func (c *Consumer) deadLetter(ctx context.Context, sub *subscription, m redis.XMessage, msg *models.BaseMessage, attempts int, reason string) {
+	now := utils.GetTimeNow()
+	deadLetterMsg := models.DeadLetterMessage{
+		Topic:      sub.topic,
+		Channel:    sub.channel,
+		Payload:    msg.GetPayload(),
+		Headers:    msg.GetHeaders(),
+		Error:      reason,
+		RetryCount: attempts,
+		Timestamp:  time.Unix(0, msg.Timestamp),
+		DeadTime:   now,
+	}
+	data, _ := json.Marshal(deadLetterMsg)
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: deadLetterKey(c.config, sub.topic),
+			MaxLen: c.config.MaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"data": data, "channel": sub.channel, "message_id": m.ID},
+		})
+		pipe.XAck(ctx, streamKey(c.config, sub.topic), sub.channel, m.ID)
+		return nil
+	})
+	if err != nil {
+		hlog.Errorf("redis mq dead letter %s error: %v", m.ID, err)
+		return
+	}
+	if handler := c.getDeadLetterHandler(); handler != nil {
+		if err = handler(deadLetterMsg); err != nil {
+			hlog.Errorf("redis mq dead letter handler error: %v", err)
+		}
+	}
+}
+
+func safeHandle(handler func(msg *models.BaseMessage) error, msg *models.BaseMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic recovered: %v", r)
+		}
+	}()
+	return handler(msg)
+}
+
+// Redis Redis Streams 实现
+type Redis struct {
+	config *models.Config
+}
+
+// NewRedis 创建 Redis Streams 实例
+func NewRedis(config *models.Config) *Redis {
+	return &Redis{
+		config: config,
+	}
+}
+
+// NewProducer 创建生产者
+func (r *Redis) NewProducer(config *models.Config) (mq.Producer, error) {
+	return NewRedisProducer(config)
+}
+
+// NewConsumer 创建消费者
+func (r *Redis) NewConsumer(config *models.Config) (mq.Consumer, error) {
+	return NewRedisConsumer(config)
+}
+
+// Close 关闭连接
+func (r *Redis) Close() error {
+	return nil
+}
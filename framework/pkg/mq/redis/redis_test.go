@@ -0,0 +1,227 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	mqredis "github.com/flare-admin/flare-server-go/framework/pkg/mq/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newConfig(mr *miniredis.Miniredis) *models.Config {
+	return mqredis.NewConfigBuilder(mr.Addr()).
+		MaxRetries(3).
+		BlockTimeout(50).
+		ClaimIdle(50).
+		ClaimInterval(20).
+		DelayPollInterval(3600000).
+		Build().
+		ToMQConfig()
+}
+
+func newProducer(t *testing.T, config *models.Config) *mqredis.Producer {
+	p, err := mqredis.NewRedisProducer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func newConsumer(t *testing.T, config *models.Config) *mqredis.Consumer {
+	c, err := mqredis.NewRedisConsumer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedis_GroupsAndCompeting(t *testing.T) {
+	mr := miniredis.RunT(t)
+	config := newConfig(mr)
+	ctx := context.Background()
+	producer := newProducer(t, config)
+	c1 := newConsumer(t, config)
+	c2 := newConsumer(t, config)
+
+	var mu sync.Mutex
+	counts := map[string]int{}
+	seen := map[string]map[string]int{}
+	handler := func(name, channel string) func(msg *models.BaseMessage) error {
+		return func(msg *models.BaseMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[name]++
+			if seen[channel] == nil {
+				seen[channel] = map[string]int{}
+			}
+			seen[channel][string(msg.GetPayload())]++
+			return nil
+		}
+	}
+	// a 组两个消费者竞争消费，b 组单独收到全部消息
+	_ = c1.Subscribe(ctx, "order", "a", handler("a1", "a"))
+	_ = c2.Subscribe(ctx, "order", "a", handler("a2", "a"))
+	_ = c1.Subscribe(ctx, "order", "b", handler("b", "b"))
+
+	for i := 0; i < 50; i++ {
+		if err := producer.Publish(ctx, "order", []byte{byte(i)}, map[string]string{"k": "v"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts["a1"]+counts["a2"] == 50 && counts["b"] == 50
+	})
+	for channel, payloads := range seen {
+		for payload, n := range payloads {
+			if n != 1 {
+				t.Fatalf("channel %s received %v %d times", channel, []byte(payload), n)
+			}
+		}
+	}
+}
+
+func TestRedis_RetryAndDeadLetter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	config := newConfig(mr)
+	ctx := context.Background()
+	producer := newProducer(t, config)
+	consumer := newConsumer(t, config)
+
+	var attempts atomic.Int32
+	dead := make(chan models.DeadLetterMessage, 1)
+	consumer.SetDeadLetterHandler(func(msg models.DeadLetterMessage) error {
+		dead <- msg
+		return nil
+	})
+	_ = consumer.Subscribe(ctx, "pay", "notify", func(msg *models.BaseMessage) error {
+		if attempts.Add(1) == 2 {
+			panic("boom")
+		}
+		return errors.New("failed")
+	})
+	_ = producer.Publish(ctx, "pay", []byte("p1"), map[string]string{"k": "v"})
+
+	select {
+	case msg := <-dead:
+		if msg.RetryCount != 3 || msg.Channel != "notify" || string(msg.Payload) != "p1" || msg.Headers["k"] != "v" {
+			t.Fatalf("unexpected dead letter: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dead letter not received")
+	}
+	if attempts.Load() != 3 {
+		t.Fatalf("attempts = %d", attempts.Load())
+	}
+
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	entries, err := rdb.XRange(ctx, "mq:pay:dead", "-", "+").Result()
+	if err != nil || len(entries) != 1 || entries[0].Values["channel"] != "notify" {
+		t.Fatalf("unexpected dead stream: %v %v", entries, err)
+	}
+	pending, err := rdb.XPending(ctx, "mq:pay", "notify").Result()
+	if err != nil || pending.Count != 0 {
+		t.Fatalf("pending = %+v %v", pending, err)
+	}
+}
+
+func TestRedis_ReclaimFromCrashedConsumer(t *testing.T) {
+	mr := miniredis.RunT(t)
+	config := newConfig(mr)
+	ctx := context.Background()
+	producer := newProducer(t, config)
+
+	// 模拟已读取但未确认就崩溃的消费者
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	if err := rdb.XGroupCreateMkStream(ctx, "mq:job", "worker", "$").Err(); err != nil {
+		t.Fatal(err)
+	}
+	_ = producer.Publish(ctx, "job", []byte("j1"), nil)
+	if _, err := rdb.XReadGroup(ctx, &goredis.XReadGroupArgs{
+		Group: "worker", Consumer: "crashed", Streams: []string{"mq:job", ">"}, Count: 1,
+	}).Result(); err != nil {
+		t.Fatal(err)
+	}
+
+	consumer := newConsumer(t, config)
+	received := make(chan string, 1)
+	_ = consumer.Subscribe(ctx, "job", "worker", func(msg *models.BaseMessage) error {
+		received <- string(msg.GetPayload())
+		return nil
+	})
+	select {
+	case payload := <-received:
+		if payload != "j1" {
+			t.Fatalf("payload = %s", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending message not reclaimed")
+	}
+}
+
+func TestRedis_PublishDelay(t *testing.T) {
+	mr := miniredis.RunT(t)
+	config := newConfig(mr)
+	ctx := context.Background()
+	producer := newProducer(t, config)
+	consumer := newConsumer(t, config)
+
+	var received atomic.Int32
+	_ = consumer.Subscribe(ctx, "timeout", "close", func(msg *models.BaseMessage) error {
+		received.Add(1)
+		return nil
+	})
+	_ = producer.PublishDelay(ctx, "timeout", []byte("late"), nil, time.Hour)
+	_ = producer.PublishDelay(ctx, "timeout", []byte("due"), nil, time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+	n, err := producer.DispatchDue(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("dispatched %d: %v", n, err)
+	}
+	waitFor(t, func() bool { return received.Load() == 1 })
+
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	if left, _ := rdb.ZCard(ctx, "mq:delayed").Result(); left != 1 {
+		t.Fatalf("delayed left = %d", left)
+	}
+}
+
+func TestRedis_StreamTrimming(t *testing.T) {
+	mr := miniredis.RunT(t)
+	config := mqredis.NewConfigBuilder(mr.Addr()).MaxLen(10).Build().ToMQConfig()
+	ctx := context.Background()
+	producer := newProducer(t, config)
+
+	for i := 0; i < 100; i++ {
+		_ = producer.Publish(ctx, "log", []byte{byte(i)}, nil)
+	}
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	// 近似裁剪允许略多于 MaxLen
+	if n, _ := rdb.XLen(ctx, "mq:log").Result(); n < 10 || n >= 100 {
+		t.Fatalf("stream length = %d", n)
+	}
+}
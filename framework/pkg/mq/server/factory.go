@@ -3,9 +3,11 @@ package server
 import (
 	"fmt"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/memory"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq/nats"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq/nsq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/redis"
 	"sync"
 )
 
@@ -56,6 +58,10 @@ func NewMQ(config *models.Config) (mq.MQ, error) {
 		return nsq.NewNSQ(config), nil
 	case "nats":
 		return nats.NewNATS(config), nil
+	case "memory":
+		return memory.NewMemory(config), nil
+	case "redis":
+		return redis.NewRedis(config), nil
 	default:
 		return nil, fmt.Errorf("不支持的MQ类型: %s", config.Type)
 	}
@@ -66,4 +72,6 @@ func Init() {
 	factory := GetFactory()
 	factory.Register("nsq", &nsq.NSQ{})
 	factory.Register("nats", &nats.NATS{})
+	factory.Register("memory", memory.NewMemory(nil))
+	factory.Register("redis", &redis.Redis{})
 }
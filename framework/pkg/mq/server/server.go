@@ -26,17 +26,16 @@ type UnifiedServer struct {
 
 // NewServer 创建统一服务
 func NewServer(config *models.Config) (mq2.Server, error) {
-	// 验证配置
-	if config.Type != "nsq" && config.Type != "nats" {
-		return nil, fmt.Errorf("不支持的MQ类型: %s", config.Type)
-	}
-
 	// 创建MQ实例
 	mq, err := NewMQ(config)
 	if err != nil {
 		return nil, fmt.Errorf("创建MQ失败: %v", err)
 	}
+	return NewServerWithMQ(config, mq)
+}
 
+// NewServerWithMQ 使用已创建的MQ实例创建统一服务，测试中可传入内存队列以便等待消息处理完成
+func NewServerWithMQ(config *models.Config, mq mq2.MQ) (mq2.Server, error) {
 	// 创建生产者
 	producer, err := mq.NewProducer(config)
 	if err != nil {
@@ -13,6 +13,7 @@ type BaseEvent struct {
 	Timestamp time.Time         `json:"timestamp"`
 	Metadata  map[string]string `json:"metadata"`
 	TenantID  string            `json:"tenant_id"` // 租户ID
+	raw       []byte            // 订阅收到的原始负载(已升级到最新版本)
 }
 
 // BaseEventOption 事件选项函数类型
@@ -94,6 +95,11 @@ func (b *BaseEvent) GetTenantID() string {
 	return b.TenantID
 }
 
+// RawData 获取订阅收到的原始负载，发布方创建的事件返回 nil
+func (b *BaseEvent) RawData() []byte {
+	return b.raw
+}
+
 // SetTenantID 设置租户ID
 func (b *BaseEvent) SetTenantID(tenantID string) {
 	b.TenantID = tenantID
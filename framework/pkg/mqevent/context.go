@@ -19,6 +19,8 @@ type EventContext struct {
 	// 时间信息
 	receivedTime  time.Time
 	processedTime time.Time
+	// 处理结果，幂等处理器重复收到消息时回放
+	result []byte
 }
 
 // NewEventContext 创建事件上下文
@@ -92,6 +94,25 @@ func (c *EventContext) SetProcessedTime(t time.Time) {
 	c.processedTime = t
 }
 
+// SetResult 设置处理结果，幂等处理器会保存该结果
+func (c *EventContext) SetResult(v interface{}) error {
+	if b, ok := v.([]byte); ok {
+		c.result = b
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	c.result = b
+	return nil
+}
+
+// Result 获取处理结果
+func (c *EventContext) Result() []byte {
+	return c.result
+}
+
 // GetParameterAs 泛型函数：根据类型获取参数
 func GetParameterAs[T any](ctx *EventContext, key string) (T, error) {
 	var result T
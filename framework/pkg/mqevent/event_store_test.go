@@ -0,0 +1,84 @@
+package mqevent_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/delay"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/memory"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/server"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+)
+
+type memoryStore struct {
+	messages []*models.BaseMessage
+}
+
+func (s *memoryStore) Append(ctx context.Context, msg *models.BaseMessage) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func TestBus_PublishAppendsToEventStore(t *testing.T) {
+	cfg := memory.NewConfigBuilder().MaxRetries(1).Build().ToMQConfig()
+	srv, err := server.NewServerWithMQ(cfg, memory.NewMemory(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	store := &memoryStore{}
+	bus := mqevent.NewMQEventBus(srv, mqevent.WithEventStore(store))
+
+	event := mqevent.NewBaseEvent("order.paid", map[string]interface{}{"amount": 12.5}, mqevent.WithTenantID("t1"))
+	if err = bus.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.messages) != 1 {
+		t.Fatalf("stored %d messages", len(store.messages))
+	}
+
+	// 存储的消息可以还原为原事件用于回放
+	decoded, err := mqevent.DecodeMessage(nil, store.messages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := decoded.GetData().(map[string]interface{})
+	if decoded.GetID() != event.GetID() || decoded.GetType() != "order.paid" || decoded.GetTenantID() != "t1" || data["amount"] != 12.5 {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+}
+
+func TestBus_DelayedEventAppendedOnDispatch(t *testing.T) {
+	cfg := memory.NewConfigBuilder().MaxRetries(1).Build().ToMQConfig()
+	srv, err := server.NewServerWithMQ(cfg, memory.NewMemory(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	scheduler := delay.NewScheduler(delay.NewMemoryStore(), nil, delay.NewServerProducer(srv), delay.Config{PollInterval: time.Hour})
+	defer scheduler.Close()
+	store := &memoryStore{}
+	bus := mqevent.NewMQEventBus(srv, mqevent.WithDelayScheduler(scheduler), mqevent.WithEventStore(store))
+
+	ctx := context.Background()
+	event := mqevent.NewBaseEvent("order.timeout", map[string]interface{}{"order": "o1"})
+	if err = bus.PublishAt(ctx, event, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	// 其他业务直接调度的延迟消息不是事件，不写入存储
+	if _, err = scheduler.Schedule(ctx, "raw", models.NewBaseMessage("raw.topic", []byte("x"), nil), time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.messages) != 0 {
+		t.Fatalf("stored %d messages before dispatch", len(store.messages))
+	}
+
+	if n, err := scheduler.DispatchDue(ctx); err != nil || n != 2 {
+		t.Fatalf("dispatch = %d %v", n, err)
+	}
+	if len(store.messages) != 1 || store.messages[0].GetHeaders()["event_id"] != event.GetID() {
+		t.Fatalf("stored = %+v", store.messages)
+	}
+}
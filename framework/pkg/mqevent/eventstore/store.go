@@ -0,0 +1,226 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTablePrefix 默认分表前缀，按月分表：sys_event_store_202601
+const DefaultTablePrefix = "sys_event_store_"
+
+// 分表后缀格式
+const partitionLayout = "200601"
+
+// 创建分表的超时时间
+const partitionTimeout = 30 * time.Second
+
+// Record 事件存储记录
+type Record struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement:false" json:"id"`    // 主键，按时间递增
+	EventID   string `gorm:"column:event_id;size:64;not null" json:"eventId"`       // 事件ID
+	Topic     string `gorm:"column:topic;size:150;not null" json:"topic"`           // 事件主题
+	Payload   string `gorm:"column:payload;type:text" json:"payload"`               // 事件负载
+	Headers   string `gorm:"column:headers;type:text" json:"headers"`               // 消息头 json
+	TenantID  string `gorm:"column:tenant_id;size:255;default:''" json:"tenantId"`  // 租户ID
+	CreatedAt int64  `gorm:"column:created_at;not null;default:0" json:"createdAt"` // 存储时间（毫秒）
+}
+
+// ToMessage 还原为发布时的消息
+func (r *Record) ToMessage() (*models.BaseMessage, error) {
+	headers := make(map[string]string)
+	if r.Headers != "" {
+		if err := json.Unmarshal([]byte(r.Headers), &headers); err != nil {
+			return nil, fmt.Errorf("解析事件 %d 消息头失败: %v", r.ID, err)
+		}
+	}
+	msg := models.NewBaseMessage(r.Topic, []byte(r.Payload), headers)
+	msg.ID = r.EventID
+	return msg, nil
+}
+
+// Query 回放查询条件，时间为毫秒，为 0 时不限制
+type Query struct {
+	Topic    string
+	FromTime int64
+	ToTime   int64
+	AfterID  int64 // 从该记录之后开始，用于断点续传
+	ToID     int64 // 截止记录（包含）
+}
+
+// Store 基于数据库的事件存储，按月分表，表内按主题和主键索引
+type Store struct {
+	db       database.IDataBase
+	prefix   string
+	mu       sync.Mutex
+	prepared map[string]bool
+}
+
+// NewStore 创建事件存储，prefix 为空时使用 DefaultTablePrefix
+func NewStore(db database.IDataBase, prefix string) *Store {
+	if prefix == "" {
+		prefix = DefaultTablePrefix
+	}
+	return &Store{db: db, prefix: prefix, prepared: make(map[string]bool)}
+}
+
+// Append 追加事件，实现 mqevent.IEventStore
+func (s *Store) Append(ctx context.Context, msg *models.BaseMessage) error {
+	headers, err := json.Marshal(msg.GetHeaders())
+	if err != nil {
+		return err
+	}
+	now := utils.GetTimeNow()
+	table, err := s.ensurePartition(now)
+	if err != nil {
+		return err
+	}
+	eventID := msg.GetHeaders()["event_id"]
+	if eventID == "" {
+		eventID = msg.ID
+	}
+	record := &Record{
+		ID:        s.db.GenInt64Id(),
+		EventID:   eventID,
+		Topic:     msg.Topic,
+		Payload:   string(msg.GetPayload()),
+		Headers:   string(headers),
+		TenantID:  msg.GetHeaders()["tenant_id"],
+		CreatedAt: now.UnixMilli(),
+	}
+	return s.db.DB(ctx).Table(table).Create(record).Error
+}
+
+// Scan 按主键顺序分批遍历满足条件的事件，fn 返回错误时停止
+func (s *Store) Scan(ctx context.Context, q Query, batchSize int, fn func(ctx context.Context, batch []*Record) error) error {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	tables, err := s.partitions(ctx, q.FromTime, q.ToTime)
+	if err != nil {
+		return err
+	}
+	afterID := q.AfterID
+	for _, table := range tables {
+		for {
+			db := s.db.DB(ctx).Table(table).Where("topic = ? AND id > ?", q.Topic, afterID)
+			if q.ToID > 0 {
+				db = db.Where("id <= ?", q.ToID)
+			}
+			if q.FromTime > 0 {
+				db = db.Where("created_at >= ?", q.FromTime)
+			}
+			if q.ToTime > 0 {
+				db = db.Where("created_at <= ?", q.ToTime)
+			}
+			var batch []*Record
+			if err = db.Order("id").Limit(batchSize).Find(&batch).Error; err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			if err = fn(ctx, batch); err != nil {
+				return err
+			}
+			afterID = batch[len(batch)-1].ID
+			if len(batch) < batchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// 获取时间范围内已存在的分表，按时间升序
+func (s *Store) partitions(ctx context.Context, from, to int64) ([]string, error) {
+	all, err := s.db.DB(ctx).Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+	var fromName, toName string
+	if from > 0 {
+		fromName = s.tableName(time.UnixMilli(from))
+	}
+	if to > 0 {
+		toName = s.tableName(time.UnixMilli(to))
+	}
+	var tables []string
+	for _, table := range all {
+		suffix, ok := strings.CutPrefix(table, s.prefix)
+		if !ok || len(suffix) != len(partitionLayout) {
+			continue
+		}
+		if _, err = time.Parse(partitionLayout, suffix); err != nil {
+			continue
+		}
+		if (fromName != "" && table < fromName) || (toName != "" && table > toName) {
+			continue
+		}
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// Prepare 创建当月和下月的分表，启动时调用，避免月初第一次写入时才建表
+func (s *Store) Prepare() error {
+	_, err := s.ensurePartition(utils.GetTimeNow())
+	return err
+}
+
+// ensurePartition 返回 t 所在月份的分表，第一次使用时创建该月和下月的分表
+// 建表使用独立的连接，不在调用方的事务中执行：MySQL 的 DDL 会隐式提交当前事务
+func (s *Store) ensurePartition(t time.Time) (string, error) {
+	table := s.tableName(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.prepared[table] {
+		return table, nil
+	}
+	// 调用方的上下文可能带有事务，建表使用新的上下文
+	ctx, cancel := context.WithTimeout(context.Background(), partitionTimeout)
+	defer cancel()
+	db := s.db.DB(ctx)
+	for _, month := range []time.Time{t, t.AddDate(0, 1, 1-t.Day())} {
+		name := s.tableName(month)
+		if s.prepared[name] {
+			continue
+		}
+		if err := createPartition(db, name); err != nil {
+			return "", err
+		}
+		s.prepared[name] = true
+	}
+	return table, nil
+}
+
+// createPartition 创建分表及索引，多个实例同时创建时已存在视为成功
+func createPartition(db *gorm.DB, table string) error {
+	migrator := db.Table(table).Migrator()
+	if !migrator.HasTable(table) {
+		if err := migrator.CreateTable(&Record{}); err != nil && !migrator.HasTable(table) {
+			return fmt.Errorf("创建事件存储分表 %s 失败: %v", table, err)
+		}
+	}
+	// 索引名在 postgres 中全库唯一，按分表命名
+	index := fmt.Sprintf("idx_%s_topic", table)
+	if !migrator.HasIndex(&Record{}, index) {
+		if err := db.Exec(fmt.Sprintf("CREATE INDEX %s ON %s (topic, id)", index, table)).Error; err != nil && !migrator.HasIndex(&Record{}, index) {
+			return fmt.Errorf("创建事件存储分表 %s 索引失败: %v", table, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) tableName(t time.Time) string {
+	return s.prefix + t.Format(partitionLayout)
+}
@@ -0,0 +1,69 @@
+package eventstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/snowflake_id"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestData(t *testing.T) database.IDataBase {
+	dsn := filepath.Join(t.TempDir(), "event_store.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	data, _ := database.NewData(snowflake_id.NewSnowIdGen(), db, &configs.Data{})
+	return data
+}
+
+// 在事务中写入时分表在独立连接中创建，同时创建下月的分表
+func TestStore_AppendInTx(t *testing.T) {
+	data := newTestData(t)
+	store := NewStore(data, "")
+	ctx := context.Background()
+
+	msg := models.NewBaseMessage("order.created", []byte(`{"id":1}`), map[string]string{"event_id": "e1"})
+	if err := data.InTx(ctx, func(ctx context.Context) error {
+		return store.Append(ctx, msg)
+	}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	now := utils.GetTimeNow()
+	migrator := data.DB(ctx).Migrator()
+	for _, month := range []string{store.tableName(now), store.tableName(now.AddDate(0, 1, 1-now.Day()))} {
+		if !migrator.HasTable(month) || !migrator.HasIndex(month, "idx_"+month+"_topic") {
+			t.Fatalf("partition %s was not prepared", month)
+		}
+	}
+
+	var events []string
+	if err := store.Scan(ctx, Query{Topic: "order.created"}, 10, func(ctx context.Context, batch []*Record) error {
+		for _, r := range batch {
+			events = append(events, r.EventID)
+		}
+		return nil
+	}); err != nil || len(events) != 1 || events[0] != "e1" {
+		t.Fatalf("scan: events=%v err=%v", events, err)
+	}
+
+	// 其他实例已创建分表时视为成功
+	other := NewStore(data, "")
+	if err := other.Prepare(); err != nil {
+		t.Fatalf("prepare existing partitions: %v", err)
+	}
+	if err := other.Append(ctx, msg); err != nil {
+		t.Fatalf("append to existing partition: %v", err)
+	}
+}
@@ -46,6 +46,12 @@ type DeadLetterEventHandlerFunc func(ctx context.Context, event *DeadLetterEvent
 type IMQEventBus interface {
 	// Publish 发布事件
 	Publish(ctx context.Context, event Event) error
+	// PublishDelay 延迟发布事件，配置了持久化延迟调度器时进程重启不丢失，可按事件ID取消
+	PublishDelay(ctx context.Context, event Event, delay time.Duration) error
+	// PublishAt 在指定时间发布事件，例如订单超时关闭
+	PublishAt(ctx context.Context, event Event, at time.Time) error
+	// CancelDelay 按事件ID取消尚未发布的延迟事件，返回是否取消成功
+	CancelDelay(ctx context.Context, eventID string) (bool, error)
 	// Subscribe 订阅事件
 	// channel 用于区分不同的业务场景，例如：
 	// - "user-init" 用于用户注册后的初始化
@@ -55,8 +55,10 @@ type EventManager interface {
 	//DeactivateSubscription 停止订阅
 	DeactivateSubscription(topic, channel string) error
 
-	// 死信处理
-	RetryDeadLetter(ctx context.Context, dead *mqevent.DeadLetterEvent) error
+	// 死信处理，返回处理器设置的处理结果，幂等订阅的重复消息返回首次处理的结果
+	RetryDeadLetter(ctx context.Context, dead *mqevent.DeadLetterEvent) ([]byte, error)
+	// DeliverTo 将事件只投递给指定通道的处理器，用于历史事件回放，返回值同 RetryDeadLetter
+	DeliverTo(ctx context.Context, event mqevent.Event, channel string) ([]byte, error)
 
 	// 生命周期管理
 	Start() error
@@ -97,8 +97,8 @@ func (e *EventBusManager) DeactivateSubscription(topic, channel string) error {
 	return nil
 }
 
-// handel 处理事件
-func (e *EventBusManager) handel(ctx context.Context, event mqevent.Event, channel string) error {
+// handel 处理事件，返回处理器设置的处理结果，重复消息返回首次处理保存的结果
+func (e *EventBusManager) handel(ctx context.Context, event mqevent.Event, channel string) ([]byte, error) {
 	key := getHandlerKey(event.GetType(), channel)
 
 	// 检查订阅状态
@@ -106,7 +106,7 @@ func (e *EventBusManager) handel(ctx context.Context, event mqevent.Event, chann
 		if !status.(bool) {
 			// 如果订阅已停用，直接返回
 			hlog.Debugf("Subscription is deactivated, skipping event: %s", key)
-			return nil
+			return nil, nil
 		}
 	}
 	ctx = actx.WithTenantId(ctx, event.GetTenantID())
@@ -115,7 +115,7 @@ func (e *EventBusManager) handel(ctx context.Context, event mqevent.Event, chann
 	params, err := e.subsampling.GetParameters(ctx, event.GetType(), channel)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "failed to get subscription parameters: %v", err)
-		return fmt.Errorf("failed to get subscription parameters: %w", err)
+		return nil, fmt.Errorf("failed to get subscription parameters: %w", err)
 	}
 	eventCtx := mqevent.NewEventContext(ctx, event, channel, params)
 
@@ -123,40 +123,62 @@ func (e *EventBusManager) handel(ctx context.Context, event mqevent.Event, chann
 	handler, ok := e.handlers.Load(key)
 	if !ok {
 		hlog.CtxErrorf(ctx, "handler not found for event: %s", event.GetType())
-		return fmt.Errorf("handler not found for event: %s", event.GetType())
+		return nil, fmt.Errorf("handler not found for event: %s", event.GetType())
 	}
 
 	// 检查是否需要幂等性处理
 	if needIdempotence, ok := e.handlerIdempotence.Load(key); ok && needIdempotence.(bool) {
-		check, err := e.idempotenceTool.Check(ctx, event.GetType(), channel, event.GetID())
-		if err != nil {
-			hlog.CtxErrorf(ctx, "failed to check idempotence: %v", err)
-			return fmt.Errorf("failed to check idempotence: %w", err)
-		}
-		if check {
-			// 如果已经处理过，直接返回
-			hlog.Infof("Event already processed, skipping: %s", event.GetID())
-			return nil
-		}
+		return e.handelIdempotence(ctx, eventCtx, handler.(EventHandler), channel)
+	}
 
-		// 处理
-		err = handler.(EventHandler).Handle(eventCtx)
-		if err != nil {
-			hlog.CtxErrorf(ctx, "failed to process event: %v", err)
-			return fmt.Errorf("failed to process event: %w", err)
-		}
-		// 使用幂等性工具处理
-		return e.idempotenceTool.MarkProcessed(ctx, event.GetType(), channel, event.GetID())
+	if err = handler.(EventHandler).Handle(eventCtx); err != nil {
+		return nil, err
+	}
+	return eventCtx.Result(), nil
+}
+
+// handelIdempotence 认领消息后处理，处理中的消息返回错误等待重投，已处理的消息不再处理并返回保存的处理结果
+func (e *EventBusManager) handelIdempotence(ctx context.Context, eventCtx *mqevent.EventContext, handler EventHandler, channel string) ([]byte, error) {
+	event := eventCtx.Event()
+	claim, err := e.idempotenceTool.Claim(ctx, event.GetType(), channel, event.GetID(), idempotence.DefaultLease)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "failed to claim event: %v", err)
+		return nil, fmt.Errorf("failed to claim event: %w", err)
+	}
+	switch claim.State {
+	case idempotence.ClaimDone:
+		// 如果已经处理过，回放首次处理的结果
+		hlog.Infof("Event already processed, replaying result: %s", event.GetID())
+		_ = eventCtx.SetResult(claim.Result)
+		return claim.Result, nil
+	case idempotence.ClaimBusy:
+		return nil, fmt.Errorf("event %s is being processed by another consumer", event.GetID())
 	}
 
-	return handler.(EventHandler).Handle(eventCtx)
+	// 处理
+	if err = handler.Handle(eventCtx); err != nil {
+		hlog.CtxErrorf(ctx, "failed to process event: %v", err)
+		if ferr := e.idempotenceTool.Fail(ctx, claim, err); ferr != nil {
+			hlog.CtxErrorf(ctx, "failed to mark event failed: %v", ferr)
+		}
+		return nil, fmt.Errorf("failed to process event: %w", err)
+	}
+	if err = e.idempotenceTool.Complete(ctx, claim, eventCtx.Result()); err != nil {
+		return nil, err
+	}
+	return eventCtx.Result(), nil
 }
 
-// RetryDeadLetter 重试死信队列
-func (e *EventBusManager) RetryDeadLetter(ctx context.Context, dead *mqevent.DeadLetterEvent) error {
+// RetryDeadLetter 重试死信队列，返回处理结果
+func (e *EventBusManager) RetryDeadLetter(ctx context.Context, dead *mqevent.DeadLetterEvent) ([]byte, error) {
 	return e.handel(ctx, dead.OriginalEvent, dead.Channel)
 }
 
+// DeliverTo 投递事件到指定通道，返回处理结果
+func (e *EventBusManager) DeliverTo(ctx context.Context, event mqevent.Event, channel string) ([]byte, error) {
+	return e.handel(ctx, event, channel)
+}
+
 // Start 启动事件总线管理器
 func (e *EventBusManager) Start() error {
 	// 获取所有激活状态的订阅
@@ -191,7 +213,8 @@ func (e *EventBusManager) Start() error {
 			}()
 			ctx = actx.WithTenantId(ctx, event.GetTenantID())
 			// 处理事件
-			return e.handel(ctx, event, channel)
+			_, err := e.handel(ctx, event, channel)
+			return err
 		})
 		// 订阅事件
 		subscriptionID, err := e.ebs.Subscribe(topic, channel, eventHandler)
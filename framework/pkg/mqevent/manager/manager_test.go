@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/idempotence"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/redis/go-redis/v9"
+)
+
+// paramsApi 只返回空订阅参数
+type paramsApi struct {
+	ISubscribeSmServerApi
+}
+
+func (paramsApi) GetParameters(ctx context.Context, topic, channel string) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestDeliverTo_ReplaysStoredResult(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	em := NewEventBusManager(paramsApi{}, nil, idempotence.NewRedisIdempotencyTool(rdb, "", time.Hour))
+
+	calls := 0
+	err := em.RegisterIdempotenceSubscribeHandel("order", "bill", EventHandlerFunc(func(ctx *mqevent.EventContext) error {
+		calls++
+		return ctx.SetResult(map[string]int{"bill": calls})
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := mqevent.NewEvent("order", map[string]string{"id": "o1"})
+	first, err := em.DeliverTo(context.Background(), event, "bill")
+	if err != nil || string(first) != `{"bill":1}` {
+		t.Fatalf("first = %s, %v", first, err)
+	}
+	// 重复投递不再处理，返回首次处理的结果
+	again, err := em.DeliverTo(context.Background(), event, "bill")
+	if err != nil || string(again) != `{"bill":1}` || calls != 1 {
+		t.Fatalf("duplicate = %s, %v, calls = %d", again, err, calls)
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/delay"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mq/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"reflect"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -16,19 +21,124 @@ type Bus struct {
 	server          mq.Server
 	subscriptions   map[string]string // subscriptionID -> topic:channel
 	deadLetterSubID string
+	scheduler       *delay.Scheduler
+	registry        *SchemaRegistry
+	store           IEventStore
 	mu              sync.RWMutex
 }
 
+// IEventStore 事件存储，发布成功的事件追加到存储中用于回放
+type IEventStore interface {
+	Append(ctx context.Context, msg *models.BaseMessage) error
+}
+
+// BusOption 事件总线选项
+type BusOption func(*Bus)
+
+// WithDelayScheduler 使用持久化延迟调度器发布延迟事件，未设置时使用 MQ 驱动自身的延迟发布(重启丢失且不可取消)
+func WithDelayScheduler(scheduler *delay.Scheduler) BusOption {
+	return func(b *Bus) {
+		b.scheduler = scheduler
+	}
+}
+
+// WithSchemaRegistry 指定事件类型注册表，默认使用 DefaultRegistry
+func WithSchemaRegistry(registry *SchemaRegistry) BusOption {
+	return func(b *Bus) {
+		b.registry = registry
+	}
+}
+
+// WithEventStore 发布事件后追加到事件存储，存储失败只记录日志不影响发布
+// 使用延迟调度器时延迟事件在调度器到期发布后写入，否则在交给 MQ 驱动延迟发布后写入
+func WithEventStore(store IEventStore) BusOption {
+	return func(b *Bus) {
+		b.store = store
+	}
+}
+
 // NewMQEventBus 创建基于 MQ Server 的事件总线
-func NewMQEventBus(server mq.Server) IMQEventBus {
-	return &Bus{
+func NewMQEventBus(server mq.Server, opts ...BusOption) IMQEventBus {
+	b := &Bus{
 		server:        server,
 		subscriptions: make(map[string]string),
+		registry:      DefaultRegistry,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.scheduler != nil && b.store != nil {
+		b.scheduler.OnDispatch(b.appendDelayed)
+	}
+	return b
+}
+
+// appendDelayed 调度器发布延迟消息后写入事件存储，调度器上的其他延迟消息不是事件，跳过
+func (b *Bus) appendDelayed(ctx context.Context, msg *models.BaseMessage) {
+	if msg.GetHeaders()["event_type"] == "" {
+		return
+	}
+	b.appendToStore(ctx, msg)
+}
+
+func (b *Bus) appendToStore(ctx context.Context, msg *models.BaseMessage) {
+	if err := b.store.Append(ctx, msg); err != nil {
+		hlog.CtxErrorf(ctx, "事件 %s 写入事件存储失败: %v", msg.GetHeaders()["event_id"], err)
 	}
 }
 
 // Publish 发布事件
 func (b *Bus) Publish(ctx context.Context, event Event) error {
+	msg, err := b.buildMessage(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	// 发布消息
+	if err = b.server.Publish(ctx, msg); err != nil {
+		return err
+	}
+	if b.store != nil {
+		b.appendToStore(ctx, msg)
+	}
+	return nil
+}
+
+// PublishDelay 延迟发布事件
+func (b *Bus) PublishDelay(ctx context.Context, event Event, delay time.Duration) error {
+	return b.PublishAt(ctx, event, utils.GetTimeNow().Add(delay))
+}
+
+// PublishAt 在指定时间发布事件，延迟调度器以事件ID作为取消标识
+func (b *Bus) PublishAt(ctx context.Context, event Event, at time.Time) error {
+	msg, err := b.buildMessage(ctx, event)
+	if err != nil {
+		return err
+	}
+	if b.scheduler == nil {
+		// MQ 驱动自身的延迟发布无法感知投递时刻，交给驱动后即写入存储
+		if err = b.server.PublishDelay(ctx, msg, at.Sub(utils.GetTimeNow())); err != nil {
+			return err
+		}
+		if b.store != nil {
+			b.appendToStore(ctx, msg)
+		}
+		return nil
+	}
+	_, err = b.scheduler.Schedule(ctx, event.GetID(), msg, at)
+	return err
+}
+
+// CancelDelay 取消延迟事件
+func (b *Bus) CancelDelay(ctx context.Context, eventID string) (bool, error) {
+	if b.scheduler == nil {
+		return false, fmt.Errorf("未配置延迟调度器，无法取消延迟事件")
+	}
+	return b.scheduler.Cancel(ctx, eventID)
+}
+
+// 构建事件消息
+func (b *Bus) buildMessage(ctx context.Context, event Event) (*models.BaseMessage, error) {
 	// 构建消息头
 	headers := make(map[string]string)
 	headers["event_id"] = event.GetID()
@@ -46,17 +156,25 @@ func (b *Bus) Publish(ctx context.Context, event Event) error {
 		headers[k] = v
 	}
 
+	// 已注册的事件按负载的 Go 类型确定版本，类型未登记时拒绝发布，避免旧结构被标记为最新版本
+	if _, ok := headers[HeaderEventVersion]; !ok && b.registry != nil {
+		if _, registered := b.registry.Latest(event.GetType()); registered {
+			version, ok := b.registry.VersionOf(event.GetType(), reflect.TypeOf(event.GetData()))
+			if !ok {
+				return nil, fmt.Errorf("事件 %s 的负载类型 %T 未登记版本", event.GetType(), event.GetData())
+			}
+			headers[HeaderEventVersion] = strconv.Itoa(version)
+		}
+	}
+
 	// 序列化事件数据
 	data, err := json.Marshal(event.GetData())
 	if err != nil {
-		return fmt.Errorf("序列化事件数据失败: %v", err)
+		return nil, fmt.Errorf("序列化事件数据失败: %v", err)
 	}
 
 	// 创建基础消息
-	msg := models.NewBaseMessage(event.GetType(), data, headers)
-
-	// 发布消息
-	return b.server.Publish(ctx, msg)
+	return models.NewBaseMessage(event.GetType(), data, headers), nil
 }
 
 // Subscribe 订阅事件
@@ -69,27 +187,10 @@ func (b *Bus) Subscribe(eventType string, channel string, handler EventHandler)
 
 	// 订阅消息
 	err := b.server.Subscribe(context.Background(), eventType, channel, func(msg *models.BaseMessage) error {
-		// 解析消息头
-		headers := msg.GetHeaders()
-		eventID := headers["event_id"]
-		eventType := headers["event_type"]
-		tenantID := headers["tenant_id"]
-		timestamp, _ := time.Parse(time.RFC3339, headers["timestamp"])
-
-		// 创建基础事件
-		event := NewBaseEvent(eventType, nil,
-			WithID(eventID),
-			WithTimestamp(timestamp),
-			WithTenantID(tenantID),
-			WithMetadata(headers),
-		)
-
-		// 解析事件数据
-		var data interface{}
-		if err := json.Unmarshal(msg.GetPayload(), &data); err != nil {
-			return fmt.Errorf("反序列化事件数据失败: %v", err)
+		event, err := DecodeMessage(b.registry, msg)
+		if err != nil {
+			return err
 		}
-		event.Data = data
 
 		// 处理事件
 		return handler.Handle(context.Background(), event)
@@ -105,6 +206,46 @@ func (b *Bus) Subscribe(eventType string, channel string, handler EventHandler)
 	return subscriptionID, nil
 }
 
+// DecodeMessage 将消息还原为事件，registry 不为空时负载升级到最新版本
+func DecodeMessage(registry *SchemaRegistry, msg *models.BaseMessage) (*BaseEvent, error) {
+	// 解析消息头
+	headers := msg.GetHeaders()
+	eventID := headers["event_id"]
+	eventType := headers["event_type"]
+	tenantID := headers["tenant_id"]
+	timestamp, _ := time.Parse(time.RFC3339, headers["timestamp"])
+
+	// 创建基础事件
+	event := NewBaseEvent(eventType, nil,
+		WithID(eventID),
+		WithTimestamp(timestamp),
+		WithTenantID(tenantID),
+		WithMetadata(headers),
+	)
+
+	// 旧版本负载升级到最新版本，处理器只会看到最新结构
+	payload := msg.GetPayload()
+	if registry != nil {
+		if latest, ok := registry.Latest(eventType); ok {
+			upcast, _, err := registry.Upcast(eventType, parseVersion(headers[HeaderEventVersion]), payload)
+			if err != nil {
+				return nil, err
+			}
+			payload = upcast
+			event.SetMetadata(HeaderEventVersion, strconv.Itoa(latest))
+		}
+	}
+
+	// 解析事件数据
+	var data interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("反序列化事件数据失败: %v", err)
+	}
+	event.Data = data
+	event.raw = payload
+	return event, nil
+}
+
 // Unsubscribe 取消订阅
 func (b *Bus) Unsubscribe(subscriptionID string) error {
 	b.mu.Lock()
@@ -0,0 +1,276 @@
+package mqevent
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HeaderEventVersion 事件负载版本消息头，未携带时视为版本 1
+	HeaderEventVersion = "event_version"
+)
+
+// Upcaster 将某个版本的负载转换为下一个版本
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+// EventSchema 已注册的事件负载结构
+type EventSchema struct {
+	Topic   string          `json:"topic"`   // 主题
+	Version int             `json:"version"` // 版本
+	Type    string          `json:"type"`    // Go 类型
+	Schema  json.RawMessage `json:"schema"`  // JSON Schema
+	goType  reflect.Type
+}
+
+// SchemaRegistry 事件类型注册表，按主题和版本登记负载类型，并保存版本之间的升级器
+type SchemaRegistry struct {
+	mu        sync.RWMutex
+	schemas   map[string]map[int]*EventSchema
+	upcasters map[string]map[int]Upcaster
+}
+
+// DefaultRegistry 默认注册表，事件总线未指定注册表时使用
+var DefaultRegistry = NewSchemaRegistry()
+
+// NewSchemaRegistry 创建事件类型注册表
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas:   make(map[string]map[int]*EventSchema),
+		upcasters: make(map[string]map[int]Upcaster),
+	}
+}
+
+// Register 登记主题某个版本的负载类型
+func (r *SchemaRegistry) Register(topic string, version int, t reflect.Type) error {
+	if topic == "" || version < 1 {
+		return fmt.Errorf("事件主题不能为空且版本必须大于0")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schema, err := json.Marshal(GenerateJSONSchema(t))
+	if err != nil {
+		return fmt.Errorf("生成 JSON Schema 失败: %v", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.schemas[topic] == nil {
+		r.schemas[topic] = make(map[int]*EventSchema)
+	}
+	if old, ok := r.schemas[topic][version]; ok && old.goType != t {
+		return fmt.Errorf("事件 %s 版本 %d 已注册为 %s", topic, version, old.Type)
+	}
+	r.schemas[topic][version] = &EventSchema{
+		Topic:   topic,
+		Version: version,
+		Type:    t.String(),
+		Schema:  schema,
+		goType:  t,
+	}
+	return nil
+}
+
+// RegisterUpcaster 登记将 fromVersion 版本负载转换为 fromVersion+1 版本的升级器
+func (r *SchemaRegistry) RegisterUpcaster(topic string, fromVersion int, upcaster Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.upcasters[topic] == nil {
+		r.upcasters[topic] = make(map[int]Upcaster)
+	}
+	r.upcasters[topic][fromVersion] = upcaster
+}
+
+// Latest 获取主题最新版本，未注册时返回 false
+func (r *SchemaRegistry) Latest(topic string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	latest := 0
+	for v := range r.schemas[topic] {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest, latest > 0
+}
+
+// VersionOf 根据负载的 Go 类型查找主题中登记的版本，类型未登记时返回 false
+func (r *SchemaRegistry) VersionOf(topic string, t reflect.Type) (int, bool) {
+	if t == nil {
+		return 0, false
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for v, s := range r.schemas[topic] {
+		if s.goType == t {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// Schema 获取主题指定版本的结构，version 为 0 时返回最新版本
+func (r *SchemaRegistry) Schema(topic string, version int) (*EventSchema, bool) {
+	if version == 0 {
+		var ok bool
+		if version, ok = r.Latest(topic); !ok {
+			return nil, false
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.schemas[topic][version]
+	return s, ok
+}
+
+// Schemas 获取主题所有已注册版本，按版本升序
+func (r *SchemaRegistry) Schemas(topic string) []*EventSchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*EventSchema, 0, len(r.schemas[topic]))
+	for _, s := range r.schemas[topic] {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+// Upcast 将负载逐版本升级到最新版本，返回升级后的负载和版本；主题未注册时原样返回
+func (r *SchemaRegistry) Upcast(topic string, version int, data []byte) ([]byte, int, error) {
+	latest, ok := r.Latest(topic)
+	if !ok {
+		return data, version, nil
+	}
+	if version < 1 {
+		version = 1
+	}
+	if version > latest {
+		return nil, version, fmt.Errorf("事件 %s 版本 %d 高于已注册的最新版本 %d", topic, version, latest)
+	}
+	for ; version < latest; version++ {
+		r.mu.RLock()
+		upcaster, ok := r.upcasters[topic][version]
+		r.mu.RUnlock()
+		if !ok {
+			return nil, version, fmt.Errorf("事件 %s 缺少版本 %d 到 %d 的升级器", topic, version, version+1)
+		}
+		out, err := upcaster(data)
+		if err != nil {
+			return nil, version, fmt.Errorf("事件 %s 版本 %d 升级失败: %v", topic, version, err)
+		}
+		data = out
+	}
+	return data, latest, nil
+}
+
+// RegisterEvent 在默认注册表中登记主题某个版本的负载类型 T
+func RegisterEvent[T any](topic string, version int) error {
+	return DefaultRegistry.Register(topic, version, reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// RegisterUpcaster 在默认注册表中登记升级器
+func RegisterUpcaster(topic string, fromVersion int, upcaster Upcaster) {
+	DefaultRegistry.RegisterUpcaster(topic, fromVersion, upcaster)
+}
+
+// 解析版本消息头
+func parseVersion(v string) int {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// GenerateJSONSchema 根据 Go 类型生成 JSON Schema，字段名和必填项遵循 json 标签
+func GenerateJSONSchema(t reflect.Type) map[string]interface{} {
+	schema := jsonSchema(t, map[reflect.Type]bool{})
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return schema
+}
+
+func jsonSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			// 递归类型不再展开
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := map[string]interface{}{}
+		var required []string
+		addStructFields(t, properties, &required, visiting)
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		// 匿名嵌入结构体的字段提升到外层
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, properties, required, visiting)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = jsonSchema(f.Type, visiting)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
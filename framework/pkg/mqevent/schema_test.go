@@ -0,0 +1,173 @@
+package mqevent_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/memory"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mq/server"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+)
+
+type userCreatedV1 struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type userCreatedV2 struct {
+	ID        int64     `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Inviter   *struct {
+		ID int64 `json:"id"`
+	} `json:"inviter"`
+}
+
+// v1 的 name 拆分为 first_name/last_name
+func upcastUserV1(data json.RawMessage) (json.RawMessage, error) {
+	var v1 userCreatedV1
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return nil, err
+	}
+	first, last, _ := strings.Cut(v1.Name, " ")
+	return json.Marshal(map[string]interface{}{"id": v1.ID, "first_name": first, "last_name": last})
+}
+
+func newRegistry(t *testing.T) *mqevent.SchemaRegistry {
+	r := mqevent.NewSchemaRegistry()
+	if err := r.Register("user.created", 1, reflect.TypeOf(userCreatedV1{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("user.created", 2, reflect.TypeOf(&userCreatedV2{})); err != nil {
+		t.Fatal(err)
+	}
+	r.RegisterUpcaster("user.created", 1, upcastUserV1)
+	return r
+}
+
+func TestSchemaRegistry_SchemaAndUpcast(t *testing.T) {
+	r := newRegistry(t)
+
+	if latest, ok := r.Latest("user.created"); !ok || latest != 2 {
+		t.Fatalf("latest = %d %v", latest, ok)
+	}
+	if err := r.Register("user.created", 2, reflect.TypeOf(userCreatedV1{})); err == nil {
+		t.Fatal("expected conflict when re-registering a version with another type")
+	}
+
+	s, _ := r.Schema("user.created", 0)
+	var schema map[string]interface{}
+	_ = json.Unmarshal(s.Schema, &schema)
+	props := schema["properties"].(map[string]interface{})
+	if props["created_at"].(map[string]interface{})["format"] != "date-time" ||
+		props["tags"].(map[string]interface{})["type"] != "array" ||
+		props["id"].(map[string]interface{})["type"] != "integer" {
+		t.Fatalf("unexpected properties: %v", props)
+	}
+	required := schema["required"].([]interface{})
+	if len(required) != 4 {
+		t.Fatalf("required = %v", required)
+	}
+
+	out, version, err := r.Upcast("user.created", 1, []byte(`{"id":1,"name":"Ada Lovelace"}`))
+	if err != nil || version != 2 || !strings.Contains(string(out), `"first_name":"Ada"`) {
+		t.Fatalf("upcast = %s %d %v", out, version, err)
+	}
+	if _, _, err = r.Upcast("user.created", 3, []byte(`{}`)); err == nil {
+		t.Fatal("expected error for unknown future version")
+	}
+	// 未注册的主题原样返回
+	if out, _, err = r.Upcast("other", 1, []byte(`x`)); err != nil || string(out) != "x" {
+		t.Fatalf("passthrough = %s %v", out, err)
+	}
+}
+
+func TestTypedSubscribe_UpcastsOldPayloads(t *testing.T) {
+	m := memory.NewMemory(memory.NewConfigBuilder().MaxRetries(1).Build().ToMQConfig())
+	srv, err := server.NewServerWithMQ(memory.NewConfigBuilder().MaxRetries(1).Build().ToMQConfig(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	bus := mqevent.NewMQEventBus(srv, mqevent.WithSchemaRegistry(newRegistry(t)))
+
+	var got []userCreatedV2
+	var versions atomic.Int32
+	_, err = mqevent.Subscribe(bus, "user.created", "welcome", func(ctx context.Context, event mqevent.Event, data userCreatedV2) error {
+		got = append(got, data)
+		if event.GetMetadata()[mqevent.HeaderEventVersion] == "2" {
+			versions.Add(1)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	// 旧版本生产者
+	old := mqevent.NewBaseEvent("user.created", userCreatedV1{ID: 1, Name: "Ada Lovelace"}, mqevent.WithMetadataItem(mqevent.HeaderEventVersion, "1"))
+	if err = bus.Publish(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+	// 新版本生产者，大整数不丢失精度
+	if err = mqevent.Publish(ctx, bus, "user.created", userCreatedV2{ID: 1<<60 + 1, FirstName: "Grace"}); err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err = m.WaitIdle(waitCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || got[0].FirstName != "Ada" || got[0].LastName != "Lovelace" || got[1].ID != 1<<60+1 {
+		t.Fatalf("unexpected payloads: %+v", got)
+	}
+	if versions.Load() != 2 {
+		t.Fatalf("events seen with latest version header = %d", versions.Load())
+	}
+}
+
+func TestPublish_VersionFromPayloadType(t *testing.T) {
+	m := memory.NewMemory(memory.NewConfigBuilder().MaxRetries(1).Build().ToMQConfig())
+	srv, err := server.NewServerWithMQ(memory.NewConfigBuilder().MaxRetries(1).Build().ToMQConfig(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	bus := mqevent.NewMQEventBus(srv, mqevent.WithSchemaRegistry(newRegistry(t)))
+
+	var got []userCreatedV2
+	_, err = mqevent.Subscribe(bus, "user.created", "welcome", func(ctx context.Context, event mqevent.Event, data userCreatedV2) error {
+		got = append(got, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// v2 已登记后仍发布 v1 结构的生产者，不携带版本头时按类型标记为 v1 并升级
+	ctx := context.Background()
+	if err = mqevent.Publish(ctx, bus, "user.created", &userCreatedV1{ID: 7, Name: "Alan Turing"}); err != nil {
+		t.Fatal(err)
+	}
+	// 未登记的负载类型拒绝发布
+	if err = mqevent.Publish(ctx, bus, "user.created", map[string]interface{}{"id": 8}); err == nil {
+		t.Fatal("expected error for unregistered payload type")
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err = m.WaitIdle(waitCtx); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != 7 || got[0].FirstName != "Alan" || got[0].LastName != "Turing" {
+		t.Fatalf("unexpected payloads: %+v", got)
+	}
+}
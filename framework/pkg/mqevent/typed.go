@@ -0,0 +1,49 @@
+package mqevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedEventHandlerFunc 强类型事件处理函数，data 为解析后的最新版本负载
+type TypedEventHandlerFunc[T any] func(ctx context.Context, event Event, data T) error
+
+// Publish 发布强类型事件，事件类型已注册时由事件总线携带最新版本号
+func Publish[T any](ctx context.Context, bus IMQEventBus, topic string, data T, opts ...BaseEventOption) error {
+	return bus.Publish(ctx, NewBaseEvent(topic, data, opts...))
+}
+
+// Subscribe 订阅强类型事件，负载由事件总线升级到最新版本后解析为 T
+func Subscribe[T any](bus IMQEventBus, topic string, channel string, handler TypedEventHandlerFunc[T]) (string, error) {
+	return bus.Subscribe(topic, channel, EventHandlerFunc(func(ctx context.Context, event Event) error {
+		data, err := DecodeData[T](event)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, event, data)
+	}))
+}
+
+// DecodeData 将事件负载解析为 T，优先使用原始负载避免大整数精度丢失
+func DecodeData[T any](event Event) (T, error) {
+	var result T
+	if v, ok := event.GetData().(T); ok {
+		return v, nil
+	}
+	raw, ok := event.(interface{ RawData() []byte })
+	var data []byte
+	if ok && raw.RawData() != nil {
+		data = raw.RawData()
+	} else {
+		b, err := json.Marshal(event.GetData())
+		if err != nil {
+			return result, fmt.Errorf("序列化事件数据失败: %v", err)
+		}
+		data = b
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("事件 %s 负载解析为 %T 失败: %v", event.GetType(), result, err)
+	}
+	return result, nil
+}
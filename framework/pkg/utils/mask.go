@@ -0,0 +1,54 @@
+package utils
+
+import "strings"
+
+// MaskPhone 手机号脱敏，保留前3位和后4位：138****8888
+func MaskPhone(phone string) string {
+	r := []rune(phone)
+	if len(r) <= 7 {
+		return maskMiddle(r, 1, 1)
+	}
+	return maskMiddle(r, 3, 4)
+}
+
+// MaskEmail 邮箱脱敏，保留用户名首尾字符和域名：z***n@example.com
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return MaskName(email)
+	}
+	name := []rune(email[:at])
+	if len(name) <= 2 {
+		return string(name[:1]) + "***" + email[at:]
+	}
+	return string(name[:1]) + "***" + string(name[len(name)-1:]) + email[at:]
+}
+
+// MaskName 姓名脱敏，保留首字符：张**
+func MaskName(name string) string {
+	r := []rune(name)
+	if len(r) <= 1 {
+		return name
+	}
+	return string(r[:1]) + strings.Repeat("*", len(r)-1)
+}
+
+// Mask 按类型脱敏，支持 phone、email、name
+func Mask(kind, value string) string {
+	switch kind {
+	case "phone":
+		return MaskPhone(value)
+	case "email":
+		return MaskEmail(value)
+	case "name":
+		return MaskName(value)
+	}
+	return value
+}
+
+func maskMiddle(r []rune, head, tail int) string {
+	if len(r) <= head+tail {
+		return string(r)
+	}
+	return string(r[:head]) + strings.Repeat("*", len(r)-head-tail) + string(r[len(r)-tail:])
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestMask(t *testing.T) {
+	cases := []struct {
+		kind, in, want string
+	}{
+		{"phone", "13800138000", "138****8000"},
+		{"phone", "12345", "1***5"},
+		{"phone", "", ""},
+		{"email", "zhangsan@example.com", "z***n@example.com"},
+		{"email", "ab@example.com", "a***@example.com"},
+		{"name", "张三丰", "张**"},
+		{"name", "张", "张"},
+		{"other", "raw", "raw"},
+	}
+	for _, c := range cases {
+		if got := Mask(c.kind, c.in); got != c.want {
+			t.Errorf("Mask(%s, %q) = %q, want %q", c.kind, c.in, got, c.want)
+		}
+	}
+}
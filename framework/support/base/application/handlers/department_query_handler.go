@@ -10,6 +10,7 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
 
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/encryption"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
@@ -135,7 +136,7 @@ func (h *DepartmentQueryHandler) HandleGetUsers(ctx context.Context, req *querie
 		qb.Where("username", db_query.Like, "%"+req.Username+"%")
 	}
 	if req.Name != "" {
-		qb.Where("name", db_query.Like, "%"+req.Name+"%")
+		qb.WhereRaw(encryption.SearchCondition("name", req.Name, true))
 	}
 	qb.WithPage(&req.Page)
 	qb.OrderBy("created_at", true)
@@ -167,7 +168,7 @@ func (h *DepartmentQueryHandler) HandleGetUnassignedUsers(ctx context.Context, r
 		qb.Where("username", db_query.Like, "%"+req.Username+"%")
 	}
 	if req.Name != "" {
-		qb.Where("name", db_query.Like, "%"+req.Name+"%")
+		qb.WhereRaw(encryption.SearchCondition("name", req.Name, true))
 	}
 	qb.Where("status", db_query.Eq, 1)
 	qb.WithPage(&req.Page)
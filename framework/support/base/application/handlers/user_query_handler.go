@@ -6,6 +6,7 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/support/base/infrastructure/dto"
 
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/encryption"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
 	"github.com/flare-admin/flare-server-go/framework/support/base/application/queries"
@@ -38,14 +39,15 @@ func (h *UserQueryHandler) HandleList(ctx context.Context, q *queries.ListUsersQ
 	if q.Username != "" {
 		qb.Where("username", db_query.Like, "%"+q.Username+"%")
 	}
+	// 姓名、手机号、邮箱开启盲索引后只支持精确匹配，未开启时模糊匹配
 	if q.Name != "" {
-		qb.Where("name", db_query.Like, "%"+q.Name+"%")
+		qb.WhereRaw(encryption.SearchCondition("name", q.Name, true))
 	}
 	if q.Phone != "" {
-		qb.Where("phone", db_query.Like, "%"+q.Phone+"%")
+		qb.WhereRaw(encryption.SearchCondition("phone", q.Phone, true))
 	}
 	if q.Email != "" {
-		qb.Where("email", db_query.Like, "%"+q.Email+"%")
+		qb.WhereRaw(encryption.SearchCondition("email", q.Email, true))
 	}
 	if q.Status != 0 {
 		qb.Where("status", db_query.Eq, q.Status)
@@ -16,6 +16,7 @@ type IUserRepository interface {
 	// 用于业务规则验证
 	FindByID(ctx context.Context, id string) (*model.User, error)
 	FindByUsername(ctx context.Context, username string) (*model.User, error)
+	FindByPhone(ctx context.Context, phone string) (*model.User, error)
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 
 	// 角色分配
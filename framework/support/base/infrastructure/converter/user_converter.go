@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 	"github.com/flare-admin/flare-server-go/framework/support/base/infrastructure/dto"
 	"github.com/flare-admin/flare-server-go/framework/support/base/infrastructure/persistence/entity"
 )
@@ -35,13 +36,24 @@ func (c *UserConverter) ToDTO(user *entity.SysUser, roleIds []int64) *dto.UserDt
 	}
 }
 
-// ToDTOList 将领域模型列表转换为DTO列表
+// ToDTOList 将领域模型列表转换为DTO列表，列表中的姓名、手机号、邮箱脱敏展示
 func (c *UserConverter) ToDTOList(users []*entity.SysUser) []*dto.UserDto {
 	dos := make([]*dto.UserDto, 0, len(users))
 	for _, user := range users {
 		if userDto := c.ToDTO(user, nil); userDto != nil {
-			dos = append(dos, userDto)
+			dos = append(dos, c.Mask(userDto))
 		}
 	}
 	return dos
 }
+
+// Mask 敏感字段脱敏
+func (c *UserConverter) Mask(user *dto.UserDto) *dto.UserDto {
+	if user == nil {
+		return nil
+	}
+	user.Name = utils.MaskName(user.Name)
+	user.Phone = utils.MaskPhone(user.Phone)
+	user.Email = utils.MaskEmail(user.Email)
+	return user
+}
@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"time"
+
 	pkgEvent "github.com/flare-admin/flare-server-go/framework/pkg/events"
 	"github.com/flare-admin/flare-server-go/framework/support/base/domain/events"
 )
 
+// cacheEventOptions 清除缓存的订阅在事务提交后投递，避免提交前的并发读取把旧数据重新写入缓存；失败时重试
+var cacheEventOptions = []pkgEvent.SubscribeOption{
+	pkgEvent.WithAfterCommit(),
+	pkgEvent.WithRetry(2, 100*time.Millisecond),
+}
+
 type HandlerEvent struct {
 	uh       *UserEventHandler
 	rh       *RoleEventHandler
@@ -37,17 +45,17 @@ func NewHandlerEvent(
 
 func (h *HandlerEvent) Register() {
 	// 注册用户相关事件
-	h.eventBus.Subscribe(events.UserLoggedIn, h.uh)
-	h.eventBus.Subscribe(events.UserCreated, h.uh)
-	h.eventBus.Subscribe(events.UserUpdated, h.uh)
-	h.eventBus.Subscribe(events.UserDeleted, h.uh)
-	h.eventBus.Subscribe(events.UserRoleChanged, h.uh)
+	h.eventBus.SubscribeWith(events.UserLoggedIn, h.uh, cacheEventOptions...)
+	h.eventBus.SubscribeWith(events.UserCreated, h.uh, cacheEventOptions...)
+	h.eventBus.SubscribeWith(events.UserUpdated, h.uh, cacheEventOptions...)
+	h.eventBus.SubscribeWith(events.UserDeleted, h.uh, cacheEventOptions...)
+	h.eventBus.SubscribeWith(events.UserRoleChanged, h.uh, cacheEventOptions...)
 
 	// 角色事件
-	h.eventBus.Subscribe(events.RoleCreated, h.rh)
-	h.eventBus.Subscribe(events.RoleUpdated, h.rh)
-	h.eventBus.Subscribe(events.RoleDeleted, h.rh)
-	h.eventBus.Subscribe(events.RolePermissionsChanged, h.rh)
+	h.eventBus.SubscribeWith(events.RoleCreated, h.rh, cacheEventOptions...)
+	h.eventBus.SubscribeWith(events.RoleUpdated, h.rh, cacheEventOptions...)
+	h.eventBus.SubscribeWith(events.RoleDeleted, h.rh, cacheEventOptions...)
+	h.eventBus.SubscribeWith(events.RolePermissionsChanged, h.rh, cacheEventOptions...)
 
 	// 部门事件
 	h.eventBus.Subscribe(events.DepartmentCreated, h.dh)
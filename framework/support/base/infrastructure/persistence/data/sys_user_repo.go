@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/encryption"
 
 	"github.com/flare-admin/flare-server-go/framework/support/base/infrastructure/persistence/entity"
 	"github.com/flare-admin/flare-server-go/framework/support/base/infrastructure/persistence/repository"
@@ -44,6 +45,18 @@ func (r *sysUserRepo) GetByUsername(ctx context.Context, username string) (*enti
 	}
 	return result, nil
 }
+
+// GetByPhone 根据手机号查询，开启盲索引时通过盲索引匹配，盲索引未回填的历史数据按明文匹配
+func (r *sysUserRepo) GetByPhone(ctx context.Context, phone string) (*entity.SysUser, error) {
+	var result *entity.SysUser
+	query, args := encryption.SearchCondition("phone", phone, false)
+	err := r.Db(ctx).Where(query, args...).First(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (r *sysUserRepo) DeleteRoleByUserId(ctx context.Context, userId string) error {
 	return r.Db(ctx).Where("user_id = ?", userId).Delete(&entity.SysUserRole{}).Error
 }
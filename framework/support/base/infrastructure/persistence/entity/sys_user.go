@@ -9,11 +9,14 @@ type SysUser struct {
 	TenantID       string `json:"tenant_id" gorm:"size:32;index;comment:租户ID"`
 	Username       string `json:"username" gorm:"size:32;uniqueIndex;comment:用户名"`
 	Avatar         string `json:"avatar" gorm:"size:255;comment:头像"`
-	Name           string `json:"name" gorm:"size:128;comment:姓名"`
+	Name           string `json:"name" gorm:"size:512;serializer:encrypted;comment:姓名"`
 	Nickname       string `json:"nickname" gorm:"size:128;comment:昵称"`
 	Password       string `json:"password" gorm:"size:128;comment:密码"`
-	Phone          string `json:"phone" gorm:"size:32;comment:手机号"`
-	Email          string `json:"email" gorm:"size:128;comment:邮箱"`
+	Phone          string `json:"phone" gorm:"size:255;serializer:encrypted;comment:手机号"`
+	Email          string `json:"email" gorm:"size:512;serializer:encrypted;comment:邮箱"`
+	NameIndex      string `json:"-" gorm:"column:name_bidx;size:64;index;comment:姓名盲索引" blindindex:"name"`
+	PhoneIndex     string `json:"-" gorm:"column:phone_bidx;size:64;index;comment:手机号盲索引" blindindex:"phone"`
+	EmailIndex     string `json:"-" gorm:"column:email_bidx;size:64;index;comment:邮箱盲索引" blindindex:"email"`
 	Remark         string `json:"remark" gorm:"size:512;comment:备注"`
 	InvitationCode string `json:"invitation_code" gorm:"size:32;comment:邀请码"`
 	Status         int8   `json:"status" gorm:"column:status;default:1;comment:状态,1启用,2禁用"`
@@ -18,6 +18,7 @@ import (
 type ISysUserRepo interface {
 	baserepo.IBaseRepo[entity.SysUser, string]
 	GetByUsername(ctx context.Context, username string) (*entity.SysUser, error)
+	GetByPhone(ctx context.Context, phone string) (*entity.SysUser, error)
 	DeleteRoleByUserId(ctx context.Context, userId string) error
 	BelongsToDepartment(ctx context.Context, userID string, deptID string) (bool, error)
 	GetUserPermissionCodes(ctx context.Context, userID string) ([]string, error)
@@ -158,7 +159,23 @@ func (r *userRepository) FindByUsername(ctx context.Context, username string) (*
 		}
 		return nil, err
 	}
+	return r.toDomainWithRoles(ctx, userEntity)
+}
+
+// FindByPhone 根据手机号查询用户，手机号加密存储，通过盲索引匹配
+func (r *userRepository) FindByPhone(ctx context.Context, phone string) (*model.User, error) {
+	userEntity, err := r.repo.GetByPhone(ctx, phone)
+	if err != nil {
+		if database.IfErrorNotFound(err) {
+			return nil, database.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return r.toDomainWithRoles(ctx, userEntity)
+}
 
+// toDomainWithRoles 加载用户角色并转换为领域模型
+func (r *userRepository) toDomainWithRoles(ctx context.Context, userEntity *entity.SysUser) (*model.User, error) {
 	// 查询用户角色关联
 	userRoles, err := r.roleRepo.GetByUserId(ctx, userEntity.ID)
 	if err != nil {
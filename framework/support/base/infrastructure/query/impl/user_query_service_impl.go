@@ -121,7 +121,7 @@ func (u *UserQueryService) FindUsers(ctx context.Context, qb *db_query.QueryBuil
 			return nil, err
 		}
 		if userDto := u.userConverter.ToDTO(user, roleIds); userDto != nil {
-			userDtos = append(userDtos, userDto)
+			userDtos = append(userDtos, u.userConverter.Mask(userDto))
 		}
 	}
 
@@ -293,7 +293,7 @@ func (u *UserQueryService) FindUsersByDepartment(ctx context.Context, deptID str
 			return nil, err
 		}
 		if userDto := u.userConverter.ToDTO(user, roleIds); userDto != nil {
-			userDtos = append(userDtos, userDto)
+			userDtos = append(userDtos, u.userConverter.Mask(userDto))
 		}
 	}
 
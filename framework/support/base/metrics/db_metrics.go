@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var (
+	// DBQueryLatencyHistogram 数据库操作延迟直方图
+	DBQueryLatencyHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_latency_seconds",
+			Help:    "Database operation latency in seconds.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"table", "operation"},
+	)
+
+	// DBQueryErrorCounter 数据库错误计数器，不包含记录不存在
+	DBQueryErrorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_query_error_total",
+			Help: "Database operation error counter.",
+		},
+		[]string{"table", "operation"},
+	)
+
+	// DBSlowQueryCounter 慢查询计数器
+	DBSlowQueryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_slow_query_total",
+			Help: "Database slow query counter.",
+		},
+		[]string{"table", "operation"},
+	)
+)
+
+func init() {
+	// 注册监控指标
+	prometheus.MustRegister(DBQueryLatencyHistogram)
+	prometheus.MustRegister(DBQueryErrorCounter)
+	prometheus.MustRegister(DBSlowQueryCounter)
+}
+
+// 进程内累计值，供监控模块计算 QPS 和平均响应时间
+var (
+	dbQueries      atomic.Int64
+	dbErrors       atomic.Int64
+	dbSlowQueries  atomic.Int64
+	dbLatencyNanos atomic.Int64
+
+	dbStatsMu sync.RWMutex
+	dbStatsDB *sql.DB
+)
+
+// DBSnapshot 数据库指标快照，除连接池外均为进程启动以来的累计值
+type DBSnapshot struct {
+	Queries      int64         // 执行次数
+	Errors       int64         // 错误次数
+	SlowQueries  int64         // 慢查询次数
+	TotalLatency time.Duration // 累计耗时
+	Stats        sql.DBStats   // 连接池状态
+	At           time.Time     // 采集时间
+}
+
+// ObserveDBQuery 记录一次数据库操作
+func ObserveDBQuery(table, operation string, elapsed time.Duration, failed bool, slow bool) {
+	DBQueryLatencyHistogram.WithLabelValues(table, operation).Observe(elapsed.Seconds())
+	dbQueries.Add(1)
+	dbLatencyNanos.Add(int64(elapsed))
+	if failed {
+		DBQueryErrorCounter.WithLabelValues(table, operation).Inc()
+		dbErrors.Add(1)
+	}
+	if slow {
+		DBSlowQueryCounter.WithLabelValues(table, operation).Inc()
+		dbSlowQueries.Add(1)
+	}
+}
+
+// RegisterDBStats 注册连接池指标，重复注册时忽略
+func RegisterDBStats(name string, db *sql.DB) error {
+	dbStatsMu.Lock()
+	dbStatsDB = db
+	dbStatsMu.Unlock()
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, name))
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		return nil
+	}
+	return err
+}
+
+// GetDBSnapshot 获取数据库指标快照
+func GetDBSnapshot() DBSnapshot {
+	s := DBSnapshot{
+		Queries:      dbQueries.Load(),
+		Errors:       dbErrors.Load(),
+		SlowQueries:  dbSlowQueries.Load(),
+		TotalLatency: time.Duration(dbLatencyNanos.Load()),
+		At:           time.Now(),
+	}
+	dbStatsMu.RLock()
+	if dbStatsDB != nil {
+		s.Stats = dbStatsDB.Stats()
+	}
+	dbStatsMu.RUnlock()
+	return s
+}
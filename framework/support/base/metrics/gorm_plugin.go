@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+const (
+	gormStartKey = "metrics:start_time"
+	// DefaultSlowThreshold 默认慢查询阈值
+	DefaultSlowThreshold = 200 * time.Millisecond
+)
+
+var (
+	placeholderRe = regexp.MustCompile(`\$\d+`)
+	spaceRe       = regexp.MustCompile(`\s+`)
+	tupleRe       = regexp.MustCompile(`\(\?(?:\s*,\s*\?)*\)`)
+	tuplesRe      = regexp.MustCompile(`\(\?\)(?:\s*,\s*\(\?\))+`)
+)
+
+// GormPlugin GORM 指标插件
+//   - 按表和操作记录延迟直方图、错误数
+//   - 超过阈值的语句输出慢查询日志，包含归一化 SQL 和调用位置
+type GormPlugin struct {
+	slowThreshold time.Duration
+}
+
+// NewGormPlugin 创建指标插件
+// 参数：
+//
+//	slowThreshold ：慢查询阈值，为 0 时使用默认值，小于 0 时不记录慢查询
+func NewGormPlugin(slowThreshold time.Duration) *GormPlugin {
+	if slowThreshold == 0 {
+		slowThreshold = DefaultSlowThreshold
+	}
+	return &GormPlugin{slowThreshold: slowThreshold}
+}
+
+func (p *GormPlugin) Name() string {
+	return "metrics_plugin"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", p.after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", p.after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", p.after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", p.after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func (p *GormPlugin) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(v.(time.Time))
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		slow := p.slowThreshold > 0 && elapsed >= p.slowThreshold
+		ObserveDBQuery(table, operation, elapsed, failed, slow)
+		if slow {
+			hlog.CtxWarnf(db.Statement.Context, "slow sql: elapsed=%s threshold=%s table=%s operation=%s rows=%d caller=%s sql=%s",
+				elapsed, p.slowThreshold, table, operation, db.RowsAffected, caller(), NormalizeSQL(db.Statement.SQL.String()))
+		}
+	}
+}
+
+// NormalizeSQL 归一化 SQL，合并空白、统一占位符并折叠 IN 列表和批量 VALUES，便于按语句聚合
+func NormalizeSQL(sql string) string {
+	sql = placeholderRe.ReplaceAllString(sql, "?")
+	sql = spaceRe.ReplaceAllString(strings.TrimSpace(sql), " ")
+	sql = tupleRe.ReplaceAllString(sql, "(?)")
+	return tuplesRe.ReplaceAllString(sql, "(?)")
+}
+
+// 跳过 gorm、仓储基类和本插件，返回业务代码的调用位置
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "gorm.io/") &&
+			!strings.Contains(frame.Function, "framework/pkg/database") &&
+			!strings.Contains(frame.Function, "framework/support/base/metrics") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type metricsModel struct {
+	ID   int64  `gorm:"column:id;primaryKey"`
+	Name string `gorm:"column:name"`
+}
+
+func (metricsModel) TableName() string {
+	return "metrics_model"
+}
+
+func newMetricsTestDB(t *testing.T, threshold time.Duration) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err = db.Use(NewGormPlugin(threshold)); err != nil {
+		t.Fatalf("use plugin: %v", err)
+	}
+	return db
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM t WHERE id IN (?,?,?) AND name = ?":   "SELECT * FROM t WHERE id IN (?) AND name = ?",
+		"INSERT INTO t (a,b) VALUES (?,?),(?,?),(?,?)":       "INSERT INTO t (a,b) VALUES (?)",
+		"SELECT *\n\t FROM t  WHERE a = $1 AND b IN ($2,$3)": "SELECT * FROM t WHERE a = ? AND b IN (?)",
+	}
+	for in, want := range cases {
+		if got := NormalizeSQL(in); got != want {
+			t.Errorf("NormalizeSQL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGormPlugin_Observe(t *testing.T) {
+	before := GetDBSnapshot()
+
+	db := newMetricsTestDB(t, time.Nanosecond)
+	var res []metricsModel
+	db.Where("id IN ?", []int64{1, 2}).Find(&res)
+	db.Create(&metricsModel{ID: 1})
+	db.Model(&metricsModel{}).Where("id = ?", 1).Update("name", "a")
+
+	after := GetDBSnapshot()
+	if after.Queries-before.Queries != 3 {
+		t.Fatalf("queries = %d", after.Queries-before.Queries)
+	}
+	if after.SlowQueries-before.SlowQueries != 3 {
+		t.Fatalf("slow queries = %d", after.SlowQueries-before.SlowQueries)
+	}
+	for _, op := range []string{"query", "create", "update"} {
+		if n := testutil.ToFloat64(DBSlowQueryCounter.WithLabelValues("metrics_model", op)); n < 1 {
+			t.Fatalf("slow counter for %s = %v", op, n)
+		}
+	}
+
+	// 关闭慢查询记录
+	db = newMetricsTestDB(t, -1)
+	db.Find(&res)
+	if n := GetDBSnapshot().SlowQueries; n != after.SlowQueries {
+		t.Fatalf("slow query recorded when disabled: %d", n-after.SlowQueries)
+	}
+}
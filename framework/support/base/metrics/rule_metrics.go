@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// RuleExecuteCounter 规则执行计数器，result 为 success 或 failure
+	RuleExecuteCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rule_execute_total",
+			Help: "Rule execution counter.",
+		},
+		[]string{"rule", "result"},
+	)
+
+	// RuleExecuteLatencyHistogram 规则执行耗时直方图
+	RuleExecuteLatencyHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "rule_execute_latency_seconds",
+			Help:    "Rule execution latency in seconds.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"rule"},
+	)
+
+	// RuleExecutionLogDroppedCounter 写入队列已满而丢弃的执行日志计数器
+	RuleExecutionLogDroppedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rule_execution_log_dropped_total",
+			Help: "Rule execution logs dropped because the write queue was full.",
+		},
+	)
+)
+
+func init() {
+	// 注册监控指标
+	prometheus.MustRegister(RuleExecuteCounter)
+	prometheus.MustRegister(RuleExecuteLatencyHistogram)
+	prometheus.MustRegister(RuleExecutionLogDroppedCounter)
+}
+
+// ObserveRuleExecution 记录一次规则执行
+func ObserveRuleExecution(rule string, success bool, elapsed time.Duration) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	RuleExecuteCounter.WithLabelValues(rule, result).Inc()
+	RuleExecuteLatencyHistogram.WithLabelValues(rule).Observe(elapsed.Seconds())
+}
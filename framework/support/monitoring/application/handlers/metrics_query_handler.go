@@ -55,3 +55,16 @@ func (h *MetricsQueryHandler) HandleGetRuntimeMetrics(ctx context.Context) (*dto
 		GCCPUFraction: metrics.GCCPUFraction,
 	}, nil
 }
+
+// HandleGetDatabaseMetrics 处理获取数据库指标
+func (h *MetricsQueryHandler) HandleGetDatabaseMetrics(ctx context.Context, q *queries.GetDatabaseMetricsQuery) (*dto.DatabaseMetricsDto, herrors.Herr) {
+	metrics := h.service.GetDatabaseMetrics(ctx)
+
+	return &dto.DatabaseMetricsDto{
+		Connections:  metrics.Connections,
+		SlowQueries:  metrics.SlowQueries,
+		QPS:          metrics.QPS,
+		ResponseTime: metrics.ResponseTime,
+		CreatedAt:    metrics.CreatedAt,
+	}, nil
+}
@@ -19,7 +19,7 @@ type DatabaseMetrics struct {
 	Connections  int64     // 连接数
 	SlowQueries  int64     // 慢查询数
 	QPS          float64   // 每秒查询数
-	ResponseTime float64   // 响应时间
+	ResponseTime float64   // 平均响应时间(毫秒)
 	CreatedAt    time.Time // 创建时间
 }
 
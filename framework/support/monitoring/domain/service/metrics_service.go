@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/flare-admin/flare-server-go/framework/support/base/metrics"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/flare-admin/flare-server-go/framework/support/monitoring/domain/model"
@@ -12,10 +14,17 @@ import (
 	"github.com/shirou/gopsutil/v3/mem"
 )
 
-type MetricsService struct{}
+// 数据库 QPS 的最小统计窗口，窗口内的重复请求返回上一次的结果
+const dbSampleWindow = time.Second
+
+type MetricsService struct {
+	mu       sync.Mutex
+	lastDB   metrics.DBSnapshot
+	dbResult *model.DatabaseMetrics
+}
 
 func NewMetricsService() *MetricsService {
-	return &MetricsService{}
+	return &MetricsService{lastDB: metrics.GetDBSnapshot()}
 }
 
 // GetSystemMetrics 获取系统指标
@@ -68,3 +77,31 @@ func (s *MetricsService) GetRuntimeMetrics() *model.RuntimeMetrics {
 		GCCPUFraction: m.GCCPUFraction,
 	}
 }
+
+// GetDatabaseMetrics 获取数据库指标
+// QPS 和平均响应时间按两次采集之间的增量计算，慢查询数为进程启动以来的累计值
+func (s *MetricsService) GetDatabaseMetrics(ctx context.Context) *model.DatabaseMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := metrics.GetDBSnapshot()
+	elapsed := cur.At.Sub(s.lastDB.At)
+	if s.dbResult != nil && elapsed < dbSampleWindow {
+		res := *s.dbResult
+		res.Connections = int64(cur.Stats.OpenConnections)
+		res.SlowQueries = cur.SlowQueries
+		return &res
+	}
+	res := &model.DatabaseMetrics{
+		Connections: int64(cur.Stats.OpenConnections),
+		SlowQueries: cur.SlowQueries,
+		CreatedAt:   utils.GetTimeNow(),
+	}
+	if queries := cur.Queries - s.lastDB.Queries; queries > 0 && elapsed > 0 {
+		res.QPS = float64(queries) / elapsed.Seconds()
+		// 平均响应时间(毫秒)
+		res.ResponseTime = float64(cur.TotalLatency-s.lastDB.TotalLatency) / float64(queries) / float64(time.Millisecond)
+	}
+	s.lastDB = cur
+	s.dbResult = res
+	return res
+}
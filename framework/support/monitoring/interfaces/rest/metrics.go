@@ -27,6 +27,7 @@ func (c *MetricsController) RegisterRouter(g *route.RouterGroup, t token.IToken)
 	{
 		metrics.GET("/system", hserver.NewHandlerFu[queries.GetSystemMetricsQuery](c.GetSystemMetrics))
 		metrics.GET("/runtime", hserver.NewNotParHandlerFu(c.GetRuntimeMetrics))
+		metrics.GET("/database", hserver.NewHandlerFu[queries.GetDatabaseMetricsQuery](c.GetDatabaseMetrics))
 	}
 }
 
@@ -63,3 +64,20 @@ func (c *MetricsController) GetRuntimeMetrics(ctx context.Context) *hserver.Resp
 	}
 	return result.WithData(data)
 }
+
+// GetDatabaseMetrics 获取数据库指标
+// @Summary 获取数据库指标
+// @Description 获取数据库连接数、慢查询数、QPS和平均响应时间
+// @Tags 监控指标
+// @Accept json
+// @Produce json
+// @Success 200 {object} base_info.Success{data=dto.DatabaseMetricsDto}
+// @Router /v1/metrics/database [get]
+func (c *MetricsController) GetDatabaseMetrics(ctx context.Context, q *queries.GetDatabaseMetricsQuery) *hserver.ResponseResult {
+	result := hserver.DefaultResponseResult()
+	data, err := c.queryHandler.HandleGetDatabaseMetrics(ctx, q)
+	if herrors.HaveError(err) {
+		return result.WithError(err)
+	}
+	return result.WithData(data)
+}
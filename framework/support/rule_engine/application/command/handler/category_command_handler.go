@@ -41,6 +41,8 @@ func (h *CategoryCommandHandler) HandleCreateCategory(ctx context.Context, cmd *
 
 	// 设置排序
 	category.SetSorting(cmd.Sorting)
+	category.DBPolicy = cmd.DBPolicy
+	category.ExecutionStrategy = cmd.ExecutionStrategy
 
 	// 调用领域服务创建分类
 	return h.categoryService.CreateCategory(ctx, category)
@@ -55,15 +57,17 @@ func (h *CategoryCommandHandler) HandleUpdateCategory(ctx context.Context, cmd *
 
 	// 创建分类领域模型
 	category := &model.RuleCategory{
-		ID:           cmd.ID,
-		Code:         cmd.Code,
-		Name:         cmd.Name,
-		Description:  cmd.Description,
-		ParentID:     cmd.ParentID,
-		Type:         cmd.Type,
-		BusinessType: cmd.BusinessType,
-		Sorting:      cmd.Sorting,
-		UpdatedAt:    utils.GetDateUnix(),
+		ID:                cmd.ID,
+		Code:              cmd.Code,
+		Name:              cmd.Name,
+		Description:       cmd.Description,
+		ParentID:          cmd.ParentID,
+		Type:              cmd.Type,
+		BusinessType:      cmd.BusinessType,
+		Sorting:           cmd.Sorting,
+		DBPolicy:          cmd.DBPolicy,
+		ExecutionStrategy: cmd.ExecutionStrategy,
+		UpdatedAt:         utils.GetDateUnix(),
 	}
 
 	// 调用领域服务更新分类
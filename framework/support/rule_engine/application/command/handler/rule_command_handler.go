@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 
@@ -59,6 +60,9 @@ func (h *RuleCommandHandler) HandleCreateRule(ctx context.Context, cmd *command.
 			"expression": cmd.Condition.Expression,
 			"parameters": cmd.Condition.Parameters,
 		}
+		if cmd.Condition.Root != nil {
+			conditions["root"] = cmd.Condition.Root
+		}
 		rule.SetConditions(conditions)
 	}
 
@@ -66,6 +70,11 @@ func (h *RuleCommandHandler) HandleCreateRule(ctx context.Context, cmd *command.
 	if cmd.LuaScript != "" {
 		rule.SetLuaScript(cmd.LuaScript)
 	}
+	// 设置数据库访问策略
+	if err2 = rule.SetDBPolicy(cmd.DBPolicy); err2 != nil {
+		hlog.CtxErrorf(ctx, "Failed to set db policy: %v", err2)
+		return herrors.NewBadReqHError(err2)
+	}
 	// 设置执行时机
 	err2 = rule.SetExecutionTiming(cmd.ExecutionTiming)
 	if err2 != nil {
@@ -75,7 +84,17 @@ func (h *RuleCommandHandler) HandleCreateRule(ctx context.Context, cmd *command.
 
 	// 设置计算公式
 	if cmd.Formula != "" {
-		rule.SetFormula(cmd.Formula, make(map[string]interface{}))
+		vars, err2 := parseFormulaVars(cmd.FormulaVars)
+		if err2 != nil {
+			hlog.CtxErrorf(ctx, "Failed to parse formula vars: %v", err2)
+			return herrors.NewBadReqHError(err2)
+		}
+		rule.SetFormula(cmd.Formula, vars)
+	}
+
+	// 设置决策表
+	if cmd.DecisionTable != "" {
+		rule.SetDecisionTable(cmd.DecisionTable)
 	}
 
 	// 设置动作
@@ -92,14 +111,15 @@ func (h *RuleCommandHandler) HandleCreateRule(ctx context.Context, cmd *command.
 }
 
 // HandleUpdateRule 处理更新规则命令
+// 修改保存为草稿，发布后生效
 func (h *RuleCommandHandler) HandleUpdateRule(ctx context.Context, cmd *command.UpdateRuleCommand) *herrors.HError {
 	// 验证命令参数
 	if err := h.validateUpdateRuleCommand(cmd); err != nil {
 		return err
 	}
 
-	// 先获取现有规则
-	existingRule, err := h.ruleService.GetRule(ctx, cmd.ID)
+	// 在现有草稿的基础上修改，没有草稿时基于线上内容
+	existingRule, err := h.ruleService.GetRuleDraft(ctx, cmd.ID)
 	if err != nil {
 		return err
 	}
@@ -139,6 +159,9 @@ func (h *RuleCommandHandler) HandleUpdateRule(ctx context.Context, cmd *command.
 			"expression": cmd.Condition.Expression,
 			"parameters": cmd.Condition.Parameters,
 		}
+		if cmd.Condition.Root != nil {
+			conditions["root"] = cmd.Condition.Root
+		}
 		existingRule.SetConditions(conditions)
 	}
 
@@ -147,8 +170,25 @@ func (h *RuleCommandHandler) HandleUpdateRule(ctx context.Context, cmd *command.
 		existingRule.SetLuaScript(cmd.LuaScript)
 	}
 
+	// 设置数据库访问策略
+	if err2 = existingRule.SetDBPolicy(cmd.DBPolicy); err2 != nil {
+		hlog.CtxErrorf(ctx, "Failed to set db policy: %v", err2)
+		return herrors.NewBadReqHError(err2)
+	}
+
 	// 设置计算公式
 	if cmd.Formula != "" {
+		vars, err2 := parseFormulaVars(cmd.FormulaVars)
+		if err2 != nil {
+			hlog.CtxErrorf(ctx, "Failed to parse formula vars: %v", err2)
+			return herrors.NewBadReqHError(err2)
+		}
+		existingRule.SetFormula(cmd.Formula, vars)
+	}
+
+	// 设置决策表
+	if cmd.DecisionTable != "" {
+		existingRule.SetDecisionTable(cmd.DecisionTable)
 	}
 
 	// 设置动作
@@ -160,8 +200,138 @@ func (h *RuleCommandHandler) HandleUpdateRule(ctx context.Context, cmd *command.
 	existingRule.SetPriority(cmd.Priority)
 	existingRule.SetSorting(cmd.Sorting)
 
-	// 调用领域服务更新规则
-	return h.ruleService.UpdateRule(ctx, existingRule)
+	// 调用领域服务保存草稿
+	return h.ruleService.SaveRuleDraft(ctx, existingRule)
+}
+
+// HandlePublishRule 处理发布规则草稿命令
+func (h *RuleCommandHandler) HandlePublishRule(ctx context.Context, cmd *command.PublishRuleCommand) *herrors.HError {
+	if cmd.ID == "" {
+		return err.RuleValidationFailed(fmt.Errorf("规则ID不能为空"))
+	}
+	_, herr := h.ruleService.PublishRule(ctx, cmd.ID, cmd.Comment)
+	return herr
+}
+
+// HandleRollbackRule 处理回滚规则版本命令
+func (h *RuleCommandHandler) HandleRollbackRule(ctx context.Context, cmd *command.RollbackRuleCommand) *herrors.HError {
+	if cmd.ID == "" {
+		return err.RuleValidationFailed(fmt.Errorf("规则ID不能为空"))
+	}
+	if cmd.Version <= 0 {
+		return err.RuleValidationFailed(fmt.Errorf("无效的版本号"))
+	}
+	_, herr := h.ruleService.RollbackRule(ctx, cmd.ID, cmd.Version, cmd.Comment)
+	return herr
+}
+
+// HandleDiscardRuleDraft 处理丢弃规则草稿命令
+func (h *RuleCommandHandler) HandleDiscardRuleDraft(ctx context.Context, cmd *command.DiscardRuleDraftCommand) *herrors.HError {
+	if cmd.ID == "" {
+		return err.RuleValidationFailed(fmt.Errorf("规则ID不能为空"))
+	}
+	return h.ruleService.DiscardRuleDraft(ctx, cmd.ID)
+}
+
+// HandleCreateRuleTestCase 处理创建规则测试用例命令
+func (h *RuleCommandHandler) HandleCreateRuleTestCase(ctx context.Context, cmd *command.CreateRuleTestCaseCommand) *herrors.HError {
+	if cmd.RuleID == "" {
+		return err.RuleTestCaseValidationFailed(fmt.Errorf("规则ID不能为空"))
+	}
+	testCase := model.NewRuleTestCase(cmd.RuleID, cmd.Name)
+	testCase.Description = cmd.Description
+	testCase.ScopeID = cmd.ScopeID
+	if cmd.Input != nil {
+		testCase.Input = cmd.Input
+	}
+	testCase.ExpectValid = cmd.ExpectValid
+	testCase.ExpectAction = cmd.ExpectAction
+	testCase.ExpectOutputs = cmd.ExpectOutputs
+	if cmd.Enabled != nil {
+		testCase.Enabled = *cmd.Enabled
+	}
+	return h.ruleService.CreateRuleTestCase(ctx, testCase)
+}
+
+// HandleUpdateRuleTestCase 处理更新规则测试用例命令
+func (h *RuleCommandHandler) HandleUpdateRuleTestCase(ctx context.Context, cmd *command.UpdateRuleTestCaseCommand) *herrors.HError {
+	if cmd.ID == "" {
+		return err.RuleTestCaseValidationFailed(fmt.Errorf("用例ID不能为空"))
+	}
+	testCase, herr := h.ruleService.GetRuleTestCase(ctx, cmd.ID)
+	if herr != nil {
+		return herr
+	}
+	testCase.Name = cmd.Name
+	testCase.Description = cmd.Description
+	testCase.ScopeID = cmd.ScopeID
+	testCase.Input = cmd.Input
+	testCase.ExpectValid = cmd.ExpectValid
+	testCase.ExpectAction = cmd.ExpectAction
+	testCase.ExpectOutputs = cmd.ExpectOutputs
+	if cmd.Enabled != nil {
+		testCase.Enabled = *cmd.Enabled
+	}
+	return h.ruleService.UpdateRuleTestCase(ctx, testCase)
+}
+
+// HandleDeleteRuleTestCase 处理删除规则测试用例命令
+func (h *RuleCommandHandler) HandleDeleteRuleTestCase(ctx context.Context, cmd *command.DeleteRuleTestCaseCommand) *herrors.HError {
+	if cmd.ID == "" {
+		return err.RuleTestCaseValidationFailed(fmt.Errorf("用例ID不能为空"))
+	}
+	return h.ruleService.DeleteRuleTestCase(ctx, cmd.ID)
+}
+
+// HandleSimulateRules 处理模拟执行规则命令
+func (h *RuleCommandHandler) HandleSimulateRules(ctx context.Context, cmd *command.SimulateRulesCommand) (*model.RuleResult, *herrors.HError) {
+	ruleContext := model.NewRuleContext(cmd.Scope, cmd.Trigger, cmd.ExecutionTiming, cmd.ScopeID)
+	if cmd.Data != nil {
+		ruleContext.SetData(cmd.Data)
+	}
+	ruleContext.WithTenantID(cmd.TenantID)
+	return h.ruleService.SimulateRules(ctx, ruleContext)
+}
+
+// HandleSimulateRule 处理模拟执行单个规则命令
+func (h *RuleCommandHandler) HandleSimulateRule(ctx context.Context, cmd *command.SimulateRuleCommand) (*model.RuleResult, *herrors.HError) {
+	if cmd.ID == "" {
+		return nil, err.RuleValidationFailed(fmt.Errorf("规则ID不能为空"))
+	}
+	ruleContext := model.NewRuleContext("", "", "", cmd.ScopeID)
+	if cmd.Data != nil {
+		ruleContext.SetData(cmd.Data)
+	}
+	ruleContext.WithTenantID(cmd.TenantID)
+	return h.ruleService.SimulateRule(ctx, cmd.ID, cmd.UseDraft, ruleContext)
+}
+
+// HandleRunRuleTestCases 处理执行规则测试用例命令
+func (h *RuleCommandHandler) HandleRunRuleTestCases(ctx context.Context, cmd *command.RunRuleTestCasesCommand) (*model.RuleTestReport, *herrors.HError) {
+	if cmd.ID == "" {
+		return nil, err.RuleValidationFailed(fmt.Errorf("规则ID不能为空"))
+	}
+	return h.ruleService.RunRuleTestCases(ctx, cmd.ID, cmd.UseDraft)
+}
+
+// HandleImportDecisionTable 处理导入决策表命令
+func (h *RuleCommandHandler) HandleImportDecisionTable(ctx context.Context, cmd *command.ImportDecisionTableCommand) (*model.DecisionTableReport, *herrors.HError) {
+	if cmd.ID == "" {
+		return nil, err.RuleValidationFailed(fmt.Errorf("规则ID不能为空"))
+	}
+	if cmd.File == nil {
+		return nil, err.RuleValidationFailed(fmt.Errorf("导入文件不能为空"))
+	}
+	return h.ruleService.ImportDecisionTable(ctx, cmd.ID, cmd.File)
+}
+
+// HandleValidateDecisionTable 处理检查决策表命令
+func (h *RuleCommandHandler) HandleValidateDecisionTable(ctx context.Context, cmd *command.ValidateDecisionTableCommand) (*model.DecisionTableReport, *herrors.HError) {
+	table, err2 := model.ParseDecisionTableJSON(cmd.Table)
+	if err2 != nil {
+		return nil, err.RuleValidationFailed(err2)
+	}
+	return h.ruleService.AnalyzeDecisionTable(table.Table)
 }
 
 // HandleUpdateRuleStatus 处理更新规则状态命令
@@ -224,6 +394,10 @@ func (h *RuleCommandHandler) validateCreateRuleCommand(cmd *command.CreateRuleCo
 		if cmd.Formula == "" {
 			return err.RuleValidationFailed(fmt.Errorf("公式规则必须提供计算公式"))
 		}
+	case "decision_table":
+		if cmd.DecisionTable == "" {
+			return err.RuleValidationFailed(fmt.Errorf("决策表规则必须提供决策表"))
+		}
 	}
 
 	// 验证动作配置
@@ -267,6 +441,10 @@ func (h *RuleCommandHandler) validateUpdateRuleCommand(cmd *command.UpdateRuleCo
 		if cmd.Formula == "" {
 			return err.RuleValidationFailed(fmt.Errorf("公式规则必须提供计算公式"))
 		}
+	case "decision_table":
+		if cmd.DecisionTable == "" {
+			return err.RuleValidationFailed(fmt.Errorf("决策表规则必须提供决策表"))
+		}
 	}
 
 	// 验证动作配置
@@ -279,7 +457,7 @@ func (h *RuleCommandHandler) validateUpdateRuleCommand(cmd *command.UpdateRuleCo
 
 // isValidRuleType 验证规则类型是否有效
 func (h *RuleCommandHandler) isValidRuleType(ruleType string) bool {
-	validTypes := []string{"condition", "lua", "formula"}
+	validTypes := []string{"condition", "lua", "formula", "decision_table"}
 	for _, validType := range validTypes {
 		if ruleType == validType {
 			return true
@@ -287,3 +465,15 @@ func (h *RuleCommandHandler) isValidRuleType(ruleType string) bool {
 	}
 	return false
 }
+
+// parseFormulaVars 解析公式变量定义
+func parseFormulaVars(data string) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	if data == "" {
+		return vars, nil
+	}
+	if err := json.Unmarshal([]byte(data), &vars); err != nil {
+		return nil, fmt.Errorf("invalid formula vars format: %v", err)
+	}
+	return vars, nil
+}
@@ -1,5 +1,7 @@
 package command
 
+import "io"
+
 // ==================== 模板相关命令 ====================
 
 // CreateTemplateCommand 创建模板命令
@@ -50,25 +52,29 @@ type DeleteTemplateCommand struct {
 
 // CreateCategoryCommand 创建分类命令
 type CreateCategoryCommand struct {
-	Name         string `json:"name" form:"name" query:"name"`                         // 分类名称
-	Code         string `json:"code" form:"code" query:"code"`                         // 分类编码
-	Description  string `json:"description" form:"description" query:"description"`    // 分类描述
-	ParentID     string `json:"parentId" form:"parentId" query:"parentId"`             // 父分类ID
-	Type         string `json:"type" form:"type" query:"type"`                         // 分类类型
-	BusinessType string `json:"businessType" form:"businessType" query:"businessType"` // 业务类型
-	Sorting      int32  `json:"sorting" form:"sorting" query:"sorting"`                // 排序权重
+	Name              string `json:"name" form:"name" query:"name"`                                        // 分类名称
+	Code              string `json:"code" form:"code" query:"code"`                                        // 分类编码
+	Description       string `json:"description" form:"description" query:"description"`                   // 分类描述
+	ParentID          string `json:"parentId" form:"parentId" query:"parentId"`                            // 父分类ID
+	Type              string `json:"type" form:"type" query:"type"`                                        // 分类类型
+	BusinessType      string `json:"businessType" form:"businessType" query:"businessType"`                // 业务类型
+	DBPolicy          string `json:"dbPolicy" form:"dbPolicy" query:"dbPolicy"`                            // 数据库访问策略(JSON格式)
+	ExecutionStrategy string `json:"executionStrategy" form:"executionStrategy" query:"executionStrategy"` // 规则链执行策略：all first_match collect deny_overrides
+	Sorting           int32  `json:"sorting" form:"sorting" query:"sorting"`                               // 排序权重
 }
 
 // UpdateCategoryCommand 更新分类命令
 type UpdateCategoryCommand struct {
-	ID           string `json:"id" form:"id" query:"id"`                               // 分类ID
-	Name         string `json:"name" form:"name" query:"name"`                         // 分类名称
-	Code         string `json:"code" form:"code" query:"code"`                         // 分类编码
-	Description  string `json:"description" form:"description" query:"description"`    // 分类描述
-	ParentID     string `json:"parentId" form:"parentId" query:"parentId"`             // 父分类ID
-	Type         string `json:"type" form:"type" query:"type"`                         // 分类类型
-	BusinessType string `json:"businessType" form:"businessType" query:"businessType"` // 业务类型
-	Sorting      int32  `json:"sorting" form:"sorting" query:"sorting"`                // 排序权重
+	ID                string `json:"id" form:"id" query:"id"`                                              // 分类ID
+	Name              string `json:"name" form:"name" query:"name"`                                        // 分类名称
+	Code              string `json:"code" form:"code" query:"code"`                                        // 分类编码
+	Description       string `json:"description" form:"description" query:"description"`                   // 分类描述
+	ParentID          string `json:"parentId" form:"parentId" query:"parentId"`                            // 父分类ID
+	Type              string `json:"type" form:"type" query:"type"`                                        // 分类类型
+	BusinessType      string `json:"businessType" form:"businessType" query:"businessType"`                // 业务类型
+	DBPolicy          string `json:"dbPolicy" form:"dbPolicy" query:"dbPolicy"`                            // 数据库访问策略(JSON格式)
+	ExecutionStrategy string `json:"executionStrategy" form:"executionStrategy" query:"executionStrategy"` // 规则链执行策略：all first_match collect deny_overrides
+	Sorting           int32  `json:"sorting" form:"sorting" query:"sorting"`                               // 排序权重
 }
 
 // UpdateCategoryStatusCommand 更新分类状态命令
@@ -99,6 +105,9 @@ type CreateRuleCommand struct {
 	Condition       *ConditionConfig `json:"condition" form:"condition" query:"condition"`                   // 条件配置
 	LuaScript       string           `json:"luaScript" form:"luaScript" query:"luaScript"`                   // Lua脚本
 	Formula         string           `json:"formula" form:"formula" query:"formula"`                         // 计算公式
+	FormulaVars     string           `json:"formulaVars" form:"formulaVars" query:"formulaVars"`             // 公式变量定义(JSON格式)
+	DecisionTable   string           `json:"decisionTable" form:"decisionTable" query:"decisionTable"`       // 决策表(JSON格式)
+	DBPolicy        string           `json:"dbPolicy" form:"dbPolicy" query:"dbPolicy"`                      // 数据库访问策略(JSON格式)
 	Action          string           `json:"action" form:"action" query:"action"`                            // 触发动作：allow(允许) deny(拒绝) modify(修改) notify(通知) redirect(重定向)
 	Priority        int32            `json:"priority" form:"priority" query:"priority"`                      // 优先级
 	Sorting         int32            `json:"sorting" form:"sorting" query:"sorting"`                         // 排序权重
@@ -120,6 +129,9 @@ type UpdateRuleCommand struct {
 	Condition       *ConditionConfig `json:"condition" form:"condition" query:"condition"`                   // 条件配置
 	LuaScript       string           `json:"luaScript" form:"luaScript" query:"luaScript"`                   // Lua脚本
 	Formula         string           `json:"formula" form:"formula" query:"formula"`                         // 计算公式
+	FormulaVars     string           `json:"formulaVars" form:"formulaVars" query:"formulaVars"`             // 公式变量定义(JSON格式)
+	DecisionTable   string           `json:"decisionTable" form:"decisionTable" query:"decisionTable"`       // 决策表(JSON格式)
+	DBPolicy        string           `json:"dbPolicy" form:"dbPolicy" query:"dbPolicy"`                      // 数据库访问策略(JSON格式)
 	Action          string           `json:"action" form:"action" query:"action"`                            // 触发动作：allow(允许) deny(拒绝) modify(修改) notify(通知) redirect(重定向)
 	Priority        int32            `json:"priority" form:"priority" query:"priority"`                      // 优先级
 	Sorting         int32            `json:"sorting" form:"sorting" query:"sorting"`                         // 排序权重
@@ -136,6 +148,91 @@ type DeleteRuleCommand struct {
 	ID string `json:"id" form:"id" query:"id"` // 规则ID
 }
 
+// PublishRuleCommand 发布规则草稿命令
+type PublishRuleCommand struct {
+	ID      string `json:"id" form:"id" query:"id"`                // 规则ID
+	Comment string `json:"comment" form:"comment" query:"comment"` // 版本说明
+}
+
+// RollbackRuleCommand 回滚规则版本命令
+type RollbackRuleCommand struct {
+	ID      string `json:"id" form:"id" query:"id"`                // 规则ID
+	Version int32  `json:"version" form:"version" query:"version"` // 回滚到的版本号
+	Comment string `json:"comment" form:"comment" query:"comment"` // 版本说明，为空时自动生成
+}
+
+// DiscardRuleDraftCommand 丢弃规则草稿命令
+type DiscardRuleDraftCommand struct {
+	ID string `json:"id" form:"id" query:"id"` // 规则ID
+}
+
+// CreateRuleTestCaseCommand 创建规则测试用例命令
+type CreateRuleTestCaseCommand struct {
+	RuleID        string                 `json:"ruleId" form:"ruleId" query:"ruleId"`                      // 规则ID
+	Name          string                 `json:"name" form:"name" query:"name"`                            // 用例名称
+	Description   string                 `json:"description" form:"description" query:"description"`       // 用例描述
+	ScopeID       string                 `json:"scopeId" form:"scopeId" query:"scopeId"`                   // 执行时的作用域ID
+	Input         map[string]interface{} `json:"input" form:"input" query:"input"`                         // 执行输入数据
+	ExpectValid   *bool                  `json:"expectValid" form:"expectValid" query:"expectValid"`       // 期望是否通过，为空时不校验
+	ExpectAction  string                 `json:"expectAction" form:"expectAction" query:"expectAction"`    // 期望动作，为空时不校验
+	ExpectOutputs map[string]interface{} `json:"expectOutputs" form:"expectOutputs" query:"expectOutputs"` // 期望输出变量，只校验列出的变量
+	Enabled       *bool                  `json:"enabled" form:"enabled" query:"enabled"`                   // 是否启用，为空时启用
+}
+
+// UpdateRuleTestCaseCommand 更新规则测试用例命令
+type UpdateRuleTestCaseCommand struct {
+	ID            string                 `json:"id" form:"id" query:"id"`                                  // 用例ID
+	Name          string                 `json:"name" form:"name" query:"name"`                            // 用例名称
+	Description   string                 `json:"description" form:"description" query:"description"`       // 用例描述
+	ScopeID       string                 `json:"scopeId" form:"scopeId" query:"scopeId"`                   // 执行时的作用域ID
+	Input         map[string]interface{} `json:"input" form:"input" query:"input"`                         // 执行输入数据
+	ExpectValid   *bool                  `json:"expectValid" form:"expectValid" query:"expectValid"`       // 期望是否通过，为空时不校验
+	ExpectAction  string                 `json:"expectAction" form:"expectAction" query:"expectAction"`    // 期望动作，为空时不校验
+	ExpectOutputs map[string]interface{} `json:"expectOutputs" form:"expectOutputs" query:"expectOutputs"` // 期望输出变量，只校验列出的变量
+	Enabled       *bool                  `json:"enabled" form:"enabled" query:"enabled"`                   // 是否启用，为空时不修改
+}
+
+// DeleteRuleTestCaseCommand 删除规则测试用例命令
+type DeleteRuleTestCaseCommand struct {
+	ID string `json:"id" form:"id" query:"id"` // 用例ID
+}
+
+// SimulateRulesCommand 模拟执行规则命令
+type SimulateRulesCommand struct {
+	Scope           string                 `json:"scope" form:"scope" query:"scope"`                               // 作用域
+	Trigger         string                 `json:"trigger" form:"trigger" query:"trigger"`                         // 触发动作
+	ScopeID         string                 `json:"scopeId" form:"scopeId" query:"scopeId"`                         // 作用域ID
+	ExecutionTiming string                 `json:"executionTiming" form:"executionTiming" query:"executionTiming"` // 执行时机
+	TenantID        string                 `json:"tenantId" form:"tenantId" query:"tenantId"`                      // 租户ID
+	Data            map[string]interface{} `json:"data" form:"data" query:"data"`                                  // 执行数据
+}
+
+// SimulateRuleCommand 模拟执行单个规则命令
+type SimulateRuleCommand struct {
+	ID       string                 `json:"id" form:"id" query:"id"`                   // 规则ID
+	UseDraft bool                   `json:"useDraft" form:"useDraft" query:"useDraft"` // 是否执行草稿内容
+	ScopeID  string                 `json:"scopeId" form:"scopeId" query:"scopeId"`    // 作用域ID
+	TenantID string                 `json:"tenantId" form:"tenantId" query:"tenantId"` // 租户ID，为空时使用规则所属租户
+	Data     map[string]interface{} `json:"data" form:"data" query:"data"`             // 执行数据
+}
+
+// RunRuleTestCasesCommand 执行规则测试用例命令
+type RunRuleTestCasesCommand struct {
+	ID       string `json:"id" form:"id" query:"id"`                   // 规则ID
+	UseDraft bool   `json:"useDraft" form:"useDraft" query:"useDraft"` // 是否测试草稿内容
+}
+
+// ImportDecisionTableCommand 导入决策表命令
+type ImportDecisionTableCommand struct {
+	ID   string    `json:"id" form:"id" query:"id"` // 规则ID
+	File io.Reader `json:"-" form:"-" query:"-"`    // Excel文件内容
+}
+
+// ValidateDecisionTableCommand 检查决策表命令
+type ValidateDecisionTableCommand struct {
+	Table string `json:"table" form:"table" query:"table"` // 决策表(JSON格式)
+}
+
 // ExecuteRuleCommand 执行规则命令
 type ExecuteRuleCommand struct {
 	RuleID  string                 `json:"ruleId" form:"ruleId" query:"ruleId"`    // 规则ID
@@ -145,6 +242,7 @@ type ExecuteRuleCommand struct {
 // ExecuteRuleByCodeCommand 根据编码执行规则命令
 type ExecuteRuleByCodeCommand struct {
 	Code    string                 `json:"code" form:"code" query:"code"`          // 规则编码
+	Version int32                  `json:"version" form:"version" query:"version"` // 执行的版本号，为0时执行线上版本
 	Context map[string]interface{} `json:"context" form:"context" query:"context"` // 执行上下文
 }
 
@@ -161,4 +259,5 @@ type ConditionConfig struct {
 	Type       string                 `json:"type" form:"type" query:"type"`                   // 条件类型
 	Expression string                 `json:"expression" form:"expression" query:"expression"` // 条件表达式
 	Parameters map[string]interface{} `json:"parameters" form:"parameters" query:"parameters"` // 条件参数
+	Root       map[string]interface{} `json:"root" form:"root" query:"root"`                   // 条件树，支持 all/any/not 分组嵌套
 }
@@ -28,21 +28,23 @@ type TemplateDTO struct {
 
 // CategoryDTO 分类数据传输对象
 type CategoryDTO struct {
-	ID           string `json:"id"`           // 分类ID
-	Code         string `json:"code"`         // 分类编码
-	Name         string `json:"name"`         // 分类名称
-	Description  string `json:"description"`  // 分类描述
-	ParentID     string `json:"parentId"`     // 父分类ID
-	Type         string `json:"type"`         // 分类类型
-	BusinessType string `json:"businessType"` // 业务类型
-	Level        int32  `json:"level"`        // 层级
-	Path         string `json:"path"`         // 路径
-	IsLeaf       bool   `json:"isLeaf"`       // 是否叶子节点
-	Sorting      int32  `json:"sorting"`      // 排序权重
-	Status       int    `json:"status"`       // 状态
-	CreatedAt    int64  `json:"createdAt"`    // 创建时间
-	UpdatedAt    int64  `json:"updatedAt"`    // 更新时间
-	TenantID     string `json:"tenantId"`     // 租户ID
+	ID                string `json:"id"`                // 分类ID
+	Code              string `json:"code"`              // 分类编码
+	Name              string `json:"name"`              // 分类名称
+	Description       string `json:"description"`       // 分类描述
+	ParentID          string `json:"parentId"`          // 父分类ID
+	Type              string `json:"type"`              // 分类类型
+	BusinessType      string `json:"businessType"`      // 业务类型
+	DBPolicy          string `json:"dbPolicy"`          // 数据库访问策略(JSON格式)
+	ExecutionStrategy string `json:"executionStrategy"` // 规则链执行策略
+	Level             int32  `json:"level"`             // 层级
+	Path              string `json:"path"`              // 路径
+	IsLeaf            bool   `json:"isLeaf"`            // 是否叶子节点
+	Sorting           int32  `json:"sorting"`           // 排序权重
+	Status            int    `json:"status"`            // 状态
+	CreatedAt         int64  `json:"createdAt"`         // 创建时间
+	UpdatedAt         int64  `json:"updatedAt"`         // 更新时间
+	TenantID          string `json:"tenantId"`          // 租户ID
 }
 
 // CategoryTreeDTO 分类树数据传输对象
@@ -55,27 +57,94 @@ type CategoryTreeDTO struct {
 
 // RuleDTO 规则数据传输对象
 type RuleDTO struct {
-	ID              string        `json:"id"`              // 规则ID
-	Code            string        `json:"code"`            // 规则编码
-	Name            string        `json:"name"`            // 规则名称
-	Description     string        `json:"description"`     // 规则描述
+	ID               string        `json:"id"`               // 规则ID
+	Code             string        `json:"code"`             // 规则编码
+	Name             string        `json:"name"`             // 规则名称
+	Description      string        `json:"description"`      // 规则描述
+	CategoryID       string        `json:"categoryId"`       // 分类ID
+	TemplateID       string        `json:"templateId"`       // 模板ID
+	Type             string        `json:"type"`             // 规则类型
+	Version          string        `json:"version"`          // 规则版本
+	PublishedVersion int32         `json:"publishedVersion"` // 线上版本号
+	HasDraft         bool          `json:"hasDraft"`         // 是否有未发布的草稿
+	Triggers         []string      `json:"triggers"`         // 触发条件
+	ExecutionTiming  string        `json:"executionTiming"`  // 执行时机：before(前置) after(后置) both(前后都执行)
+	Scope            string        `json:"scope"`            // 作用域
+	ScopeID          string        `json:"scopeId"`          // 作用域ID（商品ID、用户ID、订单ID等）
+	Condition        *ConditionDTO `json:"condition"`        // 条件配置
+	LuaScript        string        `json:"luaScript"`        // Lua脚本
+	Formula          string        `json:"formula"`          // 计算公式
+	FormulaVars      string        `json:"formulaVars"`      // 公式变量定义(JSON格式)
+	DecisionTable    string        `json:"decisionTable"`    // 决策表(JSON格式)
+	DBPolicy         string        `json:"dbPolicy"`         // 数据库访问策略(JSON格式)
+	Action           string        `json:"action"`           // 触发动作：allow(允许) deny(拒绝) modify(修改) notify(通知) redirect(重定向)
+	Priority         int32         `json:"priority"`         // 优先级
+	Sorting          int32         `json:"sorting"`          // 排序权重
+	Status           int32         `json:"status"`           // 状态
+	CreatedAt        int64         `json:"createdAt"`        // 创建时间
+	UpdatedAt        int64         `json:"updatedAt"`        // 更新时间
+	TenantID         string        `json:"tenantId"`         // 租户ID
+}
+
+// RuleVersionDTO 规则版本数据传输对象
+type RuleVersionDTO struct {
+	ID              string        `json:"id"`              // 版本ID
+	RuleID          string        `json:"ruleId"`          // 规则ID
+	Version         int32         `json:"version"`         // 版本号，草稿为0
+	Status          string        `json:"status"`          // 状态：draft(草稿) published(已发布)
+	Comment         string        `json:"comment"`         // 版本说明
+	RollbackFrom    int32         `json:"rollbackFrom"`    // 回滚来源版本号
+	Current         bool          `json:"current"`         // 是否为线上版本
 	CategoryID      string        `json:"categoryId"`      // 分类ID
 	TemplateID      string        `json:"templateId"`      // 模板ID
 	Type            string        `json:"type"`            // 规则类型
 	Triggers        []string      `json:"triggers"`        // 触发条件
-	ExecutionTiming string        `json:"executionTiming"` // 执行时机：before(前置) after(后置) both(前后都执行)
 	Scope           string        `json:"scope"`           // 作用域
-	ScopeID         string        `json:"scopeId"`         // 作用域ID（商品ID、用户ID、订单ID等）
+	ScopeID         string        `json:"scopeId"`         // 作用域ID
+	ExecutionTiming string        `json:"executionTiming"` // 执行时机
 	Condition       *ConditionDTO `json:"condition"`       // 条件配置
 	LuaScript       string        `json:"luaScript"`       // Lua脚本
 	Formula         string        `json:"formula"`         // 计算公式
-	Action          string        `json:"action"`          // 触发动作：allow(允许) deny(拒绝) modify(修改) notify(通知) redirect(重定向)
+	FormulaVars     string        `json:"formulaVars"`     // 公式变量定义(JSON格式)
+	DecisionTable   string        `json:"decisionTable"`   // 决策表(JSON格式)
+	DBPolicy        string        `json:"dbPolicy"`        // 数据库访问策略(JSON格式)
+	Action          string        `json:"action"`          // 触发动作
 	Priority        int32         `json:"priority"`        // 优先级
 	Sorting         int32         `json:"sorting"`         // 排序权重
-	Status          int32         `json:"status"`          // 状态
+	PublishedAt     int64         `json:"publishedAt"`     // 发布时间
 	CreatedAt       int64         `json:"createdAt"`       // 创建时间
 	UpdatedAt       int64         `json:"updatedAt"`       // 更新时间
-	TenantID        string        `json:"tenantId"`        // 租户ID
+}
+
+// RuleVersionDiffDTO 规则版本差异数据传输对象
+type RuleVersionDiffDTO struct {
+	RuleID  string                 `json:"ruleId"`  // 规则ID
+	From    int32                  `json:"from"`    // 原版本号，0 表示草稿
+	To      int32                  `json:"to"`      // 新版本号，0 表示草稿
+	Changes []RuleVersionChangeDTO `json:"changes"` // 有差异的字段
+}
+
+// RuleVersionChangeDTO 规则版本字段差异
+type RuleVersionChangeDTO struct {
+	Field string `json:"field"` // 字段名称
+	From  string `json:"from"`  // 原值
+	To    string `json:"to"`    // 新值
+}
+
+// RuleTestCaseDTO 规则测试用例数据传输对象
+type RuleTestCaseDTO struct {
+	ID            string                 `json:"id"`            // 用例ID
+	RuleID        string                 `json:"ruleId"`        // 规则ID
+	Name          string                 `json:"name"`          // 用例名称
+	Description   string                 `json:"description"`   // 用例描述
+	ScopeID       string                 `json:"scopeId"`       // 执行时的作用域ID
+	Input         map[string]interface{} `json:"input"`         // 执行输入数据
+	ExpectValid   *bool                  `json:"expectValid"`   // 期望是否通过
+	ExpectAction  string                 `json:"expectAction"`  // 期望动作
+	ExpectOutputs map[string]interface{} `json:"expectOutputs"` // 期望输出变量
+	Enabled       bool                   `json:"enabled"`       // 是否启用
+	CreatedAt     int64                  `json:"createdAt"`     // 创建时间
+	UpdatedAt     int64                  `json:"updatedAt"`     // 更新时间
 }
 
 // ConditionDTO 条件配置数据传输对象
@@ -83,6 +152,7 @@ type ConditionDTO struct {
 	Type       string                 `json:"type"`       // 条件类型
 	Expression string                 `json:"expression"` // 条件表达式
 	Parameters map[string]interface{} `json:"parameters"` // 条件参数
+	Root       map[string]interface{} `json:"root"`       // 条件树
 }
 
 // ==================== 规则执行相关DTO ====================
@@ -167,6 +237,7 @@ type ExecuteRuleRequestDTO struct {
 // ExecuteRuleByCodeRequestDTO 根据编码执行规则请求数据传输对象
 type ExecuteRuleByCodeRequestDTO struct {
 	Code    string                 `json:"code"`    // 规则编码
+	Version int32                  `json:"version"` // 执行的版本号，为0时执行线上版本
 	Context map[string]interface{} `json:"context"` // 执行上下文
 }
 
@@ -175,3 +246,51 @@ type ExecuteRulesByTriggerRequestDTO struct {
 	Trigger string                 `json:"trigger"` // 触发条件
 	Context map[string]interface{} `json:"context"` // 执行上下文
 }
+
+// ==================== 执行日志相关DTO ====================
+
+// RuleExecutionLogDTO 规则执行日志数据传输对象
+type RuleExecutionLogDTO struct {
+	ID              string                     `json:"id"`              // 日志ID
+	Scope           string                     `json:"scope"`           // 作用域
+	Trigger         string                     `json:"trigger"`         // 触发动作
+	ScopeID         string                     `json:"scopeId"`         // 作用域ID
+	ExecutionTiming string                     `json:"executionTiming"` // 执行时机
+	RuleCode        string                     `json:"ruleCode"`        // 按编码执行时的规则编码
+	Valid           bool                       `json:"valid"`           // 是否通过
+	Action          string                     `json:"action"`          // 执行动作
+	Error           string                     `json:"error"`           // 错误信息
+	ErrorReason     string                     `json:"errorReason"`     // 错误原因
+	ExecuteTime     int64                      `json:"executeTime"`     // 执行时间(毫秒)
+	StepCount       int32                      `json:"stepCount"`       // 执行的规则数量
+	ExecuteAt       int64                      `json:"executeAt"`       // 执行时间戳
+	Weight          float64                    `json:"weight"`          // 统计权重(采样率的倒数)
+	Steps           []*RuleExecutionLogStepDTO `json:"steps,omitempty"` // 执行步骤，仅详情返回
+}
+
+// RuleExecutionLogStepDTO 规则执行日志步骤数据传输对象
+type RuleExecutionLogStepDTO struct {
+	ID          string `json:"id"`          // 步骤ID
+	Seq         int32  `json:"seq"`         // 执行顺序
+	RuleID      string `json:"ruleId"`      // 规则ID
+	RuleCode    string `json:"ruleCode"`    // 规则编码
+	RuleName    string `json:"ruleName"`    // 规则名称
+	RuleVersion int32  `json:"ruleVersion"` // 执行的版本号
+	Input       string `json:"input"`       // 输入数据(JSON格式)
+	Output      string `json:"output"`      // 输出数据(JSON格式)
+	Success     bool   `json:"success"`     // 是否执行成功
+	Action      string `json:"action"`      // 执行动作
+	Error       string `json:"error"`       // 错误信息
+	ExecuteTime int64  `json:"executeTime"` // 执行时间(毫秒)
+	ExecuteAt   int64  `json:"executeAt"`   // 执行时间戳
+}
+
+// RuleExecutionStatsDTO 规则执行统计数据传输对象
+type RuleExecutionStatsDTO struct {
+	Key            string  `json:"key"`            // 分组值：规则编码、作用域或触发动作
+	Total          float64 `json:"total"`          // 执行次数，开启采样时为估算值
+	Success        float64 `json:"success"`        // 成功次数
+	Failed         float64 `json:"failed"`         // 失败次数
+	FailureRate    float64 `json:"failureRate"`    // 失败率(0-1)
+	AvgExecuteTime float64 `json:"avgExecuteTime"` // 平均执行时间(毫秒)
+}
@@ -227,20 +227,22 @@ func (h *CategoryQueryHandler) HandleGetAllCategories(ctx context.Context) ([]*d
 // convertToDTO 转换为DTO
 func (h *CategoryQueryHandler) convertToDTO(category *model.RuleCategory) *dto.CategoryDTO {
 	return &dto.CategoryDTO{
-		ID:           category.ID,
-		Code:         category.Code,
-		Name:         category.Name,
-		Description:  category.Description,
-		ParentID:     category.ParentID,
-		Type:         category.Type,
-		BusinessType: category.BusinessType,
-		Level:        category.Level,
-		Path:         category.Path,
-		IsLeaf:       category.IsLeaf,
-		Sorting:      category.Sorting,
-		Status:       int(category.Status),
-		CreatedAt:    category.CreatedAt,
-		UpdatedAt:    category.UpdatedAt,
-		TenantID:     category.TenantID,
+		ID:                category.ID,
+		Code:              category.Code,
+		Name:              category.Name,
+		Description:       category.Description,
+		ParentID:          category.ParentID,
+		Type:              category.Type,
+		BusinessType:      category.BusinessType,
+		DBPolicy:          category.DBPolicy,
+		ExecutionStrategy: category.ExecutionStrategy,
+		Level:             category.Level,
+		Path:              category.Path,
+		IsLeaf:            category.IsLeaf,
+		Sorting:           category.Sorting,
+		Status:            int(category.Status),
+		CreatedAt:         category.CreatedAt,
+		UpdatedAt:         category.UpdatedAt,
+		TenantID:          category.TenantID,
 	}
 }
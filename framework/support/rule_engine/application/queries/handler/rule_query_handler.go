@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
@@ -11,18 +12,26 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/application/dto"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/application/queries"
+	ruleengineerr "github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/err"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/service"
 )
 
 // RuleQueryHandler 规则查询处理器
 type RuleQueryHandler struct {
-	ruleRepo repository.IRuleRepository
+	ruleRepo     repository.IRuleRepository
+	versionRepo  repository.IRuleVersionRepository
+	testCaseRepo repository.IRuleTestCaseRepository
+	logRepo      repository.IRuleExecutionLogRepository
 }
 
 // NewRuleQueryHandler 创建规则查询处理器
-func NewRuleQueryHandler(ruleRepo repository.IRuleRepository) *RuleQueryHandler {
+func NewRuleQueryHandler(ruleRepo repository.IRuleRepository, versionRepo repository.IRuleVersionRepository, testCaseRepo repository.IRuleTestCaseRepository, logRepo repository.IRuleExecutionLogRepository) *RuleQueryHandler {
 	return &RuleQueryHandler{
-		ruleRepo: ruleRepo,
+		ruleRepo:     ruleRepo,
+		versionRepo:  versionRepo,
+		testCaseRepo: testCaseRepo,
+		logRepo:      logRepo,
 	}
 }
 
@@ -238,8 +247,303 @@ func (h *RuleQueryHandler) HandleGetRule(ctx context.Context, req *queries.GetRu
 	if err != nil {
 		return nil, herrors.QueryFail(err)
 	}
+	draft, err := h.versionRepo.FindDraft(ctx, req.ID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
 
-	return h.convertToDTO(rule), nil
+	ruleDTO := h.convertToDTO(rule)
+	ruleDTO.HasDraft = draft != nil
+	return ruleDTO, nil
+}
+
+// HandleGetRuleDraft 处理获取规则草稿查询，没有草稿时返回线上内容
+func (h *RuleQueryHandler) HandleGetRuleDraft(ctx context.Context, req *queries.GetRuleReq) (*dto.RuleDTO, *herrors.HError) {
+	rule, err := h.ruleRepo.FindByID(ctx, req.ID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	draft, err := h.versionRepo.FindDraft(ctx, req.ID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	if draft != nil {
+		draft.ApplyTo(rule)
+	}
+
+	ruleDTO := h.convertToDTO(rule)
+	ruleDTO.HasDraft = draft != nil
+	return ruleDTO, nil
+}
+
+// HandleExportDecisionTable 处理导出决策表查询，返回 Excel 文件内容
+func (h *RuleQueryHandler) HandleExportDecisionTable(ctx context.Context, req *queries.ExportDecisionTableReq) ([]byte, *herrors.HError) {
+	rule, err := h.ruleRepo.FindByID(ctx, req.ID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	if req.UseDraft {
+		draft, err := h.versionRepo.FindDraft(ctx, req.ID)
+		if err != nil {
+			return nil, herrors.QueryFail(err)
+		}
+		if draft != nil {
+			draft.ApplyTo(rule)
+		}
+	}
+	if rule.Type != "decision_table" {
+		return nil, ruleengineerr.RuleTypeInvalid
+	}
+
+	table, err := rule.CompileDecisionTable()
+	if err != nil {
+		return nil, ruleengineerr.RuleDecisionTableExportFailed(err)
+	}
+	data, err := service.DecisionTableToExcel(table.Table)
+	if err != nil {
+		return nil, ruleengineerr.RuleDecisionTableExportFailed(err)
+	}
+	return data, nil
+}
+
+// HandleGetRuleVersions 处理获取规则版本列表查询
+func (h *RuleQueryHandler) HandleGetRuleVersions(ctx context.Context, req *queries.GetRuleVersionsReq) ([]*dto.RuleVersionDTO, *herrors.HError) {
+	rule, err := h.ruleRepo.FindByID(ctx, req.RuleID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	versions, err := h.versionRepo.FindVersions(ctx, req.RuleID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+
+	dtos := make([]*dto.RuleVersionDTO, len(versions))
+	for i, version := range versions {
+		dtos[i] = h.convertVersionToDTO(version, rule.PublishedVersion)
+	}
+	return dtos, nil
+}
+
+// HandleGetRuleVersion 处理获取规则版本详情查询
+func (h *RuleQueryHandler) HandleGetRuleVersion(ctx context.Context, req *queries.GetRuleVersionReq) (*dto.RuleVersionDTO, *herrors.HError) {
+	rule, err := h.ruleRepo.FindByID(ctx, req.RuleID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	version, herr := h.findVersion(ctx, req.RuleID, req.Version)
+	if herr != nil {
+		return nil, herr
+	}
+	return h.convertVersionToDTO(version, rule.PublishedVersion), nil
+}
+
+// HandleDiffRuleVersions 处理比较规则版本查询
+func (h *RuleQueryHandler) HandleDiffRuleVersions(ctx context.Context, req *queries.DiffRuleVersionsReq) (*dto.RuleVersionDiffDTO, *herrors.HError) {
+	from, herr := h.findVersion(ctx, req.RuleID, req.From)
+	if herr != nil {
+		return nil, herr
+	}
+	to, herr := h.findVersion(ctx, req.RuleID, req.To)
+	if herr != nil {
+		return nil, herr
+	}
+
+	changes := from.Diff(to)
+	diff := &dto.RuleVersionDiffDTO{
+		RuleID:  req.RuleID,
+		From:    req.From,
+		To:      req.To,
+		Changes: make([]dto.RuleVersionChangeDTO, len(changes)),
+	}
+	for i, change := range changes {
+		diff.Changes[i] = dto.RuleVersionChangeDTO{Field: change.Field, From: change.From, To: change.To}
+	}
+	return diff, nil
+}
+
+// findVersion 查找规则版本，版本号为 0 时查找草稿
+func (h *RuleQueryHandler) findVersion(ctx context.Context, ruleID string, version int32) (*model.RuleVersion, *herrors.HError) {
+	var (
+		v   *model.RuleVersion
+		err error
+	)
+	if version == 0 {
+		v, err = h.versionRepo.FindDraft(ctx, ruleID)
+	} else {
+		v, err = h.versionRepo.FindVersion(ctx, ruleID, version)
+	}
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	if v == nil {
+		if version == 0 {
+			return nil, ruleengineerr.RuleDraftNotExist
+		}
+		return nil, ruleengineerr.RuleVersionNotExist
+	}
+	return v, nil
+}
+
+// HandleGetRuleTestCases 处理获取规则测试用例列表查询
+func (h *RuleQueryHandler) HandleGetRuleTestCases(ctx context.Context, req *queries.GetRuleTestCasesReq) ([]*dto.RuleTestCaseDTO, *herrors.HError) {
+	cases, err := h.testCaseRepo.FindByRuleID(ctx, req.RuleID)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+
+	dtos := make([]*dto.RuleTestCaseDTO, len(cases))
+	for i, testCase := range cases {
+		dtos[i] = h.convertTestCaseToDTO(testCase)
+	}
+	return dtos, nil
+}
+
+// HandleGetExecutionLogs 处理获取规则执行日志列表查询
+func (h *RuleQueryHandler) HandleGetExecutionLogs(ctx context.Context, req *queries.GetExecutionLogsReq) ([]*dto.RuleExecutionLogDTO, int64, *herrors.HError) {
+	query := db_query.NewQueryBuilder()
+	if req.RuleCode != "" {
+		query.WhereRaw("(rule_code = ? OR id IN (SELECT log_id FROM rule_execution_steps WHERE rule_code = ?))", req.RuleCode, req.RuleCode)
+	}
+	if req.Scope != "" {
+		query.Where("scope", db_query.Eq, req.Scope)
+	}
+	if req.Trigger != "" {
+		query.Where("trigger_action", db_query.Eq, req.Trigger)
+	}
+	if req.ScopeID != "" {
+		query.Where("scope_id", db_query.Eq, req.ScopeID)
+	}
+	if req.Valid != nil {
+		query.Where("valid", db_query.Eq, *req.Valid)
+	}
+	h.applyTimeRange(query, req.StartTime, req.EndTime)
+
+	total, err := h.logRepo.Count(ctx, query)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Count rule execution logs error: %v", err)
+		return nil, 0, ruleengineerr.RuleExecutionLogGetFailed(err)
+	}
+
+	query.OrderByDESC("execute_at")
+	query.WithPage(&req.Page)
+	logs, err := h.logRepo.Find(ctx, query)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Get rule execution logs error: %v", err)
+		return nil, 0, ruleengineerr.RuleExecutionLogGetFailed(err)
+	}
+
+	dtos := make([]*dto.RuleExecutionLogDTO, len(logs))
+	for i, log := range logs {
+		dtos[i] = h.convertExecutionLogToDTO(log)
+	}
+	return dtos, total, nil
+}
+
+// HandleGetExecutionLog 处理获取规则执行日志详情查询，包含执行步骤
+func (h *RuleQueryHandler) HandleGetExecutionLog(ctx context.Context, req *queries.GetExecutionLogReq) (*dto.RuleExecutionLogDTO, *herrors.HError) {
+	log, err := h.logRepo.FindByID(ctx, req.ID)
+	if err != nil {
+		return nil, ruleengineerr.RuleExecutionLogGetFailed(err)
+	}
+	if log == nil {
+		return nil, ruleengineerr.RuleExecutionLogNotExist
+	}
+	return h.convertExecutionLogToDTO(log), nil
+}
+
+// HandleGetExecutionStats 处理规则执行统计查询
+// 按规则编码统计时统计每个规则的执行步骤，按作用域和触发动作统计时统计每次执行的最终结果
+func (h *RuleQueryHandler) HandleGetExecutionStats(ctx context.Context, req *queries.GetExecutionStatsReq) ([]*dto.RuleExecutionStatsDTO, *herrors.HError) {
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = model.ExecutionStatsByRule
+	}
+	if groupBy != model.ExecutionStatsByRule && groupBy != model.ExecutionStatsByScope && groupBy != model.ExecutionStatsByTrigger {
+		return nil, herrors.NewParameterHError(fmt.Errorf("unsupported stats group: %s", groupBy))
+	}
+
+	query := db_query.NewQueryBuilder()
+	if req.RuleCode != "" {
+		if groupBy == model.ExecutionStatsByRule {
+			query.Where("rule_code", db_query.Eq, req.RuleCode)
+		} else {
+			query.WhereRaw("(rule_code = ? OR id IN (SELECT log_id FROM rule_execution_steps WHERE rule_code = ?))", req.RuleCode, req.RuleCode)
+		}
+	}
+	if req.Scope != "" {
+		query.Where("scope", db_query.Eq, req.Scope)
+	}
+	if req.Trigger != "" {
+		query.Where("trigger_action", db_query.Eq, req.Trigger)
+	}
+	h.applyTimeRange(query, req.StartTime, req.EndTime)
+
+	stats, err := h.logRepo.Stats(ctx, groupBy, query)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Get rule execution stats error: %v", err)
+		return nil, ruleengineerr.RuleExecutionStatsFailed(err)
+	}
+
+	dtos := make([]*dto.RuleExecutionStatsDTO, len(stats))
+	for i, s := range stats {
+		dtos[i] = &dto.RuleExecutionStatsDTO{
+			Key:            s.Key,
+			Total:          s.Total,
+			Success:        s.Success,
+			Failed:         s.Failed,
+			FailureRate:    s.FailureRate,
+			AvgExecuteTime: s.AvgExecuteTime,
+		}
+	}
+	return dtos, nil
+}
+
+// applyTimeRange 添加执行时间范围条件
+func (h *RuleQueryHandler) applyTimeRange(query *db_query.QueryBuilder, startTime, endTime int64) {
+	if startTime > 0 {
+		query.Where("execute_at", db_query.Gte, startTime)
+	}
+	if endTime > 0 {
+		query.Where("execute_at", db_query.Lte, endTime)
+	}
+}
+
+// convertExecutionLogToDTO 将执行日志转换为DTO
+func (h *RuleQueryHandler) convertExecutionLogToDTO(log *model.RuleExecutionLog) *dto.RuleExecutionLogDTO {
+	d := &dto.RuleExecutionLogDTO{
+		ID:              log.ID,
+		Scope:           log.Scope,
+		Trigger:         log.Trigger,
+		ScopeID:         log.ScopeID,
+		ExecutionTiming: log.ExecutionTiming,
+		RuleCode:        log.RuleCode,
+		Valid:           log.Valid,
+		Action:          log.Action,
+		Error:           log.Error,
+		ErrorReason:     log.ErrorReason,
+		ExecuteTime:     log.ExecuteTime,
+		StepCount:       log.StepCount,
+		ExecuteAt:       log.ExecuteAt,
+		Weight:          log.Weight,
+	}
+	for _, step := range log.Steps {
+		d.Steps = append(d.Steps, &dto.RuleExecutionLogStepDTO{
+			ID:          step.ID,
+			Seq:         step.Seq,
+			RuleID:      step.RuleID,
+			RuleCode:    step.RuleCode,
+			RuleName:    step.RuleName,
+			RuleVersion: step.RuleVersion,
+			Input:       step.Input,
+			Output:      step.Output,
+			Success:     step.Success,
+			Action:      step.Action,
+			Error:       step.Error,
+			ExecuteTime: step.ExecuteTime,
+			ExecuteAt:   step.ExecuteAt,
+		})
+	}
+	return d
 }
 
 // HandleGetRuleByCode 处理根据编码获取规则查询
@@ -271,27 +575,82 @@ func (h *RuleQueryHandler) HandleGetAllRules(ctx context.Context) ([]*dto.RuleDT
 // convertToDTO 转换为DTO
 func (h *RuleQueryHandler) convertToDTO(rule *model.Rule) *dto.RuleDTO {
 	return &dto.RuleDTO{
-		ID:              rule.ID,
-		Code:            rule.Code,
-		Name:            rule.Name,
-		Description:     rule.Description,
-		CategoryID:      rule.CategoryID,
-		TemplateID:      rule.TemplateID,
-		Type:            rule.Type,
-		Action:          rule.Action,
-		Triggers:        rule.Triggers,
-		Scope:           rule.Scope,
-		ScopeID:         rule.ScopeID,
-		ExecutionTiming: rule.ExecutionTiming,
-		Condition:       h.convertConditionToDTO(rule.Conditions),
-		LuaScript:       rule.LuaScript,
-		Formula:         rule.Formula,
-		Priority:        rule.Priority,
-		Sorting:         rule.Sorting,
-		Status:          rule.Status,
-		CreatedAt:       rule.CreatedAt,
-		UpdatedAt:       rule.UpdatedAt,
-		TenantID:        rule.TenantID,
+		ID:               rule.ID,
+		Code:             rule.Code,
+		Name:             rule.Name,
+		Description:      rule.Description,
+		CategoryID:       rule.CategoryID,
+		TemplateID:       rule.TemplateID,
+		Type:             rule.Type,
+		Version:          rule.Version,
+		PublishedVersion: rule.PublishedVersion,
+		Action:           rule.Action,
+		Triggers:         rule.Triggers,
+		Scope:            rule.Scope,
+		ScopeID:          rule.ScopeID,
+		ExecutionTiming:  rule.ExecutionTiming,
+		Condition:        h.convertConditionToDTO(rule.Conditions),
+		LuaScript:        rule.LuaScript,
+		Formula:          rule.Formula,
+		FormulaVars:      rule.FormulaVars,
+		DecisionTable:    rule.DecisionTable,
+		DBPolicy:         rule.DBPolicy,
+		Priority:         rule.Priority,
+		Sorting:          rule.Sorting,
+		Status:           rule.Status,
+		CreatedAt:        rule.CreatedAt,
+		UpdatedAt:        rule.UpdatedAt,
+		TenantID:         rule.TenantID,
+	}
+}
+
+// convertVersionToDTO 转换规则版本为DTO
+func (h *RuleQueryHandler) convertVersionToDTO(version *model.RuleVersion, current int32) *dto.RuleVersionDTO {
+	return &dto.RuleVersionDTO{
+		ID:              version.ID,
+		RuleID:          version.RuleID,
+		Version:         version.Version,
+		Status:          version.Status,
+		Comment:         version.Comment,
+		RollbackFrom:    version.RollbackFrom,
+		Current:         !version.IsDraft() && version.Version == current,
+		CategoryID:      version.CategoryID,
+		TemplateID:      version.TemplateID,
+		Type:            version.Type,
+		Triggers:        version.Triggers,
+		Scope:           version.Scope,
+		ScopeID:         version.ScopeID,
+		ExecutionTiming: version.ExecutionTiming,
+		Condition:       h.convertConditionToDTO(version.Conditions),
+		LuaScript:       version.LuaScript,
+		Formula:         version.Formula,
+		FormulaVars:     version.FormulaVars,
+		DecisionTable:   version.DecisionTable,
+		DBPolicy:        version.DBPolicy,
+		Action:          version.Action,
+		Priority:        version.Priority,
+		Sorting:         version.Sorting,
+		PublishedAt:     version.PublishedAt,
+		CreatedAt:       version.CreatedAt,
+		UpdatedAt:       version.UpdatedAt,
+	}
+}
+
+// convertTestCaseToDTO 转换规则测试用例为DTO
+func (h *RuleQueryHandler) convertTestCaseToDTO(testCase *model.RuleTestCase) *dto.RuleTestCaseDTO {
+	return &dto.RuleTestCaseDTO{
+		ID:            testCase.ID,
+		RuleID:        testCase.RuleID,
+		Name:          testCase.Name,
+		Description:   testCase.Description,
+		ScopeID:       testCase.ScopeID,
+		Input:         testCase.Input,
+		ExpectValid:   testCase.ExpectValid,
+		ExpectAction:  testCase.ExpectAction,
+		ExpectOutputs: testCase.ExpectOutputs,
+		Enabled:       testCase.Enabled,
+		CreatedAt:     testCase.CreatedAt,
+		UpdatedAt:     testCase.UpdatedAt,
 	}
 }
 
@@ -327,9 +686,12 @@ func (h *RuleQueryHandler) convertConditionToDTO(conditions string) *dto.Conditi
 		parameters = make(map[string]interface{})
 	}
 
+	root, _ := conditionMap["root"].(map[string]interface{})
+
 	return &dto.ConditionDTO{
 		Type:       conditionType,
 		Expression: expression,
 		Parameters: parameters,
+		Root:       root,
 	}
 }
@@ -159,3 +159,61 @@ type GetRuleReq struct {
 type GetRuleByCodeReq struct {
 	Code string `form:"code" query:"code"` // 规则编码
 }
+
+// GetRuleVersionsReq 获取规则版本列表请求
+type GetRuleVersionsReq struct {
+	RuleID string `form:"ruleId" query:"ruleId" json:"ruleId"` // 规则ID
+}
+
+// GetRuleVersionReq 获取规则版本详情请求
+type GetRuleVersionReq struct {
+	RuleID  string `form:"ruleId" query:"ruleId" json:"ruleId"`    // 规则ID
+	Version int32  `form:"version" query:"version" json:"version"` // 版本号，0 表示草稿
+}
+
+// DiffRuleVersionsReq 比较规则版本请求
+type DiffRuleVersionsReq struct {
+	RuleID string `form:"ruleId" query:"ruleId" json:"ruleId"` // 规则ID
+	From   int32  `form:"from" query:"from" json:"from"`       // 原版本号，0 表示草稿
+	To     int32  `form:"to" query:"to" json:"to"`             // 新版本号，0 表示草稿
+}
+
+// GetRuleTestCasesReq 获取规则测试用例列表请求
+type GetRuleTestCasesReq struct {
+	RuleID string `form:"ruleId" query:"ruleId" json:"ruleId"` // 规则ID
+}
+
+// ExportDecisionTableReq 导出决策表请求
+type ExportDecisionTableReq struct {
+	ID       string `form:"id" query:"id" json:"id"`                   // 规则ID
+	UseDraft bool   `form:"useDraft" query:"useDraft" json:"useDraft"` // 是否导出草稿内容
+}
+
+// ==================== 执行日志相关查询 ====================
+
+// GetExecutionLogsReq 获取规则执行日志列表请求
+type GetExecutionLogsReq struct {
+	db_query.Page
+	RuleCode  string `form:"ruleCode" query:"ruleCode" json:"ruleCode"`    // 规则编码，匹配执行链路中包含该规则的日志
+	Scope     string `form:"scope" query:"scope" json:"scope"`             // 作用域
+	Trigger   string `form:"trigger" query:"trigger" json:"trigger"`       // 触发动作
+	ScopeID   string `form:"scopeId" query:"scopeId" json:"scopeId"`       // 作用域ID
+	Valid     *bool  `form:"valid" query:"valid" json:"valid"`             // 是否通过
+	StartTime int64  `form:"startTime" query:"startTime" json:"startTime"` // 开始时间
+	EndTime   int64  `form:"endTime" query:"endTime" json:"endTime"`       // 结束时间
+}
+
+// GetExecutionLogReq 获取规则执行日志详情请求
+type GetExecutionLogReq struct {
+	ID string `form:"id" query:"id"` // 日志ID
+}
+
+// GetExecutionStatsReq 获取规则执行统计请求
+type GetExecutionStatsReq struct {
+	GroupBy   string `form:"groupBy" query:"groupBy" json:"groupBy"`       // 分组维度：rule(规则编码，默认) scope(作用域) trigger(触发动作)
+	RuleCode  string `form:"ruleCode" query:"ruleCode" json:"ruleCode"`    // 规则编码
+	Scope     string `form:"scope" query:"scope" json:"scope"`             // 作用域
+	Trigger   string `form:"trigger" query:"trigger" json:"trigger"`       // 触发动作
+	StartTime int64  `form:"startTime" query:"startTime" json:"startTime"` // 开始时间
+	EndTime   int64  `form:"endTime" query:"endTime" json:"endTime"`       // 结束时间
+}
@@ -65,6 +65,56 @@ var (
 	// RuleFormulaError 公式计算错误
 	RuleFormulaError = herrors.NewServerError("RuleFormulaError")
 
+	// ==================== 规则版本相关错误 ====================
+
+	// RuleDraftSaveFailed 保存规则草稿失败
+	RuleDraftSaveFailed = herrors.NewServerError("RuleDraftSaveFailed")
+	// RuleDraftNotExist 规则草稿不存在
+	RuleDraftNotExist = herrors.NewBusinessServerError("RuleDraftNotExist")
+	// RulePublishFailed 发布规则失败
+	RulePublishFailed = herrors.NewServerError("RulePublishFailed")
+	// RuleVersionGetFailed 获取规则版本失败
+	RuleVersionGetFailed = herrors.NewServerError("RuleVersionGetFailed")
+	// RuleVersionNotExist 规则版本不存在
+	RuleVersionNotExist = herrors.NewBusinessServerError("RuleVersionNotExist")
+	// RuleVersionIsCurrent 规则版本已是线上版本
+	RuleVersionIsCurrent = herrors.NewBusinessServerError("RuleVersionIsCurrent")
+
+	// ==================== 规则测试相关错误 ====================
+
+	// RuleTestCaseCreateFailed 创建规则测试用例失败
+	RuleTestCaseCreateFailed = herrors.NewServerError("RuleTestCaseCreateFailed")
+	// RuleTestCaseUpdateFailed 更新规则测试用例失败
+	RuleTestCaseUpdateFailed = herrors.NewServerError("RuleTestCaseUpdateFailed")
+	// RuleTestCaseDeleteFailed 删除规则测试用例失败
+	RuleTestCaseDeleteFailed = herrors.NewServerError("RuleTestCaseDeleteFailed")
+	// RuleTestCaseGetFailed 获取规则测试用例失败
+	RuleTestCaseGetFailed = herrors.NewServerError("RuleTestCaseGetFailed")
+	// RuleTestCaseNotExist 规则测试用例不存在
+	RuleTestCaseNotExist = herrors.NewBusinessServerError("RuleTestCaseNotExist")
+	// RuleTestCaseValidationFailed 规则测试用例数据验证失败
+	RuleTestCaseValidationFailed = herrors.NewServerError("RuleTestCaseValidationFailed")
+	// RuleTestCaseFailed 规则测试用例未通过
+	RuleTestCaseFailed = herrors.NewServerError("RuleTestCaseFailed")
+	// RuleSimulateFailed 规则模拟执行失败
+	RuleSimulateFailed = herrors.NewServerError("RuleSimulateFailed")
+
+	// ==================== 决策表相关错误 ====================
+
+	// RuleDecisionTableImportFailed 导入决策表失败
+	RuleDecisionTableImportFailed = herrors.NewServerError("RuleDecisionTableImportFailed")
+	// RuleDecisionTableExportFailed 导出决策表失败
+	RuleDecisionTableExportFailed = herrors.NewServerError("RuleDecisionTableExportFailed")
+
+	// ==================== 执行日志相关错误 ====================
+
+	// RuleExecutionLogGetFailed 获取规则执行日志失败
+	RuleExecutionLogGetFailed = herrors.NewServerError("RuleExecutionLogGetFailed")
+	// RuleExecutionLogNotExist 规则执行日志不存在
+	RuleExecutionLogNotExist = herrors.NewBusinessServerError("RuleExecutionLogNotExist")
+	// RuleExecutionStatsFailed 统计规则执行情况失败
+	RuleExecutionStatsFailed = herrors.NewServerError("RuleExecutionStatsFailed")
+
 	// ==================== 分类相关错误 ====================
 
 	// RuleCategoryCreateFailed 创建规则分类失败
@@ -3,6 +3,8 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/formula"
+	"github.com/flare-admin/flare-server-go/framework/pkg/lua_engine"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 )
 
@@ -17,9 +19,10 @@ type Rule struct {
 	TemplateID  string `json:"templateId"`  // 模板ID（可选）
 
 	// 规则配置
-	Type    string `json:"type"`    // 规则类型：condition(条件规则) lua(lua脚本规则) formula(公式规则)
-	Version string `json:"version"` // 规则版本
-	Status  int32  `json:"status"`  // 状态：1-启用 2-禁用
+	Type             string `json:"type"`             // 规则类型：condition(条件规则) lua(lua脚本规则) formula(公式规则)
+	Version          string `json:"version"`          // 规则版本，发布时设置为线上版本号
+	PublishedVersion int32  `json:"publishedVersion"` // 线上版本号，0 表示未通过发布流程产生过版本
+	Status           int32  `json:"status"`           // 状态：1-启用 2-禁用
 
 	// 触发配置
 	Triggers        []string `json:"triggers"`        // 触发动作列表
@@ -28,10 +31,14 @@ type Rule struct {
 	ExecutionTiming string   `json:"executionTiming"` // 执行时机：before(前置) after(后置) both(前后都执行)
 
 	// 规则内容（从模板继承或自定义）
-	Conditions  string `json:"conditions"`  // 条件表达式(JSON格式)
-	LuaScript   string `json:"luaScript"`   // Lua脚本代码
-	Formula     string `json:"formula"`     // 计算公式
-	FormulaVars string `json:"formulaVars"` // 公式变量映射(JSON格式)
+	Conditions    string `json:"conditions"`    // 条件表达式(JSON格式)
+	LuaScript     string `json:"luaScript"`     // Lua脚本代码
+	Formula       string `json:"formula"`       // 计算公式
+	FormulaVars   string `json:"formulaVars"`   // 公式变量映射(JSON格式)
+	DecisionTable string `json:"decisionTable"` // 决策表(JSON格式)
+
+	// 数据访问配置
+	DBPolicy string `json:"dbPolicy"` // Lua脚本的数据库访问策略(JSON格式)，为空时使用分类的策略
 
 	// 动作配置
 	Action string `json:"action"` // 规则动作：allow(允许) deny(拒绝) modify(修改) notify(通知) redirect(重定向)
@@ -252,6 +259,32 @@ func (r *Rule) SetLuaScript(script string) error {
 	return nil
 }
 
+// SetDecisionTable 设置决策表(JSON格式)
+func (r *Rule) SetDecisionTable(table string) error {
+	if r.Type != "decision_table" {
+		return fmt.Errorf("rule type is not decision_table")
+	}
+
+	r.DecisionTable = table
+	r.UpdatedAt = utils.GetDateUnix()
+	return nil
+}
+
+// SetDBPolicy 设置数据库访问策略
+func (r *Rule) SetDBPolicy(policy string) error {
+	if _, err := lua_engine.ParseDBPolicy(policy); err != nil {
+		return err
+	}
+	r.DBPolicy = policy
+	r.UpdatedAt = utils.GetDateUnix()
+	return nil
+}
+
+// GetDBPolicy 获取数据库访问策略，未配置时返回 nil
+func (r *Rule) GetDBPolicy() (*lua_engine.DBPolicy, error) {
+	return lua_engine.ParseDBPolicy(r.DBPolicy)
+}
+
 // SetFormula 设置计算公式
 func (r *Rule) SetFormula(formula string, vars map[string]interface{}) error {
 	if r.Type != "formula" {
@@ -286,6 +319,33 @@ func (r *Rule) GetFormulaVars() (map[string]interface{}, error) {
 	return vars, nil
 }
 
+// GetFormulaVarDefs 获取公式变量定义
+func (r *Rule) GetFormulaVarDefs() (formula.VarDefs, error) {
+	vars, err := r.GetFormulaVars()
+	if err != nil {
+		return nil, err
+	}
+	return formula.ParseVarDefs(vars)
+}
+
+// CompileFormula 编译计算公式并解析变量定义
+func (r *Rule) CompileFormula() (*formula.Expression, formula.VarDefs, error) {
+	expr, err := formula.Compile(r.Formula)
+	if err != nil {
+		return nil, nil, err
+	}
+	defs, err := r.GetFormulaVarDefs()
+	if err != nil {
+		return nil, nil, err
+	}
+	return expr, defs, nil
+}
+
+// CompileDecisionTable 解析决策表
+func (r *Rule) CompileDecisionTable() (*CompiledDecisionTable, error) {
+	return ParseDecisionTableJSON(r.DecisionTable)
+}
+
 // SetAction 设置规则动作
 func (r *Rule) SetAction(action string) error {
 	r.Action = action
@@ -330,7 +390,7 @@ func (r *Rule) Validate() error {
 	}
 
 	// 验证规则类型
-	validTypes := []string{"condition", "lua", "formula"}
+	validTypes := []string{"condition", "lua", "formula", "decision_table"}
 	isValidType := false
 	for _, validType := range validTypes {
 		if r.Type == validType {
@@ -360,19 +420,30 @@ func (r *Rule) Validate() error {
 	// 根据类型验证内容
 	switch r.Type {
 	case "condition":
-		if _, err := r.GetConditions(); err != nil {
+		if _, err := ParseConditionTreeJSON(r.Conditions); err != nil {
 			return fmt.Errorf("invalid conditions: %v", err)
 		}
 	case "lua":
 		if r.LuaScript == "" {
 			return fmt.Errorf("lua script cannot be empty for lua rule")
 		}
+		if _, err := r.GetDBPolicy(); err != nil {
+			return fmt.Errorf("invalid db policy: %v", err)
+		}
 	case "formula":
 		if r.Formula == "" {
 			return fmt.Errorf("formula cannot be empty for formula rule")
 		}
-		if _, err := r.GetFormulaVars(); err != nil {
-			return fmt.Errorf("invalid formula vars: %v", err)
+		if _, _, err := r.CompileFormula(); err != nil {
+			return fmt.Errorf("invalid formula: %v", err)
+		}
+	case "decision_table":
+		table, err := r.CompileDecisionTable()
+		if err != nil {
+			return fmt.Errorf("invalid decision table: %v", err)
+		}
+		if err := table.Table.Validate(); err != nil {
+			return fmt.Errorf("invalid decision table: %v", err)
 		}
 	}
 
@@ -400,6 +471,12 @@ func (r *Rule) Update(name, description string) {
 	r.UpdatedAt = utils.GetDateUnix()
 }
 
+// ChangeCode 修改规则编码，编码不属于版本内容，修改后立即生效
+func (r *Rule) ChangeCode(code string) {
+	r.Code = code
+	r.UpdatedAt = utils.GetDateUnix()
+}
+
 // SetPriority 设置优先级
 func (r *Rule) SetPriority(priority int32) {
 	r.Priority = priority
@@ -459,6 +536,23 @@ func (r *Rule) ApplyTemplate(template *RuleTemplate) error {
 	return nil
 }
 
+// SetPublishedVersion 设置线上版本
+func (r *Rule) SetPublishedVersion(version *RuleVersion) {
+	version.ApplyTo(r)
+	r.PublishedVersion = version.Version
+	r.Version = fmt.Sprint(version.Version)
+	r.UpdatedAt = utils.GetDateUnix()
+}
+
+// WithVersion 返回应用了指定版本内容的规则副本，用于按版本执行
+func (r *Rule) WithVersion(version *RuleVersion) *Rule {
+	pinned := *r
+	version.ApplyTo(&pinned)
+	pinned.PublishedVersion = version.Version
+	pinned.Version = fmt.Sprint(version.Version)
+	return &pinned
+}
+
 // IsTriggered 检查是否触发
 func (r *Rule) IsTriggered(trigger string) bool {
 	triggers := r.GetTriggers()
@@ -0,0 +1,65 @@
+package model
+
+// 规则链执行策略
+const (
+	ChainStrategyAll           = "all"            // 依次执行所有规则，第一个失败时中断（默认）
+	ChainStrategyFirstMatch    = "first_match"    // 第一个通过的规则决定结果，其余规则不再执行
+	ChainStrategyCollect       = "collect"        // 执行所有规则不中断，收集每个规则的输出
+	ChainStrategyDenyOverrides = "deny_overrides" // 执行所有规则，任一规则失败或拒绝时结果为拒绝
+)
+
+// IsValidChainStrategy 是否为有效的执行策略，空值使用默认策略
+func IsValidChainStrategy(strategy string) bool {
+	switch strategy {
+	case "", ChainStrategyAll, ChainStrategyFirstMatch, ChainStrategyCollect, ChainStrategyDenyOverrides:
+		return true
+	}
+	return false
+}
+
+// 规则动作执行状态
+const (
+	ActionStatusExecuted = "executed" // 已执行
+	ActionStatusFailed   = "failed"   // 执行失败
+	ActionStatusPending  = "pending"  // 没有注册处理器，由调用方处理
+	ActionStatusSkipped  = "skipped"  // 模拟执行或最终结果为拒绝时跳过
+)
+
+// IsDecisionAction 是否为只表示决策结果的动作，这些动作不需要执行
+func IsDecisionAction(action string) bool {
+	return action == "" || action == "allow" || action == "deny"
+}
+
+// RuleAction 规则通过后产生的动作
+type RuleAction struct {
+	RuleID   string                 `json:"ruleId"`          // 规则ID
+	RuleCode string                 `json:"ruleCode"`        // 规则编码
+	Type     string                 `json:"type"`            // 动作类型：modify notify redirect 等
+	Params   map[string]interface{} `json:"params"`          // 动作参数，即规则的输出
+	Status   string                 `json:"status"`          // 执行状态
+	Error    string                 `json:"error,omitempty"` // 执行失败时的错误信息
+}
+
+// NewRuleAction 创建规则动作
+func NewRuleAction(rule *Rule, action string, params map[string]interface{}) *RuleAction {
+	return &RuleAction{
+		RuleID:   rule.ID,
+		RuleCode: rule.Code,
+		Type:     action,
+		Params:   params,
+		Status:   ActionStatusPending,
+	}
+}
+
+// AddAction 添加规则动作
+func (rr *RuleResult) AddAction(actions ...*RuleAction) {
+	rr.Actions = append(rr.Actions, actions...)
+}
+
+// AddOutput 记录规则的输出，collect 策略下按规则编码收集
+func (rr *RuleResult) AddOutput(ruleCode string, output map[string]interface{}) {
+	if rr.Outputs == nil {
+		rr.Outputs = make(map[string]map[string]interface{})
+	}
+	rr.Outputs[ruleCode] = output
+}
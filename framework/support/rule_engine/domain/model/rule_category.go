@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/lua_engine"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 )
 
@@ -25,7 +26,9 @@ type RuleCategory struct {
 	IsLeaf bool  `json:"isLeaf"` // 是否为叶子节点
 
 	// 业务配置
-	BusinessType string `json:"businessType"` // 业务类型：order(订单) user(用户) product(商品) payment(支付) withdrawal(提现) declaration(申报)
+	BusinessType      string `json:"businessType"`      // 业务类型：order(订单) user(用户) product(商品) payment(支付) withdrawal(提现) declaration(申报)
+	DBPolicy          string `json:"dbPolicy"`          // 分类下Lua规则默认的数据库访问策略(JSON格式)
+	ExecutionStrategy string `json:"executionStrategy"` // 分类下规则链的执行策略：all first_match collect deny_overrides，为空时使用 all
 
 	// 时间信息
 	CreatedAt int64 `json:"createdAt"` // 创建时间
@@ -105,6 +108,19 @@ func (rc *RuleCategory) SetSorting(sorting int32) {
 	rc.UpdatedAt = utils.GetDateUnix()
 }
 
+// GetExecutionStrategy 获取规则链执行策略，未配置时返回 all
+func (rc *RuleCategory) GetExecutionStrategy() string {
+	if rc.ExecutionStrategy == "" {
+		return ChainStrategyAll
+	}
+	return rc.ExecutionStrategy
+}
+
+// GetDBPolicy 获取数据库访问策略，未配置时返回 nil
+func (rc *RuleCategory) GetDBPolicy() (*lua_engine.DBPolicy, error) {
+	return lua_engine.ParseDBPolicy(rc.DBPolicy)
+}
+
 // Validate 验证分类
 func (rc *RuleCategory) Validate() error {
 	if rc.Code == "" {
@@ -143,6 +159,14 @@ func (rc *RuleCategory) Validate() error {
 		return fmt.Errorf("invalid business type: %s", rc.BusinessType)
 	}
 
+	if _, err := rc.GetDBPolicy(); err != nil {
+		return fmt.Errorf("invalid db policy: %v", err)
+	}
+
+	if !IsValidChainStrategy(rc.ExecutionStrategy) {
+		return fmt.Errorf("invalid execution strategy: %s", rc.ExecutionStrategy)
+	}
+
 	return nil
 }
 
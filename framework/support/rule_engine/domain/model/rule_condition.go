@@ -0,0 +1,296 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 条件节点类型
+const (
+	ConditionNodeAll  = "all"  // 所有子条件都满足
+	ConditionNodeAny  = "any"  // 任一子条件满足
+	ConditionNodeNot  = "not"  // 子条件不满足
+	ConditionNodeLeaf = "leaf" // 字段比较
+)
+
+// 条件值类型
+const (
+	ConditionValueAuto   = ""       // 自动识别
+	ConditionValueNumber = "number" // 数字
+	ConditionValueString = "string" // 字符串
+	ConditionValueDate   = "date"   // 日期时间，支持日期字符串和Unix时间戳(秒)
+	ConditionValueBool   = "bool"   // 布尔值
+)
+
+// MaxConditionDepth 条件树最大深度
+const MaxConditionDepth = 32
+
+// 条件操作符及其对值的要求
+var conditionOperators = map[string]string{
+	"eq":           "value",
+	"neq":          "value",
+	"ne":           "value",
+	"gt":           "value",
+	"gte":          "value",
+	"lt":           "value",
+	"lte":          "value",
+	"in":           "list",
+	"nin":          "list",
+	"not_in":       "list",
+	"between":      "range",
+	"contains":     "value",
+	"not_contains": "value",
+	"starts_with":  "value",
+	"ends_with":    "value",
+	"regex":        "regex",
+	"is_null":      "none",
+	"not_null":     "none",
+	"is_empty":     "none",
+	"not_empty":    "none",
+	// 数组量词，where 为数组元素需要满足的条件
+	"any_item":  "where",
+	"all_items": "where",
+	"no_items":  "where",
+}
+
+// 条件值类型
+var conditionValueTypes = map[string]bool{
+	ConditionValueAuto: true, ConditionValueNumber: true, ConditionValueString: true, ConditionValueDate: true, ConditionValueBool: true,
+}
+
+// ConditionNode 条件树节点
+// 分组节点使用 all、any、not 之一，叶子节点使用 field、operator、value
+//
+//	{"all": [
+//	    {"field": "user.age", "operator": "gte", "value": 18},
+//	    {"any": [
+//	        {"field": "user.level", "operator": "in", "value": ["gold", "vip"]},
+//	        {"not": {"field": "order.coupon", "operator": "is_null"}}
+//	    ]},
+//	    {"field": "order.items", "operator": "any_item", "where": {"field": "price", "operator": "gt", "value": 100}}
+//	]}
+type ConditionNode struct {
+	All      []*ConditionNode `json:"all,omitempty"`       // 所有子条件都满足
+	Any      []*ConditionNode `json:"any,omitempty"`       // 任一子条件满足
+	Not      *ConditionNode   `json:"not,omitempty"`       // 子条件不满足
+	Field    string           `json:"field,omitempty"`     // 字段路径，如 order.amount；数组量词的 where 中相对数组元素取值，$item 为元素本身，$root. 开头时从根数据取值
+	Operator string           `json:"operator,omitempty"`  // 操作符
+	Value    interface{}      `json:"value,omitempty"`     // 比较值
+	Type     string           `json:"valueType,omitempty"` // 值类型：number string date bool，为空时自动识别
+	Where    *ConditionNode   `json:"where,omitempty"`     // 数组量词的元素条件
+	Label    string           `json:"label,omitempty"`     // 节点说明，展示在执行轨迹中
+}
+
+// Kind 节点类型
+func (n *ConditionNode) Kind() string {
+	switch {
+	case n.All != nil:
+		return ConditionNodeAll
+	case n.Any != nil:
+		return ConditionNodeAny
+	case n.Not != nil:
+		return ConditionNodeNot
+	}
+	return ConditionNodeLeaf
+}
+
+// Validate 校验条件树结构、操作符和比较值
+func (n *ConditionNode) Validate() error {
+	return n.validate("root", 1)
+}
+
+func (n *ConditionNode) validate(path string, depth int) error {
+	if n == nil {
+		return fmt.Errorf("%s: condition cannot be empty", path)
+	}
+	if depth > MaxConditionDepth {
+		return fmt.Errorf("%s: condition tree exceeds max depth %d", path, MaxConditionDepth)
+	}
+
+	groups := 0
+	for _, set := range []bool{n.All != nil, n.Any != nil, n.Not != nil} {
+		if set {
+			groups++
+		}
+	}
+	if groups > 1 || groups == 1 && (n.Field != "" || n.Operator != "") {
+		return fmt.Errorf("%s: node must be exactly one of all, any, not or field condition", path)
+	}
+
+	switch n.Kind() {
+	case ConditionNodeAll, ConditionNodeAny:
+		children := n.All
+		if n.Kind() == ConditionNodeAny {
+			children = n.Any
+		}
+		if len(children) == 0 {
+			return fmt.Errorf("%s.%s: group cannot be empty", path, n.Kind())
+		}
+		for i, child := range children {
+			if err := child.validate(fmt.Sprintf("%s.%s[%d]", path, n.Kind(), i), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ConditionNodeNot:
+		return n.Not.validate(path+".not", depth+1)
+	}
+
+	if n.Field == "" {
+		return fmt.Errorf("%s: field cannot be empty", path)
+	}
+	require, ok := conditionOperators[n.Operator]
+	if !ok {
+		return fmt.Errorf("%s: invalid operator: %s", path, n.Operator)
+	}
+	if !conditionValueTypes[n.Type] {
+		return fmt.Errorf("%s: invalid value type: %s", path, n.Type)
+	}
+	if require != "where" && n.Where != nil {
+		return fmt.Errorf("%s: where is only allowed for array operators", path)
+	}
+	switch require {
+	case "value":
+		if n.Value == nil {
+			return fmt.Errorf("%s: operator %s requires value", path, n.Operator)
+		}
+	case "list":
+		if _, ok := n.Value.([]interface{}); !ok {
+			if s, ok := n.Value.(string); !ok || s == "" {
+				return fmt.Errorf("%s: operator %s requires a list value", path, n.Operator)
+			}
+		}
+	case "range":
+		if bounds, ok := n.Value.([]interface{}); ok {
+			if len(bounds) != 2 {
+				return fmt.Errorf("%s: operator between requires two values", path)
+			}
+		} else if s, ok := n.Value.(string); !ok || len(strings.Split(s, ",")) != 2 {
+			return fmt.Errorf("%s: operator between requires two values", path)
+		}
+	case "regex":
+		pattern, ok := n.Value.(string)
+		if !ok {
+			return fmt.Errorf("%s: operator regex requires a string pattern", path)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s: invalid regex: %v", path, err)
+		}
+	case "where":
+		return n.Where.validate(path+".where", depth+1)
+	}
+	return nil
+}
+
+// ParseConditionTree 解析规则的条件配置
+// 支持三种格式：条件树本身；{"type": ..., "root": 条件树}；
+// 旧的扁平格式，其中所有包含 field 的对象都必须满足
+func ParseConditionTree(conditions map[string]interface{}) (*ConditionNode, error) {
+	raw := conditions
+	if root, ok := conditions["root"].(map[string]interface{}); ok {
+		raw = root
+	} else if !isConditionNode(conditions) {
+		raw = legacyConditionTree(conditions)
+		if raw == nil {
+			return nil, nil
+		}
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid conditions format: %v", err)
+	}
+	var node ConditionNode
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&node); err != nil {
+		return nil, fmt.Errorf("invalid conditions format: %v", err)
+	}
+	if err := node.Validate(); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// ParseConditionTreeJSON 解析JSON格式的条件配置，未配置条件时返回 nil
+func ParseConditionTreeJSON(conditions string) (*ConditionNode, error) {
+	if strings.TrimSpace(conditions) == "" {
+		return nil, nil
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(conditions), &raw); err != nil {
+		return nil, fmt.Errorf("invalid conditions format: %v", err)
+	}
+	return ParseConditionTree(raw)
+}
+
+// isConditionNode 是否为条件树节点
+func isConditionNode(raw map[string]interface{}) bool {
+	for _, key := range []string{"all", "any", "not", "field"} {
+		if _, ok := raw[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyConditionTree 将旧的扁平条件转换为 all 分组，没有条件时返回 nil
+func legacyConditionTree(conditions map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(conditions))
+	for key := range conditions {
+		keys = append(keys, key)
+	}
+	// 按键排序保证执行轨迹稳定
+	sort.Strings(keys)
+	var children []interface{}
+	for _, key := range keys {
+		if condition, ok := conditions[key].(map[string]interface{}); ok && isConditionNode(condition) {
+			children = append(children, condition)
+		}
+	}
+	if len(children) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"all": children}
+}
+
+// ConditionTrace 条件节点的执行结果
+type ConditionTrace struct {
+	Node     string            `json:"node"`               // 节点类型：all any not leaf
+	Label    string            `json:"label,omitempty"`    // 节点说明
+	Field    string            `json:"field,omitempty"`    // 字段路径
+	Operator string            `json:"operator,omitempty"` // 操作符
+	Expected interface{}       `json:"expected,omitempty"` // 比较值
+	Actual   interface{}       `json:"actual,omitempty"`   // 实际值
+	Matched  bool              `json:"matched"`            // 是否满足
+	Reason   string            `json:"reason,omitempty"`   // 不满足或无法比较的原因
+	Index    *int              `json:"index,omitempty"`    // 数组量词中决定结果的元素下标
+	Children []*ConditionTrace `json:"children,omitempty"` // 子节点的执行结果
+}
+
+// FailedField 第一个导致条件不满足的字段
+func (t *ConditionTrace) FailedField() string {
+	if t == nil || t.Matched {
+		return ""
+	}
+	switch t.Node {
+	case ConditionNodeLeaf:
+		return t.Field
+	case ConditionNodeNot:
+		// not 不满足是因为子条件满足，返回子条件的第一个字段
+		for child := t.Children[0]; ; child = child.Children[0] {
+			if child.Field != "" || len(child.Children) == 0 {
+				return child.Field
+			}
+		}
+	}
+	for _, child := range t.Children {
+		if field := child.FailedField(); field != "" {
+			return field
+		}
+	}
+	return ""
+}
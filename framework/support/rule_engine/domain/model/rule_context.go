@@ -7,6 +7,7 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
 	"github.com/flare-admin/flare-server-go/framework/pkg/lua_engine"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"time"
 )
 
 // RuleContext 规则执行上下文
@@ -84,6 +85,21 @@ type RuleResult struct {
 	// 规则执行链路
 	ExecutionChain []*RuleExecutionStep `json:"executionChain"` // 规则执行链路
 
+	// 规则版本
+	RuleVersion int32 `json:"ruleVersion,omitempty"` // 单个规则执行时实际执行的版本号
+
+	// 数据库访问记录
+	DBCalls []lua_engine.DBCallRecord `json:"dbCalls,omitempty"` // 单个规则执行时的数据库访问记录
+
+	// 条件执行轨迹
+	ConditionTrace *ConditionTrace `json:"conditionTrace,omitempty"` // 单个条件规则执行时各条件节点的结果
+
+	// 规则动作
+	Actions []*RuleAction `json:"actions,omitempty"` // 通过的规则产生的动作及执行状态
+
+	// 规则输出
+	Outputs map[string]map[string]interface{} `json:"outputs,omitempty"` // collect 策略下各规则的输出，键为规则编码
+
 	// 租户信息
 	TenantID string `json:"tenantId"` // 租户ID
 }
@@ -96,6 +112,8 @@ type RuleExecutionStep struct {
 	RuleName string `json:"ruleName"` // 规则名称
 	Priority int32  `json:"priority"` // 优先级
 
+	RuleVersion int32 `json:"ruleVersion"` // 实际执行的版本号，0 表示规则没有版本记录
+
 	// 执行输入
 	Input map[string]interface{} `json:"input"` // 执行输入数据
 
@@ -108,6 +126,14 @@ type RuleExecutionStep struct {
 	Error       string `json:"error"`       // 错误信息
 	ExecuteTime int64  `json:"executeTime"` // 执行时间(毫秒)
 
+	Elapsed time.Duration `json:"-"` // 实际执行耗时，用于监控指标
+
+	// 数据库访问记录
+	DBCalls []lua_engine.DBCallRecord `json:"dbCalls,omitempty"` // 规则脚本的数据库访问记录
+
+	// 条件执行轨迹
+	ConditionTrace *ConditionTrace `json:"conditionTrace,omitempty"` // 条件规则各条件节点的结果
+
 	// 执行时间
 	ExecuteAt int64 `json:"executeAt"` // 执行时间戳
 }
@@ -0,0 +1,945 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/formula"
+)
+
+// 决策表命中策略
+const (
+	DecisionHitFirst    = "first"    // 按行顺序取第一个命中的行
+	DecisionHitUnique   = "unique"   // 最多命中一行，行之间不允许重叠
+	DecisionHitCollect  = "collect"  // 取所有命中的行，输出为数组
+	DecisionHitPriority = "priority" // 取优先级最高的命中行，优先级相同的行不允许重叠
+)
+
+// 决策表列的数据类型
+const (
+	DecisionTypeNumber = "number" // 数字，支持比较和区间
+	DecisionTypeString = "string" // 字符串，支持集合
+	DecisionTypeBool   = "bool"   // 布尔值
+)
+
+// 决策表检查结果类型
+const (
+	DecisionIssueOverlap = "overlap" // 多行命中同一组输入
+	DecisionIssueGap     = "gap"     // 存在没有任何行命中的输入
+)
+
+// DecisionAny 匹配任意值的单元格，空单元格与其相同
+const DecisionAny = "-"
+
+const (
+	// MaxDecisionTableCells 检查遗漏时最多枚举的输入组合数量
+	MaxDecisionTableCells = 100000
+	// MaxDecisionTableGaps 最多报告的遗漏数量
+	MaxDecisionTableGaps = 20
+)
+
+var decisionHitPolicies = map[string]bool{
+	DecisionHitFirst: true, DecisionHitUnique: true, DecisionHitCollect: true, DecisionHitPriority: true,
+}
+
+var decisionTypes = map[string]bool{
+	DecisionTypeNumber: true, DecisionTypeString: true, DecisionTypeBool: true,
+}
+
+// DecisionTable 决策表
+// 每行的输入单元格全部匹配时命中该行，按命中策略输出命中行的输出单元格。单元格写法：
+//   - 空或 -: 任意值
+//   - 数字: 100、=100、>100、>=100、<100、<=100，区间 [100,500)、(100,]，集合 1,2,3
+//   - 字符串: gold，集合 gold,vip，包含逗号的值使用双引号 "a,b"
+//   - 布尔值: true、false、是、否
+//   - ! 开头表示取反，如 !gold,vip、![0,100)
+type DecisionTable struct {
+	HitPolicy string            `json:"hitPolicy"` // 命中策略：first unique collect priority，为空时使用 first
+	Inputs    []*DecisionColumn `json:"inputs"`    // 输入列
+	Outputs   []*DecisionColumn `json:"outputs"`   // 输出列
+	Rows      []*DecisionRow    `json:"rows"`      // 规则行
+}
+
+// DecisionColumn 决策表的列
+type DecisionColumn struct {
+	Name    string `json:"name"`              // 列名称，Excel 中的表头
+	Field   string `json:"field"`             // 输入列为取值路径，如 order.amount；输出列为输出变量名
+	Type    string `json:"type"`              // 数据类型：number string bool
+	Domain  string `json:"domain,omitempty"`  // 输入列的取值范围，写法与单元格相同，检查遗漏时只检查该范围
+	Default string `json:"default,omitempty"` // 输出列的默认值，任一输出列配置了默认值时，没有命中的执行使用默认值并视为通过
+}
+
+// DecisionRow 决策表的行
+type DecisionRow struct {
+	Inputs      []string `json:"inputs"`                // 输入单元格，与输入列一一对应
+	Outputs     []string `json:"outputs"`               // 输出单元格，与输出列一一对应
+	Priority    int32    `json:"priority,omitempty"`    // 优先级，priority 策略下数字越大优先级越高
+	Description string   `json:"description,omitempty"` // 说明
+}
+
+// DecisionTableIssue 决策表检查发现的问题，行号从 1 开始
+type DecisionTableIssue struct {
+	Type    string `json:"type"`           // 问题类型：overlap gap
+	Rows    []int  `json:"rows,omitempty"` // 相关的行号
+	Message string `json:"message"`        // 说明
+}
+
+// DecisionTableReport 决策表检查报告
+type DecisionTableReport struct {
+	Issues    []*DecisionTableIssue `json:"issues"`    // 发现的问题，不影响保存
+	Gaps      int                   `json:"gaps"`      // 没有命中任何行的输入组合数量
+	Truncated bool                  `json:"truncated"` // 输入组合超过上限，未检查遗漏
+}
+
+// ParseDecisionTableJSON 解析并校验JSON格式的决策表
+func ParseDecisionTableJSON(data string) (*CompiledDecisionTable, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, fmt.Errorf("decision table cannot be empty")
+	}
+	var table DecisionTable
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		return nil, fmt.Errorf("invalid decision table format: %v", err)
+	}
+	return table.Compile()
+}
+
+// ToJSON 序列化决策表
+func (t *DecisionTable) ToJSON() (string, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Compile 校验决策表结构并解析所有单元格
+// 不检查行之间的重叠，重叠由 Validate 检查
+func (t *DecisionTable) Compile() (*CompiledDecisionTable, error) {
+	if t.HitPolicy == "" {
+		t.HitPolicy = DecisionHitFirst
+	}
+	if !decisionHitPolicies[t.HitPolicy] {
+		return nil, fmt.Errorf("invalid hit policy: %s", t.HitPolicy)
+	}
+	if len(t.Inputs) == 0 {
+		return nil, fmt.Errorf("decision table requires at least one input column")
+	}
+	if len(t.Outputs) == 0 {
+		return nil, fmt.Errorf("decision table requires at least one output column")
+	}
+	if len(t.Rows) == 0 {
+		return nil, fmt.Errorf("decision table requires at least one row")
+	}
+
+	c := &CompiledDecisionTable{Table: t}
+	names := make(map[string]bool)
+	fields := make(map[string]bool)
+	for i, col := range t.Inputs {
+		if err := validateDecisionColumn(col, fmt.Sprintf("input[%d]", i), names, fields); err != nil {
+			return nil, err
+		}
+		var domain *DecisionCell
+		if strings.TrimSpace(col.Domain) != "" {
+			cell, err := ParseDecisionCell(col.Type, col.Domain)
+			if err != nil {
+				return nil, fmt.Errorf("input %s: invalid domain: %v", col.Name, err)
+			}
+			domain = cell
+		}
+		c.domains = append(c.domains, domain)
+	}
+	fields = make(map[string]bool)
+	for i, col := range t.Outputs {
+		if err := validateDecisionColumn(col, fmt.Sprintf("output[%d]", i), names, fields); err != nil {
+			return nil, err
+		}
+		value, err := ParseDecisionValue(col.Type, col.Default)
+		if err != nil {
+			return nil, fmt.Errorf("output %s: invalid default: %v", col.Name, err)
+		}
+		c.defaults = append(c.defaults, value)
+		c.hasDefault = c.hasDefault || value != nil
+	}
+
+	for i, row := range t.Rows {
+		line := i + 1
+		if row == nil {
+			return nil, fmt.Errorf("row %d cannot be empty", line)
+		}
+		if len(row.Inputs) != len(t.Inputs) {
+			return nil, fmt.Errorf("row %d: expected %d input cells, got %d", line, len(t.Inputs), len(row.Inputs))
+		}
+		if len(row.Outputs) != len(t.Outputs) {
+			return nil, fmt.Errorf("row %d: expected %d output cells, got %d", line, len(t.Outputs), len(row.Outputs))
+		}
+		cells := make([]*DecisionCell, len(row.Inputs))
+		for j, text := range row.Inputs {
+			cell, err := ParseDecisionCell(t.Inputs[j].Type, text)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, input %s: %v", line, t.Inputs[j].Name, err)
+			}
+			cells[j] = cell
+		}
+		outputs := make([]interface{}, len(row.Outputs))
+		for j, text := range row.Outputs {
+			value, err := ParseDecisionValue(t.Outputs[j].Type, text)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, output %s: %v", line, t.Outputs[j].Name, err)
+			}
+			outputs[j] = value
+		}
+		c.cells = append(c.cells, cells)
+		c.outputs = append(c.outputs, outputs)
+	}
+	return c, nil
+}
+
+// Validate 校验决策表，unique 策略下存在重叠的行，或 priority 策略下优先级相同的行重叠时返回错误
+func (t *DecisionTable) Validate() error {
+	c, err := t.Compile()
+	if err != nil {
+		return err
+	}
+	if t.HitPolicy != DecisionHitUnique && t.HitPolicy != DecisionHitPriority {
+		return nil
+	}
+	for _, pair := range c.overlaps(c.atoms()) {
+		a, b := t.Rows[pair[0]], t.Rows[pair[1]]
+		if t.HitPolicy == DecisionHitUnique || a.Priority == b.Priority {
+			return fmt.Errorf("rows %d and %d overlap under %s hit policy", pair[0]+1, pair[1]+1, t.HitPolicy)
+		}
+	}
+	return nil
+}
+
+func validateDecisionColumn(col *DecisionColumn, path string, names, fields map[string]bool) error {
+	if col == nil {
+		return fmt.Errorf("%s: column cannot be empty", path)
+	}
+	if col.Name == "" {
+		return fmt.Errorf("%s: column name cannot be empty", path)
+	}
+	if names[col.Name] {
+		return fmt.Errorf("%s: duplicate column name: %s", path, col.Name)
+	}
+	names[col.Name] = true
+	if col.Field == "" {
+		return fmt.Errorf("%s: column field cannot be empty", path)
+	}
+	if fields[col.Field] {
+		return fmt.Errorf("%s: duplicate column field: %s", path, col.Field)
+	}
+	fields[col.Field] = true
+	if !decisionTypes[col.Type] {
+		return fmt.Errorf("%s: invalid column type: %s", path, col.Type)
+	}
+	return nil
+}
+
+// CompiledDecisionTable 解析后的决策表
+type CompiledDecisionTable struct {
+	Table      *DecisionTable
+	cells      [][]*DecisionCell // 每行的输入单元格
+	outputs    [][]interface{}   // 每行的输出值
+	domains    []*DecisionCell   // 输入列的取值范围，未限定时为 nil
+	defaults   []interface{}     // 输出列的默认值
+	hasDefault bool
+}
+
+// DecisionMatch 决策表的命中结果
+type DecisionMatch struct {
+	Rows    []int                  // 命中的行号，从 1 开始
+	Outputs map[string]interface{} // 输出变量，collect 策略下为数组
+	Default bool                   // 没有命中任何行，使用了默认值
+}
+
+// Match 按命中策略匹配输入，lookup 按输入列的取值路径取值
+// 没有命中且未配置默认值时返回 nil；unique 策略下命中多行时返回错误
+func (c *CompiledDecisionTable) Match(lookup func(field string) (interface{}, bool)) (*DecisionMatch, error) {
+	values := make([]interface{}, len(c.Table.Inputs))
+	for i, col := range c.Table.Inputs {
+		value, ok := lookup(col.Field)
+		if !ok {
+			continue
+		}
+		converted, err := decisionInputValue(col.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("输入 %s: %v", col.Name, err)
+		}
+		values[i] = converted
+	}
+
+	var hits []int
+	for i, cells := range c.cells {
+		matched := true
+		for j, cell := range cells {
+			if !cell.match(values[j]) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		hits = append(hits, i)
+		if c.Table.HitPolicy == DecisionHitFirst {
+			break
+		}
+	}
+
+	if len(hits) == 0 {
+		if !c.hasDefault {
+			return nil, nil
+		}
+		return &DecisionMatch{Outputs: c.rowOutputs(c.defaults), Default: true}, nil
+	}
+
+	switch c.Table.HitPolicy {
+	case DecisionHitUnique:
+		if len(hits) > 1 {
+			return nil, fmt.Errorf("unique 策略下命中了多行: %v", rowNumbers(hits))
+		}
+	case DecisionHitPriority:
+		best := hits[0]
+		for _, i := range hits[1:] {
+			if c.Table.Rows[i].Priority > c.Table.Rows[best].Priority {
+				best = i
+			}
+		}
+		hits = []int{best}
+	case DecisionHitCollect:
+		outputs := make(map[string]interface{}, len(c.Table.Outputs))
+		for j, col := range c.Table.Outputs {
+			list := make([]interface{}, 0, len(hits))
+			for _, i := range hits {
+				list = append(list, formula.ToNative(c.outputs[i][j]))
+			}
+			outputs[col.Field] = list
+		}
+		return &DecisionMatch{Rows: rowNumbers(hits), Outputs: outputs}, nil
+	}
+	return &DecisionMatch{Rows: rowNumbers(hits), Outputs: c.rowOutputs(c.outputs[hits[0]])}, nil
+}
+
+func (c *CompiledDecisionTable) rowOutputs(values []interface{}) map[string]interface{} {
+	outputs := make(map[string]interface{}, len(values))
+	for j, col := range c.Table.Outputs {
+		outputs[col.Field] = formula.ToNative(values[j])
+	}
+	return outputs
+}
+
+func rowNumbers(rows []int) []int {
+	numbers := make([]int, len(rows))
+	for i, row := range rows {
+		numbers[i] = row + 1
+	}
+	return numbers
+}
+
+// Analyze 检查行之间的重叠和没有任何行命中的输入组合
+// collect 策略允许重叠，不报告重叠
+func (c *CompiledDecisionTable) Analyze() *DecisionTableReport {
+	report := &DecisionTableReport{Issues: make([]*DecisionTableIssue, 0)}
+	atoms := c.atoms()
+
+	if c.Table.HitPolicy != DecisionHitCollect {
+		for _, pair := range c.overlaps(atoms) {
+			a, b := pair[0]+1, pair[1]+1
+			message := fmt.Sprintf("第 %d 行和第 %d 行存在相同的输入", a, b)
+			switch c.Table.HitPolicy {
+			case DecisionHitFirst:
+				message += fmt.Sprintf("，重叠部分只会命中第 %d 行", a)
+			case DecisionHitPriority:
+				message += "，重叠部分命中优先级高的行"
+			}
+			report.Issues = append(report.Issues, &DecisionTableIssue{Type: DecisionIssueOverlap, Rows: []int{a, b}, Message: message})
+		}
+	}
+
+	// 按列枚举取值区间的组合，检查是否有行覆盖
+	total := 1
+	for _, col := range atoms {
+		total *= len(col.atoms)
+		if total == 0 {
+			return report
+		}
+		if total > MaxDecisionTableCells {
+			report.Truncated = true
+			return report
+		}
+	}
+	index := make([]int, len(atoms))
+	words := (len(c.cells) + 63) / 64
+	covered := make([]uint64, words)
+	for {
+		copy(covered, atoms[0].rows[index[0]])
+		for k := 1; k < len(atoms); k++ {
+			for w, bits := range atoms[k].rows[index[k]] {
+				covered[w] &= bits
+			}
+		}
+		if !anyBit(covered) {
+			report.Gaps++
+			if report.Gaps <= MaxDecisionTableGaps {
+				parts := make([]string, len(atoms))
+				for k, col := range c.Table.Inputs {
+					parts[k] = fmt.Sprintf("%s %s", col.Name, atoms[k].atoms[index[k]].String())
+				}
+				report.Issues = append(report.Issues, &DecisionTableIssue{
+					Type:    DecisionIssueGap,
+					Message: "没有命中任何行: " + strings.Join(parts, ", "),
+				})
+			}
+		}
+		// 下一个组合
+		k := len(index) - 1
+		for ; k >= 0; k-- {
+			index[k]++
+			if index[k] < len(atoms[k].atoms) {
+				break
+			}
+			index[k] = 0
+		}
+		if k < 0 {
+			break
+		}
+	}
+	return report
+}
+
+// decisionColumnAtoms 输入列按所有单元格的边界划分出的取值区间，以及每个区间被哪些行覆盖
+type decisionColumnAtoms struct {
+	atoms []*decisionAtom
+	rows  [][]uint64 // rows[i] 为覆盖第 i 个区间的行的位图
+}
+
+// atoms 划分每个输入列的取值区间
+// 同一区间内的值对所有单元格的匹配结果相同，两行重叠当且仅当每列都存在同时覆盖的区间
+func (c *CompiledDecisionTable) atoms() []*decisionColumnAtoms {
+	result := make([]*decisionColumnAtoms, len(c.Table.Inputs))
+	words := (len(c.cells) + 63) / 64
+	for j := range c.Table.Inputs {
+		cells := make([]*DecisionCell, 0, len(c.cells)+1)
+		for _, row := range c.cells {
+			cells = append(cells, row[j])
+		}
+		if c.domains[j] != nil {
+			cells = append(cells, c.domains[j])
+		}
+		col := &decisionColumnAtoms{}
+		for _, atom := range splitDecisionAtoms(c.Table.Inputs[j].Type, cells) {
+			if c.domains[j] != nil && !c.domains[j].covers(atom) {
+				continue
+			}
+			bits := make([]uint64, words)
+			for i, row := range c.cells {
+				if row[j].covers(atom) {
+					bits[i/64] |= 1 << (i % 64)
+				}
+			}
+			col.atoms = append(col.atoms, atom)
+			col.rows = append(col.rows, bits)
+		}
+		result[j] = col
+	}
+	return result
+}
+
+// overlaps 返回所有重叠的行对，行下标从 0 开始
+func (c *CompiledDecisionTable) overlaps(atoms []*decisionColumnAtoms) [][2]int {
+	// 每行在每列覆盖的区间
+	covers := make([][][]bool, len(c.cells))
+	for i := range c.cells {
+		covers[i] = make([][]bool, len(atoms))
+		for j, col := range atoms {
+			covers[i][j] = make([]bool, len(col.atoms))
+			for k, bits := range col.rows {
+				covers[i][j][k] = bits[i/64]&(1<<(i%64)) != 0
+			}
+		}
+	}
+	var pairs [][2]int
+	for a := 0; a < len(c.cells); a++ {
+		for b := a + 1; b < len(c.cells); b++ {
+			overlap := true
+			for j := range atoms {
+				shared := false
+				for k := range covers[a][j] {
+					if covers[a][j][k] && covers[b][j][k] {
+						shared = true
+						break
+					}
+				}
+				if !shared {
+					overlap = false
+					break
+				}
+			}
+			if overlap {
+				pairs = append(pairs, [2]int{a, b})
+			}
+		}
+	}
+	return pairs
+}
+
+func anyBit(bits []uint64) bool {
+	for _, w := range bits {
+		if w != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DecisionCell 解析后的输入单元格
+type DecisionCell struct {
+	any    bool
+	negate bool
+	ranges []*decisionRange // 数字的区间和值
+	values map[string]bool  // 字符串和布尔值的集合
+	order  []string         // 集合中的值，按出现顺序
+}
+
+// decisionRange 数字区间，边界为 nil 时无界
+type decisionRange struct {
+	lower, upper         *big.Rat
+	lowerOpen, upperOpen bool
+}
+
+// ParseDecisionCell 按列的数据类型解析输入单元格
+func ParseDecisionCell(valueType, text string) (*DecisionCell, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || text == DecisionAny {
+		return &DecisionCell{any: true}, nil
+	}
+	cell := &DecisionCell{}
+	if strings.HasPrefix(text, "!") {
+		cell.negate = true
+		text = strings.TrimSpace(text[1:])
+		if text == "" {
+			return nil, fmt.Errorf("negation requires a value")
+		}
+	}
+
+	switch valueType {
+	case DecisionTypeNumber:
+		r, err := parseDecisionRanges(text)
+		if err != nil {
+			return nil, err
+		}
+		cell.ranges = r
+	case DecisionTypeString, DecisionTypeBool:
+		items, err := splitDecisionList(text)
+		if err != nil {
+			return nil, err
+		}
+		cell.values = make(map[string]bool, len(items))
+		for _, item := range items {
+			if valueType == DecisionTypeBool {
+				b, err := parseDecisionBool(item)
+				if err != nil {
+					return nil, err
+				}
+				item = strconv.FormatBool(b)
+			}
+			if !cell.values[item] {
+				cell.values[item] = true
+				cell.order = append(cell.order, item)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid column type: %s", valueType)
+	}
+	return cell, nil
+}
+
+// parseDecisionRanges 解析数字单元格：比较、区间或逗号分隔的集合
+func parseDecisionRanges(text string) ([]*decisionRange, error) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "(") {
+		if !strings.HasSuffix(text, "]") && !strings.HasSuffix(text, ")") {
+			return nil, fmt.Errorf("invalid range: %s", text)
+		}
+		bounds := strings.Split(text[1:len(text)-1], ",")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("range requires two bounds: %s", text)
+		}
+		r := &decisionRange{lowerOpen: text[0] == '(', upperOpen: text[len(text)-1] == ')'}
+		var err error
+		if r.lower, err = parseDecisionBound(bounds[0]); err != nil {
+			return nil, err
+		}
+		if r.upper, err = parseDecisionBound(bounds[1]); err != nil {
+			return nil, err
+		}
+		if r.lower != nil && r.upper != nil {
+			c := r.lower.Cmp(r.upper)
+			if c > 0 || c == 0 && (r.lowerOpen || r.upperOpen) {
+				return nil, fmt.Errorf("empty range: %s", text)
+			}
+		}
+		return []*decisionRange{r}, nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if !strings.HasPrefix(text, op) {
+			continue
+		}
+		n, err := parseDecisionNumber(text[len(op):])
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case ">=":
+			return []*decisionRange{{lower: n}}, nil
+		case "<=":
+			return []*decisionRange{{upper: n}}, nil
+		case ">":
+			return []*decisionRange{{lower: n, lowerOpen: true}}, nil
+		case "<":
+			return []*decisionRange{{upper: n, upperOpen: true}}, nil
+		}
+		return []*decisionRange{{lower: n, upper: n}}, nil
+	}
+
+	var ranges []*decisionRange
+	for _, item := range strings.Split(text, ",") {
+		n, err := parseDecisionNumber(item)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, &decisionRange{lower: n, upper: n})
+	}
+	return ranges, nil
+}
+
+func parseDecisionBound(text string) (*big.Rat, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return parseDecisionNumber(text)
+}
+
+func parseDecisionNumber(text string) (*big.Rat, error) {
+	text = strings.TrimSpace(text)
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %s", text)
+	}
+	return r, nil
+}
+
+func parseDecisionBool(text string) (bool, error) {
+	switch strings.TrimSpace(text) {
+	case "是":
+		return true, nil
+	case "否":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(text))
+	if err != nil {
+		return false, fmt.Errorf("invalid bool: %s", text)
+	}
+	return b, nil
+}
+
+// splitDecisionList 按逗号分隔集合，双引号内的逗号不分隔
+func splitDecisionList(text string) ([]string, error) {
+	var (
+		items   []string
+		current strings.Builder
+		quoted  bool
+		inQuote bool
+	)
+	flush := func() {
+		item := current.String()
+		if !quoted {
+			item = strings.TrimSpace(item)
+		}
+		items = append(items, item)
+		current.Reset()
+		quoted = false
+	}
+	for _, ch := range text {
+		switch {
+		case ch == '"':
+			if !inQuote && strings.TrimSpace(current.String()) == "" {
+				current.Reset()
+				quoted = true
+			}
+			inQuote = !inQuote
+		case ch == ',' && !inQuote:
+			flush()
+		case quoted && !inQuote && ch != ' ':
+			return nil, fmt.Errorf("unexpected character after quoted value: %s", text)
+		case !quoted || inQuote:
+			current.WriteRune(ch)
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote: %s", text)
+	}
+	flush()
+	return items, nil
+}
+
+// String 单元格的规范写法，用于导出
+func (c *DecisionCell) String() string {
+	if c.any {
+		return DecisionAny
+	}
+	var parts []string
+	for _, r := range c.ranges {
+		parts = append(parts, r.String())
+	}
+	for _, v := range c.order {
+		if strings.ContainsAny(v, `,"`) || strings.TrimSpace(v) != v {
+			v = `"` + v + `"`
+		}
+		parts = append(parts, v)
+	}
+	text := strings.Join(parts, ",")
+	if c.negate {
+		text = "!" + text
+	}
+	return text
+}
+
+// match 单元格是否匹配输入值，未提供的输入只匹配任意值
+func (c *DecisionCell) match(value interface{}) bool {
+	if c.any {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	matched := false
+	switch v := value.(type) {
+	case *big.Rat:
+		for _, r := range c.ranges {
+			if r.contains(v) {
+				matched = true
+				break
+			}
+		}
+	case string:
+		matched = c.values[v]
+	case bool:
+		matched = c.values[strconv.FormatBool(v)]
+	}
+	return matched != c.negate
+}
+
+// covers 单元格是否覆盖取值区间，区间由单元格的边界划分，只会被完全覆盖或完全不覆盖
+func (c *DecisionCell) covers(atom *decisionAtom) bool {
+	if c.any {
+		return true
+	}
+	covered := false
+	if atom.number != nil {
+		for _, r := range c.ranges {
+			if r.includes(atom.number) {
+				covered = true
+				break
+			}
+		}
+	} else if !atom.other {
+		covered = c.values[atom.value]
+	}
+	return covered != c.negate
+}
+
+func (r *decisionRange) contains(v *big.Rat) bool {
+	if r.lower != nil {
+		c := v.Cmp(r.lower)
+		if c < 0 || c == 0 && r.lowerOpen {
+			return false
+		}
+	}
+	if r.upper != nil {
+		c := v.Cmp(r.upper)
+		if c > 0 || c == 0 && r.upperOpen {
+			return false
+		}
+	}
+	return true
+}
+
+// includes 区间 other 是否完全包含在 r 中
+func (r *decisionRange) includes(other *decisionRange) bool {
+	if r.lower != nil {
+		if other.lower == nil {
+			return false
+		}
+		c := other.lower.Cmp(r.lower)
+		if c < 0 || c == 0 && r.lowerOpen && !other.lowerOpen {
+			return false
+		}
+	}
+	if r.upper != nil {
+		if other.upper == nil {
+			return false
+		}
+		c := other.upper.Cmp(r.upper)
+		if c > 0 || c == 0 && r.upperOpen && !other.upperOpen {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *decisionRange) String() string {
+	switch {
+	case r.lower != nil && r.upper != nil && r.lower.Cmp(r.upper) == 0:
+		return formatDecisionNumber(r.lower)
+	case r.lower == nil && r.upper == nil:
+		return DecisionAny
+	case r.lower == nil:
+		if r.upperOpen {
+			return "<" + formatDecisionNumber(r.upper)
+		}
+		return "<=" + formatDecisionNumber(r.upper)
+	case r.upper == nil:
+		if r.lowerOpen {
+			return ">" + formatDecisionNumber(r.lower)
+		}
+		return ">=" + formatDecisionNumber(r.lower)
+	}
+	left, right := "[", "]"
+	if r.lowerOpen {
+		left = "("
+	}
+	if r.upperOpen {
+		right = ")"
+	}
+	return left + formatDecisionNumber(r.lower) + "," + formatDecisionNumber(r.upper) + right
+}
+
+// formatDecisionNumber 数字的十进制写法，无法精确表示时保留 10 位小数
+func formatDecisionNumber(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	if places, exact := r.FloatPrec(); exact {
+		return r.FloatString(places)
+	}
+	return strings.TrimRight(strings.TrimRight(r.FloatString(10), "0"), ".")
+}
+
+// decisionAtom 输入列的一个取值区间：数字区间、字符串值、其他字符串或布尔值
+type decisionAtom struct {
+	number *decisionRange
+	value  string
+	other  bool // 单元格中没有出现的其他字符串
+}
+
+func (a *decisionAtom) String() string {
+	switch {
+	case a.number != nil:
+		return a.number.String()
+	case a.other:
+		return "其他值"
+	}
+	return a.value
+}
+
+// splitDecisionAtoms 按单元格的边界划分取值区间
+func splitDecisionAtoms(valueType string, cells []*DecisionCell) []*decisionAtom {
+	var atoms []*decisionAtom
+	switch valueType {
+	case DecisionTypeNumber:
+		var points []*big.Rat
+		for _, cell := range cells {
+			for _, r := range cell.ranges {
+				for _, p := range []*big.Rat{r.lower, r.upper} {
+					if p != nil {
+						points = append(points, p)
+					}
+				}
+			}
+		}
+		sort.Slice(points, func(i, j int) bool { return points[i].Cmp(points[j]) < 0 })
+		var prev *big.Rat
+		for _, p := range points {
+			if prev != nil && prev.Cmp(p) == 0 {
+				continue
+			}
+			atoms = append(atoms,
+				&decisionAtom{number: &decisionRange{lower: prev, upper: p, lowerOpen: prev != nil, upperOpen: true}},
+				&decisionAtom{number: &decisionRange{lower: p, upper: p}})
+			prev = p
+		}
+		atoms = append(atoms, &decisionAtom{number: &decisionRange{lower: prev, lowerOpen: prev != nil}})
+	case DecisionTypeString:
+		seen := make(map[string]bool)
+		for _, cell := range cells {
+			for _, v := range cell.order {
+				if !seen[v] {
+					seen[v] = true
+					atoms = append(atoms, &decisionAtom{value: v})
+				}
+			}
+		}
+		atoms = append(atoms, &decisionAtom{other: true})
+	case DecisionTypeBool:
+		atoms = []*decisionAtom{{value: "true"}, {value: "false"}}
+	}
+	return atoms
+}
+
+// ParseDecisionValue 按列的数据类型解析输出单元格，空单元格返回 nil
+func ParseDecisionValue(valueType, text string) (interface{}, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	switch valueType {
+	case DecisionTypeNumber:
+		return parseDecisionNumber(text)
+	case DecisionTypeBool:
+		return parseDecisionBool(text)
+	case DecisionTypeString:
+		return text, nil
+	}
+	return nil, fmt.Errorf("invalid column type: %s", valueType)
+}
+
+// decisionInputValue 将输入值转换为列的数据类型，数字为 *big.Rat
+func decisionInputValue(valueType string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch valueType {
+	case DecisionTypeNumber:
+		if s, ok := value.(string); ok {
+			return parseDecisionNumber(s)
+		}
+		v, err := formula.FromNative(value)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := v.(*big.Rat)
+		if !ok {
+			return nil, fmt.Errorf("不是数字: %v", value)
+		}
+		return r, nil
+	case DecisionTypeBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return parseDecisionBool(fmt.Sprint(value))
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,274 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+// feeTable 地区 × 会员等级 × 金额区间 → 费率
+func feeTable(hitPolicy string) *DecisionTable {
+	return &DecisionTable{
+		HitPolicy: hitPolicy,
+		Inputs: []*DecisionColumn{
+			{Name: "地区", Field: "region", Type: DecisionTypeString},
+			{Name: "会员等级", Field: "customer.level", Type: DecisionTypeString},
+			{Name: "金额", Field: "amount", Type: DecisionTypeNumber, Domain: ">=0"},
+		},
+		Outputs: []*DecisionColumn{
+			{Name: "费率", Field: "rate", Type: DecisionTypeNumber},
+		},
+		Rows: []*DecisionRow{
+			{Inputs: []string{"east", "gold,vip", "[0,1000)"}, Outputs: []string{"0.01"}},
+			{Inputs: []string{"east", "gold,vip", ">=1000"}, Outputs: []string{"0.005"}},
+			{Inputs: []string{"east", "!gold,vip", "-"}, Outputs: []string{"0.02"}},
+			{Inputs: []string{"!east", "", ""}, Outputs: []string{"0.03"}},
+		},
+	}
+}
+
+func lookupMap(data map[string]interface{}) func(string) (interface{}, bool) {
+	return func(field string) (interface{}, bool) {
+		v, ok := data[field]
+		return v, ok
+	}
+}
+
+func TestDecisionCellParse(t *testing.T) {
+	tests := []struct {
+		valueType string
+		text      string
+		want      string
+		match     []interface{}
+		miss      []interface{}
+	}{
+		{DecisionTypeNumber, "", "-", []interface{}{"1"}, nil},
+		{DecisionTypeNumber, "100", "100", []interface{}{"100", "100.0"}, []interface{}{"99"}},
+		{DecisionTypeNumber, "=0.1", "0.1", []interface{}{"0.1"}, []interface{}{"0.10001"}},
+		{DecisionTypeNumber, ">=100", ">=100", []interface{}{"100"}, []interface{}{"99.99"}},
+		{DecisionTypeNumber, "<100", "<100", []interface{}{"-5"}, []interface{}{"100"}},
+		{DecisionTypeNumber, "[0, 1000)", "[0,1000)", []interface{}{"0", "999.99"}, []interface{}{"1000"}},
+		{DecisionTypeNumber, "(100,]", ">100", []interface{}{"100.01"}, []interface{}{"100"}},
+		{DecisionTypeNumber, "1,2, 3", "1,2,3", []interface{}{"2"}, []interface{}{"4"}},
+		{DecisionTypeNumber, "![0,10]", "![0,10]", []interface{}{"11"}, []interface{}{"10"}},
+		{DecisionTypeString, `gold, "a,b" `, `gold,"a,b"`, []interface{}{"gold", "a,b"}, []interface{}{"a"}},
+		{DecisionTypeString, "!gold", "!gold", []interface{}{"vip"}, []interface{}{"gold"}},
+		{DecisionTypeBool, "是", "true", []interface{}{"true"}, []interface{}{"false"}},
+	}
+	for _, tt := range tests {
+		cell, err := ParseDecisionCell(tt.valueType, tt.text)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.text, err)
+		}
+		if got := cell.String(); got != tt.want {
+			t.Errorf("%q: string = %q, want %q", tt.text, got, tt.want)
+		}
+		for _, v := range tt.match {
+			value, _ := decisionInputValue(tt.valueType, v)
+			if !cell.match(value) {
+				t.Errorf("%q should match %v", tt.text, v)
+			}
+		}
+		for _, v := range tt.miss {
+			value, _ := decisionInputValue(tt.valueType, v)
+			if cell.match(value) {
+				t.Errorf("%q should not match %v", tt.text, v)
+			}
+		}
+		if cell.match(nil) != (tt.text == "") {
+			t.Errorf("%q: missing input should only match any", tt.text)
+		}
+	}
+
+	for _, text := range []string{"abc", "[1,2", "[3,1]", "(1,1]", "[1,2,3]", ">", "!"} {
+		if _, err := ParseDecisionCell(DecisionTypeNumber, text); err == nil {
+			t.Errorf("%q should be invalid", text)
+		}
+	}
+	if _, err := ParseDecisionCell(DecisionTypeString, `"a`); err == nil {
+		t.Error("unterminated quote should be invalid")
+	}
+}
+
+func TestDecisionTableMatch(t *testing.T) {
+	compiled, err := feeTable(DecisionHitFirst).Compile()
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	tests := []struct {
+		data map[string]interface{}
+		rows []int
+		rate interface{}
+	}{
+		{map[string]interface{}{"region": "east", "customer.level": "gold", "amount": 999.99}, []int{1}, 0.01},
+		{map[string]interface{}{"region": "east", "customer.level": "vip", "amount": 1000}, []int{2}, 0.005},
+		{map[string]interface{}{"region": "east", "customer.level": "normal", "amount": 5}, []int{3}, 0.02},
+		{map[string]interface{}{"region": "west"}, []int{4}, 0.03},
+	}
+	for _, tt := range tests {
+		match, err := compiled.Match(lookupMap(tt.data))
+		if err != nil {
+			t.Fatalf("match %v: %v", tt.data, err)
+		}
+		if match == nil || len(match.Rows) != 1 || match.Rows[0] != tt.rows[0] || match.Outputs["rate"] != tt.rate {
+			t.Fatalf("match %v = %+v, want rows %v rate %v", tt.data, match, tt.rows, tt.rate)
+		}
+	}
+
+	// 缺少金额时，只有金额为任意值的行可以命中
+	match, err := compiled.Match(lookupMap(map[string]interface{}{"region": "east", "customer.level": "gold"}))
+	if err != nil || match != nil {
+		t.Fatalf("missing amount: match=%+v err=%v", match, err)
+	}
+	if _, err := compiled.Match(lookupMap(map[string]interface{}{"region": "east", "customer.level": "gold", "amount": "abc"})); err == nil {
+		t.Fatal("invalid number input should fail")
+	}
+
+	// 配置默认值后没有命中时使用默认值
+	table := feeTable(DecisionHitFirst)
+	table.Outputs[0].Default = "0.05"
+	compiled, err = table.Compile()
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	match, err = compiled.Match(lookupMap(map[string]interface{}{"region": "east", "customer.level": "gold"}))
+	if err != nil || match == nil || !match.Default || match.Outputs["rate"] != 0.05 {
+		t.Fatalf("default: match=%+v err=%v", match, err)
+	}
+}
+
+func TestDecisionTableHitPolicies(t *testing.T) {
+	table := &DecisionTable{
+		Inputs:  []*DecisionColumn{{Name: "金额", Field: "amount", Type: DecisionTypeNumber}},
+		Outputs: []*DecisionColumn{{Name: "标签", Field: "tag", Type: DecisionTypeString}},
+		Rows: []*DecisionRow{
+			{Inputs: []string{">=100"}, Outputs: []string{"large"}, Priority: 1},
+			{Inputs: []string{">=1000"}, Outputs: []string{"huge"}, Priority: 2},
+			{Inputs: []string{"<100"}, Outputs: []string{"small"}},
+		},
+	}
+	data := lookupMap(map[string]interface{}{"amount": 5000})
+
+	table.HitPolicy = DecisionHitFirst
+	compiled, _ := table.Compile()
+	if match, _ := compiled.Match(data); match.Outputs["tag"] != "large" {
+		t.Fatalf("first = %+v", match)
+	}
+
+	table.HitPolicy = DecisionHitPriority
+	compiled, _ = table.Compile()
+	if match, _ := compiled.Match(data); match.Outputs["tag"] != "huge" || match.Rows[0] != 2 {
+		t.Fatalf("priority = %+v", match)
+	}
+	if err := table.Validate(); err != nil {
+		t.Fatalf("overlapping rows with different priorities should be valid: %v", err)
+	}
+
+	table.HitPolicy = DecisionHitCollect
+	compiled, _ = table.Compile()
+	match, _ := compiled.Match(data)
+	tags, _ := match.Outputs["tag"].([]interface{})
+	if len(tags) != 2 || tags[0] != "large" || tags[1] != "huge" || len(match.Rows) != 2 {
+		t.Fatalf("collect = %+v", match)
+	}
+
+	table.HitPolicy = DecisionHitUnique
+	if err := table.Validate(); err == nil || !strings.Contains(err.Error(), "rows 1 and 2") {
+		t.Fatalf("unique with overlapping rows should be invalid, got %v", err)
+	}
+	compiled, _ = table.Compile()
+	if _, err := compiled.Match(data); err == nil {
+		t.Fatal("unique with multiple hits should fail")
+	}
+
+	table.HitPolicy = "random"
+	if _, err := table.Compile(); err == nil {
+		t.Fatal("unknown hit policy should be invalid")
+	}
+}
+
+func TestDecisionTableAnalyze(t *testing.T) {
+	compiled, err := feeTable(DecisionHitFirst).Compile()
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	report := compiled.Analyze()
+	if report.Gaps != 0 || len(report.Issues) != 0 {
+		t.Fatalf("complete table should have no issues: %+v", report.Issues)
+	}
+
+	// 删除金额 >= 1000 的行，留下遗漏；任意金额的行与第一行重叠
+	table := feeTable(DecisionHitFirst)
+	table.Rows = append(table.Rows[:1], table.Rows[2:]...)
+	table.Rows = append(table.Rows, &DecisionRow{Inputs: []string{"east", "gold", "-"}, Outputs: []string{"0.1"}})
+	compiled, err = table.Compile()
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	report = compiled.Analyze()
+	var overlaps, gaps []string
+	for _, issue := range report.Issues {
+		if issue.Type == DecisionIssueOverlap {
+			overlaps = append(overlaps, issue.Message)
+		} else {
+			gaps = append(gaps, issue.Message)
+		}
+	}
+	if len(overlaps) != 1 || !strings.Contains(overlaps[0], "第 1 行和第 4 行") {
+		t.Fatalf("overlaps = %v", overlaps)
+	}
+	// 剩余的遗漏为 vip 且金额 >= 1000，分为边界点和之后的区间
+	if report.Gaps != 2 || len(gaps) != 2 || !strings.Contains(gaps[0], "会员等级 vip") || !strings.Contains(gaps[0], "金额 1000") {
+		t.Fatalf("gaps = %d %v", report.Gaps, gaps)
+	}
+	for _, gap := range gaps {
+		if strings.Contains(gap, "<0") {
+			t.Fatalf("gap outside the domain should be ignored: %s", gap)
+		}
+	}
+
+	// collect 策略不报告重叠
+	table.HitPolicy = DecisionHitCollect
+	compiled, _ = table.Compile()
+	for _, issue := range compiled.Analyze().Issues {
+		if issue.Type == DecisionIssueOverlap {
+			t.Fatalf("collect should not report overlaps: %s", issue.Message)
+		}
+	}
+}
+
+func TestDecisionTableJSON(t *testing.T) {
+	data, err := feeTable("").ToJSON()
+	if err != nil {
+		t.Fatalf("to json: %v", err)
+	}
+	compiled, err := ParseDecisionTableJSON(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if compiled.Table.HitPolicy != DecisionHitFirst || len(compiled.Table.Rows) != 4 {
+		t.Fatalf("table = %+v", compiled.Table)
+	}
+
+	invalid := []string{
+		"",
+		`{"unknown":1}`,
+		`{"inputs":[{"name":"a","field":"a","type":"number"}],"outputs":[{"name":"b","field":"b","type":"date"}],"rows":[{"inputs":["1"],"outputs":["1"]}]}`,
+		`{"inputs":[{"name":"a","field":"a","type":"number"}],"outputs":[{"name":"a","field":"b","type":"number"}],"rows":[{"inputs":["1"],"outputs":["1"]}]}`,
+		`{"inputs":[{"name":"a","field":"a","type":"number"}],"outputs":[{"name":"b","field":"b","type":"number"}],"rows":[{"inputs":["1","2"],"outputs":["1"]}]}`,
+		`{"inputs":[{"name":"a","field":"a","type":"number"}],"outputs":[{"name":"b","field":"b","type":"number"}],"rows":[{"inputs":["1"],"outputs":["x"]}]}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseDecisionTableJSON(data); err == nil {
+			t.Errorf("%s should be invalid", data)
+		}
+	}
+
+	rule := &Rule{Code: "fee", Name: "fee", CategoryID: "c1", Type: "decision_table", ExecutionTiming: "before", DecisionTable: data}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("validate rule: %v", err)
+	}
+	rule.DecisionTable = ""
+	if err := rule.Validate(); err == nil {
+		t.Fatal("decision table rule without table should be invalid")
+	}
+}
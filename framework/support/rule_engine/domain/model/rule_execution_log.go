@@ -0,0 +1,160 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// 执行统计的分组维度
+const (
+	ExecutionStatsByRule    = "rule"    // 按规则编码
+	ExecutionStatsByScope   = "scope"   // 按作用域
+	ExecutionStatsByTrigger = "trigger" // 按触发动作
+)
+
+// RuleExecutionLog 规则执行日志
+// 一次 ExecuteRules 或按编码执行对应一条日志，执行链路中的每个规则对应一个步骤
+type RuleExecutionLog struct {
+	ID              string `json:"id"`              // 日志ID
+	Scope           string `json:"scope"`           // 作用域
+	Trigger         string `json:"trigger"`         // 触发动作
+	ScopeID         string `json:"scopeId"`         // 作用域ID
+	ExecutionTiming string `json:"executionTiming"` // 执行时机
+	RuleCode        string `json:"ruleCode"`        // 按编码执行时的规则编码，按上下文匹配执行时为空
+
+	// 执行结果
+	Valid       bool   `json:"valid"`       // 是否通过
+	Action      string `json:"action"`      // 执行动作
+	Error       string `json:"error"`       // 错误信息
+	ErrorReason string `json:"errorReason"` // 错误原因
+	ExecuteTime int64  `json:"executeTime"` // 执行时间(毫秒)
+	StepCount   int32  `json:"stepCount"`   // 执行的规则数量
+	ExecuteAt   int64  `json:"executeAt"`   // 执行时间戳
+
+	// Weight 统计权重，等于采样率的倒数，按采样保存的日志统计时还原真实执行次数
+	Weight float64 `json:"weight"`
+
+	TenantID string `json:"tenantId"` // 租户ID
+
+	Steps []*RuleExecutionLogStep `json:"steps,omitempty"` // 执行步骤
+}
+
+// RuleExecutionLogStep 规则执行日志步骤
+type RuleExecutionLogStep struct {
+	ID          string  `json:"id"`          // 步骤ID
+	LogID       string  `json:"logId"`       // 日志ID
+	Seq         int32   `json:"seq"`         // 在执行链路中的顺序，从 0 开始
+	RuleID      string  `json:"ruleId"`      // 规则ID
+	RuleCode    string  `json:"ruleCode"`    // 规则编码
+	RuleName    string  `json:"ruleName"`    // 规则名称
+	RuleVersion int32   `json:"ruleVersion"` // 执行的版本号
+	Scope       string  `json:"scope"`       // 作用域，冗余日志字段用于统计
+	Trigger     string  `json:"trigger"`     // 触发动作，冗余日志字段用于统计
+	Input       string  `json:"input"`       // 输入数据(JSON格式)，未开启数据记录时为空
+	Output      string  `json:"output"`      // 输出数据(JSON格式)，未开启数据记录时为空
+	Success     bool    `json:"success"`     // 是否执行成功
+	Action      string  `json:"action"`      // 执行动作
+	Error       string  `json:"error"`       // 错误信息
+	ExecuteTime int64   `json:"executeTime"` // 执行时间(毫秒)
+	ExecuteAt   int64   `json:"executeAt"`   // 执行时间戳
+	Weight      float64 `json:"weight"`      // 统计权重，与所属日志相同
+	TenantID    string  `json:"tenantId"`    // 租户ID
+}
+
+// NewRuleExecutionLog 根据执行上下文和执行结果创建执行日志，统计权重为 1
+// recordData 为 true 时记录每个步骤的输入输出，数据在调用时序列化，之后修改上下文不影响日志
+func NewRuleExecutionLog(context *RuleContext, result *RuleResult, ruleCode string, recordData bool) *RuleExecutionLog {
+	log := &RuleExecutionLog{
+		Scope:           context.Scope,
+		Trigger:         context.Trigger,
+		ScopeID:         context.ScopeID,
+		ExecutionTiming: context.ExecutionTiming,
+		RuleCode:        ruleCode,
+		Valid:           result.IsSuccess(),
+		Action:          result.Action,
+		Error:           result.Error,
+		ErrorReason:     result.ErrorReason,
+		ExecuteTime:     result.ExecuteTime,
+		StepCount:       int32(len(result.ExecutionChain)),
+		ExecuteAt:       result.ExecuteAt,
+		Weight:          1,
+		TenantID:        context.TenantID,
+		Steps:           make([]*RuleExecutionLogStep, 0, len(result.ExecutionChain)),
+	}
+	for i, step := range result.ExecutionChain {
+		logStep := &RuleExecutionLogStep{
+			Seq:         int32(i),
+			RuleID:      step.RuleID,
+			RuleCode:    step.RuleCode,
+			RuleName:    step.RuleName,
+			RuleVersion: step.RuleVersion,
+			Scope:       context.Scope,
+			Trigger:     context.Trigger,
+			Success:     step.IsSuccess(),
+			Action:      step.Action,
+			Error:       step.Error,
+			ExecuteTime: step.ExecuteTime,
+			ExecuteAt:   step.ExecuteAt,
+			Weight:      1,
+			TenantID:    context.TenantID,
+		}
+		if recordData {
+			logStep.Input = marshalLogData(step.Input)
+			logStep.Output = marshalLogData(step.Output)
+		}
+		log.Steps = append(log.Steps, logStep)
+	}
+	return log
+}
+
+// SetWeight 设置日志及其步骤的统计权重
+func (l *RuleExecutionLog) SetWeight(weight float64) {
+	l.Weight = weight
+	for _, step := range l.Steps {
+		step.Weight = weight
+	}
+}
+
+// marshalLogData 序列化日志数据，无法序列化时记录错误信息
+func marshalLogData(data map[string]interface{}) string {
+	if len(data) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return `{"marshal_error":` + string(mustMarshalString(err.Error())) + `}`
+	}
+	return string(b)
+}
+
+// mustMarshalString 序列化字符串
+func mustMarshalString(s string) []byte {
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// RuleExecutionStats 规则执行统计
+// 执行次数按日志的统计权重累加，开启采样时为估算值
+type RuleExecutionStats struct {
+	Key            string  `json:"key"`            // 分组值：规则编码、作用域或触发动作
+	Total          float64 `json:"total"`          // 执行次数
+	Success        float64 `json:"success"`        // 成功次数
+	Failed         float64 `json:"failed"`         // 失败次数
+	FailureRate    float64 `json:"failureRate"`    // 失败率(0-1)
+	AvgExecuteTime float64 `json:"avgExecuteTime"` // 平均执行时间(毫秒)
+}
+
+// Calculate 根据执行次数和成功次数计算失败次数和失败率
+func (s *RuleExecutionStats) Calculate() {
+	s.Failed = s.Total - s.Success
+	if s.Total > 0 {
+		s.FailureRate = s.Failed / s.Total
+	}
+}
+
+// RuleStatsDelta 规则执行统计增量，批量写入规则的执行次数
+type RuleStatsDelta struct {
+	RuleID        string // 规则ID
+	Executed      int64  // 新增执行次数
+	Succeeded     int64  // 新增成功次数
+	LastExecuteAt int64  // 最后执行时间
+}
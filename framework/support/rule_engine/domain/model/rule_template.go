@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/flare-admin/flare-server-go/framework/pkg/formula"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 )
 
@@ -229,7 +230,7 @@ func (rt *RuleTemplate) Validate() error {
 	// 根据类型验证内容
 	switch rt.Type {
 	case "condition":
-		if _, err := rt.GetConditions(); err != nil {
+		if _, err := ParseConditionTreeJSON(rt.Conditions); err != nil {
 			return fmt.Errorf("invalid conditions: %v", err)
 		}
 	case "lua":
@@ -240,7 +241,14 @@ func (rt *RuleTemplate) Validate() error {
 		if rt.Formula == "" {
 			return fmt.Errorf("formula cannot be empty for formula template")
 		}
-		if _, err := rt.GetFormulaVars(); err != nil {
+		if _, err := formula.Compile(rt.Formula); err != nil {
+			return fmt.Errorf("invalid formula: %v", err)
+		}
+		vars, err := rt.GetFormulaVars()
+		if err != nil {
+			return fmt.Errorf("invalid formula vars: %v", err)
+		}
+		if _, err := formula.ParseVarDefs(vars); err != nil {
 			return fmt.Errorf("invalid formula vars: %v", err)
 		}
 	}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+)
+
+// RuleTestCase 规则测试用例
+// 保存一组输入数据和期望结果，发布规则前自动执行，全部通过才允许发布
+type RuleTestCase struct {
+	ID          string                 `json:"id"`          // 用例ID
+	RuleID      string                 `json:"ruleId"`      // 规则ID
+	Name        string                 `json:"name"`        // 用例名称
+	Description string                 `json:"description"` // 用例描述
+	ScopeID     string                 `json:"scopeId"`     // 执行时的作用域ID
+	Input       map[string]interface{} `json:"input"`       // 执行输入数据
+
+	// 期望结果，未设置的项不校验
+	ExpectValid   *bool                  `json:"expectValid"`   // 期望是否通过
+	ExpectAction  string                 `json:"expectAction"`  // 期望动作
+	ExpectOutputs map[string]interface{} `json:"expectOutputs"` // 期望输出变量，只校验列出的变量
+
+	Enabled   bool   `json:"enabled"`   // 是否启用，停用的用例不参与发布校验
+	CreatedAt int64  `json:"createdAt"` // 创建时间
+	UpdatedAt int64  `json:"updatedAt"` // 更新时间
+	TenantID  string `json:"tenantId"`  // 租户ID
+}
+
+// NewRuleTestCase 创建规则测试用例
+func NewRuleTestCase(ruleID, name string) *RuleTestCase {
+	now := utils.GetDateUnix()
+	return &RuleTestCase{
+		RuleID:    ruleID,
+		Name:      name,
+		Input:     make(map[string]interface{}),
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Validate 验证测试用例
+func (c *RuleTestCase) Validate() error {
+	if c.RuleID == "" {
+		return fmt.Errorf("rule id cannot be empty")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("test case name cannot be empty")
+	}
+	if c.ExpectValid == nil && c.ExpectAction == "" && len(c.ExpectOutputs) == 0 {
+		return fmt.Errorf("test case %s has no expectation", c.Name)
+	}
+	return nil
+}
+
+// NewContext 根据用例输入创建规则执行上下文，输入数据会被复制，执行过程不影响用例本身
+func (c *RuleTestCase) NewContext(rule *Rule) *RuleContext {
+	ctx := NewRuleContext(rule.Scope, "", rule.ExecutionTiming, c.ScopeID)
+	for k, v := range c.Input {
+		ctx.AddData(k, v)
+	}
+	ctx.WithTenantID(rule.TenantID)
+	return ctx
+}
+
+// Check 校验执行结果是否符合期望，返回不符合的项，全部符合时返回空
+func (c *RuleTestCase) Check(result *RuleResult) []string {
+	failures := make([]string, 0)
+	if c.ExpectValid != nil && result.Valid != *c.ExpectValid {
+		failures = append(failures, fmt.Sprintf("valid: expected %v, got %v", *c.ExpectValid, result.Valid))
+	}
+	if c.ExpectAction != "" && result.Action != c.ExpectAction {
+		failures = append(failures, fmt.Sprintf("action: expected %q, got %q", c.ExpectAction, result.Action))
+	}
+
+	keys := make([]string, 0, len(c.ExpectOutputs))
+	for k := range c.ExpectOutputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		actual, ok := result.Context[k]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("output %s: missing", k))
+			continue
+		}
+		if !outputEqual(c.ExpectOutputs[k], actual) {
+			failures = append(failures, fmt.Sprintf("output %s: expected %v, got %v", k, c.ExpectOutputs[k], actual))
+		}
+	}
+	return failures
+}
+
+// outputEqual 按 JSON 表示比较期望值和实际值，避免 int64 和 float64 等类型差异
+func outputEqual(expected, actual interface{}) bool {
+	return reflect.DeepEqual(normalizeJSON(expected), normalizeJSON(actual))
+}
+
+// normalizeJSON 将值转换为 JSON 解码后的通用类型
+func normalizeJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// RuleTestCaseResult 单个测试用例的执行结果
+type RuleTestCaseResult struct {
+	CaseID   string      `json:"caseId"`   // 用例ID
+	Name     string      `json:"name"`     // 用例名称
+	Passed   bool        `json:"passed"`   // 是否通过
+	Failures []string    `json:"failures"` // 不符合期望的项
+	Result   *RuleResult `json:"result"`   // 执行结果，包含执行链路
+}
+
+// RuleTestReport 规则测试报告
+type RuleTestReport struct {
+	RuleID  string                `json:"ruleId"`  // 规则ID
+	Total   int                   `json:"total"`   // 用例总数
+	Passed  int                   `json:"passed"`  // 通过数
+	Failed  int                   `json:"failed"`  // 失败数
+	Results []*RuleTestCaseResult `json:"results"` // 各用例结果
+}
+
+// NewRuleTestReport 创建规则测试报告
+func NewRuleTestReport(ruleID string) *RuleTestReport {
+	return &RuleTestReport{RuleID: ruleID, Results: make([]*RuleTestCaseResult, 0)}
+}
+
+// Add 添加用例结果
+func (r *RuleTestReport) Add(result *RuleTestCaseResult) {
+	r.Results = append(r.Results, result)
+	r.Total++
+	if result.Passed {
+		r.Passed++
+	} else {
+		r.Failed++
+	}
+}
+
+// AllPassed 是否全部通过
+func (r *RuleTestReport) AllPassed() bool {
+	return r.Failed == 0
+}
+
+// FailedNames 未通过的用例名称
+func (r *RuleTestReport) FailedNames() []string {
+	names := make([]string, 0, r.Failed)
+	for _, result := range r.Results {
+		if !result.Passed {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+)
+
+// 规则版本状态
+const (
+	RuleVersionDraft     = "draft"     // 草稿，每个规则最多一份，可反复修改
+	RuleVersionPublished = "published" // 已发布，不可修改
+)
+
+// RuleVersion 规则版本
+// 保存影响规则执行的全部内容，发布后不可修改；草稿的版本号为 0
+type RuleVersion struct {
+	ID           string `json:"id"`           // 版本ID
+	RuleID       string `json:"ruleId"`       // 规则ID
+	Version      int32  `json:"version"`      // 版本号，从 1 递增，草稿为 0
+	Status       string `json:"status"`       // 状态：draft(草稿) published(已发布)
+	Comment      string `json:"comment"`      // 版本说明
+	RollbackFrom int32  `json:"rollbackFrom"` // 回滚来源版本号，非回滚产生的版本为 0
+
+	// 规则内容快照
+	CategoryID      string   `json:"categoryId"`      // 分类ID
+	TemplateID      string   `json:"templateId"`      // 模板ID
+	Type            string   `json:"type"`            // 规则类型
+	Triggers        []string `json:"triggers"`        // 触发动作列表
+	Scope           string   `json:"scope"`           // 作用域
+	ScopeID         string   `json:"scopeId"`         // 作用域ID
+	ExecutionTiming string   `json:"executionTiming"` // 执行时机
+	Conditions      string   `json:"conditions"`      // 条件表达式(JSON格式)
+	LuaScript       string   `json:"luaScript"`       // Lua脚本代码
+	Formula         string   `json:"formula"`         // 计算公式
+	FormulaVars     string   `json:"formulaVars"`     // 公式变量映射(JSON格式)
+	DecisionTable   string   `json:"decisionTable"`   // 决策表(JSON格式)
+	DBPolicy        string   `json:"dbPolicy"`        // 数据库访问策略(JSON格式)
+	Action          string   `json:"action"`          // 规则动作
+	Priority        int32    `json:"priority"`        // 优先级
+	Sorting         int32    `json:"sorting"`         // 排序权重
+
+	PublishedAt int64 `json:"publishedAt"` // 发布时间
+	CreatedAt   int64 `json:"createdAt"`   // 创建时间
+	UpdatedAt   int64 `json:"updatedAt"`   // 更新时间
+
+	TenantID string `json:"tenantId"` // 租户ID
+}
+
+// NewRuleDraft 根据规则内容创建草稿
+func NewRuleDraft(rule *Rule) *RuleVersion {
+	now := utils.GetDateUnix()
+	v := &RuleVersion{
+		RuleID:    rule.ID,
+		Status:    RuleVersionDraft,
+		CreatedAt: now,
+		UpdatedAt: now,
+		TenantID:  rule.TenantID,
+	}
+	v.CopyFrom(rule)
+	return v
+}
+
+// CopyFrom 从规则复制内容
+func (v *RuleVersion) CopyFrom(rule *Rule) {
+	v.CategoryID = rule.CategoryID
+	v.TemplateID = rule.TemplateID
+	v.Type = rule.Type
+	v.Triggers = append([]string{}, rule.GetTriggers()...)
+	v.Scope = rule.Scope
+	v.ScopeID = rule.ScopeID
+	v.ExecutionTiming = rule.ExecutionTiming
+	v.Conditions = rule.Conditions
+	v.LuaScript = rule.LuaScript
+	v.Formula = rule.Formula
+	v.FormulaVars = rule.FormulaVars
+	v.DecisionTable = rule.DecisionTable
+	v.DBPolicy = rule.DBPolicy
+	v.Action = rule.Action
+	v.Priority = rule.Priority
+	v.Sorting = rule.Sorting
+	v.UpdatedAt = utils.GetDateUnix()
+}
+
+// ApplyTo 将版本内容应用到规则，不修改规则的编码、名称、状态和统计信息
+func (v *RuleVersion) ApplyTo(rule *Rule) {
+	rule.CategoryID = v.CategoryID
+	rule.TemplateID = v.TemplateID
+	rule.Type = v.Type
+	rule.Triggers = append([]string{}, v.Triggers...)
+	rule.Scope = v.Scope
+	rule.ScopeID = v.ScopeID
+	rule.ExecutionTiming = v.ExecutionTiming
+	rule.Conditions = v.Conditions
+	rule.LuaScript = v.LuaScript
+	rule.Formula = v.Formula
+	rule.FormulaVars = v.FormulaVars
+	rule.DecisionTable = v.DecisionTable
+	rule.DBPolicy = v.DBPolicy
+	rule.Action = v.Action
+	rule.Priority = v.Priority
+	rule.Sorting = v.Sorting
+}
+
+// IsDraft 是否为草稿
+func (v *RuleVersion) IsDraft() bool {
+	return v.Status == RuleVersionDraft
+}
+
+// Publish 将草稿发布为指定版本号
+func (v *RuleVersion) Publish(version int32, comment string) error {
+	if !v.IsDraft() {
+		return fmt.Errorf("rule version %d is already published", v.Version)
+	}
+	now := utils.GetDateUnix()
+	v.Version = version
+	v.Status = RuleVersionPublished
+	v.Comment = comment
+	v.PublishedAt = now
+	v.UpdatedAt = now
+	return nil
+}
+
+// Rollback 基于已发布的版本创建新的待发布版本
+func (v *RuleVersion) Rollback() *RuleVersion {
+	now := utils.GetDateUnix()
+	rollback := *v
+	rollback.ID = ""
+	rollback.Version = 0
+	rollback.Status = RuleVersionDraft
+	rollback.Comment = ""
+	rollback.RollbackFrom = v.Version
+	rollback.Triggers = append([]string{}, v.Triggers...)
+	rollback.PublishedAt = 0
+	rollback.CreatedAt = now
+	rollback.UpdatedAt = now
+	return &rollback
+}
+
+// RuleVersionChange 两个版本之间有差异的字段
+type RuleVersionChange struct {
+	Field string `json:"field"` // 字段名称
+	From  string `json:"from"`  // 原值
+	To    string `json:"to"`    // 新值
+}
+
+// Diff 比较两个版本的内容，返回有差异的字段，按字段定义顺序排列
+func (v *RuleVersion) Diff(other *RuleVersion) []RuleVersionChange {
+	from, to := v.contentFields(), other.contentFields()
+	changes := make([]RuleVersionChange, 0)
+	for i, field := range from {
+		if field[1] != to[i][1] {
+			changes = append(changes, RuleVersionChange{Field: field[0], From: field[1], To: to[i][1]})
+		}
+	}
+	return changes
+}
+
+// contentFields 版本内容的字段名称和字符串值
+func (v *RuleVersion) contentFields() [][2]string {
+	return [][2]string{
+		{"categoryId", v.CategoryID},
+		{"templateId", v.TemplateID},
+		{"type", v.Type},
+		{"triggers", strings.Join(v.Triggers, ",")},
+		{"scope", v.Scope},
+		{"scopeId", v.ScopeID},
+		{"executionTiming", v.ExecutionTiming},
+		{"conditions", v.Conditions},
+		{"luaScript", v.LuaScript},
+		{"formula", v.Formula},
+		{"formulaVars", v.FormulaVars},
+		{"decisionTable", v.DecisionTable},
+		{"dbPolicy", v.DBPolicy},
+		{"action", v.Action},
+		{"priority", fmt.Sprint(v.Priority)},
+		{"sorting", fmt.Sprint(v.Sorting)},
+	}
+}
@@ -0,0 +1,45 @@
+package model
+
+import "testing"
+
+func TestRuleVersionLifecycle(t *testing.T) {
+	rule := &Rule{ID: "r1", Code: "c1", Name: "n1", Type: "formula", Formula: "a > 1", Priority: 1, Triggers: []string{"create"}}
+
+	draft := NewRuleDraft(rule)
+	if !draft.IsDraft() || draft.Version != 0 {
+		t.Fatalf("new draft: status=%s version=%d", draft.Status, draft.Version)
+	}
+	if err := draft.Publish(1, "v1"); err != nil {
+		t.Fatalf("publish draft: %v", err)
+	}
+	if err := draft.Publish(2, "again"); err == nil {
+		t.Fatal("publishing a published version should fail")
+	}
+
+	next := NewRuleDraft(rule)
+	next.Formula = "a > 2"
+	next.Priority = 5
+	changes := draft.Diff(next)
+	if len(changes) != 2 || changes[0].Field != "formula" || changes[1].Field != "priority" {
+		t.Fatalf("diff = %+v", changes)
+	}
+	if changes[1].From != "1" || changes[1].To != "5" {
+		t.Fatalf("priority change = %+v", changes[1])
+	}
+
+	rollback := draft.Rollback()
+	if !rollback.IsDraft() || rollback.RollbackFrom != 1 || rollback.ID != "" {
+		t.Fatalf("rollback = %+v", rollback)
+	}
+	if len(draft.Diff(rollback)) != 0 {
+		t.Fatal("rollback content should equal the source version")
+	}
+
+	pinned := rule.WithVersion(next)
+	if pinned.Formula != "a > 2" || rule.Formula != "a > 1" {
+		t.Fatalf("WithVersion should not modify the original rule: pinned=%s rule=%s", pinned.Formula, rule.Formula)
+	}
+	if pinned.Code != "c1" || pinned.Name != "n1" {
+		t.Fatal("WithVersion should keep code and name")
+	}
+}
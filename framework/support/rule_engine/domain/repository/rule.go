@@ -53,4 +53,7 @@ type IRuleRepository interface {
 
 	// FindAll 查找所有规则
 	FindAll(ctx context.Context) ([]*model.Rule, error)
+
+	// IncrementExecuteStats 累加规则的执行次数和成功次数
+	IncrementExecuteStats(ctx context.Context, delta *model.RuleStatsDelta) error
 }
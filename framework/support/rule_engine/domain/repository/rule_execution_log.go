@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// IRuleExecutionLogRepository 规则执行日志仓储接口
+type IRuleExecutionLogRepository interface {
+	// SaveBatch 批量保存执行日志及其步骤
+	SaveBatch(ctx context.Context, logs []*model.RuleExecutionLog) error
+
+	// Find 根据查询条件查找执行日志，不包含执行步骤
+	Find(ctx context.Context, query *db_query.QueryBuilder) ([]*model.RuleExecutionLog, error)
+
+	// Count 根据查询条件统计执行日志数量
+	Count(ctx context.Context, query *db_query.QueryBuilder) (int64, error)
+
+	// FindByID 根据ID查找执行日志及其步骤，不存在时返回 nil
+	FindByID(ctx context.Context, id string) (*model.RuleExecutionLog, error)
+
+	// Stats 按维度统计执行次数和失败率
+	// groupBy 为 rule 时按步骤的规则编码统计，为 scope、trigger 时按日志统计，query 的条件作用于对应的表
+	Stats(ctx context.Context, groupBy string, query *db_query.QueryBuilder) ([]*model.RuleExecutionStats, error)
+
+	// DeleteBefore 删除执行时间早于 before 的日志及其步骤，返回删除的日志数量
+	DeleteBefore(ctx context.Context, before int64) (int64, error)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// IRuleTestCaseRepository 规则测试用例仓储接口
+type IRuleTestCaseRepository interface {
+	// Create 创建测试用例
+	Create(ctx context.Context, testCase *model.RuleTestCase) error
+
+	// Update 更新测试用例
+	Update(ctx context.Context, testCase *model.RuleTestCase) error
+
+	// Delete 删除测试用例
+	Delete(ctx context.Context, id string) error
+
+	// FindByID 根据ID查找测试用例，不存在时返回 nil
+	FindByID(ctx context.Context, id string) (*model.RuleTestCase, error)
+
+	// FindByRuleID 查找规则的所有测试用例，按创建时间排序
+	FindByRuleID(ctx context.Context, ruleID string) ([]*model.RuleTestCase, error)
+
+	// DeleteByRuleID 删除规则的所有测试用例
+	DeleteByRuleID(ctx context.Context, ruleID string) error
+}
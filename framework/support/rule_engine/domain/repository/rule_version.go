@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// IRuleVersionRepository 规则版本仓储接口
+type IRuleVersionRepository interface {
+	// SaveDraft 保存规则草稿，已存在时覆盖
+	SaveDraft(ctx context.Context, draft *model.RuleVersion) error
+
+	// FindDraft 查找规则草稿，不存在时返回 nil
+	FindDraft(ctx context.Context, ruleID string) (*model.RuleVersion, error)
+
+	// DeleteDraft 删除规则草稿
+	DeleteDraft(ctx context.Context, ruleID string) error
+
+	// FindVersion 根据版本号查找已发布的版本
+	FindVersion(ctx context.Context, ruleID string, version int32) (*model.RuleVersion, error)
+
+	// FindVersions 查找规则的所有已发布版本，按版本号倒序
+	FindVersions(ctx context.Context, ruleID string) ([]*model.RuleVersion, error)
+
+	// LatestVersion 规则最大的已发布版本号，没有版本时返回 0
+	LatestVersion(ctx context.Context, ruleID string) (int32, error)
+
+	// Publish 在同一事务中保存已发布的版本并更新规则的线上内容，discardDraft 为 true 时同时删除草稿
+	Publish(ctx context.Context, rule *model.Rule, versions []*model.RuleVersion, discardDraft bool) error
+
+	// DeleteByRuleID 删除规则的所有版本和草稿
+	DeleteByRuleID(ctx context.Context, ruleID string) error
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// 日期字符串支持的格式
+var conditionDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// 数组量词中引用元素本身和根数据的字段前缀
+const (
+	conditionItemField  = "$item"
+	conditionRootPrefix = "$root."
+)
+
+// conditionEvaluator 条件树求值器
+// 对所有子节点求值并记录执行轨迹，便于定位具体不满足的分支
+type conditionEvaluator struct {
+	regexps sync.Map // 正则表达式缓存
+}
+
+// conditionScope 求值时的数据范围，数组量词的 where 中 data 为当前元素
+type conditionScope struct {
+	root map[string]interface{}
+	data map[string]interface{}
+	item interface{}
+}
+
+// Evaluate 对条件树求值，返回是否满足和执行轨迹
+func (e *conditionEvaluator) Evaluate(node *model.ConditionNode, data map[string]interface{}) (bool, *model.ConditionTrace) {
+	trace := e.eval(node, &conditionScope{root: data, data: data})
+	return trace.Matched, trace
+}
+
+func (e *conditionEvaluator) eval(node *model.ConditionNode, scope *conditionScope) *model.ConditionTrace {
+	trace := &model.ConditionTrace{Node: node.Kind(), Label: node.Label}
+	switch trace.Node {
+	case model.ConditionNodeAll:
+		trace.Matched = true
+		for _, child := range node.All {
+			childTrace := e.eval(child, scope)
+			trace.Children = append(trace.Children, childTrace)
+			trace.Matched = trace.Matched && childTrace.Matched
+		}
+	case model.ConditionNodeAny:
+		for _, child := range node.Any {
+			childTrace := e.eval(child, scope)
+			trace.Children = append(trace.Children, childTrace)
+			trace.Matched = trace.Matched || childTrace.Matched
+		}
+	case model.ConditionNodeNot:
+		childTrace := e.eval(node.Not, scope)
+		trace.Children = append(trace.Children, childTrace)
+		trace.Matched = !childTrace.Matched
+	default:
+		e.evalLeaf(node, scope, trace)
+	}
+	return trace
+}
+
+// evalLeaf 字段比较
+func (e *conditionEvaluator) evalLeaf(node *model.ConditionNode, scope *conditionScope, trace *model.ConditionTrace) {
+	trace.Field = node.Field
+	trace.Operator = node.Operator
+	trace.Expected = node.Value
+	actual, exists := scope.lookup(node.Field)
+	trace.Actual = actual
+
+	switch node.Operator {
+	case "is_null":
+		trace.Matched = actual == nil
+		return
+	case "not_null":
+		trace.Matched = actual != nil
+		return
+	case "is_empty":
+		trace.Matched = isEmptyValue(actual)
+		return
+	case "not_empty":
+		trace.Matched = !isEmptyValue(actual)
+		return
+	case "any_item", "all_items", "no_items":
+		e.evalItems(node, scope, actual, trace)
+		return
+	}
+
+	if actual == nil {
+		if !exists {
+			trace.Reason = "字段不存在"
+		} else {
+			trace.Reason = "字段为空"
+		}
+		// 空值只满足不等于和不包含类的条件
+		switch node.Operator {
+		case "neq", "ne", "nin", "not_in", "not_contains":
+			trace.Matched = true
+			trace.Reason = ""
+		}
+		return
+	}
+
+	matched, err := e.compare(node, actual)
+	if err != nil {
+		trace.Reason = err.Error()
+		return
+	}
+	trace.Matched = matched
+}
+
+// evalItems 数组量词，对数组的每个元素求 where 条件
+// 找到决定结果的元素即停止，只记录该元素的执行轨迹
+func (e *conditionEvaluator) evalItems(node *model.ConditionNode, scope *conditionScope, actual interface{}, trace *model.ConditionTrace) {
+	trace.Expected = nil
+	// any_item 遇到满足的元素成立；all_items 遇到不满足的元素不成立；no_items 遇到满足的元素不成立
+	stopOn, result := true, true
+	switch node.Operator {
+	case "all_items":
+		stopOn, result = false, false
+	case "no_items":
+		result = false
+	}
+
+	items, ok := toSlice(actual)
+	if !ok {
+		if actual != nil {
+			trace.Reason = fmt.Sprintf("字段不是数组: %T", actual)
+			return
+		}
+		// 空值按空数组处理
+		trace.Reason = "字段为空"
+	}
+	trace.Actual = len(items)
+
+	for i, item := range items {
+		itemScope := &conditionScope{root: scope.root, item: item}
+		if m, ok := item.(map[string]interface{}); ok {
+			itemScope.data = m
+		}
+		itemTrace := e.eval(node.Where, itemScope)
+		if itemTrace.Matched == stopOn {
+			index := i
+			trace.Index = &index
+			trace.Children = []*model.ConditionTrace{itemTrace}
+			trace.Matched = result
+			return
+		}
+	}
+	trace.Matched = !result
+}
+
+// lookup 按路径取值，返回值和字段是否存在
+func (s *conditionScope) lookup(field string) (interface{}, bool) {
+	if field == conditionItemField {
+		return s.item, true
+	}
+	data := s.data
+	if strings.HasPrefix(field, conditionRootPrefix) {
+		data = s.root
+		field = strings.TrimPrefix(field, conditionRootPrefix)
+	}
+	if data == nil {
+		return nil, false
+	}
+	if v, ok := data[field]; ok {
+		return v, true
+	}
+	current := data
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		v, ok := current[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return v, true
+		}
+		next, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return nil, false
+}
+
+// compare 按操作符比较实际值和期望值
+func (e *conditionEvaluator) compare(node *model.ConditionNode, actual interface{}) (bool, error) {
+	switch node.Operator {
+	case "eq":
+		return e.equal(node.Type, actual, node.Value)
+	case "neq", "ne":
+		eq, err := e.equal(node.Type, actual, node.Value)
+		return !eq, err
+	case "gt", "gte", "lt", "lte":
+		c, err := compareConditionValues(node.Type, actual, node.Value)
+		if err != nil {
+			return false, err
+		}
+		switch node.Operator {
+		case "gt":
+			return c > 0, nil
+		case "gte":
+			return c >= 0, nil
+		case "lt":
+			return c < 0, nil
+		}
+		return c <= 0, nil
+	case "in", "nin", "not_in":
+		found := false
+		for _, v := range conditionList(node.Value) {
+			if eq, err := e.equal(node.Type, actual, v); err == nil && eq {
+				found = true
+				break
+			}
+		}
+		return found == (node.Operator == "in"), nil
+	case "between":
+		bounds := conditionList(node.Value)
+		low, err := compareConditionValues(node.Type, actual, bounds[0])
+		if err != nil {
+			return false, err
+		}
+		high, err := compareConditionValues(node.Type, actual, bounds[1])
+		if err != nil {
+			return false, err
+		}
+		return low >= 0 && high <= 0, nil
+	case "contains", "not_contains":
+		found := containsValue(actual, node.Value)
+		return found == (node.Operator == "contains"), nil
+	case "starts_with":
+		return strings.HasPrefix(toConditionString(actual), toConditionString(node.Value)), nil
+	case "ends_with":
+		return strings.HasSuffix(toConditionString(actual), toConditionString(node.Value)), nil
+	case "regex":
+		re, err := e.regexp(toConditionString(node.Value))
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(toConditionString(actual)), nil
+	}
+	return false, fmt.Errorf("不支持的操作符: %s", node.Operator)
+}
+
+// equal 判断相等，数字按数值、日期按时间、其他按字符串比较
+func (e *conditionEvaluator) equal(valueType string, actual, expected interface{}) (bool, error) {
+	if expected == nil {
+		return actual == nil, nil
+	}
+	switch valueType {
+	case model.ConditionValueAuto:
+		if c, err := compareConditionValues(valueType, actual, expected); err == nil {
+			return c == 0, nil
+		}
+		return toConditionString(actual) == toConditionString(expected), nil
+	case model.ConditionValueString:
+		return toConditionString(actual) == toConditionString(expected), nil
+	case model.ConditionValueBool:
+		a, err := toConditionBool(actual)
+		if err != nil {
+			return false, err
+		}
+		b, err := toConditionBool(expected)
+		if err != nil {
+			return false, err
+		}
+		return a == b, nil
+	}
+	c, err := compareConditionValues(valueType, actual, expected)
+	return c == 0, err
+}
+
+func (e *conditionEvaluator) regexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := e.regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式: %v", err)
+	}
+	e.regexps.Store(pattern, re)
+	return re, nil
+}
+
+// compareConditionValues 比较大小，返回 -1、0、1
+// 自动识别时优先按数字比较，其次按日期比较
+func compareConditionValues(valueType string, actual, expected interface{}) (int, error) {
+	switch valueType {
+	case model.ConditionValueAuto, model.ConditionValueNumber:
+		a, aerr := toConditionFloat(actual)
+		b, berr := toConditionFloat(expected)
+		if aerr == nil && berr == nil {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+		if valueType == model.ConditionValueNumber {
+			return 0, fmt.Errorf("无法按数字比较 %v 和 %v", actual, expected)
+		}
+		if c, err := compareConditionValues(model.ConditionValueDate, actual, expected); err == nil {
+			return c, nil
+		}
+		// 都是字符串时按字典序比较
+		if a, ok := actual.(string); ok {
+			if b, ok := expected.(string); ok {
+				return strings.Compare(a, b), nil
+			}
+		}
+		return 0, fmt.Errorf("无法比较 %v 和 %v", actual, expected)
+	case model.ConditionValueDate:
+		a, err := toConditionTime(actual)
+		if err != nil {
+			return 0, err
+		}
+		b, err := toConditionTime(expected)
+		if err != nil {
+			return 0, err
+		}
+		return a.Compare(b), nil
+	case model.ConditionValueString:
+		return strings.Compare(toConditionString(actual), toConditionString(expected)), nil
+	}
+	return 0, fmt.Errorf("值类型 %s 不支持比较大小", valueType)
+}
+
+// conditionList 解析列表值，字符串按逗号分隔
+func conditionList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	var list []interface{}
+	for _, part := range strings.Split(toConditionString(value), ",") {
+		list = append(list, strings.TrimSpace(part))
+	}
+	return list
+}
+
+// containsValue 数组包含元素，或字符串包含子串
+func containsValue(actual, expected interface{}) bool {
+	if items, ok := toSlice(actual); ok {
+		for _, item := range items {
+			if toConditionString(item) == toConditionString(expected) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(toConditionString(actual), toConditionString(expected))
+}
+
+// isEmptyValue 空值、空字符串、空数组和空对象为空
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch val := v.(type) {
+	case string:
+		return val == ""
+	case map[string]interface{}:
+		return len(val) == 0
+	}
+	if items, ok := toSlice(v); ok {
+		return len(items) == 0
+	}
+	return false
+}
+
+// toSlice 转换为切片，支持任意元素类型的切片和数组
+func toSlice(v interface{}) ([]interface{}, bool) {
+	if items, ok := v.([]interface{}); ok {
+		return items, true
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
+
+func toConditionString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func toConditionFloat(v interface{}) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case float32:
+		return float64(val), nil
+	case int:
+		return float64(val), nil
+	case int32:
+		return float64(val), nil
+	case int64:
+		return float64(val), nil
+	case uint:
+		return float64(val), nil
+	case uint32:
+		return float64(val), nil
+	case uint64:
+		return float64(val), nil
+	case json.Number:
+		return val.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(val), 64)
+	}
+	return 0, fmt.Errorf("不是数字: %v", v)
+}
+
+func toConditionBool(v interface{}) (bool, error) {
+	switch val := v.(type) {
+	case bool:
+		return val, nil
+	case string:
+		return strconv.ParseBool(val)
+	}
+	if f, err := toConditionFloat(v); err == nil {
+		return f != 0, nil
+	}
+	return false, fmt.Errorf("不是布尔值: %v", v)
+}
+
+// toConditionTime 转换为时间，数字按Unix时间戳(秒)处理
+func toConditionTime(v interface{}) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case string:
+		for _, layout := range conditionDateLayouts {
+			if t, err := time.ParseInLocation(layout, val, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("不是日期: %s", val)
+	}
+	if f, err := toConditionFloat(v); err == nil {
+		return time.Unix(int64(f), 0), nil
+	}
+	return time.Time{}, fmt.Errorf("不是日期: %v", v)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+var conditionTestData = map[string]interface{}{
+	"user": map[string]interface{}{"age": 20.0, "level": "gold", "registeredAt": "2024-01-15"},
+	"order": map[string]interface{}{
+		"amount": 250.0,
+		"coupon": nil,
+		"items": []interface{}{
+			map[string]interface{}{"sku": "A", "price": 50.0},
+			map[string]interface{}{"sku": "B", "price": 200.0},
+		},
+		"tags": []interface{}{"new", "promo"},
+	},
+	"limit": 100.0,
+}
+
+func evalConditions(t *testing.T, conditions string) (bool, *model.ConditionTrace) {
+	t.Helper()
+	tree, err := model.ParseConditionTreeJSON(conditions)
+	if err != nil {
+		t.Fatalf("parse %s: %v", conditions, err)
+	}
+	return (&conditionEvaluator{}).Evaluate(tree, conditionTestData)
+}
+
+func TestConditionTree(t *testing.T) {
+	cases := map[string]bool{
+		`{"field": "user.age", "operator": "gte", "value": 18}`:                                                                                 true,
+		`{"all": [{"field": "user.age", "operator": "gte", "value": 18}, {"field": "user.level", "operator": "in", "value": ["gold", "vip"]}]}`: true,
+		`{"any": [{"field": "user.age", "operator": "lt", "value": 18}, {"not": {"field": "order.coupon", "operator": "not_null"}}]}`:           true,
+		`{"field": "order.items", "operator": "any_item", "where": {"field": "price", "operator": "gt", "value": 100}}`:                         true,
+		`{"field": "order.items", "operator": "all_items", "where": {"field": "price", "operator": "gt", "value": 100}}`:                        false,
+		`{"field": "order.items", "operator": "no_items", "where": {"field": "price", "operator": "gt", "value": {"x": 1}}}`:                    true,
+		`{"field": "order.items", "operator": "any_item", "where": {"field": "price", "operator": "gt", "value": 0, "label": "x"}}`:             true,
+		`{"field": "order.items", "operator": "all_items", "where": {"field": "price", "operator": "lt", "value": 0}}`:                          false,
+		`{"field": "order.tags", "operator": "any_item", "where": {"field": "$item", "operator": "eq", "value": "promo"}}`:                      true,
+		`{"field": "order.items", "operator": "any_item", "where": {"field": "price", "operator": "gt", "value": 99}}`:                          true,
+		`{"field": "order.missing", "operator": "all_items", "where": {"field": "price", "operator": "gt", "value": 0}}`:                        true,
+		`{"field": "order.items", "operator": "all_items", "where": {"field": "$root.limit", "operator": "eq", "value": 100}}`:                  true,
+		`{"field": "user.registeredAt", "operator": "between", "value": ["2024-01-01", "2024-02-01 00:00:00"], "valueType": "date"}`:            true,
+		`{"field": "user.registeredAt", "operator": "gt", "value": "2024-01-15"}`:                                                               false,
+		`{"field": "order.coupon", "operator": "is_null"}`:                                                                                      true,
+		`{"field": "order.coupon", "operator": "eq", "value": 1}`:                                                                               false,
+		`{"field": "order.coupon", "operator": "neq", "value": 1}`:                                                                              true,
+		`{"field": "user.level", "operator": "regex", "value": "^go"}`:                                                                          true,
+		`{"field": "order.tags", "operator": "contains", "value": "promo"}`:                                                                     true,
+		// 旧的扁平格式
+		`{"type": "simple", "parameters": {"field": "order.amount", "operator": "gt", "value": 300}}`: false,
+		`{"type": "expression", "expression": "x"}`:                                                   true,
+	}
+	for conditions, want := range cases {
+		if tree, _ := model.ParseConditionTreeJSON(conditions); tree == nil && want {
+			continue
+		}
+		if got, trace := evalConditions(t, conditions); got != want {
+			t.Errorf("%s = %v, want %v, trace %+v", conditions, got, want, trace)
+		}
+	}
+}
+
+func TestConditionTrace(t *testing.T) {
+	matched, trace := evalConditions(t, `{"all": [
+		{"field": "user.age", "operator": "gte", "value": 18},
+		{"any": [
+			{"field": "user.level", "operator": "eq", "value": "vip"},
+			{"field": "order.items", "operator": "all_items", "where": {"field": "price", "operator": "gte", "value": 100}}
+		]}
+	]}`)
+	if matched {
+		t.Fatal("expected not matched")
+	}
+	if !trace.Children[0].Matched || trace.Children[1].Matched {
+		t.Fatalf("children = %+v", trace.Children)
+	}
+	items := trace.Children[1].Children[1]
+	if items.Index == nil || *items.Index != 0 || items.Children[0].Actual != 50.0 {
+		t.Fatalf("items trace = %+v", items)
+	}
+	if field := trace.FailedField(); field != "user.level" {
+		t.Fatalf("failed field = %s", field)
+	}
+}
+
+func TestConditionTreeValidate(t *testing.T) {
+	for _, conditions := range []string{
+		`{"all": []}`,
+		`{"field": "a", "operator": "like", "value": 1}`,
+		`{"field": "a", "operator": "gt"}`,
+		`{"field": "a", "operator": "between", "value": [1]}`,
+		`{"field": "a", "operator": "regex", "value": "("}`,
+		`{"field": "a", "operator": "any_item"}`,
+		`{"field": "a", "operator": "eq", "value": 1, "valueType": "money"}`,
+		`{"all": [{"field": "a", "operator": "eq", "value": 1}], "any": [{"field": "a", "operator": "eq", "value": 1}]}`,
+		`{"not": {"field": "a", "operator": "eq", "value": 1, "unknown": 1}}`,
+	} {
+		if _, err := model.ParseConditionTreeJSON(conditions); err == nil {
+			t.Errorf("%s: expected error", conditions)
+		}
+	}
+}
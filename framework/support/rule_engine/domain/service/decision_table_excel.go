@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/excel"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// 决策表 Excel 的工作表和表头
+const (
+	decisionSheetRows     = "决策表"
+	decisionSheetColumns  = "列定义"
+	decisionSheetSettings = "设置"
+
+	decisionHeaderPriority    = "优先级"
+	decisionHeaderDescription = "说明"
+	decisionColumnInput       = "输入"
+	decisionColumnOutput      = "输出"
+	decisionSettingHitPolicy  = "命中策略"
+)
+
+var decisionColumnHeaders = []interface{}{"列类型", "名称", "字段", "数据类型", "取值范围/默认值"}
+
+// DecisionTableToExcel 将决策表导出为 Excel
+//   - 决策表: 表头为输入列、输出列的名称和优先级、说明，每行一条规则
+//   - 列定义: 每行一个列，输入列的取值范围或输出列的默认值
+//   - 设置: 命中策略
+func DecisionTableToExcel(table *model.DecisionTable) ([]byte, error) {
+	exporter := excel.NewExcelExporter(decisionSheetRows)
+	header := make([]interface{}, 0, len(table.Inputs)+len(table.Outputs)+2)
+	for _, col := range table.Inputs {
+		header = append(header, col.Name)
+	}
+	for _, col := range table.Outputs {
+		header = append(header, col.Name)
+	}
+	header = append(header, decisionHeaderPriority, decisionHeaderDescription)
+	if err := exporter.WriteValues(header...); err != nil {
+		return nil, err
+	}
+	for _, row := range table.Rows {
+		values := make([]interface{}, 0, len(header))
+		for _, cell := range row.Inputs {
+			values = append(values, cell)
+		}
+		for _, cell := range row.Outputs {
+			values = append(values, cell)
+		}
+		values = append(values, row.Priority, row.Description)
+		if err := exporter.WriteValues(values...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := exporter.AddSheet(decisionSheetColumns); err != nil {
+		return nil, err
+	}
+	if err := exporter.WriteValues(decisionColumnHeaders...); err != nil {
+		return nil, err
+	}
+	for _, col := range table.Inputs {
+		if err := exporter.WriteValues(decisionColumnInput, col.Name, col.Field, col.Type, col.Domain); err != nil {
+			return nil, err
+		}
+	}
+	for _, col := range table.Outputs {
+		if err := exporter.WriteValues(decisionColumnOutput, col.Name, col.Field, col.Type, col.Default); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := exporter.AddSheet(decisionSheetSettings); err != nil {
+		return nil, err
+	}
+	if err := exporter.WriteValues(decisionSettingHitPolicy, table.HitPolicy); err != nil {
+		return nil, err
+	}
+	return exporter.SaveAsBytes()
+}
+
+// DecisionTableFromExcel 从 Excel 导入决策表
+// 没有列定义工作表时使用 base 的列，没有设置工作表时使用 base 的命中策略，决策表的表头按名称对应到列
+func DecisionTableFromExcel(r io.Reader, base *model.DecisionTable) (*model.DecisionTable, error) {
+	importer, err := excel.NewExcelImporter(r)
+	if err != nil {
+		return nil, err
+	}
+	defer importer.Close()
+
+	table := &model.DecisionTable{}
+	if base != nil {
+		table.HitPolicy = base.HitPolicy
+		table.Inputs = base.Inputs
+		table.Outputs = base.Outputs
+	}
+
+	if importer.HasSheet(decisionSheetColumns) {
+		rows, err := importer.ReadRows(decisionSheetColumns)
+		if err != nil {
+			return nil, err
+		}
+		table.Inputs, table.Outputs = nil, nil
+		for i, row := range rows {
+			if i == 0 || isBlankRow(row) {
+				continue
+			}
+			col := &model.DecisionColumn{Name: cellAt(row, 1), Field: cellAt(row, 2), Type: cellAt(row, 3)}
+			switch cellAt(row, 0) {
+			case decisionColumnInput:
+				col.Domain = cellAt(row, 4)
+				table.Inputs = append(table.Inputs, col)
+			case decisionColumnOutput:
+				col.Default = numberCell(col.Type, cellAt(row, 4))
+				table.Outputs = append(table.Outputs, col)
+			default:
+				return nil, fmt.Errorf("%s第 %d 行: 列类型只能是%s或%s", decisionSheetColumns, i+1, decisionColumnInput, decisionColumnOutput)
+			}
+		}
+	}
+	if len(table.Inputs) == 0 || len(table.Outputs) == 0 {
+		return nil, fmt.Errorf("缺少列定义，请在%s工作表中定义输入列和输出列", decisionSheetColumns)
+	}
+
+	if importer.HasSheet(decisionSheetSettings) {
+		rows, err := importer.ReadRows(decisionSheetSettings)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if cellAt(row, 0) == decisionSettingHitPolicy {
+				table.HitPolicy = cellAt(row, 1)
+			}
+		}
+	}
+
+	rows, err := importer.ReadRows(decisionSheetRows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s工作表缺少表头", decisionSheetRows)
+	}
+
+	// 表头按名称对应到列，顺序可以与列定义不同
+	inputs := make(map[string]int, len(table.Inputs))
+	for i, col := range table.Inputs {
+		inputs[col.Name] = i
+	}
+	outputs := make(map[string]int, len(table.Outputs))
+	for i, col := range table.Outputs {
+		outputs[col.Name] = i
+	}
+	inputAt := make([]int, len(table.Inputs))
+	outputAt := make([]int, len(table.Outputs))
+	for i := range inputAt {
+		inputAt[i] = -1
+	}
+	for i := range outputAt {
+		outputAt[i] = -1
+	}
+	priorityAt, descriptionAt := -1, -1
+	for i, name := range rows[0] {
+		name = strings.TrimSpace(name)
+		if j, ok := inputs[name]; ok {
+			inputAt[j] = i
+		} else if j, ok := outputs[name]; ok {
+			outputAt[j] = i
+		} else if name == decisionHeaderPriority {
+			priorityAt = i
+		} else if name == decisionHeaderDescription {
+			descriptionAt = i
+		} else if name != "" {
+			return nil, fmt.Errorf("%s表头第 %d 列 %s 未定义", decisionSheetRows, i+1, name)
+		}
+	}
+	for i, at := range inputAt {
+		if at < 0 {
+			return nil, fmt.Errorf("%s表头缺少输入列 %s", decisionSheetRows, table.Inputs[i].Name)
+		}
+	}
+	for i, at := range outputAt {
+		if at < 0 {
+			return nil, fmt.Errorf("%s表头缺少输出列 %s", decisionSheetRows, table.Outputs[i].Name)
+		}
+	}
+
+	for i, cells := range rows[1:] {
+		if isBlankRow(cells) {
+			continue
+		}
+		row := &model.DecisionRow{
+			Inputs:      make([]string, len(inputAt)),
+			Outputs:     make([]string, len(outputAt)),
+			Description: cellAt(cells, descriptionAt),
+		}
+		for j, at := range inputAt {
+			row.Inputs[j] = numberCell(table.Inputs[j].Type, cellAt(cells, at))
+		}
+		for j, at := range outputAt {
+			row.Outputs[j] = numberCell(table.Outputs[j].Type, cellAt(cells, at))
+		}
+		if priority := cellAt(cells, priorityAt); priority != "" {
+			n, err := strconv.ParseInt(priority, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s第 %d 行: 优先级必须是整数", decisionSheetRows, i+2)
+			}
+			row.Priority = int32(n)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	if _, err := table.Compile(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// cellAt 获取行中的单元格，超出行的长度时为空
+func cellAt(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// numberCell 数字列的单元格按原始值读取，浮点数可能是 7.0000000000000007E-2 这样的形式，转换为最短的十进制表示
+// 区间、比较等文本单元格不是数字，原样返回
+func numberCell(valueType, text string) string {
+	if valueType != model.DecisionTypeNumber {
+		return text
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return text
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/excel"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/xuri/excelize/v2"
+)
+
+func decisionTableRule(t *testing.T, hitPolicy string) *model.Rule {
+	table := &model.DecisionTable{
+		HitPolicy: hitPolicy,
+		Inputs: []*model.DecisionColumn{
+			{Name: "地区", Field: "order.region", Type: model.DecisionTypeString},
+			{Name: "金额", Field: "order.amount", Type: model.DecisionTypeNumber},
+		},
+		Outputs: []*model.DecisionColumn{
+			{Name: "费率", Field: "rate", Type: model.DecisionTypeNumber},
+			{Name: "免审", Field: "skipReview", Type: model.DecisionTypeBool},
+		},
+		Rows: []*model.DecisionRow{
+			{Inputs: []string{"east", "<1000"}, Outputs: []string{"0.01", "是"}, Description: "小额"},
+			{Inputs: []string{"east,\"south, west\"", ">=500"}, Outputs: []string{"0.005", "false"}, Priority: 3},
+		},
+	}
+	data, err := table.ToJSON()
+	if err != nil {
+		t.Fatalf("to json: %v", err)
+	}
+	return &model.Rule{ID: "r1", Code: "fee", Type: "decision_table", Action: "allow", DecisionTable: data}
+}
+
+func TestExecuteDecisionTableRule(t *testing.T) {
+	s := NewRuleExecutionService(nil, nil, nil, nil, nil, nil, nil, nil)
+	order := func(region string, amount interface{}) *model.RuleContext {
+		rc := model.NewRuleContext("order", "create", "before", "")
+		rc.SetData(map[string]interface{}{"order": map[string]interface{}{"region": region, "amount": amount}})
+		return rc
+	}
+
+	result, err := s.executeRuleWithExecutor(context.Background(), decisionTableRule(t, model.DecisionHitFirst), order("east", 600), nil)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !result.Valid || result.Context["rate"] != 0.01 || result.Context["skipReview"] != true || fmt.Sprint(result.Context["matched_rows"]) != "[1]" {
+		t.Fatalf("first = %+v", result)
+	}
+
+	result, _ = s.executeRuleWithExecutor(context.Background(), decisionTableRule(t, model.DecisionHitCollect), order("east", 600), nil)
+	if fmt.Sprint(result.Context["rate"]) != "[0.01 0.005]" || fmt.Sprint(result.Context["matched_rows"]) != "[1 2]" {
+		t.Fatalf("collect = %+v", result.Context)
+	}
+
+	result, _ = s.executeRuleWithExecutor(context.Background(), decisionTableRule(t, model.DecisionHitFirst), order("north", 600), nil)
+	if result.Valid || result.ErrorReason != "decision_table_no_match" {
+		t.Fatalf("no match = %+v", result)
+	}
+
+	if _, err := s.executeRuleWithExecutor(context.Background(), decisionTableRule(t, model.DecisionHitUnique), order("east", 600), nil); err == nil {
+		t.Fatal("unique with multiple hits should fail")
+	}
+
+	// 编译结果按规则版本缓存，同一规则内容变化时替换而不是累积
+	if s.tables.len() != 1 {
+		t.Fatalf("cached tables = %d", s.tables.len())
+	}
+}
+
+func TestDecisionTableExcelRoundTrip(t *testing.T) {
+	compiled, err := decisionTableRule(t, model.DecisionHitPriority).CompileDecisionTable()
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	table := compiled.Table
+	data, err := DecisionTableToExcel(table)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	imported, err := DecisionTableFromExcel(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	want, _ := table.ToJSON()
+	got, _ := imported.ToJSON()
+	if got != want {
+		t.Fatalf("round trip:\n got %s\nwant %s", got, want)
+	}
+
+	// 只有决策表工作表时沿用已有的列，表头顺序可以不同
+	exporter := excel.NewExcelExporter("决策表")
+	exporter.WriteValues("说明", "金额", "费率", "地区", "免审")
+	exporter.WriteValues("", ">=0", 0.02, "west", "否")
+	exporter.WriteValues()
+	exporter.WriteValues("大额", 5000, 0.001, "west", "是")
+	data, err = exporter.SaveAsBytes()
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	imported, err = DecisionTableFromExcel(bytes.NewReader(data), table)
+	if err != nil {
+		t.Fatalf("import rows only: %v", err)
+	}
+	if imported.HitPolicy != model.DecisionHitPriority || len(imported.Rows) != 2 {
+		t.Fatalf("imported = %+v", imported)
+	}
+	if row := imported.Rows[1]; strings.Join(row.Inputs, "|") != "west|5000" || strings.Join(row.Outputs, "|") != "0.001|是" || row.Description != "大额" {
+		t.Fatalf("row = %+v", row)
+	}
+
+	// 数字单元格的原始值是浮点数的完整精度，导入时转换为最短的十进制表示
+	f := excelize.NewFile()
+	_ = f.SetSheetName(f.GetSheetName(0), "决策表")
+	_ = f.SetSheetRow("决策表", "A1", &[]interface{}{"地区", "金额", "费率", "免审"})
+	_ = f.SetSheetRow("决策表", "A2", &[]interface{}{"east", ">=100", nil, "否"})
+	_ = f.SetCellFloat("决策表", "C2", 0.07, 17, 64)
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if raw, _ := f.GetCellValue("决策表", "C2", excelize.Options{RawCellValue: true}); raw == "0.07" {
+		t.Fatalf("raw cell value = %s, want full precision", raw)
+	}
+	imported, err = DecisionTableFromExcel(bytes.NewReader(buf.Bytes()), table)
+	if err != nil {
+		t.Fatalf("import numeric cell: %v", err)
+	}
+	if row := imported.Rows[0]; row.Outputs[0] != "0.07" || row.Inputs[1] != ">=100" {
+		t.Fatalf("row = %+v", row)
+	}
+
+	if _, err := DecisionTableFromExcel(bytes.NewReader(data), nil); err == nil {
+		t.Fatal("import without column definitions should fail")
+	}
+	exporter = excel.NewExcelExporter("决策表")
+	exporter.WriteValues("地区", "金额", "费率", "折扣")
+	data, _ = exporter.SaveAsBytes()
+	if _, err := DecisionTableFromExcel(bytes.NewReader(data), table); err == nil || !strings.Contains(err.Error(), "折扣") {
+		t.Fatalf("unknown header should fail, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// ActionHandler 规则动作处理器，由宿主应用按动作类型注册
+// rc 为规则执行时的上下文，处理器可以修改 rc.Data，修改后的数据作为下一个规则的输入
+type ActionHandler interface {
+	Handle(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) error
+}
+
+// ActionHandlerFunc 函数形式的动作处理器
+type ActionHandlerFunc func(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) error
+
+// Handle 执行动作
+func (f ActionHandlerFunc) Handle(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) error {
+	return f(ctx, action, rc)
+}
+
+// ActionModify 修改上下文数据的动作
+const ActionModify = "modify"
+
+type actionEntry struct {
+	handler ActionHandler
+	dryRun  bool // 模拟执行时是否也执行
+}
+
+// ActionRegistry 规则动作处理器注册表
+type ActionRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]actionEntry
+}
+
+// NewActionRegistry 创建动作处理器注册表，默认注册 modify 处理器
+func NewActionRegistry() *ActionRegistry {
+	r := &ActionRegistry{handlers: make(map[string]actionEntry)}
+	r.RegisterDryRun(ActionModify, ActionHandlerFunc(modifyAction))
+	return r
+}
+
+// Register 注册动作处理器，适用于发送消息等有副作用的动作，规则链结束且最终结果为允许时才执行，模拟执行时跳过
+func (r *ActionRegistry) Register(action string, handler ActionHandler) {
+	r.register(action, handler, false)
+}
+
+// RegisterDryRun 注册动作处理器，规则通过时立即执行，模拟执行时也执行，适用于只修改上下文的动作
+func (r *ActionRegistry) RegisterDryRun(action string, handler ActionHandler) {
+	r.register(action, handler, true)
+}
+
+func (r *ActionRegistry) register(action string, handler ActionHandler, dryRun bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[action] = actionEntry{handler: handler, dryRun: dryRun}
+}
+
+// Execute 执行规则动作并记录执行状态，没有注册处理器的动作保持 pending 由调用方处理
+// 动作执行失败不影响规则结果
+func (r *ActionRegistry) Execute(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) {
+	entry, ok := r.lookup(action.Type)
+	if !ok {
+		return
+	}
+	r.run(ctx, entry, action, rc)
+}
+
+// ExecuteInline 规则通过时立即执行 RegisterDryRun 注册的动作，修改后的上下文作为下一个规则的输入
+// 有副作用的动作保持 pending，由 ExecuteDeferred 在规则链结束后执行
+func (r *ActionRegistry) ExecuteInline(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) {
+	entry, ok := r.lookup(action.Type)
+	if !ok || !entry.dryRun {
+		return
+	}
+	r.run(ctx, entry, action, rc)
+}
+
+// ExecuteDeferred 规则链结束后执行 Register 注册的有副作用的动作，rc 为规则链最终的上下文
+// allowed 为 false 时最终结果为拒绝，动作不再执行，状态为 skipped
+func (r *ActionRegistry) ExecuteDeferred(ctx context.Context, actions []*model.RuleAction, rc *model.RuleContext, allowed bool) {
+	for _, action := range actions {
+		if action.Status != model.ActionStatusPending {
+			continue
+		}
+		entry, ok := r.lookup(action.Type)
+		if !ok || entry.dryRun {
+			continue
+		}
+		if !allowed {
+			action.Status = model.ActionStatusSkipped
+			continue
+		}
+		r.run(ctx, entry, action, rc)
+	}
+}
+
+func (r *ActionRegistry) lookup(action string) (actionEntry, bool) {
+	if r == nil {
+		return actionEntry{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.handlers[action]
+	return entry, ok
+}
+
+func (r *ActionRegistry) run(ctx context.Context, entry actionEntry, action *model.RuleAction, rc *model.RuleContext) {
+	if isDryRun(ctx) && !entry.dryRun {
+		action.Status = model.ActionStatusSkipped
+		return
+	}
+	if err := entry.handler.Handle(ctx, action, rc); err != nil {
+		action.Status = model.ActionStatusFailed
+		action.Error = err.Error()
+		return
+	}
+	action.Status = model.ActionStatusExecuted
+}
+
+// modifyAction 将规则输出合并到上下文数据
+// 参数中有 patch 时只合并 patch，键可以是 order.amount 形式的路径
+func modifyAction(_ context.Context, action *model.RuleAction, rc *model.RuleContext) error {
+	patch := action.Params
+	if p, ok := action.Params["patch"]; ok {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("patch must be an object, got %T", p)
+		}
+		patch = m
+	}
+	data := make(map[string]interface{}, len(rc.Data)+len(patch))
+	for k, v := range rc.Data {
+		data[k] = v
+	}
+	for path, value := range patch {
+		if err := setPath(data, strings.Split(path, "."), value); err != nil {
+			return fmt.Errorf("patch %s: %v", path, err)
+		}
+	}
+	rc.Data = data
+	return nil
+}
+
+// setPath 按路径设置值，途经的对象复制后再修改，不影响上一个规则的输入
+func setPath(data map[string]interface{}, keys []string, value interface{}) error {
+	if len(keys) == 1 {
+		data[keys[0]] = value
+		return nil
+	}
+	child := make(map[string]interface{})
+	switch v := data[keys[0]].(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, item := range v {
+			child[k] = item
+		}
+	default:
+		return fmt.Errorf("%s is not an object", keys[0])
+	}
+	data[keys[0]] = child
+	return setPath(child, keys[1:], value)
+}
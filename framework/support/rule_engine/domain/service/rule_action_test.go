@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/lua_engine"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+)
+
+// strategyCategoryRepository 返回指定执行策略的分类仓储
+type strategyCategoryRepository struct {
+	repository.ICategoryRepository
+	strategies map[string]string
+	finds      int
+}
+
+func (r *strategyCategoryRepository) FindByID(ctx context.Context, id string) (*model.RuleCategory, error) {
+	r.finds++
+	return &model.RuleCategory{ID: id, ExecutionStrategy: r.strategies[id]}, nil
+}
+
+func (r *strategyCategoryRepository) FindAll(ctx context.Context) ([]*model.RuleCategory, error) {
+	var categories []*model.RuleCategory
+	for id, strategy := range r.strategies {
+		categories = append(categories, &model.RuleCategory{ID: id, ExecutionStrategy: strategy})
+	}
+	return categories, nil
+}
+
+func chainRule(id, categoryID string, priority int32, formula, action string) *model.Rule {
+	return &model.Rule{ID: id, Code: id, CategoryID: categoryID, Type: "formula", Formula: formula, Action: action,
+		Status: 1, Priority: priority, Scope: "order", Triggers: []string{"create"}, ExecutionTiming: "both"}
+}
+
+func TestExecuteRulesChainStrategies(t *testing.T) {
+	repo := &scopeRuleRepository{rules: []*model.Rule{
+		chainRule("limit", "c1", 3, "amount > 1000", "deny"),
+		chainRule("double", "c1", 2, "amount * 2", "modify"),
+		chainRule("review", "c1", 1, "result > 900", "deny"),
+		chainRule("fee", "c2", 0, "result + 1", "allow"),
+	}}
+	cases := []struct {
+		strategy string
+		amount   int
+		valid    bool
+		reason   string
+		steps    string
+		actions  int
+	}{
+		{model.ChainStrategyAll, 500, false, "", "[limit]", 0},
+		{model.ChainStrategyFirstMatch, 500, true, "", "[limit double fee]", 1},
+		{model.ChainStrategyFirstMatch, 2000, false, "rule_denied", "[limit]", 0},
+		{model.ChainStrategyCollect, 500, false, "", "[limit double review]", 1},
+		{model.ChainStrategyDenyOverrides, 2000, false, "rule_denied", "[limit double review]", 1},
+	}
+	for _, c := range cases {
+		categories := &strategyCategoryRepository{strategies: map[string]string{"c1": c.strategy}}
+		s := NewRuleExecutionService(repo, categories, nil, nil, nil, nil, nil, NewActionRegistry())
+		rc := model.NewRuleContext("order", "create", "before", "")
+		rc.SetData(map[string]interface{}{"amount": c.amount})
+		result, herr := s.ExecuteRules(context.Background(), rc)
+		if herr != nil {
+			t.Fatalf("%s: execute: %v", c.strategy, herr)
+		}
+		var steps []string
+		for _, step := range result.ExecutionChain {
+			steps = append(steps, step.RuleCode)
+		}
+		name := fmt.Sprintf("%s/%d", c.strategy, c.amount)
+		if result.Valid != c.valid || result.ErrorReason != c.reason || fmt.Sprint(steps) != c.steps || len(result.Actions) != c.actions {
+			t.Fatalf("%s: valid=%v reason=%q steps=%v actions=%d", name, result.Valid, result.ErrorReason, steps, len(result.Actions))
+		}
+		for _, action := range result.Actions {
+			if action.Type != ActionModify || action.Status != model.ActionStatusExecuted {
+				t.Fatalf("%s: action = %+v", name, action)
+			}
+		}
+	}
+
+	// first_match 下 modify 的输出作为后续分组的输入，collect 收集各规则的输出
+	categories := &strategyCategoryRepository{strategies: map[string]string{"c1": model.ChainStrategyFirstMatch}}
+	s := NewRuleExecutionService(repo, categories, nil, nil, nil, nil, nil, NewActionRegistry())
+	rc := model.NewRuleContext("order", "create", "before", "")
+	rc.SetData(map[string]interface{}{"amount": 500})
+	result, _ := s.ExecuteRules(context.Background(), rc)
+	if fmt.Sprint(result.GetLastExecutionStep().Output["result"]) != "1001" || fmt.Sprint(result.Context["result"]) != "1000" {
+		t.Fatalf("context = %v, last output = %v", result.Context, result.GetLastExecutionStep().Output)
+	}
+
+	categories.strategies["c1"] = model.ChainStrategyCollect
+	result, _ = s.ExecuteRules(context.Background(), rc)
+	if len(result.Outputs) != 2 || fmt.Sprint(result.Outputs["double"]["result"]) != "1000" || result.Outputs["review"]["result"] != true {
+		t.Fatalf("outputs = %v", result.Outputs)
+	}
+
+	// 没有注册处理器时动作由调用方处理
+	s = NewRuleExecutionService(repo, categories, nil, nil, nil, nil, nil, nil)
+	result, _ = s.ExecuteRules(context.Background(), rc)
+	if len(result.Actions) != 1 || result.Actions[0].Status != model.ActionStatusPending {
+		t.Fatalf("actions without registry = %+v", result.Actions)
+	}
+}
+
+// 不同分类的规则按优先级交错时保持全局顺序，分类的执行策略在规则各自的位置上生效
+func TestExecuteRulesInterleavedCategories(t *testing.T) {
+	repo := &scopeRuleRepository{rules: []*model.Rule{
+		chainRule("limit", "c1", 4, "amount > 1000", "deny"),
+		chainRule("double", "c2", 3, "amount * 2", "modify"),
+		chainRule("review", "c1", 2, "result > 900", "allow"),
+		chainRule("block", "c1", 1, "true", "deny"),
+		chainRule("fee", "c2", 0, "result + 1", "allow"),
+	}}
+	cases := []struct {
+		strategy string
+		valid    bool
+		reason   string
+		steps    string
+	}{
+		{model.ChainStrategyFirstMatch, true, "", "[limit double review fee]"},
+		{model.ChainStrategyDenyOverrides, false, "", "[limit double review block]"},
+		{model.ChainStrategyCollect, false, "", "[limit double review block]"},
+	}
+	for _, c := range cases {
+		categories := &strategyCategoryRepository{strategies: map[string]string{"c1": c.strategy}}
+		s := NewRuleExecutionService(repo, categories, nil, nil, nil, nil, nil, NewActionRegistry())
+		rc := model.NewRuleContext("order", "create", "before", "")
+		rc.SetData(map[string]interface{}{"amount": 500})
+		result, herr := s.ExecuteRules(context.Background(), rc)
+		if herr != nil {
+			t.Fatalf("%s: execute: %v", c.strategy, herr)
+		}
+		var steps []string
+		for _, step := range result.ExecutionChain {
+			steps = append(steps, step.RuleCode)
+		}
+		if result.Valid != c.valid || result.ErrorReason != c.reason || fmt.Sprint(steps) != c.steps {
+			t.Fatalf("%s: valid=%v reason=%q steps=%v", c.strategy, result.Valid, result.ErrorReason, steps)
+		}
+	}
+}
+
+// 脚本没有设置动作时使用规则配置的动作，配置为 deny 的规则在各策略下都是拒绝
+func TestExecuteRulesEmptyResultAction(t *testing.T) {
+	gate := &model.Rule{ID: "gate", Code: "gate", CategoryID: "c1", Type: "lua", LuaScript: "valid = true", Action: "deny",
+		Status: 1, Priority: 2, Scope: "order", Triggers: []string{"create"}, ExecutionTiming: "both"}
+	repo := &scopeRuleRepository{rules: []*model.Rule{gate, chainRule("fee", "c1", 1, "amount + 1", "allow")}}
+	for _, strategy := range []string{model.ChainStrategyFirstMatch, model.ChainStrategyDenyOverrides} {
+		categories := &strategyCategoryRepository{strategies: map[string]string{"c1": strategy}}
+		s := NewRuleExecutionService(repo, categories, nil, nil, lua_engine.NewRuleExecutor(), nil, nil, NewActionRegistry())
+		rc := model.NewRuleContext("order", "create", "before", "")
+		rc.SetData(map[string]interface{}{"amount": 500})
+		result, herr := s.ExecuteRules(context.Background(), rc)
+		if herr != nil {
+			t.Fatalf("%s: execute: %v", strategy, herr)
+		}
+		if result.Valid || result.ErrorReason != "rule_denied" {
+			t.Fatalf("%s: valid=%v reason=%q", strategy, result.Valid, result.ErrorReason)
+		}
+	}
+}
+
+// 有副作用的动作在规则链结束后执行，最终结果为拒绝时跳过
+func TestExecuteRulesDeferredActions(t *testing.T) {
+	repo := &scopeRuleRepository{rules: []*model.Rule{
+		chainRule("alert", "c1", 2, "amount > 100", "notify"),
+		chainRule("limit", "c1", 1, "amount < 1000", "allow"),
+	}}
+	for _, c := range []struct {
+		strategy string
+		amount   int
+		notified int
+		status   string
+	}{
+		{model.ChainStrategyAll, 500, 1, model.ActionStatusExecuted},
+		{model.ChainStrategyAll, 2000, 0, model.ActionStatusSkipped},
+		{model.ChainStrategyCollect, 2000, 0, model.ActionStatusSkipped},
+		{model.ChainStrategyDenyOverrides, 2000, 0, model.ActionStatusSkipped},
+	} {
+		registry := NewActionRegistry()
+		notified := 0
+		var seen interface{}
+		registry.Register("notify", ActionHandlerFunc(func(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) error {
+			notified++
+			seen = rc.Data["amount"]
+			return nil
+		}))
+		categories := &strategyCategoryRepository{strategies: map[string]string{"c1": c.strategy}}
+		s := NewRuleExecutionService(repo, categories, nil, nil, nil, nil, nil, registry)
+		rc := model.NewRuleContext("order", "create", "before", "")
+		rc.SetData(map[string]interface{}{"amount": c.amount})
+		result, herr := s.ExecuteRules(context.Background(), rc)
+		if herr != nil {
+			t.Fatalf("%s/%d: execute: %v", c.strategy, c.amount, herr)
+		}
+		if notified != c.notified || len(result.Actions) != 1 || result.Actions[0].Status != c.status {
+			t.Fatalf("%s/%d: notified=%d actions=%+v", c.strategy, c.amount, notified, result.Actions)
+		}
+		if notified > 0 && seen != c.amount {
+			t.Fatalf("%s/%d: handler context amount = %v", c.strategy, c.amount, seen)
+		}
+	}
+}
+
+func TestActionRegistry(t *testing.T) {
+	registry := NewActionRegistry()
+	notified := 0
+	registry.Register("notify", ActionHandlerFunc(func(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) error {
+		notified++
+		return nil
+	}))
+	registry.Register("redirect", ActionHandlerFunc(func(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) error {
+		return fmt.Errorf("no target")
+	}))
+
+	order := map[string]interface{}{"amount": 100, "region": "east"}
+	rc := model.NewRuleContext("order", "create", "before", "")
+	rc.SetData(map[string]interface{}{"order": order})
+	rule := &model.Rule{ID: "r1", Code: "discount"}
+
+	modify := model.NewRuleAction(rule, ActionModify, map[string]interface{}{
+		"result": 1,
+		"patch":  map[string]interface{}{"order.amount": 90, "order.tags.vip": true},
+	})
+	registry.Execute(context.Background(), modify, rc)
+	got := rc.Data["order"].(map[string]interface{})
+	if modify.Status != model.ActionStatusExecuted || got["amount"] != 90 || got["region"] != "east" ||
+		fmt.Sprint(got["tags"]) != "map[vip:true]" || rc.Data["result"] != nil {
+		t.Fatalf("modify = %+v, data = %v", modify, rc.Data)
+	}
+	if order["amount"] != 100 {
+		t.Fatalf("patch modified the previous input: %v", order)
+	}
+
+	bad := model.NewRuleAction(rule, ActionModify, map[string]interface{}{"order.region.code": "e"})
+	registry.Execute(context.Background(), bad, rc)
+	if bad.Status != model.ActionStatusFailed {
+		t.Fatalf("patch through a value should fail: %+v", bad)
+	}
+
+	dryRun := context.WithValue(context.Background(), dryRunKey{}, true)
+	for _, c := range []struct {
+		ctx    context.Context
+		action string
+		status string
+	}{
+		{dryRun, "notify", model.ActionStatusSkipped},
+		{dryRun, ActionModify, model.ActionStatusExecuted},
+		{context.Background(), "notify", model.ActionStatusExecuted},
+		{context.Background(), "redirect", model.ActionStatusFailed},
+		{context.Background(), "unknown", model.ActionStatusPending},
+	} {
+		action := model.NewRuleAction(rule, c.action, map[string]interface{}{})
+		registry.Execute(c.ctx, action, rc)
+		if action.Status != c.status {
+			t.Fatalf("%s: status = %s, want %s", c.action, action.Status, c.status)
+		}
+	}
+	if notified != 1 {
+		t.Fatalf("notified = %d", notified)
+	}
+}
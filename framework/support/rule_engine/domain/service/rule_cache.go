@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/plugin"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+)
+
+const (
+	defaultRuleCacheRefreshInterval = 5 * time.Minute
+	ruleCacheReloadTimeout          = 30 * time.Second
+)
+
+// RuleChangeNotifier 规则变更通知，用于多实例之间同步规则缓存
+type RuleChangeNotifier interface {
+	// Publish 通知其他实例规则已变更，source 为发送通知的实例标识
+	Publish(ctx context.Context, source string) error
+	// Subscribe 订阅规则变更通知，阻塞直到 ctx 取消
+	Subscribe(ctx context.Context, handler func(source string))
+}
+
+// RuleCacheConfig 规则缓存配置
+type RuleCacheConfig struct {
+	// RefreshInterval 定时全量刷新间隔，防止丢失变更通知，为 0 时使用 5 分钟，小于 0 时不定时刷新
+	RefreshInterval time.Duration
+}
+
+func (c RuleCacheConfig) withDefaults() RuleCacheConfig {
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = defaultRuleCacheRefreshInterval
+	}
+	return c
+}
+
+// RuleCache 启用规则和规则分类的内存缓存
+//   - 启动时加载所有租户的规则和分类并建立索引，执行规则时不再查询数据库
+//   - 本实例修改规则或分类后立即重建索引，并通过 notifier 通知其他实例重建
+//   - 收到的通知合并处理，定时全量刷新作为兜底
+//
+// 缓存为 nil 或尚未加载时 Match 返回 false，调用方回退到查询数据库
+type RuleCache struct {
+	ruleRepo     repository.IRuleRepository
+	categoryRepo repository.ICategoryRepository
+	notifier     RuleChangeNotifier
+	cfg          RuleCacheConfig
+	instanceID   string
+	index        atomic.Pointer[RuleIndex]
+	reloadMu     sync.Mutex    // 串行重建，保证后开始的重建读取到最新的规则
+	reloadCh     chan struct{} // 待处理的重建请求，容量为 1，多个请求合并为一次
+	cancel       context.CancelFunc
+	done         chan struct{}
+}
+
+// NewRuleCache 创建规则缓存并启动通知订阅，notifier 为 nil 时只在本实例内生效
+// 创建后需要调用 Reload 加载规则
+func NewRuleCache(ruleRepo repository.IRuleRepository, categoryRepo repository.ICategoryRepository, notifier RuleChangeNotifier, cfg RuleCacheConfig) *RuleCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	hostname, _ := os.Hostname()
+	c := &RuleCache{
+		ruleRepo:     ruleRepo,
+		categoryRepo: categoryRepo,
+		notifier:     notifier,
+		cfg:          cfg.withDefaults(),
+		instanceID:   fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		reloadCh:     make(chan struct{}, 1),
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	if notifier != nil {
+		go notifier.Subscribe(ctx, c.onNotify)
+	}
+	go c.run(ctx)
+	return c
+}
+
+// Match 查找匹配执行上下文的规则，结果未排序
+// 上下文中有租户时只匹配该租户的规则，没有租户或忽略租户时匹配所有租户的规则，与数据库查询一致
+func (c *RuleCache) Match(ctx context.Context, context *model.RuleContext) ([]*model.Rule, bool) {
+	if c == nil {
+		return nil, false
+	}
+	idx := c.index.Load()
+	if idx == nil {
+		return nil, false
+	}
+	tenantID := ""
+	if !plugin.IsIgnoreTenant(ctx) {
+		tenantID = plugin.GetCtxTenantID(ctx)
+	}
+	return idx.Match(tenantID, context), true
+}
+
+// Category 获取缓存的规则分类，缓存为 nil 或尚未加载时返回 false，调用方回退到查询数据库
+// 分类不存在时返回 nil 和 true
+func (c *RuleCache) Category(id string) (*model.RuleCategory, bool) {
+	if c == nil {
+		return nil, false
+	}
+	idx := c.index.Load()
+	if idx == nil {
+		return nil, false
+	}
+	return idx.Category(id), true
+}
+
+// Reload 从数据库加载所有规则和分类并重建索引
+func (c *RuleCache) Reload(ctx context.Context) error {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(actx.BuildIgnoreTenantCtx(ctx), ruleCacheReloadTimeout)
+	defer cancel()
+	rules, err := c.ruleRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	var categories []*model.RuleCategory
+	if c.categoryRepo != nil {
+		if categories, err = c.categoryRepo.FindAll(ctx); err != nil {
+			return err
+		}
+	}
+	c.index.Store(NewRuleIndex(rules, categories))
+	return nil
+}
+
+// Invalidate 规则或分类变更后调用，重建本实例的索引并通知其他实例
+// 重建失败时由后台重试，不影响规则的修改
+func (c *RuleCache) Invalidate(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	if err := c.Reload(context.WithoutCancel(ctx)); err != nil {
+		hlog.CtxErrorf(ctx, "rule engine: reload rule cache error: %v", err)
+		c.requestReload()
+	}
+	if c.notifier != nil {
+		if err := c.notifier.Publish(context.WithoutCancel(ctx), c.instanceID); err != nil {
+			hlog.CtxErrorf(ctx, "rule engine: publish rule change error: %v", err)
+		}
+	}
+}
+
+// onNotify 收到其他实例的变更通知，忽略本实例发出的通知
+func (c *RuleCache) onNotify(source string) {
+	if source == c.instanceID {
+		return
+	}
+	c.requestReload()
+}
+
+// requestReload 请求后台重建，已有待处理的请求时忽略
+func (c *RuleCache) requestReload() {
+	select {
+	case c.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *RuleCache) run(ctx context.Context) {
+	defer close(c.done)
+	var refresh <-chan time.Time
+	if c.cfg.RefreshInterval > 0 {
+		ticker := time.NewTicker(c.cfg.RefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.reloadCh:
+		case <-refresh:
+		}
+		if err := c.Reload(ctx); err != nil {
+			hlog.Errorf("rule engine: reload rule cache error: %v", err)
+		}
+	}
+}
+
+// Close 停止通知订阅和定时刷新
+func (c *RuleCache) Close() {
+	if c == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
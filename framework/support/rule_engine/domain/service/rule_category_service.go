@@ -18,6 +18,7 @@ type RuleCategoryService struct {
 	templateRepo repository.ITemplateRepository
 	ruleRepo     repository.IRuleRepository
 	ig           snowflake_id.IIdGenerate
+	cache        *RuleCache // 规则缓存，分类变更后刷新，为 nil 时不使用缓存
 }
 
 // NewRuleCategoryService 创建规则分类服务
@@ -26,12 +27,14 @@ func NewRuleCategoryService(
 	templateRepo repository.ITemplateRepository,
 	ruleRepo repository.IRuleRepository,
 	ig snowflake_id.IIdGenerate,
+	cache *RuleCache,
 ) *RuleCategoryService {
 	return &RuleCategoryService{
 		categoryRepo: categoryRepo,
 		templateRepo: templateRepo,
 		ruleRepo:     ruleRepo,
 		ig:           ig,
+		cache:        cache,
 	}
 }
 
@@ -135,6 +138,7 @@ func (s *RuleCategoryService) UpdateCategory(ctx context.Context, category *mode
 	if err := s.categoryRepo.Update(ctx, category); err != nil {
 		return ruleengineerr.RuleCategoryUpdateFailed(err)
 	}
+	s.cache.Invalidate(ctx)
 
 	return nil
 }
@@ -178,6 +182,7 @@ func (s *RuleCategoryService) DeleteCategory(ctx context.Context, categoryID str
 	if err := s.categoryRepo.Delete(ctx, categoryID); err != nil {
 		return ruleengineerr.RuleCategoryDeleteFailed(err)
 	}
+	s.cache.Invalidate(ctx)
 
 	return nil
 }
@@ -286,6 +291,7 @@ func (s *RuleCategoryService) EnableCategory(ctx context.Context, categoryID str
 	if err := s.categoryRepo.Update(ctx, category); err != nil {
 		return ruleengineerr.RuleCategoryUpdateFailed(err)
 	}
+	s.cache.Invalidate(ctx)
 
 	return nil
 }
@@ -301,6 +307,7 @@ func (s *RuleCategoryService) DisableCategory(ctx context.Context, categoryID st
 	if err := s.categoryRepo.Update(ctx, category); err != nil {
 		return ruleengineerr.RuleCategoryUpdateFailed(err)
 	}
+	s.cache.Invalidate(ctx)
 
 	return nil
 }
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// defaultCompiledCacheSize 默认缓存的规则数量
+const defaultCompiledCacheSize = 1024
+
+// compiledCache 规则内容的解析结果缓存，按规则ID和版本缓存，避免每次执行都重新解析
+// 草稿和模拟执行的规则与线上版本ID、版本号相同但内容不同，缓存项同时记录源内容，不一致时重新解析并替换
+type compiledCache[T any] struct {
+	mu    sync.RWMutex
+	size  int
+	items map[string]compiledEntry[T]
+}
+
+type compiledEntry[T any] struct {
+	source string
+	value  T
+}
+
+func newCompiledCache[T any](size int) *compiledCache[T] {
+	if size <= 0 {
+		size = defaultCompiledCacheSize
+	}
+	return &compiledCache[T]{size: size, items: make(map[string]compiledEntry[T])}
+}
+
+// get 获取规则 source 内容的解析结果，未缓存时调用 compile 解析并缓存，解析失败不缓存
+func (c *compiledCache[T]) get(rule *model.Rule, source string, compile func(source string) (T, error)) (T, error) {
+	key := rule.ID + "@" + rule.Version
+	c.mu.RLock()
+	entry, ok := c.items[key]
+	c.mu.RUnlock()
+	if ok && entry.source == source {
+		return entry.value, nil
+	}
+
+	value, err := compile(source)
+	if err != nil {
+		return value, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.items[key]; !exists && len(c.items) >= c.size {
+		// 缓存已满时随机淘汰一个，规则发布新版本后旧版本的缓存不会再被使用
+		for k := range c.items {
+			delete(c.items, k)
+			break
+		}
+	}
+	c.items[key] = compiledEntry[T]{source: source, value: value}
+	return value, nil
+}
+
+// len 缓存的规则数量
+func (c *compiledCache[T]) len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
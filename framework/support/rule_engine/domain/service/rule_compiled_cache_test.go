@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+func TestCompiledCache(t *testing.T) {
+	cache := newCompiledCache[*model.ConditionNode](2)
+	compiles := 0
+	parse := func(source string) (*model.ConditionNode, error) {
+		compiles++
+		return model.ParseConditionTreeJSON(source)
+	}
+	rule := &model.Rule{ID: "r1", Version: "1"}
+	cond := `{"field":"amount","operator":"gt","value":100}`
+
+	first, err := cache.get(rule, cond, parse)
+	if err != nil || first == nil {
+		t.Fatalf("get = %v, %v", first, err)
+	}
+	if second, _ := cache.get(rule, cond, parse); second != first || compiles != 1 {
+		t.Fatalf("cached tree not reused, compiles = %d", compiles)
+	}
+
+	// 相同版本的草稿内容不同，重新解析
+	draft := `{"field":"amount","operator":"gt","value":200}`
+	if tree, _ := cache.get(rule, draft, parse); tree == first || compiles != 2 || cache.len() != 1 {
+		t.Fatalf("changed source reused cache, compiles = %d, len = %d", compiles, cache.len())
+	}
+
+	// 解析失败不缓存
+	if _, err = cache.get(&model.Rule{ID: "r2", Version: "1"}, `{`, parse); err == nil || cache.len() != 1 {
+		t.Fatalf("invalid source = %v, len = %d", err, cache.len())
+	}
+
+	// 超过容量时淘汰
+	cache.get(&model.Rule{ID: "r2", Version: "1"}, cond, parse)
+	cache.get(&model.Rule{ID: "r3", Version: "1"}, cond, parse)
+	if cache.len() != 2 {
+		t.Fatalf("len = %d", cache.len())
+	}
+}
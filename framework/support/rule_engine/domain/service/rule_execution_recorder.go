@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/flare-admin/flare-server-go/framework/support/base/metrics"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+)
+
+const (
+	defaultRecorderBatchSize       = 100
+	defaultRecorderFlushInterval   = time.Second
+	defaultRecorderQueueSize       = 10000
+	defaultRecorderRetentionDays   = 30
+	defaultRecorderCleanupInterval = time.Hour
+	recorderFlushTimeout           = 10 * time.Second
+)
+
+// RecorderConfig 执行记录器配置
+type RecorderConfig struct {
+	// Disabled 关闭执行日志，只更新规则的执行次数和监控指标
+	Disabled bool
+	// SampleRate 成功执行的日志采样率(0-1]，为 0 时使用 1，小于 0 时只记录失败的执行；失败的执行总是记录
+	SampleRate float64
+	// RecordData 是否记录每个步骤的输入输出数据
+	RecordData bool
+	// BatchSize 每批写入的日志数量
+	BatchSize int
+	// FlushInterval 写入间隔，队列中的日志最长等待该时间后写入
+	FlushInterval time.Duration
+	// QueueSize 写入队列长度，队列已满时丢弃日志，规则执行不受影响
+	QueueSize int
+	// RetentionDays 日志保留天数，为 0 时使用 30，小于 0 时不清理
+	RetentionDays int
+	// CleanupInterval 过期日志清理间隔
+	CleanupInterval time.Duration
+}
+
+func (c RecorderConfig) withDefaults() RecorderConfig {
+	if c.SampleRate == 0 || c.SampleRate > 1 {
+		c.SampleRate = 1
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultRecorderBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultRecorderFlushInterval
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultRecorderQueueSize
+	}
+	if c.RetentionDays == 0 {
+		c.RetentionDays = defaultRecorderRetentionDays
+	}
+	if c.CleanupInterval <= 0 {
+		c.CleanupInterval = defaultRecorderCleanupInterval
+	}
+	return c
+}
+
+// executionRecord 等待写入的执行记录
+type executionRecord struct {
+	log     *model.RuleExecutionLog
+	sampled bool // 是否保存日志，未采样的记录只累加规则的执行次数
+}
+
+// RuleExecutionRecorder 规则执行记录器
+//   - 监控指标在执行时同步记录，执行日志和规则执行次数通过队列异步批量写入，不阻塞规则执行
+//   - 规则执行次数按全部执行累加，执行日志按采样率保存，统计时按权重还原
+//   - 进程退出时 Close 写入队列中剩余的记录
+type RuleExecutionRecorder struct {
+	logRepo  repository.IRuleExecutionLogRepository
+	ruleRepo repository.IRuleRepository
+	cfg      RecorderConfig
+	queue    chan *executionRecord
+	random   func() float64
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewRuleExecutionRecorder 创建并启动执行记录器
+func NewRuleExecutionRecorder(logRepo repository.IRuleExecutionLogRepository, ruleRepo repository.IRuleRepository, cfg RecorderConfig) *RuleExecutionRecorder {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &RuleExecutionRecorder{
+		logRepo:  logRepo,
+		ruleRepo: ruleRepo,
+		cfg:      cfg.withDefaults(),
+		random:   rand.Float64,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	r.queue = make(chan *executionRecord, r.cfg.QueueSize)
+	go r.run(ctx)
+	return r
+}
+
+// Record 记录一次规则执行，ruleCode 为按编码执行时的规则编码
+// 记录器为 nil 或执行链路为空时忽略
+func (r *RuleExecutionRecorder) Record(rc *model.RuleContext, result *model.RuleResult, ruleCode string) {
+	if r == nil || result == nil || len(result.ExecutionChain) == 0 {
+		return
+	}
+	for _, step := range result.ExecutionChain {
+		metrics.ObserveRuleExecution(step.RuleCode, step.IsSuccess(), step.Elapsed)
+	}
+
+	sampled := !r.cfg.Disabled && (!result.IsSuccess() || r.sample())
+	log := model.NewRuleExecutionLog(rc, result, ruleCode, sampled && r.cfg.RecordData)
+	if sampled && result.IsSuccess() {
+		log.SetWeight(1 / r.cfg.SampleRate)
+	}
+
+	select {
+	case r.queue <- &executionRecord{log: log, sampled: sampled}:
+	default:
+		metrics.RuleExecutionLogDroppedCounter.Inc()
+	}
+}
+
+// sample 按采样率决定是否保存成功执行的日志
+func (r *RuleExecutionRecorder) sample() bool {
+	if r.cfg.SampleRate < 0 {
+		return false
+	}
+	return r.cfg.SampleRate >= 1 || r.random() < r.cfg.SampleRate
+}
+
+func (r *RuleExecutionRecorder) run(ctx context.Context) {
+	defer close(r.done)
+	flushTicker := time.NewTicker(r.cfg.FlushInterval)
+	defer flushTicker.Stop()
+	cleanupTicker := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanupTicker.Stop()
+
+	batch := make([]*executionRecord, 0, r.cfg.BatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			// 写入队列中剩余的记录
+			for {
+				select {
+				case record := <-r.queue:
+					batch = append(batch, record)
+					if len(batch) >= r.cfg.BatchSize {
+						r.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					r.flush(batch)
+					return
+				}
+			}
+		case record := <-r.queue:
+			batch = append(batch, record)
+			if len(batch) >= r.cfg.BatchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-flushTicker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		case <-cleanupTicker.C:
+			r.cleanup()
+		}
+	}
+}
+
+// flush 写入一批记录，写入失败时记录错误日志并丢弃
+func (r *RuleExecutionRecorder) flush(batch []*executionRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recorderFlushTimeout)
+	defer cancel()
+
+	deltas := make(map[string]*model.RuleStatsDelta)
+	order := make([]string, 0)
+	logs := make([]*model.RuleExecutionLog, 0, len(batch))
+	for _, record := range batch {
+		for _, step := range record.log.Steps {
+			delta, ok := deltas[step.RuleID]
+			if !ok {
+				delta = &model.RuleStatsDelta{RuleID: step.RuleID}
+				deltas[step.RuleID] = delta
+				order = append(order, step.RuleID)
+			}
+			delta.Executed++
+			if step.Success {
+				delta.Succeeded++
+			}
+			if step.ExecuteAt > delta.LastExecuteAt {
+				delta.LastExecuteAt = step.ExecuteAt
+			}
+		}
+		if record.sampled {
+			logs = append(logs, record.log)
+		}
+	}
+
+	for _, ruleID := range order {
+		if err := r.ruleRepo.IncrementExecuteStats(ctx, deltas[ruleID]); err != nil {
+			hlog.Errorf("rule engine: update execute stats of rule %s error: %v", ruleID, err)
+		}
+	}
+	if len(logs) > 0 {
+		if err := r.logRepo.SaveBatch(ctx, logs); err != nil {
+			hlog.Errorf("rule engine: save %d execution logs error: %v", len(logs), err)
+		}
+	}
+}
+
+// cleanup 删除超过保留天数的执行日志
+func (r *RuleExecutionRecorder) cleanup() {
+	if r.cfg.RetentionDays < 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	before := utils.GetDateUnix() - int64(r.cfg.RetentionDays)*24*3600
+	deleted, err := r.logRepo.DeleteBefore(ctx, before)
+	if err != nil {
+		hlog.Errorf("rule engine: cleanup execution logs error: %v", err)
+		return
+	}
+	if deleted > 0 {
+		hlog.Infof("rule engine: cleaned up %d expired execution logs", deleted)
+	}
+}
+
+// Close 停止记录器并写入队列中剩余的记录
+func (r *RuleExecutionRecorder) Close() {
+	if r == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
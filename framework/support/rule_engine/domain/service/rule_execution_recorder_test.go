@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+)
+
+// memoryLogRepository 保存在内存中的执行日志仓储
+type memoryLogRepository struct {
+	repository.IRuleExecutionLogRepository
+	mu   sync.Mutex
+	logs []*model.RuleExecutionLog
+}
+
+func (r *memoryLogRepository) SaveBatch(ctx context.Context, logs []*model.RuleExecutionLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, logs...)
+	return nil
+}
+
+// statsRuleRepository 记录执行统计增量的规则仓储
+type statsRuleRepository struct {
+	repository.IRuleRepository
+	mu     sync.Mutex
+	deltas map[string]model.RuleStatsDelta
+}
+
+func (r *statsRuleRepository) IncrementExecuteStats(ctx context.Context, delta *model.RuleStatsDelta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.deltas[delta.RuleID]
+	d.RuleID = delta.RuleID
+	d.Executed += delta.Executed
+	d.Succeeded += delta.Succeeded
+	d.LastExecuteAt = delta.LastExecuteAt
+	r.deltas[delta.RuleID] = d
+	return nil
+}
+
+func (r *statsRuleRepository) FindByScope(ctx context.Context, scope string) ([]*model.Rule, error) {
+	if scope != "order" {
+		return nil, nil
+	}
+	return []*model.Rule{{ID: "r1", Code: "amount_limit", Type: "formula", Formula: "1", Status: 1,
+		Scope: "order", Triggers: []string{"create"}, ExecutionTiming: "before"}}, nil
+}
+
+func newTestResult(success bool) *model.RuleResult {
+	result := model.NewRuleResult()
+	step := model.NewRuleExecutionStep("r1", "amount_limit", "amount limit", 1)
+	step.SetInput(map[string]interface{}{"amount": 10})
+	if success {
+		step.SetSuccess(true, "allow")
+		result.SetSuccess(true, "allow")
+	} else {
+		step.SetFailure("deny", "too large")
+		result.SetFailure("deny", "limit", "too large")
+	}
+	result.AddExecutionStep(step)
+	return result
+}
+
+func TestRuleExecutionRecorder(t *testing.T) {
+	logRepo := &memoryLogRepository{}
+	ruleRepo := &statsRuleRepository{deltas: make(map[string]model.RuleStatsDelta)}
+	recorder := NewRuleExecutionRecorder(logRepo, ruleRepo, RecorderConfig{SampleRate: 0.5, RecordData: true, BatchSize: 2, RetentionDays: -1})
+	samples := []float64{0.9, 0.1}
+	recorder.random = func() float64 {
+		v := samples[0]
+		samples = samples[1:]
+		return v
+	}
+
+	rc := model.NewRuleContext("order", "create", "before", "")
+	recorder.Record(rc, newTestResult(true), "")   // 未采样
+	recorder.Record(rc, newTestResult(true), "")   // 采样
+	recorder.Record(rc, newTestResult(false), "")  // 失败总是记录
+	recorder.Record(rc, model.NewRuleResult(), "") // 没有执行规则，忽略
+	recorder.Close()
+
+	if d := ruleRepo.deltas["r1"]; d.Executed != 3 || d.Succeeded != 2 || d.LastExecuteAt == 0 {
+		t.Fatalf("stats delta = %+v", d)
+	}
+	if len(logRepo.logs) != 2 {
+		t.Fatalf("saved %d logs, want 2", len(logRepo.logs))
+	}
+	sampled, failed := logRepo.logs[0], logRepo.logs[1]
+	if !sampled.Valid || sampled.Weight != 2 || sampled.Steps[0].Weight != 2 {
+		t.Fatalf("sampled success log = %+v", sampled)
+	}
+	if failed.Valid || failed.Weight != 1 || failed.Steps[0].Error != "too large" {
+		t.Fatalf("failed log = %+v", failed)
+	}
+	if sampled.Steps[0].Input != `{"amount":10}` {
+		t.Fatalf("step input = %q", sampled.Steps[0].Input)
+	}
+}
+
+func TestRuleExecutionRecorderDisabled(t *testing.T) {
+	logRepo := &memoryLogRepository{}
+	ruleRepo := &statsRuleRepository{deltas: make(map[string]model.RuleStatsDelta)}
+	recorder := NewRuleExecutionRecorder(logRepo, ruleRepo, RecorderConfig{Disabled: true, RetentionDays: -1})
+
+	rc := model.NewRuleContext("order", "create", "before", "")
+	recorder.Record(rc, newTestResult(false), "amount_limit")
+	recorder.Close()
+
+	if len(logRepo.logs) != 0 {
+		t.Fatalf("disabled recorder saved %d logs", len(logRepo.logs))
+	}
+	if d := ruleRepo.deltas["r1"]; d.Executed != 1 || d.Succeeded != 0 {
+		t.Fatalf("stats should still be updated, got %+v", d)
+	}
+}
+
+func TestRecordSkipsDryRun(t *testing.T) {
+	logRepo := &memoryLogRepository{}
+	ruleRepo := &statsRuleRepository{deltas: make(map[string]model.RuleStatsDelta)}
+	recorder := NewRuleExecutionRecorder(logRepo, ruleRepo, RecorderConfig{RetentionDays: -1})
+	s := NewRuleExecutionService(ruleRepo, nil, nil, &rollbackDataBase{}, nil, recorder, nil, nil)
+
+	rc := model.NewRuleContext("order", "create", "before", "")
+	if _, herr := s.SimulateRules(context.Background(), rc); herr != nil {
+		t.Fatalf("simulate: %v", herr)
+	}
+	if _, herr := s.ExecuteRules(context.Background(), rc); herr != nil {
+		t.Fatalf("execute: %v", herr)
+	}
+	recorder.Close()
+
+	if len(logRepo.logs) != 1 || ruleRepo.deltas["r1"].Executed != 1 {
+		t.Fatalf("only the real execution should be recorded, logs=%d delta=%+v", len(logRepo.logs), ruleRepo.deltas["r1"])
+	}
+}
@@ -2,13 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"golang.org/x/exp/slices"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/formula"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
 	"github.com/flare-admin/flare-server-go/framework/pkg/lua_engine"
 	ruleengineerr "github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/err"
@@ -21,31 +23,67 @@ import (
 // RuleExecutionService 规则执行服务
 type RuleExecutionService struct {
 	ruleRepo     repository.IRuleRepository
+	categoryRepo repository.ICategoryRepository
+	versionRepo  repository.IRuleVersionRepository
+	db           database.IDataBase // 模拟执行时开启只回滚的事务
 	ruleExecutor *lua_engine.RuleExecutor
+	recorder     *RuleExecutionRecorder                       // 执行记录器，为 nil 时不记录
+	cache        *RuleCache                                   // 规则缓存，为 nil 时每次执行查询数据库
+	formulas     *formula.Cache                               // 公式编译结果缓存
+	trees        *compiledCache[*model.ConditionNode]         // 条件树解析结果缓存
+	tables       *compiledCache[*model.CompiledDecisionTable] // 决策表编译结果缓存
+	conditions   *conditionEvaluator                          // 条件树求值器
+	actions      *ActionRegistry                              // 规则动作处理器，为 nil 时动作均由调用方处理
 }
 
 // NewRuleExecutionService 创建规则执行服务
 func NewRuleExecutionService(
 	ruleRepo repository.IRuleRepository,
+	categoryRepo repository.ICategoryRepository,
+	versionRepo repository.IRuleVersionRepository,
+	db database.IDataBase,
 	ruleExecutor *lua_engine.RuleExecutor,
+	recorder *RuleExecutionRecorder,
+	cache *RuleCache,
+	actions *ActionRegistry,
 ) *RuleExecutionService {
 	return &RuleExecutionService{
 		ruleRepo:     ruleRepo,
+		categoryRepo: categoryRepo,
+		versionRepo:  versionRepo,
+		db:           db,
 		ruleExecutor: ruleExecutor,
+		recorder:     recorder,
+		cache:        cache,
+		formulas:     formula.NewCache(formula.DefaultCacheSize),
+		trees:        newCompiledCache[*model.ConditionNode](defaultCompiledCacheSize),
+		tables:       newCompiledCache[*model.CompiledDecisionTable](defaultCompiledCacheSize),
+		conditions:   &conditionEvaluator{},
+		actions:      actions,
 	}
 }
 
 // ExecuteRules 执行多个规则
 // 根据上下文中的业务类型和触发动作自动匹配并执行所有相关规则
-// 按照优先级排序执行，执行失败时中断，上一个规则的执行结果是下一个规则的输入
+// 按照优先级排序后依次执行，每个规则按所属分类的执行策略处理，上一个规则的执行结果是下一个规则的输入
+// 通过的规则产生的动作由注册的处理器执行，动作列表返回在结果的 Actions 中
 func (s *RuleExecutionService) ExecuteRules(ctx context.Context, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+	result, herr := s.executeRules(ctx, context)
+	if herr == nil {
+		s.record(ctx, context, result, "")
+	}
+	return result, herr
+}
+
+// executeRules 匹配并按优先级执行规则链
+func (s *RuleExecutionService) executeRules(ctx context.Context, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
 	// 验证上下文
 	if err := context.Validate(); err != nil {
 		return nil, ruleengineerr.RuleContextInvalid(err)
 	}
 
 	// 查找匹配的规则
-	rules, err := s.findMatchingRules(ctx, context)
+	rules, err := s.matchRules(ctx, context)
 	if err != nil {
 		return nil, err
 	}
@@ -60,71 +98,369 @@ func (s *RuleExecutionService) ExecuteRules(ctx context.Context, context *model.
 	// 按照优先级排序规则（优先级数字越大优先级越高）
 	s.sortRulesByPriority(rules)
 
-	// 执行规则链，上一个规则的执行结果是下一个规则的输入
-	var currentContext = context
-
+	// 按全局优先级顺序执行规则链，每个规则按所属分类的执行策略处理，上一个规则的执行结果是下一个规则的输入
+	chains, herr := s.buildCategoryChains(ctx, rules)
+	if herr != nil {
+		return nil, herr
+	}
+	currentContext := context
 	for i, rule := range rules {
-		// 创建执行步骤
-		step := model.NewRuleExecutionStep(rule.ID, rule.Code, rule.Name, rule.Priority)
+		chain := chains[rule.CategoryID]
+		next, ok := s.executeChainRule(ctx, chain, rule, currentContext, finalResult)
+		currentContext = next
+		// 分类的最后一个规则执行后汇总 collect、deny_overrides 的结果
+		if ok && i == chain.last {
+			ok = chain.finish(finalResult)
+		}
+		// 分类的结果为拒绝时中断执行链
+		if !ok {
+			break
+		}
+	}
+	finalResult.Context = currentContext.Data
+	// 有副作用的动作在最终结果确定后执行，结果为拒绝时跳过
+	s.actions.ExecuteDeferred(ctx, finalResult.Actions, currentContext, finalResult.IsSuccess())
 
-		// 记录输入数据
-		step.SetInput(currentContext.Data)
+	// 设置最终结果的总执行时间
+	finalResult.SetExecuteTime(finalResult.GetTotalExecuteTime())
+
+	return finalResult, nil
+}
 
-		// 执行单个规则
-		result, err := s.executeSingleRule(ctx, rule, currentContext)
+// ExecuteRuleByCode 根据编码执行规则
+// version 大于 0 时执行指定的已发布版本，否则执行线上版本
+func (s *RuleExecutionService) ExecuteRuleByCode(ctx context.Context, code string, version int32, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+	rule, err := s.ruleRepo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, ruleengineerr.RuleGetFailed(err)
+	}
+
+	if version > 0 && version != rule.PublishedVersion {
+		pinned, err := s.versionRepo.FindVersion(ctx, rule.ID, version)
 		if err != nil {
-			// 执行失败，记录失败步骤并中断执行链
-			step.SetFailure("deny", err.Error())
-			finalResult.AddExecutionStep(step)
-			finalResult.SetFailure("deny", err.Reason, err.Error())
-			return finalResult, nil
+			return nil, ruleengineerr.RuleVersionGetFailed(err)
+		}
+		if pinned == nil {
+			return nil, ruleengineerr.RuleVersionNotExist
+		}
+		rule = rule.WithVersion(pinned)
+	}
+
+	result, herr := s.executeRuleStep(ctx, rule, context)
+	if herr == nil {
+		s.record(ctx, context, result, code)
+	}
+	return result, herr
+}
+
+// record 记录执行结果，模拟执行不记录
+func (s *RuleExecutionService) record(ctx context.Context, rc *model.RuleContext, result *model.RuleResult, ruleCode string) {
+	if isDryRun(ctx) {
+		return
+	}
+	s.recorder.Record(rc, result, ruleCode)
+}
+
+// errDryRunRollback 模拟执行结束时返回，使事务回滚
+var errDryRunRollback = errors.New("rule dry run rollback")
+
+// dryRunKey 模拟执行上下文标记
+type dryRunKey struct{}
+
+// isDryRun 是否为模拟执行
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// SimulateRules 模拟执行多个规则
+// 与 ExecuteRules 的匹配和执行过程相同，规则脚本的数据库写入在执行结束后全部回滚
+func (s *RuleExecutionService) SimulateRules(ctx context.Context, rc *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+	var result *model.RuleResult
+	herr := s.dryRun(ctx, func(ctx context.Context) *herrors.HError {
+		var herr *herrors.HError
+		result, herr = s.ExecuteRules(ctx, rc)
+		return herr
+	})
+	if herr != nil {
+		return nil, herr
+	}
+	return result, nil
+}
+
+// SimulateRule 模拟执行单个规则，规则可以是尚未发布的草稿，返回包含执行步骤的结果
+func (s *RuleExecutionService) SimulateRule(ctx context.Context, rule *model.Rule, rc *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+	var result *model.RuleResult
+	herr := s.dryRun(ctx, func(ctx context.Context) *herrors.HError {
+		var herr *herrors.HError
+		result, herr = s.executeRuleStep(ctx, rule, rc)
+		return herr
+	})
+	if herr != nil {
+		return nil, herr
+	}
+	return result, nil
+}
+
+// RunTestCases 逐个模拟执行测试用例并校验结果，每个用例在独立的事务中执行，互不影响
+// 用例执行出错时记为失败并继续执行后续用例
+func (s *RuleExecutionService) RunTestCases(ctx context.Context, rule *model.Rule, cases []*model.RuleTestCase) (*model.RuleTestReport, *herrors.HError) {
+	report := model.NewRuleTestReport(rule.ID)
+	for _, testCase := range cases {
+		result, herr := s.SimulateRule(ctx, rule, testCase.NewContext(rule))
+		if herr != nil {
+			result = model.NewRuleResult()
+			result.SetFailure("deny", herr.Reason, herr.Error())
+			report.Add(&model.RuleTestCaseResult{
+				CaseID:   testCase.ID,
+				Name:     testCase.Name,
+				Failures: []string{fmt.Sprintf("error: %s", herr.Error())},
+				Result:   result,
+			})
+			continue
 		}
+		failures := testCase.Check(result)
+		report.Add(&model.RuleTestCaseResult{
+			CaseID:   testCase.ID,
+			Name:     testCase.Name,
+			Passed:   len(failures) == 0,
+			Failures: failures,
+			Result:   result,
+		})
+	}
+	return report, nil
+}
+
+// dryRun 在只回滚的独立事务中执行 fn
+func (s *RuleExecutionService) dryRun(ctx context.Context, fn func(ctx context.Context) *herrors.HError) *herrors.HError {
+	var herr *herrors.HError
+	err := s.db.InIndependentTx(ctx, func(ctx context.Context) error {
+		herr = fn(context.WithValue(ctx, dryRunKey{}, true))
+		return errDryRunRollback
+	})
+	if herr != nil {
+		return herr
+	}
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		return ruleengineerr.RuleSimulateFailed(err)
+	}
+	return nil
+}
+
+// executeRuleStep 执行单个规则并记录执行步骤，规则通过时执行规则动作
+func (s *RuleExecutionService) executeRuleStep(ctx context.Context, rule *model.Rule, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+	result, step, herr := s.runRule(ctx, rule, context)
+	if herr != nil {
+		return nil, herr
+	}
+	result.AddExecutionStep(step)
+	if result.IsSuccess() {
+		next := s.runActions(ctx, rule, effectiveAction(rule, result), result, context, result)
+		s.actions.ExecuteDeferred(ctx, result.Actions, next, result.IsSuccess())
+	}
+	return result, nil
+}
+
+// runRule 执行单个规则，返回执行结果和执行步骤
+func (s *RuleExecutionService) runRule(ctx context.Context, rule *model.Rule, context *model.RuleContext) (*model.RuleResult, *model.RuleExecutionStep, *herrors.HError) {
+	step := model.NewRuleExecutionStep(rule.ID, rule.Code, rule.Name, rule.Priority)
+	step.RuleVersion = rule.PublishedVersion
+	step.SetInput(context.Data)
+
+	start := time.Now()
+	result, herr := s.executeSingleRule(ctx, rule, context)
+	step.Elapsed = time.Since(start)
+	if herr != nil {
+		step.SetFailure("deny", herr.Error())
+		return nil, step, herr
+	}
+	step.DBCalls = result.DBCalls
+	step.ConditionTrace = result.ConditionTrace
+	step.SetExecuteTime(result.ExecuteTime)
+	if result.IsSuccess() {
+		step.SetSuccess(result.Valid, result.Action)
+		if result.Context != nil {
+			step.SetOutput(result.Context)
+		}
+	} else {
+		step.SetFailure(result.Action, result.Error)
+	}
+	return result, step, nil
+}
+
+// runChainRule 执行规则链中的单个规则并记录执行步骤，执行出错时作为失败结果返回
+func (s *RuleExecutionService) runChainRule(ctx context.Context, rule *model.Rule, context *model.RuleContext, finalResult *model.RuleResult) *model.RuleResult {
+	result, step, herr := s.runRule(ctx, rule, context)
+	finalResult.AddExecutionStep(step)
+	if herr != nil {
+		result = model.NewRuleResult()
+		result.SetFailure("deny", herr.Reason, herr.Error())
+	}
+	return result
+}
+
+// categoryChain 规则链中一个分类的执行状态
+// 不同分类的规则按优先级交错时，分类的执行策略在规则各自的位置上生效
+type categoryChain struct {
+	strategy string
+	last     int               // 分类中最后一个规则在规则链中的位置
+	matched  bool              // first_match 已有规则通过，分类中后续的规则不再执行
+	failed   *model.RuleResult // collect 第一个失败的规则结果
+	denied   bool              // deny_overrides 已有规则失败或拒绝
+	reason   string
+	errMsg   string
+}
+
+// finish 分类的规则执行完后汇总结果，结果为拒绝时返回 false
+func (c *categoryChain) finish(finalResult *model.RuleResult) bool {
+	switch {
+	case c.failed != nil:
+		finalResult.SetFailure(c.failed.Action, c.failed.ErrorReason, c.failed.Error)
+		return false
+	case c.denied:
+		finalResult.SetFailure("deny", c.reason, c.errMsg)
+		return false
+	}
+	return true
+}
 
-		// 记录执行结果到步骤中
-		step.SetExecuteTime(result.ExecuteTime)
-		if result.IsSuccess() {
-			step.SetSuccess(result.Valid, result.Action)
-			// 记录输出数据
-			if result.Context != nil {
-				step.SetOutput(result.Context)
+// buildCategoryChains 获取已排序规则所属分类的执行策略和最后一个规则的位置
+func (s *RuleExecutionService) buildCategoryChains(ctx context.Context, rules []*model.Rule) (map[string]*categoryChain, *herrors.HError) {
+	chains := make(map[string]*categoryChain)
+	for i, rule := range rules {
+		chain, ok := chains[rule.CategoryID]
+		if !ok {
+			strategy, herr := s.resolveChainStrategy(ctx, rule.CategoryID)
+			if herr != nil {
+				return nil, herr
 			}
-		} else {
-			step.SetFailure(result.Action, result.Error)
+			chain = &categoryChain{strategy: strategy}
+			chains[rule.CategoryID] = chain
 		}
+		chain.last = i
+	}
+	return chains, nil
+}
 
-		// 添加执行步骤到结果中
-		finalResult.AddExecutionStep(step)
+// executeChainRule 按所属分类的执行策略执行规则链中的一个规则
+// 返回下一个规则的输入上下文，规则使执行链中断时返回 false
+func (s *RuleExecutionService) executeChainRule(ctx context.Context, chain *categoryChain, rule *model.Rule, context *model.RuleContext, finalResult *model.RuleResult) (*model.RuleContext, bool) {
+	switch chain.strategy {
+	case model.ChainStrategyFirstMatch:
+		// 第一个通过的规则决定结果，未通过的规则跳过，没有规则通过时允许
+		if chain.matched {
+			return context, true
+		}
+		result := s.runChainRule(ctx, rule, context, finalResult)
+		if !result.IsSuccess() {
+			return context, true
+		}
+		action := effectiveAction(rule, result)
+		if action == "deny" {
+			finalResult.SetFailure("deny", "rule_denied", fmt.Sprintf("rule %s denied", rule.Code))
+			return context, false
+		}
+		chain.matched = true
+		return s.runActions(ctx, rule, action, result, context, finalResult), true
 
-		// 如果规则执行失败，中断执行链
+	case model.ChainStrategyCollect:
+		// 执行所有规则不中断，结果为第一个失败的规则
+		result := s.runChainRule(ctx, rule, context, finalResult)
 		if !result.IsSuccess() {
-			finalResult.SetFailure(result.Action, result.ErrorReason, result.Error)
-			break
+			if chain.failed == nil {
+				chain.failed = result
+			}
+			return context, true
+		}
+		finalResult.AddOutput(rule.Code, result.Context)
+		return s.runActions(ctx, rule, effectiveAction(rule, result), result, context, finalResult), true
+
+	case model.ChainStrategyDenyOverrides:
+		// 执行所有规则，任一规则失败或拒绝时结果为拒绝，原因取第一个拒绝的规则
+		result := s.runChainRule(ctx, rule, context, finalResult)
+		action := effectiveAction(rule, result)
+		switch {
+		case !result.IsSuccess():
+			if !chain.denied {
+				chain.denied, chain.reason, chain.errMsg = true, result.ErrorReason, result.Error
+			}
+		case action == "deny":
+			if !chain.denied {
+				chain.denied, chain.reason, chain.errMsg = true, "rule_denied", fmt.Sprintf("rule %s denied", rule.Code)
+			}
+		default:
+			context = s.runActions(ctx, rule, action, result, context, finalResult)
 		}
+		return context, true
 
-		// 将当前规则的执行结果作为下一个规则的输入
-		if i < len(rules)-1 {
-			// 更新上下文数据，将当前规则的输出变量合并到上下文中
-			currentContext = s.updateContextWithRuleResult(currentContext, result)
+	default:
+		// 依次执行，第一个失败的规则中断执行链
+		result := s.runChainRule(ctx, rule, context, finalResult)
+		if !result.IsSuccess() {
+			finalResult.SetFailure(result.Action, result.ErrorReason, result.Error)
+			return context, false
 		}
+		return s.runActions(ctx, rule, effectiveAction(rule, result), result, context, finalResult), true
 	}
+}
 
-	// 设置最终结果的总执行时间
-	finalResult.SetExecuteTime(finalResult.GetTotalExecuteTime())
+// effectiveAction 规则生效的动作，脚本没有设置动作时使用规则配置的动作
+func effectiveAction(rule *model.Rule, result *model.RuleResult) string {
+	if result.Action != "" {
+		return result.Action
+	}
+	return rule.Action
+}
 
-	return finalResult, nil
+// runActions 规则通过后执行规则动作，返回动作执行后的上下文副本作为下一个规则的输入
+// allow、deny 只表示决策结果，不产生动作；只立即执行修改上下文的动作，有副作用的动作等规则链结束后由 ExecuteDeferred 执行
+func (s *RuleExecutionService) runActions(ctx context.Context, rule *model.Rule, action string, result *model.RuleResult, context *model.RuleContext, finalResult *model.RuleResult) *model.RuleContext {
+	next := s.updateContextWithRuleResult(context, result)
+	if model.IsDecisionAction(action) {
+		return next
+	}
+	ruleAction := model.NewRuleAction(rule, action, result.Context)
+	s.actions.ExecuteInline(ctx, ruleAction, next)
+	finalResult.AddAction(ruleAction)
+	return next
 }
 
-// ExecuteRuleByCode 根据编码执行规则
-func (s *RuleExecutionService) ExecuteRuleByCode(ctx context.Context, code string, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
-	rule, err := s.ruleRepo.FindByCode(ctx, code)
+// resolveChainStrategy 获取分类的规则链执行策略，规则没有分类时使用默认策略
+func (s *RuleExecutionService) resolveChainStrategy(ctx context.Context, categoryID string) (string, *herrors.HError) {
+	category, err := s.findCategory(ctx, categoryID)
 	if err != nil {
-		return nil, ruleengineerr.RuleGetFailed(err)
+		hlog.CtxErrorf(ctx, "get rule category failed: %v", err)
+		return "", ruleengineerr.RuleCategoryGetFailed(err)
 	}
+	if category == nil {
+		return model.ChainStrategyAll, nil
+	}
+	return category.GetExecutionStrategy(), nil
+}
+
+// findCategory 获取规则所属的分类，优先使用缓存，规则没有分类时返回 nil
+func (s *RuleExecutionService) findCategory(ctx context.Context, categoryID string) (*model.RuleCategory, error) {
+	if categoryID == "" {
+		return nil, nil
+	}
+	if category, ok := s.cache.Category(categoryID); ok {
+		return category, nil
+	}
+	if s.categoryRepo == nil {
+		return nil, nil
+	}
+	return s.categoryRepo.FindByID(ctx, categoryID)
+}
 
-	return s.executeSingleRule(ctx, rule, context)
+// matchRules 查找匹配的规则，优先使用缓存
+func (s *RuleExecutionService) matchRules(ctx context.Context, context *model.RuleContext) ([]*model.Rule, *herrors.HError) {
+	if rules, ok := s.cache.Match(ctx, context); ok {
+		return rules, nil
+	}
+	return s.findMatchingRules(ctx, context)
 }
 
-// findMatchingRules 查找匹配的规则
+// findMatchingRules 查询数据库查找匹配的规则
 func (s *RuleExecutionService) findMatchingRules(ctx context.Context, context *model.RuleContext) ([]*model.Rule, *herrors.HError) {
 	var rules []*model.Rule
 	// 获取全局规则
@@ -170,18 +506,20 @@ func (s *RuleExecutionService) findMatchingRules(ctx context.Context, context *m
 }
 
 // executeSingleRule 执行单个规则
-func (s *RuleExecutionService) executeSingleRule(_ context.Context, rule *model.Rule, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+func (s *RuleExecutionService) executeSingleRule(ctx context.Context, rule *model.Rule, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
 	// 创建结果对象
 	result := model.NewRuleResult()
+	result.RuleVersion = rule.PublishedVersion
 	context.AddData("scopeId", context.ScopeID)
 	// 执行规则
-	execResult, err := s.executeRuleWithExecutor(rule, context)
+	execResult, err := s.executeRuleWithExecutor(ctx, rule, context, result)
 	if err != nil {
 		result.SetFailure("deny", "rule_execute_err", err.Error())
 		return result, nil
 	}
 
 	// 设置执行结果
+	result.Context = execResult.Context
 	if execResult.Valid {
 		result.SetSuccess(execResult.Valid, execResult.Action)
 	} else {
@@ -194,28 +532,42 @@ func (s *RuleExecutionService) executeSingleRule(_ context.Context, rule *model.
 }
 
 // executeRuleWithExecutor 使用规则执行器执行规则
-func (s *RuleExecutionService) executeRuleWithExecutor(rule *model.Rule, context *model.RuleContext) (*lua_engine.ExecuteResult, error) {
+// result 用于收集执行过程中的数据库访问记录和条件执行轨迹
+func (s *RuleExecutionService) executeRuleWithExecutor(ctx context.Context, rule *model.Rule, context *model.RuleContext, result *model.RuleResult) (*lua_engine.ExecuteResult, error) {
 	switch rule.Type {
 	case "condition":
-		return s.executeConditionRule(rule, context)
+		return s.executeConditionRule(rule, context, result)
 	case "lua":
-		return s.executeLuaRule(rule, context)
+		return s.executeLuaRule(ctx, rule, context, result)
 	case "formula":
 		return s.executeFormulaRule(rule, context)
+	case "decision_table":
+		return s.executeDecisionTableRule(rule, context)
 	default:
 		return nil, fmt.Errorf("unsupported rule type: %s", rule.Type)
 	}
 }
 
 // executeLuaRule 执行Lua脚本规则
-func (s *RuleExecutionService) executeLuaRule(rule *model.Rule, context *model.RuleContext) (*lua_engine.ExecuteResult, error) {
+func (s *RuleExecutionService) executeLuaRule(ctx context.Context, rule *model.Rule, context *model.RuleContext, result *model.RuleResult) (*lua_engine.ExecuteResult, error) {
 	context.AddData("tenantId", context.TenantID)
 
-	// 使用自定义执行器执行Lua脚本
-	execResult, err := s.ruleExecutor.Execute(rule.LuaScript, &lua_engine.ExecuteOptions{
-		Timeout:   5 * time.Second,
-		Context:   context.Data,
-		MaxMemory: 10 * 1024 * 1024, // 10MB
+	policy, err := s.resolveDBPolicy(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用自定义执行器执行Lua脚本，数据库访问限定在规则所属租户内
+	execResult, err := s.ruleExecutor.ExecuteWithContext(ctx, rule.LuaScript, &lua_engine.ExecuteOptions{
+		Timeout:         5 * time.Second,
+		Context:         context.Data,
+		MaxMemory:       10 * 1024 * 1024, // 10MB
+		MaxInstructions: lua_engine.DefaultMaxInstructions,
+		DBPolicy:        policy,
+		TenantID:        context.TenantID,
+		DBCallCallback: func(call lua_engine.DBCallRecord) {
+			result.DBCalls = append(result.DBCalls, call)
+		},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("lua script execution failed: %w", err)
@@ -224,119 +576,123 @@ func (s *RuleExecutionService) executeLuaRule(rule *model.Rule, context *model.R
 	return execResult, nil
 }
 
+// resolveDBPolicy 获取规则生效的数据库访问策略
+// 优先使用规则自身的策略，其次使用所属分类的策略，均未配置时禁止访问数据库
+func (s *RuleExecutionService) resolveDBPolicy(ctx context.Context, rule *model.Rule) (*lua_engine.DBPolicy, error) {
+	policy, err := rule.GetDBPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule db policy: %w", err)
+	}
+	if policy != nil {
+		return policy, nil
+	}
+	category, err := s.findCategory(ctx, rule.CategoryID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "get rule category failed: %v", err)
+		return nil, fmt.Errorf("get rule category failed: %w", err)
+	}
+	if category != nil {
+		policy, err = category.GetDBPolicy()
+		if err != nil {
+			return nil, fmt.Errorf("invalid category db policy: %w", err)
+		}
+		if policy != nil {
+			return policy, nil
+		}
+	}
+	return &lua_engine.DBPolicy{}, nil
+}
+
 // executeFormulaRule 执行公式规则
+// 公式结果为布尔值时决定规则是否通过，其他结果视为通过，结果写入输出的 result 变量
 func (s *RuleExecutionService) executeFormulaRule(rule *model.Rule, context *model.RuleContext) (*lua_engine.ExecuteResult, error) {
-	// 替换公式中的变量
-	formula := s.replaceFormulaVariables(rule.Formula, context.Data)
+	start := time.Now()
+	expr, err := s.formulas.Compile(rule.Formula)
+	if err != nil {
+		return nil, fmt.Errorf("formula compile failed: %w", err)
+	}
+	defs, err := rule.GetFormulaVarDefs()
+	if err != nil {
+		return nil, fmt.Errorf("invalid formula vars: %w", err)
+	}
 
-	// 计算公式结果
-	formulaResult, err := s.evaluateFormula(formula)
+	value, err := expr.EvalWithVars(context.Data, defs)
 	if err != nil {
 		return nil, fmt.Errorf("formula evaluation failed: %w", err)
 	}
 
+	valid := true
+	if b, ok := value.(bool); ok {
+		valid = b
+	}
 	return &lua_engine.ExecuteResult{
-		Valid:       formulaResult.Valid,
+		Valid:       valid,
 		Action:      rule.Action,
-		Context:     map[string]interface{}{"result": formulaResult.Value},
-		ExecuteTime: 0,
+		Context:     map[string]interface{}{"result": formula.ToNative(value)},
+		ExecuteTime: time.Since(start).Milliseconds(),
 	}, nil
 }
 
-// replaceFormulaVariables 替换公式中的变量
-func (s *RuleExecutionService) replaceFormulaVariables(formula string, data map[string]interface{}) string {
-	// 简单的变量替换，实际项目中可以使用更复杂的表达式解析器
-	for key, value := range data {
-		placeholder := "${" + key + "}"
-		formula = strings.ReplaceAll(formula, placeholder, fmt.Sprintf("%v", value))
-	}
-	return formula
-}
-
-// evaluateFormula 评估公式
-func (s *RuleExecutionService) evaluateFormula(formula string) (*FormulaResult, error) {
-	// 基本语法检查
-	if formula == "" {
-		return &FormulaResult{Valid: false, Value: nil}, fmt.Errorf("公式不能为空")
-	}
-
-	// 检查括号匹配
-	if !s.checkBracketMatch(formula) {
-		return &FormulaResult{Valid: false, Value: nil}, fmt.Errorf("公式括号不匹配")
-	}
-
-	// 尝试解析为数学表达式
-	result, err := s.evaluateMathExpression(formula)
+// executeDecisionTableRule 执行决策表规则
+// 命中行的输出写入输出变量，matched_rows 为命中的行号；没有命中且未配置默认值时规则不通过
+func (s *RuleExecutionService) executeDecisionTableRule(rule *model.Rule, context *model.RuleContext) (*lua_engine.ExecuteResult, error) {
+	start := time.Now()
+	table, err := s.tables.get(rule, rule.DecisionTable, model.ParseDecisionTableJSON)
 	if err != nil {
-		// 如果不是数学表达式，尝试解析为逻辑表达式
-		result, err = s.evaluateLogicalExpression(formula)
-		if err != nil {
-			return &FormulaResult{Valid: false, Value: nil}, fmt.Errorf("公式解析失败: %w", err)
-		}
+		return nil, fmt.Errorf("解析决策表失败: %w", err)
 	}
 
-	return &FormulaResult{Valid: true, Value: result}, nil
-}
-
-// evaluateMathExpression 评估数学表达式
-func (s *RuleExecutionService) evaluateMathExpression(formula string) (interface{}, error) {
-	// 简单的数学表达式计算
-	// 支持基本的四则运算和比较运算
-
-	// 移除所有空格
-	formula = strings.ReplaceAll(formula, " ", "")
-
-	// 检查是否包含数学运算符
-	mathOperators := []string{"+", "-", "*", "/", "(", ")", ">", "<", ">=", "<=", "==", "!="}
-	hasMathOperator := false
-	for _, op := range mathOperators {
-		if strings.Contains(formula, op) {
-			hasMathOperator = true
-			break
-		}
+	scope := &conditionScope{root: context.Data, data: context.Data}
+	match, err := table.Match(scope.lookup)
+	if err != nil {
+		return nil, fmt.Errorf("决策表执行失败: %w", err)
 	}
-
-	if !hasMathOperator {
-		return nil, fmt.Errorf("不是数学表达式")
+	if match == nil {
+		return &lua_engine.ExecuteResult{
+			Valid:       false,
+			Action:      rule.Action,
+			Error:       "决策表没有命中任何行",
+			ErrorReason: "decision_table_no_match",
+			Context:     map[string]interface{}{"matched_rows": []int{}},
+			ExecuteTime: time.Since(start).Milliseconds(),
+		}, nil
 	}
 
-	// 尝试解析为数字
-	if num, err := strconv.ParseFloat(formula, 64); err == nil {
-		return num, nil
+	variables := match.Outputs
+	variables["matched_rows"] = match.Rows
+	if match.Rows == nil {
+		variables["matched_rows"] = []int{}
 	}
-
-	// 简单的表达式计算
-	return s.calculateSimpleExpression(formula)
+	return &lua_engine.ExecuteResult{
+		Valid:       true,
+		Action:      rule.Action,
+		Context:     variables,
+		ExecuteTime: time.Since(start).Milliseconds(),
+	}, nil
 }
 
 // executeConditionRule 执行条件规则
-func (s *RuleExecutionService) executeConditionRule(rule *model.Rule, context *model.RuleContext) (*lua_engine.ExecuteResult, error) {
-	// 获取条件配置
-	conditions, err := rule.GetConditions()
+// 条件树的执行轨迹记录到 result 中
+func (s *RuleExecutionService) executeConditionRule(rule *model.Rule, context *model.RuleContext, result *model.RuleResult) (*lua_engine.ExecuteResult, error) {
+	start := time.Now()
+	// 解析条件树，解析结果按规则版本缓存
+	tree, err := s.trees.get(rule, rule.Conditions, model.ParseConditionTreeJSON)
 	if err != nil {
 		return nil, fmt.Errorf("解析条件配置失败: %w", err)
 	}
 
-	// 检查所有条件是否匹配
+	// 未配置条件时视为满足
 	matched := true
-	var failedCondition string
-
-	for _, condition := range conditions {
-		if conditionMap, ok := condition.(map[string]interface{}); ok {
-			if !s.evaluateCondition(conditionMap, context.Data) {
-				matched = false
-				if field, ok := conditionMap["field"].(string); ok {
-					failedCondition = field
-				}
-				break
-			}
-		}
+	if tree != nil {
+		var trace *model.ConditionTrace
+		matched, trace = s.conditions.Evaluate(tree, context.Data)
+		result.ConditionTrace = trace
 	}
 
 	// 构建输出变量
 	variables := make(map[string]interface{})
 	if !matched {
-		variables["failed_field"] = failedCondition
+		variables["failed_field"] = result.ConditionTrace.FailedField()
 		variables["condition_result"] = false
 	} else {
 		variables["condition_result"] = true
@@ -346,443 +702,10 @@ func (s *RuleExecutionService) executeConditionRule(rule *model.Rule, context *m
 		Valid:       matched,
 		Action:      rule.Action,
 		Context:     variables,
-		ExecuteTime: 0,
+		ExecuteTime: time.Since(start).Milliseconds(),
 	}, nil
 }
 
-// evaluateCondition 评估单个条件
-func (s *RuleExecutionService) evaluateCondition(condition map[string]interface{}, data map[string]interface{}) bool {
-	// 获取条件字段
-	field, ok := condition["field"].(string)
-	if !ok || field == "" {
-		return false
-	}
-
-	// 获取操作符
-	operator, ok := condition["operator"].(string)
-	if !ok || operator == "" {
-		return false
-	}
-
-	// 获取目标值
-	targetValue, ok := condition["value"]
-	if !ok {
-		return false
-	}
-
-	// 从数据中获取实际值
-	actualValue := s.getFieldValue(data, field)
-	if actualValue == nil {
-		return false
-	}
-
-	// 使用条件评估器评估条件
-	return s.evaluateConditionWithOperator(operator, targetValue, actualValue)
-}
-
-// getFieldValue 从数据中获取字段值，支持嵌套字段
-func (s *RuleExecutionService) getFieldValue(data map[string]interface{}, field string) interface{} {
-	// 处理嵌套字段，如 "user.age"
-	parts := strings.Split(field, ".")
-
-	current := data
-	for i, part := range parts {
-		if i == len(parts)-1 {
-			// 最后一个部分，返回值
-			if value, exists := current[part]; exists {
-				return value
-			}
-			return nil
-		}
-
-		// 中间部分，继续遍历
-		if next, ok := current[part].(map[string]interface{}); ok {
-			current = next
-		} else {
-			return nil
-		}
-	}
-
-	return nil
-}
-
-// evaluateConditionWithOperator 使用操作符评估条件
-func (s *RuleExecutionService) evaluateConditionWithOperator(operator string, targetValue, actualValue interface{}) bool {
-	switch operator {
-	case "eq":
-		return s.evaluateEqual(targetValue, actualValue)
-	case "neq":
-		return !s.evaluateEqual(targetValue, actualValue)
-	case "gt":
-		return s.compareValues(actualValue, targetValue) > 0
-	case "gte":
-		return s.compareValues(actualValue, targetValue) >= 0
-	case "lt":
-		return s.compareValues(actualValue, targetValue) < 0
-	case "lte":
-		return s.compareValues(actualValue, targetValue) <= 0
-	case "in":
-		return s.evaluateIn(targetValue, actualValue)
-	case "between":
-		return s.evaluateBetween(targetValue, actualValue)
-	case "contains":
-		return s.evaluateContains(targetValue, actualValue)
-	case "not_contains":
-		return !s.evaluateContains(targetValue, actualValue)
-	case "regex":
-		return s.evaluateRegex(targetValue, actualValue)
-	default:
-		return false
-	}
-}
-
-// evaluateEqual 评估等于
-func (s *RuleExecutionService) evaluateEqual(targetValue, actualValue interface{}) bool {
-	return fmt.Sprintf("%v", actualValue) == fmt.Sprintf("%v", targetValue)
-}
-
-// evaluateIn 评估包含
-func (s *RuleExecutionService) evaluateIn(targetValue, actualValue interface{}) bool {
-	// 将目标值转换为字符串列表
-	targetStr := fmt.Sprintf("%v", targetValue)
-	values := strings.Split(targetStr, ",")
-	actualStr := fmt.Sprintf("%v", actualValue)
-
-	for _, v := range values {
-		if strings.TrimSpace(v) == actualStr {
-			return true
-		}
-	}
-	return false
-}
-
-// evaluateBetween 评估区间
-func (s *RuleExecutionService) evaluateBetween(targetValue, actualValue interface{}) bool {
-	// 将目标值解析为区间
-	targetStr := fmt.Sprintf("%v", targetValue)
-	values := strings.Split(targetStr, ",")
-	if len(values) != 2 {
-		return false
-	}
-
-	min, err1 := strconv.ParseFloat(strings.TrimSpace(values[0]), 64)
-	max, err2 := strconv.ParseFloat(strings.TrimSpace(values[1]), 64)
-	if err1 != nil || err2 != nil {
-		return false
-	}
-
-	actualFloat, err := s.toFloat64(actualValue)
-	if err != nil {
-		return false
-	}
-
-	return actualFloat >= min && actualFloat <= max
-}
-
-// evaluateContains 评估包含关系
-func (s *RuleExecutionService) evaluateContains(targetValue, actualValue interface{}) bool {
-	actualStr := fmt.Sprintf("%v", actualValue)
-	targetStr := fmt.Sprintf("%v", targetValue)
-
-	return strings.Contains(actualStr, targetStr)
-}
-
-// evaluateRegex 评估正则表达式
-func (s *RuleExecutionService) evaluateRegex(targetValue, actualValue interface{}) bool {
-	// 这里可以添加正则表达式评估逻辑
-	// 为了简化，暂时返回false
-	return false
-}
-
-// compareValues 比较值
-func (s *RuleExecutionService) compareValues(actual, target interface{}) int {
-	actualFloat, err1 := s.toFloat64(actual)
-	if err1 != nil {
-		return 0
-	}
-
-	targetFloat, err2 := s.toFloat64(target)
-	if err2 != nil {
-		return 0
-	}
-
-	if actualFloat < targetFloat {
-		return -1
-	} else if actualFloat > targetFloat {
-		return 1
-	}
-	return 0
-}
-
-// toFloat64 转换为float64
-func (s *RuleExecutionService) toFloat64(value interface{}) (float64, error) {
-	switch v := value.(type) {
-	case float64:
-		return v, nil
-	case int:
-		return float64(v), nil
-	case int64:
-		return float64(v), nil
-	case string:
-		return strconv.ParseFloat(v, 64)
-	default:
-		return 0, fmt.Errorf("unsupported type: %T", value)
-	}
-}
-
-// evaluateLogicalExpression 评估逻辑表达式
-func (s *RuleExecutionService) evaluateLogicalExpression(formula string) (interface{}, error) {
-	// 支持基本的逻辑表达式
-	// 如: true, false, and, or, not
-
-	formula = strings.ToLower(strings.TrimSpace(formula))
-
-	switch formula {
-	case "true":
-		return true, nil
-	case "false":
-		return false, nil
-	case "1":
-		return true, nil
-	case "0":
-		return false, nil
-	default:
-		// 尝试解析逻辑表达式
-		return s.calculateLogicalExpression(formula)
-	}
-}
-
-// calculateSimpleExpression 计算简单表达式
-func (s *RuleExecutionService) calculateSimpleExpression(formula string) (interface{}, error) {
-	// 这里实现一个简单的表达式计算器
-	// 实际项目中建议使用成熟的表达式解析库
-
-	// 处理比较运算
-	if strings.Contains(formula, ">=") {
-		return s.evaluateComparison(formula, ">=")
-	} else if strings.Contains(formula, "<=") {
-		return s.evaluateComparison(formula, "<=")
-	} else if strings.Contains(formula, "==") {
-		return s.evaluateComparison(formula, "==")
-	} else if strings.Contains(formula, "!=") {
-		return s.evaluateComparison(formula, "!=")
-	} else if strings.Contains(formula, ">") {
-		return s.evaluateComparison(formula, ">")
-	} else if strings.Contains(formula, "<") {
-		return s.evaluateComparison(formula, "<")
-	}
-
-	// 处理数学运算
-	if strings.Contains(formula, "+") {
-		return s.evaluateMathOperation(formula, "+")
-	} else if strings.Contains(formula, "-") {
-		return s.evaluateMathOperation(formula, "-")
-	} else if strings.Contains(formula, "*") {
-		return s.evaluateMathOperation(formula, "*")
-	} else if strings.Contains(formula, "/") {
-		return s.evaluateMathOperation(formula, "/")
-	}
-
-	return nil, fmt.Errorf("不支持的表达式: %s", formula)
-}
-
-// evaluateComparison 评估比较运算
-func (s *RuleExecutionService) evaluateComparison(formula, operator string) (interface{}, error) {
-	parts := strings.Split(formula, operator)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("无效的比较表达式: %s", formula)
-	}
-
-	left, err1 := s.parseValue(strings.TrimSpace(parts[0]))
-	right, err2 := s.parseValue(strings.TrimSpace(parts[1]))
-
-	if err1 != nil || err2 != nil {
-		return nil, fmt.Errorf("解析比较值失败")
-	}
-
-	switch operator {
-	case ">=":
-		return s.compareValues(left, right) >= 0, nil
-	case "<=":
-		return s.compareValues(left, right) <= 0, nil
-	case "==":
-		return s.evaluateEqual(left, right), nil
-	case "!=":
-		return !s.evaluateEqual(left, right), nil
-	case ">":
-		return s.compareValues(left, right) > 0, nil
-	case "<":
-		return s.compareValues(left, right) < 0, nil
-	default:
-		return nil, fmt.Errorf("不支持的比较运算符: %s", operator)
-	}
-}
-
-// evaluateMathOperation 评估数学运算
-func (s *RuleExecutionService) evaluateMathOperation(formula, operator string) (interface{}, error) {
-	parts := strings.Split(formula, operator)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("无效的数学表达式: %s", formula)
-	}
-
-	left, err1 := s.parseValue(strings.TrimSpace(parts[0]))
-	right, err2 := s.parseValue(strings.TrimSpace(parts[1]))
-
-	if err1 != nil || err2 != nil {
-		return nil, fmt.Errorf("解析数学值失败")
-	}
-
-	leftFloat, err1 := s.toFloat64(left)
-	rightFloat, err2 := s.toFloat64(right)
-
-	if err1 != nil || err2 != nil {
-		return nil, fmt.Errorf("转换为数字失败")
-	}
-
-	switch operator {
-	case "+":
-		return leftFloat + rightFloat, nil
-	case "-":
-		return leftFloat - rightFloat, nil
-	case "*":
-		return leftFloat * rightFloat, nil
-	case "/":
-		if rightFloat == 0 {
-			return nil, fmt.Errorf("除零错误")
-		}
-		return leftFloat / rightFloat, nil
-	default:
-		return nil, fmt.Errorf("不支持的数学运算符: %s", operator)
-	}
-}
-
-// calculateLogicalExpression 计算逻辑表达式
-func (s *RuleExecutionService) calculateLogicalExpression(formula string) (interface{}, error) {
-	// 简单的逻辑表达式计算
-	// 支持: and, or, not
-
-	if strings.Contains(formula, " and ") {
-		return s.evaluateLogicalOperation(formula, " and ")
-	} else if strings.Contains(formula, " or ") {
-		return s.evaluateLogicalOperation(formula, " or ")
-	} else if strings.HasPrefix(formula, "not ") {
-		return s.evaluateNotOperation(formula)
-	}
-
-	return nil, fmt.Errorf("不支持的逻辑表达式: %s", formula)
-}
-
-// evaluateLogicalOperation 评估逻辑运算
-func (s *RuleExecutionService) evaluateLogicalOperation(formula, operator string) (interface{}, error) {
-	parts := strings.Split(formula, operator)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("无效的逻辑表达式: %s", formula)
-	}
-
-	left, err1 := s.parseValue(strings.TrimSpace(parts[0]))
-	right, err2 := s.parseValue(strings.TrimSpace(parts[1]))
-
-	if err1 != nil || err2 != nil {
-		return nil, fmt.Errorf("解析逻辑值失败")
-	}
-
-	leftBool := s.toBool(left)
-	rightBool := s.toBool(right)
-
-	switch operator {
-	case " and ":
-		return leftBool && rightBool, nil
-	case " or ":
-		return leftBool || rightBool, nil
-	default:
-		return nil, fmt.Errorf("不支持的逻辑运算符: %s", operator)
-	}
-}
-
-// evaluateNotOperation 评估非运算
-func (s *RuleExecutionService) evaluateNotOperation(formula string) (interface{}, error) {
-	if !strings.HasPrefix(formula, "not ") {
-		return nil, fmt.Errorf("无效的非运算表达式: %s", formula)
-	}
-
-	operand := strings.TrimSpace(strings.TrimPrefix(formula, "not "))
-	value, err := s.parseValue(operand)
-	if err != nil {
-		return nil, err
-	}
-
-	return !s.toBool(value), nil
-}
-
-// parseValue 解析值
-func (s *RuleExecutionService) parseValue(value string) (interface{}, error) {
-	// 尝试解析为数字
-	if num, err := strconv.ParseFloat(value, 64); err == nil {
-		return num, nil
-	}
-
-	// 尝试解析为布尔值
-	switch strings.ToLower(value) {
-	case "true", "1":
-		return true, nil
-	case "false", "0":
-		return false, nil
-	}
-
-	// 返回字符串
-	return value, nil
-}
-
-// toBool 转换为布尔值
-func (s *RuleExecutionService) toBool(value interface{}) bool {
-	switch v := value.(type) {
-	case bool:
-		return v
-	case int:
-		return v != 0
-	case float64:
-		return v != 0
-	case string:
-		switch strings.ToLower(v) {
-		case "true", "1", "yes", "on":
-			return true
-		case "false", "0", "no", "off":
-			return false
-		default:
-			return len(v) > 0
-		}
-	default:
-		return value != nil
-	}
-}
-
-// checkBracketMatch 检查括号匹配
-func (s *RuleExecutionService) checkBracketMatch(text string) bool {
-	stack := make([]rune, 0)
-	bracketPairs := map[rune]rune{
-		')': '(',
-		'}': '{',
-		']': '[',
-	}
-
-	for _, char := range text {
-		switch char {
-		case '(', '{', '[':
-			stack = append(stack, char)
-		case ')', '}', ']':
-			if len(stack) == 0 {
-				return false
-			}
-			if stack[len(stack)-1] != bracketPairs[char] {
-				return false
-			}
-			stack = stack[:len(stack)-1]
-		}
-	}
-
-	return len(stack) == 0
-}
-
 // sortRulesByPriority 按照优先级排序规则（优先级数字越大优先级越高）
 func (s *RuleExecutionService) sortRulesByPriority(rules []*model.Rule) {
 	// 使用稳定的排序算法，按照优先级降序排列
@@ -813,9 +736,3 @@ func (s *RuleExecutionService) updateContextWithRuleResult(context *model.RuleCo
 	}
 	return newContext
 }
-
-// FormulaResult 公式计算结果
-type FormulaResult struct {
-	Valid bool        `json:"valid"`
-	Value interface{} `json:"value"`
-}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// globalScope 全局作用域，匹配所有作用域的执行
+const globalScope = "global"
+
+// ruleIndexKey 规则索引键
+type ruleIndexKey struct {
+	tenantID string
+	scope    string
+	trigger  string
+	timing   string
+}
+
+// indexedRule 索引中的规则，预先拆分作用域ID
+type indexedRule struct {
+	rule     *model.Rule
+	scopeIDs map[string]struct{} // 限定的作用域ID，为空时不限定
+}
+
+// matches 是否匹配作用域ID
+func (r *indexedRule) matches(scopeID string) bool {
+	if r.scopeIDs == nil {
+		return true
+	}
+	_, ok := r.scopeIDs[scopeID]
+	return ok
+}
+
+// RuleIndex 启用规则的只读索引
+// 按租户、作用域、触发动作和执行时机分组，与 findMatchingRules 的匹配结果相同：
+//   - 每个规则同时按所属租户和空租户建立索引，上下文没有租户时匹配所有租户的规则，与数据库查询不加租户条件一致
+//   - 执行时机为 both 的规则单独分组，查询时与指定时机的分组合并
+//
+// 同时保存规则分类，执行时读取分类的执行策略和数据库访问策略
+//
+// 索引创建后不再修改，规则或分类变化时整体替换，查询不需要加锁
+type RuleIndex struct {
+	buckets    map[ruleIndexKey][]*indexedRule
+	categories map[string]*model.RuleCategory
+	size       int
+}
+
+// NewRuleIndex 根据规则和分类列表创建索引，未启用的规则不会加入索引
+func NewRuleIndex(rules []*model.Rule, categories []*model.RuleCategory) *RuleIndex {
+	idx := &RuleIndex{
+		buckets:    make(map[ruleIndexKey][]*indexedRule),
+		categories: make(map[string]*model.RuleCategory, len(categories)),
+	}
+	for _, category := range categories {
+		idx.categories[category.ID] = category
+	}
+	for _, rule := range rules {
+		if !rule.IsEnabled() {
+			continue
+		}
+		entry := &indexedRule{rule: rule}
+		if rule.ScopeID != "" {
+			entry.scopeIDs = make(map[string]struct{})
+			for _, id := range strings.Split(rule.ScopeID, ",") {
+				entry.scopeIDs[id] = struct{}{}
+			}
+		}
+		tenants := []string{""}
+		if rule.TenantID != "" {
+			tenants = append(tenants, rule.TenantID)
+		}
+		for _, tenantID := range tenants {
+			for _, trigger := range rule.Triggers {
+				key := ruleIndexKey{tenantID: tenantID, scope: rule.Scope, trigger: trigger, timing: rule.ExecutionTiming}
+				idx.buckets[key] = append(idx.buckets[key], entry)
+			}
+		}
+		idx.size++
+	}
+	return idx
+}
+
+// Size 索引中的规则数量
+func (idx *RuleIndex) Size() int {
+	return idx.size
+}
+
+// Category 获取规则分类，分类不存在时返回 nil
+func (idx *RuleIndex) Category(id string) *model.RuleCategory {
+	return idx.categories[id]
+}
+
+// Match 查找匹配执行上下文的规则，结果未排序
+func (idx *RuleIndex) Match(tenantID string, context *model.RuleContext) []*model.Rule {
+	scopes := []string{globalScope}
+	if context.Scope != "" && context.Scope != globalScope {
+		scopes = append(scopes, context.Scope)
+	}
+	timings := []string{context.ExecutionTiming}
+	if context.ExecutionTiming != "both" {
+		timings = append(timings, "both")
+	}
+
+	var (
+		rules []*model.Rule
+		seen  map[string]struct{}
+	)
+	for _, scope := range scopes {
+		for _, timing := range timings {
+			bucket := idx.buckets[ruleIndexKey{tenantID: tenantID, scope: scope, trigger: context.Trigger, timing: timing}]
+			for _, entry := range bucket {
+				if !entry.matches(context.ScopeID) {
+					continue
+				}
+				// 重复的触发动作会使规则在同一分组中出现多次
+				if seen == nil {
+					seen = make(map[string]struct{})
+				}
+				if _, ok := seen[entry.rule.ID]; ok {
+					continue
+				}
+				seen[entry.rule.ID] = struct{}{}
+				rules = append(rules, entry.rule)
+			}
+		}
+	}
+	return rules
+}
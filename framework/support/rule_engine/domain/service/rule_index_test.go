@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/actx"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/plugin"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+)
+
+// scopeRuleRepository 按作用域和租户过滤的内存规则仓储，与数据库查询的过滤方式相同
+type scopeRuleRepository struct {
+	repository.IRuleRepository
+	rules []*model.Rule
+	loads atomic.Int32
+}
+
+func (r *scopeRuleRepository) FindByScope(ctx context.Context, scope string) ([]*model.Rule, error) {
+	tenantID := ""
+	if !plugin.IsIgnoreTenant(ctx) {
+		tenantID = plugin.GetCtxTenantID(ctx)
+	}
+	var rules []*model.Rule
+	for _, rule := range r.rules {
+		if tenantID != "" && rule.TenantID != tenantID {
+			continue
+		}
+		if rule.Scope == globalScope || rule.Scope == scope {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *scopeRuleRepository) FindAll(ctx context.Context) ([]*model.Rule, error) {
+	r.loads.Add(1)
+	if !plugin.IsIgnoreTenant(ctx) && plugin.GetCtxTenantID(ctx) != "" {
+		return nil, fmt.Errorf("cache should load rules of all tenants")
+	}
+	return r.rules, nil
+}
+
+// generateRules 生成分布在多个租户、作用域、触发动作和执行时机上的规则
+func generateRules(n int) []*model.Rule {
+	scopes := []string{globalScope, "order", "user", "payment", "coupon"}
+	triggers := []string{"create", "update", "delete", "pay"}
+	timings := []string{"before", "after", "both"}
+	rules := make([]*model.Rule, 0, n)
+	for i := 0; i < n; i++ {
+		rule := &model.Rule{
+			ID:              fmt.Sprintf("r%d", i),
+			Code:            fmt.Sprintf("rule_%d", i),
+			Status:          1,
+			Priority:        int32(i % 7),
+			TenantID:        fmt.Sprintf("t%d", i%3),
+			Scope:           scopes[i%len(scopes)],
+			Triggers:        []string{triggers[i%len(triggers)], triggers[(i/3)%len(triggers)]},
+			ExecutionTiming: timings[i%len(timings)],
+		}
+		if i%11 == 0 {
+			rule.Status = 2
+		}
+		if i%5 == 1 {
+			rule.ScopeID = fmt.Sprintf("s%d,s%d", i%4, (i+1)%4)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func ruleIDs(rules []*model.Rule) []string {
+	ids := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestRuleCacheMatchesRepository(t *testing.T) {
+	repo := &scopeRuleRepository{rules: generateRules(300)}
+	s := NewRuleExecutionService(repo, nil, nil, nil, nil, nil, nil, nil)
+	cache := NewRuleCache(repo, nil, nil, RuleCacheConfig{RefreshInterval: -1})
+	defer cache.Close()
+	if err := cache.Reload(actx.WithTenantId(context.Background(), "t1")); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	ctxs := map[string]context.Context{
+		"no tenant":     context.Background(),
+		"tenant":        actx.WithTenantId(context.Background(), "t1"),
+		"ignore tenant": actx.BuildIgnoreTenantCtx(actx.WithTenantId(context.Background(), "t2")),
+	}
+	for name, ctx := range ctxs {
+		for _, scope := range []string{"", globalScope, "order", "coupon", "unknown"} {
+			for _, trigger := range []string{"create", "pay", "unknown"} {
+				for _, timing := range []string{"before", "after", "both"} {
+					for _, scopeID := range []string{"", "s1", "s3"} {
+						rc := model.NewRuleContext(scope, trigger, timing, scopeID)
+						want, herr := s.findMatchingRules(ctx, rc)
+						if herr != nil {
+							t.Fatalf("find: %v", herr)
+						}
+						got, ok := cache.Match(ctx, rc)
+						if !ok {
+							t.Fatal("cache is not loaded")
+						}
+						if fmt.Sprint(ruleIDs(got)) != fmt.Sprint(ruleIDs(want)) {
+							t.Fatalf("%s %s/%s/%s/%s: got %v, want %v", name, scope, trigger, timing, scopeID, ruleIDs(got), ruleIDs(want))
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// memoryNotifier 在同一进程内广播的变更通知
+type memoryNotifier struct {
+	subscribers chan func(string)
+	handlers    []func(string)
+}
+
+func (n *memoryNotifier) Publish(ctx context.Context, source string) error {
+	for _, handler := range n.handlers {
+		handler(source)
+	}
+	return nil
+}
+
+func (n *memoryNotifier) Subscribe(ctx context.Context, handler func(source string)) {
+	n.subscribers <- handler
+	<-ctx.Done()
+}
+
+func TestRuleCacheInvalidate(t *testing.T) {
+	repo := &scopeRuleRepository{}
+	notifier := &memoryNotifier{subscribers: make(chan func(string), 2)}
+	local := NewRuleCache(repo, nil, notifier, RuleCacheConfig{RefreshInterval: -1})
+	defer local.Close()
+	remote := NewRuleCache(repo, nil, notifier, RuleCacheConfig{RefreshInterval: -1})
+	defer remote.Close()
+	notifier.handlers = []func(string){<-notifier.subscribers, <-notifier.subscribers}
+
+	rc := model.NewRuleContext("order", "create", "before", "")
+	if _, ok := local.Match(context.Background(), rc); ok {
+		t.Fatal("cache should not match before loaded")
+	}
+	for _, c := range []*RuleCache{local, remote} {
+		if err := c.Reload(context.Background()); err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+	}
+
+	repo.rules = []*model.Rule{{ID: "r1", Status: 1, Scope: "order", Triggers: []string{"create"}, ExecutionTiming: "before"}}
+	local.Invalidate(context.Background())
+	if rules, _ := local.Match(context.Background(), rc); len(rules) != 1 {
+		t.Fatalf("local cache should be reloaded immediately, got %d rules", len(rules))
+	}
+	// 远端实例收到通知后在后台重建，本实例忽略自己发出的通知
+	for i := 0; ; i++ {
+		if rules, _ := remote.Match(context.Background(), rc); len(rules) == 1 {
+			break
+		}
+		if i > 1000 {
+			t.Fatal("remote cache was not reloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if loads := repo.loads.Load(); loads != 4 {
+		t.Fatalf("loaded %d times, want 4", loads)
+	}
+
+	var nilCache *RuleCache
+	nilCache.Invalidate(context.Background())
+	if _, ok := nilCache.Match(context.Background(), rc); ok {
+		t.Fatal("nil cache should not match")
+	}
+}
+
+// 分类的执行策略从缓存读取，执行时不查询分类，分类变更后随缓存刷新
+func TestRuleCacheCategories(t *testing.T) {
+	repo := &scopeRuleRepository{rules: []*model.Rule{
+		chainRule("limit", "c1", 2, "amount > 1000", "deny"),
+		chainRule("fee", "c1", 1, "amount + 1", "allow"),
+	}}
+	categories := &strategyCategoryRepository{strategies: map[string]string{"c1": model.ChainStrategyFirstMatch}}
+	cache := NewRuleCache(repo, categories, nil, RuleCacheConfig{RefreshInterval: -1})
+	defer cache.Close()
+	if err := cache.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	s := NewRuleExecutionService(repo, categories, nil, nil, nil, nil, cache, nil)
+	execute := func() *model.RuleResult {
+		rc := model.NewRuleContext("order", "create", "before", "")
+		rc.SetData(map[string]interface{}{"amount": 500})
+		result, herr := s.ExecuteRules(context.Background(), rc)
+		if herr != nil {
+			t.Fatalf("execute: %v", herr)
+		}
+		return result
+	}
+
+	for i := 0; i < 3; i++ {
+		if result := execute(); !result.Valid || len(result.ExecutionChain) != 2 {
+			t.Fatalf("first_match: valid=%v steps=%d", result.Valid, len(result.ExecutionChain))
+		}
+	}
+	if categories.finds != 0 {
+		t.Fatalf("category queried %d times, want cached", categories.finds)
+	}
+
+	categories.strategies["c1"] = model.ChainStrategyAll
+	cache.Invalidate(context.Background())
+	if result := execute(); result.Valid || len(result.ExecutionChain) != 1 {
+		t.Fatalf("all: valid=%v steps=%d", result.Valid, len(result.ExecutionChain))
+	}
+	if categories.finds != 0 {
+		t.Fatalf("category queried %d times, want cached", categories.finds)
+	}
+}
+
+// BenchmarkFindMatchingRules 当前实现：每次执行查询两次仓储并过滤
+// 仓储在内存中，结果不包含数据库往返的耗时，实际差距更大
+func BenchmarkFindMatchingRules(b *testing.B) {
+	repo := &scopeRuleRepository{rules: generateRules(1000)}
+	s := NewRuleExecutionService(repo, nil, nil, nil, nil, nil, nil, nil)
+	ctx := actx.WithTenantId(context.Background(), "t1")
+	rc := model.NewRuleContext("order", "create", "before", "s1")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, herr := s.matchRules(ctx, rc); herr != nil {
+			b.Fatal(herr)
+		}
+	}
+}
+
+// BenchmarkRuleCacheMatch 使用规则索引匹配
+func BenchmarkRuleCacheMatch(b *testing.B) {
+	repo := &scopeRuleRepository{rules: generateRules(1000)}
+	cache := NewRuleCache(repo, nil, nil, RuleCacheConfig{RefreshInterval: -1})
+	defer cache.Close()
+	if err := cache.Reload(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+	s := NewRuleExecutionService(repo, nil, nil, nil, nil, nil, cache, nil)
+	ctx := actx.WithTenantId(context.Background(), "t1")
+	rc := model.NewRuleContext("order", "create", "before", "s1")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, herr := s.matchRules(ctx, rc); herr != nil {
+			b.Fatal(herr)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/snowflake_id"
 	"github.com/flare-admin/flare-server-go/framework/pkg/lua_engine"
@@ -18,8 +20,12 @@ type RuleService struct {
 	ruleRepo     repository.IRuleRepository
 	templateRepo repository.ITemplateRepository
 	categoryRepo repository.ICategoryRepository
+	versionRepo  repository.IRuleVersionRepository
+	testCaseRepo repository.IRuleTestCaseRepository
+	execution    *RuleExecutionService // 模拟执行和测试用例
 	ruleExecutor *lua_engine.RuleExecutor
 	ig           snowflake_id.IIdGenerate
+	cache        *RuleCache // 规则缓存，规则变更后刷新，为 nil 时不使用缓存
 }
 
 // NewRuleService 创建规则服务
@@ -27,19 +33,28 @@ func NewRuleService(
 	ruleRepo repository.IRuleRepository,
 	templateRepo repository.ITemplateRepository,
 	categoryRepo repository.ICategoryRepository,
+	versionRepo repository.IRuleVersionRepository,
+	testCaseRepo repository.IRuleTestCaseRepository,
+	execution *RuleExecutionService,
 	ruleExecutor *lua_engine.RuleExecutor,
 	ig snowflake_id.IIdGenerate,
+	cache *RuleCache,
 ) *RuleService {
 	return &RuleService{
 		ruleRepo:     ruleRepo,
 		templateRepo: templateRepo,
 		categoryRepo: categoryRepo,
+		versionRepo:  versionRepo,
+		testCaseRepo: testCaseRepo,
+		execution:    execution,
 		ruleExecutor: ruleExecutor,
 		ig:           ig,
+		cache:        cache,
 	}
 }
 
 // CreateRule 创建规则
+// 创建时的内容直接发布为版本 1
 func (s *RuleService) CreateRule(ctx context.Context, rule *model.Rule) *herrors.HError {
 	// 验证规则数据
 	if err := rule.Validate(); err != nil {
@@ -90,11 +105,23 @@ func (s *RuleService) CreateRule(ctx context.Context, rule *model.Rule) *herrors
 		return ruleengineerr.RuleCreateFailed(err)
 	}
 
+	// 发布初始版本
+	version := model.NewRuleDraft(rule)
+	if err := version.Publish(1, "初始版本"); err != nil {
+		return ruleengineerr.RulePublishFailed(err)
+	}
+	rule.SetPublishedVersion(version)
+	if err := s.versionRepo.Publish(ctx, rule, []*model.RuleVersion{version}, false); err != nil {
+		return ruleengineerr.RulePublishFailed(err)
+	}
+	s.cache.Invalidate(ctx)
+
 	return nil
 }
 
-// UpdateRule 更新规则
-func (s *RuleService) UpdateRule(ctx context.Context, rule *model.Rule) *herrors.HError {
+// SaveRuleDraft 保存规则草稿
+// 编码、名称和描述立即生效，其余内容保存为草稿，发布后才会在执行时生效
+func (s *RuleService) SaveRuleDraft(ctx context.Context, rule *model.Rule) *herrors.HError {
 	// 验证规则数据
 	if err := rule.Validate(); err != nil {
 		return ruleengineerr.RuleValidationFailed(err)
@@ -147,14 +174,251 @@ func (s *RuleService) UpdateRule(ctx context.Context, rule *model.Rule) *herrors
 		return ruleengineerr.RuleContentInvalid
 	}
 	rule.Completion()
-	// 更新规则
-	if err := s.ruleRepo.Update(ctx, rule); err != nil {
-		return ruleengineerr.RuleUpdateFailed(err)
+
+	// 更新基础信息
+	if rule.Code != existingRule.Code || rule.Name != existingRule.Name || rule.Description != existingRule.Description {
+		if rule.Code != existingRule.Code {
+			existingRule.ChangeCode(rule.Code)
+		}
+		existingRule.Update(rule.Name, rule.Description)
+		if err := s.ruleRepo.Update(ctx, existingRule); err != nil {
+			return ruleengineerr.RuleUpdateFailed(err)
+		}
+		s.cache.Invalidate(ctx)
+	}
+
+	// 保存草稿
+	if err := s.versionRepo.SaveDraft(ctx, model.NewRuleDraft(rule)); err != nil {
+		return ruleengineerr.RuleDraftSaveFailed(err)
+	}
+
+	return nil
+}
+
+// GetRuleDraft 获取应用了草稿内容的规则，没有草稿时返回线上内容
+func (s *RuleService) GetRuleDraft(ctx context.Context, ruleID string) (*model.Rule, *herrors.HError) {
+	rule, err := s.ruleRepo.FindByID(ctx, ruleID)
+	if err != nil {
+		return nil, ruleengineerr.RuleGetFailed(err)
+	}
+	draft, err := s.versionRepo.FindDraft(ctx, ruleID)
+	if err != nil {
+		return nil, ruleengineerr.RuleVersionGetFailed(err)
+	}
+	if draft != nil {
+		draft.ApplyTo(rule)
+	}
+	return rule, nil
+}
+
+// PublishRule 发布规则草稿，生成新的不可修改的版本
+func (s *RuleService) PublishRule(ctx context.Context, ruleID, comment string) (*model.RuleVersion, *herrors.HError) {
+	rule, err := s.ruleRepo.FindByID(ctx, ruleID)
+	if err != nil {
+		return nil, ruleengineerr.RuleGetFailed(err)
+	}
+	draft, err := s.versionRepo.FindDraft(ctx, ruleID)
+	if err != nil {
+		return nil, ruleengineerr.RuleVersionGetFailed(err)
+	}
+	if draft == nil {
+		return nil, ruleengineerr.RuleDraftNotExist
+	}
+
+	// 草稿保存后分类、模板或依赖的内容可能已变化，发布前重新校验
+	candidate := rule.WithVersion(draft)
+	if herr := s.ValidateRule(ctx, candidate); herr != nil {
+		return nil, herr
+	}
+
+	// 启用的测试用例全部通过才允许发布
+	report, herr := s.runTestCases(ctx, candidate)
+	if herr != nil {
+		return nil, herr
+	}
+	if !report.AllPassed() {
+		return nil, ruleengineerr.RuleTestCaseFailed(fmt.Errorf("测试用例未通过: %s", strings.Join(report.FailedNames(), ", ")))
+	}
+
+	latest, err := s.versionRepo.LatestVersion(ctx, ruleID)
+	if err != nil {
+		return nil, ruleengineerr.RuleVersionGetFailed(err)
+	}
+	versions := make([]*model.RuleVersion, 0, 2)
+	if latest == 0 {
+		// 引入版本管理之前创建的规则没有历史版本，先将当前线上内容保存为版本 1，以便回滚
+		baseline := model.NewRuleDraft(rule)
+		if err := baseline.Publish(1, "发布前的线上内容"); err != nil {
+			return nil, ruleengineerr.RulePublishFailed(err)
+		}
+		versions = append(versions, baseline)
+		latest = 1
+	}
+	if err := draft.Publish(latest+1, comment); err != nil {
+		return nil, ruleengineerr.RulePublishFailed(err)
+	}
+	versions = append(versions, draft)
+
+	rule.SetPublishedVersion(draft)
+	if err := s.versionRepo.Publish(ctx, rule, versions, true); err != nil {
+		return nil, ruleengineerr.RulePublishFailed(err)
+	}
+	s.cache.Invalidate(ctx)
+	return draft, nil
+}
+
+// RollbackRule 回滚到指定的已发布版本
+// 回滚不会修改历史版本，而是以目标版本的内容发布一个新版本，草稿保持不变
+func (s *RuleService) RollbackRule(ctx context.Context, ruleID string, version int32, comment string) (*model.RuleVersion, *herrors.HError) {
+	rule, err := s.ruleRepo.FindByID(ctx, ruleID)
+	if err != nil {
+		return nil, ruleengineerr.RuleGetFailed(err)
+	}
+	if rule.PublishedVersion == version {
+		return nil, ruleengineerr.RuleVersionIsCurrent
+	}
+	target, err := s.versionRepo.FindVersion(ctx, ruleID, version)
+	if err != nil {
+		return nil, ruleengineerr.RuleVersionGetFailed(err)
+	}
+	if target == nil {
+		return nil, ruleengineerr.RuleVersionNotExist
+	}
+
+	latest, err := s.versionRepo.LatestVersion(ctx, ruleID)
+	if err != nil {
+		return nil, ruleengineerr.RuleVersionGetFailed(err)
+	}
+	if comment == "" {
+		comment = fmt.Sprintf("回滚到版本 %d", version)
+	}
+	rollback := target.Rollback()
+	if err := rollback.Publish(latest+1, comment); err != nil {
+		return nil, ruleengineerr.RulePublishFailed(err)
+	}
+
+	rule.SetPublishedVersion(rollback)
+	if err := s.versionRepo.Publish(ctx, rule, []*model.RuleVersion{rollback}, false); err != nil {
+		return nil, ruleengineerr.RulePublishFailed(err)
+	}
+	s.cache.Invalidate(ctx)
+	return rollback, nil
+}
+
+// DiscardRuleDraft 丢弃规则草稿
+func (s *RuleService) DiscardRuleDraft(ctx context.Context, ruleID string) *herrors.HError {
+	draft, err := s.versionRepo.FindDraft(ctx, ruleID)
+	if err != nil {
+		return ruleengineerr.RuleVersionGetFailed(err)
+	}
+	if draft == nil {
+		return ruleengineerr.RuleDraftNotExist
+	}
+	if err := s.versionRepo.DeleteDraft(ctx, ruleID); err != nil {
+		return ruleengineerr.RuleDraftSaveFailed(err)
+	}
+	return nil
+}
+
+// CreateRuleTestCase 创建规则测试用例
+func (s *RuleService) CreateRuleTestCase(ctx context.Context, testCase *model.RuleTestCase) *herrors.HError {
+	rule, err := s.ruleRepo.FindByID(ctx, testCase.RuleID)
+	if err != nil {
+		return ruleengineerr.RuleGetFailed(err)
 	}
+	if err := testCase.Validate(); err != nil {
+		return ruleengineerr.RuleTestCaseValidationFailed(err)
+	}
+	testCase.TenantID = rule.TenantID
+	if err := s.testCaseRepo.Create(ctx, testCase); err != nil {
+		return ruleengineerr.RuleTestCaseCreateFailed(err)
+	}
+	return nil
+}
 
+// UpdateRuleTestCase 更新规则测试用例
+func (s *RuleService) UpdateRuleTestCase(ctx context.Context, testCase *model.RuleTestCase) *herrors.HError {
+	if err := testCase.Validate(); err != nil {
+		return ruleengineerr.RuleTestCaseValidationFailed(err)
+	}
+	if err := s.testCaseRepo.Update(ctx, testCase); err != nil {
+		return ruleengineerr.RuleTestCaseUpdateFailed(err)
+	}
 	return nil
 }
 
+// GetRuleTestCase 获取规则测试用例
+func (s *RuleService) GetRuleTestCase(ctx context.Context, id string) (*model.RuleTestCase, *herrors.HError) {
+	testCase, err := s.testCaseRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ruleengineerr.RuleTestCaseGetFailed(err)
+	}
+	if testCase == nil {
+		return nil, ruleengineerr.RuleTestCaseNotExist
+	}
+	return testCase, nil
+}
+
+// DeleteRuleTestCase 删除规则测试用例
+func (s *RuleService) DeleteRuleTestCase(ctx context.Context, id string) *herrors.HError {
+	if _, herr := s.GetRuleTestCase(ctx, id); herr != nil {
+		return herr
+	}
+	if err := s.testCaseRepo.Delete(ctx, id); err != nil {
+		return ruleengineerr.RuleTestCaseDeleteFailed(err)
+	}
+	return nil
+}
+
+// SimulateRules 模拟执行匹配上下文的所有规则，不产生数据库写入
+func (s *RuleService) SimulateRules(ctx context.Context, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+	return s.execution.SimulateRules(ctx, context)
+}
+
+// SimulateRule 模拟执行单个规则，useDraft 为 true 时执行草稿内容，没有草稿时执行线上内容
+func (s *RuleService) SimulateRule(ctx context.Context, ruleID string, useDraft bool, context *model.RuleContext) (*model.RuleResult, *herrors.HError) {
+	rule, herr := s.findRuleContent(ctx, ruleID, useDraft)
+	if herr != nil {
+		return nil, herr
+	}
+	if context.TenantID == "" {
+		context.WithTenantID(rule.TenantID)
+	}
+	return s.execution.SimulateRule(ctx, rule, context)
+}
+
+// RunRuleTestCases 执行规则的所有启用的测试用例，useDraft 为 true 时测试草稿内容
+func (s *RuleService) RunRuleTestCases(ctx context.Context, ruleID string, useDraft bool) (*model.RuleTestReport, *herrors.HError) {
+	rule, herr := s.findRuleContent(ctx, ruleID, useDraft)
+	if herr != nil {
+		return nil, herr
+	}
+	return s.runTestCases(ctx, rule)
+}
+
+// findRuleContent 获取规则的线上内容或草稿内容
+func (s *RuleService) findRuleContent(ctx context.Context, ruleID string, useDraft bool) (*model.Rule, *herrors.HError) {
+	if useDraft {
+		return s.GetRuleDraft(ctx, ruleID)
+	}
+	return s.GetRule(ctx, ruleID)
+}
+
+// runTestCases 对规则内容执行启用的测试用例
+func (s *RuleService) runTestCases(ctx context.Context, rule *model.Rule) (*model.RuleTestReport, *herrors.HError) {
+	cases, err := s.testCaseRepo.FindByRuleID(ctx, rule.ID)
+	if err != nil {
+		return nil, ruleengineerr.RuleTestCaseGetFailed(err)
+	}
+	enabled := make([]*model.RuleTestCase, 0, len(cases))
+	for _, testCase := range cases {
+		if testCase.Enabled {
+			enabled = append(enabled, testCase)
+		}
+	}
+	return s.execution.RunTestCases(ctx, rule, enabled)
+}
+
 // DeleteRule 删除规则
 func (s *RuleService) DeleteRule(ctx context.Context, ruleID string) *herrors.HError {
 	// 检查规则是否存在
@@ -167,6 +431,17 @@ func (s *RuleService) DeleteRule(ctx context.Context, ruleID string) *herrors.HE
 	if err := s.ruleRepo.Delete(ctx, ruleID); err != nil {
 		return ruleengineerr.RuleDeleteFailed(err)
 	}
+	s.cache.Invalidate(ctx)
+
+	// 删除规则的版本和草稿
+	if err := s.versionRepo.DeleteByRuleID(ctx, ruleID); err != nil {
+		return ruleengineerr.RuleDeleteFailed(err)
+	}
+
+	// 删除规则的测试用例
+	if err := s.testCaseRepo.DeleteByRuleID(ctx, ruleID); err != nil {
+		return ruleengineerr.RuleDeleteFailed(err)
+	}
 
 	return nil
 }
@@ -278,6 +553,7 @@ func (s *RuleService) EnableRule(ctx context.Context, ruleID string) *herrors.HE
 	if err := s.ruleRepo.Update(ctx, rule); err != nil {
 		return ruleengineerr.RuleUpdateFailed(err)
 	}
+	s.cache.Invalidate(ctx)
 
 	return nil
 }
@@ -293,10 +569,54 @@ func (s *RuleService) DisableRule(ctx context.Context, ruleID string) *herrors.H
 	if err := s.ruleRepo.Update(ctx, rule); err != nil {
 		return ruleengineerr.RuleUpdateFailed(err)
 	}
+	s.cache.Invalidate(ctx)
 
 	return nil
 }
 
+// ImportDecisionTable 从 Excel 导入决策表规则的行，保存为草稿，发布后生效
+// Excel 没有列定义时沿用草稿中的列，返回导入后的重叠和遗漏检查报告
+func (s *RuleService) ImportDecisionTable(ctx context.Context, ruleID string, r io.Reader) (*model.DecisionTableReport, *herrors.HError) {
+	rule, herr := s.GetRuleDraft(ctx, ruleID)
+	if herr != nil {
+		return nil, herr
+	}
+	if rule.Type != "decision_table" {
+		return nil, ruleengineerr.RuleTypeInvalid
+	}
+
+	var base *model.DecisionTable
+	if rule.DecisionTable != "" {
+		if current, err := rule.CompileDecisionTable(); err == nil {
+			base = current.Table
+		}
+	}
+	table, err := DecisionTableFromExcel(r, base)
+	if err != nil {
+		return nil, ruleengineerr.RuleDecisionTableImportFailed(err)
+	}
+	data, err := table.ToJSON()
+	if err != nil {
+		return nil, ruleengineerr.RuleDecisionTableImportFailed(err)
+	}
+	if err := rule.SetDecisionTable(data); err != nil {
+		return nil, ruleengineerr.RuleDecisionTableImportFailed(err)
+	}
+	if herr := s.SaveRuleDraft(ctx, rule); herr != nil {
+		return nil, herr
+	}
+	return s.AnalyzeDecisionTable(table)
+}
+
+// AnalyzeDecisionTable 检查决策表的重叠和遗漏
+func (s *RuleService) AnalyzeDecisionTable(table *model.DecisionTable) (*model.DecisionTableReport, *herrors.HError) {
+	compiled, err := table.Compile()
+	if err != nil {
+		return nil, ruleengineerr.RuleValidationFailed(err)
+	}
+	return compiled.Analyze(), nil
+}
+
 // ValidateRule 验证规则
 func (s *RuleService) ValidateRule(ctx context.Context, rule *model.Rule) *herrors.HError {
 	if err := rule.Validate(); err != nil {
@@ -315,16 +635,10 @@ func (s *RuleService) ValidateRule(ctx context.Context, rule *model.Rule) *herro
 func (s *RuleService) validateRuleContent(ctx context.Context, rule *model.Rule) error {
 	switch rule.Type {
 	case "condition":
-		// 验证条件规则
-		//if rule.Conditions == nil || len(rule.Conditions) == 0 {
-		//	return fmt.Errorf("condition rule must have conditions configuration")
-		//}
-		//// 验证条件配置
-		//for i, condition := range rule.Conditions {
-		//	if err := s.validateCondition(condition); err != nil {
-		//		return fmt.Errorf("condition %d validation failed: %w", i, err)
-		//	}
-		//}
+		// 验证条件树结构、操作符和比较值
+		if _, err := model.ParseConditionTreeJSON(rule.Conditions); err != nil {
+			return fmt.Errorf("condition validation failed: %w", err)
+		}
 		return nil
 	case "lua":
 		// 验证Lua脚本规则
@@ -341,165 +655,22 @@ func (s *RuleService) validateRuleContent(ctx context.Context, rule *model.Rule)
 		if rule.Formula == "" {
 			return fmt.Errorf("formula rule must have formula")
 		}
-		// 验证公式语法
-		if err := s.validateFormula(rule.Formula); err != nil {
+		// 编译公式，检查语法、函数和变量定义
+		if _, _, err := rule.CompileFormula(); err != nil {
 			return fmt.Errorf("formula validation failed: %w", err)
 		}
 		return nil
-	default:
-		return fmt.Errorf("unsupported rule type: %s", rule.Type)
-	}
-}
-
-// validateCondition 验证条件配置
-func (s *RuleService) validateCondition(condition map[string]interface{}) error {
-	// 检查必要字段
-	field, ok := condition["field"].(string)
-	if !ok || field == "" {
-		return fmt.Errorf("condition must have valid field")
-	}
-
-	operator, ok := condition["operator"].(string)
-	if !ok || operator == "" {
-		return fmt.Errorf("condition must have valid operator")
-	}
-
-	// 验证操作符
-	validOperators := []string{"eq", "ne", "gt", "gte", "lt", "lte", "in", "nin", "contains", "not_contains", "regex"}
-	isValidOperator := false
-	for _, validOp := range validOperators {
-		if operator == validOp {
-			isValidOperator = true
-			break
-		}
-	}
-	if !isValidOperator {
-		return fmt.Errorf("invalid operator: %s", operator)
-	}
-
-	// 检查值字段
-	if _, ok := condition["value"]; !ok {
-		return fmt.Errorf("condition must have value")
-	}
-
-	return nil
-}
-
-// validateFormula 验证公式语法
-func (s *RuleService) validateFormula(formula string) error {
-	// 基本语法检查
-	if len(formula) == 0 {
-		return fmt.Errorf("formula cannot be empty")
-	}
-
-	// 检查基本数学运算符
-	validOperators := []string{"+", "-", "*", "/", "(", ")", "=", ">", "<", ">=", "<=", "!=", "&&", "||"}
-	for _, operator := range validOperators {
-		if contains(formula, operator) {
-			// 检查运算符前后是否有操作数
-			if !s.checkOperatorContext(formula, operator) {
-				return fmt.Errorf("invalid operator context for '%s'", operator)
-			}
-		}
-	}
-
-	// 检查括号匹配
-	if !s.checkBracketMatch(formula) {
-		return fmt.Errorf("formula has unmatched brackets")
-	}
-
-	// 检查变量引用格式
-	if err := s.checkVariableReferences(formula); err != nil {
-		return fmt.Errorf("invalid variable reference: %w", err)
-	}
-
-	return nil
-}
-
-// checkBracketMatch 检查括号匹配
-func (s *RuleService) checkBracketMatch(text string) bool {
-	stack := make([]rune, 0)
-	bracketPairs := map[rune]rune{
-		')': '(',
-		'}': '{',
-		']': '[',
-	}
-
-	for _, char := range text {
-		switch char {
-		case '(', '{', '[':
-			stack = append(stack, char)
-		case ')', '}', ']':
-			if len(stack) == 0 {
-				return false
-			}
-			if stack[len(stack)-1] != bracketPairs[char] {
-				return false
-			}
-			stack = stack[:len(stack)-1]
-		}
-	}
-
-	return len(stack) == 0
-}
-
-// checkOperatorContext 检查运算符上下文
-func (s *RuleService) checkOperatorContext(formula, operator string) bool {
-	// 简单的上下文检查，确保运算符前后有字符
-	index := 0
-	for {
-		pos := indexOf(formula[index:], operator)
-		if pos == -1 {
-			break
-		}
-		actualPos := index + pos
-
-		// 检查运算符前是否有字符
-		if actualPos > 0 && formula[actualPos-1] == ' ' {
-			// 运算符前是空格，需要检查更前面的字符
-			prevChar := actualPos - 2
-			if prevChar < 0 || formula[prevChar] == ' ' {
-				return false
-			}
+	case "decision_table":
+		// 验证决策表结构，unique 和 priority 策略不允许冲突的重叠
+		table, err := rule.CompileDecisionTable()
+		if err != nil {
+			return fmt.Errorf("decision table validation failed: %w", err)
 		}
-
-		// 检查运算符后是否有字符
-		if actualPos+len(operator) < len(formula) && formula[actualPos+len(operator)] == ' ' {
-			// 运算符后是空格，需要检查更后面的字符
-			nextChar := actualPos + len(operator) + 1
-			if nextChar >= len(formula) || formula[nextChar] == ' ' {
-				return false
-			}
-		}
-
-		index = actualPos + len(operator)
-	}
-
-	return true
-}
-
-// checkVariableReferences 检查变量引用
-func (s *RuleService) checkVariableReferences(formula string) error {
-	// 检查变量引用格式，例如 ${varName} 或 $varName
-	// 这里实现简单的检查逻辑
-	if contains(formula, "${") && !contains(formula, "}") {
-		return fmt.Errorf("unclosed variable reference")
-	}
-
-	return nil
-}
-
-// contains 检查字符串是否包含子字符串
-func contains(s, substr string) bool {
-	return indexOf(s, substr) != -1
-}
-
-// indexOf 查找子字符串的位置
-func indexOf(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
+		if err := table.Table.Validate(); err != nil {
+			return fmt.Errorf("decision table validation failed: %w", err)
 		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported rule type: %s", rule.Type)
 	}
-	return -1
 }
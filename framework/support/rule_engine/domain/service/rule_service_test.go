@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	ruleengineerr "github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/err"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+)
+
+type draftRuleRepository struct {
+	repository.IRuleRepository
+	rule  *model.Rule
+	codes map[string]bool
+}
+
+func (r *draftRuleRepository) FindByID(ctx context.Context, id string) (*model.Rule, error) {
+	rule := *r.rule
+	return &rule, nil
+}
+
+func (r *draftRuleRepository) ExistsByCode(ctx context.Context, code string) (bool, error) {
+	return r.codes[code], nil
+}
+
+func (r *draftRuleRepository) Update(ctx context.Context, rule *model.Rule) error {
+	r.rule = rule
+	return nil
+}
+
+type draftCategoryRepository struct {
+	repository.ICategoryRepository
+}
+
+func (draftCategoryRepository) FindByID(ctx context.Context, id string) (*model.RuleCategory, error) {
+	return &model.RuleCategory{ID: id, Status: 1}, nil
+}
+
+type draftVersionRepository struct {
+	repository.IRuleVersionRepository
+	draft *model.RuleVersion
+}
+
+func (r *draftVersionRepository) SaveDraft(ctx context.Context, draft *model.RuleVersion) error {
+	r.draft = draft
+	return nil
+}
+
+func TestSaveRuleDraftChangesCode(t *testing.T) {
+	newRule := func(code, formula string) *model.Rule {
+		return &model.Rule{ID: "r1", CategoryID: "c1", Code: code, Name: "amount limit", Type: "formula", Formula: formula, Status: 1,
+			Scope: "order", Triggers: []string{"create"}, ExecutionTiming: "before", Action: "allow"}
+	}
+	ruleRepo := &draftRuleRepository{rule: newRule("amount_limit", "1"), codes: map[string]bool{"amount_limit": true, "taken": true}}
+	versionRepo := &draftVersionRepository{}
+	s := NewRuleService(ruleRepo, nil, draftCategoryRepository{}, versionRepo, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	if err := s.SaveRuleDraft(ctx, newRule("taken", "2")); err != ruleengineerr.RuleCodeExists {
+		t.Fatalf("duplicate code = %v", err)
+	}
+	if err := s.SaveRuleDraft(ctx, newRule("amount_cap", "2")); err != nil {
+		t.Fatal(err)
+	}
+	// 编码立即生效，公式保存为草稿
+	if ruleRepo.rule.Code != "amount_cap" || ruleRepo.rule.Formula != "1" {
+		t.Fatalf("rule = %+v", ruleRepo.rule)
+	}
+	if versionRepo.draft == nil || versionRepo.draft.Formula != "2" {
+		t.Fatalf("draft = %+v", versionRepo.draft)
+	}
+}
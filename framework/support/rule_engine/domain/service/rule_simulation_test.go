@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// rollbackDataBase 记录事务结果的数据库，不连接真实数据库
+type rollbackDataBase struct {
+	database.IDataBase
+	rollbacks int
+}
+
+func (d *rollbackDataBase) InIndependentTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	if err != nil {
+		d.rollbacks++
+	}
+	return err
+}
+
+func TestRunTestCases(t *testing.T) {
+	db := &rollbackDataBase{}
+	s := NewRuleExecutionService(nil, nil, nil, db, nil, nil, nil, nil)
+	rule := &model.Rule{ID: "r1", Code: "amount_limit", Type: "formula", Formula: "amount * 2", Action: "allow"}
+
+	valid := true
+	cases := []*model.RuleTestCase{
+		{ID: "c1", Name: "double", Input: map[string]interface{}{"amount": 21}, ExpectValid: &valid, ExpectOutputs: map[string]interface{}{"result": 42}},
+		{ID: "c2", Name: "wrong", Input: map[string]interface{}{"amount": 1}, ExpectAction: "deny", ExpectOutputs: map[string]interface{}{"result": 3, "missing": 1}},
+	}
+	report, herr := s.RunTestCases(context.Background(), rule, cases)
+	if herr != nil {
+		t.Fatalf("run test cases: %v", herr)
+	}
+	if report.Total != 2 || report.Passed != 1 || report.Failed != 1 || report.AllPassed() {
+		t.Fatalf("report = %+v", report)
+	}
+	if got := report.Results[1].Failures; len(got) != 3 {
+		t.Fatalf("failures = %v", got)
+	}
+	if names := report.FailedNames(); len(names) != 1 || names[0] != "wrong" {
+		t.Fatalf("failed names = %v", names)
+	}
+	if db.rollbacks != 2 {
+		t.Fatalf("each case should roll back, got %d rollbacks", db.rollbacks)
+	}
+	if steps := report.Results[0].Result.GetExecutionStepCount(); steps != 1 {
+		t.Fatalf("simulation should record the execution step, got %d", steps)
+	}
+	if cases[0].Input["scopeId"] != nil {
+		t.Fatal("running a case should not modify its input")
+	}
+
+	// 执行出错的用例记为失败，不影响后续用例
+	s = NewRuleExecutionService(nil, nil, nil, &failingDataBase{failures: 1}, nil, nil, nil, nil)
+	report, herr = s.RunTestCases(context.Background(), rule, cases)
+	if herr != nil {
+		t.Fatalf("run test cases with an error: %v", herr)
+	}
+	if report.Total != 2 || report.Passed != 0 || report.Failed != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if broken := report.Results[0]; len(broken.Failures) != 1 || broken.Result == nil || broken.Result.Error == "" {
+		t.Fatalf("broken case = %+v", broken)
+	}
+	if got := report.Results[1].Failures; len(got) != 3 {
+		t.Fatalf("the case after the error should still run, failures = %v", got)
+	}
+}
+
+func TestDryRunError(t *testing.T) {
+	s := NewRuleExecutionService(nil, nil, nil, &failingDataBase{}, nil, nil, nil, nil)
+	_, herr := s.SimulateRule(context.Background(), &model.Rule{Type: "formula", Formula: "1"}, model.NewRuleContext("", "", "", ""))
+	if herr == nil {
+		t.Fatal("transaction errors should be reported")
+	}
+}
+
+// failingDataBase 无法开启事务的数据库，failures 大于0时只有前几次失败
+type failingDataBase struct {
+	database.IDataBase
+	failures int
+	calls    int
+}
+
+func (d *failingDataBase) InIndependentTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	d.calls++
+	if d.failures > 0 && d.calls > d.failures {
+		return fn(ctx)
+	}
+	return errors.New("connection refused")
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hredis"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// ruleChangeChannel 规则变更channel
+const ruleChangeChannel = "rule_engine:rules:update"
+
+// RedisRuleChangeNotifier 基于 Redis 发布订阅的规则变更通知
+type RedisRuleChangeNotifier struct {
+	rdb *redis.Client
+}
+
+// NewRedisRuleChangeNotifier 创建规则变更通知
+func NewRedisRuleChangeNotifier(rdb *hredis.RedisClient) service.RuleChangeNotifier {
+	return &RedisRuleChangeNotifier{rdb: rdb.GetClient()}
+}
+
+// Publish 发布规则变更消息
+func (n *RedisRuleChangeNotifier) Publish(ctx context.Context, source string) error {
+	return n.rdb.Publish(ctx, ruleChangeChannel, source).Err()
+}
+
+// Subscribe 订阅规则变更消息，连接断开后由客户端自动重连
+func (n *RedisRuleChangeNotifier) Subscribe(ctx context.Context, handler func(source string)) {
+	pubsub := n.rdb.Subscribe(ctx, ruleChangeChannel)
+	defer func(pubsub *redis.PubSub) {
+		if err := pubsub.Close(); err != nil {
+			hlog.Errorf("close rule change pubsub error: %v", err)
+		}
+	}(pubsub)
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			handler(msg.Payload)
+		}
+	}
+}
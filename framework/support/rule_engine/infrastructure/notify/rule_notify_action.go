@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/service"
+)
+
+// ActionNotify 发送通知的规则动作
+const ActionNotify = "notify"
+
+// RuleNotifyTopic 规则通知默认发布的主题
+const RuleNotifyTopic = "rule_engine.rule_notify"
+
+// RuleNotifyTopicPrefix 规则输出中的 topic 必须使用的前缀，防止规则向系统事件等其他主题发布消息
+const RuleNotifyTopicPrefix = "rule.notify."
+
+// ErrNotifyTopicDenied 规则输出的主题不在允许的范围内
+var ErrNotifyTopicDenied = errors.New("rule notify topic is not allowed")
+
+// RuleNotifyEvent 规则通知事件数据
+type RuleNotifyEvent struct {
+	RuleID   string                 `json:"ruleId"`   // 规则ID
+	RuleCode string                 `json:"ruleCode"` // 规则编码
+	Scope    string                 `json:"scope"`    // 业务范围
+	ScopeID  string                 `json:"scopeId"`  // 业务ID
+	Trigger  string                 `json:"trigger"`  // 触发动作
+	TenantID string                 `json:"tenantId"` // 租户ID
+	Params   map[string]interface{} `json:"params"`   // 规则输出
+}
+
+// NewNotifyActionHandler 创建将通知发布到事件总线的动作处理器
+// 规则输出中的 topic 只能是 RuleNotifyTopicPrefix 开头的主题，未指定时发布到 RuleNotifyTopic
+func NewNotifyActionHandler(bus mqevent.IMQEventBus) service.ActionHandler {
+	return service.ActionHandlerFunc(func(ctx context.Context, action *model.RuleAction, rc *model.RuleContext) error {
+		topic := RuleNotifyTopic
+		if t, ok := action.Params["topic"].(string); ok && t != "" {
+			if !strings.HasPrefix(t, RuleNotifyTopicPrefix) || len(t) == len(RuleNotifyTopicPrefix) {
+				return fmt.Errorf("%w: %s", ErrNotifyTopicDenied, t)
+			}
+			topic = t
+		}
+		event := RuleNotifyEvent{
+			RuleID:   action.RuleID,
+			RuleCode: action.RuleCode,
+			Scope:    rc.Scope,
+			ScopeID:  rc.ScopeID,
+			Trigger:  rc.Trigger,
+			TenantID: rc.TenantID,
+			Params:   action.Params,
+		}
+		return mqevent.Publish(ctx, bus, topic, event, mqevent.WithTenantID(rc.TenantID))
+	})
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+)
+
+// topicBus 记录发布主题的事件总线
+type topicBus struct {
+	mqevent.IMQEventBus
+	topics []string
+}
+
+func (b *topicBus) Publish(ctx context.Context, event mqevent.Event) error {
+	b.topics = append(b.topics, event.GetType())
+	return nil
+}
+
+func TestNotifyActionTopic(t *testing.T) {
+	rule := &model.Rule{ID: "r1", Code: "notify"}
+	rc := model.NewRuleContext("order", "create", "before", "")
+	for _, c := range []struct {
+		topic string
+		want  string
+	}{
+		{"", RuleNotifyTopic},
+		{"rule.notify.order", "rule.notify.order"},
+		{"sys.user.update", ""},
+		{"rule.notify.", ""},
+		{"rule_engine.rule_notify.x", ""},
+	} {
+		bus := &topicBus{}
+		params := map[string]interface{}{}
+		if c.topic != "" {
+			params["topic"] = c.topic
+		}
+		err := NewNotifyActionHandler(bus).Handle(context.Background(), model.NewRuleAction(rule, ActionNotify, params), rc)
+		if c.want == "" {
+			if !errors.Is(err, ErrNotifyTopicDenied) || len(bus.topics) != 0 {
+				t.Fatalf("%q: err = %v, published %v", c.topic, err, bus.topics)
+			}
+			continue
+		}
+		if err != nil || len(bus.topics) != 1 || bus.topics[0] != c.want {
+			t.Fatalf("%q: err = %v, published %v", c.topic, err, bus.topics)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/entity"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/repository"
+	"gorm.io/gorm"
+)
+
+// stepInsertBatchSize 执行步骤单条 INSERT 语句的最大行数
+const stepInsertBatchSize = 500
+
+// ruleExecutionLogRepository 规则执行日志数据访问层
+type ruleExecutionLogRepository struct {
+	*baserepo.BaseRepo[entity.RuleExecutionLog, string]
+}
+
+// NewRuleExecutionLogRepository 创建规则执行日志数据访问层
+func NewRuleExecutionLogRepository(data database.IDataBase) repository.IRuleExecutionLogRepository {
+	// 同步表
+	tables := []interface{}{
+		&entity.RuleExecutionLog{},
+		&entity.RuleExecutionStep{},
+	}
+	if err := data.AutoMigrate(tables...); err != nil {
+		hlog.Fatalf("sync rule execution log tables error: %v", err)
+	}
+	return &ruleExecutionLogRepository{
+		BaseRepo: baserepo.NewBaseRepo[entity.RuleExecutionLog, string](data),
+	}
+}
+
+// SaveLogs 在同一事务中保存执行日志和执行步骤
+func (r *ruleExecutionLogRepository) SaveLogs(ctx context.Context, logs []*entity.RuleExecutionLog, steps []*entity.RuleExecutionStep) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.InTx(ctx, func(ctx context.Context) error {
+		if err := r.BathAdd(ctx, logs...); err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			return nil
+		}
+		return r.Db(ctx).CreateInBatches(steps, stepInsertBatchSize).Error
+	})
+}
+
+// FindOne 根据ID查询，不存在时返回 nil
+func (r *ruleExecutionLogRepository) FindOne(ctx context.Context, id string) (*entity.RuleExecutionLog, error) {
+	var log entity.RuleExecutionLog
+	err := r.Db(ctx).Model(&entity.RuleExecutionLog{}).Where("id = ?", id).First(&log).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &log, nil
+}
+
+// FindSteps 查询日志的执行步骤，按执行顺序排序
+func (r *ruleExecutionLogRepository) FindSteps(ctx context.Context, logID string) ([]*entity.RuleExecutionStep, error) {
+	steps := make([]*entity.RuleExecutionStep, 0)
+	err := r.Db(ctx).Model(&entity.RuleExecutionStep{}).
+		Where("log_id = ?", logID).
+		Order("seq ASC").
+		Find(&steps).Error
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// Stats 按列分组统计，fromSteps 为 true 时统计执行步骤表，否则统计执行日志表
+func (r *ruleExecutionLogRepository) Stats(ctx context.Context, column string, fromSteps bool, query *db_query.QueryBuilder) ([]*entity.RuleExecutionStats, error) {
+	var (
+		dbQuery   *gorm.DB
+		successOn = "valid"
+	)
+	if fromSteps {
+		dbQuery = r.Db(ctx).Model(&entity.RuleExecutionStep{})
+		successOn = "success"
+	} else {
+		dbQuery = r.Db(ctx).Model(&entity.RuleExecutionLog{})
+	}
+	if query != nil {
+		if where, values := query.BuildWhere(); where != "" {
+			dbQuery = dbQuery.Where(where, values...)
+		}
+	}
+
+	stats := make([]*entity.RuleExecutionStats, 0)
+	err := dbQuery.
+		Select(fmt.Sprintf("%s AS group_key, SUM(weight) AS total, "+
+			"SUM(CASE WHEN %s = ? THEN weight ELSE 0 END) AS success, "+
+			"SUM(execute_time * weight) AS total_time", column, successOn), true).
+		Group(column).
+		Order("total DESC").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// DeleteBefore 删除执行时间早于 before 的日志及其步骤
+func (r *ruleExecutionLogRepository) DeleteBefore(ctx context.Context, before int64) (int64, error) {
+	var deleted int64
+	err := r.InTx(ctx, func(ctx context.Context) error {
+		err := r.Db(ctx).Unscoped().
+			Where("log_id IN (?)", r.Db(ctx).Model(&entity.RuleExecutionLog{}).Select("id").Where("execute_at < ?", before)).
+			Delete(&entity.RuleExecutionStep{}).Error
+		if err != nil {
+			return err
+		}
+		res := r.Db(ctx).Unscoped().Where("execute_at < ?", before).Delete(&entity.RuleExecutionLog{})
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
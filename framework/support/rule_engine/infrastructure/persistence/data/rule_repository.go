@@ -9,6 +9,7 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/entity"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/repository"
+	"gorm.io/gorm"
 )
 
 // ruleRepository 规则数据访问层
@@ -164,20 +165,25 @@ func (r *ruleRepository) Count(ctx context.Context, query *db_query.QueryBuilder
 	return count, nil
 }
 
-// UpdateExecuteStats 更新执行统计
-func (r *ruleRepository) UpdateExecuteStats(ctx context.Context, ruleID string, success bool) error {
-	rule, err := r.FindById(ctx, ruleID)
-	if err != nil {
-		return err
-	}
-
-	rule.ExecuteCount++
-	if success {
-		rule.SuccessCount++
-	}
-	rule.LastExecuteAt = utils.GetDateUnix()
+// IncrementExecuteStats 原子累加执行统计，并发执行和多实例部署时不会丢失计数
+func (r *ruleRepository) IncrementExecuteStats(ctx context.Context, ruleID string, executed, succeeded, lastExecuteAt int64) error {
+	return r.Db(ctx).Model(&entity.Rule{}).Where("id = ?", ruleID).
+		UpdateColumns(map[string]interface{}{
+			"execute_count":   gorm.Expr("execute_count + ?", executed),
+			"success_count":   gorm.Expr("success_count + ?", succeeded),
+			"last_execute_at": lastExecuteAt,
+		}).Error
+}
 
-	return r.EditById(ctx, rule)
+// UpdateContent 更新规则的线上内容和版本号
+// 只更新版本化的列，空值同样写入，用于发布和回滚
+func (r *ruleRepository) UpdateContent(ctx context.Context, rule *entity.Rule) error {
+	rule.UpdatedAt = utils.GetDateUnix()
+	return r.Db(ctx).Model(&entity.Rule{}).Where("id = ?", rule.ID).
+		Select("category_id", "template_id", "type", "version", "published_version", "scope", "scope_id", "triggers",
+			"execution_timing", "conditions", "lua_script", "formula", "formula_vars", "decision_table", "db_policy", "action",
+			"priority", "sorting", "updated_at").
+		Updates(rule).Error
 }
 
 // FindByBusinessType 根据业务类型查询规则列表
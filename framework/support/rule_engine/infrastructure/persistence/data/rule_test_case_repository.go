@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/entity"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/repository"
+	"gorm.io/gorm"
+)
+
+// ruleTestCaseRepository 规则测试用例数据访问层
+type ruleTestCaseRepository struct {
+	*baserepo.BaseRepo[entity.RuleTestCase, string]
+}
+
+// NewRuleTestCaseRepository 创建规则测试用例数据访问层
+func NewRuleTestCaseRepository(data database.IDataBase) repository.IRuleTestCaseRepository {
+	// 同步表
+	tables := []interface{}{
+		&entity.RuleTestCase{},
+	}
+	if err := data.AutoMigrate(tables...); err != nil {
+		hlog.Fatalf("sync rule test case tables error: %v", err)
+	}
+	return &ruleTestCaseRepository{
+		BaseRepo: baserepo.NewBaseRepo[entity.RuleTestCase, string](data),
+	}
+}
+
+// FindOne 根据ID查询，不存在时返回 nil
+func (r *ruleTestCaseRepository) FindOne(ctx context.Context, id string) (*entity.RuleTestCase, error) {
+	var c entity.RuleTestCase
+	err := r.Db(ctx).Model(&entity.RuleTestCase{}).Where("id = ?", id).First(&c).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindByRuleID 查询规则的测试用例，按创建时间排序
+func (r *ruleTestCaseRepository) FindByRuleID(ctx context.Context, ruleID string) ([]*entity.RuleTestCase, error) {
+	cases := make([]*entity.RuleTestCase, 0)
+	err := r.Db(ctx).Model(&entity.RuleTestCase{}).
+		Where("rule_id = ?", ruleID).
+		Order("created_at ASC").
+		Find(&cases).Error
+	if err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// UpdateCase 更新测试用例，空值同样写入
+func (r *ruleTestCaseRepository) UpdateCase(ctx context.Context, c *entity.RuleTestCase) error {
+	c.UpdatedAt = utils.GetDateUnix()
+	return r.Db(ctx).Model(&entity.RuleTestCase{}).Where("id = ?", c.ID).
+		Select("name", "description", "scope_id", "input", "expect_valid", "expect_action", "expect_outputs",
+			"enabled", "updated_at").
+		Updates(c).Error
+}
+
+// DeleteByRuleID 删除规则的所有测试用例
+func (r *ruleTestCaseRepository) DeleteByRuleID(ctx context.Context, ruleID string) error {
+	return r.Db(ctx).Where("rule_id = ?", ruleID).Delete(&entity.RuleTestCase{}).Error
+}
@@ -0,0 +1,78 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/entity"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/repository"
+	"gorm.io/gorm"
+)
+
+// ruleVersionRepository 规则版本数据访问层
+type ruleVersionRepository struct {
+	*baserepo.BaseRepo[entity.RuleVersion, string]
+}
+
+// NewRuleVersionRepository 创建规则版本数据访问层
+func NewRuleVersionRepository(data database.IDataBase) repository.IRuleVersionRepository {
+	// 同步表
+	tables := []interface{}{
+		&entity.RuleVersion{},
+	}
+	if err := data.AutoMigrate(tables...); err != nil {
+		hlog.Fatalf("sync rule version tables error: %v", err)
+	}
+	return &ruleVersionRepository{
+		BaseRepo: baserepo.NewBaseRepo[entity.RuleVersion, string](data),
+	}
+}
+
+// FindByRuleVersion 根据规则ID和版本号查询，不存在时返回 nil
+func (r *ruleVersionRepository) FindByRuleVersion(ctx context.Context, ruleID string, version int32) (*entity.RuleVersion, error) {
+	var v entity.RuleVersion
+	err := r.Db(ctx).Model(&entity.RuleVersion{}).Where("rule_id = ? AND version = ?", ruleID, version).First(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindPublished 查询规则的已发布版本，按版本号倒序
+func (r *ruleVersionRepository) FindPublished(ctx context.Context, ruleID string) ([]*entity.RuleVersion, error) {
+	versions := make([]*entity.RuleVersion, 0)
+	err := r.Db(ctx).Model(&entity.RuleVersion{}).
+		Where("rule_id = ? AND version > 0", ruleID).
+		Order("version DESC").
+		Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// MaxVersion 规则最大的已发布版本号
+func (r *ruleVersionRepository) MaxVersion(ctx context.Context, ruleID string) (int32, error) {
+	var max int32
+	err := r.Db(ctx).Model(&entity.RuleVersion{}).
+		Where("rule_id = ?", ruleID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&max).Error
+	return max, err
+}
+
+// DeleteByRuleVersion 删除规则的指定版本，版本为草稿时即删除草稿
+func (r *ruleVersionRepository) DeleteByRuleVersion(ctx context.Context, ruleID string, version int32) error {
+	return r.Db(ctx).Unscoped().Where("rule_id = ? AND version = ?", ruleID, version).Delete(&entity.RuleVersion{}).Error
+}
+
+// DeleteByRuleID 删除规则的所有版本
+func (r *ruleVersionRepository) DeleteByRuleID(ctx context.Context, ruleID string) error {
+	return r.Db(ctx).Unscoped().Where("rule_id = ?", ruleID).Delete(&entity.RuleVersion{}).Error
+}
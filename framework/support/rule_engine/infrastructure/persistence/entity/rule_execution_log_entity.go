@@ -0,0 +1,72 @@
+package entity
+
+import "github.com/flare-admin/flare-server-go/framework/pkg/database"
+
+// RuleExecutionLog 规则执行日志实体
+type RuleExecutionLog struct {
+	database.BaseModel
+	ID              string  `gorm:"primarykey"`
+	Scope           string  `gorm:"size:50;index:idx_rule_exec_log_scope;comment:作用域"`
+	Trigger         string  `gorm:"column:trigger_action;size:50;comment:触发动作"`
+	ScopeID         string  `gorm:"size:100;comment:作用域ID"`
+	ExecutionTiming string  `gorm:"size:10;comment:执行时机"`
+	RuleCode        string  `gorm:"size:100;comment:按编码执行时的规则编码"`
+	Valid           bool    `gorm:"not null;default:false;comment:是否通过"`
+	Action          string  `gorm:"size:50;comment:执行动作"`
+	Error           string  `gorm:"type:text;comment:错误信息"`
+	ErrorReason     string  `gorm:"size:100;comment:错误原因"`
+	ExecuteTime     int64   `gorm:"not null;default:0;comment:执行时间(毫秒)"`
+	StepCount       int32   `gorm:"not null;default:0;comment:执行的规则数量"`
+	ExecuteAt       int64   `gorm:"not null;default:0;index;index:idx_rule_exec_log_scope;comment:执行时间戳"`
+	Weight          float64 `gorm:"not null;default:1;comment:统计权重(采样率的倒数)"`
+	TenantID        string  `gorm:"size:50;comment:租户ID"`
+}
+
+func (RuleExecutionLog) TableName() string {
+	return "rule_execution_logs"
+}
+
+// GetPrimaryKey 获取主键
+func (RuleExecutionLog) GetPrimaryKey() string {
+	return "id"
+}
+
+// RuleExecutionStep 规则执行日志步骤实体
+type RuleExecutionStep struct {
+	database.BaseModel
+	ID          string  `gorm:"primarykey"`
+	LogID       string  `gorm:"size:64;not null;index;comment:日志ID"`
+	Seq         int32   `gorm:"not null;default:0;comment:执行顺序"`
+	RuleID      string  `gorm:"size:64;comment:规则ID"`
+	RuleCode    string  `gorm:"size:100;index:idx_rule_exec_step_code;comment:规则编码"`
+	RuleName    string  `gorm:"size:100;comment:规则名称"`
+	RuleVersion int32   `gorm:"not null;default:0;comment:执行的版本号"`
+	Scope       string  `gorm:"size:50;comment:作用域"`
+	Trigger     string  `gorm:"column:trigger_action;size:50;comment:触发动作"`
+	Input       string  `gorm:"type:text;comment:输入数据(JSON格式)"`
+	Output      string  `gorm:"type:text;comment:输出数据(JSON格式)"`
+	Success     bool    `gorm:"not null;default:false;comment:是否执行成功"`
+	Action      string  `gorm:"size:50;comment:执行动作"`
+	Error       string  `gorm:"type:text;comment:错误信息"`
+	ExecuteTime int64   `gorm:"not null;default:0;comment:执行时间(毫秒)"`
+	ExecuteAt   int64   `gorm:"not null;default:0;index;index:idx_rule_exec_step_code;comment:执行时间戳"`
+	Weight      float64 `gorm:"not null;default:1;comment:统计权重(采样率的倒数)"`
+	TenantID    string  `gorm:"size:50;comment:租户ID"`
+}
+
+func (RuleExecutionStep) TableName() string {
+	return "rule_execution_steps"
+}
+
+// GetPrimaryKey 获取主键
+func (RuleExecutionStep) GetPrimaryKey() string {
+	return "id"
+}
+
+// RuleExecutionStats 执行统计查询结果
+type RuleExecutionStats struct {
+	GroupKey  string  // 分组值
+	Total     float64 // 按权重累加的执行次数
+	Success   float64 // 按权重累加的成功次数
+	TotalTime float64 // 按权重累加的执行时间(毫秒)
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "github.com/flare-admin/flare-server-go/framework/pkg/database"
+
+// RuleTestCase 规则测试用例实体
+type RuleTestCase struct {
+	database.BaseModel
+	ID            string `gorm:"primarykey"`
+	RuleID        string `gorm:"size:64;not null;index;comment:规则ID"`
+	Name          string `gorm:"size:100;not null;comment:用例名称"`
+	Description   string `gorm:"size:500;comment:用例描述"`
+	ScopeID       string `gorm:"size:100;comment:执行时的作用域ID"`
+	Input         string `gorm:"type:json;comment:执行输入数据(JSON格式)"`
+	ExpectValid   *bool  `gorm:"comment:期望是否通过，为空时不校验"`
+	ExpectAction  string `gorm:"size:50;comment:期望动作，为空时不校验"`
+	ExpectOutputs string `gorm:"type:json;comment:期望输出变量(JSON格式)"`
+	Enabled       bool   `gorm:"not null;default:false;comment:是否启用"`
+	TenantID      string `gorm:"size:50;comment:租户ID"`
+}
+
+func (RuleTestCase) TableName() string {
+	return "rule_test_cases"
+}
+
+// GetPrimaryKey 获取主键
+func (RuleTestCase) GetPrimaryKey() string {
+	return "id"
+}
@@ -0,0 +1,41 @@
+package entity
+
+import "github.com/flare-admin/flare-server-go/framework/pkg/database"
+
+// RuleVersion 规则版本实体
+type RuleVersion struct {
+	database.BaseModel
+	ID              string `gorm:"primarykey"`
+	RuleID          string `gorm:"size:64;not null;uniqueIndex:idx_rule_version;comment:规则ID"`
+	Version         int32  `gorm:"not null;uniqueIndex:idx_rule_version;comment:版本号，草稿为0"`
+	Status          string `gorm:"size:20;not null;comment:状态：draft(草稿) published(已发布)"`
+	Comment         string `gorm:"size:500;comment:版本说明"`
+	RollbackFrom    int32  `gorm:"not null;default:0;comment:回滚来源版本号"`
+	CategoryID      string `gorm:"comment:分类ID"`
+	TemplateID      string `gorm:"comment:模板ID"`
+	Type            string `gorm:"size:50;not null;comment:规则类型"`
+	Triggers        string `gorm:"size:200;comment:触发动作列表(逗号分隔)"`
+	Scope           string `gorm:"size:50;comment:作用域"`
+	ScopeID         string `gorm:"size:100;comment:作用域ID"`
+	ExecutionTiming string `gorm:"size:10;comment:执行时机"`
+	Conditions      string `gorm:"type:json;comment:条件表达式(JSON格式)"`
+	LuaScript       string `gorm:"type:text;comment:Lua脚本代码"`
+	Formula         string `gorm:"type:text;comment:计算公式"`
+	FormulaVars     string `gorm:"type:json;comment:公式变量映射(JSON格式)"`
+	DecisionTable   string `gorm:"type:text;comment:决策表(JSON格式)"`
+	DBPolicy        string `gorm:"type:text;comment:数据库访问策略(JSON格式)"`
+	Action          string `gorm:"size:50;comment:规则动作"`
+	Priority        int32  `gorm:"not null;default:0;comment:优先级"`
+	Sorting         int32  `gorm:"not null;default:0;comment:排序权重"`
+	PublishedAt     int64  `gorm:"not null;default:0;comment:发布时间"`
+	TenantID        string `gorm:"size:50;comment:租户ID"`
+}
+
+func (RuleVersion) TableName() string {
+	return "rule_versions"
+}
+
+// GetPrimaryKey 获取主键
+func (RuleVersion) GetPrimaryKey() string {
+	return "id"
+}
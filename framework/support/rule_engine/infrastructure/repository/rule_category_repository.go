@@ -42,18 +42,20 @@ func NewRuleCategoryRepository(repo IRuleCategoryRepository) repository.ICategor
 func (r *RuleCategoryRepository) Create(ctx context.Context, category *model.RuleCategory) error {
 	// 转换为数据库实体
 	entity := &entity.RuleCategory{
-		Code:         category.Code,
-		Name:         category.Name,
-		Description:  category.Description,
-		Type:         category.Type,
-		ParentID:     category.ParentID,
-		Level:        category.Level,
-		Path:         category.Path,
-		Sorting:      category.Sorting,
-		Status:       int(category.Status),
-		IsLeaf:       category.IsLeaf,
-		BusinessType: category.BusinessType,
-		TenantID:     category.TenantID,
+		Code:              category.Code,
+		Name:              category.Name,
+		Description:       category.Description,
+		Type:              category.Type,
+		ParentID:          category.ParentID,
+		Level:             category.Level,
+		Path:              category.Path,
+		Sorting:           category.Sorting,
+		Status:            int(category.Status),
+		IsLeaf:            category.IsLeaf,
+		BusinessType:      category.BusinessType,
+		DBPolicy:          category.DBPolicy,
+		ExecutionStrategy: category.ExecutionStrategy,
+		TenantID:          category.TenantID,
 	}
 	_, err := r.repo.Add(ctx, entity)
 	if err != nil {
@@ -66,19 +68,21 @@ func (r *RuleCategoryRepository) Create(ctx context.Context, category *model.Rul
 func (r *RuleCategoryRepository) Update(ctx context.Context, category *model.RuleCategory) error {
 	// 转换为数据库实体
 	entity := &entity.RuleCategory{
-		ID:           category.ID,
-		Code:         category.Code,
-		Name:         category.Name,
-		Description:  category.Description,
-		Type:         category.Type,
-		ParentID:     category.ParentID,
-		Level:        category.Level,
-		Path:         category.Path,
-		Sorting:      category.Sorting,
-		Status:       int(category.Status),
-		IsLeaf:       category.IsLeaf,
-		BusinessType: category.BusinessType,
-		TenantID:     category.TenantID,
+		ID:                category.ID,
+		Code:              category.Code,
+		Name:              category.Name,
+		Description:       category.Description,
+		Type:              category.Type,
+		ParentID:          category.ParentID,
+		Level:             category.Level,
+		Path:              category.Path,
+		Sorting:           category.Sorting,
+		Status:            int(category.Status),
+		IsLeaf:            category.IsLeaf,
+		BusinessType:      category.BusinessType,
+		DBPolicy:          category.DBPolicy,
+		ExecutionStrategy: category.ExecutionStrategy,
+		TenantID:          category.TenantID,
 	}
 	return r.repo.EditById(ctx, entity)
 }
@@ -267,21 +271,23 @@ func (r *RuleCategoryRepository) toModel(entity *entity.RuleCategory) *model.Rul
 	}
 
 	return &model.RuleCategory{
-		ID:           entity.ID,
-		Code:         entity.Code,
-		Name:         entity.Name,
-		Description:  entity.Description,
-		Type:         entity.Type,
-		ParentID:     entity.ParentID,
-		Level:        entity.Level,
-		Path:         entity.Path,
-		Sorting:      entity.Sorting,
-		Status:       int32(entity.Status),
-		IsLeaf:       entity.IsLeaf,
-		BusinessType: entity.BusinessType,
-		CreatedAt:    entity.CreatedAt,
-		UpdatedAt:    entity.UpdatedAt,
-		TenantID:     entity.TenantID,
+		ID:                entity.ID,
+		Code:              entity.Code,
+		Name:              entity.Name,
+		Description:       entity.Description,
+		Type:              entity.Type,
+		ParentID:          entity.ParentID,
+		Level:             entity.Level,
+		Path:              entity.Path,
+		Sorting:           entity.Sorting,
+		Status:            int32(entity.Status),
+		IsLeaf:            entity.IsLeaf,
+		BusinessType:      entity.BusinessType,
+		DBPolicy:          entity.DBPolicy,
+		ExecutionStrategy: entity.ExecutionStrategy,
+		CreatedAt:         entity.CreatedAt,
+		UpdatedAt:         entity.UpdatedAt,
+		TenantID:          entity.TenantID,
 	}
 }
 
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/entity"
+)
+
+// IRuleExecutionLogRepository 规则执行日志数据访问接口
+type IRuleExecutionLogRepository interface {
+	baserepo.IBaseRepo[entity.RuleExecutionLog, string]
+	SaveLogs(ctx context.Context, logs []*entity.RuleExecutionLog, steps []*entity.RuleExecutionStep) error
+	FindOne(ctx context.Context, id string) (*entity.RuleExecutionLog, error)
+	FindSteps(ctx context.Context, logID string) ([]*entity.RuleExecutionStep, error)
+	Stats(ctx context.Context, column string, fromSteps bool, query *db_query.QueryBuilder) ([]*entity.RuleExecutionStats, error)
+	DeleteBefore(ctx context.Context, before int64) (int64, error)
+}
+
+// RuleExecutionLogRepository 规则执行日志仓储实现
+type RuleExecutionLogRepository struct {
+	repo IRuleExecutionLogRepository
+}
+
+// NewRuleExecutionLogRepository 创建规则执行日志仓储
+func NewRuleExecutionLogRepository(repo IRuleExecutionLogRepository) repository.IRuleExecutionLogRepository {
+	return &RuleExecutionLogRepository{repo: repo}
+}
+
+// SaveBatch 批量保存执行日志及其步骤
+func (r *RuleExecutionLogRepository) SaveBatch(ctx context.Context, logs []*model.RuleExecutionLog) error {
+	logEntities := make([]*entity.RuleExecutionLog, 0, len(logs))
+	stepEntities := make([]*entity.RuleExecutionStep, 0, len(logs))
+	for _, log := range logs {
+		if log.ID == "" {
+			log.ID = r.repo.GenStringId()
+		}
+		logEntities = append(logEntities, r.toEntity(log))
+		for _, step := range log.Steps {
+			step.LogID = log.ID
+			if step.ID == "" {
+				step.ID = r.repo.GenStringId()
+			}
+			stepEntities = append(stepEntities, r.toStepEntity(step))
+		}
+	}
+	return r.repo.SaveLogs(ctx, logEntities, stepEntities)
+}
+
+// Find 根据查询条件查找执行日志
+func (r *RuleExecutionLogRepository) Find(ctx context.Context, query *db_query.QueryBuilder) ([]*model.RuleExecutionLog, error) {
+	entities, err := r.repo.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]*model.RuleExecutionLog, 0, len(entities))
+	for _, e := range entities {
+		logs = append(logs, r.toModel(e))
+	}
+	return logs, nil
+}
+
+// Count 根据查询条件统计执行日志数量
+func (r *RuleExecutionLogRepository) Count(ctx context.Context, query *db_query.QueryBuilder) (int64, error) {
+	return r.repo.Count(ctx, query)
+}
+
+// FindByID 根据ID查找执行日志及其步骤，不存在时返回 nil
+func (r *RuleExecutionLogRepository) FindByID(ctx context.Context, id string) (*model.RuleExecutionLog, error) {
+	e, err := r.repo.FindOne(ctx, id)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	steps, err := r.repo.FindSteps(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	log := r.toModel(e)
+	for _, step := range steps {
+		log.Steps = append(log.Steps, r.toStepModel(step))
+	}
+	return log, nil
+}
+
+// Stats 按维度统计执行次数和失败率
+func (r *RuleExecutionLogRepository) Stats(ctx context.Context, groupBy string, query *db_query.QueryBuilder) ([]*model.RuleExecutionStats, error) {
+	var (
+		column    string
+		fromSteps bool
+	)
+	switch groupBy {
+	case model.ExecutionStatsByRule:
+		column, fromSteps = "rule_code", true
+	case model.ExecutionStatsByScope:
+		column = "scope"
+	case model.ExecutionStatsByTrigger:
+		column = "trigger_action"
+	default:
+		return nil, fmt.Errorf("unsupported stats group: %s", groupBy)
+	}
+
+	rows, err := r.repo.Stats(ctx, column, fromSteps, query)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*model.RuleExecutionStats, 0, len(rows))
+	for _, row := range rows {
+		s := &model.RuleExecutionStats{
+			Key:     row.GroupKey,
+			Total:   row.Total,
+			Success: row.Success,
+		}
+		if row.Total > 0 {
+			s.AvgExecuteTime = row.TotalTime / row.Total
+		}
+		s.Calculate()
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// DeleteBefore 删除执行时间早于 before 的日志及其步骤
+func (r *RuleExecutionLogRepository) DeleteBefore(ctx context.Context, before int64) (int64, error) {
+	return r.repo.DeleteBefore(ctx, before)
+}
+
+// toEntity 将领域模型转换为实体
+func (r *RuleExecutionLogRepository) toEntity(log *model.RuleExecutionLog) *entity.RuleExecutionLog {
+	return &entity.RuleExecutionLog{
+		ID:              log.ID,
+		Scope:           log.Scope,
+		Trigger:         log.Trigger,
+		ScopeID:         log.ScopeID,
+		ExecutionTiming: log.ExecutionTiming,
+		RuleCode:        log.RuleCode,
+		Valid:           log.Valid,
+		Action:          log.Action,
+		Error:           log.Error,
+		ErrorReason:     log.ErrorReason,
+		ExecuteTime:     log.ExecuteTime,
+		StepCount:       log.StepCount,
+		ExecuteAt:       log.ExecuteAt,
+		Weight:          log.Weight,
+		TenantID:        log.TenantID,
+	}
+}
+
+// toModel 将实体转换为领域模型
+func (r *RuleExecutionLogRepository) toModel(e *entity.RuleExecutionLog) *model.RuleExecutionLog {
+	return &model.RuleExecutionLog{
+		ID:              e.ID,
+		Scope:           e.Scope,
+		Trigger:         e.Trigger,
+		ScopeID:         e.ScopeID,
+		ExecutionTiming: e.ExecutionTiming,
+		RuleCode:        e.RuleCode,
+		Valid:           e.Valid,
+		Action:          e.Action,
+		Error:           e.Error,
+		ErrorReason:     e.ErrorReason,
+		ExecuteTime:     e.ExecuteTime,
+		StepCount:       e.StepCount,
+		ExecuteAt:       e.ExecuteAt,
+		Weight:          e.Weight,
+		TenantID:        e.TenantID,
+	}
+}
+
+// toStepEntity 将执行步骤转换为实体
+func (r *RuleExecutionLogRepository) toStepEntity(step *model.RuleExecutionLogStep) *entity.RuleExecutionStep {
+	return &entity.RuleExecutionStep{
+		ID:          step.ID,
+		LogID:       step.LogID,
+		Seq:         step.Seq,
+		RuleID:      step.RuleID,
+		RuleCode:    step.RuleCode,
+		RuleName:    step.RuleName,
+		RuleVersion: step.RuleVersion,
+		Scope:       step.Scope,
+		Trigger:     step.Trigger,
+		Input:       step.Input,
+		Output:      step.Output,
+		Success:     step.Success,
+		Action:      step.Action,
+		Error:       step.Error,
+		ExecuteTime: step.ExecuteTime,
+		ExecuteAt:   step.ExecuteAt,
+		Weight:      step.Weight,
+		TenantID:    step.TenantID,
+	}
+}
+
+// toStepModel 将执行步骤实体转换为领域模型
+func (r *RuleExecutionLogRepository) toStepModel(e *entity.RuleExecutionStep) *model.RuleExecutionLogStep {
+	return &model.RuleExecutionLogStep{
+		ID:          e.ID,
+		LogID:       e.LogID,
+		Seq:         e.Seq,
+		RuleID:      e.RuleID,
+		RuleCode:    e.RuleCode,
+		RuleName:    e.RuleName,
+		RuleVersion: e.RuleVersion,
+		Scope:       e.Scope,
+		Trigger:     e.Trigger,
+		Input:       e.Input,
+		Output:      e.Output,
+		Success:     e.Success,
+		Action:      e.Action,
+		Error:       e.Error,
+		ExecuteTime: e.ExecuteTime,
+		ExecuteAt:   e.ExecuteAt,
+		Weight:      e.Weight,
+		TenantID:    e.TenantID,
+	}
+}
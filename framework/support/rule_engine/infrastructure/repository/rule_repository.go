@@ -25,7 +25,8 @@ type IRuleRepository interface {
 	FindByBusinessType(ctx context.Context, businessType string) ([]*entity.Rule, error)
 	ExistsByCode(ctx context.Context, code string) (bool, error)
 	FindAll(ctx context.Context) ([]*entity.Rule, error)
-	UpdateExecuteStats(ctx context.Context, ruleID string, success bool) error
+	IncrementExecuteStats(ctx context.Context, ruleID string, executed, succeeded, lastExecuteAt int64) error
+	UpdateContent(ctx context.Context, rule *entity.Rule) error
 	Find(ctx context.Context, query *db_query.QueryBuilder) ([]*entity.Rule, error)
 	Count(ctx context.Context, query *db_query.QueryBuilder) (int64, error)
 }
@@ -171,37 +172,41 @@ func (r *RuleRepository) Count(ctx context.Context, query *db_query.QueryBuilder
 	return r.repo.Count(ctx, query)
 }
 
-// RecordExecution 记录执行统计
-func (r *RuleRepository) RecordExecution(ctx context.Context, ruleID string, success bool) error {
-	return r.repo.UpdateExecuteStats(ctx, ruleID, success)
+// IncrementExecuteStats 累加规则的执行次数和成功次数
+func (r *RuleRepository) IncrementExecuteStats(ctx context.Context, delta *model.RuleStatsDelta) error {
+	return r.repo.IncrementExecuteStats(ctx, delta.RuleID, delta.Executed, delta.Succeeded, delta.LastExecuteAt)
 }
 
 // toEntity 将领域模型转换为实体
 func (r *RuleRepository) toEntity(rule *model.Rule) *entity.Rule {
 	return &entity.Rule{
-		ID:            rule.ID,
-		Code:          rule.Code,
-		Name:          rule.Name,
-		Description:   rule.Description,
-		CategoryID:    rule.CategoryID,
-		TemplateID:    rule.TemplateID,
-		Type:          rule.Type,
-		Version:       rule.Version,
-		Status:        int(rule.Status),
-		Triggers:      strings.Join(rule.Triggers, ","),
-		Scope:         rule.Scope,
-		ScopeID:       rule.ScopeID,
-		Conditions:    rule.Conditions,
-		LuaScript:     rule.LuaScript,
-		Formula:       rule.Formula,
-		FormulaVars:   rule.FormulaVars,
-		Action:        rule.Action,
-		Priority:      rule.Priority,
-		Sorting:       rule.Sorting,
-		ExecuteCount:  rule.ExecuteCount,
-		SuccessCount:  rule.SuccessCount,
-		LastExecuteAt: rule.LastExecuteAt,
-		TenantID:      rule.TenantID,
+		ID:               rule.ID,
+		Code:             rule.Code,
+		Name:             rule.Name,
+		Description:      rule.Description,
+		CategoryID:       rule.CategoryID,
+		TemplateID:       rule.TemplateID,
+		Type:             rule.Type,
+		Version:          rule.Version,
+		PublishedVersion: rule.PublishedVersion,
+		Status:           int(rule.Status),
+		Triggers:         strings.Join(rule.Triggers, ","),
+		Scope:            rule.Scope,
+		ScopeID:          rule.ScopeID,
+		ExecutionTiming:  rule.ExecutionTiming,
+		Conditions:       rule.Conditions,
+		LuaScript:        rule.LuaScript,
+		Formula:          rule.Formula,
+		FormulaVars:      rule.FormulaVars,
+		DecisionTable:    rule.DecisionTable,
+		DBPolicy:         rule.DBPolicy,
+		Action:           rule.Action,
+		Priority:         rule.Priority,
+		Sorting:          rule.Sorting,
+		ExecuteCount:     rule.ExecuteCount,
+		SuccessCount:     rule.SuccessCount,
+		LastExecuteAt:    rule.LastExecuteAt,
+		TenantID:         rule.TenantID,
 	}
 }
 
@@ -215,32 +220,35 @@ func (r *RuleRepository) toModel(entity *entity.Rule) *model.Rule {
 		triggers = strings.Split(entity.Triggers, ",")
 	}
 	return &model.Rule{
-		ID:              entity.ID,
-		Code:            entity.Code,
-		Name:            entity.Name,
-		Description:     entity.Description,
-		CategoryID:      entity.CategoryID,
-		TemplateID:      entity.TemplateID,
-		Type:            entity.Type,
-		Version:         entity.Version,
-		Status:          int32(entity.Status),
-		Triggers:        triggers,
-		Scope:           entity.Scope,
-		ScopeID:         entity.ScopeID,
-		ExecutionTiming: entity.ExecutionTiming,
-		Conditions:      entity.Conditions,
-		LuaScript:       entity.LuaScript,
-		Formula:         entity.Formula,
-		FormulaVars:     entity.FormulaVars,
-		Action:          entity.Action,
-		Priority:        entity.Priority,
-		Sorting:         entity.Sorting,
-		ExecuteCount:    entity.ExecuteCount,
-		SuccessCount:    entity.SuccessCount,
-		LastExecuteAt:   entity.LastExecuteAt,
-		CreatedAt:       entity.CreatedAt,
-		UpdatedAt:       entity.UpdatedAt,
-		TenantID:        entity.TenantID,
+		ID:               entity.ID,
+		Code:             entity.Code,
+		Name:             entity.Name,
+		Description:      entity.Description,
+		CategoryID:       entity.CategoryID,
+		TemplateID:       entity.TemplateID,
+		Type:             entity.Type,
+		Version:          entity.Version,
+		PublishedVersion: entity.PublishedVersion,
+		Status:           int32(entity.Status),
+		Triggers:         triggers,
+		Scope:            entity.Scope,
+		ScopeID:          entity.ScopeID,
+		ExecutionTiming:  entity.ExecutionTiming,
+		Conditions:       entity.Conditions,
+		LuaScript:        entity.LuaScript,
+		Formula:          entity.Formula,
+		FormulaVars:      entity.FormulaVars,
+		DecisionTable:    entity.DecisionTable,
+		DBPolicy:         entity.DBPolicy,
+		Action:           entity.Action,
+		Priority:         entity.Priority,
+		Sorting:          entity.Sorting,
+		ExecuteCount:     entity.ExecuteCount,
+		SuccessCount:     entity.SuccessCount,
+		LastExecuteAt:    entity.LastExecuteAt,
+		CreatedAt:        entity.CreatedAt,
+		UpdatedAt:        entity.UpdatedAt,
+		TenantID:         entity.TenantID,
 	}
 }
 
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/entity"
+)
+
+// IRuleTestCaseRepository 规则测试用例数据访问接口
+type IRuleTestCaseRepository interface {
+	baserepo.IBaseRepo[entity.RuleTestCase, string]
+	FindOne(ctx context.Context, id string) (*entity.RuleTestCase, error)
+	FindByRuleID(ctx context.Context, ruleID string) ([]*entity.RuleTestCase, error)
+	UpdateCase(ctx context.Context, c *entity.RuleTestCase) error
+	DeleteByRuleID(ctx context.Context, ruleID string) error
+}
+
+// RuleTestCaseRepository 规则测试用例仓储实现
+type RuleTestCaseRepository struct {
+	repo IRuleTestCaseRepository
+}
+
+// NewRuleTestCaseRepository 创建规则测试用例仓储
+func NewRuleTestCaseRepository(repo IRuleTestCaseRepository) repository.IRuleTestCaseRepository {
+	return &RuleTestCaseRepository{repo: repo}
+}
+
+// Create 创建测试用例
+func (r *RuleTestCaseRepository) Create(ctx context.Context, testCase *model.RuleTestCase) error {
+	e, err := r.toEntity(testCase)
+	if err != nil {
+		return err
+	}
+	e, err = r.repo.Add(ctx, e)
+	if err != nil {
+		return err
+	}
+	testCase.ID = e.ID
+	return nil
+}
+
+// Update 更新测试用例
+func (r *RuleTestCaseRepository) Update(ctx context.Context, testCase *model.RuleTestCase) error {
+	e, err := r.toEntity(testCase)
+	if err != nil {
+		return err
+	}
+	return r.repo.UpdateCase(ctx, e)
+}
+
+// Delete 删除测试用例
+func (r *RuleTestCaseRepository) Delete(ctx context.Context, id string) error {
+	return r.repo.DelById(ctx, id)
+}
+
+// FindByID 根据ID查找测试用例，不存在时返回 nil
+func (r *RuleTestCaseRepository) FindByID(ctx context.Context, id string) (*model.RuleTestCase, error) {
+	e, err := r.repo.FindOne(ctx, id)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	return r.toModel(e)
+}
+
+// FindByRuleID 查找规则的所有测试用例
+func (r *RuleTestCaseRepository) FindByRuleID(ctx context.Context, ruleID string) ([]*model.RuleTestCase, error) {
+	entities, err := r.repo.FindByRuleID(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	cases := make([]*model.RuleTestCase, 0, len(entities))
+	for _, e := range entities {
+		c, err := r.toModel(e)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// DeleteByRuleID 删除规则的所有测试用例
+func (r *RuleTestCaseRepository) DeleteByRuleID(ctx context.Context, ruleID string) error {
+	return r.repo.DeleteByRuleID(ctx, ruleID)
+}
+
+// toEntity 将领域模型转换为实体
+func (r *RuleTestCaseRepository) toEntity(c *model.RuleTestCase) (*entity.RuleTestCase, error) {
+	input, err := json.Marshal(c.Input)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := json.Marshal(c.ExpectOutputs)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.RuleTestCase{
+		ID:            c.ID,
+		RuleID:        c.RuleID,
+		Name:          c.Name,
+		Description:   c.Description,
+		ScopeID:       c.ScopeID,
+		Input:         string(input),
+		ExpectValid:   c.ExpectValid,
+		ExpectAction:  c.ExpectAction,
+		ExpectOutputs: string(outputs),
+		Enabled:       c.Enabled,
+		TenantID:      c.TenantID,
+	}, nil
+}
+
+// toModel 将实体转换为领域模型
+func (r *RuleTestCaseRepository) toModel(e *entity.RuleTestCase) (*model.RuleTestCase, error) {
+	c := &model.RuleTestCase{
+		ID:           e.ID,
+		RuleID:       e.RuleID,
+		Name:         e.Name,
+		Description:  e.Description,
+		ScopeID:      e.ScopeID,
+		ExpectValid:  e.ExpectValid,
+		ExpectAction: e.ExpectAction,
+		Enabled:      e.Enabled,
+		CreatedAt:    e.CreatedAt,
+		UpdatedAt:    e.UpdatedAt,
+		TenantID:     e.TenantID,
+	}
+	if e.Input != "" {
+		if err := json.Unmarshal([]byte(e.Input), &c.Input); err != nil {
+			return nil, err
+		}
+	}
+	if e.ExpectOutputs != "" {
+		if err := json.Unmarshal([]byte(e.ExpectOutputs), &c.ExpectOutputs); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/model"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/entity"
+)
+
+// IRuleVersionRepository 规则版本数据访问接口
+type IRuleVersionRepository interface {
+	baserepo.IBaseRepo[entity.RuleVersion, string]
+	FindByRuleVersion(ctx context.Context, ruleID string, version int32) (*entity.RuleVersion, error)
+	FindPublished(ctx context.Context, ruleID string) ([]*entity.RuleVersion, error)
+	MaxVersion(ctx context.Context, ruleID string) (int32, error)
+	DeleteByRuleVersion(ctx context.Context, ruleID string, version int32) error
+	DeleteByRuleID(ctx context.Context, ruleID string) error
+}
+
+// RuleVersionRepository 规则版本仓储实现
+type RuleVersionRepository struct {
+	repo  IRuleVersionRepository
+	rules *RuleRepository
+}
+
+// NewRuleVersionRepository 创建规则版本仓储
+func NewRuleVersionRepository(repo IRuleVersionRepository, ruleRepo IRuleRepository) repository.IRuleVersionRepository {
+	return &RuleVersionRepository{
+		repo:  repo,
+		rules: &RuleRepository{repo: ruleRepo},
+	}
+}
+
+// SaveDraft 保存规则草稿，已存在时覆盖
+func (r *RuleVersionRepository) SaveDraft(ctx context.Context, draft *model.RuleVersion) error {
+	return r.repo.GetDb().InTx(ctx, func(ctx context.Context) error {
+		if err := r.repo.DeleteByRuleVersion(ctx, draft.RuleID, 0); err != nil {
+			return err
+		}
+		draft.ID = ""
+		e, err := r.repo.Add(ctx, r.toEntity(draft))
+		if err != nil {
+			return err
+		}
+		draft.ID = e.ID
+		return nil
+	})
+}
+
+// FindDraft 查找规则草稿，不存在时返回 nil
+func (r *RuleVersionRepository) FindDraft(ctx context.Context, ruleID string) (*model.RuleVersion, error) {
+	e, err := r.repo.FindByRuleVersion(ctx, ruleID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return r.toModel(e), nil
+}
+
+// DeleteDraft 删除规则草稿
+func (r *RuleVersionRepository) DeleteDraft(ctx context.Context, ruleID string) error {
+	return r.repo.DeleteByRuleVersion(ctx, ruleID, 0)
+}
+
+// FindVersion 根据版本号查找已发布的版本，不存在时返回 nil
+func (r *RuleVersionRepository) FindVersion(ctx context.Context, ruleID string, version int32) (*model.RuleVersion, error) {
+	if version <= 0 {
+		return nil, nil
+	}
+	e, err := r.repo.FindByRuleVersion(ctx, ruleID, version)
+	if err != nil {
+		return nil, err
+	}
+	return r.toModel(e), nil
+}
+
+// FindVersions 查找规则的所有已发布版本，按版本号倒序
+func (r *RuleVersionRepository) FindVersions(ctx context.Context, ruleID string) ([]*model.RuleVersion, error) {
+	entities, err := r.repo.FindPublished(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]*model.RuleVersion, len(entities))
+	for i, e := range entities {
+		versions[i] = r.toModel(e)
+	}
+	return versions, nil
+}
+
+// LatestVersion 规则最大的已发布版本号
+func (r *RuleVersionRepository) LatestVersion(ctx context.Context, ruleID string) (int32, error) {
+	return r.repo.MaxVersion(ctx, ruleID)
+}
+
+// Publish 在同一事务中保存已发布的版本并更新规则的线上内容，discardDraft 为 true 时同时删除草稿
+func (r *RuleVersionRepository) Publish(ctx context.Context, rule *model.Rule, versions []*model.RuleVersion, discardDraft bool) error {
+	return r.repo.GetDb().InTx(ctx, func(ctx context.Context) error {
+		for _, version := range versions {
+			version.ID = ""
+			e, err := r.repo.Add(ctx, r.toEntity(version))
+			if err != nil {
+				return err
+			}
+			version.ID = e.ID
+		}
+		if err := r.rules.repo.UpdateContent(ctx, r.rules.toEntity(rule)); err != nil {
+			return err
+		}
+		if !discardDraft {
+			return nil
+		}
+		return r.repo.DeleteByRuleVersion(ctx, rule.ID, 0)
+	})
+}
+
+// DeleteByRuleID 删除规则的所有版本和草稿
+func (r *RuleVersionRepository) DeleteByRuleID(ctx context.Context, ruleID string) error {
+	return r.repo.DeleteByRuleID(ctx, ruleID)
+}
+
+// toEntity 将领域模型转换为实体
+func (r *RuleVersionRepository) toEntity(v *model.RuleVersion) *entity.RuleVersion {
+	return &entity.RuleVersion{
+		ID:              v.ID,
+		RuleID:          v.RuleID,
+		Version:         v.Version,
+		Status:          v.Status,
+		Comment:         v.Comment,
+		RollbackFrom:    v.RollbackFrom,
+		CategoryID:      v.CategoryID,
+		TemplateID:      v.TemplateID,
+		Type:            v.Type,
+		Triggers:        strings.Join(v.Triggers, ","),
+		Scope:           v.Scope,
+		ScopeID:         v.ScopeID,
+		ExecutionTiming: v.ExecutionTiming,
+		Conditions:      v.Conditions,
+		LuaScript:       v.LuaScript,
+		Formula:         v.Formula,
+		FormulaVars:     v.FormulaVars,
+		DecisionTable:   v.DecisionTable,
+		DBPolicy:        v.DBPolicy,
+		Action:          v.Action,
+		Priority:        v.Priority,
+		Sorting:         v.Sorting,
+		PublishedAt:     v.PublishedAt,
+		TenantID:        v.TenantID,
+	}
+}
+
+// toModel 将实体转换为领域模型
+func (r *RuleVersionRepository) toModel(e *entity.RuleVersion) *model.RuleVersion {
+	if e == nil {
+		return nil
+	}
+	triggers := make([]string, 0)
+	if e.Triggers != "" {
+		triggers = strings.Split(e.Triggers, ",")
+	}
+	return &model.RuleVersion{
+		ID:              e.ID,
+		RuleID:          e.RuleID,
+		Version:         e.Version,
+		Status:          e.Status,
+		Comment:         e.Comment,
+		RollbackFrom:    e.RollbackFrom,
+		CategoryID:      e.CategoryID,
+		TemplateID:      e.TemplateID,
+		Type:            e.Type,
+		Triggers:        triggers,
+		Scope:           e.Scope,
+		ScopeID:         e.ScopeID,
+		ExecutionTiming: e.ExecutionTiming,
+		Conditions:      e.Conditions,
+		LuaScript:       e.LuaScript,
+		Formula:         e.Formula,
+		FormulaVars:     e.FormulaVars,
+		DecisionTable:   e.DecisionTable,
+		DBPolicy:        e.DBPolicy,
+		Action:          e.Action,
+		Priority:        e.Priority,
+		Sorting:         e.Sorting,
+		PublishedAt:     e.PublishedAt,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+		TenantID:        e.TenantID,
+	}
+}
@@ -2,7 +2,11 @@ package admin
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/cloudwego/hertz/pkg/route"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
@@ -75,6 +79,73 @@ func (rs *RuleService) RegisterRouter(rg *route.RouterGroup, t token.IToken) {
 		g.GET("/by-category", hserver.NewHandlerFu[queries.GetRulesByCategoryReq](rs.GetRulesByCategory)) // 根据分类获取规则
 
 		g.GET("/by-type", hserver.NewHandlerFu[queries.GetRulesByTypeReq](rs.GetRulesByType)) // 根据类型获取规则
+
+		g.GET("/:id/draft", hserver.NewHandlerFu[models.StringIdReq](rs.GetRuleDraft)) // 获取规则草稿
+
+		g.DELETE("/:id/draft", oplog.Record(oplog.LogOption{
+			IncludeBody: true,
+			Module:      "规则管理",
+			Action:      "丢弃草稿",
+		}), hserver.NewHandlerFu[models.StringIdReq](rs.DiscardRuleDraft)) // 丢弃规则草稿
+
+		g.POST("/publish", oplog.Record(oplog.LogOption{
+			IncludeBody: true,
+			Module:      "规则管理",
+			Action:      "发布",
+		}), hserver.NewHandlerFu[command.PublishRuleCommand](rs.PublishRule)) // 发布规则草稿
+
+		g.POST("/rollback", oplog.Record(oplog.LogOption{
+			IncludeBody: true,
+			Module:      "规则管理",
+			Action:      "回滚",
+		}), hserver.NewHandlerFu[command.RollbackRuleCommand](rs.RollbackRule)) // 回滚规则版本
+
+		g.GET("/versions", hserver.NewHandlerFu[queries.GetRuleVersionsReq](rs.GetRuleVersions)) // 获取规则版本列表
+
+		g.GET("/version", hserver.NewHandlerFu[queries.GetRuleVersionReq](rs.GetRuleVersion)) // 获取规则版本详情
+
+		g.GET("/version/diff", hserver.NewHandlerFu[queries.DiffRuleVersionsReq](rs.DiffRuleVersions)) // 比较规则版本
+
+		g.POST("/simulate", hserver.NewHandlerFu[command.SimulateRulesCommand](rs.SimulateRules)) // 模拟执行规则
+
+		g.POST("/simulate-rule", hserver.NewHandlerFu[command.SimulateRuleCommand](rs.SimulateRule)) // 模拟执行单个规则
+
+		g.POST("/test", hserver.NewHandlerFu[command.RunRuleTestCasesCommand](rs.RunRuleTestCases)) // 执行规则测试用例
+
+		g.GET("/test-cases", hserver.NewHandlerFu[queries.GetRuleTestCasesReq](rs.GetRuleTestCases)) // 获取规则测试用例列表
+
+		g.POST("/test-case", oplog.Record(oplog.LogOption{
+			IncludeBody: true,
+			Module:      "规则管理",
+			Action:      "新增测试用例",
+		}), hserver.NewHandlerFu[command.CreateRuleTestCaseCommand](rs.CreateRuleTestCase)) // 新增规则测试用例
+
+		g.PUT("/test-case", oplog.Record(oplog.LogOption{
+			IncludeBody: true,
+			Module:      "规则管理",
+			Action:      "修改测试用例",
+		}), hserver.NewHandlerFu[command.UpdateRuleTestCaseCommand](rs.UpdateRuleTestCase)) // 修改规则测试用例
+
+		g.DELETE("/test-case/:id", oplog.Record(oplog.LogOption{
+			IncludeBody: true,
+			Module:      "规则管理",
+			Action:      "删除测试用例",
+		}), hserver.NewHandlerFu[models.StringIdReq](rs.DeleteRuleTestCase)) // 删除规则测试用例
+
+		g.POST("/decision-table/import", oplog.Record(oplog.LogOption{
+			Module: "规则管理",
+			Action: "导入决策表",
+		}), rs.ImportDecisionTable) // 导入决策表
+
+		g.GET("/decision-table/export", rs.ExportDecisionTable) // 导出决策表
+
+		g.POST("/decision-table/validate", hserver.NewHandlerFu[command.ValidateDecisionTableCommand](rs.ValidateDecisionTable)) // 检查决策表
+
+		g.GET("/execution/logs", hserver.NewHandlerFu[queries.GetExecutionLogsReq](rs.GetExecutionLogs)) // 获取规则执行日志列表
+
+		g.GET("/execution/log/:id", hserver.NewHandlerFu[models.StringIdReq](rs.GetExecutionLog)) // 获取规则执行日志详情
+
+		g.GET("/execution/stats", hserver.NewHandlerFu[queries.GetExecutionStatsReq](rs.GetExecutionStats)) // 获取规则执行统计
 	}
 }
 
@@ -284,3 +355,485 @@ func (rs *RuleService) GetRulesByType(ctx context.Context, req *queries.GetRules
 	}
 	return res.WithData(data)
 }
+
+// GetRuleDraft 获取规则草稿
+// @Summary 获取规则草稿
+// @Description 获取规则未发布的草稿内容，没有草稿时返回线上内容
+// @Tags 规则引擎
+// @ID GetRuleDraft
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "规则ID"
+// @Success 200 {object} base_info.Success{data=dto.RuleDTO} "规则草稿"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/{id}/draft [get]
+func (rs *RuleService) GetRuleDraft(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rh.HandleGetRuleDraft(ctx, &queries.GetRuleReq{ID: req.Id})
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// DiscardRuleDraft 丢弃规则草稿
+// @Summary 丢弃规则草稿
+// @Description 丢弃规则未发布的草稿
+// @Tags 规则引擎
+// @ID DiscardRuleDraft
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "规则ID"
+// @Success 200 {object} base_info.Success{} "丢弃成功"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/{id}/draft [delete]
+func (rs *RuleService) DiscardRuleDraft(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	err := rs.rc.HandleDiscardRuleDraft(ctx, &command.DiscardRuleDraftCommand{ID: req.Id})
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res
+}
+
+// PublishRule 发布规则草稿
+// @Summary 发布规则草稿
+// @Description 将规则草稿发布为新版本，发布后立即生效
+// @Tags 规则引擎
+// @ID PublishRule
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.PublishRuleCommand true "发布信息"
+// @Success 200 {object} base_info.Success{} "发布成功"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/publish [post]
+func (rs *RuleService) PublishRule(ctx context.Context, req *command.PublishRuleCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	err := rs.rc.HandlePublishRule(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res
+}
+
+// RollbackRule 回滚规则版本
+// @Summary 回滚规则版本
+// @Description 以指定版本的内容发布一个新版本
+// @Tags 规则引擎
+// @ID RollbackRule
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.RollbackRuleCommand true "回滚信息"
+// @Success 200 {object} base_info.Success{} "回滚成功"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/rollback [post]
+func (rs *RuleService) RollbackRule(ctx context.Context, req *command.RollbackRuleCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	err := rs.rc.HandleRollbackRule(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res
+}
+
+// GetRuleVersions 获取规则版本列表
+// @Summary 获取规则版本列表
+// @Description 获取规则的所有已发布版本，按版本号倒序
+// @Tags 规则引擎
+// @ID GetRuleVersions
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req query queries.GetRuleVersionsReq true "查询参数"
+// @Success 200 {object} base_info.Success{data=[]dto.RuleVersionDTO} "版本列表"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/versions [get]
+func (rs *RuleService) GetRuleVersions(ctx context.Context, req *queries.GetRuleVersionsReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rh.HandleGetRuleVersions(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// GetRuleVersion 获取规则版本详情
+// @Summary 获取规则版本详情
+// @Description 获取规则指定版本的内容，版本号为0时获取草稿
+// @Tags 规则引擎
+// @ID GetRuleVersion
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req query queries.GetRuleVersionReq true "查询参数"
+// @Success 200 {object} base_info.Success{data=dto.RuleVersionDTO} "版本详情"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/version [get]
+func (rs *RuleService) GetRuleVersion(ctx context.Context, req *queries.GetRuleVersionReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rh.HandleGetRuleVersion(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// DiffRuleVersions 比较规则版本
+// @Summary 比较规则版本
+// @Description 比较规则两个版本的内容差异，版本号为0时表示草稿
+// @Tags 规则引擎
+// @ID DiffRuleVersions
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req query queries.DiffRuleVersionsReq true "查询参数"
+// @Success 200 {object} base_info.Success{data=dto.RuleVersionDiffDTO} "版本差异"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/version/diff [get]
+func (rs *RuleService) DiffRuleVersions(ctx context.Context, req *queries.DiffRuleVersionsReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rh.HandleDiffRuleVersions(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// SimulateRules 模拟执行规则
+// @Summary 模拟执行规则
+// @Description 按上下文匹配并执行规则，数据库写入在执行结束后回滚，返回完整的执行链路
+// @Tags 规则引擎
+// @ID SimulateRules
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.SimulateRulesCommand true "执行上下文"
+// @Success 200 {object} base_info.Success{data=model.RuleResult} "执行结果"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/simulate [post]
+func (rs *RuleService) SimulateRules(ctx context.Context, req *command.SimulateRulesCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rc.HandleSimulateRules(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// SimulateRule 模拟执行单个规则
+// @Summary 模拟执行单个规则
+// @Description 执行指定规则的线上内容或草稿内容，数据库写入在执行结束后回滚
+// @Tags 规则引擎
+// @ID SimulateRule
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.SimulateRuleCommand true "执行参数"
+// @Success 200 {object} base_info.Success{data=model.RuleResult} "执行结果"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/simulate-rule [post]
+func (rs *RuleService) SimulateRule(ctx context.Context, req *command.SimulateRuleCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rc.HandleSimulateRule(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// RunRuleTestCases 执行规则测试用例
+// @Summary 执行规则测试用例
+// @Description 执行规则所有启用的测试用例并返回测试报告
+// @Tags 规则引擎
+// @ID RunRuleTestCases
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.RunRuleTestCasesCommand true "测试参数"
+// @Success 200 {object} base_info.Success{data=model.RuleTestReport} "测试报告"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/test [post]
+func (rs *RuleService) RunRuleTestCases(ctx context.Context, req *command.RunRuleTestCasesCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rc.HandleRunRuleTestCases(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// GetRuleTestCases 获取规则测试用例列表
+// @Summary 获取规则测试用例列表
+// @Description 获取规则的所有测试用例
+// @Tags 规则引擎
+// @ID GetRuleTestCases
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req query queries.GetRuleTestCasesReq true "查询参数"
+// @Success 200 {object} base_info.Success{data=[]dto.RuleTestCaseDTO} "测试用例列表"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/test-cases [get]
+func (rs *RuleService) GetRuleTestCases(ctx context.Context, req *queries.GetRuleTestCasesReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rh.HandleGetRuleTestCases(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// CreateRuleTestCase 新增规则测试用例
+// @Summary 新增规则测试用例
+// @Description 新增规则测试用例，发布规则前自动执行
+// @Tags 规则引擎
+// @ID CreateRuleTestCase
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.CreateRuleTestCaseCommand true "测试用例"
+// @Success 200 {object} base_info.Success{} "创建成功"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/test-case [post]
+func (rs *RuleService) CreateRuleTestCase(ctx context.Context, req *command.CreateRuleTestCaseCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	err := rs.rc.HandleCreateRuleTestCase(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res
+}
+
+// UpdateRuleTestCase 修改规则测试用例
+// @Summary 修改规则测试用例
+// @Description 修改规则测试用例
+// @Tags 规则引擎
+// @ID UpdateRuleTestCase
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.UpdateRuleTestCaseCommand true "测试用例"
+// @Success 200 {object} base_info.Success{} "更新成功"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/test-case [put]
+func (rs *RuleService) UpdateRuleTestCase(ctx context.Context, req *command.UpdateRuleTestCaseCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	err := rs.rc.HandleUpdateRuleTestCase(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res
+}
+
+// DeleteRuleTestCase 删除规则测试用例
+// @Summary 删除规则测试用例
+// @Description 删除规则测试用例
+// @Tags 规则引擎
+// @ID DeleteRuleTestCase
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "用例ID"
+// @Success 200 {object} base_info.Success{} "删除成功"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/test-case/{id} [delete]
+func (rs *RuleService) DeleteRuleTestCase(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	err := rs.rc.HandleDeleteRuleTestCase(ctx, &command.DeleteRuleTestCaseCommand{ID: req.Id})
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res
+}
+
+// GetExecutionLogs 获取规则执行日志列表
+// @Summary 获取规则执行日志列表
+// @Description 分页获取规则执行日志，按规则编码筛选时返回执行链路中包含该规则的日志
+// @Tags 规则引擎
+// @ID GetRuleExecutionLogs
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req query queries.GetExecutionLogsReq true "查询参数"
+// @Success 200 {object} base_info.Success{data=models.PageRes[dto.RuleExecutionLogDTO]{list=[]dto.RuleExecutionLogDTO}} "执行日志列表"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/execution/logs [get]
+func (rs *RuleService) GetExecutionLogs(ctx context.Context, req *queries.GetExecutionLogsReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, total, err := rs.rh.HandleGetExecutionLogs(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(models.NewPageRes(total, data))
+}
+
+// GetExecutionLog 获取规则执行日志详情
+// @Summary 获取规则执行日志详情
+// @Description 获取规则执行日志及其执行步骤
+// @Tags 规则引擎
+// @ID GetRuleExecutionLog
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "日志ID"
+// @Success 200 {object} base_info.Success{data=dto.RuleExecutionLogDTO} "执行日志详情"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/execution/log/{id} [get]
+func (rs *RuleService) GetExecutionLog(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rh.HandleGetExecutionLog(ctx, &queries.GetExecutionLogReq{ID: req.Id})
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// GetExecutionStats 获取规则执行统计
+// @Summary 获取规则执行统计
+// @Description 按规则编码、作用域或触发动作统计执行次数、失败率和平均耗时
+// @Tags 规则引擎
+// @ID GetRuleExecutionStats
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req query queries.GetExecutionStatsReq true "查询参数"
+// @Success 200 {object} base_info.Success{data=[]dto.RuleExecutionStatsDTO} "执行统计"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/execution/stats [get]
+func (rs *RuleService) GetExecutionStats(ctx context.Context, req *queries.GetExecutionStatsReq) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rh.HandleGetExecutionStats(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
+
+// ImportDecisionTable 导入决策表
+// @Summary 导入决策表
+// @Description 上传 Excel 文件替换决策表规则的行并保存为草稿，返回重叠和遗漏检查报告
+// @Tags 规则引擎
+// @ID ImportDecisionTable
+// @Accept multipart/form-data
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param id formData string true "规则ID"
+// @Param file formData file true "Excel文件"
+// @Success 200 {object} base_info.Success{data=model.DecisionTableReport} "检查报告"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/decision-table/import [post]
+func (rs *RuleService) ImportDecisionTable(ctx context.Context, c *app.RequestContext) {
+	header, err := c.FormFile("file")
+	if err != nil || header == nil {
+		hserver.ResponseFailureErr(ctx, c, herrors.NewParameterHError(fmt.Errorf("导入文件不能为空")))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		hserver.ResponseFailureErr(ctx, c, herrors.NewParameterHError(err))
+		return
+	}
+	defer file.Close()
+
+	req := &command.ImportDecisionTableCommand{ID: string(c.FormValue("id")), File: file}
+	data, herr := rs.rc.HandleImportDecisionTable(ctx, req)
+	if herrors.HaveError(herr) {
+		hserver.ResponseFailureErr(ctx, c, herr)
+		return
+	}
+	hserver.ResponseSuccess(ctx, c, data)
+}
+
+// ExportDecisionTable 导出决策表
+// @Summary 导出决策表
+// @Description 将决策表规则的线上内容或草稿内容导出为 Excel
+// @Tags 规则引擎
+// @ID ExportDecisionTable
+// @Accept application/json
+// @Produce application/octet-stream
+// @Param Authorization header string true "Bearer token"
+// @Param req query queries.ExportDecisionTableReq true "导出参数"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/decision-table/export [get]
+func (rs *RuleService) ExportDecisionTable(ctx context.Context, c *app.RequestContext) {
+	req := queries.ExportDecisionTableReq{}
+	if err := c.BindAndValidate(&req); err != nil {
+		hserver.ResponseFailureErr(ctx, c, herrors.NewParameterHError(err))
+		return
+	}
+	data, herr := rs.rh.HandleExportDecisionTable(ctx, &req)
+	if herrors.HaveError(herr) {
+		hserver.ResponseFailureErr(ctx, c, herr)
+		return
+	}
+	filename := fmt.Sprintf("decision_table_%s_%s.xlsx", req.ID, time.Now().Format("20060102150405"))
+	c.Response.Header.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Response.SetStatusCode(consts.StatusOK)
+	c.Response.SetBody(data)
+}
+
+// ValidateDecisionTable 检查决策表
+// @Summary 检查决策表
+// @Description 检查决策表的结构、行之间的重叠和没有任何行命中的输入组合，不保存
+// @Tags 规则引擎
+// @ID ValidateDecisionTable
+// @Accept application/json
+// @Produce application/json
+// @Param Authorization header string true "Bearer token"
+// @Param req body command.ValidateDecisionTableCommand true "决策表"
+// @Success 200 {object} base_info.Success{data=model.DecisionTableReport} "检查报告"
+// @Failure 400 {object} base_info.Swagger400Resp "参数错误"
+// @Failure 401 {object} base_info.Swagger401Resp "未授权"
+// @Failure 500 {object} base_info.Swagger500Resp "服务器内部错误"
+// @Router /v1/rule-engine/rule/decision-table/validate [post]
+func (rs *RuleService) ValidateDecisionTable(ctx context.Context, req *command.ValidateDecisionTableCommand) *hserver.ResponseResult {
+	res := hserver.DefaultResponseResult()
+	data, err := rs.rc.HandleValidateDecisionTable(ctx, req)
+	if herrors.HaveError(err) {
+		return res.WithError(err)
+	}
+	return res.WithData(data)
+}
@@ -1,13 +1,20 @@
 package rule_engine
 
 import (
+	"context"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/infrastructure/configs"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
 	comhandler "github.com/flare-admin/flare-server-go/framework/support/rule_engine/application/command/handler"
 	queryhandler "github.com/flare-admin/flare-server-go/framework/support/rule_engine/application/queries/handler"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/persistence/data"
 	"github.com/google/wire"
 	// 领域层
+	domainrepo "github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/repository"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/domain/service"
 	// 基础设施层
+	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/notify"
 	"github.com/flare-admin/flare-server-go/framework/support/rule_engine/infrastructure/repository"
 	// 接口层
 	admin "github.com/flare-admin/flare-server-go/framework/support/rule_engine/interfaces/admin"
@@ -19,16 +26,26 @@ var BaseProviderSet = wire.NewSet(
 	data.NewRuleCategoryRepository,
 	data.NewRuleTemplateRepository,
 	data.NewRuleRepository,
+	data.NewRuleVersionRepository,
+	data.NewRuleTestCaseRepository,
+	data.NewRuleExecutionLogRepository,
 
 	repository.NewRuleTemplateRepository,
 	repository.NewRuleCategoryRepository,
 	repository.NewRuleRepository,
+	repository.NewRuleVersionRepository,
+	repository.NewRuleTestCaseRepository,
+	repository.NewRuleExecutionLogRepository,
+	notify.NewRedisRuleChangeNotifier,
 
 	// 领域层
 	service.NewRuleTemplateService,
 	service.NewRuleCategoryService,
 	service.NewRuleService,
 	service.NewRuleExecutionService,
+	NewRuleExecutionRecorder,
+	NewRuleCache,
+	NewRuleActionRegistry,
 
 	// 应用层
 	comhandler.NewTemplateCommandHandler,
@@ -50,3 +67,54 @@ var ProviderSet = wire.NewSet(
 	admin.NewRuleService,
 	NewServer,
 )
+
+// NewRuleExecutionRecorder 创建规则执行记录器，进程退出时写入队列中剩余的执行日志
+func NewRuleExecutionRecorder(cof *configs.Bootstrap, logRepo domainrepo.IRuleExecutionLogRepository, ruleRepo domainrepo.IRuleRepository) (*service.RuleExecutionRecorder, func(), error) {
+	cfg := service.RecorderConfig{}
+	if cof.RuleEngine != nil && cof.RuleEngine.ExecutionLog != nil {
+		c := cof.RuleEngine.ExecutionLog
+		cfg = service.RecorderConfig{
+			Disabled:      c.Disabled,
+			SampleRate:    c.SampleRate,
+			RecordData:    c.RecordData,
+			BatchSize:     c.BatchSize,
+			FlushInterval: time.Duration(c.FlushInterval) * time.Millisecond,
+			QueueSize:     c.QueueSize,
+			RetentionDays: c.RetentionDays,
+		}
+	}
+	recorder := service.NewRuleExecutionRecorder(logRepo, ruleRepo, cfg)
+	cleanup := func() {
+		recorder.Close()
+	}
+	return recorder, cleanup, nil
+}
+
+// NewRuleCache 创建规则缓存并加载规则，关闭缓存时返回 nil，执行时查询数据库
+func NewRuleCache(cof *configs.Bootstrap, ruleRepo domainrepo.IRuleRepository, categoryRepo domainrepo.ICategoryRepository, notifier service.RuleChangeNotifier) (*service.RuleCache, func(), error) {
+	cfg := service.RuleCacheConfig{}
+	if cof.RuleEngine != nil && cof.RuleEngine.Cache != nil {
+		c := cof.RuleEngine.Cache
+		if c.Disabled {
+			return nil, func() {}, nil
+		}
+		cfg.RefreshInterval = time.Duration(c.RefreshInterval) * time.Second
+	}
+	cache := service.NewRuleCache(ruleRepo, categoryRepo, notifier, cfg)
+	if err := cache.Reload(context.Background()); err != nil {
+		cache.Close()
+		return nil, nil, err
+	}
+	cleanup := func() {
+		cache.Close()
+	}
+	return cache, cleanup, nil
+}
+
+// NewRuleActionRegistry 创建规则动作处理器注册表，notify 动作发布到事件总线
+// 宿主应用可以在启动时继续注册 redirect 等动作的处理器
+func NewRuleActionRegistry(bus mqevent.IMQEventBus) *service.ActionRegistry {
+	registry := service.NewActionRegistry()
+	registry.Register(notify.ActionNotify, notify.NewNotifyActionHandler(bus))
+	return registry
+}
@@ -83,6 +83,16 @@ func (s SubscribeManagerUseCase) DadEventSave(ctx context.Context, event *mqeven
 		hlog.CtxErrorf(ctx, "convert dead letter event to subscribe failed: %v", err)
 		return err
 	}
+	sub, err := s.sr.GetByTopicAndGroup(ctx, subscribe.Topic, subscribe.Channel)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "get subscribe of dead letter failed: %v", err)
+		return err
+	}
+	if sub != nil {
+		subscribe.Name = sub.Name
+	}
+	// 按订阅的重试策略安排首次自动重试
+	subscribe.ScheduleRetry(sub.GetRetryPolicy(), time.Now())
 	_, err = s.deadPar.Add(ctx, &subscribe)
 	return err
 }
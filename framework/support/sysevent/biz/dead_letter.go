@@ -2,22 +2,44 @@ package biz
 
 import (
 	"context"
+	"errors"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/excel"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
 	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/manager"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/event_err"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/service"
+	"time"
+)
+
+const (
+	// 每次自动重试的默认条数
+	defaultRetryBatch = 100
+	// 认领死信后的租约，实例在重试过程中崩溃时租约到期后重新被认领
+	retryClaimLease = 5 * time.Minute
+	// 单次导出的最大条数
+	maxExportRows = 10000
+)
+
+var (
+	// 可以重试或丢弃的死信状态
+	retryableStatus = []int8{model.DeadLetterStatusPending, model.DeadLetterStatusFailed, model.DeadLetterStatusParked}
+	// 导出达到上限时提前结束遍历
+	errExportLimit = errors.New("export limit reached")
 )
 
 type IDeadLetterSubscribeRepo interface {
 	baserepo.IBaseRepo[model.DeadLetterSubscribe, string]
 	GetBy(ctx context.Context, topic, channel, messageId string) (*model.DeadLetterSubscribe, error)
 	UpdateStatus(ctx context.Context, id string, status int8) error
+	// FindDue 获取到达重试时间的待处理死信
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*model.DeadLetterSubscribe, error)
 }
 
 type DeadLetterSubscribeUseCase struct {
@@ -41,22 +63,166 @@ func (d *DeadLetterSubscribeUseCase) Retry(ctx context.Context, id string) error
 		hlog.CtxErrorf(ctx, "convert dead letter event failed: %v", err)
 		return err
 	}
-	return d.em.RetryDeadLetter(ctx, event)
+	if _, err = d.em.RetryDeadLetter(ctx, event); err != nil {
+		return err
+	}
+	return d.repo.UpdateStatus(ctx, id, model.DeadLetterStatusSucceeded)
 }
 
-func (d *DeadLetterSubscribeUseCase) GetList(ctx context.Context, req *dto.GetDeadLetterSubscribeListReq) (models.PageRes[dto.DeadLetterSubscribeModel], error) {
+// RetryDue 按订阅的重试策略重试到期死信，失败后计算下次重试时间，达到最大次数后搁置
+func (d *DeadLetterSubscribeUseCase) RetryDue(ctx context.Context, limit int) (int, error) {
+	ctx = getCtx(ctx)
+	if limit <= 0 {
+		limit = defaultRetryBatch
+	}
+	now := time.Now()
+	deads, err := d.repo.FindDue(ctx, now, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "find due dead letters failed: %v", err)
+		return 0, err
+	}
+	policies := make(map[string]model.RetryPolicy)
+	count := 0
+	for _, dead := range deads {
+		claimed, err := d.claim(ctx, dead, now)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "claim dead letter %s failed: %v", dead.Id, err)
+			return count, err
+		}
+		if !claimed {
+			// 已被其他实例认领
+			continue
+		}
+		policy, err := d.retryPolicy(ctx, dead, policies)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "get retry policy of %s/%s failed: %v", dead.Topic, dead.Channel, err)
+			return count, err
+		}
+		if err = d.retryOnce(ctx, dead, policy, now); err != nil {
+			hlog.CtxErrorf(ctx, "save dead letter %s failed: %v", dead.Id, err)
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// claim 通过推迟下次重试时间认领死信，避免多实例重复投递
+func (d *DeadLetterSubscribeUseCase) claim(ctx context.Context, dead *model.DeadLetterSubscribe, now time.Time) (bool, error) {
+	qb := db_query.NewQueryBuilder().
+		Where("id", db_query.Eq, dead.Id).
+		Where("status", db_query.Eq, model.DeadLetterStatusPending).
+		Where("next_retry", db_query.Eq, dead.NextRetry)
+	affected, err := d.repo.UpdateWhere(ctx, qb, map[string]interface{}{"next_retry": now.Add(retryClaimLease)})
+	return affected > 0, err
+}
+
+func (d *DeadLetterSubscribeUseCase) retryPolicy(ctx context.Context, dead *model.DeadLetterSubscribe, cache map[string]model.RetryPolicy) (model.RetryPolicy, error) {
+	key := dead.Topic + ":" + dead.Channel
+	if policy, ok := cache[key]; ok {
+		return policy, nil
+	}
+	sub, err := d.par.GetByTopicAndGroup(ctx, dead.Topic, dead.Channel)
+	if err != nil {
+		return model.RetryPolicy{}, err
+	}
+	// 订阅不存在时使用默认策略
+	policy := sub.GetRetryPolicy()
+	cache[key] = policy
+	return policy, nil
+}
+
+// retryOnce 重新投递一次死信并保存结果
+func (d *DeadLetterSubscribeUseCase) retryOnce(ctx context.Context, dead *model.DeadLetterSubscribe, policy model.RetryPolicy, now time.Time) error {
+	dead.Attempts++
+	dead.LastAttempt = now
+	event, err := dead.ToDeadLetterEvent()
+	if err == nil {
+		_, err = d.em.RetryDeadLetter(ctx, event)
+	}
+	if err != nil {
+		hlog.CtxWarnf(ctx, "retry dead letter %s failed, attempts %d: %v", dead.Id, dead.Attempts, err)
+		dead.Error = err.Error()
+		dead.ScheduleRetry(policy, now)
+	} else {
+		dead.Status = model.DeadLetterStatusSucceeded
+	}
+	return d.repo.EditById(ctx, dead)
+}
+
+// BulkRetry 将死信重新排队，清零重试次数后由定时任务立即重试
+func (d *DeadLetterSubscribeUseCase) BulkRetry(ctx context.Context, req *dto.BulkDeadLetterReq) (*dto.BulkDeadLetterRes, error) {
+	return d.bulkUpdate(ctx, req, map[string]interface{}{
+		"status":     model.DeadLetterStatusPending,
+		"attempts":   0,
+		"next_retry": time.Now(),
+	})
+}
+
+// BulkDiscard 丢弃死信，丢弃后不再重试
+func (d *DeadLetterSubscribeUseCase) BulkDiscard(ctx context.Context, req *dto.BulkDeadLetterReq) (*dto.BulkDeadLetterRes, error) {
+	return d.bulkUpdate(ctx, req, map[string]interface{}{
+		"status": model.DeadLetterStatusDiscarded,
+	})
+}
+
+func (d *DeadLetterSubscribeUseCase) bulkUpdate(ctx context.Context, req *dto.BulkDeadLetterReq, values map[string]interface{}) (*dto.BulkDeadLetterRes, error) {
 	qb := db_query.NewQueryBuilder()
-	if req.Topic != "" {
-		qb.Where("topic", db_query.Eq, req.Topic)
+	if len(req.Ids) > 0 {
+		qb.WhereIn("id", req.Ids)
+	} else {
+		req.DeadLetterQuery.Build(qb)
 	}
-	if req.Group != "" {
-		qb.Where("group", db_query.Eq, req.Group)
+	// 不允许无条件批量操作
+	if len(qb.GetConditions()) == 0 {
+		return nil, event_err.DeadLetterFilterIsEmpty
 	}
-	if req.Name != "" {
-		qb.Where("name", db_query.Eq, req.Name)
+	qb.WhereIn("status", retryableStatus)
+	affected, err := d.repo.UpdateWhere(ctx, qb, values)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "bulk update dead letters failed: %v", err)
+		return nil, event_err.DeadLetterOperateFail(err)
+	}
+	return &dto.BulkDeadLetterRes{Affected: affected}, nil
+}
+
+// Export 按筛选条件导出死信，最多导出 maxExportRows 条
+func (d *DeadLetterSubscribeUseCase) Export(ctx context.Context, req *dto.ExportDeadLetterReq) ([]byte, error) {
+	exporter := excel.NewExcelExporter("死信队列")
+	exporter.ParseModelColumns(dto.DeadLetterSubscribeModel{})
+	if err := exporter.WriteHeader(); err != nil {
+		return nil, event_err.DeadLetterExportFail(err)
+	}
+	rows := 0
+	qb := req.DeadLetterQuery.Build(db_query.NewQueryBuilder())
+	err := d.repo.FindInBatches(ctx, qb, 500, func(ctx context.Context, batch []*model.DeadLetterSubscribe) error {
+		for _, item := range batch {
+			if rows >= maxExportRows {
+				return errExportLimit
+			}
+			if err := exporter.WriteRow(dto.DeadLetterToDto(item)); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errExportLimit) {
+		hlog.CtxErrorf(ctx, "export dead letters failed: %v", err)
+		return nil, event_err.DeadLetterExportFail(err)
 	}
+	data, err := exporter.SaveAsBytes()
+	if err != nil {
+		return nil, event_err.DeadLetterExportFail(err)
+	}
+	return data, nil
+}
+
+func (d *DeadLetterSubscribeUseCase) GetList(ctx context.Context, req *dto.GetDeadLetterSubscribeListReq) (models.PageRes[dto.DeadLetterSubscribeModel], error) {
+	qb := req.DeadLetterQuery.Build(db_query.NewQueryBuilder())
 	qb.OrderBy("status", true)
 	qb.OrderBy("created_at", false)
+	qb.WithPage(&req.Page)
 	res := models.PageRes[dto.DeadLetterSubscribeModel]{}
 	// 查询总数
 	total, err := d.repo.Count(ctx, qb)
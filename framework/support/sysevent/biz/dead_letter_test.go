@@ -0,0 +1,121 @@
+package biz_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/manager"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/biz"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+)
+
+type fakeDeadLetterRepo struct {
+	biz.IDeadLetterSubscribeRepo
+	deads   []*model.DeadLetterSubscribe
+	claimed map[string]bool
+	saved   map[string]model.DeadLetterSubscribe
+	where   string
+	values  map[string]interface{}
+}
+
+func (f *fakeDeadLetterRepo) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.DeadLetterSubscribe, error) {
+	return f.deads, nil
+}
+
+func (f *fakeDeadLetterRepo) UpdateWhere(ctx context.Context, qb *db_query.QueryBuilder, values map[string]interface{}) (int64, error) {
+	f.where, _ = qb.BuildWhere()
+	f.values = values
+	id := qb.GetConditions()[0].Value
+	if s, ok := id.(string); ok {
+		if f.claimed[s] {
+			return 0, nil
+		}
+		f.claimed[s] = true
+	}
+	return 1, nil
+}
+
+func (f *fakeDeadLetterRepo) EditById(ctx context.Context, data *model.DeadLetterSubscribe) error {
+	f.saved[data.Id] = *data
+	return nil
+}
+
+type fakeSubscribeRepo struct {
+	biz.ISubscribeRepo
+}
+
+func (fakeSubscribeRepo) GetByTopicAndGroup(ctx context.Context, topic, group string) (*model.Subscribe, error) {
+	if topic == "order" {
+		return &model.Subscribe{Topic: topic, Group: group, RetryMaxAttempts: 2, RetrySchedule: "30"}, nil
+	}
+	return nil, nil
+}
+
+type fakeEventManager struct {
+	manager.EventManager
+	fail map[string]bool
+}
+
+func (f fakeEventManager) RetryDeadLetter(ctx context.Context, dead *mqevent.DeadLetterEvent) ([]byte, error) {
+	if f.fail[dead.OriginalEvent.GetID()] {
+		return nil, errors.New("handler failed")
+	}
+	return nil, nil
+}
+
+func TestDeadLetter_RetryDue(t *testing.T) {
+	repo := &fakeDeadLetterRepo{
+		deads: []*model.DeadLetterSubscribe{
+			{Id: "ok", EventId: "e1", Topic: "user", Channel: "c", Status: model.DeadLetterStatusPending},
+			{Id: "again", EventId: "e2", Topic: "order", Channel: "c", Status: model.DeadLetterStatusPending},
+			{Id: "park", EventId: "e3", Topic: "order", Channel: "c", Status: model.DeadLetterStatusPending, Attempts: 1},
+			{Id: "taken", EventId: "e4", Topic: "user", Channel: "c", Status: model.DeadLetterStatusPending},
+		},
+		claimed: map[string]bool{"taken": true},
+		saved:   map[string]model.DeadLetterSubscribe{},
+	}
+	em := fakeEventManager{fail: map[string]bool{"e2": true, "e3": true}}
+	uc := biz.NewDeadLetterSubscribeUseCase(repo, fakeSubscribeRepo{}, em, nil)
+
+	before := time.Now()
+	n, err := uc.RetryDue(context.Background(), 10)
+	if err != nil || n != 3 {
+		t.Fatalf("RetryDue = %d, %v", n, err)
+	}
+	if _, ok := repo.saved["taken"]; ok {
+		t.Fatal("dead letter claimed by another worker must be skipped")
+	}
+	if d := repo.saved["ok"]; d.Status != model.DeadLetterStatusSucceeded || d.Attempts != 1 {
+		t.Fatalf("ok: %+v", d)
+	}
+	again := repo.saved["again"]
+	if again.Status != model.DeadLetterStatusPending || again.Error != "handler failed" ||
+		again.NextRetry.Before(before.Add(30*time.Second)) {
+		t.Fatalf("again: %+v", again)
+	}
+	if d := repo.saved["park"]; d.Status != model.DeadLetterStatusParked || d.Attempts != 2 {
+		t.Fatalf("park: %+v", d)
+	}
+}
+
+func TestDeadLetter_BulkRequiresFilter(t *testing.T) {
+	repo := &fakeDeadLetterRepo{claimed: map[string]bool{}}
+	uc := biz.NewDeadLetterSubscribeUseCase(repo, fakeSubscribeRepo{}, fakeEventManager{}, nil)
+	ctx := context.Background()
+
+	if _, err := uc.BulkDiscard(ctx, &dto.BulkDeadLetterReq{}); err == nil {
+		t.Fatal("bulk operation without filter must be rejected")
+	}
+	res, err := uc.BulkRetry(ctx, &dto.BulkDeadLetterReq{DeadLetterQuery: dto.DeadLetterQuery{Topic: "order", Error: "timeout"}})
+	if err != nil || res.Affected != 1 {
+		t.Fatalf("BulkRetry = %+v, %v", res, err)
+	}
+	if repo.where != "topic = ? AND error LIKE ? AND status IN (?)" || repo.values["status"] != model.DeadLetterStatusPending || repo.values["attempts"] != 0 {
+		t.Fatalf("where = %q, values = %v", repo.where, repo.values)
+	}
+}
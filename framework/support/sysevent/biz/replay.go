@@ -0,0 +1,345 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/goroutine"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/eventstore"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/manager"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/event_err"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/service"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+const (
+	// 默认每秒投递条数
+	defaultReplayRate = 100
+	// 每次从事件存储读取的条数
+	replayBatchSize = 100
+	// 保存进度的间隔，同时检查是否被其他实例取消
+	replayFlushInterval = 2 * time.Second
+)
+
+var (
+	// 回放已被取消或被其他实例接管
+	errReplayCancelled = errors.New("replay cancelled or taken over by another instance")
+	// 回放超过租约时间未保存进度
+	errReplayLeaseExpired = errors.New("replay lease expired, the instance running it has exited")
+)
+
+type IEventReplayRepo interface {
+	baserepo.IBaseRepo[model.EventReplay, string]
+}
+
+// IEventStoreScanner 事件存储的读取接口
+type IEventStoreScanner interface {
+	Scan(ctx context.Context, q eventstore.Query, batchSize int, fn func(ctx context.Context, batch []*eventstore.Record) error) error
+}
+
+// EventReplayUseCase 将事件存储中的历史事件重新投递给指定的消费通道
+type EventReplayUseCase struct {
+	repo    IEventReplayRepo
+	sr      ISubscribeRepo
+	store   IEventStoreScanner
+	em      manager.EventManager
+	db      database.IDataBase
+	cancels sync.Map // 本实例运行中的回放 id -> context.CancelFunc
+}
+
+// NewEventReplayUseCase store 为 nil 表示未开启事件存储
+func NewEventReplayUseCase(repo IEventReplayRepo, sr ISubscribeRepo, store *eventstore.Store, em manager.EventManager, db database.IDataBase) service.IEventReplayServiceApi {
+	uc := &EventReplayUseCase{repo: repo, sr: sr, em: em, db: db}
+	if store != nil {
+		uc.store = store
+	}
+	return uc
+}
+
+func (r *EventReplayUseCase) Enabled() bool {
+	return r.store != nil
+}
+
+// Start 创建回放任务并在后台执行，同一通道同时只能有一个回放
+func (r *EventReplayUseCase) Start(ctx context.Context, req *dto.StartReplayReq) (string, herrors.Herr) {
+	if !r.Enabled() {
+		return "", event_err.EventStoreNotEnabled
+	}
+	ctx = getCtx(ctx)
+	sub, err := r.sr.GetByTopicAndGroup(ctx, req.Topic, req.Group)
+	if err != nil || sub == nil {
+		hlog.CtxErrorf(ctx, "replay get subscribe %s:%s failed: %v", req.Topic, req.Group, err)
+		return "", event_err.GetSubscribeFail(fmt.Errorf("subscribe %s:%s not found: %v", req.Topic, req.Group, err))
+	}
+	// 租约过期的回放所在实例已退出，不再阻塞该通道
+	now := utils.GetTimeNow().UnixMilli()
+	stale := db_query.NewQueryBuilder()
+	stale.Where("topic", db_query.Eq, req.Topic)
+	stale.Where("channel", db_query.Eq, req.Group)
+	stale.Where("status", db_query.Eq, model.ReplayStatusRunning)
+	stale.Where("updated_at", db_query.Lt, now-model.ReplayLeaseTimeout.Milliseconds())
+	if _, err = r.repo.UpdateWhere(ctx, stale, map[string]interface{}{
+		"status": model.ReplayStatusFailed,
+		"error":  errReplayLeaseExpired.Error(),
+	}); err != nil {
+		return "", event_err.ReplayFail(err)
+	}
+	qb := db_query.NewQueryBuilder()
+	qb.Where("topic", db_query.Eq, req.Topic)
+	qb.Where("channel", db_query.Eq, req.Group)
+	qb.Where("status", db_query.Eq, model.ReplayStatusRunning)
+	running, err := r.repo.Exists(ctx, qb)
+	if err != nil {
+		return "", event_err.ReplayFail(err)
+	}
+	if running {
+		return "", event_err.ReplayIsRunning
+	}
+	replay := &model.EventReplay{
+		BaseIntTime: database.BaseIntTime{UpdatedAt: now},
+		Id:          r.db.GenStringId(),
+		Topic:       req.Topic,
+		Channel:     req.Group,
+		FromTime:    req.FromTime * 1000,
+		ToTime:      req.ToTime * 1000,
+		FromId:      req.FromId,
+		ToId:        req.ToId,
+		Rate:        req.Rate,
+		Status:      model.ReplayStatusRunning,
+		Owner:       r.db.GenStringId(),
+	}
+	if replay.Rate <= 0 {
+		replay.Rate = defaultReplayRate
+	}
+	if _, err = r.repo.Add(ctx, replay); err != nil {
+		hlog.CtxErrorf(ctx, "add event replay failed: %v", err)
+		return "", event_err.ReplayFail(err)
+	}
+	r.launch(replay)
+	return replay.Id, nil
+}
+
+// Cancel 取消回放，其他实例上运行的回放在下次保存进度时停止
+func (r *EventReplayUseCase) Cancel(ctx context.Context, id string) herrors.Herr {
+	ctx = getCtx(ctx)
+	qb := db_query.NewQueryBuilder()
+	qb.Where("id", db_query.Eq, id)
+	qb.Where("status", db_query.Eq, model.ReplayStatusRunning)
+	affected, err := r.repo.UpdateWhere(ctx, qb, map[string]interface{}{
+		"status":     model.ReplayStatusCancelled,
+		"updated_at": utils.GetTimeNow().UnixMilli(),
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "cancel event replay %s failed: %v", id, err)
+		return event_err.ReplayFail(err)
+	}
+	if affected == 0 {
+		return event_err.ReplayNotRunning
+	}
+	if cancel, ok := r.cancels.Load(id); ok {
+		cancel.(context.CancelFunc)()
+	}
+	return nil
+}
+
+// Recover 接管租约过期的回放，从最后投递的记录继续；未开启事件存储时标记为失败
+// 服务启动时和定时任务中调用，返回接管的回放数量
+func (r *EventReplayUseCase) Recover(ctx context.Context) (int, error) {
+	ctx = getCtx(ctx)
+	qb := db_query.NewQueryBuilder()
+	qb.Where("status", db_query.Eq, model.ReplayStatusRunning)
+	qb.Where("updated_at", db_query.Lt, utils.GetTimeNow().UnixMilli()-model.ReplayLeaseTimeout.Milliseconds())
+	replays, err := r.repo.Find(ctx, qb)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, replay := range replays {
+		// 以更新时间作为版本号认领，多个实例同时接管时只有一个成功
+		claim := db_query.NewQueryBuilder()
+		claim.Where("id", db_query.Eq, replay.Id)
+		claim.Where("status", db_query.Eq, model.ReplayStatusRunning)
+		claim.Where("updated_at", db_query.Eq, replay.UpdatedAt)
+		owner := r.db.GenStringId()
+		values := map[string]interface{}{"updated_at": utils.GetTimeNow().UnixMilli(), "owner": owner}
+		if !r.Enabled() {
+			values["status"] = model.ReplayStatusFailed
+			values["error"] = errReplayLeaseExpired.Error()
+		}
+		affected, err := r.repo.UpdateWhere(ctx, claim, values)
+		if err != nil {
+			return n, err
+		}
+		if affected == 0 || !r.Enabled() {
+			continue
+		}
+		hlog.CtxInfof(ctx, "resume event replay %s from event %d", replay.Id, replay.LastId)
+		replay.Owner = owner
+		r.launch(replay)
+		n++
+	}
+	return n, nil
+}
+
+func (r *EventReplayUseCase) GetDetails(ctx context.Context, id string) (*dto.EventReplayModel, herrors.Herr) {
+	replay, err := r.repo.FindById(getCtx(ctx), id)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	return dto.ReplayToDto(replay), nil
+}
+
+func (r *EventReplayUseCase) GetList(ctx context.Context, req *dto.GetReplayListReq) (models.PageRes[dto.EventReplayModel], herrors.Herr) {
+	ctx = getCtx(ctx)
+	qb := db_query.NewQueryBuilder()
+	if req.Topic != "" {
+		qb.Where("topic", db_query.Eq, req.Topic)
+	}
+	if req.Group != "" {
+		qb.Where("channel", db_query.Eq, req.Group)
+	}
+	if req.Status > 0 {
+		qb.Where("status", db_query.Eq, req.Status)
+	}
+	qb.OrderBy("created_at", false)
+	qb.WithPage(&req.Page)
+	res := models.PageRes[dto.EventReplayModel]{}
+	total, err := r.repo.Count(ctx, qb)
+	if err != nil {
+		return res, herrors.QueryFail(err)
+	}
+	replays, err := r.repo.Find(ctx, qb)
+	if err != nil {
+		return res, herrors.QueryFail(err)
+	}
+	list := make([]*dto.EventReplayModel, 0, len(replays))
+	for _, replay := range replays {
+		list = append(list, dto.ReplayToDto(replay))
+	}
+	res.Total = total
+	res.List = list
+	return res, nil
+}
+
+// 后台执行回放，不受请求上下文取消的影响
+func (r *EventReplayUseCase) launch(replay *model.EventReplay) {
+	ctx, cancel := context.WithCancel(getCtx(context.Background()))
+	r.cancels.Store(replay.Id, cancel)
+	goroutine.SecureGo(ctx, func(ctx context.Context) {
+		defer func() {
+			cancel()
+			r.cancels.Delete(replay.Id)
+		}()
+		r.finish(replay, r.run(ctx, replay))
+	})
+}
+
+// run 按限速逐条投递，定期保存进度
+func (r *EventReplayUseCase) run(ctx context.Context, replay *model.EventReplay) error {
+	limiter := rate.NewLimiter(rate.Limit(replay.Rate), 1)
+	q := eventstore.Query{
+		Topic:    replay.Topic,
+		FromTime: replay.FromTime,
+		ToTime:   replay.ToTime,
+		AfterID:  replay.LastId,
+		ToID:     replay.ToId,
+	}
+	if q.AfterID == 0 && replay.FromId > 0 {
+		q.AfterID = replay.FromId - 1
+	}
+	lastFlush := time.Now()
+	return r.store.Scan(ctx, q, replayBatchSize, func(ctx context.Context, batch []*eventstore.Record) error {
+		for _, record := range batch {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			if err := r.deliver(ctx, record, replay.Channel); err != nil {
+				hlog.CtxWarnf(ctx, "replay %s deliver event %d failed: %v", replay.Id, record.ID, err)
+				replay.Failed++
+				replay.Error = fmt.Sprintf("event %d: %v", record.ID, err)
+			}
+			replay.Processed++
+			replay.LastId = record.ID
+			if time.Since(lastFlush) >= replayFlushInterval {
+				if err := r.flush(ctx, replay); err != nil {
+					return err
+				}
+				lastFlush = time.Now()
+			}
+		}
+		return nil
+	})
+}
+
+func (r *EventReplayUseCase) deliver(ctx context.Context, record *eventstore.Record, channel string) error {
+	msg, err := record.ToMessage()
+	if err != nil {
+		return err
+	}
+	event, err := mqevent.DecodeMessage(mqevent.DefaultRegistry, msg)
+	if err != nil {
+		return err
+	}
+	_, err = r.em.DeliverTo(ctx, event, channel)
+	return err
+}
+
+// flush 保存进度，回放已不在进行中或已被其他实例接管时返回 errReplayCancelled
+func (r *EventReplayUseCase) flush(ctx context.Context, replay *model.EventReplay) error {
+	qb := db_query.NewQueryBuilder()
+	qb.Where("id", db_query.Eq, replay.Id)
+	qb.Where("owner", db_query.Eq, replay.Owner)
+	qb.Where("status", db_query.Eq, model.ReplayStatusRunning)
+	affected, err := r.repo.UpdateWhere(ctx, qb, progressValues(replay))
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errReplayCancelled
+	}
+	return nil
+}
+
+// finish 保存最终进度和状态，已取消的回放只更新进度，已被其他实例接管时不更新
+func (r *EventReplayUseCase) finish(replay *model.EventReplay, runErr error) {
+	ctx := getCtx(context.Background())
+	values := progressValues(replay)
+	qb := db_query.NewQueryBuilder()
+	qb.Where("id", db_query.Eq, replay.Id)
+	qb.Where("owner", db_query.Eq, replay.Owner)
+	switch {
+	case runErr == nil:
+		values["status"] = model.ReplayStatusCompleted
+		qb.Where("status", db_query.Eq, model.ReplayStatusRunning)
+	case errors.Is(runErr, errReplayCancelled), errors.Is(runErr, context.Canceled):
+	default:
+		values["status"] = model.ReplayStatusFailed
+		values["error"] = runErr.Error()
+		qb.Where("status", db_query.Eq, model.ReplayStatusRunning)
+	}
+	if _, err := r.repo.UpdateWhere(ctx, qb, values); err != nil {
+		hlog.CtxErrorf(ctx, "save event replay %s result failed: %v", replay.Id, err)
+	}
+}
+
+func progressValues(replay *model.EventReplay) map[string]interface{} {
+	return map[string]interface{}{
+		"processed":  replay.Processed,
+		"failed":     replay.Failed,
+		"last_id":    replay.LastId,
+		"error":      replay.Error,
+		"updated_at": utils.GetTimeNow().UnixMilli(),
+	}
+}
@@ -0,0 +1,256 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/eventstore"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent/manager"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/event_err"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+)
+
+type fakeIDGen struct {
+	database.IDataBase
+}
+
+func (fakeIDGen) GenStringId() string {
+	return "r1"
+}
+
+type replayRepo struct {
+	IEventReplayRepo
+	mu      sync.Mutex
+	status  int8
+	owner   string
+	values  map[string]interface{}
+	history []map[string]interface{}
+	stale   []*model.EventReplay
+}
+
+func (r *replayRepo) Find(ctx context.Context, qb *db_query.QueryBuilder) ([]*model.EventReplay, error) {
+	return r.stale, nil
+}
+
+func (r *replayRepo) Exists(ctx context.Context, qb *db_query.QueryBuilder) (bool, error) {
+	return false, nil
+}
+
+func (r *replayRepo) Add(ctx context.Context, data *model.EventReplay) (*model.EventReplay, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = data.Status
+	r.owner = data.Owner
+	return data, nil
+}
+
+func (r *replayRepo) UpdateWhere(ctx context.Context, qb *db_query.QueryBuilder, values map[string]interface{}) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// 带状态或租约条件的更新只在匹配时生效
+	for _, c := range qb.GetConditions() {
+		if c.Field == "status" && c.Value != r.status || c.Field == "owner" && c.Value != r.owner {
+			return 0, nil
+		}
+	}
+	if s, ok := values["status"].(int8); ok {
+		r.status = s
+	}
+	if owner, ok := values["owner"].(string); ok {
+		r.owner = owner
+	}
+	r.values = values
+	r.history = append(r.history, values)
+	return 1, nil
+}
+
+func (r *replayRepo) result() (int8, map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status, r.values
+}
+
+type replaySubscribeRepo struct {
+	ISubscribeRepo
+}
+
+func (replaySubscribeRepo) GetByTopicAndGroup(ctx context.Context, topic, group string) (*model.Subscribe, error) {
+	return &model.Subscribe{Topic: topic, Group: group}, nil
+}
+
+type replayStore struct {
+	records []*eventstore.Record
+	query   eventstore.Query
+}
+
+func (s *replayStore) Scan(ctx context.Context, q eventstore.Query, batchSize int, fn func(ctx context.Context, batch []*eventstore.Record) error) error {
+	s.query = q
+	return fn(ctx, s.records)
+}
+
+type replayEventManager struct {
+	manager.EventManager
+	mu        sync.Mutex
+	delivered []string
+	block     chan struct{}
+}
+
+func (m *replayEventManager) DeliverTo(ctx context.Context, event mqevent.Event, channel string) ([]byte, error) {
+	if m.block != nil {
+		<-m.block
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivered = append(m.delivered, channel+"/"+event.GetID())
+	if event.GetID() == "e2" {
+		return nil, errors.New("handler failed")
+	}
+	return nil, nil
+}
+
+func waitReplay(t *testing.T, repo *replayRepo, status int8) map[string]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s, values := repo.result(); s == status && values != nil {
+			return values
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s, values := repo.result()
+	t.Fatalf("replay status = %d, values = %v, want %d", s, values, status)
+	return nil
+}
+
+func TestEventReplay_Start(t *testing.T) {
+	record := func(id int64, eventID string) *eventstore.Record {
+		return &eventstore.Record{ID: id, EventID: eventID, Topic: "order", Payload: `{}`,
+			Headers: `{"event_id":"` + eventID + `","event_type":"order"}`}
+	}
+	store := &replayStore{records: []*eventstore.Record{record(11, "e1"), record(12, "e2"), record(13, "e3")}}
+	repo := &replayRepo{}
+	em := &replayEventManager{}
+	uc := &EventReplayUseCase{repo: repo, sr: replaySubscribeRepo{}, store: store, em: em, db: fakeIDGen{}}
+
+	if _, err := uc.Start(context.Background(), &dto.StartReplayReq{Topic: "order", Group: "billing", FromId: 11, Rate: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	values := waitReplay(t, repo, model.ReplayStatusCompleted)
+	if values["processed"] != int64(3) || values["failed"] != int64(1) || values["last_id"] != int64(13) {
+		t.Fatalf("progress = %v", values)
+	}
+	if store.query.AfterID != 10 || store.query.Topic != "order" {
+		t.Fatalf("query = %+v", store.query)
+	}
+	if len(em.delivered) != 3 || em.delivered[0] != "billing/e1" {
+		t.Fatalf("delivered = %v", em.delivered)
+	}
+}
+
+func TestEventReplay_Cancel(t *testing.T) {
+	store := &replayStore{records: []*eventstore.Record{{ID: 1, Topic: "order", Headers: `{"event_id":"e1"}`}}}
+	repo := &replayRepo{}
+	em := &replayEventManager{block: make(chan struct{})}
+	uc := &EventReplayUseCase{repo: repo, sr: replaySubscribeRepo{}, store: store, em: em, db: fakeIDGen{}}
+
+	id, err := uc.Start(context.Background(), &dto.StartReplayReq{Topic: "order", Group: "billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.Cancel(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	close(em.block)
+	waitReplay(t, repo, model.ReplayStatusCancelled)
+	if err := uc.Cancel(context.Background(), id); err != event_err.ReplayNotRunning {
+		t.Fatalf("cancel finished replay = %v", err)
+	}
+}
+
+func TestEventReplay_StoreDisabled(t *testing.T) {
+	uc := NewEventReplayUseCase(&replayRepo{}, replaySubscribeRepo{}, nil, &replayEventManager{}, nil)
+	if uc.Enabled() {
+		t.Fatal("replay must be disabled without event store")
+	}
+	if _, err := uc.Start(context.Background(), &dto.StartReplayReq{Topic: "order", Group: "billing"}); err != event_err.EventStoreNotEnabled {
+		t.Fatalf("Start = %v", err)
+	}
+}
+
+func TestEventReplay_StaleLease(t *testing.T) {
+	store := &replayStore{records: []*eventstore.Record{{ID: 13, EventID: "e3", Topic: "order", Payload: `{}`,
+		Headers: `{"event_id":"e3","event_type":"order"}`}}}
+	em := &replayEventManager{}
+
+	// 租约过期的回放不阻塞新的回放，先标记为失败
+	repo := &replayRepo{status: model.ReplayStatusRunning}
+	uc := &EventReplayUseCase{repo: repo, sr: replaySubscribeRepo{}, store: store, em: em, db: fakeIDGen{}}
+	if _, err := uc.Start(context.Background(), &dto.StartReplayReq{Topic: "order", Group: "billing", Rate: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	waitReplay(t, repo, model.ReplayStatusCompleted)
+	if first := repo.history[0]; first["status"] != model.ReplayStatusFailed || first["error"] != errReplayLeaseExpired.Error() {
+		t.Fatalf("stale replay update = %v", first)
+	}
+
+	// 接管的回放从最后投递的记录继续
+	stale := &model.EventReplay{Id: "r0", Topic: "order", Channel: "billing", Rate: 1000, LastId: 12, Processed: 2,
+		Status: model.ReplayStatusRunning}
+	repo = &replayRepo{status: model.ReplayStatusRunning, stale: []*model.EventReplay{stale}}
+	uc = &EventReplayUseCase{repo: repo, sr: replaySubscribeRepo{}, store: store, em: em, db: fakeIDGen{}}
+	n, err := uc.Recover(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("recover = %d, %v", n, err)
+	}
+	values := waitReplay(t, repo, model.ReplayStatusCompleted)
+	if store.query.AfterID != 12 || values["processed"] != int64(3) || values["last_id"] != int64(13) {
+		t.Fatalf("query = %+v, progress = %v", store.query, values)
+	}
+
+	// 未开启事件存储时无法继续，标记为失败
+	repo = &replayRepo{status: model.ReplayStatusRunning, stale: []*model.EventReplay{{Id: "r0", Status: model.ReplayStatusRunning}}}
+	uc = &EventReplayUseCase{repo: repo, sr: replaySubscribeRepo{}, em: em, db: fakeIDGen{}}
+	if n, err = uc.Recover(context.Background()); err != nil || n != 0 {
+		t.Fatalf("recover without store = %d, %v", n, err)
+	}
+	if status, _ := repo.result(); status != model.ReplayStatusFailed {
+		t.Fatalf("status = %d", status)
+	}
+}
+
+func TestEventReplay_TakenOver(t *testing.T) {
+	store := &replayStore{records: []*eventstore.Record{{ID: 13, EventID: "e3", Topic: "order", Payload: `{}`,
+		Headers: `{"event_id":"e3","event_type":"order"}`}}}
+	stale := &model.EventReplay{Id: "r0", Topic: "order", Channel: "billing", Rate: 1000, LastId: 12,
+		Status: model.ReplayStatusRunning, Owner: "old"}
+	repo := &replayRepo{status: model.ReplayStatusRunning, owner: "old", stale: []*model.EventReplay{stale}}
+	uc := &EventReplayUseCase{repo: repo, sr: replaySubscribeRepo{}, store: store, em: &replayEventManager{}, db: fakeIDGen{}}
+
+	// 原实例仍持有接管前的租约标识
+	old := *stale
+	if n, err := uc.Recover(context.Background()); err != nil || n != 1 {
+		t.Fatalf("recover = %d, %v", n, err)
+	}
+	waitReplay(t, repo, model.ReplayStatusCompleted)
+	if repo.owner != "r1" {
+		t.Fatalf("owner = %q", repo.owner)
+	}
+
+	// 原实例保存进度时发现已被接管，停止投递且不覆盖新实例的结果
+	repo.status = model.ReplayStatusRunning
+	if err := uc.flush(context.Background(), &old); !errors.Is(err, errReplayCancelled) {
+		t.Fatalf("flush by old owner = %v", err)
+	}
+	updates := len(repo.history)
+	uc.finish(&old, nil)
+	if len(repo.history) != updates || repo.status != model.ReplayStatusRunning {
+		t.Fatalf("finish by old owner updated the replay: %v", repo.values)
+	}
+}
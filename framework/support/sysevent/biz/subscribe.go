@@ -40,11 +40,12 @@ type SubscribeUseCase struct {
 	db    database.IDataBase
 	sm    manager.EventManager
 	rc    *rockscache.Client
+	rp    service.IEventReplayServiceApi
 }
 
 func NewSubscribeUseCase(repo ISubscribeRepo, db database.IDataBase, par ISubscribeParameterRepo, sm manager.EventManager,
-	drepo IDeadLetterSubscribeRepo, rc *rockscache.Client) service.ISubscribeServerApi {
-	return &SubscribeUseCase{repo: repo, db: db, par: par, sm: sm, drepo: drepo, rc: rc}
+	drepo IDeadLetterSubscribeRepo, rc *rockscache.Client, rp service.IEventReplayServiceApi) service.ISubscribeServerApi {
+	return &SubscribeUseCase{repo: repo, db: db, par: par, sm: sm, drepo: drepo, rc: rc, rp: rp}
 }
 
 func (s SubscribeUseCase) Add(ctx context.Context, req *dto.AddSubscribeReq) herrors.Herr {
@@ -54,6 +55,12 @@ func (s SubscribeUseCase) Add(ctx context.Context, req *dto.AddSubscribeReq) her
 		Topic: req.Topic,
 		Group: req.Group,
 		Dis:   req.Dis,
+
+		RetryMaxAttempts: req.RetryMaxAttempts,
+		RetryInterval:    req.RetryInterval,
+		RetryMaxInterval: req.RetryMaxInterval,
+		RetryMultiplier:  req.RetryMultiplier,
+		RetrySchedule:    req.RetrySchedule,
 	}
 	subscribe, err1 := s.repo.GetByTopicAndGroup(ctx, req.Topic, req.Topic)
 	if err1 != nil && !database.IfErrorNotFound(err1) {
@@ -128,6 +135,21 @@ func (s SubscribeUseCase) Update(ctx context.Context, req *dto.UpdateSubscribeRe
 	if req.Dis != "" {
 		sub.Dis = req.Dis
 	}
+	if req.RetryMaxAttempts != 0 {
+		sub.RetryMaxAttempts = req.RetryMaxAttempts
+	}
+	if req.RetryInterval != 0 {
+		sub.RetryInterval = req.RetryInterval
+	}
+	if req.RetryMaxInterval != 0 {
+		sub.RetryMaxInterval = req.RetryMaxInterval
+	}
+	if req.RetryMultiplier != 0 {
+		sub.RetryMultiplier = req.RetryMultiplier
+	}
+	if req.RetrySchedule != "" {
+		sub.RetrySchedule = req.RetrySchedule
+	}
 	if err = s.db.InTx(ctx, func(ctx1 context.Context) error {
 		if err = s.repo.EditById(ctx, sub); err != nil {
 			hlog.CtxErrorf(ctx, "edit subscribe fiels tx err: %v", err)
@@ -218,6 +240,17 @@ func (s SubscribeUseCase) Enable(ctx context.Context, id string, ignoringHistory
 		hlog.CtxErrorf(ctx, "Enable subscribe err: %v", err)
 		return event_err.EditSubscribeFail(err)
 	}
+	// 不忽略历史消息时回放停用期间发布的事件
+	if ignoringHistory == 0 && sub.End > 0 && s.rp != nil && s.rp.Enabled() {
+		if _, hr := s.rp.Start(ctx, &dto.StartReplayReq{
+			Topic:    sub.Topic,
+			Group:    sub.Group,
+			FromTime: sub.End,
+			ToTime:   sub.Start,
+		}); herrors.HaveError(hr) {
+			hlog.CtxErrorf(ctx, "replay history of subscribe %s err: %v", sub.Id, hr)
+		}
+	}
 	return nil
 }
 
@@ -228,7 +261,7 @@ func (s SubscribeUseCase) Disable(ctx context.Context, id string) herrors.Herr {
 		return event_err.GetSubscribeFail(err)
 	}
 	sub.Status = int8(manager.StatusDisable)
-	sub.Start = utils.GetDateUnix()
+	sub.End = utils.GetDateUnix()
 	if err = s.db.InTx(ctx, func(ctx context.Context) error {
 		if err = s.repo.EditById(ctx, sub); err != nil {
 			hlog.CtxErrorf(ctx, "update subscribe status err: %v", err)
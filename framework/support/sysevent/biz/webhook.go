@@ -0,0 +1,307 @@
+package biz
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/pkg/random"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/event_err"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/service"
+	"time"
+)
+
+// WebhookChannel Webhook 在事件总线上使用的消费通道，多实例部署时每个事件只由一个实例投递
+const WebhookChannel = "sys_webhook"
+
+// 自动生成的签名密钥长度
+const webhookSecretLength = 32
+
+type IWebhookRepo interface {
+	baserepo.IBaseRepo[model.Webhook, string]
+	// FindEnabled 获取租户可接收事件的启用的 Webhook，包含未指定租户的
+	FindEnabled(ctx context.Context, tenantID string) ([]*model.Webhook, error)
+	// GetAllTopics 获取启用的 Webhook 订阅的全部主题
+	GetAllTopics(ctx context.Context) ([]string, error)
+}
+
+type IWebhookDeliveryRepo interface {
+	baserepo.IBaseRepo[model.WebhookDelivery, string]
+	// AddIfAbsent 同一事件已存在投递记录时返回 false
+	AddIfAbsent(ctx context.Context, delivery *model.WebhookDelivery) (bool, error)
+	// FindDue 获取到达投递时间的待投递记录
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error)
+}
+
+// WebhookUseCase 将订阅主题的事件签名后投递到租户注册的回调地址
+type WebhookUseCase struct {
+	repo   IWebhookRepo
+	drepo  IWebhookDeliveryRepo
+	ebs    mqevent.IMQEventBus
+	db     database.IDataBase
+	client *http.Client
+	// 已在事件总线上订阅的主题
+	subscribed sync.Map
+}
+
+// NewWebhookUseCase 创建时订阅启用的 Webhook 的全部主题
+func NewWebhookUseCase(repo IWebhookRepo, drepo IWebhookDeliveryRepo, ebs mqevent.IMQEventBus, db database.IDataBase) service.IWebhookServiceApi {
+	w := &WebhookUseCase{
+		repo:   repo,
+		drepo:  drepo,
+		ebs:    ebs,
+		db:     db,
+		client: newWebhookClient(false),
+	}
+	ctx := getCtx(context.Background())
+	topics, err := repo.GetAllTopics(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "load webhook topics failed: %v", err)
+	}
+	w.subscribe(ctx, topics)
+	return w
+}
+
+func (w *WebhookUseCase) Add(ctx context.Context, req *dto.AddWebhookReq) (*dto.WebhookModel, herrors.Herr) {
+	topics, herr := checkWebhookReq(req)
+	if herr != nil {
+		return nil, herr
+	}
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = random.GenerateRandomAlphaNumericString(webhookSecretLength); err != nil {
+			return nil, event_err.WebhookOperateFail(err)
+		}
+	}
+	vo := &model.Webhook{
+		Id:               w.db.GenStringId(),
+		Name:             req.Name,
+		Url:              req.Url,
+		Secret:           secret,
+		Topics:           topics,
+		Timeout:          req.Timeout,
+		MaxAttempts:      req.MaxAttempts,
+		FailureThreshold: req.FailureThreshold,
+		Status:           model.WebhookStatusEnabled,
+		Description:      req.Description,
+	}
+	if _, err := w.repo.Add(ctx, vo); err != nil {
+		hlog.CtxErrorf(ctx, "add webhook failed: %v", err)
+		return nil, event_err.WebhookOperateFail(err)
+	}
+	w.subscribe(ctx, vo.TopicList())
+	return dto.WebhookToDto(vo, true), nil
+}
+
+func (w *WebhookUseCase) Update(ctx context.Context, req *dto.UpdateWebhookReq) herrors.Herr {
+	topics, herr := checkWebhookReq(&req.AddWebhookReq)
+	if herr != nil {
+		return herr
+	}
+	if _, herr = w.get(ctx, req.Id); herr != nil {
+		return herr
+	}
+	values := map[string]interface{}{
+		"name":              req.Name,
+		"url":               req.Url,
+		"topics":            topics,
+		"timeout":           req.Timeout,
+		"max_attempts":      req.MaxAttempts,
+		"failure_threshold": req.FailureThreshold,
+		"description":       req.Description,
+		"updated_at":        utils.GetDateUnixMilli(),
+	}
+	if req.Secret != "" {
+		// 加密字段通过模型更新才会经过序列化器
+		if err := w.repo.EditById(ctx, &model.Webhook{Id: req.Id, Secret: req.Secret}); err != nil {
+			return event_err.WebhookOperateFail(err)
+		}
+	}
+	if _, err := w.repo.UpdateWhere(ctx, idQuery(req.Id), values); err != nil {
+		hlog.CtxErrorf(ctx, "update webhook failed: %v", err)
+		return event_err.WebhookOperateFail(err)
+	}
+	w.subscribe(ctx, strings.Split(topics, ","))
+	return nil
+}
+
+func (w *WebhookUseCase) Delete(ctx context.Context, id string) herrors.Herr {
+	if _, herr := w.get(ctx, id); herr != nil {
+		return herr
+	}
+	if err := w.repo.DelById(ctx, id); err != nil {
+		hlog.CtxErrorf(ctx, "delete webhook failed: %v", err)
+		return event_err.WebhookOperateFail(err)
+	}
+	return nil
+}
+
+func (w *WebhookUseCase) GetDetails(ctx context.Context, id string) (*dto.WebhookModel, herrors.Herr) {
+	hook, herr := w.get(ctx, id)
+	if herr != nil {
+		return nil, herr
+	}
+	return dto.WebhookToDto(hook, true), nil
+}
+
+func (w *WebhookUseCase) GetList(ctx context.Context, req *dto.GetWebhookListReq) (models.PageRes[dto.WebhookModel], herrors.Herr) {
+	qb := db_query.NewQueryBuilder()
+	if req.Name != "" {
+		qb.WhereLike("name", req.Name)
+	}
+	if req.Topic != "" {
+		qb.WhereLike("topics", req.Topic)
+	}
+	if req.Status > 0 {
+		qb.Where("status", db_query.Eq, req.Status)
+	}
+	qb.OrderBy("created_at", false)
+	qb.WithPage(&req.Page)
+	res := models.PageRes[dto.WebhookModel]{}
+	total, err := w.repo.Count(ctx, qb)
+	if err != nil {
+		return res, herrors.QueryFail(err)
+	}
+	hooks, err := w.repo.Find(ctx, qb)
+	if err != nil {
+		return res, herrors.QueryFail(err)
+	}
+	list := make([]*dto.WebhookModel, 0, len(hooks))
+	for _, hook := range hooks {
+		list = append(list, dto.WebhookToDto(hook, false))
+	}
+	res.Total = total
+	res.List = list
+	return res, nil
+}
+
+func (w *WebhookUseCase) Enable(ctx context.Context, id string) herrors.Herr {
+	hook, herr := w.get(ctx, id)
+	if herr != nil {
+		return herr
+	}
+	if _, err := w.repo.UpdateWhere(ctx, idQuery(id), map[string]interface{}{
+		"status":               model.WebhookStatusEnabled,
+		"consecutive_failures": 0,
+		"disabled_reason":      "",
+		"updated_at":           utils.GetDateUnixMilli(),
+	}); err != nil {
+		hlog.CtxErrorf(ctx, "enable webhook failed: %v", err)
+		return event_err.WebhookOperateFail(err)
+	}
+	w.subscribe(ctx, hook.TopicList())
+	return nil
+}
+
+func (w *WebhookUseCase) Disable(ctx context.Context, id string) herrors.Herr {
+	if _, herr := w.get(ctx, id); herr != nil {
+		return herr
+	}
+	if _, err := w.repo.UpdateWhere(ctx, idQuery(id), map[string]interface{}{
+		"status":     model.WebhookStatusDisabled,
+		"updated_at": utils.GetDateUnixMilli(),
+	}); err != nil {
+		hlog.CtxErrorf(ctx, "disable webhook failed: %v", err)
+		return event_err.WebhookOperateFail(err)
+	}
+	return nil
+}
+
+func (w *WebhookUseCase) GetDeliveryList(ctx context.Context, req *dto.GetWebhookDeliveryListReq) (models.PageRes[dto.WebhookDeliveryModel], herrors.Herr) {
+	qb := db_query.NewQueryBuilder()
+	if req.WebhookId != "" {
+		qb.Where("webhook_id", db_query.Eq, req.WebhookId)
+	}
+	if req.Topic != "" {
+		qb.Where("topic", db_query.Eq, req.Topic)
+	}
+	if req.EventId != "" {
+		qb.Where("event_id", db_query.Eq, req.EventId)
+	}
+	if req.Status > 0 {
+		qb.Where("status", db_query.Eq, req.Status)
+	}
+	qb.OrderBy("created_at", false)
+	qb.WithPage(&req.Page)
+	res := models.PageRes[dto.WebhookDeliveryModel]{}
+	total, err := w.drepo.Count(ctx, qb)
+	if err != nil {
+		return res, herrors.QueryFail(err)
+	}
+	deliveries, err := w.drepo.Find(ctx, qb)
+	if err != nil {
+		return res, herrors.QueryFail(err)
+	}
+	list := make([]*dto.WebhookDeliveryModel, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		list = append(list, dto.WebhookDeliveryToDto(delivery))
+	}
+	res.Total = total
+	res.List = list
+	return res, nil
+}
+
+// get 按当前租户获取 Webhook
+func (w *WebhookUseCase) get(ctx context.Context, id string) (*model.Webhook, herrors.Herr) {
+	hook, err := w.repo.FindById(ctx, id)
+	if err != nil {
+		if database.IfErrorNotFound(err) {
+			return nil, event_err.WebhookNotExist
+		}
+		return nil, herrors.QueryFail(err)
+	}
+	return hook, nil
+}
+
+// subscribe 在事件总线上订阅尚未订阅的主题
+func (w *WebhookUseCase) subscribe(ctx context.Context, topics []string) {
+	for _, topic := range topics {
+		if topic == "" {
+			continue
+		}
+		if _, loaded := w.subscribed.LoadOrStore(topic, true); loaded {
+			continue
+		}
+		if _, err := w.ebs.Subscribe(topic, WebhookChannel, mqevent.EventHandlerFunc(w.onEvent)); err != nil {
+			w.subscribed.Delete(topic)
+			hlog.CtxErrorf(ctx, "subscribe webhook topic %s failed: %v", topic, err)
+		}
+	}
+}
+
+// checkWebhookReq 校验回调地址和主题，返回逗号拼接的主题
+func checkWebhookReq(req *dto.AddWebhookReq) (string, herrors.Herr) {
+	u, err := url.Parse(req.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || isDeniedWebhookHost(u.Hostname()) {
+		return "", event_err.WebhookUrlInvalid
+	}
+	topics := make([]string, 0, len(req.Topics))
+	for _, topic := range req.Topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	if len(topics) == 0 {
+		return "", event_err.WebhookTopicsIsEmpty
+	}
+	return strings.Join(topics, ","), nil
+}
+
+func idQuery(id string) *db_query.QueryBuilder {
+	qb := db_query.NewQueryBuilder()
+	qb.Where("id", db_query.Eq, id)
+	return qb
+}
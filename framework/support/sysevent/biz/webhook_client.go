@@ -0,0 +1,90 @@
+package biz
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrWebhookAddressDenied 回调地址解析到内网、回环等地址
+	ErrWebhookAddressDenied = errors.New("webhook address is not allowed")
+	// ErrWebhookRedirect 回调地址返回重定向
+	ErrWebhookRedirect = errors.New("webhook redirect is not allowed")
+)
+
+// 除 netip 判断的类别外禁止访问的地址段
+var deniedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"), // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),   // 保留
+}
+
+// newWebhookClient 创建投递 Webhook 的客户端，allowPrivate 为 false 时在 DNS 解析后的连接阶段拒绝内网地址，防止通过回调地址访问内网服务
+// 不使用环境变量中的代理，代理会绕过连接地址的检查；拒绝重定向，避免跳转到内网地址
+func newWebhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = denyPrivateAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return fmt.Errorf("%w: %s", ErrWebhookRedirect, req.URL.Redacted())
+		},
+	}
+}
+
+// denyPrivateAddress 连接前检查解析后的地址
+func denyPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddress(addr) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressDenied, addr)
+	}
+	return nil
+}
+
+// isPublicAddress 是否为公网单播地址
+func isPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range deniedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDeniedWebhookHost 注册时检查回调地址的主机，域名在投递连接时检查解析结果
+func isDeniedWebhookHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return !isPublicAddress(addr)
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/event_err"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	cases := map[string]bool{
+		"8.8.8.8":          true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::":               false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+		"224.0.0.1":        false,
+	}
+	for ip, want := range cases {
+		if got := isPublicAddress(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("%s: public = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestCheckWebhookReq_DeniedHost(t *testing.T) {
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://api.localhost/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"ftp://example.com/hook",
+	} {
+		if _, err := checkWebhookReq(&dto.AddWebhookReq{Url: u, Topics: []string{"order"}}); err != event_err.WebhookUrlInvalid {
+			t.Errorf("%s: err = %v", u, err)
+		}
+	}
+	if _, err := checkWebhookReq(&dto.AddWebhookReq{Url: "https://example.com/hook", Topics: []string{"order"}}); err != nil {
+		t.Fatalf("public url: %v", err)
+	}
+}
+
+func TestWebhookClient_DenyPrivateAndRedirect(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer redirect.Close()
+
+	// 解析后的回环地址在连接阶段被拒绝
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, target.URL, nil)
+	if _, err := newWebhookClient(false).Do(req); !errors.Is(err, ErrWebhookAddressDenied) {
+		t.Fatalf("private address: %v", err)
+	}
+
+	// 不跟随重定向
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodPost, redirect.URL, nil)
+	if _, err := newWebhookClient(true).Do(req); !errors.Is(err, ErrWebhookRedirect) {
+		t.Fatalf("redirect: %v", err)
+	}
+}
+
+func TestWebhookResponseBodyTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 10*maxWebhookResponseBody)))
+	}))
+	defer srv.Close()
+
+	hook := &model.Webhook{Id: "w1", Url: srv.URL, Secret: "secret", Status: model.WebhookStatusEnabled}
+	uc, _, _ := newTestWebhookUseCase(hook)
+	delivery := &model.WebhookDelivery{Id: "d1", WebhookId: "w1", Payload: "{}"}
+	if err := uc.attempt(context.Background(), hook, delivery); err != nil {
+		t.Fatal(err)
+	}
+	if len(delivery.ResponseBody) != maxWebhookResponseBody {
+		t.Fatalf("saved response body length = %d", len(delivery.ResponseBody))
+	}
+}
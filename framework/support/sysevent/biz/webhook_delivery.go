@@ -0,0 +1,326 @@
+package biz
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"gorm.io/gorm"
+)
+
+// Webhook 请求头
+const (
+	HeaderWebhookId        = "X-Webhook-Id"        // 投递记录ID，重新投递时不变
+	HeaderWebhookEvent     = "X-Webhook-Event"     // 事件主题
+	HeaderWebhookEventId   = "X-Webhook-Event-Id"  // 事件ID，接收方可用于去重
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp" // 签名时间（秒）
+	HeaderWebhookSignature = "X-Webhook-Signature" // 签名，格式 sha256=<hex>
+)
+
+const (
+	// 投递中的记录在该时间内不会被定时任务重复投递
+	webhookClaimLease = 5 * time.Minute
+	// 保存的响应内容最大长度，只用于排查问题，不保存完整响应
+	maxWebhookResponseBody = 256
+	webhookSignaturePrefix = "sha256="
+)
+
+// ErrWebhookSignature 签名校验失败
+var ErrWebhookSignature = errors.New("webhook signature mismatch")
+
+// WebhookPayload 投递的请求体
+type WebhookPayload struct {
+	Id        string      `json:"id"`                 // 事件ID
+	Topic     string      `json:"topic"`              // 事件主题
+	TenantId  string      `json:"tenantId,omitempty"` // 租户ID
+	Timestamp int64       `json:"timestamp"`          // 事件发生时间（毫秒）
+	Data      interface{} `json:"data"`               // 事件数据
+}
+
+// SignWebhook 计算签名 hex(HMAC-SHA256(secret, timestamp + "." + body))
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook 接收方校验签名，签名时间与当前时间相差超过 tolerance 时拒绝，防止重放
+func VerifyWebhook(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp := header.Get(HeaderWebhookTimestamp)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %q", ErrWebhookSignature, timestamp)
+	}
+	if tolerance > 0 {
+		if diff := time.Since(time.Unix(sec, 0)); diff > tolerance || diff < -tolerance {
+			return fmt.Errorf("%w: timestamp out of tolerance", ErrWebhookSignature)
+		}
+	}
+	if !hmac.Equal([]byte(header.Get(HeaderWebhookSignature)), []byte(SignWebhook(secret, timestamp, body))) {
+		return ErrWebhookSignature
+	}
+	return nil
+}
+
+// onEvent 为订阅了事件主题的 Webhook 创建待投递记录，由定时任务投递，失败后按退避策略重试
+// 消费事件时不发送请求，接收方响应慢不会阻塞消息消费
+func (w *WebhookUseCase) onEvent(ctx context.Context, event mqevent.Event) error {
+	ctx = getCtx(ctx)
+	hooks, err := w.repo.FindEnabled(ctx, event.GetTenantID())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "find webhooks for %s failed: %v", event.GetType(), err)
+		return err
+	}
+	var body []byte
+	for _, hook := range hooks {
+		if !hook.HasTopic(event.GetType()) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(WebhookPayload{
+				Id:        event.GetID(),
+				Topic:     event.GetType(),
+				TenantId:  event.GetTenantID(),
+				Timestamp: event.GetTimestamp().UnixMilli(),
+				Data:      event.GetData(),
+			}); err != nil {
+				return fmt.Errorf("marshal webhook payload: %w", err)
+			}
+		}
+		delivery := &model.WebhookDelivery{
+			BaseIntTime: database.BaseIntTime{CreatedAt: utils.GetDateUnixMilli()},
+			Id:          w.db.GenStringId(),
+			WebhookId:   hook.Id,
+			EventId:     event.GetID(),
+			Topic:       event.GetType(),
+			Payload:     string(body),
+			Status:      model.WebhookDeliveryPending,
+			NextRetry:   time.Now(),
+			TenantID:    hook.TenantID,
+		}
+		// 事件重复投递时已有投递记录，不会重复创建
+		if _, err = w.drepo.AddIfAbsent(ctx, delivery); err != nil {
+			hlog.CtxErrorf(ctx, "add webhook delivery failed: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RetryDue 认领并投递到达重试时间的记录，Webhook 已停用或删除的记录标记为失败
+func (w *WebhookUseCase) RetryDue(ctx context.Context, limit int) (int, error) {
+	ctx = getCtx(ctx)
+	if limit <= 0 {
+		limit = defaultRetryBatch
+	}
+	now := time.Now()
+	deliveries, err := w.drepo.FindDue(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	hooks := make(map[string]*model.Webhook)
+	n := 0
+	for _, delivery := range deliveries {
+		claimed, err := w.claim(ctx, delivery, now)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "claim webhook delivery %s failed: %v", delivery.Id, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		hook, ok := hooks[delivery.WebhookId]
+		if !ok {
+			if hook, err = w.repo.FindById(ctx, delivery.WebhookId); err != nil {
+				if !database.IfErrorNotFound(err) {
+					hlog.CtxErrorf(ctx, "find webhook %s failed: %v", delivery.WebhookId, err)
+					continue
+				}
+				hook = nil
+			}
+			hooks[delivery.WebhookId] = hook
+		}
+		n++
+		if hook == nil || hook.Status != model.WebhookStatusEnabled {
+			w.abandon(ctx, delivery, "webhook is disabled or deleted")
+			continue
+		}
+		_ = w.attempt(ctx, hook, delivery)
+	}
+	return n, nil
+}
+
+// Redeliver 立即重新投递，不受 Webhook 状态和投递状态限制
+func (w *WebhookUseCase) Redeliver(ctx context.Context, id string) (*dto.WebhookDeliveryModel, herrors.Herr) {
+	delivery, err := w.drepo.FindById(ctx, id)
+	if err != nil {
+		return nil, herrors.QueryFail(err)
+	}
+	hook, herr := w.get(ctx, delivery.WebhookId)
+	if herr != nil {
+		return nil, herr
+	}
+	if err = w.attempt(ctx, hook, delivery); err != nil {
+		hlog.CtxWarnf(ctx, "redeliver webhook %s failed: %v", id, err)
+	}
+	return dto.WebhookDeliveryToDto(delivery), nil
+}
+
+// attempt 投递一次并保存结果，失败时按 Webhook 的退避策略安排下次投递
+func (w *WebhookUseCase) attempt(ctx context.Context, hook *model.Webhook, delivery *model.WebhookDelivery) error {
+	start := time.Now()
+	code, resp, err := w.send(ctx, hook, delivery)
+	delivery.Attempts++
+	delivery.LastAttempt = start
+	delivery.Latency = time.Since(start).Milliseconds()
+	delivery.ResponseCode = code
+	delivery.ResponseBody = resp
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.Error = ""
+	} else {
+		delivery.Error = err.Error()
+		if policy := hook.GetRetryPolicy(); policy.Exhausted(delivery.Attempts) {
+			delivery.Status = model.WebhookDeliveryFailed
+		} else {
+			delivery.Status = model.WebhookDeliveryPending
+			delivery.NextRetry = start.Add(policy.Backoff(delivery.Attempts))
+		}
+	}
+	if _, serr := w.drepo.UpdateWhere(ctx, idQuery(delivery.Id), map[string]interface{}{
+		"status":        delivery.Status,
+		"attempts":      delivery.Attempts,
+		"next_retry":    delivery.NextRetry,
+		"last_attempt":  delivery.LastAttempt,
+		"response_code": delivery.ResponseCode,
+		"response_body": delivery.ResponseBody,
+		"latency":       delivery.Latency,
+		"error":         delivery.Error,
+		"updated_at":    utils.GetDateUnixMilli(),
+	}); serr != nil {
+		hlog.CtxErrorf(ctx, "save webhook delivery %s failed: %v", delivery.Id, serr)
+	}
+	w.recordResult(ctx, hook, err)
+	return err
+}
+
+// send 签名并发送请求，2xx 视为成功
+func (w *WebhookUseCase) send(ctx context.Context, hook *model.Webhook, delivery *model.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, hook.GetTimeout())
+	defer cancel()
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookId, delivery.Id)
+	req.Header.Set(HeaderWebhookEvent, delivery.Topic)
+	req.Header.Set(HeaderWebhookEventId, delivery.EventId)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(hook.Secret, timestamp, body))
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	text := strings.ToValidUTF8(string(data), "")
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, text, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, text, nil
+}
+
+// recordResult 成功时清零连续失败次数，连续失败达到阈值后自动停用
+func (w *WebhookUseCase) recordResult(ctx context.Context, hook *model.Webhook, err error) {
+	if err == nil {
+		if hook.ConsecutiveFailures == 0 {
+			return
+		}
+		hook.ConsecutiveFailures = 0
+		if _, uerr := w.repo.UpdateWhere(ctx, idQuery(hook.Id), map[string]interface{}{
+			"consecutive_failures": 0,
+		}); uerr != nil {
+			hlog.CtxErrorf(ctx, "reset webhook %s failures failed: %v", hook.Id, uerr)
+		}
+		return
+	}
+	hook.ConsecutiveFailures++
+	hook.LastFailureAt = utils.GetDateUnixMilli()
+	if _, uerr := w.repo.UpdateWhere(ctx, idQuery(hook.Id), map[string]interface{}{
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+		"last_failure_at":      hook.LastFailureAt,
+	}); uerr != nil {
+		hlog.CtxErrorf(ctx, "record webhook %s failure failed: %v", hook.Id, uerr)
+		return
+	}
+	threshold := hook.GetFailureThreshold()
+	if hook.Status != model.WebhookStatusEnabled || hook.ConsecutiveFailures < threshold {
+		return
+	}
+	qb := idQuery(hook.Id)
+	qb.Where("status", db_query.Eq, model.WebhookStatusEnabled)
+	qb.Where("consecutive_failures", db_query.Gte, threshold)
+	reason := fmt.Sprintf("%d consecutive failures, last error: %v", hook.ConsecutiveFailures, err)
+	affected, uerr := w.repo.UpdateWhere(ctx, qb, map[string]interface{}{
+		"status":          model.WebhookStatusAutoDisabled,
+		"disabled_reason": reason,
+		"updated_at":      utils.GetDateUnixMilli(),
+	})
+	if uerr != nil {
+		hlog.CtxErrorf(ctx, "auto disable webhook %s failed: %v", hook.Id, uerr)
+		return
+	}
+	if affected > 0 {
+		hook.Status = model.WebhookStatusAutoDisabled
+		hook.DisabledReason = reason
+		hlog.CtxWarnf(ctx, "webhook %s auto disabled: %s", hook.Id, reason)
+	}
+}
+
+// claim 将下次投递时间推迟一个租约，多实例时只有一个实例认领成功
+func (w *WebhookUseCase) claim(ctx context.Context, delivery *model.WebhookDelivery, now time.Time) (bool, error) {
+	qb := idQuery(delivery.Id)
+	qb.Where("status", db_query.Eq, model.WebhookDeliveryPending)
+	qb.Where("next_retry", db_query.Eq, delivery.NextRetry)
+	next := now.Add(webhookClaimLease)
+	affected, err := w.drepo.UpdateWhere(ctx, qb, map[string]interface{}{"next_retry": next})
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	delivery.NextRetry = next
+	return true, nil
+}
+
+// abandon Webhook 不可用时放弃投递
+func (w *WebhookUseCase) abandon(ctx context.Context, delivery *model.WebhookDelivery, reason string) {
+	if _, err := w.drepo.UpdateWhere(ctx, idQuery(delivery.Id), map[string]interface{}{
+		"status":     model.WebhookDeliveryFailed,
+		"error":      reason,
+		"updated_at": utils.GetDateUnixMilli(),
+	}); err != nil {
+		hlog.CtxErrorf(ctx, "abandon webhook delivery %s failed: %v", delivery.Id, err)
+	}
+}
@@ -0,0 +1,199 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"gorm.io/gorm"
+)
+
+type webhookRepo struct {
+	IWebhookRepo
+	hooks   []*model.Webhook
+	updates []map[string]interface{}
+}
+
+func (r *webhookRepo) FindEnabled(ctx context.Context, tenantID string) ([]*model.Webhook, error) {
+	return r.hooks, nil
+}
+
+func (r *webhookRepo) FindById(ctx context.Context, id string) (*model.Webhook, error) {
+	for _, hook := range r.hooks {
+		if hook.Id == id {
+			return hook, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *webhookRepo) UpdateWhere(ctx context.Context, qb *db_query.QueryBuilder, values map[string]interface{}) (int64, error) {
+	r.updates = append(r.updates, values)
+	return 1, nil
+}
+
+type deliveryRepo struct {
+	IWebhookDeliveryRepo
+	mu      sync.Mutex
+	added   map[string]bool
+	pending []*model.WebhookDelivery
+	updates []map[string]interface{}
+}
+
+func (r *deliveryRepo) AddIfAbsent(ctx context.Context, delivery *model.WebhookDelivery) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := delivery.WebhookId + ":" + delivery.EventId
+	if r.added[key] {
+		return false, nil
+	}
+	r.added[key] = true
+	r.pending = append(r.pending, delivery)
+	return true, nil
+}
+
+func (r *deliveryRepo) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*model.WebhookDelivery
+	for _, d := range r.pending {
+		if d.Status == model.WebhookDeliveryPending && !d.NextRetry.After(now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (r *deliveryRepo) UpdateWhere(ctx context.Context, qb *db_query.QueryBuilder, values map[string]interface{}) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, values)
+	return 1, nil
+}
+
+func newTestWebhookUseCase(hooks ...*model.Webhook) (*WebhookUseCase, *webhookRepo, *deliveryRepo) {
+	repo := &webhookRepo{hooks: hooks}
+	drepo := &deliveryRepo{added: make(map[string]bool)}
+	return &WebhookUseCase{
+		repo:   repo,
+		drepo:  drepo,
+		db:     fakeIDGen{},
+		client: newWebhookClient(true),
+	}, repo, drepo
+}
+
+func TestWebhookSignVerify(t *testing.T) {
+	body := []byte(`{"id":"e1"}`)
+	ts := "1700000000"
+	header := http.Header{}
+	header.Set(HeaderWebhookTimestamp, ts)
+	header.Set(HeaderWebhookSignature, SignWebhook("secret", ts, body))
+
+	if err := VerifyWebhook("secret", header, body, 0); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := VerifyWebhook("other", header, body, 0); !errors.Is(err, ErrWebhookSignature) {
+		t.Fatalf("wrong secret: got %v", err)
+	}
+	if err := VerifyWebhook("secret", header, []byte(`{"id":"e2"}`), 0); !errors.Is(err, ErrWebhookSignature) {
+		t.Fatalf("tampered body: got %v", err)
+	}
+	if err := VerifyWebhook("secret", header, body, time.Minute); !errors.Is(err, ErrWebhookSignature) {
+		t.Fatalf("expired timestamp: got %v", err)
+	}
+}
+
+func TestWebhookDeliverSigned(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received int
+		verr     error
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received++
+		verr = VerifyWebhook("secret", r.Header, body, time.Minute)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	hook := &model.Webhook{Id: "w1", Url: srv.URL, Secret: "secret", Topics: "order.created", Status: model.WebhookStatusEnabled}
+	uc, _, drepo := newTestWebhookUseCase(hook)
+	event := mqevent.NewBaseEvent("order.created", map[string]string{"no": "1"}, mqevent.WithID("e1"))
+
+	// 同一事件重复到达只投递一次
+	for i := 0; i < 2; i++ {
+		if err := uc.onEvent(context.Background(), event); err != nil {
+			t.Fatalf("onEvent: %v", err)
+		}
+	}
+	// 未订阅的主题不投递
+	if err := uc.onEvent(context.Background(), mqevent.NewBaseEvent("order.paid", nil)); err != nil {
+		t.Fatalf("onEvent: %v", err)
+	}
+	// 消费事件时只创建待投递记录，由定时任务发送
+	if received != 0 || len(drepo.pending) != 1 {
+		t.Fatalf("received %d requests, %d pending deliveries before retry", received, len(drepo.pending))
+	}
+	if n, err := uc.RetryDue(context.Background(), 10); err != nil || n != 1 {
+		t.Fatalf("RetryDue = %d, %v", n, err)
+	}
+
+	if received != 1 {
+		t.Fatalf("received %d requests, want 1", received)
+	}
+	if verr != nil {
+		t.Fatalf("signature: %v", verr)
+	}
+	// 认领和投递结果各更新一次
+	if len(drepo.updates) != 2 || drepo.updates[1]["status"] != model.WebhookDeliverySucceeded {
+		t.Fatalf("delivery updates = %v", drepo.updates)
+	}
+}
+
+func TestWebhookFailureBackoffAndAutoDisable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	hook := &model.Webhook{Id: "w1", Url: srv.URL, Secret: "secret", MaxAttempts: 2, FailureThreshold: 2, Status: model.WebhookStatusEnabled}
+	uc, repo, _ := newTestWebhookUseCase(hook)
+
+	first := &model.WebhookDelivery{Id: "d1", WebhookId: "w1", Payload: "{}"}
+	if err := uc.attempt(context.Background(), hook, first); err == nil {
+		t.Fatal("expected failure")
+	}
+	if first.Status != model.WebhookDeliveryPending || first.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("first attempt: status=%d code=%d", first.Status, first.ResponseCode)
+	}
+	if !first.NextRetry.After(first.LastAttempt) {
+		t.Fatalf("next retry %v not after last attempt %v", first.NextRetry, first.LastAttempt)
+	}
+	if hook.Status != model.WebhookStatusEnabled {
+		t.Fatal("disabled before threshold")
+	}
+
+	// 达到最大投递次数后不再重试，连续失败达到阈值后自动停用
+	_ = uc.attempt(context.Background(), hook, first)
+	if first.Status != model.WebhookDeliveryFailed {
+		t.Fatalf("status after max attempts = %d", first.Status)
+	}
+	if hook.Status != model.WebhookStatusAutoDisabled || hook.DisabledReason == "" {
+		t.Fatalf("hook status = %d reason = %q", hook.Status, hook.DisabledReason)
+	}
+	last := repo.updates[len(repo.updates)-1]
+	if last["status"] != model.WebhookStatusAutoDisabled {
+		t.Fatalf("last hook update = %v", last)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/biz"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"time"
 )
 
 type deadLetterRepo struct {
@@ -31,6 +32,13 @@ func (d deadLetterRepo) GetBy(ctx context.Context, topic, channel, messageId str
 	err := d.Db(ctx).Model(&model.DeadLetterSubscribe{}).Where("topic = ? AND channel = ? AND msg_id = ?", topic, channel, messageId).First(&data).Error
 	return &data, err
 }
+func (d deadLetterRepo) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.DeadLetterSubscribe, error) {
+	var result []*model.DeadLetterSubscribe
+	err := d.Db(ctx).Model(&model.DeadLetterSubscribe{}).
+		Where("deleted_at = 0 AND status = ? AND next_retry <= ?", model.DeadLetterStatusPending, now).
+		Order("next_retry").Limit(limit).Find(&result).Error
+	return result, err
+}
 func (d deadLetterRepo) UpdateStatus(ctx context.Context, id string, status int8) error {
 	return d.Db(ctx).Model(&model.DeadLetterSubscribe{}).Where("id = ?", id).Update("status", status).Update("updated_at", utils.GetDateUnix()).Error
 }
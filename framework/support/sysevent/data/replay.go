@@ -0,0 +1,26 @@
+package data
+
+import (
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/biz"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+)
+
+type eventReplayRepo struct {
+	*baserepo.BaseRepo[model.EventReplay, string]
+}
+
+func NewEventReplayRepo(data database.IDataBase) biz.IEventReplayRepo {
+	// 同步表
+	tables := []interface{}{
+		&model.EventReplay{},
+	}
+	if err := data.AutoMigrate(tables...); err != nil {
+		hlog.Fatalf("sync tables  error: %v", err)
+	}
+	return &eventReplayRepo{
+		BaseRepo: baserepo.NewBaseRepo[model.EventReplay, string](data),
+	}
+}
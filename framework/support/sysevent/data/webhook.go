@@ -0,0 +1,87 @@
+package data
+
+import (
+	"context"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/baserepo"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/biz"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type webhookRepo struct {
+	*baserepo.BaseRepo[model.Webhook, string]
+}
+
+func NewWebhookRepo(data database.IDataBase) biz.IWebhookRepo {
+	// 同步表
+	tables := []interface{}{
+		&model.Webhook{},
+	}
+	if err := data.AutoMigrate(tables...); err != nil {
+		hlog.Fatalf("sync tables  error: %v", err)
+	}
+	return &webhookRepo{
+		BaseRepo: baserepo.NewBaseRepo[model.Webhook, string](data),
+	}
+}
+
+func (w webhookRepo) FindEnabled(ctx context.Context, tenantID string) ([]*model.Webhook, error) {
+	var result []*model.Webhook
+	err := w.Db(ctx).Model(&model.Webhook{}).
+		Where("deleted_at = 0 AND status = ? AND (tenant_id = ? OR tenant_id = '')", model.WebhookStatusEnabled, tenantID).
+		Find(&result).Error
+	return result, err
+}
+
+func (w webhookRepo) GetAllTopics(ctx context.Context) ([]string, error) {
+	var rows []string
+	if err := w.Db(ctx).Model(&model.Webhook{}).
+		Where("deleted_at = 0 AND status = ?", model.WebhookStatusEnabled).
+		Distinct("topics").Pluck("topics", &rows).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	topics := make([]string, 0)
+	for _, row := range rows {
+		for _, topic := range (&model.Webhook{Topics: row}).TopicList() {
+			if !seen[topic] {
+				seen[topic] = true
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics, nil
+}
+
+type webhookDeliveryRepo struct {
+	*baserepo.BaseRepo[model.WebhookDelivery, string]
+}
+
+func NewWebhookDeliveryRepo(data database.IDataBase) biz.IWebhookDeliveryRepo {
+	// 同步表
+	tables := []interface{}{
+		&model.WebhookDelivery{},
+	}
+	if err := data.AutoMigrate(tables...); err != nil {
+		hlog.Fatalf("sync tables  error: %v", err)
+	}
+	return &webhookDeliveryRepo{
+		BaseRepo: baserepo.NewBaseRepo[model.WebhookDelivery, string](data),
+	}
+}
+
+func (w webhookDeliveryRepo) AddIfAbsent(ctx context.Context, delivery *model.WebhookDelivery) (bool, error) {
+	res := w.Db(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (w webhookDeliveryRepo) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var result []*model.WebhookDelivery
+	err := w.Db(ctx).Model(&model.WebhookDelivery{}).
+		Where("deleted_at = 0 AND status = ? AND next_retry <= ?", model.WebhookDeliveryPending, now).
+		Order("next_retry").Limit(limit).Find(&result).Error
+	return result, err
+}
@@ -4,15 +4,23 @@ import (
 	"github.com/flare-admin/flare-server-go/framework/pkg/database"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"time"
 )
 
 type DeadLetterSubscribeModel struct {
 	database.BaseIntTime
-	Id     string `json:"id,omitempty"`     // ID
-	Name   string `json:"name,omitempty"`   // 订阅名称
-	Topic  string `json:"topic,omitempty"`  // 订阅的主题
-	Group  string `json:"group,omitempty"`  // 订阅的组
-	Status int32  `json:"status,omitempty"` // 状态
+	Id          string    `json:"id,omitempty" excel:"title:ID;width:22;sort:1"`              // ID
+	Name        string    `json:"name,omitempty" excel:"title:订阅名称;width:15;sort:2"`          // 订阅名称
+	Topic       string    `json:"topic,omitempty" excel:"title:主题;width:20;sort:3"`           // 订阅的主题
+	Group       string    `json:"group,omitempty" excel:"title:消费通道;width:20;sort:4"`         // 订阅的组
+	EventId     string    `json:"eventId,omitempty" excel:"title:事件ID;width:22;sort:5"`       // 事件ID
+	Error       string    `json:"error,omitempty" excel:"title:错误信息;width:40;sort:6"`         // 错误信息
+	RetryCount  int       `json:"retryCount,omitempty" excel:"title:投递次数;width:10;sort:7"`    // 进入死信前的投递次数
+	Attempts    int       `json:"attempts,omitempty" excel:"title:死信重试次数;width:12;sort:8"`    // 死信重试次数
+	LastAttempt time.Time `json:"lastAttempt,omitempty" excel:"title:最后尝试时间;width:20;sort:9"` // 最后尝试时间
+	NextRetry   time.Time `json:"nextRetry,omitempty" excel:"title:下次重试时间;width:20;sort:10"`  // 下次重试时间
+	Status      int32     `json:"status,omitempty" excel:"title:状态;width:8;sort:11"`          // 状态 1->待处理, 2->已处理，3->处理失败，4->已搁置，5->已丢弃
+	Data        string    `json:"data,omitempty" excel:"title:事件数据;width:40;sort:12"`         // 事件数据
 }
 
 func DeadLetterToDto(vo *model.DeadLetterSubscribe) *DeadLetterSubscribeModel {
@@ -21,14 +29,72 @@ func DeadLetterToDto(vo *model.DeadLetterSubscribe) *DeadLetterSubscribeModel {
 		Name:        vo.Name,
 		Topic:       vo.Topic,
 		Group:       vo.Channel,
+		EventId:     vo.EventId,
+		Error:       vo.Error,
+		RetryCount:  vo.RetryCount,
+		Attempts:    vo.Attempts,
+		LastAttempt: vo.LastAttempt,
+		NextRetry:   vo.NextRetry,
 		Status:      int32(vo.Status),
+		Data:        string(vo.Data),
 		BaseIntTime: vo.BaseIntTime,
 	}
 }
 
+// DeadLetterQuery 死信筛选条件
+type DeadLetterQuery struct {
+	Topic     string `json:"topic,omitempty" query:"topic"`         // 主题
+	Name      string `json:"name,omitempty" query:"name"`           // 订阅名称
+	Group     string `json:"group,omitempty" query:"group"`         // 消费通道
+	Status    int32  `json:"status,omitempty" query:"status"`       // 状态
+	Error     string `json:"error,omitempty" query:"error"`         // 错误信息，模糊匹配
+	StartTime int64  `json:"startTime,omitempty" query:"startTime"` // 进入死信的开始时间（秒）
+	EndTime   int64  `json:"endTime,omitempty" query:"endTime"`     // 进入死信的结束时间（秒）
+}
+
+// Build 构建查询条件
+func (q *DeadLetterQuery) Build(qb *db_query.QueryBuilder) *db_query.QueryBuilder {
+	if q.Topic != "" {
+		qb.Where("topic", db_query.Eq, q.Topic)
+	}
+	if q.Group != "" {
+		qb.Where("channel", db_query.Eq, q.Group)
+	}
+	if q.Name != "" {
+		qb.Where("name", db_query.Eq, q.Name)
+	}
+	if q.Status != 0 {
+		qb.Where("status", db_query.Eq, q.Status)
+	}
+	if q.Error != "" {
+		qb.Where("error", db_query.Like, "%"+q.Error+"%")
+	}
+	if q.StartTime > 0 {
+		qb.Where("created_at", db_query.Gte, q.StartTime*1000)
+	}
+	if q.EndTime > 0 {
+		qb.Where("created_at", db_query.Lte, q.EndTime*1000)
+	}
+	return qb
+}
+
 type GetDeadLetterSubscribeListReq struct {
 	db_query.Page
-	Topic string `json:"topic,omitempty" query:"topic"` // 主题
-	Name  string `json:"name,omitempty" query:"name"`   // 订阅名称
-	Group string `json:"group,omitempty" query:"group"` // 订阅的组
+	DeadLetterQuery
+}
+
+// BulkDeadLetterReq 死信批量操作请求，指定 ids 时只处理这些死信，否则按筛选条件处理
+type BulkDeadLetterReq struct {
+	Ids []string `json:"ids,omitempty" query:"ids"` // 死信ID
+	DeadLetterQuery
+}
+
+// BulkDeadLetterRes 死信批量操作结果
+type BulkDeadLetterRes struct {
+	Affected int64 `json:"affected"` // 影响条数
+}
+
+// ExportDeadLetterReq 导出死信请求
+type ExportDeadLetterReq struct {
+	DeadLetterQuery
 }
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/pkg/mqevent"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
 )
 
@@ -14,6 +15,8 @@ type EventModel struct {
 	Status    int8   `json:"status"`    // 状态
 	CreatedAt int64  `json:"createdAt"` // 创建时间
 	UpdatedAt int64  `json:"updatedAt"` // 更新时间
+	// Schemas 代码中注册的负载结构，按版本升序，最后一个为当前版本
+	Schemas []*mqevent.EventSchema `json:"schemas"`
 }
 
 // AddEventReq 添加事件请求
@@ -56,10 +59,11 @@ func EventToDto(event *model.Event) *EventModel {
 		return nil
 	}
 	return &EventModel{
-		Id:     event.Id,
-		Name:   event.Name,
-		Topic:  event.Topic,
-		Dis:    event.Dis,
-		Status: event.Status,
+		Id:      event.Id,
+		Name:    event.Name,
+		Topic:   event.Topic,
+		Dis:     event.Dis,
+		Status:  event.Status,
+		Schemas: mqevent.DefaultRegistry.Schemas(event.Topic),
 	}
 }
@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+)
+
+// StartReplayReq 开始回放请求，时间和记录ID范围为 0 时不限制
+type StartReplayReq struct {
+	Topic    string `json:"topic" query:"topic"`       // 主题
+	Group    string `json:"group" query:"group"`       // 消费通道，只投递给该通道
+	FromTime int64  `json:"fromTime" query:"fromTime"` // 开始时间（秒）
+	ToTime   int64  `json:"toTime" query:"toTime"`     // 结束时间（秒）
+	FromId   int64  `json:"fromId" query:"fromId"`     // 开始记录ID（包含）
+	ToId     int64  `json:"toId" query:"toId"`         // 结束记录ID（包含）
+	Rate     int    `json:"rate" query:"rate"`         // 每秒投递条数，默认 100
+}
+
+type GetReplayListReq struct {
+	db_query.Page
+	Topic  string `json:"topic,omitempty" query:"topic"`   // 主题
+	Group  string `json:"group,omitempty" query:"group"`   // 消费通道
+	Status int32  `json:"status,omitempty" query:"status"` // 状态
+}
+
+type EventReplayModel struct {
+	database.BaseIntTime
+	Id        string `json:"id"`        // ID
+	Topic     string `json:"topic"`     // 主题
+	Group     string `json:"group"`     // 消费通道
+	FromTime  int64  `json:"fromTime"`  // 开始时间（毫秒）
+	ToTime    int64  `json:"toTime"`    // 结束时间（毫秒）
+	FromId    int64  `json:"fromId"`    // 开始记录ID
+	ToId      int64  `json:"toId"`      // 结束记录ID
+	Rate      int    `json:"rate"`      // 每秒投递条数
+	LastId    int64  `json:"lastId"`    // 最后投递的记录ID
+	Processed int64  `json:"processed"` // 已投递条数
+	Failed    int64  `json:"failed"`    // 投递失败条数
+	Status    int32  `json:"status"`    // 状态 1->回放中, 2->已完成，3->已取消，4->失败
+	Error     string `json:"error"`     // 最后一次错误
+}
+
+func ReplayToDto(vo *model.EventReplay) *EventReplayModel {
+	return &EventReplayModel{
+		BaseIntTime: vo.BaseIntTime,
+		Id:          vo.Id,
+		Topic:       vo.Topic,
+		Group:       vo.Channel,
+		FromTime:    vo.FromTime,
+		ToTime:      vo.ToTime,
+		FromId:      vo.FromId,
+		ToId:        vo.ToId,
+		Rate:        vo.Rate,
+		LastId:      vo.LastId,
+		Processed:   vo.Processed,
+		Failed:      vo.Failed,
+		Status:      int32(vo.Status),
+		Error:       vo.Error,
+	}
+}
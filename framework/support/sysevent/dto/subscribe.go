@@ -15,12 +15,22 @@ type SubscribeParameterModel struct {
 	SubscribeId string `json:"subscribe_id,omitempty"` // 订阅事件的id
 }
 
+// SubscribeRetryPolicy 订阅的死信自动重试策略，为 0 时使用默认策略
+type SubscribeRetryPolicy struct {
+	RetryMaxAttempts int     `json:"retryMaxAttempts,omitempty" query:"retryMaxAttempts"` // 最大自动重试次数，-1 不自动重试
+	RetryInterval    int64   `json:"retryInterval,omitempty" query:"retryInterval"`       // 首次重试间隔（秒）
+	RetryMaxInterval int64   `json:"retryMaxInterval,omitempty" query:"retryMaxInterval"` // 最大重试间隔（秒）
+	RetryMultiplier  float64 `json:"retryMultiplier,omitempty" query:"retryMultiplier"`   // 间隔倍数
+	RetrySchedule    string  `json:"retrySchedule,omitempty" query:"retrySchedule"`       // 自定义重试间隔（秒），逗号分隔，如 60,300,1800
+}
+
 type AddSubscribeReq struct {
 	Name      string                     `json:"name,omitempty" query:"name"`           // 订阅名称
 	Topic     string                     `json:"topic,omitempty" query:"topic"`         // 订阅的主题
 	Group     string                     `json:"group,omitempty" query:"group"`         // 订阅的组
 	Dis       string                     `json:"dis,omitempty" query:"dis"`             // 描述
 	Parameter []*SubscribeParameterModel `json:"parameter,omitempty" query:"parameter"` // 参数
+	SubscribeRetryPolicy
 }
 
 // 修改事件
@@ -31,6 +41,7 @@ type UpdateSubscribeReq struct {
 	Group     string                     `json:"group,omitempty" query:"group"`         // 订阅的组
 	Dis       string                     `json:"dis,omitempty" query:"dis"`             // 描述
 	Parameter []*SubscribeParameterModel `json:"parameter,omitempty" query:"parameter"` // 参数
+	SubscribeRetryPolicy
 }
 
 // 获取事件
@@ -54,6 +65,7 @@ type SubscribeModel struct {
 	Start     int64                      `json:"start,omitempty"`     // 订阅开始时间
 	End       int64                      `json:"end,omitempty"`       // 订阅结束时间
 	Parameter []*SubscribeParameterModel `json:"parameter,omitempty"` // 参数
+	SubscribeRetryPolicy
 }
 
 // SubscribeToDto 将Subscribe模型转换为DTO
@@ -72,6 +84,13 @@ func SubscribeToDto(subscribe *model.Subscribe) *SubscribeModel {
 		Start:       subscribe.Start,
 		End:         subscribe.End,
 		Parameter:   nil, // 需要单独处理Parameter
+		SubscribeRetryPolicy: SubscribeRetryPolicy{
+			RetryMaxAttempts: subscribe.RetryMaxAttempts,
+			RetryInterval:    subscribe.RetryInterval,
+			RetryMaxInterval: subscribe.RetryMaxInterval,
+			RetryMultiplier:  subscribe.RetryMultiplier,
+			RetrySchedule:    subscribe.RetrySchedule,
+		},
 	}
 }
 
@@ -0,0 +1,114 @@
+package dto
+
+import (
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"github.com/flare-admin/flare-server-go/framework/pkg/database/db_query"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/model"
+	"time"
+)
+
+type AddWebhookReq struct {
+	Name             string   `json:"name" query:"name"`                         // 名称
+	Url              string   `json:"url" query:"url"`                           // 回调地址，http 或 https
+	Secret           string   `json:"secret" query:"secret"`                     // 签名密钥，为空时自动生成
+	Topics           []string `json:"topics" query:"topics"`                     // 订阅主题
+	Timeout          int      `json:"timeout" query:"timeout"`                   // 请求超时（秒），默认 10
+	MaxAttempts      int      `json:"maxAttempts" query:"maxAttempts"`           // 最大投递次数，默认 8
+	FailureThreshold int      `json:"failureThreshold" query:"failureThreshold"` // 连续失败自动停用阈值，默认 20
+	Description      string   `json:"description" query:"description"`           // 描述
+}
+
+type UpdateWebhookReq struct {
+	Id string `json:"id" query:"id"` // ID
+	AddWebhookReq
+}
+
+type GetWebhookListReq struct {
+	db_query.Page
+	Name   string `json:"name,omitempty" query:"name"`     // 名称
+	Topic  string `json:"topic,omitempty" query:"topic"`   // 主题
+	Status int32  `json:"status,omitempty" query:"status"` // 状态
+}
+
+type WebhookModel struct {
+	database.BaseIntTime
+	Id                  string   `json:"id"`                  // ID
+	Name                string   `json:"name"`                // 名称
+	Url                 string   `json:"url"`                 // 回调地址
+	Secret              string   `json:"secret,omitempty"`    // 签名密钥，只在详情中返回
+	Topics              []string `json:"topics"`              // 订阅主题
+	Timeout             int      `json:"timeout"`             // 请求超时（秒）
+	MaxAttempts         int      `json:"maxAttempts"`         // 最大投递次数
+	FailureThreshold    int      `json:"failureThreshold"`    // 连续失败自动停用阈值
+	ConsecutiveFailures int      `json:"consecutiveFailures"` // 连续失败次数
+	LastFailureAt       int64    `json:"lastFailureAt"`       // 最后失败时间（毫秒）
+	DisabledReason      string   `json:"disabledReason"`      // 自动停用原因
+	Status              int32    `json:"status"`              // 状态 1->启用, 2->停用，3->自动停用
+	Description         string   `json:"description"`         // 描述
+}
+
+func WebhookToDto(vo *model.Webhook, withSecret bool) *WebhookModel {
+	m := &WebhookModel{
+		BaseIntTime:         vo.BaseIntTime,
+		Id:                  vo.Id,
+		Name:                vo.Name,
+		Url:                 vo.Url,
+		Topics:              vo.TopicList(),
+		Timeout:             vo.Timeout,
+		MaxAttempts:         vo.MaxAttempts,
+		FailureThreshold:    vo.FailureThreshold,
+		ConsecutiveFailures: vo.ConsecutiveFailures,
+		LastFailureAt:       vo.LastFailureAt,
+		DisabledReason:      vo.DisabledReason,
+		Status:              int32(vo.Status),
+		Description:         vo.Description,
+	}
+	if withSecret {
+		m.Secret = vo.Secret
+	}
+	return m
+}
+
+type GetWebhookDeliveryListReq struct {
+	db_query.Page
+	WebhookId string `json:"webhookId,omitempty" query:"webhookId"` // WebhookID
+	Topic     string `json:"topic,omitempty" query:"topic"`         // 主题
+	EventId   string `json:"eventId,omitempty" query:"eventId"`     // 事件ID
+	Status    int32  `json:"status,omitempty" query:"status"`       // 状态
+}
+
+type WebhookDeliveryModel struct {
+	database.BaseIntTime
+	Id           string    `json:"id"`           // ID
+	WebhookId    string    `json:"webhookId"`    // WebhookID
+	EventId      string    `json:"eventId"`      // 事件ID
+	Topic        string    `json:"topic"`        // 主题
+	Payload      string    `json:"payload"`      // 请求体
+	Status       int32     `json:"status"`       // 状态 1->待投递, 2->成功，3->失败
+	Attempts     int       `json:"attempts"`     // 投递次数
+	NextRetry    time.Time `json:"nextRetry"`    // 下次投递时间
+	LastAttempt  time.Time `json:"lastAttempt"`  // 最后投递时间
+	ResponseCode int       `json:"responseCode"` // 响应状态码
+	ResponseBody string    `json:"responseBody"` // 响应内容
+	Latency      int64     `json:"latency"`      // 耗时（毫秒）
+	Error        string    `json:"error"`        // 错误信息
+}
+
+func WebhookDeliveryToDto(vo *model.WebhookDelivery) *WebhookDeliveryModel {
+	return &WebhookDeliveryModel{
+		BaseIntTime:  vo.BaseIntTime,
+		Id:           vo.Id,
+		WebhookId:    vo.WebhookId,
+		EventId:      vo.EventId,
+		Topic:        vo.Topic,
+		Payload:      vo.Payload,
+		Status:       int32(vo.Status),
+		Attempts:     vo.Attempts,
+		NextRetry:    vo.NextRetry,
+		LastAttempt:  vo.LastAttempt,
+		ResponseCode: vo.ResponseCode,
+		ResponseBody: vo.ResponseBody,
+		Latency:      vo.Latency,
+		Error:        vo.Error,
+	}
+}
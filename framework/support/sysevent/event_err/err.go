@@ -20,4 +20,18 @@ var (
 	SubscriptionEventFail                = herrors.NewServerError("SubscriptionEventFail")                        //订阅事件是比啊
 	SubscriptionNoCorrespondingProcessor = herrors.NewBusinessServerError("SubscriptionNoCorrespondingProcessor") //订阅没有对应的处理器
 	TheSameSubscriptionAlreadyExists     = herrors.NewBusinessServerError("TheSameSubscriptionAlreadyExists")     //已经存在相同订阅
+
+	DeadLetterFilterIsEmpty = herrors.NewBusinessServerError("DeadLetterFilterIsEmpty") //死信批量操作未指定条件
+	DeadLetterOperateFail   = herrors.NewServerError("DeadLetterOperateFail")           //死信操作失败
+	DeadLetterExportFail    = herrors.NewServerError("DeadLetterExportFail")            //死信导出失败
+
+	EventStoreNotEnabled = herrors.NewBusinessServerError("EventStoreNotEnabled") //未开启事件存储
+	ReplayIsRunning      = herrors.NewBusinessServerError("ReplayIsRunning")      //该通道已有回放进行中
+	ReplayNotRunning     = herrors.NewBusinessServerError("ReplayNotRunning")     //回放不在进行中
+	ReplayFail           = herrors.NewServerError("ReplayFail")                   //回放操作失败
+
+	WebhookUrlInvalid    = herrors.NewBusinessServerError("WebhookUrlInvalid")    //回调地址不合法
+	WebhookTopicsIsEmpty = herrors.NewBusinessServerError("WebhookTopicsIsEmpty") //未选择订阅主题
+	WebhookNotExist      = herrors.NewBusinessServerError("WebhookNotExist")      //Webhook 不存在
+	WebhookOperateFail   = herrors.NewServerError("WebhookOperateFail")           //Webhook 操作失败
 )
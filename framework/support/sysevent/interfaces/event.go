@@ -2,9 +2,14 @@ package sysevent_service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
 	"github.com/flare-admin/flare-server-go/framework/support/sysevent/service"
+	"time"
 
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/cloudwego/hertz/pkg/route"
 	"github.com/flare-admin/flare-server-go/framework/pkg/hserver"
 	_ "github.com/flare-admin/flare-server-go/framework/pkg/hserver/base_info"
@@ -20,15 +25,20 @@ type EventService struct {
 	as      service.IEventServerApi
 	sbs     service.ISubscribeServerApi
 	ds      service.IDeadLetterServiceApi
+	rps     service.IEventReplayServiceApi
+	whs     service.IWebhookServiceApi
 	ef      *casbin.Enforcer
 	modeNma string
 }
 
-func NewEventService(as service.IEventServerApi, sbs service.ISubscribeServerApi, ds service.IDeadLetterServiceApi, ef *casbin.Enforcer) *EventService {
+func NewEventService(as service.IEventServerApi, sbs service.ISubscribeServerApi, ds service.IDeadLetterServiceApi,
+	rps service.IEventReplayServiceApi, whs service.IWebhookServiceApi, ef *casbin.Enforcer) *EventService {
 	return &EventService{
 		as:      as,
 		sbs:     sbs,
 		ds:      ds,
+		rps:     rps,
+		whs:     whs,
 		ef:      ef,
 		modeNma: "事件管理",
 	}
@@ -108,6 +118,91 @@ func (a *EventService) RegisterRouter(rg *route.RouterGroup, t token.IToken) {
 				Module:      a.modeNma,
 				Action:      "重试",
 			}), hserver.NewHandlerFu[models.StringIdReq](a.DeadLetterQueueRetry)) // 死信队列重试
+
+			dg.PUT("/bulk_retry", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "批量重试",
+			}), hserver.NewHandlerFu[dto.BulkDeadLetterReq](a.DeadLetterBulkRetry)) // 死信批量重试
+
+			dg.PUT("/bulk_discard", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "批量丢弃",
+			}), hserver.NewHandlerFu[dto.BulkDeadLetterReq](a.DeadLetterBulkDiscard)) // 死信批量丢弃
+
+			dg.GET("/export", casbin.Handler(a.ef), a.ExportDeadLetter) // 导出死信
+		}
+
+		// 事件回放
+		rg := g.Group("/replay")
+		{
+			rg.POST("", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "事件回放",
+			}), hserver.NewHandlerFu[dto.StartReplayReq](a.StartReplay)) // 开始回放
+
+			rg.GET("", casbin.Handler(a.ef),
+				hserver.NewHandlerFu[dto.GetReplayListReq](a.GetReplayList)) // 获取回放列表
+
+			rg.GET("/:id", casbin.Handler(a.ef),
+				hserver.NewHandlerFu[models.StringIdReq](a.GetReplay)) // 获取回放进度
+
+			rg.PUT("/cancel/:id", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "取消回放",
+			}), hserver.NewHandlerFu[models.StringIdReq](a.CancelReplay)) // 取消回放
+		}
+
+		// Webhook
+		wg := g.Group("/webhook")
+		{
+			wg.POST("", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: false,
+				Module:      a.modeNma,
+				Action:      "新增Webhook",
+			}), hserver.NewHandlerFu[dto.AddWebhookReq](a.AddWebhook)) // 新增Webhook
+
+			wg.PUT("", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: false,
+				Module:      a.modeNma,
+				Action:      "修改Webhook",
+			}), hserver.NewHandlerFu[dto.UpdateWebhookReq](a.UpdateWebhook)) // 修改Webhook
+
+			wg.DELETE("/:id", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "删除Webhook",
+			}), hserver.NewHandlerFu[models.StringIdReq](a.DeleteWebhook)) // 删除Webhook
+
+			wg.GET("", casbin.Handler(a.ef),
+				hserver.NewHandlerFu[dto.GetWebhookListReq](a.GetWebhookList)) // 获取Webhook列表
+
+			wg.GET("/delivery", casbin.Handler(a.ef),
+				hserver.NewHandlerFu[dto.GetWebhookDeliveryListReq](a.GetWebhookDeliveryList)) // 获取投递记录
+
+			wg.GET("/:id", casbin.Handler(a.ef),
+				hserver.NewHandlerFu[models.StringIdReq](a.GetWebhook)) // 获取Webhook详情
+
+			wg.PUT("/enable/:id", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "启用Webhook",
+			}), hserver.NewHandlerFu[models.StringIdReq](a.EnableWebhook)) // 启用Webhook
+
+			wg.PUT("/disable/:id", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "停用Webhook",
+			}), hserver.NewHandlerFu[models.StringIdReq](a.DisableWebhook)) // 停用Webhook
+
+			wg.PUT("/delivery/redeliver/:id", casbin.Handler(a.ef), oplog.Record(oplog.LogOption{
+				IncludeBody: true,
+				Module:      a.modeNma,
+				Action:      "重新投递Webhook",
+			}), hserver.NewHandlerFu[models.StringIdReq](a.RedeliverWebhook)) // 重新投递
 		}
 	}
 }
@@ -432,3 +527,176 @@ func (a *EventService) DeadLetterQueueRetry(ctx context.Context, req *models.Str
 	}
 	return res
 }
+
+// DeadLetterBulkRetry 死信批量重试
+// @Summary 死信批量重试
+// @Description 将死信重新排队并清零重试次数，由定时任务立即重试；未指定 ids 时按筛选条件处理
+// @Tags 事件
+// @ID DeadLetterBulkRetry
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req body dto.BulkDeadLetterReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=dto.BulkDeadLetterRes} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/dead_letter/bulk_retry [put]
+func (a *EventService) DeadLetterBulkRetry(ctx context.Context, req *dto.BulkDeadLetterReq) *hserver.ResponseResult {
+	re, err := a.ds.BulkRetry(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// DeadLetterBulkDiscard 死信批量丢弃
+// @Summary 死信批量丢弃
+// @Description 丢弃死信，丢弃后不再重试；未指定 ids 时按筛选条件处理
+// @Tags 事件
+// @ID DeadLetterBulkDiscard
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req body dto.BulkDeadLetterReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=dto.BulkDeadLetterRes} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/dead_letter/bulk_discard [put]
+func (a *EventService) DeadLetterBulkDiscard(ctx context.Context, req *dto.BulkDeadLetterReq) *hserver.ResponseResult {
+	re, err := a.ds.BulkDiscard(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// ExportDeadLetter 导出死信
+// @Summary 导出死信
+// @Description 按筛选条件导出死信为 Excel
+// @Tags 事件
+// @ID ExportDeadLetter
+// @Accept application/json
+// @Produce application/octet-stream
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req query dto.ExportDeadLetterReq true "属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/dead_letter/export [get]
+func (a *EventService) ExportDeadLetter(ctx context.Context, c *app.RequestContext) {
+	req := dto.ExportDeadLetterReq{}
+	if err := c.BindAndValidate(&req); err != nil {
+		hserver.ResponseFailureErr(ctx, c, herrors.NewParameterHError(err))
+		return
+	}
+	data, err := a.ds.Export(ctx, &req)
+	if err != nil {
+		var herr *herrors.HError
+		if !errors.As(err, &herr) {
+			herr = herrors.NewAsServerError(err, nil)
+		}
+		hserver.ResponseFailureErr(ctx, c, herr)
+		return
+	}
+	filename := fmt.Sprintf("dead_letter_%s.xlsx", time.Now().Format("20060102150405"))
+	c.Response.Header.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Response.SetStatusCode(consts.StatusOK)
+	c.Response.SetBody(data)
+}
+
+// StartReplay 开始事件回放
+// @Summary 开始事件回放
+// @Description 将事件存储中指定时间或记录ID范围内的事件按限速重新投递给指定的消费通道，返回回放ID
+// @Tags 事件回放
+// @ID StartReplay
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req body dto.StartReplayReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=string} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/replay [post]
+func (a *EventService) StartReplay(ctx context.Context, req *dto.StartReplayReq) *hserver.ResponseResult {
+	re, err := a.rps.Start(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// GetReplayList 获取事件回放列表
+// @Summary 获取事件回放列表
+// @Description 获取事件回放列表
+// @Tags 事件回放
+// @ID GetReplayList
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req query dto.GetReplayListReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=[]dto.EventReplayModel} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/replay [get]
+func (a *EventService) GetReplayList(ctx context.Context, req *dto.GetReplayListReq) *hserver.ResponseResult {
+	re, err := a.rps.GetList(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// GetReplay 获取事件回放进度
+// @Summary 获取事件回放进度
+// @Description 获取事件回放进度
+// @Tags 事件回放
+// @ID GetReplay
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req path models.StringIdReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=dto.EventReplayModel} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/replay/:id [get]
+func (a *EventService) GetReplay(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	re, err := a.rps.GetDetails(ctx, req.Id)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// CancelReplay 取消事件回放
+// @Summary 取消事件回放
+// @Description 取消进行中的事件回放
+// @Tags 事件回放
+// @ID CancelReplay
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req path models.StringIdReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/replay/cancel/:id [put]
+func (a *EventService) CancelReplay(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	err := a.rps.Cancel(ctx, req.Id)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res
+}
@@ -0,0 +1,217 @@
+package sysevent_service
+
+import (
+	"context"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+)
+
+// AddWebhook 新增Webhook
+// @Summary 新增Webhook
+// @Description 新增Webhook，未指定签名密钥时自动生成，返回的详情中包含密钥
+// @Tags Webhook
+// @ID AddWebhook
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req body dto.AddWebhookReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=dto.WebhookModel} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook [post]
+func (a *EventService) AddWebhook(ctx context.Context, req *dto.AddWebhookReq) *hserver.ResponseResult {
+	re, err := a.whs.Add(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// UpdateWebhook 修改Webhook
+// @Summary 修改Webhook
+// @Description 修改Webhook，签名密钥为空时不修改
+// @Tags Webhook
+// @ID UpdateWebhook
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req body dto.UpdateWebhookReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook [put]
+func (a *EventService) UpdateWebhook(ctx context.Context, req *dto.UpdateWebhookReq) *hserver.ResponseResult {
+	err := a.whs.Update(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res
+}
+
+// DeleteWebhook 删除Webhook
+// @Summary 删除Webhook
+// @Description 删除Webhook
+// @Tags Webhook
+// @ID DeleteWebhook
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req path models.StringIdReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook/:id [delete]
+func (a *EventService) DeleteWebhook(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	err := a.whs.Delete(ctx, req.Id)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res
+}
+
+// GetWebhook 获取Webhook详情
+// @Summary 获取Webhook详情
+// @Description 获取Webhook详情
+// @Tags Webhook
+// @ID GetWebhook
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req path models.StringIdReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=dto.WebhookModel} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook/:id [get]
+func (a *EventService) GetWebhook(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	re, err := a.whs.GetDetails(ctx, req.Id)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// GetWebhookList 获取Webhook列表
+// @Summary 获取Webhook列表
+// @Description 获取Webhook列表
+// @Tags Webhook
+// @ID GetWebhookList
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req query dto.GetWebhookListReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=[]dto.WebhookModel} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook [get]
+func (a *EventService) GetWebhookList(ctx context.Context, req *dto.GetWebhookListReq) *hserver.ResponseResult {
+	re, err := a.whs.GetList(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// EnableWebhook 启用Webhook
+// @Summary 启用Webhook
+// @Description 启用Webhook并清零连续失败次数
+// @Tags Webhook
+// @ID EnableWebhook
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req path models.StringIdReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook/enable/:id [put]
+func (a *EventService) EnableWebhook(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	err := a.whs.Enable(ctx, req.Id)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res
+}
+
+// DisableWebhook 停用Webhook
+// @Summary 停用Webhook
+// @Description 停用Webhook
+// @Tags Webhook
+// @ID DisableWebhook
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req path models.StringIdReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook/disable/:id [put]
+func (a *EventService) DisableWebhook(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	err := a.whs.Disable(ctx, req.Id)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res
+}
+
+// GetWebhookDeliveryList 获取Webhook投递记录
+// @Summary 获取Webhook投递记录
+// @Description 获取Webhook投递记录，包含响应状态码、响应内容和耗时
+// @Tags Webhook
+// @ID GetWebhookDeliveryList
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req query dto.GetWebhookDeliveryListReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=[]dto.WebhookDeliveryModel} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook/delivery [get]
+func (a *EventService) GetWebhookDeliveryList(ctx context.Context, req *dto.GetWebhookDeliveryListReq) *hserver.ResponseResult {
+	re, err := a.whs.GetDeliveryList(ctx, req)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
+
+// RedeliverWebhook 重新投递
+// @Summary 重新投递
+// @Description 立即重新投递并返回投递结果
+// @Tags Webhook
+// @ID RedeliverWebhook
+// @Accept application/json
+// @Produce application/json
+// @Param        Authorization  header  string                     true  "Bearer token"
+// @Param req path models.StringIdReq true "属性说明请在对应model中查看"
+// @Success 200 {object} base_info.Success{data=dto.WebhookDeliveryModel} "data的属性说明请在对应model中查看"
+// @Failure 400 {object} base_info.Swagger400Resp "code为400 参数输入错误"
+// @Failure 401 {object} base_info.Swagger401Resp "code为401 token未带上"
+// @Failure 500 {object} base_info.Swagger500Resp "code为500 服务端内部错误"
+// @Router /api/admin/v1/event/webhook/delivery/redeliver/:id [put]
+func (a *EventService) RedeliverWebhook(ctx context.Context, req *models.StringIdReq) *hserver.ResponseResult {
+	re, err := a.whs.Redeliver(ctx, req.Id)
+	res := hserver.DefaultResponseResult()
+	if herrors.HaveError(err) {
+		res = res.WithError(herrors.TohError(err))
+	}
+	return res.WithData(re)
+}
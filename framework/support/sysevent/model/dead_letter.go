@@ -7,25 +7,35 @@ import (
 	"time"
 )
 
+// 死信状态
+const (
+	DeadLetterStatusPending   int8 = 1 // 待处理，到达下次重试时间后自动重试
+	DeadLetterStatusSucceeded int8 = 2 // 已处理
+	DeadLetterStatusFailed    int8 = 3 // 处理失败，订阅未开启自动重试，等待人工处理
+	DeadLetterStatusParked    int8 = 4 // 已搁置，达到最大自动重试次数，等待人工处理
+	DeadLetterStatusDiscarded int8 = 5 // 已丢弃
+)
+
 // DeadLetterSubscribe 死信订阅模型
 type DeadLetterSubscribe struct {
 	database.BaseIntTime
-	Id          string            `gorm:"column:id;primary_key" json:"id"`                                         // 主键ID
-	Name        string            `json:"name" gorm:"column:name;size:255;comment:订阅名称"`                           // 订阅名称
-	MsgId       string            `json:"msgId" gorm:"column:msg_id;size:255;comment:消息ID"`                        // 消息ID
-	Topic       string            `json:"topic" gorm:"column:topic;size:150;not null;comment:事件主题"`                // 事件主题
-	Channel     string            `json:"channel" gorm:"column:channel;size:150;not null;comment:消费通道"`            // 消费通道
-	EventType   string            `json:"eventType" gorm:"column:event_type;size:150;comment:事件类型"`                // 事件类型
-	Data        []byte            `json:"data" gorm:"column:data;type:bytea;comment:事件数据"`                         // 事件数据
-	Error       string            `json:"error" gorm:"column:error;type:text;comment:错误信息"`                        // 错误信息
-	RetryCount  int               `json:"retryCount" gorm:"column:retry_count;default:0;comment:重试次数"`             // 重试次数
-	LastAttempt time.Time         `json:"lastAttempt" gorm:"column:last_attempt;comment:最后尝试时间"`                   // 最后尝试时间
-	NextRetry   time.Time         `json:"nextRetry" gorm:"column:next_retry;comment:下次重试时间"`                       // 下次重试时间
-	Status      int8              `gorm:"column:status;default:1;comment:状态 1->待处理, 2->已处理，3->处理失败" json:"status"` // 处理状态
-	EventId     string            `json:"eventId" gorm:"column:event_id;size:255;comment:事件ID"`                    // 事件ID
-	Timestamp   time.Time         `json:"timestamp" gorm:"column:timestamp;comment:事件发生时间"`                        // 事件发生时间
-	Metadata    map[string]string `json:"metadata" gorm:"column:metadata;type:jsonb;comment:事件元数据"`                // 事件元数据
-	TenantID    string            `json:"tenantId" gorm:"column:tenant_id;size:255;comment:租户ID"`                  // 租户ID
+	Id          string            `gorm:"column:id;primary_key" json:"id"`                                                       // 主键ID
+	Name        string            `json:"name" gorm:"column:name;size:255;comment:订阅名称"`                                         // 订阅名称
+	MsgId       string            `json:"msgId" gorm:"column:msg_id;size:255;comment:消息ID"`                                      // 消息ID
+	Topic       string            `json:"topic" gorm:"column:topic;size:150;not null;comment:事件主题"`                              // 事件主题
+	Channel     string            `json:"channel" gorm:"column:channel;size:150;not null;comment:消费通道"`                          // 消费通道
+	EventType   string            `json:"eventType" gorm:"column:event_type;size:150;comment:事件类型"`                              // 事件类型
+	Data        []byte            `json:"data" gorm:"column:data;type:bytea;comment:事件数据"`                                       // 事件数据
+	Error       string            `json:"error" gorm:"column:error;type:text;comment:错误信息"`                                      // 错误信息
+	RetryCount  int               `json:"retryCount" gorm:"column:retry_count;default:0;comment:重试次数"`                           // 重试次数
+	LastAttempt time.Time         `json:"lastAttempt" gorm:"column:last_attempt;comment:最后尝试时间"`                                 // 最后尝试时间
+	NextRetry   time.Time         `json:"nextRetry" gorm:"column:next_retry;comment:下次重试时间"`                                     // 下次重试时间
+	Attempts    int               `json:"attempts" gorm:"column:attempts;not null;default:0;comment:死信重试次数"`                     // 死信重试次数
+	Status      int8              `gorm:"column:status;default:1;comment:状态 1->待处理, 2->已处理，3->处理失败，4->已搁置，5->已丢弃" json:"status"` // 处理状态
+	EventId     string            `json:"eventId" gorm:"column:event_id;size:255;comment:事件ID"`                                  // 事件ID
+	Timestamp   time.Time         `json:"timestamp" gorm:"column:timestamp;comment:事件发生时间"`                                      // 事件发生时间
+	Metadata    map[string]string `json:"metadata" gorm:"column:metadata;type:jsonb;comment:事件元数据"`                              // 事件元数据
+	TenantID    string            `json:"tenantId" gorm:"column:tenant_id;size:255;comment:租户ID"`                                // 租户ID
 }
 
 // TableName 指定表名
@@ -49,7 +59,7 @@ func (d *DeadLetterSubscribe) FromDeadLetterEvent(event *mqevent.DeadLetterEvent
 	d.RetryCount = event.RetryCount
 	d.LastAttempt = event.LastAttempt
 	d.NextRetry = event.NextRetry
-	d.Status = 1 // 默认状态为待处理
+	d.Status = DeadLetterStatusPending // 默认状态为待处理
 
 	// 存储原始事件的所有信息
 	d.EventId = event.OriginalEvent.GetID()
@@ -67,6 +77,20 @@ func (d *DeadLetterSubscribe) FromDeadLetterEvent(event *mqevent.DeadLetterEvent
 	return nil
 }
 
+// ScheduleRetry 记录一次失败的重试，按策略计算下次重试时间，达到最大次数后搁置
+func (d *DeadLetterSubscribe) ScheduleRetry(policy RetryPolicy, now time.Time) {
+	if !policy.Enabled() {
+		d.Status = DeadLetterStatusFailed
+		return
+	}
+	if policy.Exhausted(d.Attempts) {
+		d.Status = DeadLetterStatusParked
+		return
+	}
+	d.Status = DeadLetterStatusPending
+	d.NextRetry = now.Add(policy.Backoff(d.Attempts + 1))
+}
+
 // ToDeadLetterEvent 转换为 DeadLetterEvent
 func (d *DeadLetterSubscribe) ToDeadLetterEvent() (*mqevent.DeadLetterEvent, error) {
 	// 反序列化事件数据
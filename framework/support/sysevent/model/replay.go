@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+)
+
+// 回放状态
+const (
+	ReplayStatusRunning   int8 = 1 // 回放中
+	ReplayStatusCompleted int8 = 2 // 已完成
+	ReplayStatusCancelled int8 = 3 // 已取消
+	ReplayStatusFailed    int8 = 4 // 失败
+)
+
+// ReplayLeaseTimeout 回放中的任务投递时定期保存进度并刷新更新时间，超过该时间未更新视为执行的实例已退出
+// 接管时更换 Owner，原实例保存进度时发现租约标识不一致即停止投递
+const ReplayLeaseTimeout = 5 * time.Minute
+
+// EventReplay 历史事件回放任务，只投递给指定的消费通道
+type EventReplay struct {
+	database.BaseIntTime
+	Id        string `gorm:"column:id;primary_key" json:"id"`                                              // 主键ID
+	Topic     string `json:"topic" gorm:"column:topic;size:150;not null;comment:事件主题"`                     // 事件主题
+	Channel   string `json:"channel" gorm:"column:channel;size:150;not null;comment:消费通道"`                 // 消费通道
+	FromTime  int64  `json:"fromTime" gorm:"column:from_time;not null;default:0;comment:开始时间(毫秒)"`         // 开始时间
+	ToTime    int64  `json:"toTime" gorm:"column:to_time;not null;default:0;comment:结束时间(毫秒)"`             // 结束时间
+	FromId    int64  `json:"fromId" gorm:"column:from_id;not null;default:0;comment:开始记录ID"`               // 开始记录ID
+	ToId      int64  `json:"toId" gorm:"column:to_id;not null;default:0;comment:结束记录ID"`                   // 结束记录ID
+	Rate      int    `json:"rate" gorm:"column:rate;not null;default:0;comment:每秒投递条数"`                    // 每秒投递条数
+	LastId    int64  `json:"lastId" gorm:"column:last_id;not null;default:0;comment:最后投递的记录ID"`            // 最后投递的记录ID
+	Processed int64  `json:"processed" gorm:"column:processed;not null;default:0;comment:已投递条数"`           // 已投递条数
+	Failed    int64  `json:"failed" gorm:"column:failed;not null;default:0;comment:投递失败条数"`                // 投递失败条数
+	Status    int8   `json:"status" gorm:"column:status;default:1;comment:状态 1->回放中, 2->已完成，3->已取消，4->失败"` // 状态
+	Owner     string `json:"-" gorm:"column:owner;size:64;not null;default:'';comment:执行实例的租约标识"`          // 执行实例的租约标识，接管时更换
+	Error     string `json:"error" gorm:"column:error;type:text;comment:最后一次错误"`                           // 最后一次错误
+}
+
+// TableName 指定表名
+func (EventReplay) TableName() string {
+	return "sys_event_replay"
+}
+
+// GetPrimaryKey 获取主键
+func (EventReplay) GetPrimaryKey() string {
+	return "id"
+}
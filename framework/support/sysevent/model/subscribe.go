@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"github.com/flare-admin/flare-server-go/framework/pkg/database"
 	"github.com/flare-admin/flare-server-go/framework/pkg/utils"
+	"math"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Subscribe ， 订阅事件
@@ -20,6 +23,12 @@ type Subscribe struct {
 	End       int64  `json:"end" gorm:"column:end;not null;default:0;comment:结束时间"`
 	Status    int8   `gorm:"column:status;default:1;comment:公告状态 事件状态 1->新建, 2->启用，3->停用 ;NOT NULL" json:"status"`
 	TenantID  string `json:"tenantId" gorm:"column:tenant_id;size:255;comment:租户ID"` // 租户ID
+	// 死信自动重试策略，为 0 时使用默认值
+	RetryMaxAttempts int     `json:"retryMaxAttempts" gorm:"column:retry_max_attempts;not null;default:0;comment:死信最大自动重试次数 0->默认, -1->不自动重试"`
+	RetryInterval    int64   `json:"retryInterval" gorm:"column:retry_interval;not null;default:0;comment:死信首次重试间隔(秒)"`
+	RetryMaxInterval int64   `json:"retryMaxInterval" gorm:"column:retry_max_interval;not null;default:0;comment:死信最大重试间隔(秒)"`
+	RetryMultiplier  float64 `json:"retryMultiplier" gorm:"column:retry_multiplier;not null;default:0;comment:死信重试间隔倍数"`
+	RetrySchedule    string  `json:"retrySchedule" gorm:"column:retry_schedule;size:255;comment:死信自定义重试间隔(秒)，逗号分隔，优先于指数退避"`
 }
 
 func (Subscribe) TableName() string {
@@ -30,6 +39,89 @@ func (Subscribe) GetPrimaryKey() string {
 	return "id"
 }
 
+// GetRetryPolicy 获取订阅的死信重试策略，未配置的项使用默认值
+func (s *Subscribe) GetRetryPolicy() RetryPolicy {
+	p := DefaultRetryPolicy
+	if s == nil {
+		return p
+	}
+	if s.RetryMaxAttempts != 0 {
+		p.MaxAttempts = s.RetryMaxAttempts
+	}
+	if s.RetryInterval > 0 {
+		p.Interval = s.RetryInterval
+	}
+	if s.RetryMaxInterval > 0 {
+		p.MaxInterval = s.RetryMaxInterval
+	}
+	if s.RetryMultiplier >= 1 {
+		p.Multiplier = s.RetryMultiplier
+	}
+	p.Schedule = ParseRetrySchedule(s.RetrySchedule)
+	return p
+}
+
+// RetryPolicy 死信自动重试策略
+type RetryPolicy struct {
+	MaxAttempts int     // 最大自动重试次数，达到后搁置，小于 0 不自动重试
+	Interval    int64   // 首次重试间隔（秒）
+	MaxInterval int64   // 最大重试间隔（秒）
+	Multiplier  float64 // 间隔倍数
+	Schedule    []int64 // 自定义重试间隔（秒），超出部分使用最后一个
+}
+
+// DefaultRetryPolicy 默认重试策略：1 分钟起，每次翻倍，最长 1 小时，最多 5 次
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Interval:    60,
+	MaxInterval: 3600,
+	Multiplier:  2,
+}
+
+// Enabled 是否自动重试
+func (p RetryPolicy) Enabled() bool {
+	return p.MaxAttempts > 0
+}
+
+// Exhausted 已重试 attempts 次后是否达到最大次数
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
+
+// Backoff 第 attempt 次（从 1 开始）自动重试前的等待时间
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if len(p.Schedule) > 0 {
+		if attempt > len(p.Schedule) {
+			attempt = len(p.Schedule)
+		}
+		return time.Duration(p.Schedule[attempt-1]) * time.Second
+	}
+	seconds := float64(p.Interval) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxInterval > 0 && seconds > float64(p.MaxInterval) {
+		seconds = float64(p.MaxInterval)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ParseRetrySchedule 解析逗号分隔的重试间隔（秒），忽略非法项
+func ParseRetrySchedule(schedule string) []int64 {
+	if schedule == "" {
+		return nil
+	}
+	var res []int64
+	for _, item := range strings.Split(schedule, ",") {
+		v, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+		if err != nil || v < 0 {
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
 // SubscribeParameter ， 订阅过程参数
 type SubscribeParameter struct {
 	database.BaseIntTime
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := (*Subscribe)(nil).GetRetryPolicy()
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, w := range want {
+		if got := p.Backoff(i + 1); got != w {
+			t.Fatalf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := p.Backoff(10); got != time.Hour {
+		t.Fatalf("backoff should be capped, got %v", got)
+	}
+
+	s := &Subscribe{RetryMaxAttempts: 3, RetrySchedule: "10, 30,x,60"}
+	p = s.GetRetryPolicy()
+	if p.MaxAttempts != 3 || p.Backoff(2) != 30*time.Second || p.Backoff(5) != time.Minute {
+		t.Fatalf("unexpected schedule policy: %+v", p)
+	}
+}
+
+func TestDeadLetter_ScheduleRetry(t *testing.T) {
+	now := time.Now()
+	policy := RetryPolicy{MaxAttempts: 2, Interval: 10, Multiplier: 2}
+
+	d := &DeadLetterSubscribe{}
+	d.ScheduleRetry(policy, now)
+	if d.Status != DeadLetterStatusPending || !d.NextRetry.Equal(now.Add(10*time.Second)) {
+		t.Fatalf("first retry: %+v", d)
+	}
+	d.Attempts = 1
+	d.ScheduleRetry(policy, now)
+	if !d.NextRetry.Equal(now.Add(20 * time.Second)) {
+		t.Fatalf("second retry at %v", d.NextRetry)
+	}
+	d.Attempts = 2
+	d.ScheduleRetry(policy, now)
+	if d.Status != DeadLetterStatusParked {
+		t.Fatalf("status = %d, want parked", d.Status)
+	}
+
+	d = &DeadLetterSubscribe{}
+	d.ScheduleRetry((&Subscribe{RetryMaxAttempts: -1}).GetRetryPolicy(), now)
+	if d.Status != DeadLetterStatusFailed {
+		t.Fatalf("status = %d, want failed", d.Status)
+	}
+}
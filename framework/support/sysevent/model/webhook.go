@@ -0,0 +1,141 @@
+package model
+
+import (
+	"github.com/flare-admin/flare-server-go/framework/pkg/database"
+	"strings"
+	"time"
+)
+
+// Webhook 状态
+const (
+	WebhookStatusEnabled      int8 = 1 // 启用
+	WebhookStatusDisabled     int8 = 2 // 手动停用
+	WebhookStatusAutoDisabled int8 = 3 // 连续失败自动停用
+)
+
+// 投递状态
+const (
+	WebhookDeliveryPending   int8 = 1 // 待投递，到达下次重试时间后自动投递
+	WebhookDeliverySucceeded int8 = 2 // 投递成功
+	WebhookDeliveryFailed    int8 = 3 // 投递失败，达到最大次数或 Webhook 已停用
+)
+
+const (
+	// DefaultWebhookTimeout 默认请求超时时间（秒）
+	DefaultWebhookTimeout = 10
+	// DefaultWebhookFailureThreshold 默认连续失败多少次后自动停用
+	DefaultWebhookFailureThreshold = 20
+)
+
+// DefaultWebhookRetryPolicy 默认投递策略：最多投递 8 次，30 秒起每次翻倍，最长 1 小时
+var DefaultWebhookRetryPolicy = RetryPolicy{
+	MaxAttempts: 8,
+	Interval:    30,
+	MaxInterval: 3600,
+	Multiplier:  2,
+}
+
+// Webhook 租户注册的回调地址，订阅的主题发布事件时投递到该地址。
+// 租户下创建的 Webhook 只接收本租户的事件，未指定租户的接收所有事件
+type Webhook struct {
+	database.BaseIntTime
+	Id                  string `gorm:"column:id;primary_key" json:"id"`                                                          // 主键ID
+	Name                string `json:"name" gorm:"column:name;size:255;comment:名称"`                                              // 名称
+	Url                 string `json:"url" gorm:"column:url;size:1024;not null;comment:回调地址"`                                    // 回调地址
+	Secret              string `json:"secret" gorm:"column:secret;size:512;serializer:encrypted;comment:签名密钥"`                   // 签名密钥
+	Topics              string `json:"topics" gorm:"column:topics;size:2048;comment:订阅主题，逗号分隔"`                                  // 订阅主题
+	Timeout             int    `json:"timeout" gorm:"column:timeout;not null;default:0;comment:请求超时(秒)"`                         // 请求超时（秒）
+	MaxAttempts         int    `json:"maxAttempts" gorm:"column:max_attempts;not null;default:0;comment:最大投递次数"`                 // 最大投递次数
+	FailureThreshold    int    `json:"failureThreshold" gorm:"column:failure_threshold;not null;default:0;comment:连续失败自动停用阈值"`   // 连续失败自动停用阈值
+	ConsecutiveFailures int    `json:"consecutiveFailures" gorm:"column:consecutive_failures;not null;default:0;comment:连续失败次数"` // 连续失败次数
+	LastFailureAt       int64  `json:"lastFailureAt" gorm:"column:last_failure_at;not null;default:0;comment:最后失败时间(毫秒)"`        // 最后失败时间
+	DisabledReason      string `json:"disabledReason" gorm:"column:disabled_reason;size:1024;comment:自动停用原因"`                    // 自动停用原因
+	Status              int8   `json:"status" gorm:"column:status;default:1;comment:状态 1->启用, 2->停用，3->自动停用"`                    // 状态
+	Description         string `json:"description" gorm:"column:description;size:512;comment:描述"`                                // 描述
+	TenantID            string `json:"tenantId" gorm:"column:tenant_id;size:255;default:'';comment:租户ID"`                        // 租户ID
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "sys_webhook"
+}
+
+// GetPrimaryKey 获取主键
+func (Webhook) GetPrimaryKey() string {
+	return "id"
+}
+
+// TopicList 订阅的主题
+func (w *Webhook) TopicList() []string {
+	topics := make([]string, 0)
+	for _, topic := range strings.Split(w.Topics, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// HasTopic 是否订阅了主题
+func (w *Webhook) HasTopic(topic string) bool {
+	for _, t := range w.TopicList() {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRetryPolicy 投递策略，MaxAttempts 为 0 时使用默认策略
+func (w *Webhook) GetRetryPolicy() RetryPolicy {
+	policy := DefaultWebhookRetryPolicy
+	if w.MaxAttempts > 0 {
+		policy.MaxAttempts = w.MaxAttempts
+	}
+	return policy
+}
+
+// GetTimeout 请求超时时间
+func (w *Webhook) GetTimeout() time.Duration {
+	if w.Timeout <= 0 {
+		return DefaultWebhookTimeout * time.Second
+	}
+	return time.Duration(w.Timeout) * time.Second
+}
+
+// GetFailureThreshold 连续失败自动停用阈值
+func (w *Webhook) GetFailureThreshold() int {
+	if w.FailureThreshold <= 0 {
+		return DefaultWebhookFailureThreshold
+	}
+	return w.FailureThreshold
+}
+
+// WebhookDelivery Webhook 投递记录，同一事件对同一 Webhook 只投递一条
+type WebhookDelivery struct {
+	database.BaseIntTime
+	Id           string    `gorm:"column:id;primary_key" json:"id"`                                                                              // 主键ID
+	WebhookId    string    `json:"webhookId" gorm:"column:webhook_id;size:64;not null;uniqueIndex:idx_webhook_delivery_event;comment:WebhookID"` // WebhookID
+	EventId      string    `json:"eventId" gorm:"column:event_id;size:255;not null;uniqueIndex:idx_webhook_delivery_event;comment:事件ID"`         // 事件ID
+	Topic        string    `json:"topic" gorm:"column:topic;size:150;not null;comment:事件主题"`                                                     // 事件主题
+	Payload      string    `json:"payload" gorm:"column:payload;type:text;comment:请求体"`                                                          // 请求体
+	Status       int8      `json:"status" gorm:"column:status;default:1;index;comment:状态 1->待投递, 2->成功，3->失败"`                                   // 状态
+	Attempts     int       `json:"attempts" gorm:"column:attempts;not null;default:0;comment:投递次数"`                                              // 投递次数
+	NextRetry    time.Time `json:"nextRetry" gorm:"column:next_retry;comment:下次投递时间"`                                                            // 下次投递时间
+	LastAttempt  time.Time `json:"lastAttempt" gorm:"column:last_attempt;comment:最后投递时间"`                                                        // 最后投递时间
+	ResponseCode int       `json:"responseCode" gorm:"column:response_code;not null;default:0;comment:响应状态码"`                                    // 响应状态码
+	ResponseBody string    `json:"responseBody" gorm:"column:response_body;type:text;comment:响应内容"`                                              // 响应内容
+	Latency      int64     `json:"latency" gorm:"column:latency;not null;default:0;comment:耗时(毫秒)"`                                              // 耗时（毫秒）
+	Error        string    `json:"error" gorm:"column:error;type:text;comment:错误信息"`                                                             // 错误信息
+	TenantID     string    `json:"tenantId" gorm:"column:tenant_id;size:255;default:'';comment:租户ID"`                                            // 租户ID
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "sys_webhook_delivery"
+}
+
+// GetPrimaryKey 获取主键
+func (WebhookDelivery) GetPrimaryKey() string {
+	return "id"
+}
@@ -11,4 +11,12 @@ type IDeadLetterServiceApi interface {
 	GetList(ctx context.Context, req *dto.GetDeadLetterSubscribeListReq) (models.PageRes[dto.DeadLetterSubscribeModel], error)
 	// Retry 重试
 	Retry(ctx context.Context, id string) error
+	// RetryDue 重试已到重试时间的死信，返回处理条数
+	RetryDue(ctx context.Context, limit int) (int, error)
+	// BulkRetry 批量重新排队，由定时任务立即重试
+	BulkRetry(ctx context.Context, req *dto.BulkDeadLetterReq) (*dto.BulkDeadLetterRes, error)
+	// BulkDiscard 批量丢弃
+	BulkDiscard(ctx context.Context, req *dto.BulkDeadLetterReq) (*dto.BulkDeadLetterRes, error)
+	// Export 导出死信为 Excel
+	Export(ctx context.Context, req *dto.ExportDeadLetterReq) ([]byte, error)
 }
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+)
+
+type IEventReplayServiceApi interface {
+	// Enabled 是否开启了事件存储
+	Enabled() bool
+	// Start 开始回放，返回回放任务ID
+	Start(ctx context.Context, req *dto.StartReplayReq) (string, herrors.Herr)
+	// Recover 接管租约过期的回放，返回接管的数量
+	Recover(ctx context.Context) (int, error)
+	// Cancel 取消回放
+	Cancel(ctx context.Context, id string) herrors.Herr
+	// GetDetails 获取回放进度
+	GetDetails(ctx context.Context, id string) (*dto.EventReplayModel, herrors.Herr)
+	// GetList 获取回放列表
+	GetList(ctx context.Context, req *dto.GetReplayListReq) (models.PageRes[dto.EventReplayModel], herrors.Herr)
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/herrors"
+	"github.com/flare-admin/flare-server-go/framework/pkg/hserver/models"
+	"github.com/flare-admin/flare-server-go/framework/support/sysevent/dto"
+)
+
+type IWebhookServiceApi interface {
+	// Add 新增 Webhook，未指定密钥时自动生成
+	Add(ctx context.Context, req *dto.AddWebhookReq) (*dto.WebhookModel, herrors.Herr)
+	// Update 修改 Webhook
+	Update(ctx context.Context, req *dto.UpdateWebhookReq) herrors.Herr
+	// Delete 删除 Webhook
+	Delete(ctx context.Context, id string) herrors.Herr
+	// GetDetails 获取 Webhook 详情
+	GetDetails(ctx context.Context, id string) (*dto.WebhookModel, herrors.Herr)
+	// GetList 获取 Webhook 列表
+	GetList(ctx context.Context, req *dto.GetWebhookListReq) (models.PageRes[dto.WebhookModel], herrors.Herr)
+	// Enable 启用 Webhook 并清零连续失败次数
+	Enable(ctx context.Context, id string) herrors.Herr
+	// Disable 停用 Webhook
+	Disable(ctx context.Context, id string) herrors.Herr
+
+	// GetDeliveryList 获取投递记录
+	GetDeliveryList(ctx context.Context, req *dto.GetWebhookDeliveryListReq) (models.PageRes[dto.WebhookDeliveryModel], herrors.Herr)
+	// Redeliver 立即重新投递，返回投递结果
+	Redeliver(ctx context.Context, id string) (*dto.WebhookDeliveryModel, herrors.Herr)
+	// RetryDue 投递到达重试时间的记录，返回处理条数
+	RetryDue(ctx context.Context, limit int) (int, error)
+}
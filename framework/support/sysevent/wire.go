@@ -13,9 +13,14 @@ var ProviderSet = wire.NewSet(
 	data.NewSubscribeRepo,
 	data.NewSubscribeParameterRepo,
 	data.NewDeadLetterSubscribeRepo,
+	data.NewEventReplayRepo,
+	data.NewWebhookRepo,
+	data.NewWebhookDeliveryRepo,
 	biz.NewEventUseCase,
 	biz.NewSubscribeUseCase,
 	biz.NewDeadLetterSubscribeUseCase,
+	biz.NewEventReplayUseCase,
+	biz.NewWebhookUseCase,
 
 	base.NewSubscribeManagerUseCase,
 